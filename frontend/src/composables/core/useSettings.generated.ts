@@ -27,8 +27,13 @@ export function generateInitialSettings(): SettingsData {
     ai_translation_prompt: settingsDefaults.ai_translation_prompt,
     ai_usage_limit: settingsDefaults.ai_usage_limit,
     ai_usage_tokens: settingsDefaults.ai_usage_tokens,
+    archive_enabled: settingsDefaults.archive_enabled,
     auto_cleanup_enabled: settingsDefaults.auto_cleanup_enabled,
     auto_show_all_content: settingsDefaults.auto_show_all_content,
+    backup_directory: settingsDefaults.backup_directory,
+    backup_enabled: settingsDefaults.backup_enabled,
+    backup_interval_hours: settingsDefaults.backup_interval_hours,
+    backup_keep_count: settingsDefaults.backup_keep_count,
     baidu_app_id: settingsDefaults.baidu_app_id,
     baidu_secret_key: settingsDefaults.baidu_secret_key,
     close_to_tray: settingsDefaults.close_to_tray,
@@ -45,33 +50,48 @@ export function generateInitialSettings(): SettingsData {
     custom_translation_name: settingsDefaults.custom_translation_name,
     custom_translation_response_path: settingsDefaults.custom_translation_response_path,
     custom_translation_timeout: settingsDefaults.custom_translation_timeout,
+    db_maintenance_interval_hours: settingsDefaults.db_maintenance_interval_hours,
+    db_maintenance_operations: settingsDefaults.db_maintenance_operations,
     deepl_api_key: settingsDefaults.deepl_api_key,
     deepl_endpoint: settingsDefaults.deepl_endpoint,
     default_view_mode: settingsDefaults.default_view_mode,
+    email_idle_enabled: settingsDefaults.email_idle_enabled,
+    email_inbound_domain: settingsDefaults.email_inbound_domain,
+    email_inbound_enabled: settingsDefaults.email_inbound_enabled,
+    email_inbound_listen: settingsDefaults.email_inbound_listen,
+    email_inbound_protocol: settingsDefaults.email_inbound_protocol,
+    feed_auto_pause_failures: settingsDefaults.feed_auto_pause_failures,
     feed_drawer_expanded: settingsDefaults.feed_drawer_expanded,
     feed_drawer_pinned: settingsDefaults.feed_drawer_pinned,
     freshrss_api_password: settingsDefaults.freshrss_api_password,
     freshrss_auto_sync_interval: settingsDefaults.freshrss_auto_sync_interval,
     freshrss_enabled: settingsDefaults.freshrss_enabled,
     freshrss_last_sync_time: settingsDefaults.freshrss_last_sync_time,
+    freshrss_push_new_feeds: settingsDefaults.freshrss_push_new_feeds,
     freshrss_server_url: settingsDefaults.freshrss_server_url,
     freshrss_sync_on_startup: settingsDefaults.freshrss_sync_on_startup,
     freshrss_username: settingsDefaults.freshrss_username,
     full_text_fetch_enabled: settingsDefaults.full_text_fetch_enabled,
     google_translate_endpoint: settingsDefaults.google_translate_endpoint,
+    host_request_interval_ms: settingsDefaults.host_request_interval_ms,
     hover_mark_as_read: settingsDefaults.hover_mark_as_read,
     image_gallery_enabled: settingsDefaults.image_gallery_enabled,
     language: settingsDefaults.language,
+    last_backup_time: settingsDefaults.last_backup_time,
+    last_db_maintenance_time: settingsDefaults.last_db_maintenance_time,
     last_global_refresh: settingsDefaults.last_global_refresh,
     last_network_test: settingsDefaults.last_network_test,
     layout_mode: settingsDefaults.layout_mode,
     max_article_age_days: settingsDefaults.max_article_age_days,
     max_cache_size_mb: settingsDefaults.max_cache_size_mb,
     max_concurrent_refreshes: settingsDefaults.max_concurrent_refreshes,
+    max_refreshes_per_host: settingsDefaults.max_refreshes_per_host,
     media_cache_enabled: settingsDefaults.media_cache_enabled,
     media_cache_max_age_days: settingsDefaults.media_cache_max_age_days,
     media_cache_max_size_mb: settingsDefaults.media_cache_max_size_mb,
     media_proxy_fallback: settingsDefaults.media_proxy_fallback,
+    metered_min_favorites: settingsDefaults.metered_min_favorites,
+    metered_mode: settingsDefaults.metered_mode,
     microsoft_api_key: settingsDefaults.microsoft_api_key,
     microsoft_endpoint: settingsDefaults.microsoft_endpoint,
     microsoft_region: settingsDefaults.microsoft_region,
@@ -90,7 +110,9 @@ export function generateInitialSettings(): SettingsData {
     proxy_port: settingsDefaults.proxy_port,
     proxy_type: settingsDefaults.proxy_type,
     proxy_username: settingsDefaults.proxy_username,
+    quiet_hours: settingsDefaults.quiet_hours,
     refresh_mode: settingsDefaults.refresh_mode,
+    restore_required_secrets: settingsDefaults.restore_required_secrets,
     retry_timeout_seconds: settingsDefaults.retry_timeout_seconds,
     rsshub_api_key: settingsDefaults.rsshub_api_key,
     rsshub_enabled: settingsDefaults.rsshub_enabled,
@@ -147,8 +169,14 @@ export function parseSettingsData(data: Record<string, string>): SettingsData {
     ai_translation_prompt: data.ai_translation_prompt || settingsDefaults.ai_translation_prompt,
     ai_usage_limit: data.ai_usage_limit || settingsDefaults.ai_usage_limit,
     ai_usage_tokens: data.ai_usage_tokens || settingsDefaults.ai_usage_tokens,
+    archive_enabled: data.archive_enabled === 'true',
     auto_cleanup_enabled: data.auto_cleanup_enabled === 'true',
     auto_show_all_content: data.auto_show_all_content === 'true',
+    backup_directory: data.backup_directory || settingsDefaults.backup_directory,
+    backup_enabled: data.backup_enabled === 'true',
+    backup_interval_hours:
+      parseInt(data.backup_interval_hours) || settingsDefaults.backup_interval_hours,
+    backup_keep_count: parseInt(data.backup_keep_count) || settingsDefaults.backup_keep_count,
     baidu_app_id: data.baidu_app_id || settingsDefaults.baidu_app_id,
     baidu_secret_key: data.baidu_secret_key || settingsDefaults.baidu_secret_key,
     close_to_tray: data.close_to_tray === 'true',
@@ -173,9 +201,21 @@ export function parseSettingsData(data: Record<string, string>): SettingsData {
       data.custom_translation_response_path || settingsDefaults.custom_translation_response_path,
     custom_translation_timeout:
       parseInt(data.custom_translation_timeout) || settingsDefaults.custom_translation_timeout,
+    db_maintenance_interval_hours:
+      parseInt(data.db_maintenance_interval_hours) ||
+      settingsDefaults.db_maintenance_interval_hours,
+    db_maintenance_operations:
+      data.db_maintenance_operations || settingsDefaults.db_maintenance_operations,
     deepl_api_key: data.deepl_api_key || settingsDefaults.deepl_api_key,
     deepl_endpoint: data.deepl_endpoint || settingsDefaults.deepl_endpoint,
     default_view_mode: data.default_view_mode || settingsDefaults.default_view_mode,
+    email_idle_enabled: data.email_idle_enabled === 'true',
+    email_inbound_domain: data.email_inbound_domain || settingsDefaults.email_inbound_domain,
+    email_inbound_enabled: data.email_inbound_enabled === 'true',
+    email_inbound_listen: data.email_inbound_listen || settingsDefaults.email_inbound_listen,
+    email_inbound_protocol: data.email_inbound_protocol || settingsDefaults.email_inbound_protocol,
+    feed_auto_pause_failures:
+      parseInt(data.feed_auto_pause_failures) || settingsDefaults.feed_auto_pause_failures,
     feed_drawer_expanded: data.feed_drawer_expanded === 'true',
     feed_drawer_pinned: data.feed_drawer_pinned === 'true',
     freshrss_api_password: data.freshrss_api_password || settingsDefaults.freshrss_api_password,
@@ -184,15 +224,21 @@ export function parseSettingsData(data: Record<string, string>): SettingsData {
     freshrss_enabled: data.freshrss_enabled === 'true',
     freshrss_last_sync_time:
       data.freshrss_last_sync_time || settingsDefaults.freshrss_last_sync_time,
+    freshrss_push_new_feeds: data.freshrss_push_new_feeds === 'true',
     freshrss_server_url: data.freshrss_server_url || settingsDefaults.freshrss_server_url,
     freshrss_sync_on_startup: data.freshrss_sync_on_startup === 'true',
     freshrss_username: data.freshrss_username || settingsDefaults.freshrss_username,
     full_text_fetch_enabled: data.full_text_fetch_enabled === 'true',
     google_translate_endpoint:
       data.google_translate_endpoint || settingsDefaults.google_translate_endpoint,
+    host_request_interval_ms:
+      parseInt(data.host_request_interval_ms) || settingsDefaults.host_request_interval_ms,
     hover_mark_as_read: data.hover_mark_as_read === 'true',
     image_gallery_enabled: data.image_gallery_enabled === 'true',
     language: data.language || settingsDefaults.language,
+    last_backup_time: data.last_backup_time || settingsDefaults.last_backup_time,
+    last_db_maintenance_time:
+      data.last_db_maintenance_time || settingsDefaults.last_db_maintenance_time,
     last_global_refresh: data.last_global_refresh || settingsDefaults.last_global_refresh,
     last_network_test: data.last_network_test || settingsDefaults.last_network_test,
     layout_mode: data.layout_mode || settingsDefaults.layout_mode,
@@ -201,12 +247,17 @@ export function parseSettingsData(data: Record<string, string>): SettingsData {
     max_cache_size_mb: parseInt(data.max_cache_size_mb) || settingsDefaults.max_cache_size_mb,
     max_concurrent_refreshes:
       data.max_concurrent_refreshes || settingsDefaults.max_concurrent_refreshes,
+    max_refreshes_per_host:
+      parseInt(data.max_refreshes_per_host) || settingsDefaults.max_refreshes_per_host,
     media_cache_enabled: data.media_cache_enabled === 'true',
     media_cache_max_age_days:
       parseInt(data.media_cache_max_age_days) || settingsDefaults.media_cache_max_age_days,
     media_cache_max_size_mb:
       parseInt(data.media_cache_max_size_mb) || settingsDefaults.media_cache_max_size_mb,
     media_proxy_fallback: data.media_proxy_fallback === 'true',
+    metered_min_favorites:
+      parseInt(data.metered_min_favorites) || settingsDefaults.metered_min_favorites,
+    metered_mode: data.metered_mode === 'true',
     microsoft_api_key: data.microsoft_api_key || settingsDefaults.microsoft_api_key,
     microsoft_endpoint: data.microsoft_endpoint || settingsDefaults.microsoft_endpoint,
     microsoft_region: data.microsoft_region || settingsDefaults.microsoft_region,
@@ -225,7 +276,10 @@ export function parseSettingsData(data: Record<string, string>): SettingsData {
     proxy_port: data.proxy_port || settingsDefaults.proxy_port,
     proxy_type: data.proxy_type || settingsDefaults.proxy_type,
     proxy_username: data.proxy_username || settingsDefaults.proxy_username,
+    quiet_hours: data.quiet_hours || settingsDefaults.quiet_hours,
     refresh_mode: data.refresh_mode || settingsDefaults.refresh_mode,
+    restore_required_secrets:
+      data.restore_required_secrets || settingsDefaults.restore_required_secrets,
     retry_timeout_seconds:
       parseInt(data.retry_timeout_seconds) || settingsDefaults.retry_timeout_seconds,
     rsshub_api_key: data.rsshub_api_key || settingsDefaults.rsshub_api_key,
@@ -290,12 +344,25 @@ export function buildAutoSavePayload(settingsRef: Ref<SettingsData>): Record<str
       settingsRef.value.ai_translation_prompt ?? settingsDefaults.ai_translation_prompt,
     ai_usage_limit: settingsRef.value.ai_usage_limit ?? settingsDefaults.ai_usage_limit,
     ai_usage_tokens: settingsRef.value.ai_usage_tokens ?? settingsDefaults.ai_usage_tokens,
+    archive_enabled: (
+      settingsRef.value.archive_enabled ?? settingsDefaults.archive_enabled
+    ).toString(),
     auto_cleanup_enabled: (
       settingsRef.value.auto_cleanup_enabled ?? settingsDefaults.auto_cleanup_enabled
     ).toString(),
     auto_show_all_content: (
       settingsRef.value.auto_show_all_content ?? settingsDefaults.auto_show_all_content
     ).toString(),
+    backup_directory: settingsRef.value.backup_directory ?? settingsDefaults.backup_directory,
+    backup_enabled: (
+      settingsRef.value.backup_enabled ?? settingsDefaults.backup_enabled
+    ).toString(),
+    backup_interval_hours: (
+      settingsRef.value.backup_interval_hours ?? settingsDefaults.backup_interval_hours
+    ).toString(),
+    backup_keep_count: (
+      settingsRef.value.backup_keep_count ?? settingsDefaults.backup_keep_count
+    ).toString(),
     baidu_app_id: settingsRef.value.baidu_app_id ?? settingsDefaults.baidu_app_id,
     baidu_secret_key: settingsRef.value.baidu_secret_key ?? settingsDefaults.baidu_secret_key,
     close_to_tray: (settingsRef.value.close_to_tray ?? settingsDefaults.close_to_tray).toString(),
@@ -330,9 +397,30 @@ export function buildAutoSavePayload(settingsRef: Ref<SettingsData>): Record<str
     custom_translation_timeout: (
       settingsRef.value.custom_translation_timeout ?? settingsDefaults.custom_translation_timeout
     ).toString(),
+    db_maintenance_interval_hours: (
+      settingsRef.value.db_maintenance_interval_hours ??
+      settingsDefaults.db_maintenance_interval_hours
+    ).toString(),
+    db_maintenance_operations:
+      settingsRef.value.db_maintenance_operations ?? settingsDefaults.db_maintenance_operations,
     deepl_api_key: settingsRef.value.deepl_api_key ?? settingsDefaults.deepl_api_key,
     deepl_endpoint: settingsRef.value.deepl_endpoint ?? settingsDefaults.deepl_endpoint,
     default_view_mode: settingsRef.value.default_view_mode ?? settingsDefaults.default_view_mode,
+    email_idle_enabled: (
+      settingsRef.value.email_idle_enabled ?? settingsDefaults.email_idle_enabled
+    ).toString(),
+    email_inbound_domain:
+      settingsRef.value.email_inbound_domain ?? settingsDefaults.email_inbound_domain,
+    email_inbound_enabled: (
+      settingsRef.value.email_inbound_enabled ?? settingsDefaults.email_inbound_enabled
+    ).toString(),
+    email_inbound_listen:
+      settingsRef.value.email_inbound_listen ?? settingsDefaults.email_inbound_listen,
+    email_inbound_protocol:
+      settingsRef.value.email_inbound_protocol ?? settingsDefaults.email_inbound_protocol,
+    feed_auto_pause_failures: (
+      settingsRef.value.feed_auto_pause_failures ?? settingsDefaults.feed_auto_pause_failures
+    ).toString(),
     freshrss_api_password:
       settingsRef.value.freshrss_api_password ?? settingsDefaults.freshrss_api_password,
     freshrss_auto_sync_interval: (
@@ -343,6 +431,9 @@ export function buildAutoSavePayload(settingsRef: Ref<SettingsData>): Record<str
     ).toString(),
     freshrss_last_sync_time:
       settingsRef.value.freshrss_last_sync_time ?? settingsDefaults.freshrss_last_sync_time,
+    freshrss_push_new_feeds: (
+      settingsRef.value.freshrss_push_new_feeds ?? settingsDefaults.freshrss_push_new_feeds
+    ).toString(),
     freshrss_server_url:
       settingsRef.value.freshrss_server_url ?? settingsDefaults.freshrss_server_url,
     freshrss_sync_on_startup: (
@@ -354,6 +445,9 @@ export function buildAutoSavePayload(settingsRef: Ref<SettingsData>): Record<str
     ).toString(),
     google_translate_endpoint:
       settingsRef.value.google_translate_endpoint ?? settingsDefaults.google_translate_endpoint,
+    host_request_interval_ms: (
+      settingsRef.value.host_request_interval_ms ?? settingsDefaults.host_request_interval_ms
+    ).toString(),
     hover_mark_as_read: (
       settingsRef.value.hover_mark_as_read ?? settingsDefaults.hover_mark_as_read
     ).toString(),
@@ -371,6 +465,9 @@ export function buildAutoSavePayload(settingsRef: Ref<SettingsData>): Record<str
     ).toString(),
     max_concurrent_refreshes:
       settingsRef.value.max_concurrent_refreshes ?? settingsDefaults.max_concurrent_refreshes,
+    max_refreshes_per_host: (
+      settingsRef.value.max_refreshes_per_host ?? settingsDefaults.max_refreshes_per_host
+    ).toString(),
     media_cache_enabled: (
       settingsRef.value.media_cache_enabled ?? settingsDefaults.media_cache_enabled
     ).toString(),
@@ -383,6 +480,10 @@ export function buildAutoSavePayload(settingsRef: Ref<SettingsData>): Record<str
     media_proxy_fallback: (
       settingsRef.value.media_proxy_fallback ?? settingsDefaults.media_proxy_fallback
     ).toString(),
+    metered_min_favorites: (
+      settingsRef.value.metered_min_favorites ?? settingsDefaults.metered_min_favorites
+    ).toString(),
+    metered_mode: (settingsRef.value.metered_mode ?? settingsDefaults.metered_mode).toString(),
     microsoft_api_key: settingsRef.value.microsoft_api_key ?? settingsDefaults.microsoft_api_key,
     microsoft_endpoint: settingsRef.value.microsoft_endpoint ?? settingsDefaults.microsoft_endpoint,
     microsoft_region: settingsRef.value.microsoft_region ?? settingsDefaults.microsoft_region,
@@ -407,6 +508,7 @@ export function buildAutoSavePayload(settingsRef: Ref<SettingsData>): Record<str
     proxy_port: settingsRef.value.proxy_port ?? settingsDefaults.proxy_port,
     proxy_type: settingsRef.value.proxy_type ?? settingsDefaults.proxy_type,
     proxy_username: settingsRef.value.proxy_username ?? settingsDefaults.proxy_username,
+    quiet_hours: settingsRef.value.quiet_hours ?? settingsDefaults.quiet_hours,
     refresh_mode: settingsRef.value.refresh_mode ?? settingsDefaults.refresh_mode,
     retry_timeout_seconds: (
       settingsRef.value.retry_timeout_seconds ?? settingsDefaults.retry_timeout_seconds
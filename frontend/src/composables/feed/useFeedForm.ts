@@ -70,7 +70,18 @@ export function useFeedForm(feed?: Feed) {
   const showAdvancedSettings = ref(false);
 
   // Available scripts from the scripts directory
-  const availableScripts = ref<Array<{ name: string; path: string; type: string }>>([]);
+  const availableScripts = ref<
+    Array<{
+      name: string;
+      path: string;
+      type: string;
+      last_status?: string;
+      last_attempt_at?: string;
+      last_duration_ms?: number;
+      last_error?: string;
+      stderr_tail?: string;
+    }>
+  >([]);
   const scriptsDir = ref('');
 
   // Get unique categories from existing feeds, excluding FreshRSS-only categories
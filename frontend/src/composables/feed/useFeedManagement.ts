@@ -250,6 +250,7 @@ export function useFeedManagement() {
           xpath_item_uid: feed.xpath_item_uid,
           article_view_mode: feed.article_view_mode,
           auto_expand_content: feed.auto_expand_content,
+          full_text_on_ingest: feed.full_text_on_ingest,
         }),
       });
     });
@@ -304,6 +305,7 @@ export function useFeedManagement() {
           xpath_item_uid: feed.xpath_item_uid,
           article_view_mode: feed.article_view_mode,
           auto_expand_content: feed.auto_expand_content,
+          full_text_on_ingest: feed.full_text_on_ingest,
           tags: mergedTagIds,
         }),
       });
@@ -376,6 +378,7 @@ export function useFeedManagement() {
           xpath_item_uid: feed.xpath_item_uid,
           article_view_mode: feed.article_view_mode,
           auto_expand_content: feed.auto_expand_content,
+          full_text_on_ingest: feed.full_text_on_ingest,
         }),
       });
     });
@@ -433,6 +436,7 @@ export function useFeedManagement() {
           xpath_item_uid: feed.xpath_item_uid,
           article_view_mode: feed.article_view_mode,
           auto_expand_content: feed.auto_expand_content,
+          full_text_on_ingest: feed.full_text_on_ingest,
         }),
       });
     });
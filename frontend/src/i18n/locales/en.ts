@@ -274,6 +274,7 @@ const en: TranslationMessages = {
     time: {
       days: 'days',
       daysAgo: '{count} days ago',
+      hours: 'hours',
       hoursAgo: '{count} hours ago',
       justNow: 'Just now',
       minutes: 'minutes',
@@ -783,6 +784,9 @@ const en: TranslationMessages = {
       selectScriptPlaceholder: 'Select a script...',
     },
     database: {
+      archiveArticles: 'Archive Instead of Deleting',
+      archiveArticlesDesc:
+        'Move cleaned up articles to a compressed archive per year, which stays searchable and restorable',
       articleContentCacheCleanup: 'Article Content Cache',
       articleContentCacheCleanupDesc: 'Clear all cached article content',
       autoCleanup: 'Auto Cleanup',
@@ -799,6 +803,9 @@ const en: TranslationMessages = {
       currentCachedArticles: 'Current cached articles',
       dataManagement: 'Data Management',
       days: 'days',
+      maintenanceInterval: 'Database Maintenance',
+      maintenanceIntervalDesc:
+        'Every this many hours, check the database, remove orphaned data and optimize it (0 to disable)',
       maxArticleAge: 'Max Article Age',
       maxArticleAgeDesc: 'Delete articles older than this many days (except favorites)',
       maxCacheSize: 'Max Cache Size',
@@ -812,6 +819,7 @@ const en: TranslationMessages = {
       mediaCacheMaxAgeDesc: 'Delete cached media older than this many days',
       mediaCacheMaxSize: 'Max Cache Size',
       mediaCacheMaxSizeDesc: 'Maximum media cache size',
+      uncompressedSize: 'uncompressed',
       clearArticleContentCacheConfirm:
         'Are you sure you want to clear all article content cache? This action cannot be undone.',
       clearMediaCacheConfirm:
@@ -824,14 +832,30 @@ const en: TranslationMessages = {
       autoExpandContent: 'Auto Expand Content',
       autoExpandContentDesc:
         'Override global full-text fetch and auto-expand settings for this feed',
+      autoPauseFailures: 'Pause Broken Feeds',
+      autoPauseFailuresDesc:
+        'Stop refreshing a feed after this many failures in a row. Failing feeds are retried less often until then',
       enableFullTextFetch: 'Enable Full-Text Fetching',
       enableFullTextFetchDesc:
         'Allow fetching full article content from original websites when RSS provides only summaries',
       fixedInterval: 'Fixed Interval',
+      hostRequestInterval: 'Per-Host Request Spacing',
+      hostRequestIntervalDesc: 'Minimum time between refresh requests to the same site',
       imageMode: 'Multimedia Mode',
       imageModeDesc: 'Display this feed in multimedia gallery view instead of article list',
       intelligentInterval: 'Intelligent Interval',
+      maxRefreshesPerHost: 'Per-Host Concurrency',
+      maxRefreshesPerHostDesc:
+        'Maximum feeds of the same site refreshed at once. Sites answering 429 or 503 are paused as long as they ask',
+      meteredMinFavorites: 'Minimum Favorites',
+      meteredMinFavoritesDesc: 'Feeds with fewer favorite articles are not refreshed automatically',
+      meteredMode: 'Metered Connection',
+      meteredModeDesc:
+        'Save data by refreshing automatically only the feeds you often add to favorites',
       neverRefresh: 'Never Refresh',
+      quietHours: 'Quiet Hours',
+      quietHoursDesc:
+        'No automatic refreshes in this time window, e.g. 23:00-07:00 or weekends. Refreshes due then wait until it ends',
       refreshMode: 'Refresh Mode',
       refreshModeDesc: 'Choose how often to refresh all subscriptions',
       retryTimeout: 'Timeout',
@@ -268,6 +268,7 @@ const zh: TranslationMessages = {
     time: {
       days: '天',
       daysAgo: '{count} 天前',
+      hours: '小时',
       hoursAgo: '{count} 小时前',
       justNow: '刚刚',
       minutes: '分钟',
@@ -770,6 +771,8 @@ const zh: TranslationMessages = {
       fontSystemDefault: '系统默认',
     },
     database: {
+      archiveArticles: '归档而非删除',
+      archiveArticlesDesc: '将清理的文章按年份移入压缩归档，归档仍可搜索和恢复',
       articleContentCacheCleanup: '文章内容缓存',
       articleContentCacheCleanupDesc: '清除所有缓存的文章内容',
       autoCleanup: '自动清理',
@@ -785,6 +788,9 @@ const zh: TranslationMessages = {
       currentCachedArticles: '当前缓存文章数',
       dataManagement: '数据管理',
       days: '天',
+      maintenanceInterval: '数据库维护',
+      maintenanceIntervalDesc:
+        '每隔此小时数检查数据库、清除孤立数据并优化（0 表示禁用）',
       maxArticleAge: '文章最大保留天数',
       maxArticleAgeDesc: '删除超过此天数的文章（收藏除外）',
       maxCacheSize: '最大缓存大小',
@@ -798,6 +804,7 @@ const zh: TranslationMessages = {
       mediaCacheMaxAgeDesc: '删除超过此天数的缓存媒体',
       mediaCacheMaxSize: '最大缓存大小',
       mediaCacheMaxSizeDesc: '媒体缓存最大大小',
+      uncompressedSize: '未压缩',
       clearArticleContentCacheConfirm: '确定要清空所有文章内容缓存吗？此操作不可撤销。',
       clearMediaCacheConfirm: '确定要清空所有媒体缓存吗？此操作不可撤销。',
     },
@@ -807,13 +814,25 @@ const zh: TranslationMessages = {
       articleViewModeDesc: '选择此订阅源的文章应如何显示',
       autoExpandContent: '自动展开内容',
       autoExpandContentDesc: '覆盖此订阅源的全局全文提取和自动展开设置',
+      autoPauseFailures: '暂停失效订阅源',
+      autoPauseFailuresDesc: '连续失败达到此次数后停止刷新该订阅源。在此之前，失败的订阅源会逐渐降低重试频率',
       enableFullTextFetch: '启用全文提取',
       enableFullTextFetchDesc: '当 RSS 仅提供摘要时，允许从原始网站提取完整文章内容',
       fixedInterval: '固定间隔',
+      hostRequestInterval: '单站点请求间隔',
+      hostRequestIntervalDesc: '向同一站点发送刷新请求的最短间隔',
       imageMode: '多媒体模式',
       imageModeDesc: '以多媒体库视图而非文章列表展示此订阅源',
       intelligentInterval: '智能间隔',
+      maxRefreshesPerHost: '单站点并发数',
+      maxRefreshesPerHostDesc: '同一站点同时刷新的订阅源上限。返回 429 或 503 的站点将按其要求暂停刷新',
+      meteredMinFavorites: '最少收藏数',
+      meteredMinFavoritesDesc: '收藏文章少于此数量的订阅源不会自动刷新',
+      meteredMode: '按流量计费连接',
+      meteredModeDesc: '仅自动刷新常被收藏的订阅源以节省流量',
       neverRefresh: '不刷新',
+      quietHours: '静默时段',
+      quietHoursDesc: '此时间段内不自动刷新，例如 23:00-07:00 或 weekends。期间到期的刷新将在结束后进行',
       refreshMode: '刷新模式',
       refreshModeDesc: '选择以何种频率刷新所有订阅源',
       retryTimeout: '超时时间',
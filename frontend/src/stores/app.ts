@@ -725,6 +725,7 @@ export const useAppStore = defineStore('app', () => {
           ...refreshProgress.value,
           pool_tasks: data.pool_tasks,
           queue_tasks: data.queue_tasks,
+          hosts: data.hosts,
         };
       }
     } catch (e) {
@@ -58,6 +58,26 @@ export interface Feed {
   xpath_item_thumbnail?: string;
   xpath_item_categories?: string;
   xpath_item_uid?: string;
+  xpath_next_page?: string; // XPath to the next listing page link
+  xpath_max_pages?: number; // Listing pages to follow (0 = default)
+  xpath_detail_content?: string; // XPath for full content on the item page
+  xpath_detail_author?: string;
+  xpath_detail_timestamp?: string;
+  // JSON API support (type 'JSON+JSONPath'), item paths are relative to each item
+  jsonpath_item?: string;
+  jsonpath_item_title?: string;
+  jsonpath_item_uri?: string;
+  jsonpath_item_content?: string;
+  jsonpath_item_author?: string;
+  jsonpath_item_timestamp?: string;
+  jsonpath_item_time_format?: string;
+  jsonpath_item_thumbnail?: string;
+  jsonpath_item_uid?: string;
+  json_headers?: string; // One "Name: value" per line
+  json_page_param?: string; // Query parameter carrying the page number or cursor
+  json_cursor_path?: string; // JSONPath to the next cursor (empty = page numbers)
+  json_max_pages?: number; // Pages to request (0 = default)
+  full_text_on_ingest?: boolean; // Fetch the full text of new articles during refresh
   article_view_mode?: string; // Article view mode override ('global', 'webpage', 'rendered', 'external')
   auto_expand_content?: string; // Auto expand content mode ('global', 'enabled', 'disabled')
   // Email/Newsletter support
@@ -91,6 +111,14 @@ export interface RefreshProgress {
   queue_task_count?: number; // Tasks in queue
   pool_tasks?: PoolTaskInfo[]; // Detailed pool task information
   queue_tasks?: QueueTaskInfo[]; // Detailed queue task information (max 3)
+  hosts?: HostRefreshInfo[]; // Per-host refresh limits state
+  sync?: SyncProgress; // FreshRSS sync progress, absent when no sync is running
+}
+
+export interface SyncProgress {
+  stage: string; // "subscriptions", "articles", "starred", "read" or "push"
+  current: number;
+  total: number;
 }
 
 export interface PoolTaskInfo {
@@ -106,6 +134,15 @@ export interface QueueTaskInfo {
   position: number;
 }
 
+export interface HostRefreshInfo {
+  host: string;
+  active: number;
+  queued: number;
+  backoff_until?: string; // Set while the host is in backoff after a 429/503 response
+  throttles: number;
+  last_status?: number;
+}
+
 export interface UpdateInfo {
   has_update: boolean;
   latest_version: string;
@@ -19,8 +19,13 @@ export interface SettingsData {
   ai_translation_prompt: string;
   ai_usage_limit: string;
   ai_usage_tokens: string;
+  archive_enabled: boolean;
   auto_cleanup_enabled: boolean;
   auto_show_all_content: boolean;
+  backup_directory: string;
+  backup_enabled: boolean;
+  backup_interval_hours: number;
+  backup_keep_count: number;
   baidu_app_id: string;
   baidu_secret_key: string;
   close_to_tray: boolean;
@@ -37,33 +42,48 @@ export interface SettingsData {
   custom_translation_name: string;
   custom_translation_response_path: string;
   custom_translation_timeout: number;
+  db_maintenance_interval_hours: number;
+  db_maintenance_operations: string;
   deepl_api_key: string;
   deepl_endpoint: string;
   default_view_mode: string;
+  email_idle_enabled: boolean;
+  email_inbound_domain: string;
+  email_inbound_enabled: boolean;
+  email_inbound_listen: string;
+  email_inbound_protocol: string;
+  feed_auto_pause_failures: number;
   feed_drawer_expanded: boolean;
   feed_drawer_pinned: boolean;
   freshrss_api_password: string;
   freshrss_auto_sync_interval: number;
   freshrss_enabled: boolean;
   freshrss_last_sync_time: string;
+  freshrss_push_new_feeds: boolean;
   freshrss_server_url: string;
   freshrss_sync_on_startup: boolean;
   freshrss_username: string;
   full_text_fetch_enabled: boolean;
   google_translate_endpoint: string;
+  host_request_interval_ms: number;
   hover_mark_as_read: boolean;
   image_gallery_enabled: boolean;
   language: string;
+  last_backup_time: string;
+  last_db_maintenance_time: string;
   last_global_refresh: string;
   last_network_test: string;
   layout_mode: string;
   max_article_age_days: number;
   max_cache_size_mb: number;
   max_concurrent_refreshes: string;
+  max_refreshes_per_host: number;
   media_cache_enabled: boolean;
   media_cache_max_age_days: number;
   media_cache_max_size_mb: number;
   media_proxy_fallback: boolean;
+  metered_min_favorites: number;
+  metered_mode: boolean;
   microsoft_api_key: string;
   microsoft_endpoint: string;
   microsoft_region: string;
@@ -82,7 +102,9 @@ export interface SettingsData {
   proxy_port: string;
   proxy_type: string;
   proxy_username: string;
+  quiet_hours: string;
   refresh_mode: string;
+  restore_required_secrets: string;
   retry_timeout_seconds: number;
   rsshub_api_key: string;
   rsshub_enabled: boolean;
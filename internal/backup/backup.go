@@ -0,0 +1,195 @@
+// Package backup creates and restores archives of the database, custom CSS and scripts.
+package backup
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"MrRSS/internal/crypto"
+	"MrRSS/internal/database"
+	"MrRSS/internal/utils/fileutil"
+	"MrRSS/internal/version"
+)
+
+// FormatVersion is the version of the archive layout written by this build
+const FormatVersion = 1
+
+// Archive entry names
+const (
+	manifestEntry  = "manifest.json"
+	databaseEntry  = "rss.db"
+	settingsEntry  = "settings.json"
+	customCSSEntry = "custom_article.css"
+	scriptsPrefix  = "scripts/"
+)
+
+// customCSSFileName is the custom article CSS file in the data directory
+const customCSSFileName = "custom_article.css"
+
+// Manifest describes the contents of a backup archive
+type Manifest struct {
+	Format        int       `json:"format"`
+	AppVersion    string    `json:"app_version"`
+	SchemaVersion int       `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	HasCustomCSS  bool      `json:"has_custom_css"`
+	ScriptCount   int       `json:"script_count"`
+	// Secrets lists encrypted settings. Their values are bound to the machine that
+	// created the backup and must be entered again when restored elsewhere.
+	Secrets []string `json:"secrets,omitempty"`
+}
+
+// Write writes a backup archive of the database, settings, custom CSS and scripts to w
+func Write(ctx context.Context, db *database.DB, w io.Writer) (*Manifest, error) {
+	tmpDir, err := os.MkdirTemp("", "mrrss-backup-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// VACUUM INTO produces a consistent, compacted snapshot while the database stays in use
+	snapshotPath := filepath.Join(tmpDir, databaseEntry)
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", snapshotPath); err != nil {
+		return nil, fmt.Errorf("snapshot database: %w", err)
+	}
+
+	manifest := &Manifest{
+		Format:        FormatVersion,
+		AppVersion:    version.Version,
+		SchemaVersion: database.SchemaVersion,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	settings, secrets, err := exportSettings(db)
+	if err != nil {
+		return nil, err
+	}
+	manifest.Secrets = secrets
+
+	zw := zip.NewWriter(w)
+
+	if err := addFile(zw, databaseEntry, snapshotPath); err != nil {
+		return nil, err
+	}
+	if err := addJSON(zw, settingsEntry, settings); err != nil {
+		return nil, err
+	}
+
+	dataDir, err := fileutil.GetDataDir()
+	if err != nil {
+		return nil, fmt.Errorf("get data dir: %w", err)
+	}
+
+	cssPath := filepath.Join(dataDir, customCSSFileName)
+	if _, err := os.Stat(cssPath); err == nil {
+		if err := addFile(zw, customCSSEntry, cssPath); err != nil {
+			return nil, err
+		}
+		manifest.HasCustomCSS = true
+	}
+
+	scriptsDir, err := fileutil.GetScriptsDir()
+	if err != nil {
+		return nil, fmt.Errorf("get scripts dir: %w", err)
+	}
+	err = filepath.WalkDir(scriptsDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(scriptsDir, path)
+		if err != nil {
+			return err
+		}
+		manifest.ScriptCount++
+		return addFile(zw, scriptsPrefix+filepath.ToSlash(rel), path)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("archive scripts: %w", err)
+	}
+
+	// The manifest goes last so it can count what was archived
+	if err := addJSON(zw, manifestEntry, manifest); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("finish archive: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// exportSettings returns all settings with encrypted values blanked, plus the names of those secrets
+func exportSettings(db *database.DB) (map[string]string, []string, error) {
+	rows, err := db.Query("SELECT key, value FROM settings ORDER BY key")
+	if err != nil {
+		return nil, nil, fmt.Errorf("export settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	var secrets []string
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, nil, fmt.Errorf("scan setting: %w", err)
+		}
+		if crypto.IsEncrypted(value) {
+			secrets = append(secrets, key)
+			value = ""
+		}
+		settings[key] = value
+	}
+
+	return settings, secrets, rows.Err()
+}
+
+// addFile copies a file into the archive
+func addFile(zw *zip.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	entry, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("add %s: %w", name, err)
+	}
+	if _, err := io.Copy(entry, f); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// addJSON writes a value as an indented JSON entry
+func addJSON(zw *zip.Writer, name string, v interface{}) error {
+	entry, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("add %s: %w", name, err)
+	}
+	enc := json.NewEncoder(entry)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// FileName returns the file name of a backup created at the given time.
+// Milliseconds keep backups written within the same second apart.
+func FileName(t time.Time) string {
+	return "mrrss-backup-" + t.Format("20060102-150405.000") + ".zip"
+}
+
+// isBackupFile reports whether a file name was produced by FileName
+func isBackupFile(name string) bool {
+	return strings.HasPrefix(name, "mrrss-backup-") && strings.HasSuffix(name, ".zip")
+}
@@ -0,0 +1,94 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		name := FileName(base.Add(time.Duration(i) * time.Hour))
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Unrelated files are never rotated away
+	if err := os.WriteFile(filepath.Join(dir, "notes.zip"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Rotate(dir, 2); err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+
+	backups, err := List(dir)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(backups) != 2 || backups[0].Name != FileName(base.Add(4*time.Hour)) {
+		t.Fatalf("List() after Rotate = %+v, want the 2 newest", backups)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.zip")); err != nil {
+		t.Fatalf("unrelated file removed: %v", err)
+	}
+}
+
+func TestApplyPendingRestore(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "rss.db")
+
+	applied, err := ApplyPendingRestore(dbPath)
+	if err != nil || applied {
+		t.Fatalf("ApplyPendingRestore() without staged db = %v, %v; want false", applied, err)
+	}
+
+	if err := os.WriteFile(dbPath, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dbPath+"-wal", []byte("old-wal"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dbPath+pendingRestoreSuffix, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err = ApplyPendingRestore(dbPath)
+	if err != nil || !applied {
+		t.Fatalf("ApplyPendingRestore() = %v, %v; want true", applied, err)
+	}
+
+	if data, _ := os.ReadFile(dbPath); string(data) != "new" {
+		t.Fatalf("database = %q, want restored content", data)
+	}
+	if data, _ := os.ReadFile(dbPath + preRestoreSuffix); string(data) != "old" {
+		t.Fatalf("previous database = %q, want old content", data)
+	}
+	// A stale WAL must not be replayed into the restored database
+	if _, err := os.Stat(dbPath + "-wal"); !os.IsNotExist(err) {
+		t.Fatalf("old WAL still next to restored database")
+	}
+
+	// A failed swap puts the current database back
+	if err := os.WriteFile(dbPath+"-wal", []byte("new-wal"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dbPath+pendingRestoreSuffix, []byte("newer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(dbPath + preRestoreSuffix + "-wal")
+	if err := os.MkdirAll(filepath.Join(dbPath+preRestoreSuffix+"-wal", "busy"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if applied, err := ApplyPendingRestore(dbPath); err == nil || applied {
+		t.Fatalf("ApplyPendingRestore() with a blocked WAL = %v, %v; want error", applied, err)
+	}
+	if data, _ := os.ReadFile(dbPath); string(data) != "new" {
+		t.Fatalf("database after failed swap = %q, want the current one", data)
+	}
+	if data, _ := os.ReadFile(dbPath + "-wal"); string(data) != "new-wal" {
+		t.Fatalf("WAL after failed swap = %q, want the current one", data)
+	}
+}
@@ -0,0 +1,323 @@
+package backup
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"MrRSS/internal/crypto"
+	"MrRSS/internal/database"
+	"MrRSS/internal/utils/fileutil"
+)
+
+// pendingRestoreSuffix marks a validated database that replaces the current one on the next start
+const pendingRestoreSuffix = ".restore"
+
+// preRestoreSuffix marks the database that was replaced by a restore
+const preRestoreSuffix = ".pre-restore"
+
+// requiredTables must exist in a database before it is accepted for restore
+var requiredTables = []string{"feeds", "articles", "settings"}
+
+// RestoreResult describes a staged restore
+type RestoreResult struct {
+	Manifest        Manifest `json:"manifest"`
+	SchemaVersion   int      `json:"schema_version"`
+	RestartRequired bool     `json:"restart_required"`
+	// RequiredSecrets lists secrets that could not be decrypted on this machine and must be entered again
+	RequiredSecrets []string `json:"required_secrets"`
+	// SkippedScripts lists the scripts in the backup that were not restored
+	SkippedScripts []string `json:"skipped_scripts"`
+}
+
+// RestoreOptions selects what Restore writes besides the database
+type RestoreOptions struct {
+	// Scripts restores the custom scripts of the backup. Scripts run on this machine,
+	// so only admins should opt in, and only for backups they trust.
+	Scripts bool
+}
+
+// Restore validates a backup archive and stages its database for the next start.
+// Custom CSS, and scripts when opted in, are restored immediately. The database is swapped by
+// ApplyPendingRestore before it is opened, so the running instance is never replaced underneath.
+func Restore(r io.ReaderAt, size int64, dbPath string, opts RestoreOptions) (*RestoreResult, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+
+	manifest, err := readManifest(zr)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Format > FormatVersion {
+		return nil, fmt.Errorf("backup format %d is newer than supported format %d", manifest.Format, FormatVersion)
+	}
+
+	dbFile := findEntry(zr, databaseEntry)
+	if dbFile == nil {
+		return nil, fmt.Errorf("backup contains no database")
+	}
+
+	stagingPath := dbPath + pendingRestoreSuffix + ".tmp"
+	_ = os.Remove(stagingPath)
+	if err := extractEntry(dbFile, stagingPath); err != nil {
+		return nil, err
+	}
+
+	result := &RestoreResult{Manifest: *manifest, RestartRequired: true, SkippedScripts: []string{}}
+	if err := prepareDatabase(stagingPath, result); err != nil {
+		os.Remove(stagingPath)
+		return nil, err
+	}
+
+	if err := os.Rename(stagingPath, dbPath+pendingRestoreSuffix); err != nil {
+		os.Remove(stagingPath)
+		return nil, fmt.Errorf("stage database: %w", err)
+	}
+
+	if err := restoreFiles(zr, opts, result); err != nil {
+		return result, err
+	}
+
+	log.Printf("[Backup] Restore staged from backup of %s (schema %d), %d secrets must be entered again",
+		manifest.CreatedAt.Format("2006-01-02 15:04"), result.SchemaVersion, len(result.RequiredSecrets))
+	return result, nil
+}
+
+// prepareDatabase validates the staged database and clears secrets that this machine cannot decrypt
+func prepareDatabase(path string, result *RestoreResult) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("open backup database: %w", err)
+	}
+	defer db.Close()
+
+	var check string
+	if err := db.QueryRow("PRAGMA quick_check").Scan(&check); err != nil {
+		return fmt.Errorf("check backup database: %w", err)
+	}
+	if check != "ok" {
+		return fmt.Errorf("backup database is corrupt: %s", check)
+	}
+
+	for _, table := range requiredTables {
+		var name string
+		err := db.QueryRow("SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&name)
+		if err != nil {
+			return fmt.Errorf("backup database has no %s table", table)
+		}
+	}
+
+	version, err := database.ReadSchemaVersion(db)
+	if err != nil {
+		return err
+	}
+	if err := database.ValidateSchemaVersion(version); err != nil {
+		return err
+	}
+	result.SchemaVersion = version
+
+	required, err := clearForeignSecrets(db)
+	if err != nil {
+		return err
+	}
+	result.RequiredSecrets = required
+
+	_, err = db.Exec("INSERT OR REPLACE INTO settings (key, value) VALUES ('restore_required_secrets', ?)",
+		strings.Join(required, ","))
+	if err != nil {
+		return fmt.Errorf("record required secrets: %w", err)
+	}
+	return nil
+}
+
+// clearForeignSecrets blanks encrypted values that were bound to another machine.
+// Left in place they would only fail to decrypt at the moment they are needed.
+// Values encrypted with a master data key travel with the keyring and are kept.
+func clearForeignSecrets(db *sql.DB) ([]string, error) {
+	required := []string{}
+
+	sources := []struct {
+		prefix string
+		query  string
+		clear  string
+	}{
+		{"", "SELECT key, value FROM settings", "UPDATE settings SET value = '' WHERE key = ?"},
+		{"ai_profile:", "SELECT id, api_key FROM ai_profiles", "UPDATE ai_profiles SET api_key = '' WHERE id = ?"},
+		{"feed:", "SELECT id, COALESCE(email_password, '') FROM feeds", "UPDATE feeds SET email_password = '' WHERE id = ?"},
+		{"feed_email_oauth_client_secret:", "SELECT id, COALESCE(email_oauth_client_secret, '') FROM feeds", "UPDATE feeds SET email_oauth_client_secret = '' WHERE id = ?"},
+		{"feed_email_oauth_refresh_token:", "SELECT id, COALESCE(email_oauth_refresh_token, '') FROM feeds", "UPDATE feeds SET email_oauth_refresh_token = '' WHERE id = ?"},
+		{"feed_json_headers:", "SELECT id, COALESCE(json_headers, '') FROM feeds", "UPDATE feeds SET json_headers = '' WHERE id = ?"},
+	}
+
+	for _, source := range sources {
+		rows, err := db.Query(source.query)
+		if err != nil {
+			// Backups from older versions may lack the table or column
+			continue
+		}
+		var foreign []string
+		for rows.Next() {
+			var id, value string
+			if err := rows.Scan(&id, &value); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan backup secret: %w", err)
+			}
+			if crypto.IsMachineBound(value) {
+				if _, err := crypto.Decrypt(value); err != nil {
+					foreign = append(foreign, id)
+				}
+			}
+		}
+		rows.Close()
+
+		for _, id := range foreign {
+			if _, err := db.Exec(source.clear, id); err != nil {
+				return nil, fmt.Errorf("clear secret %s%s: %w", source.prefix, id, err)
+			}
+			required = append(required, source.prefix+id)
+		}
+	}
+
+	return required, nil
+}
+
+// restoreFiles restores custom CSS, and scripts when opted in, from the archive
+func restoreFiles(zr *zip.Reader, opts RestoreOptions, result *RestoreResult) error {
+	dataDir, err := fileutil.GetDataDir()
+	if err != nil {
+		return fmt.Errorf("get data dir: %w", err)
+	}
+	scriptsDir, err := fileutil.GetScriptsDir()
+	if err != nil {
+		return fmt.Errorf("get scripts dir: %w", err)
+	}
+	cleanScriptsDir := filepath.Clean(scriptsDir) + string(filepath.Separator)
+
+	for _, f := range zr.File {
+		switch {
+		case f.Name == customCSSEntry:
+			if err := extractEntry(f, filepath.Join(dataDir, customCSSFileName)); err != nil {
+				return err
+			}
+		case strings.HasPrefix(f.Name, scriptsPrefix) && !f.FileInfo().IsDir():
+			if !opts.Scripts {
+				result.SkippedScripts = append(result.SkippedScripts, strings.TrimPrefix(f.Name, scriptsPrefix))
+				continue
+			}
+			target := filepath.Join(scriptsDir, filepath.FromSlash(strings.TrimPrefix(f.Name, scriptsPrefix)))
+			// Reject entries escaping the scripts directory
+			if !strings.HasPrefix(filepath.Clean(target), cleanScriptsDir) {
+				return fmt.Errorf("invalid script path in backup: %s", f.Name)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return fmt.Errorf("create script dir: %w", err)
+			}
+			if err := extractEntry(f, target); err != nil {
+				return err
+			}
+			if f.Mode()&0111 != 0 {
+				if err := os.Chmod(target, 0755); err != nil {
+					return fmt.Errorf("make script executable: %w", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyPendingRestore swaps in a database staged by Restore.
+// It must run before the database is opened. The replaced database is kept next to it
+// with the ".pre-restore" suffix, and moved back if the swap fails. It reports whether a restore was applied.
+func ApplyPendingRestore(dbPath string) (bool, error) {
+	pending := dbPath + pendingRestoreSuffix
+	if _, err := os.Stat(pending); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	previous := dbPath + preRestoreSuffix
+	var moved []string
+	rollback := func() {
+		for _, suffix := range moved {
+			if err := os.Rename(previous+suffix, dbPath+suffix); err != nil {
+				log.Printf("[Backup] Failed to move back %s: %v", dbPath+suffix, err)
+			}
+		}
+	}
+
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		_ = os.Remove(previous + suffix)
+		if _, err := os.Stat(dbPath + suffix); err == nil {
+			if err := os.Rename(dbPath+suffix, previous+suffix); err != nil {
+				rollback()
+				return false, fmt.Errorf("move current database aside: %w", err)
+			}
+			moved = append(moved, suffix)
+		}
+	}
+
+	if err := os.Rename(pending, dbPath); err != nil {
+		rollback()
+		return false, fmt.Errorf("apply restored database: %w", err)
+	}
+
+	log.Printf("[Backup] Restored database applied, previous database kept as %s", previous)
+	return true, nil
+}
+
+// readManifest decodes the manifest entry of an archive
+func readManifest(zr *zip.Reader) (*Manifest, error) {
+	f := findEntry(zr, manifestEntry)
+	if f == nil {
+		return nil, fmt.Errorf("not a MrRSS backup: manifest missing")
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("open manifest: %w", err)
+	}
+	defer rc.Close()
+
+	var manifest Manifest
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("decode manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// findEntry returns the archive entry with the given name
+func findEntry(zr *zip.Reader, name string) *zip.File {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// extractEntry writes an archive entry to a file
+func extractEntry(f *zip.File, target string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	out, err := os.Create(target)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", target, err)
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return fmt.Errorf("extract %s: %w", f.Name, err)
+	}
+	return out.Close()
+}
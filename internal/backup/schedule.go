@@ -0,0 +1,149 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/utils/fileutil"
+)
+
+// Info describes a backup file in the backup directory
+type Info struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Dir returns the configured backup directory, falling back to the data directory
+func Dir(db *database.DB) (string, error) {
+	dir, _ := db.GetSetting("backup_directory")
+	if dir == "" {
+		return fileutil.GetBackupsDir()
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("create backup dir: %w", err)
+	}
+	return dir, nil
+}
+
+// WriteFile writes a new backup into the backup directory and removes the oldest
+// backups beyond the configured number of copies
+func WriteFile(ctx context.Context, db *database.DB) (*Info, error) {
+	dir, err := Dir(db)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	path := filepath.Join(dir, FileName(now))
+	tmpPath := path + ".part"
+
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("create backup file: %w", err)
+	}
+	if _, err := Write(ctx, db, f); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("close backup file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("finish backup file: %w", err)
+	}
+
+	_ = db.SetSetting("last_backup_time", now.Format(time.RFC3339))
+
+	keep := 7
+	if keepStr, _ := db.GetSetting("backup_keep_count"); keepStr != "" {
+		if k, err := strconv.Atoi(keepStr); err == nil && k > 0 {
+			keep = k
+		}
+	}
+	if err := Rotate(dir, keep); err != nil {
+		log.Printf("[Backup] Failed to rotate backups: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Info{Name: info.Name(), Path: path, Size: info.Size(), CreatedAt: now}, nil
+}
+
+// List returns the backups in a directory, newest first
+func List(dir string) ([]Info, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read backup dir: %w", err)
+	}
+
+	backups := []Info{}
+	for _, entry := range entries {
+		if entry.IsDir() || !isBackupFile(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Info{
+			Name:      entry.Name(),
+			Path:      filepath.Join(dir, entry.Name()),
+			Size:      info.Size(),
+			CreatedAt: info.ModTime(),
+		})
+	}
+
+	// File names embed the creation time, so name order is age order
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Name > backups[j].Name })
+	return backups, nil
+}
+
+// Rotate deletes the oldest backups so that at most keep copies remain
+func Rotate(dir string, keep int) error {
+	backups, err := List(dir)
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(backups); i++ {
+		if err := os.Remove(backups[i].Path); err != nil {
+			return fmt.Errorf("remove old backup %s: %w", backups[i].Name, err)
+		}
+		log.Printf("[Backup] Removed old backup %s", backups[i].Name)
+	}
+	return nil
+}
+
+// IsDue reports whether a scheduled backup should run now
+func IsDue(db *database.DB) bool {
+	if enabled, _ := db.GetSetting("backup_enabled"); enabled != "true" {
+		return false
+	}
+
+	interval := 24 * time.Hour
+	if hoursStr, _ := db.GetSetting("backup_interval_hours"); hoursStr != "" {
+		if hours, err := strconv.Atoi(hoursStr); err == nil && hours > 0 {
+			interval = time.Duration(hours) * time.Hour
+		}
+	}
+
+	lastStr, _ := db.GetSetting("last_backup_time")
+	last, err := time.Parse(time.RFC3339, lastStr)
+	if err != nil {
+		return true
+	}
+	return time.Since(last) >= interval
+}
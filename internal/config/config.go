@@ -32,8 +32,13 @@ type Defaults struct {
 	AITranslationPrompt           string `json:"ai_translation_prompt"`
 	AIUsageLimit                  string `json:"ai_usage_limit"`
 	AIUsageTokens                 string `json:"ai_usage_tokens"`
+	ArchiveEnabled                bool   `json:"archive_enabled"`
 	AutoCleanupEnabled            bool   `json:"auto_cleanup_enabled"`
 	AutoShowAllContent            bool   `json:"auto_show_all_content"`
+	BackupDirectory               string `json:"backup_directory"`
+	BackupEnabled                 bool   `json:"backup_enabled"`
+	BackupIntervalHours           int    `json:"backup_interval_hours"`
+	BackupKeepCount               int    `json:"backup_keep_count"`
 	BaiduAppId                    string `json:"baidu_app_id"`
 	BaiduSecretKey                string `json:"baidu_secret_key"`
 	CloseToTray                   bool   `json:"close_to_tray"`
@@ -50,33 +55,48 @@ type Defaults struct {
 	CustomTranslationName         string `json:"custom_translation_name"`
 	CustomTranslationResponsePath string `json:"custom_translation_response_path"`
 	CustomTranslationTimeout      int    `json:"custom_translation_timeout"`
+	DbMaintenanceIntervalHours    int    `json:"db_maintenance_interval_hours"`
+	DbMaintenanceOperations       string `json:"db_maintenance_operations"`
 	DeeplAPIKey                   string `json:"deepl_api_key"`
 	DeeplEndpoint                 string `json:"deepl_endpoint"`
 	DefaultViewMode               string `json:"default_view_mode"`
+	EmailIdleEnabled              bool   `json:"email_idle_enabled"`
+	EmailInboundDomain            string `json:"email_inbound_domain"`
+	EmailInboundEnabled           bool   `json:"email_inbound_enabled"`
+	EmailInboundListen            string `json:"email_inbound_listen"`
+	EmailInboundProtocol          string `json:"email_inbound_protocol"`
+	FeedAutoPauseFailures         int    `json:"feed_auto_pause_failures"`
 	FeedDrawerExpanded            bool   `json:"feed_drawer_expanded"`
 	FeedDrawerPinned              bool   `json:"feed_drawer_pinned"`
 	FreshRSSAPIPassword           string `json:"freshrss_api_password"`
 	FreshRSSAutoSyncInterval      int    `json:"freshrss_auto_sync_interval"`
 	FreshRSSEnabled               bool   `json:"freshrss_enabled"`
 	FreshRSSLastSyncTime          string `json:"freshrss_last_sync_time"`
+	FreshRSSPushNewFeeds          bool   `json:"freshrss_push_new_feeds"`
 	FreshRSSServerUrl             string `json:"freshrss_server_url"`
 	FreshRSSSyncOnStartup         bool   `json:"freshrss_sync_on_startup"`
 	FreshRSSUsername              string `json:"freshrss_username"`
 	FullTextFetchEnabled          bool   `json:"full_text_fetch_enabled"`
 	GoogleTranslateEndpoint       string `json:"google_translate_endpoint"`
+	HostRequestIntervalMs         int    `json:"host_request_interval_ms"`
 	HoverMarkAsRead               bool   `json:"hover_mark_as_read"`
 	ImageGalleryEnabled           bool   `json:"image_gallery_enabled"`
 	Language                      string `json:"language"`
+	LastBackupTime                string `json:"last_backup_time"`
+	LastDbMaintenanceTime         string `json:"last_db_maintenance_time"`
 	LastGlobalRefresh             string `json:"last_global_refresh"`
 	LastNetworkTest               string `json:"last_network_test"`
 	LayoutMode                    string `json:"layout_mode"`
 	MaxArticleAgeDays             int    `json:"max_article_age_days"`
 	MaxCacheSizeMb                int    `json:"max_cache_size_mb"`
 	MaxConcurrentRefreshes        string `json:"max_concurrent_refreshes"`
+	MaxRefreshesPerHost           int    `json:"max_refreshes_per_host"`
 	MediaCacheEnabled             bool   `json:"media_cache_enabled"`
 	MediaCacheMaxAgeDays          int    `json:"media_cache_max_age_days"`
 	MediaCacheMaxSizeMb           int    `json:"media_cache_max_size_mb"`
 	MediaProxyFallback            bool   `json:"media_proxy_fallback"`
+	MeteredMinFavorites           int    `json:"metered_min_favorites"`
+	MeteredMode                   bool   `json:"metered_mode"`
 	MicrosoftAPIKey               string `json:"microsoft_api_key"`
 	MicrosoftEndpoint             string `json:"microsoft_endpoint"`
 	MicrosoftRegion               string `json:"microsoft_region"`
@@ -95,7 +115,9 @@ type Defaults struct {
 	ProxyPort                     string `json:"proxy_port"`
 	ProxyType                     string `json:"proxy_type"`
 	ProxyUsername                 string `json:"proxy_username"`
+	QuietHours                    string `json:"quiet_hours"`
 	RefreshMode                   string `json:"refresh_mode"`
+	RestoreRequiredSecrets        string `json:"restore_required_secrets"`
 	RetryTimeoutSeconds           int    `json:"retry_timeout_seconds"`
 	RsshubAPIKey                  string `json:"rsshub_api_key"`
 	RsshubEnabled                 bool   `json:"rsshub_enabled"`
@@ -174,10 +196,20 @@ func GetString(key string) string {
 		return defaults.AIUsageLimit
 	case "ai_usage_tokens":
 		return defaults.AIUsageTokens
+	case "archive_enabled":
+		return strconv.FormatBool(defaults.ArchiveEnabled)
 	case "auto_cleanup_enabled":
 		return strconv.FormatBool(defaults.AutoCleanupEnabled)
 	case "auto_show_all_content":
 		return strconv.FormatBool(defaults.AutoShowAllContent)
+	case "backup_directory":
+		return defaults.BackupDirectory
+	case "backup_enabled":
+		return strconv.FormatBool(defaults.BackupEnabled)
+	case "backup_interval_hours":
+		return strconv.Itoa(defaults.BackupIntervalHours)
+	case "backup_keep_count":
+		return strconv.Itoa(defaults.BackupKeepCount)
 	case "baidu_app_id":
 		return defaults.BaiduAppId
 	case "baidu_secret_key":
@@ -210,12 +242,28 @@ func GetString(key string) string {
 		return defaults.CustomTranslationResponsePath
 	case "custom_translation_timeout":
 		return strconv.Itoa(defaults.CustomTranslationTimeout)
+	case "db_maintenance_interval_hours":
+		return strconv.Itoa(defaults.DbMaintenanceIntervalHours)
+	case "db_maintenance_operations":
+		return defaults.DbMaintenanceOperations
 	case "deepl_api_key":
 		return defaults.DeeplAPIKey
 	case "deepl_endpoint":
 		return defaults.DeeplEndpoint
 	case "default_view_mode":
 		return defaults.DefaultViewMode
+	case "email_idle_enabled":
+		return strconv.FormatBool(defaults.EmailIdleEnabled)
+	case "email_inbound_domain":
+		return defaults.EmailInboundDomain
+	case "email_inbound_enabled":
+		return strconv.FormatBool(defaults.EmailInboundEnabled)
+	case "email_inbound_listen":
+		return defaults.EmailInboundListen
+	case "email_inbound_protocol":
+		return defaults.EmailInboundProtocol
+	case "feed_auto_pause_failures":
+		return strconv.Itoa(defaults.FeedAutoPauseFailures)
 	case "feed_drawer_expanded":
 		return strconv.FormatBool(defaults.FeedDrawerExpanded)
 	case "feed_drawer_pinned":
@@ -228,6 +276,8 @@ func GetString(key string) string {
 		return strconv.FormatBool(defaults.FreshRSSEnabled)
 	case "freshrss_last_sync_time":
 		return defaults.FreshRSSLastSyncTime
+	case "freshrss_push_new_feeds":
+		return strconv.FormatBool(defaults.FreshRSSPushNewFeeds)
 	case "freshrss_server_url":
 		return defaults.FreshRSSServerUrl
 	case "freshrss_sync_on_startup":
@@ -238,12 +288,18 @@ func GetString(key string) string {
 		return strconv.FormatBool(defaults.FullTextFetchEnabled)
 	case "google_translate_endpoint":
 		return defaults.GoogleTranslateEndpoint
+	case "host_request_interval_ms":
+		return strconv.Itoa(defaults.HostRequestIntervalMs)
 	case "hover_mark_as_read":
 		return strconv.FormatBool(defaults.HoverMarkAsRead)
 	case "image_gallery_enabled":
 		return strconv.FormatBool(defaults.ImageGalleryEnabled)
 	case "language":
 		return defaults.Language
+	case "last_backup_time":
+		return defaults.LastBackupTime
+	case "last_db_maintenance_time":
+		return defaults.LastDbMaintenanceTime
 	case "last_global_refresh":
 		return defaults.LastGlobalRefresh
 	case "last_network_test":
@@ -256,6 +312,8 @@ func GetString(key string) string {
 		return strconv.Itoa(defaults.MaxCacheSizeMb)
 	case "max_concurrent_refreshes":
 		return defaults.MaxConcurrentRefreshes
+	case "max_refreshes_per_host":
+		return strconv.Itoa(defaults.MaxRefreshesPerHost)
 	case "media_cache_enabled":
 		return strconv.FormatBool(defaults.MediaCacheEnabled)
 	case "media_cache_max_age_days":
@@ -264,6 +322,10 @@ func GetString(key string) string {
 		return strconv.Itoa(defaults.MediaCacheMaxSizeMb)
 	case "media_proxy_fallback":
 		return strconv.FormatBool(defaults.MediaProxyFallback)
+	case "metered_min_favorites":
+		return strconv.Itoa(defaults.MeteredMinFavorites)
+	case "metered_mode":
+		return strconv.FormatBool(defaults.MeteredMode)
 	case "microsoft_api_key":
 		return defaults.MicrosoftAPIKey
 	case "microsoft_endpoint":
@@ -300,8 +362,12 @@ func GetString(key string) string {
 		return defaults.ProxyType
 	case "proxy_username":
 		return defaults.ProxyUsername
+	case "quiet_hours":
+		return defaults.QuietHours
 	case "refresh_mode":
 		return defaults.RefreshMode
+	case "restore_required_secrets":
+		return defaults.RestoreRequiredSecrets
 	case "retry_timeout_seconds":
 		return strconv.Itoa(defaults.RetryTimeoutSeconds)
 	case "rsshub_api_key":
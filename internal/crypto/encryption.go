@@ -70,10 +70,28 @@ func DeriveKey(machineID string, salt []byte) []byte {
 	return pbkdf2.Key([]byte(machineID), salt, pbkdf2Iterations, keySize, sha256.New)
 }
 
-// Encrypt encrypts plaintext using AES-256-GCM with a machine-specific key.
+// Encrypt encrypts plaintext for storage in the database.
+// When a master key has been unlocked the value is encrypted with its data key,
+// otherwise with a machine-specific key (see EncryptWithMachineID).
+// While a master key is configured but locked it returns ErrLocked.
+func Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	if key := currentDataKey(); key != nil {
+		return EncryptWithDataKey(plaintext, key)
+	}
+	if IsLocked() {
+		return "", ErrLocked
+	}
+	return EncryptWithMachineID(plaintext)
+}
+
+// EncryptWithMachineID encrypts plaintext using AES-256-GCM with a machine-specific key.
 // The output format is: [salt(16 bytes)][nonce(12 bytes)][ciphertext+tag]
 // Returns base64-encoded result for safe storage in database.
-func Encrypt(plaintext string) (string, error) {
+func EncryptWithMachineID(plaintext string) (string, error) {
 	if plaintext == "" {
 		return "", nil
 	}
@@ -132,6 +150,11 @@ func Decrypt(ciphertextBase64 string) (string, error) {
 		return "", nil
 	}
 
+	// Values encrypted with the master data key
+	if strings.HasPrefix(ciphertextBase64, dataKeyMarker) {
+		return decryptWithDataKey(ciphertextBase64)
+	}
+
 	// Check and strip version marker
 	if !strings.HasPrefix(ciphertextBase64, versionMarker) {
 		return "", fmt.Errorf("missing or invalid version marker")
@@ -199,5 +222,10 @@ func IsEncrypted(value string) bool {
 	}
 
 	// Check for version marker - this is definitive, not a heuristic
+	return strings.HasPrefix(value, versionMarker) || strings.HasPrefix(value, dataKeyMarker)
+}
+
+// IsMachineBound reports whether an encrypted value can only be decrypted on the machine that wrote it
+func IsMachineBound(value string) bool {
 	return strings.HasPrefix(value, versionMarker)
 }
@@ -0,0 +1,232 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// dataKeyMarker identifies values encrypted with the master data key.
+	// The output format after the marker is base64 of: [nonce(12 bytes)][ciphertext+tag]
+	dataKeyMarker = "MrRSS-v2:"
+
+	// MasterKeyEnv holds the master key secret, e.g. for Docker deployments
+	MasterKeyEnv = "MRRSS_MASTER_KEY"
+
+	// MasterKeyFileEnv holds the path of a file whose content is the master key secret
+	MasterKeyFileEnv = "MRRSS_MASTER_KEY_FILE"
+)
+
+var (
+	// ErrLocked is returned when a value needs the master data key but it has not been unlocked
+	ErrLocked = errors.New("encryption key is locked")
+
+	// ErrWrongSecret is returned when a wrapped data key cannot be opened with the given secret
+	ErrWrongSecret = errors.New("wrong passphrase or key file")
+)
+
+var (
+	dataKeyMu sync.RWMutex
+	dataKey   []byte
+	// keyringConfigured is set when secrets are encrypted with a data key, unlocked or not
+	keyringConfigured bool
+)
+
+// SetDataKey sets the data key used by Encrypt and Decrypt
+func SetDataKey(key []byte) {
+	dataKeyMu.Lock()
+	defer dataKeyMu.Unlock()
+	dataKey = append([]byte(nil), key...)
+	keyringConfigured = true
+}
+
+// SetKeyringConfigured records that secrets are encrypted with a data key that is not unlocked yet,
+// so Encrypt fails with ErrLocked instead of writing machine-bound values
+func SetKeyringConfigured() {
+	dataKeyMu.Lock()
+	defer dataKeyMu.Unlock()
+	keyringConfigured = true
+}
+
+// ClearDataKey forgets the data key and the keyring, so Encrypt uses the machine-specific key
+func ClearDataKey() {
+	dataKeyMu.Lock()
+	defer dataKeyMu.Unlock()
+	dataKey = nil
+	keyringConfigured = false
+}
+
+// HasDataKey reports whether a data key has been unlocked
+func HasDataKey() bool {
+	return currentDataKey() != nil
+}
+
+// IsLocked reports whether secrets are encrypted with a data key that has not been unlocked
+func IsLocked() bool {
+	dataKeyMu.RLock()
+	defer dataKeyMu.RUnlock()
+	return keyringConfigured && dataKey == nil
+}
+
+// currentDataKey returns the unlocked data key, or nil
+func currentDataKey() []byte {
+	dataKeyMu.RLock()
+	defer dataKeyMu.RUnlock()
+	return dataKey
+}
+
+// GenerateDataKey returns a new random data key
+func GenerateDataKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	return key, nil
+}
+
+// WrapDataKey encrypts a data key with a key derived from secret using PBKDF2.
+// The output is base64 of: [salt(16 bytes)][nonce(12 bytes)][wrapped key+tag]
+func WrapDataKey(key []byte, secret string) (string, error) {
+	if secret == "" {
+		return "", fmt.Errorf("empty master key secret")
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	sealed, err := seal(pbkdf2.Key([]byte(secret), salt, pbkdf2Iterations, keySize, sha256.New), key)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(append(salt, sealed...)), nil
+}
+
+// UnwrapDataKey opens a data key wrapped by WrapDataKey
+func UnwrapDataKey(wrapped, secret string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode wrapped key: %w", err)
+	}
+	if len(data) < saltSize {
+		return nil, ErrInvalidCiphertext
+	}
+
+	salt := data[:saltSize]
+	key, err := open(pbkdf2.Key([]byte(secret), salt, pbkdf2Iterations, keySize, sha256.New), data[saltSize:])
+	if err != nil {
+		return nil, ErrWrongSecret
+	}
+	return key, nil
+}
+
+// EncryptWithDataKey encrypts plaintext with the given data key
+func EncryptWithDataKey(plaintext string, key []byte) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	sealed, err := seal(key, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return dataKeyMarker + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptWithDataKey decrypts a value written by EncryptWithDataKey using the unlocked data key
+func decryptWithDataKey(value string) (string, error) {
+	key := currentDataKey()
+	if key == nil {
+		return "", ErrLocked
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, dataKeyMarker))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64: %w", err)
+	}
+
+	plaintext, err := open(key, data)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// MasterSecretFromEnv returns the master key secret configured through the environment.
+// MRRSS_MASTER_KEY takes precedence over MRRSS_MASTER_KEY_FILE.
+func MasterSecretFromEnv() (string, error) {
+	if secret := os.Getenv(MasterKeyEnv); secret != "" {
+		return secret, nil
+	}
+	if path := os.Getenv(MasterKeyFileEnv); path != "" {
+		return ReadKeyFile(path)
+	}
+	return "", nil
+}
+
+// ReadKeyFile reads a master key secret from a file, ignoring surrounding whitespace
+func ReadKeyFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read key file: %w", err)
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("key file %s is empty", path)
+	}
+	return secret, nil
+}
+
+// seal encrypts data with AES-256-GCM and returns [nonce][ciphertext+tag]
+func seal(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// open decrypts [nonce][ciphertext+tag] produced by seal
+func open(key, data []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
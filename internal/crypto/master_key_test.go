@@ -0,0 +1,71 @@
+package crypto
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWrapUnwrapDataKey(t *testing.T) {
+	key, err := GenerateDataKey()
+	if err != nil {
+		t.Fatalf("GenerateDataKey() error = %v", err)
+	}
+
+	wrapped, err := WrapDataKey(key, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("WrapDataKey() error = %v", err)
+	}
+
+	unwrapped, err := UnwrapDataKey(wrapped, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("UnwrapDataKey() error = %v", err)
+	}
+	if string(unwrapped) != string(key) {
+		t.Fatal("UnwrapDataKey() returned a different key")
+	}
+
+	if _, err := UnwrapDataKey(wrapped, "wrong"); !errors.Is(err, ErrWrongSecret) {
+		t.Fatalf("UnwrapDataKey() with wrong secret error = %v, want ErrWrongSecret", err)
+	}
+}
+
+func TestEncryptWithDataKey(t *testing.T) {
+	key, _ := GenerateDataKey()
+	SetDataKey(key)
+	defer ClearDataKey()
+
+	encrypted, err := Encrypt("sk-test")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if !strings.HasPrefix(encrypted, dataKeyMarker) || !IsEncrypted(encrypted) || IsMachineBound(encrypted) {
+		t.Fatalf("Encrypt() with data key = %q, want %s value", encrypted, dataKeyMarker)
+	}
+
+	decrypted, err := Decrypt(encrypted)
+	if err != nil || decrypted != "sk-test" {
+		t.Fatalf("Decrypt() = %q, %v; want sk-test", decrypted, err)
+	}
+
+	// Machine-bound values stay readable while a data key is set
+	legacy, _ := EncryptWithMachineID("legacy")
+	if decrypted, err := Decrypt(legacy); err != nil || decrypted != "legacy" {
+		t.Fatalf("Decrypt(machine value) = %q, %v", decrypted, err)
+	}
+
+	ClearDataKey()
+	if _, err := Decrypt(encrypted); !errors.Is(err, ErrLocked) {
+		t.Fatalf("Decrypt() without data key error = %v, want ErrLocked", err)
+	}
+
+	// A locked keyring never falls back to machine-bound values
+	SetKeyringConfigured()
+	if _, err := Encrypt("sk-test"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("Encrypt() while locked error = %v, want ErrLocked", err)
+	}
+	ClearDataKey()
+	if encrypted, err := Encrypt("sk-test"); err != nil || !IsMachineBound(encrypted) {
+		t.Fatalf("Encrypt() without keyring = %q, %v; want machine-bound value", encrypted, err)
+	}
+}
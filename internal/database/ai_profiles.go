@@ -11,6 +11,7 @@ import (
 
 // CreateAIProfile creates a new AI profile
 func (db *DB) CreateAIProfile(profile *models.AIProfile) (int64, error) {
+	defer db.HoldSecrets()()
 	// Encrypt API key before storing
 	encryptedKey := profile.APIKey
 	if profile.APIKey != "" {
@@ -142,6 +143,7 @@ func (db *DB) GetAllAIProfilesWithoutKeys() ([]models.AIProfile, error) {
 
 // UpdateAIProfile updates an existing AI profile
 func (db *DB) UpdateAIProfile(profile *models.AIProfile) error {
+	defer db.HoldSecrets()()
 	// Encrypt API key before storing
 	encryptedKey := profile.APIKey
 	if profile.APIKey != "" {
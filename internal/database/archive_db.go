@@ -0,0 +1,642 @@
+package database
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+
+	"MrRSS/internal/models"
+)
+
+// The archive keeps the articles cleanups remove, when archive_enabled is on.
+// Articles go to one SQLite file per year they were published in, next to the
+// main database, with their cached contents gzip-compressed. A view decompresses
+// the contents under the main table name, so the search queries of the main
+// database run unchanged on every archive.
+
+// archiveBatchSize is how many articles are moved to or from an archive at once
+const archiveBatchSize = 500
+
+// ErrArchiveUnavailable is returned when the database has no file to keep archives next to
+var ErrArchiveUnavailable = errors.New("archive needs a database file")
+
+// ArchiveInfo describes the archive of one year
+type ArchiveInfo struct {
+	Year     int   `json:"year"`
+	Articles int64 `json:"articles"`
+	Size     int64 `json:"size"` // File size in bytes
+}
+
+func init() {
+	// gunzip(data) decompresses archived article contents, so searches can match them
+	sqlite.MustRegisterDeterministicScalarFunction("gunzip", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		data, ok := args[0].([]byte)
+		if !ok {
+			return nil, nil
+		}
+		return gunzipString(data)
+	})
+}
+
+func gzipString(s string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(s)); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzipString(data []byte) (string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+	content, err := io.ReadAll(zr)
+	return string(content), err
+}
+
+// archiveEnabled reports whether cleanups move articles to the archive
+func (db *DB) archiveEnabled() bool {
+	value, _ := db.GetSetting("archive_enabled")
+	return value == "true"
+}
+
+// archiveDir returns the directory of the archives, next to the database file
+func (db *DB) archiveDir() (string, error) {
+	file, err := db.filePath()
+	if err != nil {
+		return "", err
+	}
+	if file == "" {
+		return "", ErrArchiveUnavailable
+	}
+	return filepath.Join(filepath.Dir(file), "archive"), nil
+}
+
+func archivePath(dir string, year int) string {
+	return filepath.Join(dir, fmt.Sprintf("articles-%d.db", year))
+}
+
+// archiveYears returns the years with an archive, newest first
+func (db *DB) archiveYears() (string, []int, error) {
+	dir, err := db.archiveDir()
+	if err != nil {
+		return "", nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return dir, nil, nil
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("read archive directory: %w", err)
+	}
+
+	var years []int
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "articles-") || !strings.HasSuffix(name, ".db") {
+			continue
+		}
+		if year, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "articles-"), ".db")); err == nil {
+			years = append(years, year)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(years)))
+	return dir, years, nil
+}
+
+// openArchive opens the archive of a year, creating it when create is set
+func openArchive(dir string, year int, create bool) (*sql.DB, error) {
+	path := archivePath(dir, year)
+	if !create {
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+	} else if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("create archive directory: %w", err)
+	}
+
+	adb, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	adb.SetMaxOpenConns(1)
+
+	_, err = adb.Exec(`
+		CREATE TABLE IF NOT EXISTS feeds (
+			id INTEGER PRIMARY KEY,
+			title TEXT,
+			url TEXT,
+			category TEXT
+		);
+		CREATE TABLE IF NOT EXISTS articles (
+			id INTEGER PRIMARY KEY,
+			archived_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE IF NOT EXISTS archived_contents (
+			article_id INTEGER PRIMARY KEY,
+			data BLOB NOT NULL,
+			fetched_at DATETIME
+		);
+		CREATE VIEW IF NOT EXISTS article_contents AS
+			SELECT article_id, gunzip(data) AS content, fetched_at FROM archived_contents;
+	`)
+	if err != nil {
+		adb.Close()
+		return nil, fmt.Errorf("create archive schema: %w", err)
+	}
+	return adb, nil
+}
+
+// tableColumns returns the names and declared types of the columns of a table
+func tableColumns(q interface {
+	Query(string, ...interface{}) (*sql.Rows, error)
+}, table string) ([]string, map[string]string, error) {
+	rows, err := q.Query(`PRAGMA table_info(` + table + `)`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	types := make(map[string]string)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue interface{}
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return nil, nil, err
+		}
+		names = append(names, name)
+		types[name] = colType
+	}
+	return names, types, rows.Err()
+}
+
+// syncArchiveColumns adds the article columns of the main database an archive lacks
+func syncArchiveColumns(adb *sql.DB, columns []string, types map[string]string) error {
+	_, existing, err := tableColumns(adb, "articles")
+	if err != nil {
+		return fmt.Errorf("get archive columns: %w", err)
+	}
+	for _, column := range columns {
+		if _, ok := existing[column]; ok {
+			continue
+		}
+		if _, err := adb.Exec(`ALTER TABLE articles ADD COLUMN "` + column + `" ` + types[column]); err != nil {
+			return fmt.Errorf("add archive column %s: %w", column, err)
+		}
+	}
+	return nil
+}
+
+// idList formats IDs for an IN clause
+func idList(ids []int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// quoteColumns quotes column names for a column list
+func quoteColumns(columns []string) string {
+	return `"` + strings.Join(columns, `", "`) + `"`
+}
+
+// removeArticles deletes the articles matching a condition and their cached
+// contents, moving them to the archive first when it is on. It returns how many
+// articles and contents were removed.
+func (db *DB) removeArticles(condition string, args ...interface{}) (int64, int64, error) {
+	rows, err := db.Query(`SELECT id, published_at FROM articles WHERE `+condition, args...)
+	if err != nil {
+		return 0, 0, err
+	}
+	var ids []int64
+	years := make(map[int64]int)
+	for rows.Next() {
+		var id int64
+		var publishedAt sql.NullTime
+		if err := rows.Scan(&id, &publishedAt); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		ids = append(ids, id)
+		if publishedAt.Valid && !publishedAt.Time.IsZero() {
+			years[id] = publishedAt.Time.Year()
+		} else {
+			years[id] = time.Now().Year()
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+	if len(ids) == 0 {
+		return 0, 0, nil
+	}
+
+	if db.archiveEnabled() {
+		if err := db.archiveArticles(ids, years); err != nil {
+			return 0, 0, fmt.Errorf("archive articles: %w", err)
+		}
+	}
+
+	var articles, contents int64
+	for start := 0; start < len(ids); start += archiveBatchSize {
+		batch := idList(ids[start:min(start+archiveBatchSize, len(ids))])
+		result, err := db.Exec(`DELETE FROM article_contents WHERE article_id IN (` + batch + `)`)
+		if err != nil {
+			return articles, contents, err
+		}
+		n, _ := result.RowsAffected()
+		contents += n
+		if result, err = db.Exec(`DELETE FROM articles WHERE id IN (` + batch + `)`); err != nil {
+			return articles, contents, err
+		}
+		n, _ = result.RowsAffected()
+		articles += n
+	}
+	return articles, contents, nil
+}
+
+// archiveArticles copies articles, their feeds and their cached contents to
+// the archives of the years they were published in
+func (db *DB) archiveArticles(ids []int64, years map[int64]int) error {
+	dir, err := db.archiveDir()
+	if err != nil {
+		return err
+	}
+	columns, types, err := tableColumns(db, "articles")
+	if err != nil {
+		return fmt.Errorf("get article columns: %w", err)
+	}
+
+	byYear := make(map[int][]int64)
+	for _, id := range ids {
+		byYear[years[id]] = append(byYear[years[id]], id)
+	}
+	for year, yearIDs := range byYear {
+		if err := db.archiveYear(dir, year, yearIDs, columns, types); err != nil {
+			return fmt.Errorf("archive %d: %w", year, err)
+		}
+	}
+	return nil
+}
+
+func (db *DB) archiveYear(dir string, year int, ids []int64, columns []string, types map[string]string) error {
+	adb, err := openArchive(dir, year, true)
+	if err != nil {
+		return err
+	}
+	defer adb.Close()
+	if err := syncArchiveColumns(adb, columns, types); err != nil {
+		return err
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	insertArticle := `INSERT OR REPLACE INTO articles (` + quoteColumns(columns) + `) VALUES (` + placeholders + `)`
+	for start := 0; start < len(ids); start += archiveBatchSize {
+		batch := idList(ids[start:min(start+archiveBatchSize, len(ids))])
+
+		tx, err := adb.Begin()
+		if err != nil {
+			return err
+		}
+		err = db.copyRows(tx, `SELECT `+quoteColumns(columns)+` FROM articles WHERE id IN (`+batch+`)`, insertArticle, nil)
+		if err == nil {
+			err = db.copyRows(tx, `SELECT id, title, url, COALESCE(category, '') FROM feeds
+				WHERE id IN (SELECT feed_id FROM articles WHERE id IN (`+batch+`))`,
+				`INSERT OR REPLACE INTO feeds (id, title, url, category) VALUES (?, ?, ?, ?)`, nil)
+		}
+		if err == nil {
+			err = db.copyRows(tx, `SELECT article_id, content, fetched_at FROM article_contents WHERE article_id IN (`+batch+`)`,
+				`INSERT OR REPLACE INTO archived_contents (article_id, data, fetched_at) VALUES (?, ?, ?)`,
+				func(values []interface{}) error {
+					content, err := decodeArticleContent(values[1])
+					if err != nil {
+						return err
+					}
+					values[1], err = gzipString(content)
+					return err
+				})
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyRows inserts the rows of a query on the main database with an insert
+// statement of the transaction, after an optional conversion of each row
+func (db *DB) copyRows(tx *sql.Tx, query, insert string, convert func([]interface{}) error) error {
+	rows, err := db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(insert)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+		if convert != nil {
+			if err := convert(values); err != nil {
+				return err
+			}
+		}
+		if _, err := stmt.Exec(values...); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ListArchives returns the archive of every year, newest first
+func (db *DB) ListArchives() ([]ArchiveInfo, error) {
+	db.WaitForReady()
+
+	archives := make([]ArchiveInfo, 0)
+	dir, years, err := db.archiveYears()
+	if errors.Is(err, ErrArchiveUnavailable) {
+		return archives, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, year := range years {
+		info := ArchiveInfo{Year: year}
+		if stat, err := os.Stat(archivePath(dir, year)); err == nil {
+			info.Size = stat.Size()
+		}
+		adb, err := openArchive(dir, year, false)
+		if err != nil {
+			return nil, fmt.Errorf("open archive %d: %w", year, err)
+		}
+		err = adb.QueryRow(`SELECT COUNT(*) FROM articles`).Scan(&info.Articles)
+		adb.Close()
+		if err != nil {
+			return nil, fmt.Errorf("count archive %d: %w", year, err)
+		}
+		archives = append(archives, info)
+	}
+	return archives, nil
+}
+
+// searchArchives runs a search query on every archive, newest first. The query
+// selects the columns scanSearchResults reads.
+func (db *DB) searchArchives(query string, args ...interface{}) ([]models.Article, error) {
+	dir, years, err := db.archiveYears()
+	if errors.Is(err, ErrArchiveUnavailable) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var articles []models.Article
+	for _, year := range years {
+		adb, err := openArchive(dir, year, false)
+		if err != nil {
+			return nil, fmt.Errorf("open archive %d: %w", year, err)
+		}
+		rows, err := adb.Query(query, args...)
+		if err != nil {
+			adb.Close()
+			return nil, fmt.Errorf("search archive %d: %w", year, err)
+		}
+		found, err := scanSearchResults(rows)
+		rows.Close()
+		adb.Close()
+		if err != nil {
+			return nil, fmt.Errorf("search archive %d: %w", year, err)
+		}
+		articles = append(articles, found...)
+	}
+	return articles, nil
+}
+
+// SearchArchiveWithSQL runs a search query of SearchArticlesWithSQL on the archives
+func (db *DB) SearchArchiveWithSQL(query string) ([]models.Article, error) {
+	db.WaitForReady()
+
+	if query == "" {
+		return nil, fmt.Errorf("empty query")
+	}
+	return db.searchArchives(query)
+}
+
+// SearchArchive returns archived articles whose title, summary or content
+// contains a keyword, at most limit of them, newest first
+func (db *DB) SearchArchive(keyword string, limit int) ([]models.Article, error) {
+	db.WaitForReady()
+
+	pattern := "%" + keyword + "%"
+	articles, err := db.searchArchives(`
+		SELECT a.id, a.feed_id, a.title, a.url, a.image_url, a.audio_url, a.video_url,
+			   a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later,
+			   a.translated_title, a.summary, a.freshrss_item_id, COALESCE(f.title, ''), a.author,
+			   0 AS relevance_score
+		FROM articles a
+		LEFT JOIN feeds f ON a.feed_id = f.id
+		LEFT JOIN article_contents c ON a.id = c.article_id
+		WHERE a.title LIKE ? OR a.summary LIKE ? OR c.content LIKE ?
+		ORDER BY a.published_at DESC
+		LIMIT ?
+	`, pattern, pattern, pattern, limit)
+	if len(articles) > limit {
+		articles = articles[:limit]
+	}
+	return articles, err
+}
+
+// RestoreArchivedArticles moves archived articles back to the main database with
+// their cached contents: the given ones, or all of them without ids, from the
+// archive of a year, or from all archives with year 0. Articles whose feed was
+// deleted, or that are already in the main database again, stay archived.
+func (db *DB) RestoreArchivedArticles(year int, ids []int64) (restored, skipped int64, err error) {
+	db.WaitForReady()
+
+	dir, years, err := db.archiveYears()
+	if err != nil {
+		return 0, 0, err
+	}
+	columns, _, err := tableColumns(db, "articles")
+	if err != nil {
+		return 0, 0, fmt.Errorf("get article columns: %w", err)
+	}
+
+	for _, archiveYear := range years {
+		if year != 0 && archiveYear != year {
+			continue
+		}
+		r, s, err := db.restoreYear(dir, archiveYear, ids, columns)
+		restored += r
+		skipped += s
+		if err != nil {
+			return restored, skipped, fmt.Errorf("restore archive %d: %w", archiveYear, err)
+		}
+	}
+	return restored, skipped, nil
+}
+
+func (db *DB) restoreYear(dir string, year int, ids []int64, mainColumns []string) (restored, skipped int64, err error) {
+	adb, err := openArchive(dir, year, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer adb.Close()
+
+	// Only the columns both databases have are restored
+	archived, _, err := tableColumns(adb, "articles")
+	if err != nil {
+		return 0, 0, err
+	}
+	inArchive := make(map[string]bool)
+	for _, column := range archived {
+		inArchive[column] = true
+	}
+	var columns []string
+	for _, column := range mainColumns {
+		if inArchive[column] {
+			columns = append(columns, column)
+		}
+	}
+
+	condition := ``
+	if ids != nil {
+		if len(ids) == 0 {
+			return 0, 0, nil
+		}
+		condition = ` WHERE id IN (` + idList(ids) + `)`
+	}
+	rows, err := adb.Query(`SELECT ` + quoteColumns(columns) + ` FROM articles` + condition)
+	if err != nil {
+		return 0, 0, err
+	}
+	var articles [][]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		articles = append(articles, values)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	idIndex, feedIndex := -1, -1
+	for i, column := range columns {
+		switch column {
+		case "id":
+			idIndex = i
+		case "feed_id":
+			feedIndex = i
+		}
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	insert := `INSERT OR IGNORE INTO articles (` + quoteColumns(columns) + `) VALUES (` + placeholders + `)`
+
+	var restoredIDs []int64
+	for _, values := range articles {
+		id, _ := values[idIndex].(int64)
+		feedID, _ := values[feedIndex].(int64)
+		var exists bool
+		if err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM feeds WHERE id = ?)`, feedID).Scan(&exists); err != nil {
+			return restored, skipped, err
+		}
+		if !exists {
+			skipped++
+			continue
+		}
+		result, err := db.Exec(insert, values...)
+		if err != nil {
+			return restored, skipped, err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			skipped++
+			continue
+		}
+
+		var data []byte
+		var fetchedAt interface{}
+		err = adb.QueryRow(`SELECT data, fetched_at FROM archived_contents WHERE article_id = ?`, id).Scan(&data, &fetchedAt)
+		if err == nil {
+			content, err := gunzipString(data)
+			if err != nil {
+				return restored, skipped, fmt.Errorf("decompress content of article %d: %w", id, err)
+			}
+			stored, err := encodeArticleContent(content)
+			if err != nil {
+				return restored, skipped, err
+			}
+			if _, err := db.Exec(`INSERT OR REPLACE INTO article_contents (article_id, content, raw_size, fetched_at) VALUES (?, ?, ?, ?)`,
+				id, stored, len(content), fetchedAt); err != nil {
+				return restored, skipped, err
+			}
+		} else if err != sql.ErrNoRows {
+			return restored, skipped, err
+		}
+		restored++
+		restoredIDs = append(restoredIDs, id)
+	}
+
+	for start := 0; start < len(restoredIDs); start += archiveBatchSize {
+		batch := idList(restoredIDs[start:min(start+archiveBatchSize, len(restoredIDs))])
+		if _, err := adb.Exec(`DELETE FROM archived_contents WHERE article_id IN (` + batch + `)`); err != nil {
+			return restored, skipped, err
+		}
+		if _, err := adb.Exec(`DELETE FROM articles WHERE id IN (` + batch + `)`); err != nil {
+			return restored, skipped, err
+		}
+	}
+	if len(restoredIDs) > 0 {
+		_, _ = adb.Exec(`DELETE FROM feeds WHERE id NOT IN (SELECT feed_id FROM articles)`)
+	}
+	return restored, skipped, nil
+}
@@ -0,0 +1,124 @@
+package database_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	dbpkg "MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+	db, err := dbpkg.NewDB(filepath.Join(dir, "rss.db"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	db.SetSetting("archive_enabled", "true")
+
+	addFeed := func(title string) int64 {
+		id, err := db.AddFeed(&models.Feed{Title: title, URL: "https://example.com/" + title})
+		if err != nil {
+			t.Fatalf("AddFeed: %v", err)
+		}
+		return id
+	}
+	addArticle := func(feedID int64, title string, published time.Time, read bool) int64 {
+		article := &models.Article{FeedID: feedID, Title: title, URL: "https://example.com/" + title, PublishedAt: published, HasValidPublishedTime: true, IsRead: read}
+		if err := db.SaveArticle(article); err != nil {
+			t.Fatalf("SaveArticle: %v", err)
+		}
+		var id int64
+		if err := db.QueryRow(`SELECT id FROM articles WHERE title = ?`, title).Scan(&id); err != nil {
+			t.Fatalf("article %s: %v", title, err)
+		}
+		return id
+	}
+
+	blog := addFeed("blog")
+	gone := addFeed("gone")
+	old := addArticle(blog, "old", time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC), true)
+	physics := addArticle(blog, "physics", time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), true)
+	addArticle(gone, "orphan", time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC), true)
+	addArticle(blog, "fresh", time.Now(), true)
+	content := strings.Repeat("<p>Notes on quantum entanglement</p>", 20)
+	if err := db.SetArticleContent(physics, content); err != nil {
+		t.Fatalf("SetArticleContent: %v", err)
+	}
+
+	removed, err := db.CleanupOldReadArticles(30)
+	if err != nil || removed != 3 {
+		t.Fatalf("CleanupOldReadArticles = %d, %v, want 3 articles", removed, err)
+	}
+	if _, found, _ := db.GetArticleContent(physics); found {
+		t.Error("Archived article content left in the main database")
+	}
+
+	archives, err := db.ListArchives()
+	if err != nil {
+		t.Fatalf("ListArchives: %v", err)
+	}
+	if len(archives) != 2 || archives[0].Year != 2024 || archives[0].Articles != 2 || archives[1].Year != 2023 || archives[1].Articles != 1 {
+		t.Fatalf("ListArchives = %+v, want 2 articles in 2024 and 1 in 2023", archives)
+	}
+
+	// Contents are compressed, yet searchable
+	raw, err := os.ReadFile(filepath.Join(dir, "archive", "articles-2024.db"))
+	if err != nil {
+		t.Fatalf("read archive: %v", err)
+	}
+	if bytes.Contains(raw, []byte("entanglement")) {
+		t.Error("Archived content stored uncompressed")
+	}
+	found, err := db.SearchArchive("entanglement", 10)
+	if err != nil || len(found) != 1 || found[0].ID != physics || found[0].FeedTitle != "blog" {
+		t.Fatalf("SearchArchive = %+v, %v, want the physics article", found, err)
+	}
+	found, err = db.SearchArchiveWithSQL(`
+		SELECT a.id, a.feed_id, a.title, a.url, a.image_url, a.audio_url, a.video_url,
+			   a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later,
+			   a.translated_title, a.summary, a.freshrss_item_id, f.title AS feed_title, a.author,
+			   (CASE WHEN c.content LIKE '%quantum%' THEN 2 ELSE 0 END) AS relevance_score
+		FROM articles a
+		JOIN feeds f ON a.feed_id = f.id
+		LEFT JOIN article_contents c ON a.id = c.article_id
+		WHERE a.is_hidden = 0 AND (c.content LIKE '%quantum%')
+		ORDER BY relevance_score DESC, a.published_at DESC
+		LIMIT 100
+	`)
+	if err != nil || len(found) != 1 {
+		t.Fatalf("SearchArchiveWithSQL = %+v, %v, want 1 article", found, err)
+	}
+
+	// Restoring brings back the content; articles of deleted feeds stay archived
+	if err := db.DeleteFeed(gone); err != nil {
+		t.Fatalf("DeleteFeed: %v", err)
+	}
+	restored, skipped, err := db.RestoreArchivedArticles(2024, nil)
+	if err != nil || restored != 1 || skipped != 1 {
+		t.Fatalf("RestoreArchivedArticles = %d, %d, %v, want 1 restored and 1 skipped", restored, skipped, err)
+	}
+	if got, found, _ := db.GetArticleContent(physics); !found || got != content {
+		t.Errorf("Restored content = %q, want %q", got, content)
+	}
+	restored, _, err = db.RestoreArchivedArticles(0, []int64{old})
+	if err != nil || restored != 1 {
+		t.Fatalf("RestoreArchivedArticles by ID = %d, %v, want 1", restored, err)
+	}
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM articles`).Scan(&count)
+	if count != 3 {
+		t.Errorf("Got %d articles after restoring, want 3", count)
+	}
+	if archives, _ := db.ListArchives(); archives[0].Articles != 1 || archives[1].Articles != 0 {
+		t.Errorf("ListArchives after restoring = %+v", archives)
+	}
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"modernc.org/sqlite"
+)
+
+// Cached article contents are stored gzip-compressed behind a header byte.
+// Contents that are short or do not compress, and contents cached before
+// compression, are stored as plain text.
+
+const (
+	// contentHeaderGzip is the first byte of gzip-compressed article contents
+	contentHeaderGzip byte = 0x01
+	// minCompressedContentSize is the size below which contents are stored as they are
+	minCompressedContentSize = 256
+)
+
+// ArticleContentStats describes the cached article contents
+type ArticleContentStats struct {
+	Count      int64 `json:"cached_articles"`
+	Compressed int64 `json:"compressed_articles"`
+	RawSize    int64 `json:"raw_size"`    // Bytes before compression
+	StoredSize int64 `json:"stored_size"` // Bytes stored
+}
+
+func init() {
+	// content_text(content) returns a cached article content as text, so
+	// queries can search compressed contents
+	sqlite.MustRegisterDeterministicScalarFunction("content_text", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		return decodeArticleContent(args[0])
+	})
+}
+
+// encodeArticleContent returns how an article content is stored
+func encodeArticleContent(content string) (interface{}, error) {
+	if len(content) < minCompressedContentSize {
+		return content, nil
+	}
+	data, err := gzipString(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)+1 >= len(content) {
+		return content, nil
+	}
+	return append([]byte{contentHeaderGzip}, data...), nil
+}
+
+// isCompressedContent reports whether a stored article content is compressed
+func isCompressedContent(stored []byte) bool {
+	return len(stored) > 3 && stored[0] == contentHeaderGzip && stored[1] == 0x1f && stored[2] == 0x8b
+}
+
+// decodeArticleContent returns an article content stored by encodeArticleContent
+func decodeArticleContent(stored interface{}) (string, error) {
+	switch v := stored.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		if isCompressedContent(v) {
+			content, err := gunzipString(v[1:])
+			if err != nil {
+				return "", fmt.Errorf("decompress article content: %w", err)
+			}
+			return content, nil
+		}
+		return string(v), nil
+	}
+	return fmt.Sprint(stored), nil
+}
+
+// GetArticleContentStats returns how many article contents are cached and
+// their size before and after compression
+func (db *DB) GetArticleContentStats() (ArticleContentStats, error) {
+	db.WaitForReady()
+	var stats ArticleContentStats
+	err := db.QueryRow(`
+		SELECT COUNT(*),
+			COALESCE(SUM(CASE WHEN typeof(content) = 'blob' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(COALESCE(raw_size, length(CAST(content AS BLOB)))), 0),
+			COALESCE(SUM(length(CAST(content AS BLOB))), 0)
+		FROM article_contents
+	`).Scan(&stats.Count, &stats.Compressed, &stats.RawSize, &stats.StoredSize)
+	return stats, err
+}
+
+// CompressArticleContents compresses up to limit article contents cached before
+// compression and returns how many it checked; 0 once all are done
+func (db *DB) CompressArticleContents(limit int) (int64, error) {
+	db.WaitForReady()
+
+	rows, err := db.Query(`SELECT article_id, content FROM article_contents WHERE raw_size IS NULL LIMIT ?`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("get uncompressed article contents: %w", err)
+	}
+	type pending struct {
+		articleID int64
+		content   interface{}
+	}
+	var contents []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.articleID, &p.content); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		contents = append(contents, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, p := range contents {
+		content, err := decodeArticleContent(p.content)
+		if err != nil {
+			return 0, err
+		}
+		stored, err := encodeArticleContent(content)
+		if err != nil {
+			return 0, err
+		}
+		// Contents cached meanwhile have their size set already and stay as they are
+		_, err = db.Exec(`UPDATE article_contents SET content = ?, raw_size = ? WHERE article_id = ? AND raw_size IS NULL`,
+			stored, len(content), p.articleID)
+		if err != nil {
+			return 0, fmt.Errorf("compress content of article %d: %w", p.articleID, err)
+		}
+	}
+	return int64(len(contents)), nil
+}
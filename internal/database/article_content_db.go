@@ -3,6 +3,9 @@ package database
 import (
 	"database/sql"
 	"strings"
+
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils/urlutil"
 )
 
 // ArticleContent represents a cached article content entry
@@ -16,11 +19,11 @@ type ArticleContent struct {
 // GetArticleContent retrieves cached content for an article
 func (db *DB) GetArticleContent(articleID int64) (string, bool, error) {
 	db.WaitForReady()
-	var content string
+	var stored interface{}
 	err := db.QueryRow(
 		`SELECT content FROM article_contents WHERE article_id = ?`,
 		articleID,
-	).Scan(&content)
+	).Scan(&stored)
 
 	if err == sql.ErrNoRows {
 		return "", false, nil
@@ -28,16 +31,59 @@ func (db *DB) GetArticleContent(articleID int64) (string, bool, error) {
 	if err != nil {
 		return "", false, err
 	}
+	content, err := decodeArticleContent(stored)
+	if err != nil {
+		return "", false, err
+	}
 	return content, true, nil
 }
 
-// SetArticleContent stores or updates content for an article
+// GetCachedArticleByURL returns a feed's article with the given link and its cached content.
+// found is false when the article is unknown or has no cached content.
+func (db *DB) GetCachedArticleByURL(feedID int64, url string) (article models.Article, content string, found bool, err error) {
+	db.WaitForReady()
+	var publishedAt sql.NullTime
+	var author, uniqueID sql.NullString
+	var stored interface{}
+	err = db.QueryRow(`
+		SELECT a.id, a.title, a.published_at, a.author, a.unique_id, c.content
+		FROM articles a
+		JOIN article_contents c ON c.article_id = a.id
+		WHERE a.feed_id = ? AND a.url = ?
+		LIMIT 1
+	`, feedID, url).Scan(&article.ID, &article.Title, &publishedAt, &author, &uniqueID, &stored)
+	if err == sql.ErrNoRows {
+		return article, "", false, nil
+	}
+	if err != nil {
+		return article, "", false, err
+	}
+	if content, err = decodeArticleContent(stored); err != nil {
+		return article, "", false, err
+	}
+
+	article.FeedID = feedID
+	article.URL = url
+	article.Author = author.String
+	if publishedAt.Valid {
+		article.PublishedAt = publishedAt.Time
+		// The unique ID only includes the date when the article had a real published time
+		article.HasValidPublishedTime = uniqueID.String == urlutil.GenerateArticleUniqueID(article.Title, feedID, publishedAt.Time, true)
+	}
+	return article, content, true, nil
+}
+
+// SetArticleContent stores or updates content for an article, compressed when it pays off
 func (db *DB) SetArticleContent(articleID int64, content string) error {
 	db.WaitForReady()
-	_, err := db.Exec(
-		`INSERT OR REPLACE INTO article_contents (article_id, content, fetched_at)
-		 VALUES (?, ?, CURRENT_TIMESTAMP)`,
-		articleID, content,
+	stored, err := encodeArticleContent(content)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(
+		`INSERT OR REPLACE INTO article_contents (article_id, content, raw_size, fetched_at)
+		 VALUES (?, ?, ?, CURRENT_TIMESTAMP)`,
+		articleID, stored, len(content),
 	)
 	return err
 }
@@ -104,8 +150,12 @@ func (db *DB) GetArticleContentsBatch(articleIDs []int64) (map[int64]string, err
 	result := make(map[int64]string)
 	for rows.Next() {
 		var articleID int64
-		var content string
-		if err := rows.Scan(&articleID, &content); err != nil {
+		var stored interface{}
+		if err := rows.Scan(&articleID, &stored); err != nil {
+			return nil, err
+		}
+		content, err := decodeArticleContent(stored)
+		if err != nil {
 			return nil, err
 		}
 		result[articleID] = content
@@ -1,7 +1,11 @@
 package database
 
 import (
+	"strings"
 	"testing"
+	"time"
+
+	"MrRSS/internal/models"
 )
 
 func TestArticleContentCache(t *testing.T) {
@@ -191,3 +195,130 @@ func TestArticleContentCache(t *testing.T) {
 		}
 	})
 }
+
+func TestGetCachedArticleByURL(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.DB.Close()
+	if err := db.Init(); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	feedID, err := db.AddFeed(&models.Feed{Title: "Scraped", URL: "https://example.com/list", Type: "HTML+XPath"})
+	if err != nil {
+		t.Fatalf("AddFeed() error = %v", err)
+	}
+	published := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	articles := []*models.Article{
+		{FeedID: feedID, Title: "Dated", URL: "https://example.com/dated", Author: "Ann", PublishedAt: published, HasValidPublishedTime: true},
+		{FeedID: feedID, Title: "Undated", URL: "https://example.com/undated", PublishedAt: time.Now()},
+	}
+	for _, a := range articles {
+		if err := db.SaveArticle(a); err != nil {
+			t.Fatalf("SaveArticle() error = %v", err)
+		}
+		id, err := db.GetArticleIDByUniqueID(a.Title, feedID, a.PublishedAt, a.HasValidPublishedTime)
+		if err != nil {
+			t.Fatalf("GetArticleIDByUniqueID() error = %v", err)
+		}
+		if err := db.SetArticleContent(id, "<p>"+a.Title+"</p>"); err != nil {
+			t.Fatalf("SetArticleContent() error = %v", err)
+		}
+	}
+
+	article, content, found, err := db.GetCachedArticleByURL(feedID, "https://example.com/dated")
+	if err != nil || !found {
+		t.Fatalf("GetCachedArticleByURL() = %v, %v", found, err)
+	}
+	if content != "<p>Dated</p>" || article.Author != "Ann" {
+		t.Errorf("content = %q, author = %q", content, article.Author)
+	}
+	if !article.HasValidPublishedTime || !article.PublishedAt.Equal(published) {
+		t.Errorf("published = %v, valid = %v", article.PublishedAt, article.HasValidPublishedTime)
+	}
+
+	article, _, found, err = db.GetCachedArticleByURL(feedID, "https://example.com/undated")
+	if err != nil || !found {
+		t.Fatalf("GetCachedArticleByURL(undated) = %v, %v", found, err)
+	}
+	if article.HasValidPublishedTime {
+		t.Error("an article saved without a published time should not report one")
+	}
+
+	if _, _, found, _ := db.GetCachedArticleByURL(feedID+1, "https://example.com/dated"); found {
+		t.Error("articles of other feeds should not match")
+	}
+}
+
+func TestArticleContentCompression(t *testing.T) {
+	db, err := NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create test database: %v", err)
+	}
+	defer db.DB.Close()
+	if err := db.Init(); err != nil {
+		t.Fatalf("Failed to initialize database: %v", err)
+	}
+
+	long := strings.Repeat("<p>Quantum computing explained</p>", 100)
+	short := "<p>Short</p>"
+	if err := db.SetArticleContent(1, long); err != nil {
+		t.Fatalf("SetArticleContent: %v", err)
+	}
+	if err := db.SetArticleContent(2, short); err != nil {
+		t.Fatalf("SetArticleContent: %v", err)
+	}
+	// Contents cached before compression are plain text without a size
+	if _, err := db.Exec(`INSERT INTO article_contents (article_id, content) VALUES (3, ?)`, long); err != nil {
+		t.Fatalf("insert legacy content: %v", err)
+	}
+
+	var storedType string
+	db.QueryRow(`SELECT typeof(content) FROM article_contents WHERE article_id = 1`).Scan(&storedType)
+	if storedType != "blob" {
+		t.Errorf("Long content stored as %s, want compressed", storedType)
+	}
+	db.QueryRow(`SELECT typeof(content) FROM article_contents WHERE article_id = 2`).Scan(&storedType)
+	if storedType != "text" {
+		t.Errorf("Short content stored as %s, want text", storedType)
+	}
+
+	contents, err := db.GetArticleContentsBatch([]int64{1, 2, 3})
+	if err != nil {
+		t.Fatalf("GetArticleContentsBatch: %v", err)
+	}
+	if contents[1] != long || contents[2] != short || contents[3] != long {
+		t.Error("GetArticleContentsBatch did not return the contents as cached")
+	}
+
+	// Queries search compressed contents through content_text
+	var matches int
+	db.QueryRow(`SELECT COUNT(*) FROM article_contents WHERE content_text(content) LIKE '%Quantum%'`).Scan(&matches)
+	if matches != 2 {
+		t.Errorf("content_text matched %d contents, want 2", matches)
+	}
+
+	stats, err := db.GetArticleContentStats()
+	if err != nil {
+		t.Fatalf("GetArticleContentStats: %v", err)
+	}
+	if stats.Count != 3 || stats.Compressed != 1 || stats.RawSize != int64(2*len(long)+len(short)) || stats.StoredSize >= stats.RawSize-int64(len(long)/2) {
+		t.Errorf("Stats before migrating = %+v", stats)
+	}
+
+	// The background migration compresses the legacy content, then has nothing left
+	if n, err := db.CompressArticleContents(10); err != nil || n != 1 {
+		t.Fatalf("CompressArticleContents = %d, %v, want 1", n, err)
+	}
+	if n, _ := db.CompressArticleContents(10); n != 0 {
+		t.Errorf("CompressArticleContents checked %d contents again", n)
+	}
+	if content, found, err := db.GetArticleContent(3); err != nil || !found || content != long {
+		t.Errorf("Migrated content = %d bytes, %v, %v", len(content), found, err)
+	}
+	if stats, _ = db.GetArticleContentStats(); stats.Compressed != 2 || stats.RawSize != int64(2*len(long)+len(short)) {
+		t.Errorf("Stats after migrating = %+v", stats)
+	}
+}
@@ -161,6 +161,11 @@ func (db *DB) SearchArticlesWithSQL(query string) ([]models.Article, error) {
 	}
 	defer rows.Close()
 
+	return scanSearchResults(rows)
+}
+
+// scanSearchResults reads the articles of a search query with relevance scoring
+func scanSearchResults(rows *sql.Rows) ([]models.Article, error) {
 	var articles []models.Article
 	for rows.Next() {
 		var a models.Article
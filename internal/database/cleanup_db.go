@@ -7,37 +7,22 @@ import (
 )
 
 // CleanupOldArticles removes articles based on age and status.
-// - Articles older than configured days: delete except favorited or read later
+// - Articles and cached contents are deleted by the retention policies
+// - Feeds without a policy keep articles max_article_age_days
+// - Favorited and read later articles are always kept
+// - With archive_enabled, removed articles are moved to the archive
 // - Also checks database size against max_cache_size_mb setting
 func (db *DB) CleanupOldArticles() (int64, error) {
 	db.WaitForReady()
 
 	totalDeleted := int64(0)
 
-	// Step 1: Clean up by age (existing logic)
-	maxAgeDaysStr, err := db.GetSetting("max_article_age_days")
-	maxAgeDays := 30
-	if err == nil {
-		if days, err := strconv.Atoi(maxAgeDaysStr); err == nil && days > 0 {
-			maxAgeDays = days
-		}
-	}
-
-	cutoffDate := time.Now().AddDate(0, 0, -maxAgeDays)
-
-	// Delete articles older than configured age that are not favorited or in read later
-	result, err := db.Exec(`
-		DELETE FROM articles
-		WHERE published_at < ?
-		AND is_favorite = 0
-		AND is_read_later = 0
-	`, cutoffDate)
+	// Step 1: Clean up by retention policy
+	articles, _, err := db.ApplyRetention(true)
 	if err != nil {
 		return 0, err
 	}
-
-	count, _ := result.RowsAffected()
-	totalDeleted += count
+	totalDeleted += articles
 
 	// Step 2: Check database size and clean up if over limit
 	sizeDeleted, err := db.CleanupBySize()
@@ -47,9 +32,8 @@ func (db *DB) CleanupOldArticles() (int64, error) {
 		totalDeleted += sizeDeleted
 	}
 
-	// Also cleanup related caches with the same age limit
-	_, _ = db.CleanupTranslationCache(maxAgeDays)
-	_, _ = db.CleanupOldArticleContents(maxAgeDays)
+	// Also cleanup the translation cache with the default age limit
+	_, _ = db.CleanupTranslationCache(db.defaultRetentionPolicy().Value)
 
 	// Run VACUUM to reclaim space
 	_, _ = db.Exec("VACUUM")
@@ -82,9 +66,8 @@ func (db *DB) DeleteAllArticles() (int64, error) {
 func (db *DB) CleanupUnimportantArticles() (int64, error) {
 	db.WaitForReady()
 
-	result, err := db.Exec(`
-		DELETE FROM articles
-		WHERE is_read = 0
+	count, _, err := db.removeArticles(`
+		is_read = 0
 		AND is_favorite = 0
 		AND is_read_later = 0
 	`)
@@ -92,8 +75,6 @@ func (db *DB) CleanupUnimportantArticles() (int64, error) {
 		return 0, err
 	}
 
-	count, _ := result.RowsAffected()
-
 	// Also cleanup related caches (remove entries older than 7 days)
 	_, _ = db.CleanupTranslationCache(7)
 	_, _ = db.CleanupOldArticleContents(7)
@@ -151,7 +132,7 @@ func (db *DB) ShouldCleanupBeforeSave() (bool, error) {
 }
 
 // CleanupBySize removes oldest articles to keep database under max_cache_size_mb limit.
-// Protects favorited and read later articles.
+// Protects favorited and read later articles, and feeds kept forever by their retention policy.
 // Uses priority order: oldest read articles first, then older unread articles.
 func (db *DB) CleanupBySize() (int64, error) {
 	db.WaitForReady()
@@ -180,16 +161,19 @@ func (db *DB) CleanupBySize() (int64, error) {
 
 	totalDeleted := int64(0)
 	targetSizeMB := float64(maxSizeMB) * 0.95 // Aim for 95% of limit
+	retained, err := db.retainedFeedsCondition()
+	if err != nil {
+		return 0, err
+	}
 
 	// Step 1: Delete oldest read articles (not favorited, not read later)
 	for currentSizeMB > targetSizeMB {
-		result, err := db.Exec(`
-			DELETE FROM articles
-			WHERE id IN (
+		count, _, err := db.removeArticles(`
+			id IN (
 				SELECT id FROM articles
 				WHERE is_read = 1
 				AND is_favorite = 0
-				AND is_read_later = 0
+				AND is_read_later = 0` + retained + `
 				ORDER BY published_at ASC
 				LIMIT 100
 			)
@@ -198,7 +182,6 @@ func (db *DB) CleanupBySize() (int64, error) {
 			break
 		}
 
-		count, _ := result.RowsAffected()
 		if count == 0 {
 			break // No more read articles to delete
 		}
@@ -210,12 +193,11 @@ func (db *DB) CleanupBySize() (int64, error) {
 
 	// Step 2: If still over limit, delete oldest unread articles (not favorited, not read later)
 	for currentSizeMB > targetSizeMB {
-		result, err := db.Exec(`
-			DELETE FROM articles
-			WHERE id IN (
+		count, _, err := db.removeArticles(`
+			id IN (
 				SELECT id FROM articles
 				WHERE is_favorite = 0
-				AND is_read_later = 0
+				AND is_read_later = 0` + retained + `
 				ORDER BY published_at ASC
 				LIMIT 100
 			)
@@ -224,7 +206,6 @@ func (db *DB) CleanupBySize() (int64, error) {
 			break
 		}
 
-		count, _ := result.RowsAffected()
 		if count == 0 {
 			break // No more articles to delete
 		}
@@ -330,15 +311,13 @@ func (db *DB) CleanupOldArticlesLayered() (int64, error) {
 
 	// Layer 1: Delete very old read articles (maxAgeDays)
 	cutoffDate := time.Now().AddDate(0, 0, -maxAgeDays)
-	result, err := db.Exec(`
-		DELETE FROM articles
-		WHERE published_at < ?
+	count, _, err := db.removeArticles(`
+		published_at < ?
 		AND is_read = 1
 		AND is_favorite = 0
 		AND is_read_later = 0
 	`, cutoffDate)
 	if err == nil {
-		count, _ := result.RowsAffected()
 		totalDeleted += count
 		if count > 0 {
 			log.Printf("Layer 1: Deleted %d read articles older than %d days", count, maxAgeDays)
@@ -347,15 +326,13 @@ func (db *DB) CleanupOldArticlesLayered() (int64, error) {
 
 	// Layer 2: Delete old read articles (14 days)
 	cutoffDate = time.Now().AddDate(0, 0, -14)
-	result, err = db.Exec(`
-		DELETE FROM articles
-		WHERE published_at < ?
+	count, _, err = db.removeArticles(`
+		published_at < ?
 		AND is_read = 1
 		AND is_favorite = 0
 		AND is_read_later = 0
 	`, cutoffDate)
 	if err == nil {
-		count, _ := result.RowsAffected()
 		totalDeleted += count
 		if count > 0 {
 			log.Printf("Layer 2: Deleted %d read articles older than 14 days", count)
@@ -364,15 +341,13 @@ func (db *DB) CleanupOldArticlesLayered() (int64, error) {
 
 	// Layer 3: Delete very old unread articles (90 days)
 	cutoffDate = time.Now().AddDate(0, 0, -90)
-	result, err = db.Exec(`
-		DELETE FROM articles
-		WHERE published_at < ?
+	count, _, err = db.removeArticles(`
+		published_at < ?
 		AND is_read = 0
 		AND is_favorite = 0
 		AND is_read_later = 0
 	`, cutoffDate)
 	if err == nil {
-		count, _ := result.RowsAffected()
 		totalDeleted += count
 		if count > 0 {
 			log.Printf("Layer 3: Deleted %d unread articles older than 90 days", count)
@@ -381,15 +356,13 @@ func (db *DB) CleanupOldArticlesLayered() (int64, error) {
 
 	// Layer 4: Delete old unread articles (60 days)
 	cutoffDate = time.Now().AddDate(0, 0, -60)
-	result, err = db.Exec(`
-		DELETE FROM articles
-		WHERE published_at < ?
+	count, _, err = db.removeArticles(`
+		published_at < ?
 		AND is_read = 0
 		AND is_favorite = 0
 		AND is_read_later = 0
 	`, cutoffDate)
 	if err == nil {
-		count, _ := result.RowsAffected()
 		totalDeleted += count
 		if count > 0 {
 			log.Printf("Layer 4: Deleted %d unread articles older than 60 days", count)
@@ -405,43 +378,41 @@ func (db *DB) CleanupOldArticlesLayered() (int64, error) {
 }
 
 // CleanupOldReadArticles removes read articles older than specified days
-// Protects favorited and read later articles
+// Protects favorited and read later articles, and feeds kept forever by their retention policy
 func (db *DB) CleanupOldReadArticles(maxAgeDays int) (int64, error) {
 	db.WaitForReady()
 
-	cutoffDate := time.Now().AddDate(0, 0, -maxAgeDays)
-	result, err := db.Exec(`
-		DELETE FROM articles
-		WHERE published_at < ?
-		AND is_read = 1
-		AND is_favorite = 0
-		AND is_read_later = 0
-	`, cutoffDate)
+	retained, err := db.retainedFeedsCondition()
 	if err != nil {
 		return 0, err
 	}
 
-	count, _ := result.RowsAffected()
-	return count, nil
+	cutoffDate := time.Now().AddDate(0, 0, -maxAgeDays)
+	query := `
+		published_at < ?
+		AND is_read = 1
+		AND is_favorite = 0
+		AND is_read_later = 0` + retained
+	count, _, err := db.removeArticles(query, cutoffDate)
+	return count, err
 }
 
 // CleanupOldUnreadArticles removes unread articles older than specified days
-// Protects favorited and read later articles
+// Protects favorited and read later articles, and feeds kept forever by their retention policy
 func (db *DB) CleanupOldUnreadArticles(maxAgeDays int) (int64, error) {
 	db.WaitForReady()
 
-	cutoffDate := time.Now().AddDate(0, 0, -maxAgeDays)
-	result, err := db.Exec(`
-		DELETE FROM articles
-		WHERE published_at < ?
-		AND is_read = 0
-		AND is_favorite = 0
-		AND is_read_later = 0
-	`, cutoffDate)
+	retained, err := db.retainedFeedsCondition()
 	if err != nil {
 		return 0, err
 	}
 
-	count, _ := result.RowsAffected()
-	return count, nil
+	cutoffDate := time.Now().AddDate(0, 0, -maxAgeDays)
+	query := `
+		published_at < ?
+		AND is_read = 0
+		AND is_favorite = 0
+		AND is_read_later = 0` + retained
+	count, _, err := db.removeArticles(query, cutoffDate)
+	return count, err
 }
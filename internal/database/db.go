@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +15,9 @@ type DB struct {
 	*sql.DB
 	ready chan struct{}
 	once  sync.Once
+	// secrets is held for writing by a re-key and for reading while a secret
+	// is encrypted and stored, so no secret is stored under a discarded key
+	secrets sync.RWMutex
 }
 
 // NewDB creates a new database connection with optimized settings.
@@ -21,10 +25,11 @@ func NewDB(dataSourceName string) (*DB, error) {
 	// Add busy_timeout to prevent "database is locked" errors
 	// Also enable WAL mode for better concurrency
 	// Add performance optimizations: increase cache size, set synchronous=NORMAL
+	// Truncate the WAL to 64 MB after checkpoints, as external readers can hold them back
 	if !strings.Contains(dataSourceName, "?") {
-		dataSourceName += "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=cache_size(-32000)&_pragma=synchronous(NORMAL)"
+		dataSourceName += "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=cache_size(-32000)&_pragma=synchronous(NORMAL)&_pragma=journal_size_limit(67108864)"
 	} else {
-		dataSourceName += "&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=cache_size(-32000)&_pragma=synchronous(NORMAL)"
+		dataSourceName += "&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=cache_size(-32000)&_pragma=synchronous(NORMAL)&_pragma=journal_size_limit(67108864)"
 	}
 
 	db, err := sql.Open("sqlite", dataSourceName)
@@ -43,6 +48,16 @@ func NewDB(dataSourceName string) (*DB, error) {
 	}, nil
 }
 
+// filePath returns the path of the database file, empty for an in-memory database
+func (db *DB) filePath() (string, error) {
+	var seq int
+	var name, file string
+	if err := db.QueryRow(`PRAGMA database_list`).Scan(&seq, &name, &file); err != nil {
+		return "", fmt.Errorf("get database file: %w", err)
+	}
+	return file, nil
+}
+
 // WaitForReady blocks until the database is initialized.
 func (db *DB) WaitForReady() {
 	<-db.ready
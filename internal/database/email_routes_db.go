@@ -0,0 +1,109 @@
+package database
+
+import (
+	"database/sql"
+
+	"MrRSS/internal/models"
+)
+
+// GetEmailRoutes returns the routing rules of a mailbox account in the order they are tried.
+func (db *DB) GetEmailRoutes(accountFeedID int64) ([]models.EmailRoute, error) {
+	db.WaitForReady()
+	rows, err := db.Query(`
+		SELECT id, account_feed_id, feed_id, match_type, pattern, COALESCE(position, 0)
+		FROM email_routes
+		WHERE account_feed_id = ?
+		ORDER BY position ASC, id ASC
+	`, accountFeedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	routes := []models.EmailRoute{}
+	for rows.Next() {
+		var r models.EmailRoute
+		if err := rows.Scan(&r.ID, &r.AccountFeedID, &r.FeedID, &r.MatchType, &r.Pattern, &r.Position); err != nil {
+			return nil, err
+		}
+		routes = append(routes, r)
+	}
+	return routes, rows.Err()
+}
+
+// AddEmailRoute adds a routing rule. A zero position appends it after the account's other rules.
+func (db *DB) AddEmailRoute(route *models.EmailRoute) (int64, error) {
+	db.WaitForReady()
+	if route.Position == 0 {
+		var maxPos sql.NullInt64
+		if err := db.QueryRow("SELECT MAX(position) FROM email_routes WHERE account_feed_id = ?", route.AccountFeedID).Scan(&maxPos); err != nil {
+			return 0, err
+		}
+		route.Position = int(maxPos.Int64) + 1
+	}
+
+	result, err := db.Exec(`INSERT INTO email_routes (account_feed_id, feed_id, match_type, pattern, position) VALUES (?, ?, ?, ?, ?)`,
+		route.AccountFeedID, route.FeedID, route.MatchType, route.Pattern, route.Position)
+	if err != nil {
+		return 0, err
+	}
+	route.ID, err = result.LastInsertId()
+	return route.ID, err
+}
+
+// DeleteEmailRoute removes a routing rule. The feed it routed to is kept.
+func (db *DB) DeleteEmailRoute(id int64) error {
+	db.WaitForReady()
+	_, err := db.Exec("DELETE FROM email_routes WHERE id = ?", id)
+	return err
+}
+
+// GetEmailRouting returns the routing options of a mailbox account, or the defaults if none are stored.
+func (db *DB) GetEmailRouting(accountFeedID int64) (models.EmailRouting, error) {
+	db.WaitForReady()
+	routing := models.EmailRouting{AccountFeedID: accountFeedID}
+	err := db.QueryRow(`
+		SELECT COALESCE(auto_create_feeds, 0), COALESCE(processed_action, ''), COALESCE(processed_folder, '')
+		FROM email_routing WHERE account_feed_id = ?
+	`, accountFeedID).Scan(&routing.AutoCreateFeeds, &routing.ProcessedAction, &routing.ProcessedFolder)
+	if err == sql.ErrNoRows {
+		return routing, nil
+	}
+	return routing, err
+}
+
+// SaveEmailRouting stores the routing options of a mailbox account.
+func (db *DB) SaveEmailRouting(routing models.EmailRouting) error {
+	db.WaitForReady()
+	_, err := db.Exec(`
+		INSERT INTO email_routing (account_feed_id, auto_create_feeds, processed_action, processed_folder)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(account_feed_id) DO UPDATE SET
+			auto_create_feeds = excluded.auto_create_feeds,
+			processed_action = excluded.processed_action,
+			processed_folder = excluded.processed_folder
+	`, routing.AccountFeedID, routing.AutoCreateFeeds, routing.ProcessedAction, routing.ProcessedFolder)
+	return err
+}
+
+// SetFeedEmailAccount makes a feed receive its newsletters from another feed's mailbox.
+func (db *DB) SetFeedEmailAccount(feedID, accountFeedID int64) error {
+	db.WaitForReady()
+	_, err := db.Exec("UPDATE feeds SET email_account_id = ? WHERE id = ?", accountFeedID, feedID)
+	return err
+}
+
+// SetFeedInboundToken gives a feed the token of its generated newsletter address.
+func (db *DB) SetFeedInboundToken(feedID int64, token string) error {
+	db.WaitForReady()
+	_, err := db.Exec("UPDATE feeds SET email_inbound_token = ? WHERE id = ?", token, feedID)
+	return err
+}
+
+// GetFeedIDByInboundToken returns the feed that receives mail for a generated address token.
+func (db *DB) GetFeedIDByInboundToken(token string) (int64, error) {
+	db.WaitForReady()
+	var id int64
+	err := db.QueryRow("SELECT id FROM feeds WHERE email_inbound_token = ? AND email_inbound_token != ''", token).Scan(&id)
+	return id, err
+}
@@ -0,0 +1,447 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"MrRSS/internal/crypto"
+)
+
+// Encryption modes
+const (
+	// EncryptionModeMachine encrypts secrets with a key derived from the machine ID (default)
+	EncryptionModeMachine = "machine"
+	// EncryptionModePassphrase encrypts secrets with a data key wrapped by a user passphrase
+	EncryptionModePassphrase = "passphrase"
+	// EncryptionModeKeyFile encrypts secrets with a data key wrapped by the content of a key file
+	EncryptionModeKeyFile = "keyfile"
+)
+
+// Keyring slots
+const (
+	// keySlotMaster holds the data key wrapped by the passphrase or key file
+	keySlotMaster = "master"
+	// keySlotMachine holds the data key wrapped by the machine ID, so this machine unlocks without prompting
+	keySlotMachine = "machine"
+)
+
+// EncryptionStatus describes how secrets are encrypted
+type EncryptionStatus struct {
+	Mode              string `json:"mode"`
+	Locked            bool   `json:"locked"`
+	KeyFile           string `json:"key_file,omitempty"`
+	RememberOnMachine bool   `json:"remember_on_machine"`
+}
+
+// RekeyOptions selects the new encryption mode for RekeyEncryption
+type RekeyOptions struct {
+	Mode       string
+	Passphrase string
+	KeyFile    string
+	// RememberOnMachine also wraps the data key with the machine ID
+	RememberOnMachine bool
+}
+
+// RekeyResult reports a re-key migration
+type RekeyResult struct {
+	Migrated int `json:"migrated"`
+	// Unreadable lists values that could not be decrypted and were left unchanged
+	Unreadable []string `json:"unreadable"`
+}
+
+// encryptedValue is a stored secret found during re-keying
+type encryptedValue struct {
+	name   string
+	query  string
+	id     interface{}
+	plain  string
+	sealed string
+}
+
+// initEncryptionKeyring creates the table of wrapped data keys
+func initEncryptionKeyring(db *sql.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS encryption_keyring (
+		slot TEXT PRIMARY KEY,
+		mode TEXT NOT NULL,
+		wrapped_key TEXT NOT NULL,
+		key_file TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	return err
+}
+
+// getKeySlot returns the mode, wrapped key and key file of a keyring slot
+func (db *DB) getKeySlot(slot string) (mode, wrapped, keyFile string, err error) {
+	err = db.QueryRow("SELECT mode, wrapped_key, COALESCE(key_file, '') FROM encryption_keyring WHERE slot = ?", slot).
+		Scan(&mode, &wrapped, &keyFile)
+	return
+}
+
+// GetEncryptionStatus returns the current encryption mode and whether its key is unlocked
+func (db *DB) GetEncryptionStatus() (*EncryptionStatus, error) {
+	db.WaitForReady()
+
+	mode, _, keyFile, err := db.getKeySlot(keySlotMaster)
+	if err == sql.ErrNoRows {
+		return &EncryptionStatus{Mode: EncryptionModeMachine}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read encryption keyring: %w", err)
+	}
+
+	var count int
+	_ = db.QueryRow("SELECT COUNT(*) FROM encryption_keyring WHERE slot = ?", keySlotMachine).Scan(&count)
+
+	return &EncryptionStatus{
+		Mode:              mode,
+		Locked:            !crypto.HasDataKey(),
+		KeyFile:           keyFile,
+		RememberOnMachine: count > 0,
+	}, nil
+}
+
+// unlockEncryption unlocks the data key at startup without user interaction.
+// It tries MRRSS_MASTER_KEY / MRRSS_MASTER_KEY_FILE, the configured key file and the machine slot.
+// If none works the key stays locked until UnlockEncryption is called with the passphrase.
+// Without a keyring, a master key in the environment turns on portable encryption.
+func (db *DB) unlockEncryption() {
+	crypto.ClearDataKey()
+
+	secret, err := crypto.MasterSecretFromEnv()
+	if err != nil {
+		log.Printf("Failed to read master key from environment: %v", err)
+	}
+
+	mode, wrapped, keyFile, err := db.getKeySlot(keySlotMaster)
+	if err == sql.ErrNoRows {
+		if secret != "" {
+			db.enableEnvironmentEncryption(secret)
+		}
+		return
+	}
+	// From here on secrets must not be written machine-bound, even while the key is locked
+	crypto.SetKeyringConfigured()
+	if err != nil {
+		log.Printf("Failed to read encryption keyring: %v", err)
+		return
+	}
+
+	if secret == "" && keyFile != "" {
+		if secret, err = crypto.ReadKeyFile(keyFile); err != nil {
+			log.Printf("Failed to read master key file: %v", err)
+		}
+	}
+	if secret != "" {
+		if key, err := crypto.UnwrapDataKey(wrapped, secret); err == nil {
+			crypto.SetDataKey(key)
+			return
+		}
+		log.Printf("Configured master key does not unlock the %s key", mode)
+	}
+
+	if _, machineWrapped, _, err := db.getKeySlot(keySlotMachine); err == nil {
+		machineID, err := crypto.GetMachineID()
+		if err == nil {
+			if key, err := crypto.UnwrapDataKey(machineWrapped, machineID); err == nil {
+				crypto.SetDataKey(key)
+				return
+			}
+		}
+		log.Printf("Machine ID changed, the %s key must be unlocked again", mode)
+	}
+
+	log.Printf("Encryption key is locked, encrypted settings are unavailable until it is unlocked")
+}
+
+// enableEnvironmentEncryption re-keys the secrets of a database in machine mode to the master key
+// of MRRSS_MASTER_KEY, or MRRSS_MASTER_KEY_FILE in key file mode, so the database is portable
+func (db *DB) enableEnvironmentEncryption(secret string) {
+	opts := RekeyOptions{Mode: EncryptionModePassphrase, Passphrase: secret}
+	if os.Getenv(crypto.MasterKeyEnv) == "" {
+		opts = RekeyOptions{Mode: EncryptionModeKeyFile, KeyFile: os.Getenv(crypto.MasterKeyFileEnv)}
+	}
+	if _, err := db.rekeyEncryption(opts); err != nil {
+		log.Printf("Failed to encrypt secrets with the master key from the environment: %v", err)
+	}
+}
+
+// UnlockEncryption unlocks the data key with a passphrase or key file content
+func (db *DB) UnlockEncryption(secret string) error {
+	db.WaitForReady()
+
+	_, wrapped, _, err := db.getKeySlot(keySlotMaster)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read encryption keyring: %w", err)
+	}
+
+	key, err := crypto.UnwrapDataKey(wrapped, secret)
+	if err != nil {
+		return err
+	}
+	crypto.SetDataKey(key)
+	return nil
+}
+
+// RekeyEncryption switches the encryption mode and re-encrypts every stored secret:
+// encrypted settings, AI profile API keys, feed email credentials and JSONPath request headers.
+// Values that cannot be decrypted with the current key are left unchanged and reported.
+func (db *DB) RekeyEncryption(opts RekeyOptions) (*RekeyResult, error) {
+	db.WaitForReady()
+	return db.rekeyEncryption(opts)
+}
+
+// rekeyEncryption is RekeyEncryption without waiting for initialization, for use during Init
+func (db *DB) rekeyEncryption(opts RekeyOptions) (*RekeyResult, error) {
+	// Secrets encrypted with the old key must not be stored once the new one is in use
+	db.secrets.Lock()
+	defer db.secrets.Unlock()
+
+	if crypto.IsLocked() {
+		return nil, crypto.ErrLocked
+	}
+
+	var err error
+	secret := ""
+	switch opts.Mode {
+	case EncryptionModeMachine:
+	case EncryptionModePassphrase:
+		secret = opts.Passphrase
+		if secret == "" {
+			return nil, fmt.Errorf("passphrase is required")
+		}
+	case EncryptionModeKeyFile:
+		if opts.KeyFile == "" {
+			return nil, fmt.Errorf("key file is required")
+		}
+		if secret, err = crypto.ReadKeyFile(opts.KeyFile); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown encryption mode: %s", opts.Mode)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Clearing the keyring first takes the write lock, so no secret changes between
+	// reading and rewriting them. A failure rolls everything back.
+	if _, err := tx.Exec("DELETE FROM encryption_keyring"); err != nil {
+		return nil, fmt.Errorf("clear encryption keyring: %w", err)
+	}
+
+	values, unreadable, err := collectSecrets(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var newKey []byte
+	if opts.Mode != EncryptionModeMachine {
+		if newKey, err = crypto.GenerateDataKey(); err != nil {
+			return nil, err
+		}
+	}
+	for i := range values {
+		if newKey != nil {
+			values[i].sealed, err = crypto.EncryptWithDataKey(values[i].plain, newKey)
+		} else {
+			values[i].sealed, err = crypto.EncryptWithMachineID(values[i].plain)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("encrypt %s: %w", values[i].name, err)
+		}
+	}
+
+	for _, v := range values {
+		if _, err := tx.Exec(v.query, v.sealed, v.id); err != nil {
+			return nil, fmt.Errorf("store %s: %w", v.name, err)
+		}
+	}
+
+	if newKey != nil {
+		wrapped, err := crypto.WrapDataKey(newKey, secret)
+		if err != nil {
+			return nil, err
+		}
+		keyFile := ""
+		if opts.Mode == EncryptionModeKeyFile {
+			keyFile = opts.KeyFile
+		}
+		if _, err := tx.Exec("INSERT INTO encryption_keyring (slot, mode, wrapped_key, key_file) VALUES (?, ?, ?, ?)",
+			keySlotMaster, opts.Mode, wrapped, keyFile); err != nil {
+			return nil, fmt.Errorf("store wrapped key: %w", err)
+		}
+
+		if opts.RememberOnMachine {
+			machineID, err := crypto.GetMachineID()
+			if err != nil {
+				return nil, fmt.Errorf("get machine ID: %w", err)
+			}
+			machineWrapped, err := crypto.WrapDataKey(newKey, machineID)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := tx.Exec("INSERT INTO encryption_keyring (slot, mode, wrapped_key) VALUES (?, ?, ?)",
+				keySlotMachine, EncryptionModeMachine, machineWrapped); err != nil {
+				return nil, fmt.Errorf("store machine key: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit re-key: %w", err)
+	}
+
+	if newKey != nil {
+		crypto.SetDataKey(newKey)
+	} else {
+		crypto.ClearDataKey()
+	}
+
+	log.Printf("Re-keyed %d secrets to %s mode (%d unreadable)", len(values), opts.Mode, len(unreadable))
+	return &RekeyResult{Migrated: len(values), Unreadable: unreadable}, nil
+}
+
+// collectSecrets decrypts every stored secret with the current key, within the re-key transaction
+func collectSecrets(tx *sql.Tx) ([]encryptedValue, []string, error) {
+	var values []encryptedValue
+	unreadable := []string{}
+
+	add := func(name, query string, id interface{}, stored string, plainAllowed bool) {
+		if stored == "" {
+			return
+		}
+		plain := stored
+		if crypto.IsEncrypted(stored) {
+			decrypted, err := crypto.Decrypt(stored)
+			if err != nil {
+				unreadable = append(unreadable, name)
+				return
+			}
+			plain = decrypted
+		} else if !plainAllowed {
+			return
+		}
+		values = append(values, encryptedValue{name: name, query: query, id: id, plain: plain})
+	}
+
+	rows, err := tx.Query("SELECT key, value FROM settings")
+	if err != nil {
+		return nil, nil, fmt.Errorf("read settings: %w", err)
+	}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("scan setting: %w", err)
+		}
+		add(key, "UPDATE settings SET value = ? WHERE key = ?", key, value, false)
+	}
+	rows.Close()
+
+	rows, err = tx.Query("SELECT id, api_key FROM ai_profiles WHERE api_key != ''")
+	if err != nil {
+		return nil, nil, fmt.Errorf("read AI profiles: %w", err)
+	}
+	for rows.Next() {
+		var id int64
+		var apiKey string
+		if err := rows.Scan(&id, &apiKey); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("scan AI profile: %w", err)
+		}
+		add(fmt.Sprintf("ai_profile:%d", id), "UPDATE ai_profiles SET api_key = ? WHERE id = ?", id, apiKey, true)
+	}
+	rows.Close()
+
+	// Email passwords from before encryption was added are plain text and get encrypted here
+	rows, err = tx.Query("SELECT id, email_password FROM feeds WHERE COALESCE(email_password, '') != ''")
+	if err != nil {
+		return nil, nil, fmt.Errorf("read feed passwords: %w", err)
+	}
+	for rows.Next() {
+		var id int64
+		var password string
+		if err := rows.Scan(&id, &password); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("scan feed password: %w", err)
+		}
+		add(fmt.Sprintf("feed:%d", id), "UPDATE feeds SET email_password = ? WHERE id = ?", id, password, true)
+	}
+	rows.Close()
+
+	// JSONPath request headers from before they were encrypted are plain text and get encrypted here
+	rows, err = tx.Query("SELECT id, json_headers FROM feeds WHERE COALESCE(json_headers, '') != ''")
+	if err != nil {
+		return nil, nil, fmt.Errorf("read feed headers: %w", err)
+	}
+	for rows.Next() {
+		var id int64
+		var headers string
+		if err := rows.Scan(&id, &headers); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("scan feed headers: %w", err)
+		}
+		add(fmt.Sprintf("feed_json_headers:%d", id), "UPDATE feeds SET json_headers = ? WHERE id = ?", id, headers, true)
+	}
+	rows.Close()
+
+	// OAuth secrets for IMAP login are always stored encrypted
+	for _, column := range []string{"email_oauth_client_secret", "email_oauth_refresh_token"} {
+		rows, err = tx.Query("SELECT id, " + column + " FROM feeds WHERE COALESCE(" + column + ", '') != ''")
+		if err != nil {
+			return nil, nil, fmt.Errorf("read feed %s: %w", column, err)
+		}
+		for rows.Next() {
+			var id int64
+			var secret string
+			if err := rows.Scan(&id, &secret); err != nil {
+				rows.Close()
+				return nil, nil, fmt.Errorf("scan feed %s: %w", column, err)
+			}
+			add(fmt.Sprintf("feed_%s:%d", column, id), "UPDATE feeds SET "+column+" = ? WHERE id = ?", id, secret, false)
+		}
+		rows.Close()
+	}
+
+	return values, unreadable, nil
+}
+
+// HoldSecrets keeps a re-key from starting until release is called.
+// Hold it from encrypting secrets until they are stored.
+func (db *DB) HoldSecrets() (release func()) {
+	db.secrets.RLock()
+	return db.secrets.RUnlock
+}
+
+// encryptFeedSecret encrypts a feed secret such as the email password before it is stored
+func encryptFeedSecret(value string) (string, error) {
+	encrypted, err := crypto.Encrypt(value)
+	if err != nil {
+		return "", fmt.Errorf("encrypt feed secret: %w", err)
+	}
+	return encrypted, nil
+}
+
+// decryptFeedSecret decrypts a stored feed secret. Plain text values from older versions are returned as is.
+func decryptFeedSecret(stored string) string {
+	if !crypto.IsEncrypted(stored) {
+		return stored
+	}
+	decrypted, err := crypto.Decrypt(stored)
+	if err != nil {
+		if !errors.Is(err, crypto.ErrLocked) {
+			log.Printf("Failed to decrypt feed secret: %v", err)
+		}
+		return ""
+	}
+	return decrypted
+}
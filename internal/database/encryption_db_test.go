@@ -0,0 +1,184 @@
+package database_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"MrRSS/internal/crypto"
+	dbpkg "MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func TestRekeyEncryption(t *testing.T) {
+	db := setupTestDB(t)
+	defer crypto.ClearDataKey()
+
+	if err := db.SetEncryptedSetting("deepl_api_key", "deepl-secret"); err != nil {
+		t.Fatalf("SetEncryptedSetting() error = %v", err)
+	}
+	feedID, err := db.AddFeed(&models.Feed{Title: "Mail", URL: "email://me@example.com", EmailPassword: "imap-secret"})
+	if err != nil {
+		t.Fatalf("AddFeed() error = %v", err)
+	}
+	apiID, err := db.AddFeed(&models.Feed{Title: "API", URL: "https://api.example.com/items"})
+	if err != nil {
+		t.Fatalf("AddFeed() error = %v", err)
+	}
+	headers := "Authorization: Bearer api-token"
+	if err := db.UpdateFeedWithOptions(apiID, dbpkg.FeedUpdateOptions{JSONHeaders: &headers}); err != nil {
+		t.Fatalf("UpdateFeedWithOptions() error = %v", err)
+	}
+	var storedHeaders string
+	_ = db.QueryRow("SELECT json_headers FROM feeds WHERE id = ?", apiID).Scan(&storedHeaders)
+	if !crypto.IsEncrypted(storedHeaders) {
+		t.Fatalf("request headers stored as %q, want them encrypted", storedHeaders)
+	}
+
+	result, err := db.RekeyEncryption(dbpkg.RekeyOptions{Mode: dbpkg.EncryptionModePassphrase, Passphrase: "hunter2"})
+	if err != nil {
+		t.Fatalf("RekeyEncryption() error = %v", err)
+	}
+	if result.Migrated != 3 || len(result.Unreadable) != 0 {
+		t.Fatalf("RekeyEncryption() = %+v, want 3 migrated", result)
+	}
+
+	stored, _ := db.GetSetting("deepl_api_key")
+	if !crypto.IsEncrypted(stored) || crypto.IsMachineBound(stored) {
+		t.Fatalf("setting after re-key = %q, want data key value", stored)
+	}
+	var storedPassword string
+	_ = db.QueryRow("SELECT email_password FROM feeds WHERE id = ?", feedID).Scan(&storedPassword)
+	if !crypto.IsEncrypted(storedPassword) || crypto.IsMachineBound(storedPassword) {
+		t.Fatalf("email password after re-key = %q, want data key value", storedPassword)
+	}
+
+	// A restart without the passphrase leaves the key locked until it is unlocked
+	crypto.ClearDataKey()
+	status, err := db.GetEncryptionStatus()
+	if err != nil || status.Mode != dbpkg.EncryptionModePassphrase || !status.Locked {
+		t.Fatalf("GetEncryptionStatus() = %+v, %v; want locked passphrase mode", status, err)
+	}
+	if err := db.UnlockEncryption("wrong"); err == nil {
+		t.Fatal("UnlockEncryption() with wrong passphrase error = nil")
+	}
+	if err := db.UnlockEncryption("hunter2"); err != nil {
+		t.Fatalf("UnlockEncryption() error = %v", err)
+	}
+
+	value, err := db.GetEncryptedSetting("deepl_api_key")
+	if err != nil || value != "deepl-secret" {
+		t.Fatalf("GetEncryptedSetting() = %q, %v; want deepl-secret", value, err)
+	}
+	feed, err := db.GetFeedByID(feedID)
+	if err != nil || feed.EmailPassword != "imap-secret" {
+		t.Fatalf("GetFeedByID() password = %q, %v; want imap-secret", feed.EmailPassword, err)
+	}
+	feed, err = db.GetFeedByID(apiID)
+	if err != nil || feed.JSONHeaders != headers {
+		t.Fatalf("GetFeedByID() headers = %q, %v; want %q", feed.JSONHeaders, err, headers)
+	}
+
+	// Switching back to machine mode drops the keyring
+	if _, err := db.RekeyEncryption(dbpkg.RekeyOptions{Mode: dbpkg.EncryptionModeMachine}); err != nil {
+		t.Fatalf("RekeyEncryption(machine) error = %v", err)
+	}
+	stored, _ = db.GetSetting("deepl_api_key")
+	if !strings.HasPrefix(stored, "MrRSS-v1:") || crypto.HasDataKey() {
+		t.Fatalf("setting after re-key to machine = %q, want machine-bound value", stored)
+	}
+}
+
+func TestMasterKeyFromEnvironment(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rss.db")
+	open := func() *dbpkg.DB {
+		db, err := dbpkg.NewDB(path)
+		if err != nil {
+			t.Fatalf("NewDB() error = %v", err)
+		}
+		if err := db.Init(); err != nil {
+			t.Fatalf("Init() error = %v", err)
+		}
+		return db
+	}
+	defer crypto.ClearDataKey()
+
+	db := open()
+	if err := db.SetEncryptedSetting("deepl_api_key", "deepl-secret"); err != nil {
+		t.Fatalf("SetEncryptedSetting() error = %v", err)
+	}
+	db.Close()
+
+	// The master key alone turns on portable encryption
+	t.Setenv(crypto.MasterKeyEnv, "env-secret")
+	db = open()
+	status, err := db.GetEncryptionStatus()
+	if err != nil || status.Mode != dbpkg.EncryptionModePassphrase || status.Locked {
+		t.Fatalf("GetEncryptionStatus() = %+v, %v; want unlocked passphrase mode", status, err)
+	}
+	if stored, _ := db.GetSetting("deepl_api_key"); crypto.IsMachineBound(stored) {
+		t.Fatalf("setting with master key = %q, want data key value", stored)
+	}
+	db.Close()
+
+	// Without it the key is locked, and new secrets are not written machine-bound
+	t.Setenv(crypto.MasterKeyEnv, "")
+	db = open()
+	defer db.Close()
+	if err := db.SetEncryptedSetting("deepl_api_key", "other"); !errors.Is(err, crypto.ErrLocked) {
+		t.Fatalf("SetEncryptedSetting() while locked error = %v, want ErrLocked", err)
+	}
+	if err := db.UnlockEncryption("env-secret"); err != nil {
+		t.Fatalf("UnlockEncryption() error = %v", err)
+	}
+	if value, err := db.GetEncryptedSetting("deepl_api_key"); err != nil || value != "deepl-secret" {
+		t.Fatalf("GetEncryptedSetting() = %q, %v; want deepl-secret", value, err)
+	}
+}
+
+func TestRekeyEncryptionConcurrentWrites(t *testing.T) {
+	db, err := dbpkg.NewDB(filepath.Join(t.TempDir(), "rss.db"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	defer db.Close()
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	defer crypto.ClearDataKey()
+	if _, err := db.RekeyEncryption(dbpkg.RekeyOptions{Mode: dbpkg.EncryptionModePassphrase, Passphrase: "first"}); err != nil {
+		t.Fatalf("RekeyEncryption() error = %v", err)
+	}
+
+	// Secrets written while re-keying end up under the new key
+	stop, done := make(chan struct{}), make(chan struct{})
+	var last string
+	go func() {
+		defer close(done)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			last = fmt.Sprintf("secret-%d", i)
+			if err := db.SetEncryptedSetting("deepl_api_key", last); err != nil {
+				t.Errorf("SetEncryptedSetting() error = %v", err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 5; i++ {
+		if _, err := db.RekeyEncryption(dbpkg.RekeyOptions{Mode: dbpkg.EncryptionModePassphrase, Passphrase: fmt.Sprintf("key-%d", i)}); err != nil {
+			t.Errorf("RekeyEncryption() error = %v", err)
+		}
+	}
+	close(stop)
+	<-done
+
+	if value, err := db.GetEncryptedSetting("deepl_api_key"); err != nil || value != last {
+		t.Fatalf("GetEncryptedSetting() = %q, %v; want %q", value, err, last)
+	}
+}
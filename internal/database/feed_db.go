@@ -31,14 +31,42 @@ type FeedUpdateOptions struct {
 	XPathItemThumbnail  *string
 	XPathItemCategories *string
 	XPathItemUid        *string
-	ArticleViewMode     *string
-	AutoExpandContent   *string
-	EmailAddress        *string
-	EmailIMAPServer     *string
-	EmailUsername       *string
-	EmailPassword       *string
-	EmailFolder         *string
-	EmailIMAPPort       *int
+	// XPath pagination and detail pages
+	XPathNextPage        *string
+	XPathMaxPages        *int
+	XPathDetailContent   *string
+	XPathDetailAuthor    *string
+	XPathDetailTimestamp *string
+	// JSON API feeds
+	JSONPathItem           *string
+	JSONPathItemTitle      *string
+	JSONPathItemUri        *string
+	JSONPathItemContent    *string
+	JSONPathItemAuthor     *string
+	JSONPathItemTimestamp  *string
+	JSONPathItemTimeFormat *string
+	JSONPathItemThumbnail  *string
+	JSONPathItemUid        *string
+	JSONHeaders            *string
+	JSONPageParam          *string
+	JSONCursorPath         *string
+	JSONMaxPages           *int
+	FullTextOnIngest       *bool
+	ArticleViewMode        *string
+	AutoExpandContent      *string
+	EmailAddress           *string
+	EmailIMAPServer        *string
+	EmailUsername          *string
+	EmailPassword          *string
+	EmailFolder            *string
+	EmailIMAPPort          *int
+	// IMAP security and OAuth login
+	EmailSecurity          *string
+	EmailAuthMethod        *string
+	EmailOAuthTokenURL     *string
+	EmailOAuthClientID     *string
+	EmailOAuthClientSecret *string
+	EmailOAuthRefreshToken *string
 }
 
 // AddFeed adds a new feed or updates an existing one.
@@ -47,11 +75,25 @@ type FeedUpdateOptions struct {
 // so we check both url AND is_freshrss_source when looking for existing feeds.
 func (db *DB) AddFeed(feed *models.Feed) (int64, error) {
 	db.WaitForReady()
+	defer db.HoldSecrets()()
+
+	emailPassword, err := encryptFeedSecret(feed.EmailPassword)
+	if err != nil {
+		return 0, err
+	}
+	oauthClientSecret, err := encryptFeedSecret(feed.EmailOAuthClientSecret)
+	if err != nil {
+		return 0, err
+	}
+	oauthRefreshToken, err := encryptFeedSecret(feed.EmailOAuthRefreshToken)
+	if err != nil {
+		return 0, err
+	}
 
 	// Check if feed already exists with same URL AND same source type
 	var existingID int64
 	var existingIsFreshRSS bool
-	err := db.QueryRow("SELECT id, is_freshrss_source FROM feeds WHERE url = ?", feed.URL).Scan(&existingID, &existingIsFreshRSS)
+	err = db.QueryRow("SELECT id, is_freshrss_source FROM feeds WHERE url = ?", feed.URL).Scan(&existingID, &existingIsFreshRSS)
 
 	if err == sql.ErrNoRows {
 		// Feed doesn't exist, insert new
@@ -64,7 +106,7 @@ func (db *DB) AddFeed(feed *models.Feed) (int64, error) {
 			}
 		}
 
-		// 36 columns to insert (added is_freshrss_source and freshrss_stream_id)
+		// 42 columns to insert (added IMAP security and OAuth columns)
 		query := `INSERT INTO feeds (
 			title, url, link, description, category, image_url, position,
 			script_path, hide_from_timeline, proxy_url, proxy_enabled, refresh_interval,
@@ -75,9 +117,11 @@ func (db *DB) AddFeed(feed *models.Feed) (int64, error) {
 			article_view_mode, auto_expand_content,
 			email_address, email_imap_server, email_imap_port,
 			email_username, email_password, email_folder, email_last_uid,
+			email_security, email_auth_method, email_oauth_token_url,
+			email_oauth_client_id, email_oauth_client_secret, email_oauth_refresh_token,
 			is_freshrss_source, freshrss_stream_id,
 			last_updated
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 		result, err := db.Exec(query,
 			feed.Title, feed.URL, feed.Link, feed.Description, feed.Category, feed.ImageURL, position,
 			feed.ScriptPath, feed.HideFromTimeline, feed.ProxyURL, feed.ProxyEnabled, feed.RefreshInterval,
@@ -87,7 +131,9 @@ func (db *DB) AddFeed(feed *models.Feed) (int64, error) {
 			feed.XPathItemThumbnail, feed.XPathItemCategories, feed.XPathItemUid,
 			feed.ArticleViewMode, feed.AutoExpandContent,
 			feed.EmailAddress, feed.EmailIMAPServer, feed.EmailIMAPPort,
-			feed.EmailUsername, feed.EmailPassword, feed.EmailFolder, feed.EmailLastUID,
+			feed.EmailUsername, emailPassword, feed.EmailFolder, feed.EmailLastUID,
+			feed.EmailSecurity, feed.EmailAuthMethod, feed.EmailOAuthTokenURL,
+			feed.EmailOAuthClientID, oauthClientSecret, oauthRefreshToken,
 			feed.IsFreshRSSSource, feed.FreshRSSStreamID,
 			time.Now())
 		if err != nil {
@@ -125,9 +171,11 @@ func (db *DB) AddFeed(feed *models.Feed) (int64, error) {
 			article_view_mode, auto_expand_content,
 			email_address, email_imap_server, email_imap_port,
 			email_username, email_password, email_folder, email_last_uid,
+			email_security, email_auth_method, email_oauth_token_url,
+			email_oauth_client_id, email_oauth_client_secret, email_oauth_refresh_token,
 			is_freshrss_source, freshrss_stream_id,
 			last_updated
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 		result, err := db.Exec(query,
 			feed.Title, feed.URL, feed.Link, feed.Description, feed.Category, feed.ImageURL, position,
 			feed.ScriptPath, feed.HideFromTimeline, feed.ProxyURL, feed.ProxyEnabled, feed.RefreshInterval,
@@ -137,7 +185,9 @@ func (db *DB) AddFeed(feed *models.Feed) (int64, error) {
 			feed.XPathItemThumbnail, feed.XPathItemCategories, feed.XPathItemUid,
 			feed.ArticleViewMode, feed.AutoExpandContent,
 			feed.EmailAddress, feed.EmailIMAPServer, feed.EmailIMAPPort,
-			feed.EmailUsername, feed.EmailPassword, feed.EmailFolder, feed.EmailLastUID,
+			feed.EmailUsername, emailPassword, feed.EmailFolder, feed.EmailLastUID,
+			feed.EmailSecurity, feed.EmailAuthMethod, feed.EmailOAuthTokenURL,
+			feed.EmailOAuthClientID, oauthClientSecret, oauthRefreshToken,
 			feed.IsFreshRSSSource, feed.FreshRSSStreamID,
 			time.Now())
 		if err != nil {
@@ -152,8 +202,8 @@ func (db *DB) AddFeed(feed *models.Feed) (int64, error) {
 
 	// Same URL and same source type - update existing feed
 	// (note: we don't update is_freshrss_source or freshrss_stream_id for existing feeds)
-	query := `UPDATE feeds SET title = ?, link = ?, description = ?, category = ?, image_url = ?, position = ?, script_path = ?, hide_from_timeline = ?, proxy_url = ?, proxy_enabled = ?, refresh_interval = ?, is_image_mode = ?, type = ?, xpath_item = ?, xpath_item_title = ?, xpath_item_content = ?, xpath_item_uri = ?, xpath_item_author = ?, xpath_item_timestamp = ?, xpath_item_time_format = ?, xpath_item_thumbnail = ?, xpath_item_categories = ?, xpath_item_uid = ?, article_view_mode = ?, auto_expand_content = ?, email_address = ?, email_imap_server = ?, email_imap_port = ?, email_username = ?, email_password = ?, email_folder = ?, email_last_uid = ?, last_updated = ? WHERE id = ?`
-	_, err = db.Exec(query, feed.Title, feed.Link, feed.Description, feed.Category, feed.ImageURL, feed.Position, feed.ScriptPath, feed.HideFromTimeline, feed.ProxyURL, feed.ProxyEnabled, feed.RefreshInterval, feed.IsImageMode, feed.Type, feed.XPathItem, feed.XPathItemTitle, feed.XPathItemContent, feed.XPathItemUri, feed.XPathItemAuthor, feed.XPathItemTimestamp, feed.XPathItemTimeFormat, feed.XPathItemThumbnail, feed.XPathItemCategories, feed.XPathItemUid, feed.ArticleViewMode, feed.AutoExpandContent, feed.EmailAddress, feed.EmailIMAPServer, feed.EmailIMAPPort, feed.EmailUsername, feed.EmailPassword, feed.EmailFolder, feed.EmailLastUID, time.Now(), existingID)
+	query := `UPDATE feeds SET title = ?, link = ?, description = ?, category = ?, image_url = ?, position = ?, script_path = ?, hide_from_timeline = ?, proxy_url = ?, proxy_enabled = ?, refresh_interval = ?, is_image_mode = ?, type = ?, xpath_item = ?, xpath_item_title = ?, xpath_item_content = ?, xpath_item_uri = ?, xpath_item_author = ?, xpath_item_timestamp = ?, xpath_item_time_format = ?, xpath_item_thumbnail = ?, xpath_item_categories = ?, xpath_item_uid = ?, article_view_mode = ?, auto_expand_content = ?, email_address = ?, email_imap_server = ?, email_imap_port = ?, email_username = ?, email_password = ?, email_folder = ?, email_last_uid = ?, email_security = ?, email_auth_method = ?, email_oauth_token_url = ?, email_oauth_client_id = ?, email_oauth_client_secret = ?, email_oauth_refresh_token = ?, last_updated = ? WHERE id = ?`
+	_, err = db.Exec(query, feed.Title, feed.Link, feed.Description, feed.Category, feed.ImageURL, feed.Position, feed.ScriptPath, feed.HideFromTimeline, feed.ProxyURL, feed.ProxyEnabled, feed.RefreshInterval, feed.IsImageMode, feed.Type, feed.XPathItem, feed.XPathItemTitle, feed.XPathItemContent, feed.XPathItemUri, feed.XPathItemAuthor, feed.XPathItemTimestamp, feed.XPathItemTimeFormat, feed.XPathItemThumbnail, feed.XPathItemCategories, feed.XPathItemUid, feed.ArticleViewMode, feed.AutoExpandContent, feed.EmailAddress, feed.EmailIMAPServer, feed.EmailIMAPPort, feed.EmailUsername, emailPassword, feed.EmailFolder, feed.EmailLastUID, feed.EmailSecurity, feed.EmailAuthMethod, feed.EmailOAuthTokenURL, feed.EmailOAuthClientID, oauthClientSecret, oauthRefreshToken, time.Now(), existingID)
 	return existingID, err
 }
 
@@ -165,6 +215,14 @@ func (db *DB) DeleteFeed(id int64) error {
 	if err != nil {
 		return err
 	}
+	// Drop the routes of a mailbox account and the routes into this feed
+	_, _ = db.Exec("DELETE FROM email_routes WHERE account_feed_id = ? OR feed_id = ?", id, id)
+	_, _ = db.Exec("DELETE FROM email_routing WHERE account_feed_id = ?", id)
+	_, _ = db.Exec("DELETE FROM feed_health WHERE feed_id = ?", id)
+	_, _ = db.Exec("DELETE FROM feed_refresh_stats WHERE feed_id = ?", id)
+	_, _ = db.Exec("DELETE FROM feed_schedule WHERE feed_id = ?", id)
+	_, _ = db.Exec("DELETE FROM schedule_rules WHERE feed_id = ?", id)
+	_, _ = db.Exec("DELETE FROM retention_policies WHERE feed_id = ?", id)
 	_, err = db.Exec("DELETE FROM feeds WHERE id = ?", id)
 	return err
 }
@@ -192,6 +250,21 @@ func (db *DB) GetFeeds() ([]models.Feed, error) {
 			COALESCE(f.email_password, ''), COALESCE(f.email_folder, 'INBOX'),
 			COALESCE(f.email_last_uid, 0), COALESCE(f.is_freshrss_source, 0),
 			COALESCE(f.freshrss_stream_id, ''),
+			COALESCE(f.email_security, ''), COALESCE(f.email_auth_method, ''),
+			COALESCE(f.email_oauth_token_url, ''), COALESCE(f.email_oauth_client_id, ''),
+			COALESCE(f.email_oauth_client_secret, ''), COALESCE(f.email_oauth_refresh_token, ''),
+			COALESCE(f.email_uid_validity, 0), COALESCE(f.email_account_id, 0),
+			COALESCE(f.email_inbound_token, ''),
+			COALESCE(f.xpath_next_page, ''), COALESCE(f.xpath_max_pages, 0),
+			COALESCE(f.xpath_detail_content, ''), COALESCE(f.xpath_detail_author, ''),
+			COALESCE(f.xpath_detail_timestamp, ''),
+			COALESCE(f.jsonpath_item, ''), COALESCE(f.jsonpath_item_title, ''),
+			COALESCE(f.jsonpath_item_uri, ''), COALESCE(f.jsonpath_item_content, ''),
+			COALESCE(f.jsonpath_item_author, ''), COALESCE(f.jsonpath_item_timestamp, ''),
+			COALESCE(f.jsonpath_item_time_format, ''), COALESCE(f.jsonpath_item_thumbnail, ''),
+			COALESCE(f.jsonpath_item_uid, ''), COALESCE(f.json_headers, ''),
+			COALESCE(f.json_page_param, ''), COALESCE(f.json_cursor_path, ''),
+			COALESCE(f.json_max_pages, 0), COALESCE(f.full_text_on_ingest, 0),
 			(SELECT MAX(a.published_at) FROM articles a WHERE a.feed_id = f.id) as latest_article_time,
 			CAST(COALESCE((
 				SELECT
@@ -223,6 +296,7 @@ func (db *DB) GetFeeds() ([]models.Feed, error) {
 	for rows.Next() {
 		var f models.Feed
 		var link, category, imageURL, lastError, scriptPath, proxyURL, feedType, xpathItem, xpathItemTitle, xpathItemContent, xpathItemUri, xpathItemAuthor, xpathItemTimestamp, xpathItemTimeFormat, xpathItemThumbnail, xpathItemCategories, xpathItemUid, articleViewMode, autoExpandContent, emailAddress, emailIMAPServer, emailUsername, emailPassword, emailFolder, freshRSSStreamID, latestArticleTimeStr sql.NullString
+		var oauthClientSecret, oauthRefreshToken string
 		var lastUpdated sql.NullTime
 		if err := rows.Scan(
 			&f.ID, &f.Title, &f.URL, &link, &f.Description, &category, &imageURL,
@@ -233,7 +307,21 @@ func (db *DB) GetFeeds() ([]models.Feed, error) {
 			&xpathItemThumbnail, &xpathItemCategories, &xpathItemUid, &articleViewMode,
 			&autoExpandContent, &emailAddress, &emailIMAPServer, &f.EmailIMAPPort,
 			&emailUsername, &emailPassword, &emailFolder, &f.EmailLastUID,
-			&f.IsFreshRSSSource, &freshRSSStreamID, &latestArticleTimeStr, &f.ArticlesPerMonth,
+			&f.IsFreshRSSSource, &freshRSSStreamID,
+			&f.EmailSecurity, &f.EmailAuthMethod, &f.EmailOAuthTokenURL, &f.EmailOAuthClientID,
+			&oauthClientSecret, &oauthRefreshToken, &f.EmailUIDValidity, &f.EmailAccountID,
+			&f.EmailInboundToken,
+			&f.XPathNextPage, &f.XPathMaxPages,
+			&f.XPathDetailContent, &f.XPathDetailAuthor,
+			&f.XPathDetailTimestamp,
+			&f.JSONPathItem, &f.JSONPathItemTitle,
+			&f.JSONPathItemUri, &f.JSONPathItemContent,
+			&f.JSONPathItemAuthor, &f.JSONPathItemTimestamp,
+			&f.JSONPathItemTimeFormat, &f.JSONPathItemThumbnail,
+			&f.JSONPathItemUid, &f.JSONHeaders,
+			&f.JSONPageParam, &f.JSONCursorPath,
+			&f.JSONMaxPages, &f.FullTextOnIngest,
+			&latestArticleTimeStr, &f.ArticlesPerMonth,
 		); err != nil {
 			return nil, err
 		}
@@ -274,7 +362,7 @@ func (db *DB) GetFeeds() ([]models.Feed, error) {
 		f.EmailAddress = emailAddress.String
 		f.EmailIMAPServer = emailIMAPServer.String
 		f.EmailUsername = emailUsername.String
-		f.EmailPassword = emailPassword.String
+		f.EmailPassword = decryptFeedSecret(emailPassword.String)
 		f.EmailFolder = emailFolder.String
 		if f.EmailFolder == "" {
 			f.EmailFolder = "INBOX"
@@ -283,6 +371,9 @@ func (db *DB) GetFeeds() ([]models.Feed, error) {
 			f.EmailIMAPPort = 993
 		}
 		f.FreshRSSStreamID = freshRSSStreamID.String
+		f.EmailOAuthClientSecret = decryptFeedSecret(oauthClientSecret)
+		f.EmailOAuthRefreshToken = decryptFeedSecret(oauthRefreshToken)
+		f.JSONHeaders = decryptFeedSecret(f.JSONHeaders)
 
 		// Set latest article time from string
 		// Format from database: "2025-11-15 18:39:02 +0000 UTC" (Go's time.String() format)
@@ -325,12 +416,13 @@ func (db *DB) GetFeeds() ([]models.Feed, error) {
 // GetFeedByID retrieves a specific feed by its ID.
 func (db *DB) GetFeedByID(id int64) (*models.Feed, error) {
 	db.WaitForReady()
-	row := db.QueryRow("SELECT id, title, url, link, description, category, image_url, COALESCE(position, 0), last_updated, last_error, COALESCE(discovery_completed, 0), COALESCE(script_path, ''), COALESCE(hide_from_timeline, 0), COALESCE(proxy_url, ''), COALESCE(proxy_enabled, 0), COALESCE(refresh_interval, 0), COALESCE(is_image_mode, 0), COALESCE(type, ''), COALESCE(xpath_item, ''), COALESCE(xpath_item_title, ''), COALESCE(xpath_item_content, ''), COALESCE(xpath_item_uri, ''), COALESCE(xpath_item_author, ''), COALESCE(xpath_item_timestamp, ''), COALESCE(xpath_item_time_format, ''), COALESCE(xpath_item_thumbnail, ''), COALESCE(xpath_item_categories, ''), COALESCE(xpath_item_uid, ''), COALESCE(article_view_mode, 'global'), COALESCE(auto_expand_content, 'global'), COALESCE(email_address, ''), COALESCE(email_imap_server, ''), COALESCE(email_imap_port, 993), COALESCE(email_username, ''), COALESCE(email_password, ''), COALESCE(email_folder, 'INBOX'), COALESCE(email_last_uid, 0), COALESCE(is_freshrss_source, 0), COALESCE(freshrss_stream_id, '') FROM feeds WHERE id = ?", id)
+	row := db.QueryRow("SELECT id, title, url, link, description, category, image_url, COALESCE(position, 0), last_updated, last_error, COALESCE(discovery_completed, 0), COALESCE(script_path, ''), COALESCE(hide_from_timeline, 0), COALESCE(proxy_url, ''), COALESCE(proxy_enabled, 0), COALESCE(refresh_interval, 0), COALESCE(is_image_mode, 0), COALESCE(type, ''), COALESCE(xpath_item, ''), COALESCE(xpath_item_title, ''), COALESCE(xpath_item_content, ''), COALESCE(xpath_item_uri, ''), COALESCE(xpath_item_author, ''), COALESCE(xpath_item_timestamp, ''), COALESCE(xpath_item_time_format, ''), COALESCE(xpath_item_thumbnail, ''), COALESCE(xpath_item_categories, ''), COALESCE(xpath_item_uid, ''), COALESCE(article_view_mode, 'global'), COALESCE(auto_expand_content, 'global'), COALESCE(email_address, ''), COALESCE(email_imap_server, ''), COALESCE(email_imap_port, 993), COALESCE(email_username, ''), COALESCE(email_password, ''), COALESCE(email_folder, 'INBOX'), COALESCE(email_last_uid, 0), COALESCE(is_freshrss_source, 0), COALESCE(freshrss_stream_id, ''), COALESCE(email_security, ''), COALESCE(email_auth_method, ''), COALESCE(email_oauth_token_url, ''), COALESCE(email_oauth_client_id, ''), COALESCE(email_oauth_client_secret, ''), COALESCE(email_oauth_refresh_token, ''), COALESCE(email_uid_validity, 0), COALESCE(email_account_id, 0), COALESCE(email_inbound_token, ''), COALESCE(xpath_next_page, ''), COALESCE(xpath_max_pages, 0), COALESCE(xpath_detail_content, ''), COALESCE(xpath_detail_author, ''), COALESCE(xpath_detail_timestamp, ''), COALESCE(jsonpath_item, ''), COALESCE(jsonpath_item_title, ''), COALESCE(jsonpath_item_uri, ''), COALESCE(jsonpath_item_content, ''), COALESCE(jsonpath_item_author, ''), COALESCE(jsonpath_item_timestamp, ''), COALESCE(jsonpath_item_time_format, ''), COALESCE(jsonpath_item_thumbnail, ''), COALESCE(jsonpath_item_uid, ''), COALESCE(json_headers, ''), COALESCE(json_page_param, ''), COALESCE(json_cursor_path, ''), COALESCE(json_max_pages, 0), COALESCE(full_text_on_ingest, 0) FROM feeds WHERE id = ?", id)
 
 	var f models.Feed
 	var link, category, imageURL, lastError, scriptPath, proxyURL, feedType, xpathItem, xpathItemTitle, xpathItemContent, xpathItemUri, xpathItemAuthor, xpathItemTimestamp, xpathItemTimeFormat, xpathItemThumbnail, xpathItemCategories, xpathItemUid, articleViewMode, autoExpandContent, emailAddress, emailIMAPServer, emailUsername, emailPassword, emailFolder, freshRSSStreamID sql.NullString
+	var oauthClientSecret, oauthRefreshToken string
 	var lastUpdated sql.NullTime
-	if err := row.Scan(&f.ID, &f.Title, &f.URL, &link, &f.Description, &category, &imageURL, &f.Position, &lastUpdated, &lastError, &f.DiscoveryCompleted, &scriptPath, &f.HideFromTimeline, &proxyURL, &f.ProxyEnabled, &f.RefreshInterval, &f.IsImageMode, &feedType, &xpathItem, &xpathItemTitle, &xpathItemContent, &xpathItemUri, &xpathItemAuthor, &xpathItemTimestamp, &xpathItemTimeFormat, &xpathItemThumbnail, &xpathItemCategories, &xpathItemUid, &articleViewMode, &autoExpandContent, &emailAddress, &emailIMAPServer, &f.EmailIMAPPort, &emailUsername, &emailPassword, &emailFolder, &f.EmailLastUID, &f.IsFreshRSSSource, &freshRSSStreamID); err != nil {
+	if err := row.Scan(&f.ID, &f.Title, &f.URL, &link, &f.Description, &category, &imageURL, &f.Position, &lastUpdated, &lastError, &f.DiscoveryCompleted, &scriptPath, &f.HideFromTimeline, &proxyURL, &f.ProxyEnabled, &f.RefreshInterval, &f.IsImageMode, &feedType, &xpathItem, &xpathItemTitle, &xpathItemContent, &xpathItemUri, &xpathItemAuthor, &xpathItemTimestamp, &xpathItemTimeFormat, &xpathItemThumbnail, &xpathItemCategories, &xpathItemUid, &articleViewMode, &autoExpandContent, &emailAddress, &emailIMAPServer, &f.EmailIMAPPort, &emailUsername, &emailPassword, &emailFolder, &f.EmailLastUID, &f.IsFreshRSSSource, &freshRSSStreamID, &f.EmailSecurity, &f.EmailAuthMethod, &f.EmailOAuthTokenURL, &f.EmailOAuthClientID, &oauthClientSecret, &oauthRefreshToken, &f.EmailUIDValidity, &f.EmailAccountID, &f.EmailInboundToken, &f.XPathNextPage, &f.XPathMaxPages, &f.XPathDetailContent, &f.XPathDetailAuthor, &f.XPathDetailTimestamp, &f.JSONPathItem, &f.JSONPathItemTitle, &f.JSONPathItemUri, &f.JSONPathItemContent, &f.JSONPathItemAuthor, &f.JSONPathItemTimestamp, &f.JSONPathItemTimeFormat, &f.JSONPathItemThumbnail, &f.JSONPathItemUid, &f.JSONHeaders, &f.JSONPageParam, &f.JSONCursorPath, &f.JSONMaxPages, &f.FullTextOnIngest); err != nil {
 		return nil, err
 	}
 	f.Link = link.String
@@ -366,7 +458,7 @@ func (db *DB) GetFeedByID(id int64) (*models.Feed, error) {
 	f.EmailAddress = emailAddress.String
 	f.EmailIMAPServer = emailIMAPServer.String
 	f.EmailUsername = emailUsername.String
-	f.EmailPassword = emailPassword.String
+	f.EmailPassword = decryptFeedSecret(emailPassword.String)
 	f.EmailFolder = emailFolder.String
 	if f.EmailFolder == "" {
 		f.EmailFolder = "INBOX"
@@ -375,6 +467,9 @@ func (db *DB) GetFeedByID(id int64) (*models.Feed, error) {
 		f.EmailIMAPPort = 993
 	}
 	f.FreshRSSStreamID = freshRSSStreamID.String
+	f.EmailOAuthClientSecret = decryptFeedSecret(oauthClientSecret)
+	f.EmailOAuthRefreshToken = decryptFeedSecret(oauthRefreshToken)
+	f.JSONHeaders = decryptFeedSecret(f.JSONHeaders)
 
 	return &f, nil
 }
@@ -403,6 +498,7 @@ func (db *DB) GetAllFeedURLs() (map[string]bool, error) {
 // Only non-nil fields in opts will be updated.
 func (db *DB) UpdateFeedWithOptions(id int64, opts FeedUpdateOptions) error {
 	db.WaitForReady()
+	defer db.HoldSecrets()()
 
 	// Build SET clause dynamically based on non-nil options
 	setParts := []string{}
@@ -492,6 +588,87 @@ func (db *DB) UpdateFeedWithOptions(id int64, opts FeedUpdateOptions) error {
 		setParts = append(setParts, "xpath_item_uid = ?")
 		args = append(args, *opts.XPathItemUid)
 	}
+	if opts.XPathNextPage != nil {
+		setParts = append(setParts, "xpath_next_page = ?")
+		args = append(args, *opts.XPathNextPage)
+	}
+	if opts.XPathMaxPages != nil {
+		setParts = append(setParts, "xpath_max_pages = ?")
+		args = append(args, *opts.XPathMaxPages)
+	}
+	if opts.XPathDetailContent != nil {
+		setParts = append(setParts, "xpath_detail_content = ?")
+		args = append(args, *opts.XPathDetailContent)
+	}
+	if opts.XPathDetailAuthor != nil {
+		setParts = append(setParts, "xpath_detail_author = ?")
+		args = append(args, *opts.XPathDetailAuthor)
+	}
+	if opts.XPathDetailTimestamp != nil {
+		setParts = append(setParts, "xpath_detail_timestamp = ?")
+		args = append(args, *opts.XPathDetailTimestamp)
+	}
+	if opts.JSONPathItem != nil {
+		setParts = append(setParts, "jsonpath_item = ?")
+		args = append(args, *opts.JSONPathItem)
+	}
+	if opts.JSONPathItemTitle != nil {
+		setParts = append(setParts, "jsonpath_item_title = ?")
+		args = append(args, *opts.JSONPathItemTitle)
+	}
+	if opts.JSONPathItemUri != nil {
+		setParts = append(setParts, "jsonpath_item_uri = ?")
+		args = append(args, *opts.JSONPathItemUri)
+	}
+	if opts.JSONPathItemContent != nil {
+		setParts = append(setParts, "jsonpath_item_content = ?")
+		args = append(args, *opts.JSONPathItemContent)
+	}
+	if opts.JSONPathItemAuthor != nil {
+		setParts = append(setParts, "jsonpath_item_author = ?")
+		args = append(args, *opts.JSONPathItemAuthor)
+	}
+	if opts.JSONPathItemTimestamp != nil {
+		setParts = append(setParts, "jsonpath_item_timestamp = ?")
+		args = append(args, *opts.JSONPathItemTimestamp)
+	}
+	if opts.JSONPathItemTimeFormat != nil {
+		setParts = append(setParts, "jsonpath_item_time_format = ?")
+		args = append(args, *opts.JSONPathItemTimeFormat)
+	}
+	if opts.JSONPathItemThumbnail != nil {
+		setParts = append(setParts, "jsonpath_item_thumbnail = ?")
+		args = append(args, *opts.JSONPathItemThumbnail)
+	}
+	if opts.JSONPathItemUid != nil {
+		setParts = append(setParts, "jsonpath_item_uid = ?")
+		args = append(args, *opts.JSONPathItemUid)
+	}
+	if opts.JSONHeaders != nil {
+		// Headers carry API keys and tokens, so they are stored like the email password
+		headers, err := encryptFeedSecret(*opts.JSONHeaders)
+		if err != nil {
+			return err
+		}
+		setParts = append(setParts, "json_headers = ?")
+		args = append(args, headers)
+	}
+	if opts.JSONPageParam != nil {
+		setParts = append(setParts, "json_page_param = ?")
+		args = append(args, *opts.JSONPageParam)
+	}
+	if opts.JSONCursorPath != nil {
+		setParts = append(setParts, "json_cursor_path = ?")
+		args = append(args, *opts.JSONCursorPath)
+	}
+	if opts.JSONMaxPages != nil {
+		setParts = append(setParts, "json_max_pages = ?")
+		args = append(args, *opts.JSONMaxPages)
+	}
+	if opts.FullTextOnIngest != nil {
+		setParts = append(setParts, "full_text_on_ingest = ?")
+		args = append(args, *opts.FullTextOnIngest)
+	}
 	if opts.ArticleViewMode != nil {
 		setParts = append(setParts, "article_view_mode = ?")
 		args = append(args, *opts.ArticleViewMode)
@@ -513,8 +690,12 @@ func (db *DB) UpdateFeedWithOptions(id int64, opts FeedUpdateOptions) error {
 		args = append(args, *opts.EmailUsername)
 	}
 	if opts.EmailPassword != nil {
+		emailPassword, err := encryptFeedSecret(*opts.EmailPassword)
+		if err != nil {
+			return err
+		}
 		setParts = append(setParts, "email_password = ?")
-		args = append(args, *opts.EmailPassword)
+		args = append(args, emailPassword)
 	}
 	if opts.EmailFolder != nil {
 		setParts = append(setParts, "email_folder = ?")
@@ -524,6 +705,38 @@ func (db *DB) UpdateFeedWithOptions(id int64, opts FeedUpdateOptions) error {
 		setParts = append(setParts, "email_imap_port = ?")
 		args = append(args, *opts.EmailIMAPPort)
 	}
+	if opts.EmailSecurity != nil {
+		setParts = append(setParts, "email_security = ?")
+		args = append(args, *opts.EmailSecurity)
+	}
+	if opts.EmailAuthMethod != nil {
+		setParts = append(setParts, "email_auth_method = ?")
+		args = append(args, *opts.EmailAuthMethod)
+	}
+	if opts.EmailOAuthTokenURL != nil {
+		setParts = append(setParts, "email_oauth_token_url = ?")
+		args = append(args, *opts.EmailOAuthTokenURL)
+	}
+	if opts.EmailOAuthClientID != nil {
+		setParts = append(setParts, "email_oauth_client_id = ?")
+		args = append(args, *opts.EmailOAuthClientID)
+	}
+	if opts.EmailOAuthClientSecret != nil {
+		clientSecret, err := encryptFeedSecret(*opts.EmailOAuthClientSecret)
+		if err != nil {
+			return err
+		}
+		setParts = append(setParts, "email_oauth_client_secret = ?")
+		args = append(args, clientSecret)
+	}
+	if opts.EmailOAuthRefreshToken != nil {
+		refreshToken, err := encryptFeedSecret(*opts.EmailOAuthRefreshToken)
+		if err != nil {
+			return err
+		}
+		setParts = append(setParts, "email_oauth_refresh_token = ?")
+		args = append(args, refreshToken)
+	}
 
 	if len(setParts) == 0 {
 		// Nothing to update
@@ -535,6 +748,10 @@ func (db *DB) UpdateFeedWithOptions(id int64, opts FeedUpdateOptions) error {
 
 	query := "UPDATE feeds SET " + joinStrings(setParts, ", ") + " WHERE id = ?"
 	_, err := db.Exec(query, args...)
+	if err == nil && opts.RefreshInterval != nil {
+		// Replan the next refresh with the new interval
+		_, _ = db.Exec("UPDATE feed_schedule SET next_due_at = 0 WHERE feed_id = ?", id)
+	}
 	return err
 }
 
@@ -663,10 +880,11 @@ func (db *DB) UpdateFeedLastUpdated(id int64) error {
 	return err
 }
 
-// UpdateFeedEmailLastUID updates a newsletter feed's last processed email UID.
-func (db *DB) UpdateFeedEmailLastUID(id int64, lastUID int) error {
+// UpdateFeedEmailUIDState records a newsletter feed's last processed UID together with
+// the folder's UIDVALIDITY, so a rebuilt mailbox can be detected on the next fetch.
+func (db *DB) UpdateFeedEmailUIDState(id int64, uidValidity uint32, lastUID int) error {
 	db.WaitForReady()
-	_, err := db.Exec("UPDATE feeds SET email_last_uid = ? WHERE id = ?", lastUID, id)
+	_, err := db.Exec("UPDATE feeds SET email_uid_validity = ?, email_last_uid = ? WHERE id = ?", uidValidity, lastUID, id)
 	return err
 }
 
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// FeedHealth is the failure history of a feed since its last successful refresh.
+// Feeds refreshing fine have none.
+type FeedHealth struct {
+	FeedID              int64
+	ConsecutiveFailures int
+	FirstFailureAt      time.Time
+	LastFailureAt       time.Time
+	StatusCode          int       // HTTP status of the last failure, 0 when there was no response
+	ErrorClass          string    // Kind of the last failure, e.g. "not_found" or "timeout"
+	LastError           string    // Message of the last failure
+	NextRetryAt         time.Time // No scheduled refresh before this time (zero = no backoff)
+	PausedAt            time.Time // When the feed was paused after too many failures (zero = not paused)
+}
+
+// Paused reports whether scheduled refreshes of the feed are paused
+func (h *FeedHealth) Paused() bool {
+	return !h.PausedAt.IsZero()
+}
+
+const feedHealthColumns = `feed_id, consecutive_failures, first_failure_at, last_failure_at,
+	status_code, error_class, last_error, next_retry_at, paused_at`
+
+// scanFeedHealth scans a row of feedHealthColumns
+func scanFeedHealth(scan func(dest ...interface{}) error) (*FeedHealth, error) {
+	var h FeedHealth
+	var firstFailureAt, lastFailureAt, nextRetryAt, pausedAt int64
+	if err := scan(&h.FeedID, &h.ConsecutiveFailures, &firstFailureAt, &lastFailureAt,
+		&h.StatusCode, &h.ErrorClass, &h.LastError, &nextRetryAt, &pausedAt); err != nil {
+		return nil, err
+	}
+	h.FirstFailureAt = unixTime(firstFailureAt)
+	h.LastFailureAt = unixTime(lastFailureAt)
+	h.NextRetryAt = unixTime(nextRetryAt)
+	h.PausedAt = unixTime(pausedAt)
+	return &h, nil
+}
+
+// unixTime converts stored Unix seconds, 0 meaning unset
+func unixTime(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// timeUnix converts a time to stored Unix seconds, the zero time to 0
+func timeUnix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// GetFeedHealth returns the failure history of a feed, or nil if its last refresh succeeded
+func (db *DB) GetFeedHealth(feedID int64) (*FeedHealth, error) {
+	db.WaitForReady()
+
+	row := db.QueryRow(`SELECT `+feedHealthColumns+` FROM feed_health WHERE feed_id = ?`, feedID)
+	h, err := scanFeedHealth(row.Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get feed health: %w", err)
+	}
+	return h, nil
+}
+
+// GetFeedHealthMap returns the failure history of all failing feeds by feed ID
+func (db *DB) GetFeedHealthMap() (map[int64]FeedHealth, error) {
+	list, err := db.GetFailingFeeds()
+	if err != nil {
+		return nil, err
+	}
+	healths := make(map[int64]FeedHealth, len(list))
+	for _, h := range list {
+		healths[h.FeedID] = h
+	}
+	return healths, nil
+}
+
+// GetFailingFeeds returns the failure history of all failing feeds, paused
+// feeds first, then by number of consecutive failures
+func (db *DB) GetFailingFeeds() ([]FeedHealth, error) {
+	db.WaitForReady()
+
+	rows, err := db.Query(`SELECT ` + feedHealthColumns + ` FROM feed_health
+		WHERE feed_id IN (SELECT id FROM feeds)
+		ORDER BY paused_at > 0 DESC, consecutive_failures DESC, feed_id`)
+	if err != nil {
+		return nil, fmt.Errorf("get failing feeds: %w", err)
+	}
+	defer rows.Close()
+
+	var healths []FeedHealth
+	for rows.Next() {
+		h, err := scanFeedHealth(rows.Scan)
+		if err != nil {
+			return nil, fmt.Errorf("scan feed health: %w", err)
+		}
+		healths = append(healths, *h)
+	}
+	return healths, rows.Err()
+}
+
+// SaveFeedHealth stores the failure history of a feed
+func (db *DB) SaveFeedHealth(h FeedHealth) error {
+	db.WaitForReady()
+
+	_, err := db.Exec(`
+		INSERT INTO feed_health (`+feedHealthColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(feed_id) DO UPDATE SET
+			consecutive_failures = excluded.consecutive_failures,
+			first_failure_at = excluded.first_failure_at,
+			last_failure_at = excluded.last_failure_at,
+			status_code = excluded.status_code,
+			error_class = excluded.error_class,
+			last_error = excluded.last_error,
+			next_retry_at = excluded.next_retry_at,
+			paused_at = excluded.paused_at
+	`, h.FeedID, h.ConsecutiveFailures, timeUnix(h.FirstFailureAt), timeUnix(h.LastFailureAt),
+		h.StatusCode, h.ErrorClass, h.LastError, timeUnix(h.NextRetryAt), timeUnix(h.PausedAt))
+	if err != nil {
+		return fmt.Errorf("save feed health: %w", err)
+	}
+	return nil
+}
+
+// ClearFeedHealth forgets the failure history of a feed after a successful refresh
+func (db *DB) ClearFeedHealth(feedID int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`DELETE FROM feed_health WHERE feed_id = ?`, feedID)
+	return err
+}
+
+// ResumeFeed lifts the pause and backoff of a failing feed, keeping its failure history.
+// It reports whether the feed had any.
+func (db *DB) ResumeFeed(feedID int64) (bool, error) {
+	db.WaitForReady()
+
+	result, err := db.Exec(`UPDATE feed_health SET next_retry_at = 0, paused_at = 0 WHERE feed_id = ?`, feedID)
+	if err != nil {
+		return false, fmt.Errorf("resume feed: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// UpdateFeedURL changes the URL of a feed. It fails if another feed already has that URL.
+func (db *DB) UpdateFeedURL(id int64, url string) error {
+	db.WaitForReady()
+
+	var existingID int64
+	err := db.QueryRow("SELECT id FROM feeds WHERE url = ? AND id != ? LIMIT 1", url, id).Scan(&existingID)
+	if err == nil {
+		return fmt.Errorf("another feed already uses %s", url)
+	}
+	if err != sql.ErrNoRows {
+		return fmt.Errorf("check feed url: %w", err)
+	}
+
+	_, err = db.Exec("UPDATE feeds SET url = ? WHERE id = ?", url, id)
+	return err
+}
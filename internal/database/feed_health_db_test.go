@@ -0,0 +1,99 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func TestFeedHealth(t *testing.T) {
+	db := setupTestDB(t)
+
+	var ids []int64
+	for _, url := range []string{"https://a.example/feed", "https://b.example/feed"} {
+		id, err := db.AddFeed(&models.Feed{Title: url, URL: url})
+		if err != nil {
+			t.Fatalf("AddFeed() error = %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if health, err := db.GetFeedHealth(ids[0]); err != nil || health != nil {
+		t.Fatalf("GetFeedHealth() of a healthy feed = %v, %v, want nil", health, err)
+	}
+
+	now := time.Unix(time.Now().Unix(), 0)
+	failing := database.FeedHealth{
+		FeedID:              ids[0],
+		ConsecutiveFailures: 3,
+		FirstFailureAt:      now.Add(-time.Hour),
+		LastFailureAt:       now,
+		StatusCode:          404,
+		ErrorClass:          "not_found",
+		LastError:           "HTTP 404: 404 Not Found",
+		NextRetryAt:         now.Add(30 * time.Minute),
+	}
+	paused := database.FeedHealth{FeedID: ids[1], ConsecutiveFailures: 1, LastFailureAt: now, ErrorClass: "timeout", PausedAt: now}
+	for _, h := range []database.FeedHealth{failing, paused} {
+		if err := db.SaveFeedHealth(h); err != nil {
+			t.Fatalf("SaveFeedHealth() error = %v", err)
+		}
+	}
+
+	got, err := db.GetFeedHealth(ids[0])
+	if err != nil || got == nil {
+		t.Fatalf("GetFeedHealth() = %v, %v", got, err)
+	}
+	if *got != failing {
+		t.Errorf("GetFeedHealth() = %+v, want %+v", *got, failing)
+	}
+
+	list, err := db.GetFailingFeeds()
+	if err != nil {
+		t.Fatalf("GetFailingFeeds() error = %v", err)
+	}
+	if len(list) != 2 || list[0].FeedID != ids[1] || !list[0].Paused() {
+		t.Errorf("GetFailingFeeds() should list paused feeds first, got %+v", list)
+	}
+
+	if ok, err := db.ResumeFeed(ids[1]); err != nil || !ok {
+		t.Fatalf("ResumeFeed() = %v, %v", ok, err)
+	}
+	if h, _ := db.GetFeedHealth(ids[1]); h == nil || h.Paused() || h.ConsecutiveFailures != 1 {
+		t.Errorf("Resumed feed should keep its history without the pause, got %+v", h)
+	}
+
+	if err := db.ClearFeedHealth(ids[0]); err != nil {
+		t.Fatalf("ClearFeedHealth() error = %v", err)
+	}
+	if err := db.DeleteFeed(ids[1]); err != nil {
+		t.Fatalf("DeleteFeed() error = %v", err)
+	}
+	if healths, err := db.GetFeedHealthMap(); err != nil || len(healths) != 0 {
+		t.Errorf("GetFeedHealthMap() = %v, %v, want no failing feeds", healths, err)
+	}
+}
+
+func TestUpdateFeedURL(t *testing.T) {
+	db := setupTestDB(t)
+
+	id, err := db.AddFeed(&models.Feed{Title: "old", URL: "https://example.com/old"})
+	if err != nil {
+		t.Fatalf("AddFeed() error = %v", err)
+	}
+	if _, err := db.AddFeed(&models.Feed{Title: "taken", URL: "https://example.com/taken"}); err != nil {
+		t.Fatalf("AddFeed() error = %v", err)
+	}
+
+	if err := db.UpdateFeedURL(id, "https://example.com/new"); err != nil {
+		t.Fatalf("UpdateFeedURL() error = %v", err)
+	}
+	if feed, _ := db.GetFeedByID(id); feed == nil || feed.URL != "https://example.com/new" {
+		t.Errorf("URL was not updated: %+v", feed)
+	}
+	if err := db.UpdateFeedURL(id, "https://example.com/taken"); err == nil {
+		t.Error("UpdateFeedURL() to the URL of another feed should fail")
+	}
+}
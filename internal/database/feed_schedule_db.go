@@ -0,0 +1,112 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// FeedSchedule is the refresh schedule of a feed, kept across restarts
+type FeedSchedule struct {
+	FeedID        int64
+	NextDueAt     time.Time // Next scheduled refresh (zero = not planned yet)
+	LastAttemptAt time.Time
+	LastSuccessAt time.Time
+	QueuedAt      time.Time // When the feed was queued for a refresh that has not run yet (zero = not queued)
+}
+
+const feedScheduleColumns = `feed_id, next_due_at, last_attempt_at, last_success_at, queued_at`
+
+// GetFeedSchedules returns the refresh schedules of all feeds by feed ID
+func (db *DB) GetFeedSchedules() (map[int64]FeedSchedule, error) {
+	db.WaitForReady()
+
+	rows, err := db.Query(`SELECT ` + feedScheduleColumns + ` FROM feed_schedule`)
+	if err != nil {
+		return nil, fmt.Errorf("get feed schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := make(map[int64]FeedSchedule)
+	for rows.Next() {
+		var s FeedSchedule
+		var nextDueAt, lastAttemptAt, lastSuccessAt, queuedAt int64
+		if err := rows.Scan(&s.FeedID, &nextDueAt, &lastAttemptAt, &lastSuccessAt, &queuedAt); err != nil {
+			return nil, fmt.Errorf("scan feed schedule: %w", err)
+		}
+		s.NextDueAt = unixTime(nextDueAt)
+		s.LastAttemptAt = unixTime(lastAttemptAt)
+		s.LastSuccessAt = unixTime(lastSuccessAt)
+		s.QueuedAt = unixTime(queuedAt)
+		schedules[s.FeedID] = s
+	}
+	return schedules, rows.Err()
+}
+
+// MarkFeedsQueued records that feeds were queued for a refresh, so the queue
+// can be restored after a restart
+func (db *DB) MarkFeedsQueued(feedIDs []int64, at time.Time) error {
+	db.WaitForReady()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT INTO feed_schedule (feed_id, queued_at) VALUES (?, ?)
+		ON CONFLICT(feed_id) DO UPDATE SET queued_at = excluded.queued_at`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, id := range feedIDs {
+		if _, err := stmt.Exec(id, timeUnix(at)); err != nil {
+			return fmt.Errorf("mark feed queued: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ClearQueuedFeeds forgets all queued refreshes
+func (db *DB) ClearQueuedFeeds() error {
+	db.WaitForReady()
+	_, err := db.Exec(`UPDATE feed_schedule SET queued_at = 0 WHERE queued_at != 0`)
+	return err
+}
+
+// RescheduleFeed sets when a feed is due next and takes it off the queue
+func (db *DB) RescheduleFeed(feedID int64, nextDueAt time.Time) error {
+	db.WaitForReady()
+
+	_, err := db.Exec(`INSERT INTO feed_schedule (feed_id, next_due_at) VALUES (?, ?)
+		ON CONFLICT(feed_id) DO UPDATE SET next_due_at = excluded.next_due_at, queued_at = 0`,
+		feedID, timeUnix(nextDueAt))
+	if err != nil {
+		return fmt.Errorf("reschedule feed: %w", err)
+	}
+	return nil
+}
+
+// RecordFeedAttempt records a finished refresh of a feed and when it is due next
+func (db *DB) RecordFeedAttempt(feedID int64, at time.Time, success bool, nextDueAt time.Time) error {
+	db.WaitForReady()
+
+	var lastSuccessAt sql.NullInt64
+	if success {
+		lastSuccessAt = sql.NullInt64{Int64: timeUnix(at), Valid: true}
+	}
+	_, err := db.Exec(`INSERT INTO feed_schedule (feed_id, next_due_at, last_attempt_at, last_success_at)
+		VALUES (?, ?, ?, COALESCE(?, 0))
+		ON CONFLICT(feed_id) DO UPDATE SET
+			next_due_at = excluded.next_due_at,
+			last_attempt_at = excluded.last_attempt_at,
+			last_success_at = COALESCE(?, last_success_at),
+			queued_at = 0`,
+		feedID, timeUnix(nextDueAt), timeUnix(at), lastSuccessAt, lastSuccessAt)
+	if err != nil {
+		return fmt.Errorf("record feed attempt: %w", err)
+	}
+	return nil
+}
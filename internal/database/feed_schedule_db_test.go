@@ -0,0 +1,56 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func TestFeedSchedule(t *testing.T) {
+	db := setupTestDB(t)
+
+	id, err := db.AddFeed(&models.Feed{Title: "a", URL: "https://a.example/feed"})
+	if err != nil {
+		t.Fatalf("AddFeed() error = %v", err)
+	}
+	now := time.Unix(time.Now().Unix(), 0)
+
+	if err := db.MarkFeedsQueued([]int64{id}, now); err != nil {
+		t.Fatalf("MarkFeedsQueued() error = %v", err)
+	}
+	if err := db.RecordFeedAttempt(id, now, true, now.Add(time.Hour)); err != nil {
+		t.Fatalf("RecordFeedAttempt() error = %v", err)
+	}
+
+	// A failed attempt keeps the last success
+	later := now.Add(time.Hour)
+	if err := db.RecordFeedAttempt(id, later, false, later.Add(time.Hour)); err != nil {
+		t.Fatalf("RecordFeedAttempt() error = %v", err)
+	}
+	schedules, err := db.GetFeedSchedules()
+	if err != nil {
+		t.Fatalf("GetFeedSchedules() error = %v", err)
+	}
+	s := schedules[id]
+	if !s.LastSuccessAt.Equal(now) || !s.LastAttemptAt.Equal(later) || !s.NextDueAt.Equal(later.Add(time.Hour)) || !s.QueuedAt.IsZero() {
+		t.Errorf("Unexpected schedule: %+v", s)
+	}
+
+	if err := db.MarkFeedsQueued([]int64{id}, later); err != nil {
+		t.Fatalf("MarkFeedsQueued() error = %v", err)
+	}
+	if err := db.ClearQueuedFeeds(); err != nil {
+		t.Fatalf("ClearQueuedFeeds() error = %v", err)
+	}
+	// Changing the refresh interval replans the next refresh
+	interval := 15
+	if err := db.UpdateFeedWithOptions(id, database.FeedUpdateOptions{RefreshInterval: &interval}); err != nil {
+		t.Fatalf("UpdateFeedWithOptions() error = %v", err)
+	}
+	schedules, _ = db.GetFeedSchedules()
+	if s := schedules[id]; !s.QueuedAt.IsZero() || !s.NextDueAt.IsZero() || !s.LastSuccessAt.Equal(now) {
+		t.Errorf("Unexpected schedule after clearing the queue and replanning: %+v", s)
+	}
+}
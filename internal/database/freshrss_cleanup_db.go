@@ -71,6 +71,11 @@ func (db *DB) CleanupFreshRSSData() error {
 		log.Printf("[FreshRSS Cleanup] Cleared FreshRSS sync queue")
 	}
 
+	// Clear pending subscription changes, the conflict log and incremental sync state
+	_, _ = db.Exec("DELETE FROM freshrss_subscription_queue")
+	_, _ = db.Exec("DELETE FROM freshrss_sync_conflicts")
+	_, _ = db.Exec("DELETE FROM freshrss_stream_state")
+
 	// Step 6: Clear FreshRSS settings (keep enabled status as it will be set by caller)
 	// Note: We don't clear freshrss_enabled here as it's managed by the settings handler
 	log.Printf("[FreshRSS Cleanup] Completed successfully")
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FreshRSSStreamState records where the last incremental sync of a FreshRSS stream stopped
+type FreshRSSStreamState struct {
+	StreamID       string
+	LastSyncAt     time.Time // Crawl time lower bound (ot) for the next sync
+	UnreadCount    int       // Server unread count seen at the last sync
+	NewestItemUsec int64     // Server newest item timestamp seen at the last sync
+}
+
+// GetFreshRSSStreamState returns the sync state of a stream, or nil if it was never synced
+func (db *DB) GetFreshRSSStreamState(streamID string) (*FreshRSSStreamState, error) {
+	db.WaitForReady()
+
+	var state FreshRSSStreamState
+	var lastSyncAt int64
+	err := db.QueryRow(`
+		SELECT stream_id, last_sync_at, unread_count, newest_item_usec
+		FROM freshrss_stream_state WHERE stream_id = ?
+	`, streamID).Scan(&state.StreamID, &lastSyncAt, &state.UnreadCount, &state.NewestItemUsec)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get stream state: %w", err)
+	}
+
+	state.LastSyncAt = time.Unix(lastSyncAt, 0)
+	return &state, nil
+}
+
+// SaveFreshRSSStreamState stores the sync state of a stream
+func (db *DB) SaveFreshRSSStreamState(state FreshRSSStreamState) error {
+	db.WaitForReady()
+
+	_, err := db.Exec(`
+		INSERT INTO freshrss_stream_state (stream_id, last_sync_at, unread_count, newest_item_usec)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(stream_id) DO UPDATE SET
+			last_sync_at = excluded.last_sync_at,
+			unread_count = excluded.unread_count,
+			newest_item_usec = excluded.newest_item_usec
+	`, state.StreamID, state.LastSyncAt.Unix(), state.UnreadCount, state.NewestItemUsec)
+	if err != nil {
+		return fmt.Errorf("save stream state: %w", err)
+	}
+	return nil
+}
+
+// GetKnownFreshRSSItemIDs returns which of the given FreshRSS item IDs already exist locally
+func (db *DB) GetKnownFreshRSSItemIDs(itemIDs []string) (map[string]bool, error) {
+	db.WaitForReady()
+
+	known := make(map[string]bool, len(itemIDs))
+	const batchSize = 500
+
+	for start := 0; start < len(itemIDs); start += batchSize {
+		end := start + batchSize
+		if end > len(itemIDs) {
+			end = len(itemIDs)
+		}
+		batch := itemIDs[start:end]
+
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+
+		rows, err := db.Query(`SELECT freshrss_item_id FROM articles WHERE freshrss_item_id IN (`+placeholders+`)`, args...)
+		if err != nil {
+			return nil, fmt.Errorf("get known item IDs: %w", err)
+		}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan known item ID: %w", err)
+			}
+			known[id] = true
+		}
+		rows.Close()
+	}
+
+	return known, nil
+}
+
+// GetFreshRSSArticles returns the sync view of every article linked to a FreshRSS item
+func (db *DB) GetFreshRSSArticles() ([]Article, error) {
+	db.WaitForReady()
+
+	rows, err := db.Query(`
+		SELECT id, feed_id, title, url, is_read, is_favorite, freshrss_item_id
+		FROM articles
+		WHERE freshrss_item_id IS NOT NULL AND freshrss_item_id != ''
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("get FreshRSS articles: %w", err)
+	}
+	defer rows.Close()
+
+	var articles []Article
+	for rows.Next() {
+		var a Article
+		if err := rows.Scan(&a.ID, &a.FeedID, &a.Title, &a.URL, &a.IsRead, &a.IsFavorite, &a.FreshRSSItemID); err != nil {
+			return nil, fmt.Errorf("scan FreshRSS article: %w", err)
+		}
+		articles = append(articles, a)
+	}
+
+	return articles, rows.Err()
+}
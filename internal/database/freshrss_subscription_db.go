@@ -0,0 +1,263 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// SubscriptionAction represents a subscription change that must be pushed to FreshRSS
+type SubscriptionAction string
+
+const (
+	SubscriptionActionSubscribe   SubscriptionAction = "subscribe"
+	SubscriptionActionUnsubscribe SubscriptionAction = "unsubscribe"
+	SubscriptionActionRename      SubscriptionAction = "rename"
+	SubscriptionActionMove        SubscriptionAction = "move"
+)
+
+// SubscriptionChange represents a queued local subscription change for FreshRSS
+type SubscriptionChange struct {
+	ID          int64
+	FeedID      int64
+	FeedURL     string
+	StreamID    string // FreshRSS stream ID, empty for feeds not yet on the server
+	Action      SubscriptionAction
+	Title       string // New title (rename, subscribe)
+	Category    string // New category (move, subscribe)
+	OldCategory string // Previous category (move)
+	CreatedAt   time.Time
+	SyncError   *string
+	RetryCount  int
+}
+
+// SyncConflict records a read/star state that changed both locally and on the server
+type SyncConflict struct {
+	ID              int64     `json:"id"`
+	ArticleID       int64     `json:"article_id"`
+	ArticleURL      string    `json:"article_url"`
+	Field           string    `json:"field"` // "is_read" or "is_favorite"
+	LocalValue      bool      `json:"local_value"`
+	RemoteValue     bool      `json:"remote_value"`
+	LocalChangedAt  time.Time `json:"local_changed_at"`
+	RemoteChangedAt time.Time `json:"remote_changed_at"`
+	Resolution      string    `json:"resolution"` // "local" or "remote"
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// EnqueueSubscriptionChange adds a subscription change to the FreshRSS push queue
+func (db *DB) EnqueueSubscriptionChange(change SubscriptionChange) error {
+	db.WaitForReady()
+
+	query := `
+	INSERT INTO freshrss_subscription_queue (feed_id, feed_url, stream_id, sync_action, title, category, old_category, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := db.Exec(query, change.FeedID, change.FeedURL, change.StreamID, string(change.Action),
+		change.Title, change.Category, change.OldCategory, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("enqueue subscription change: %w", err)
+	}
+
+	log.Printf("[EnqueueSubscriptionChange] feedID=%d url=%s action=%s", change.FeedID, change.FeedURL, change.Action)
+	return nil
+}
+
+// GetRetryableSubscriptionChanges retrieves pending subscription changes that are due to be pushed now
+func (db *DB) GetRetryableSubscriptionChanges(limit int) ([]SubscriptionChange, error) {
+	db.WaitForReady()
+
+	query := `
+	SELECT id, feed_id, feed_url, COALESCE(stream_id, ''), sync_action, COALESCE(title, ''),
+		COALESCE(category, ''), COALESCE(old_category, ''), created_at, sync_error, COALESCE(retry_count, 0)
+	FROM freshrss_subscription_queue
+	WHERE synced_at IS NULL
+		AND COALESCE(retry_count, 0) < ?
+		AND (next_retry_at IS NULL OR next_retry_at <= ?)
+	ORDER BY created_at ASC, id ASC
+	LIMIT ?
+	`
+
+	rows, err := db.Query(query, MaxSyncRetries, time.Now().Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("get retryable subscription changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []SubscriptionChange
+	for rows.Next() {
+		var change SubscriptionChange
+		var action string
+		var createdAt int64
+		var syncError sql.NullString
+
+		if err := rows.Scan(&change.ID, &change.FeedID, &change.FeedURL, &change.StreamID, &action,
+			&change.Title, &change.Category, &change.OldCategory, &createdAt, &syncError, &change.RetryCount); err != nil {
+			return nil, fmt.Errorf("scan subscription change: %w", err)
+		}
+
+		change.Action = SubscriptionAction(action)
+		change.CreatedAt = time.Unix(createdAt, 0)
+		if syncError.Valid {
+			change.SyncError = &syncError.String
+		}
+		changes = append(changes, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate subscription changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// GetPendingSubscriptionStreams returns the feed URLs with unsynced subscription changes.
+// Pulls use this to avoid reverting local edits that have not reached the server yet.
+func (db *DB) GetPendingSubscriptionStreams() (map[string]SubscriptionAction, error) {
+	db.WaitForReady()
+
+	rows, err := db.Query(`
+	SELECT feed_url, sync_action FROM freshrss_subscription_queue
+	WHERE synced_at IS NULL AND COALESCE(retry_count, 0) < ?
+	ORDER BY created_at ASC, id ASC
+	`, MaxSyncRetries)
+	if err != nil {
+		return nil, fmt.Errorf("get pending subscription streams: %w", err)
+	}
+	defer rows.Close()
+
+	pending := make(map[string]SubscriptionAction)
+	for rows.Next() {
+		var feedURL, action string
+		if err := rows.Scan(&feedURL, &action); err != nil {
+			return nil, fmt.Errorf("scan pending subscription stream: %w", err)
+		}
+		// Later changes overwrite earlier ones so the map holds the latest action per feed
+		pending[feedURL] = SubscriptionAction(action)
+	}
+
+	return pending, rows.Err()
+}
+
+// MarkSubscriptionChangeSynced marks a subscription change as pushed to the server
+func (db *DB) MarkSubscriptionChangeSynced(id int64) error {
+	db.WaitForReady()
+
+	_, err := db.Exec(`UPDATE freshrss_subscription_queue SET synced_at = ?, sync_error = NULL WHERE id = ?`,
+		time.Now().Unix(), id)
+	if err != nil {
+		return fmt.Errorf("mark subscription change synced: %w", err)
+	}
+	return nil
+}
+
+// MarkSubscriptionChangeFailed records a failed push and schedules a retry with exponential backoff
+func (db *DB) MarkSubscriptionChangeFailed(id int64, errMsg string) error {
+	db.WaitForReady()
+
+	var retryCount int
+	if err := db.QueryRow(`SELECT COALESCE(retry_count, 0) FROM freshrss_subscription_queue WHERE id = ?`, id).Scan(&retryCount); err != nil {
+		return fmt.Errorf("mark subscription change failed: %w", err)
+	}
+	retryCount++
+	nextRetry := time.Now().Add(SyncRetryDelay(retryCount)).Unix()
+
+	_, err := db.Exec(`UPDATE freshrss_subscription_queue SET sync_error = ?, retry_count = ?, next_retry_at = ? WHERE id = ?`,
+		errMsg, retryCount, nextRetry, id)
+	if err != nil {
+		return fmt.Errorf("mark subscription change failed: %w", err)
+	}
+	return nil
+}
+
+// GetFailedSubscriptionChangeCount returns the number of subscription changes that failed and are still pending
+func (db *DB) GetFailedSubscriptionChangeCount() (int, error) {
+	db.WaitForReady()
+
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM freshrss_subscription_queue WHERE sync_error IS NOT NULL AND synced_at IS NULL`).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("get failed subscription change count: %w", err)
+	}
+	return count, nil
+}
+
+// LinkFeedToFreshRSS marks a local feed as a FreshRSS feed once it has been subscribed on the server
+func (db *DB) LinkFeedToFreshRSS(feedID int64, streamID string) error {
+	db.WaitForReady()
+
+	_, err := db.Exec(`UPDATE feeds SET is_freshrss_source = 1, freshrss_stream_id = ? WHERE id = ?`, streamID, feedID)
+	if err != nil {
+		return fmt.Errorf("link feed to FreshRSS: %w", err)
+	}
+	return nil
+}
+
+// LogSyncConflict stores a resolved read/star conflict in the conflict log
+func (db *DB) LogSyncConflict(conflict SyncConflict) error {
+	db.WaitForReady()
+
+	query := `
+	INSERT INTO freshrss_sync_conflicts (article_id, article_url, field, local_value, remote_value,
+		local_changed_at, remote_changed_at, resolution, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := db.Exec(query, conflict.ArticleID, conflict.ArticleURL, conflict.Field,
+		conflict.LocalValue, conflict.RemoteValue, conflict.LocalChangedAt.Unix(),
+		conflict.RemoteChangedAt.Unix(), conflict.Resolution, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("log sync conflict: %w", err)
+	}
+	return nil
+}
+
+// GetSyncConflicts returns the most recent entries of the conflict log
+func (db *DB) GetSyncConflicts(limit int) ([]SyncConflict, error) {
+	db.WaitForReady()
+
+	rows, err := db.Query(`
+	SELECT id, article_id, article_url, field, local_value, remote_value,
+		local_changed_at, remote_changed_at, resolution, created_at
+	FROM freshrss_sync_conflicts
+	ORDER BY created_at DESC, id DESC
+	LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get sync conflicts: %w", err)
+	}
+	defer rows.Close()
+
+	var conflicts []SyncConflict
+	for rows.Next() {
+		var c SyncConflict
+		var localChangedAt, remoteChangedAt, createdAt int64
+		if err := rows.Scan(&c.ID, &c.ArticleID, &c.ArticleURL, &c.Field, &c.LocalValue, &c.RemoteValue,
+			&localChangedAt, &remoteChangedAt, &c.Resolution, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan sync conflict: %w", err)
+		}
+		c.LocalChangedAt = time.Unix(localChangedAt, 0)
+		c.RemoteChangedAt = time.Unix(remoteChangedAt, 0)
+		c.CreatedAt = time.Unix(createdAt, 0)
+		conflicts = append(conflicts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate sync conflicts: %w", err)
+	}
+
+	return conflicts, nil
+}
+
+// DeleteOldSyncConflicts removes conflict log entries older than the given duration
+func (db *DB) DeleteOldSyncConflicts(olderThan time.Duration) error {
+	db.WaitForReady()
+
+	cutoff := time.Now().Add(-olderThan).Unix()
+	if _, err := db.Exec(`DELETE FROM freshrss_sync_conflicts WHERE created_at < ?`, cutoff); err != nil {
+		return fmt.Errorf("delete old sync conflicts: %w", err)
+	}
+	return nil
+}
@@ -26,6 +26,31 @@ type SyncQueueItem struct {
 	CreatedAt  time.Time
 	SyncedAt   *time.Time
 	SyncError  *string
+	RetryCount int
+}
+
+// Retry policy for queue items that failed to sync.
+// Each failure doubles the delay before the next attempt, up to maxSyncRetryDelay.
+// Items that fail MaxSyncRetries times stay in the queue as failed but are no longer retried.
+const (
+	MaxSyncRetries     = 10
+	baseSyncRetryDelay = time.Minute
+	maxSyncRetryDelay  = 6 * time.Hour
+)
+
+// SyncRetryDelay returns the backoff delay before retrying an item that has failed retryCount times.
+func SyncRetryDelay(retryCount int) time.Duration {
+	if retryCount <= 0 {
+		return 0
+	}
+	delay := baseSyncRetryDelay
+	for i := 1; i < retryCount; i++ {
+		delay *= 2
+		if delay >= maxSyncRetryDelay {
+			return maxSyncRetryDelay
+		}
+	}
+	return delay
 }
 
 // InitFreshRSSSyncTable creates the freshrss_sync_queue table if it doesn't exist
@@ -38,12 +63,52 @@ func InitFreshRSSSyncTable(db *sql.DB) error {
 		sync_action TEXT NOT NULL,
 		created_at INTEGER NOT NULL,
 		synced_at INTEGER,
-		sync_error TEXT
+		sync_error TEXT,
+		retry_count INTEGER DEFAULT 0,
+		next_retry_at INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS freshrss_subscription_queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		feed_id INTEGER NOT NULL,
+		feed_url TEXT NOT NULL,
+		stream_id TEXT DEFAULT '',
+		sync_action TEXT NOT NULL,
+		title TEXT DEFAULT '',
+		category TEXT DEFAULT '',
+		old_category TEXT DEFAULT '',
+		created_at INTEGER NOT NULL,
+		synced_at INTEGER,
+		sync_error TEXT,
+		retry_count INTEGER DEFAULT 0,
+		next_retry_at INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS freshrss_sync_conflicts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		article_id INTEGER NOT NULL,
+		article_url TEXT NOT NULL,
+		field TEXT NOT NULL,
+		local_value BOOLEAN NOT NULL,
+		remote_value BOOLEAN NOT NULL,
+		local_changed_at INTEGER NOT NULL,
+		remote_changed_at INTEGER NOT NULL,
+		resolution TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS freshrss_stream_state (
+		stream_id TEXT PRIMARY KEY,
+		last_sync_at INTEGER NOT NULL DEFAULT 0,
+		unread_count INTEGER NOT NULL DEFAULT 0,
+		newest_item_usec INTEGER NOT NULL DEFAULT 0
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_freshrss_sync_article ON freshrss_sync_queue(article_id);
 	CREATE INDEX IF NOT EXISTS idx_freshrss_sync_synced ON freshrss_sync_queue(synced_at);
 	CREATE INDEX IF NOT EXISTS idx_freshrss_sync_url ON freshrss_sync_queue(article_url);
+	CREATE INDEX IF NOT EXISTS idx_freshrss_sub_synced ON freshrss_subscription_queue(synced_at);
+	CREATE INDEX IF NOT EXISTS idx_freshrss_conflicts_created ON freshrss_sync_conflicts(created_at DESC);
 	`
 
 	_, err := db.Exec(query)
@@ -76,12 +141,16 @@ func (db *DB) EnqueueSyncChange(articleID int64, articleURL string, action SyncA
 	return nil
 }
 
-// GetPendingSyncChanges retrieves all pending sync changes that haven't been synced yet
+// syncQueueColumns lists the columns scanned by scanSyncQueueItems.
+const syncQueueColumns = `id, article_id, article_url, sync_action, created_at, synced_at, sync_error, COALESCE(retry_count, 0)`
+
+// GetPendingSyncChanges retrieves all pending sync changes that haven't been synced yet,
+// including items that are waiting for their retry backoff to expire.
 func (db *DB) GetPendingSyncChanges(limit int) ([]SyncQueueItem, error) {
 	db.WaitForReady()
 
 	query := `
-	SELECT id, article_id, article_url, sync_action, created_at, synced_at, sync_error
+	SELECT ` + syncQueueColumns + `
 	FROM freshrss_sync_queue
 	WHERE synced_at IS NULL
 	ORDER BY created_at ASC
@@ -94,44 +163,9 @@ func (db *DB) GetPendingSyncChanges(limit int) ([]SyncQueueItem, error) {
 	}
 	defer rows.Close()
 
-	var items []SyncQueueItem
-	for rows.Next() {
-		var item SyncQueueItem
-		var syncedAt sql.NullInt64
-		var syncError sql.NullString
-		var action string
-		var createdAt int64
-
-		err := rows.Scan(
-			&item.ID,
-			&item.ArticleID,
-			&item.ArticleURL,
-			&action,
-			&createdAt,
-			&syncedAt,
-			&syncError,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("scan sync queue item: %w", err)
-		}
-
-		item.Action = SyncAction(action)
-		item.CreatedAt = time.Unix(createdAt, 0)
-
-		if syncedAt.Valid {
-			t := time.Unix(syncedAt.Int64, 0)
-			item.SyncedAt = &t
-		}
-
-		if syncError.Valid {
-			item.SyncError = &syncError.String
-		}
-
-		items = append(items, item)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterate sync queue items: %w", err)
+	items, err := scanSyncQueueItems(rows)
+	if err != nil {
+		return nil, err
 	}
 
 	log.Printf("[GetPendingSyncChanges] Retrieved %d pending items (limit=%d)", len(items), limit)
@@ -144,12 +178,36 @@ func (db *DB) GetPendingSyncChanges(limit int) ([]SyncQueueItem, error) {
 	return items, nil
 }
 
+// GetRetryableSyncChanges retrieves pending sync changes that are due to be pushed now.
+// Items still inside their backoff window, or that exhausted MaxSyncRetries, are skipped.
+func (db *DB) GetRetryableSyncChanges(limit int) ([]SyncQueueItem, error) {
+	db.WaitForReady()
+
+	query := `
+	SELECT ` + syncQueueColumns + `
+	FROM freshrss_sync_queue
+	WHERE synced_at IS NULL
+		AND COALESCE(retry_count, 0) < ?
+		AND (next_retry_at IS NULL OR next_retry_at <= ?)
+	ORDER BY created_at ASC
+	LIMIT ?
+	`
+
+	rows, err := db.Query(query, MaxSyncRetries, time.Now().Unix(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("get retryable sync changes: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSyncQueueItems(rows)
+}
+
 // GetPendingSyncChangesByAction retrieves pending sync changes grouped by action type
 func (db *DB) GetPendingSyncChangesByAction(action SyncAction, limit int) ([]SyncQueueItem, error) {
 	db.WaitForReady()
 
 	query := `
-	SELECT id, article_id, article_url, sync_action, created_at, synced_at, sync_error
+	SELECT ` + syncQueueColumns + `
 	FROM freshrss_sync_queue
 	WHERE synced_at IS NULL AND sync_action = ?
 	ORDER BY created_at ASC
@@ -162,28 +220,34 @@ func (db *DB) GetPendingSyncChangesByAction(action SyncAction, limit int) ([]Syn
 	}
 	defer rows.Close()
 
+	return scanSyncQueueItems(rows)
+}
+
+// scanSyncQueueItems scans rows selected with syncQueueColumns.
+func scanSyncQueueItems(rows *sql.Rows) ([]SyncQueueItem, error) {
 	var items []SyncQueueItem
 	for rows.Next() {
 		var item SyncQueueItem
 		var syncedAt sql.NullInt64
 		var syncError sql.NullString
-		var actionStr string
+		var action string
 		var createdAt int64
 
 		err := rows.Scan(
 			&item.ID,
 			&item.ArticleID,
 			&item.ArticleURL,
-			&actionStr,
+			&action,
 			&createdAt,
 			&syncedAt,
 			&syncError,
+			&item.RetryCount,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan sync queue item: %w", err)
 		}
 
-		item.Action = SyncAction(actionStr)
+		item.Action = SyncAction(action)
 		item.CreatedAt = time.Unix(createdAt, 0)
 
 		if syncedAt.Valid {
@@ -198,7 +262,7 @@ func (db *DB) GetPendingSyncChangesByAction(action SyncAction, limit int) ([]Syn
 		items = append(items, item)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("iterate sync queue items: %w", err)
 	}
 
@@ -213,7 +277,7 @@ func (db *DB) MarkSynced(itemIDs []int64) error {
 		return nil
 	}
 
-	query := `UPDATE freshrss_sync_queue SET synced_at = ? WHERE id = ?`
+	query := `UPDATE freshrss_sync_queue SET synced_at = ?, sync_error = NULL WHERE id = ?`
 	now := time.Now().Unix()
 
 	for _, id := range itemIDs {
@@ -227,12 +291,20 @@ func (db *DB) MarkSynced(itemIDs []int64) error {
 }
 
 // MarkSyncFailed marks a sync queue item as failed with an error message
+// and schedules its next retry using exponential backoff.
 func (db *DB) MarkSyncFailed(itemID int64, errMsg string) error {
 	db.WaitForReady()
 
-	query := `UPDATE freshrss_sync_queue SET sync_error = ? WHERE id = ?`
+	var retryCount int
+	if err := db.QueryRow(`SELECT COALESCE(retry_count, 0) FROM freshrss_sync_queue WHERE id = ?`, itemID).Scan(&retryCount); err != nil {
+		return fmt.Errorf("mark sync failed: %w", err)
+	}
+	retryCount++
+	nextRetry := time.Now().Add(SyncRetryDelay(retryCount)).Unix()
+
+	query := `UPDATE freshrss_sync_queue SET sync_error = ?, retry_count = ?, next_retry_at = ? WHERE id = ?`
 
-	_, err := db.Exec(query, errMsg, itemID)
+	_, err := db.Exec(query, errMsg, retryCount, nextRetry, itemID)
 	if err != nil {
 		return fmt.Errorf("mark sync failed: %w", err)
 	}
@@ -285,14 +357,14 @@ func (db *DB) DeleteOldSyncedItems(olderThan time.Duration) error {
 	return nil
 }
 
-// GetFailedSyncItems returns sync items that failed to sync
+// GetFailedSyncItems returns sync items that failed to sync and are still pending
 func (db *DB) GetFailedSyncItems(limit int) ([]SyncQueueItem, error) {
 	db.WaitForReady()
 
 	query := `
-	SELECT id, article_id, article_url, sync_action, created_at, synced_at, sync_error
+	SELECT ` + syncQueueColumns + `
 	FROM freshrss_sync_queue
-	WHERE sync_error IS NOT NULL
+	WHERE sync_error IS NOT NULL AND synced_at IS NULL
 	ORDER BY created_at DESC
 	LIMIT ?
 	`
@@ -303,45 +375,21 @@ func (db *DB) GetFailedSyncItems(limit int) ([]SyncQueueItem, error) {
 	}
 	defer rows.Close()
 
-	var items []SyncQueueItem
-	for rows.Next() {
-		var item SyncQueueItem
-		var syncedAt sql.NullInt64
-		var syncError sql.NullString
-		var action string
-		var createdAt int64
-
-		err := rows.Scan(
-			&item.ID,
-			&item.ArticleID,
-			&item.ArticleURL,
-			&action,
-			&createdAt,
-			&syncedAt,
-			&syncError,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("scan sync queue item: %w", err)
-		}
-
-		item.Action = SyncAction(action)
-		item.CreatedAt = time.Unix(createdAt, 0)
+	return scanSyncQueueItems(rows)
+}
 
-		if syncedAt.Valid {
-			t := time.Unix(syncedAt.Int64, 0)
-			item.SyncedAt = &t
-		}
+// ClearPendingSyncActions removes pending sync changes of the given actions for an article.
+// Unlike ClearPendingSyncForArticle, changes to other fields stay queued.
+func (db *DB) ClearPendingSyncActions(articleID int64, actions ...SyncAction) error {
+	db.WaitForReady()
 
-		if syncError.Valid {
-			item.SyncError = &syncError.String
+	for _, action := range actions {
+		_, err := db.Exec(`DELETE FROM freshrss_sync_queue WHERE article_id = ? AND sync_action = ? AND synced_at IS NULL`,
+			articleID, string(action))
+		if err != nil {
+			return fmt.Errorf("clear pending sync actions: %w", err)
 		}
-
-		items = append(items, item)
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("iterate sync queue items: %w", err)
-	}
-
-	return items, nil
+	return nil
 }
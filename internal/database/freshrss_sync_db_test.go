@@ -0,0 +1,98 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	dbpkg "MrRSS/internal/database"
+)
+
+func TestSyncRetryDelay(t *testing.T) {
+	if got := dbpkg.SyncRetryDelay(1); got != time.Minute {
+		t.Fatalf("SyncRetryDelay(1) = %v, want 1m", got)
+	}
+	if got := dbpkg.SyncRetryDelay(3); got != 4*time.Minute {
+		t.Fatalf("SyncRetryDelay(3) = %v, want 4m", got)
+	}
+	if got := dbpkg.SyncRetryDelay(50); got != 6*time.Hour {
+		t.Fatalf("SyncRetryDelay(50) = %v, want 6h cap", got)
+	}
+}
+
+func TestSubscriptionQueueBackoff(t *testing.T) {
+	db := setupTestDB(t)
+
+	change := dbpkg.SubscriptionChange{
+		FeedID:  1,
+		FeedURL: "https://example.com/feed",
+		Action:  dbpkg.SubscriptionActionSubscribe,
+		Title:   "Example",
+	}
+	if err := db.EnqueueSubscriptionChange(change); err != nil {
+		t.Fatalf("EnqueueSubscriptionChange() error = %v", err)
+	}
+
+	changes, err := db.GetRetryableSubscriptionChanges(10)
+	if err != nil || len(changes) != 1 {
+		t.Fatalf("GetRetryableSubscriptionChanges() = %d, %v; want 1 change", len(changes), err)
+	}
+
+	// A failed change is postponed and no longer due
+	if err := db.MarkSubscriptionChangeFailed(changes[0].ID, "server unavailable"); err != nil {
+		t.Fatalf("MarkSubscriptionChangeFailed() error = %v", err)
+	}
+	due, err := db.GetRetryableSubscriptionChanges(10)
+	if err != nil || len(due) != 0 {
+		t.Fatalf("GetRetryableSubscriptionChanges() after failure = %d, %v; want 0", len(due), err)
+	}
+
+	// It still blocks pulls from reverting the local edit
+	pending, err := db.GetPendingSubscriptionStreams()
+	if err != nil || pending[change.FeedURL] != dbpkg.SubscriptionActionSubscribe {
+		t.Fatalf("GetPendingSubscriptionStreams() = %v, %v", pending, err)
+	}
+
+	count, err := db.GetFailedSubscriptionChangeCount()
+	if err != nil || count != 1 {
+		t.Fatalf("GetFailedSubscriptionChangeCount() = %d, %v; want 1", count, err)
+	}
+
+	if err := db.MarkSubscriptionChangeSynced(changes[0].ID); err != nil {
+		t.Fatalf("MarkSubscriptionChangeSynced() error = %v", err)
+	}
+	pending, _ = db.GetPendingSubscriptionStreams()
+	if len(pending) != 0 {
+		t.Fatalf("GetPendingSubscriptionStreams() after sync = %v, want empty", pending)
+	}
+}
+
+func TestFreshRSSStreamState(t *testing.T) {
+	db := setupTestDB(t)
+
+	state, err := db.GetFreshRSSStreamState("feed/1")
+	if err != nil || state != nil {
+		t.Fatalf("GetFreshRSSStreamState() for new stream = %v, %v; want nil", state, err)
+	}
+
+	want := dbpkg.FreshRSSStreamState{
+		StreamID:       "feed/1",
+		LastSyncAt:     time.Unix(1700000000, 0),
+		UnreadCount:    3,
+		NewestItemUsec: 1700000000000000,
+	}
+	if err := db.SaveFreshRSSStreamState(want); err != nil {
+		t.Fatalf("SaveFreshRSSStreamState() error = %v", err)
+	}
+	want.UnreadCount = 5
+	if err := db.SaveFreshRSSStreamState(want); err != nil {
+		t.Fatalf("SaveFreshRSSStreamState() update error = %v", err)
+	}
+
+	state, err = db.GetFreshRSSStreamState("feed/1")
+	if err != nil || state == nil {
+		t.Fatalf("GetFreshRSSStreamState() = %v, %v", state, err)
+	}
+	if !state.LastSyncAt.Equal(want.LastSyncAt) || state.UnreadCount != 5 || state.NewestItemUsec != want.NewestItemUsec {
+		t.Fatalf("GetFreshRSSStreamState() = %+v, want %+v", *state, want)
+	}
+}
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"log"
+
 	"MrRSS/internal/config"
 
 	_ "modernc.org/sqlite"
@@ -17,6 +19,15 @@ func (db *DB) Init() error {
 			return
 		}
 
+		// Refuse databases written by a newer build, the migrations cannot downgrade them
+		var version int
+		if version, err = ReadSchemaVersion(db.DB); err != nil {
+			return
+		}
+		if err = ValidateSchemaVersion(version); err != nil {
+			return
+		}
+
 		if err = initSchema(db.DB); err != nil {
 			return
 		}
@@ -49,6 +60,19 @@ func (db *DB) Init() error {
 		if err = applyAdditionalMigrations(db); err != nil {
 			return
 		}
+
+		// Initialize the keyring of wrapped encryption keys
+		if err = initEncryptionKeyring(db.DB); err != nil {
+			return
+		}
+
+		// Record the schema version so backups can be validated before restore
+		if err = writeSchemaVersion(db.DB); err != nil {
+			return
+		}
+
+		// Unlock the master key from the environment, key file or machine slot if configured
+		db.unlockEncryption()
 	})
 	return err
 }
@@ -95,6 +119,13 @@ func applyAdditionalMigrations(db *DB) error {
 	// Migration: Add summary column to articles table for AI-generated summaries
 	_, _ = db.Exec(`ALTER TABLE articles ADD COLUMN summary TEXT DEFAULT ''`)
 
+	// Migration: Add retry backoff columns to freshrss_sync_queue
+	_, _ = db.Exec(`ALTER TABLE freshrss_sync_queue ADD COLUMN retry_count INTEGER DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE freshrss_sync_queue ADD COLUMN next_retry_at INTEGER`)
+
+	// Migration: Index FreshRSS item IDs for incremental sync lookups
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_articles_freshrss_item_id ON articles(freshrss_item_id)`)
+
 	// Run complex table migrations
 	if err := migrateUniqueIDOnArticles(db.DB); err != nil {
 		return err
@@ -108,5 +139,151 @@ func applyAdditionalMigrations(db *DB) error {
 		return err
 	}
 
+	// Migration: Add IMAP security mode and OAuth login columns to feeds table
+	// (after the feeds table rebuild above, which only copies the columns it knows)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN email_security TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN email_auth_method TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN email_oauth_token_url TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN email_oauth_client_id TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN email_oauth_client_secret TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN email_oauth_refresh_token TEXT DEFAULT ''`)
+
+	// Migration: Track the IMAP folder's UIDVALIDITY alongside email_last_uid
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN email_uid_validity INTEGER DEFAULT 0`)
+
+	// Migration: Newsletter routing, one mailbox account split into per-sender feeds
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN email_account_id INTEGER DEFAULT 0`)
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS email_routes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		account_feed_id INTEGER NOT NULL,
+		feed_id INTEGER NOT NULL,
+		match_type TEXT NOT NULL,
+		pattern TEXT NOT NULL,
+		position INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_email_routes_account ON email_routes(account_feed_id, position)`)
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS email_routing (
+		account_feed_id INTEGER PRIMARY KEY,
+		auto_create_feeds BOOLEAN DEFAULT 0,
+		processed_action TEXT DEFAULT '',
+		processed_folder TEXT DEFAULT ''
+	)`)
+
+	// Migration: Generated addresses for newsletters received by the built-in SMTP/LMTP listener
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN email_inbound_token TEXT DEFAULT ''`)
+	_, _ = db.Exec(`CREATE INDEX IF NOT EXISTS idx_feeds_email_inbound_token ON feeds(email_inbound_token)`)
+
+	// Migration: Pagination and detail page extraction for XPath feeds
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN xpath_next_page TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN xpath_max_pages INTEGER DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN xpath_detail_content TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN xpath_detail_author TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN xpath_detail_timestamp TEXT DEFAULT ''`)
+
+	// Migration: JSON API feeds extracted with JSONPath
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN jsonpath_item TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN jsonpath_item_title TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN jsonpath_item_uri TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN jsonpath_item_content TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN jsonpath_item_author TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN jsonpath_item_timestamp TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN jsonpath_item_time_format TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN jsonpath_item_thumbnail TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN jsonpath_item_uid TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN json_headers TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN json_page_param TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN json_cursor_path TEXT DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN json_max_pages INTEGER DEFAULT 0`)
+
+	// Migration: State handed to feed scripts on their next run
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS script_state (
+		script_path TEXT PRIMARY KEY,
+		last_run_at INTEGER NOT NULL DEFAULT 0,
+		cursor TEXT NOT NULL DEFAULT ''
+	)`)
+
+	// Outcome of each script's latest run, shown in the scripts list
+	_, _ = db.Exec(`ALTER TABLE script_state ADD COLUMN last_attempt_at INTEGER NOT NULL DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE script_state ADD COLUMN last_status TEXT NOT NULL DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE script_state ADD COLUMN last_error TEXT NOT NULL DEFAULT ''`)
+	_, _ = db.Exec(`ALTER TABLE script_state ADD COLUMN last_duration_ms INTEGER NOT NULL DEFAULT 0`)
+	_, _ = db.Exec(`ALTER TABLE script_state ADD COLUMN stderr_tail TEXT NOT NULL DEFAULT ''`)
+
+	// Migration: Per-feed full-text fetching during refresh
+	_, _ = db.Exec(`ALTER TABLE feeds ADD COLUMN full_text_on_ingest BOOLEAN DEFAULT 0`)
+
+	// Migration: Per-site full-text extraction rules
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS site_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		host TEXT NOT NULL UNIQUE,
+		content_selector TEXT NOT NULL DEFAULT '',
+		strip_selectors TEXT NOT NULL DEFAULT '',
+		next_page_selector TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+
+	// Migration: Failure history of failing feeds, for refresh backoff and auto-pause
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS feed_health (
+		feed_id INTEGER PRIMARY KEY,
+		consecutive_failures INTEGER NOT NULL DEFAULT 0,
+		first_failure_at INTEGER NOT NULL DEFAULT 0,
+		last_failure_at INTEGER NOT NULL DEFAULT 0,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		error_class TEXT NOT NULL DEFAULT '',
+		last_error TEXT NOT NULL DEFAULT '',
+		next_retry_at INTEGER NOT NULL DEFAULT 0,
+		paused_at INTEGER NOT NULL DEFAULT 0
+	)`)
+
+	// Migration: Posting statistics and planned next refresh of feeds in intelligent refresh mode
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS feed_refresh_stats (
+		feed_id INTEGER PRIMARY KEY,
+		fetches INTEGER NOT NULL DEFAULT 0,
+		hit_rate REAL NOT NULL DEFAULT 0,
+		last_new_items INTEGER NOT NULL DEFAULT 0,
+		newest_item_at INTEGER NOT NULL DEFAULT 0,
+		last_fetch_at INTEGER NOT NULL DEFAULT 0,
+		next_refresh_at INTEGER NOT NULL DEFAULT 0
+	)`)
+
+	// Migration: Refresh schedule and queued refreshes, kept across restarts
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS feed_schedule (
+		feed_id INTEGER PRIMARY KEY,
+		next_due_at INTEGER NOT NULL DEFAULT 0,
+		last_attempt_at INTEGER NOT NULL DEFAULT 0,
+		last_success_at INTEGER NOT NULL DEFAULT 0,
+		queued_at INTEGER NOT NULL DEFAULT 0
+	)`)
+
+	// Migration: Refresh schedules (cron expressions and time windows) of feeds and categories
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS schedule_rules (
+		feed_id INTEGER NOT NULL DEFAULT 0,
+		category TEXT NOT NULL DEFAULT '',
+		spec TEXT NOT NULL,
+		PRIMARY KEY (feed_id, category)
+	)`)
+
+	// Migration: Retention policies of feeds, tags and categories
+	_, _ = db.Exec(`CREATE TABLE IF NOT EXISTS retention_policies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		feed_id INTEGER NOT NULL DEFAULT 0,
+		tag_id INTEGER NOT NULL DEFAULT 0,
+		category TEXT NOT NULL DEFAULT '',
+		kind TEXT NOT NULL,
+		value INTEGER NOT NULL DEFAULT 0,
+		content_days INTEGER NOT NULL DEFAULT 0,
+		UNIQUE (feed_id, tag_id, category)
+	)`)
+
+	// Migration: Uncompressed size of cached article contents, NULL until
+	// the content is checked for compression
+	_, _ = db.Exec(`ALTER TABLE article_contents ADD COLUMN raw_size INTEGER`)
+
+	// Migration: Read-only views with stable columns for external tools
+	if err := db.createReadViews(); err != nil {
+		log.Printf("Warning: Failed to create read-only views: %v", err)
+	}
+
 	return nil
 }
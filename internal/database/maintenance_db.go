@@ -0,0 +1,280 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Database maintenance operations
+const (
+	MaintenanceIntegrityCheck = "integrity_check"
+	MaintenanceQuickCheck     = "quick_check"
+	MaintenanceOptimize       = "optimize"
+	MaintenanceWALCheckpoint  = "wal_checkpoint"
+	MaintenanceAnalyze        = "analyze"
+	MaintenanceFixOrphans     = "fix_orphans"
+)
+
+// MaintenanceOperations lists the maintenance operations in the order they run
+var MaintenanceOperations = []string{
+	MaintenanceIntegrityCheck,
+	MaintenanceQuickCheck,
+	MaintenanceFixOrphans,
+	MaintenanceAnalyze,
+	MaintenanceOptimize,
+	MaintenanceWALCheckpoint,
+}
+
+// Rows left behind by deleted parents
+const (
+	orphanArticlesCondition = `NOT EXISTS (SELECT 1 FROM feeds f WHERE f.id = articles.feed_id)`
+	orphanContentsCondition = `NOT EXISTS (SELECT 1 FROM articles a WHERE a.id = article_contents.article_id)`
+	orphanSessionsCondition = `article_id NOT IN (SELECT id FROM articles)`
+	orphanMessagesCondition = `session_id NOT IN (SELECT id FROM chat_sessions WHERE article_id IN (SELECT id FROM articles))`
+)
+
+// MaintenanceResult is the outcome of a maintenance operation
+type MaintenanceResult struct {
+	Operation  string        `json:"operation"`
+	OK         bool          `json:"ok"`
+	Messages   []string      `json:"messages,omitempty"` // Problems found by the checks, or checkpoint details
+	Orphans    *OrphanCounts `json:"orphans,omitempty"`  // Orphans removed by fix_orphans
+	DurationMs int64         `json:"duration_ms"`
+}
+
+// TableStats is the size of a table or index
+type TableStats struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`  // table or index
+	Table string `json:"table"` // Table an index belongs to
+	Rows  int64  `json:"rows"`  // Only counted for tables
+	Size  int64  `json:"size"`  // Bytes, including unused space of its pages
+}
+
+// OrphanCounts counts rows whose parent was deleted
+type OrphanCounts struct {
+	Articles     int64 `json:"articles"`      // Articles of deleted feeds
+	Contents     int64 `json:"contents"`      // Cached contents of deleted articles
+	ChatSessions int64 `json:"chat_sessions"` // Chat sessions of deleted articles
+	ChatMessages int64 `json:"chat_messages"` // Messages of deleted or orphaned chat sessions
+}
+
+// IsMaintenanceOperation reports whether op is a known maintenance operation
+func IsMaintenanceOperation(op string) bool {
+	for _, known := range MaintenanceOperations {
+		if op == known {
+			return true
+		}
+	}
+	return false
+}
+
+// RunMaintenance runs one maintenance operation
+func (db *DB) RunMaintenance(op string) (*MaintenanceResult, error) {
+	db.WaitForReady()
+
+	start := time.Now()
+	result := &MaintenanceResult{Operation: op, OK: true}
+	var err error
+
+	switch op {
+	case MaintenanceIntegrityCheck, MaintenanceQuickCheck:
+		result.Messages, err = db.checkIntegrity(op)
+		result.OK = err == nil && len(result.Messages) == 1 && result.Messages[0] == "ok"
+	case MaintenanceOptimize:
+		_, err = db.Exec("PRAGMA optimize")
+	case MaintenanceAnalyze:
+		_, err = db.Exec("ANALYZE")
+	case MaintenanceWALCheckpoint:
+		var busy, logFrames, checkpointed int
+		err = db.QueryRow("PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &logFrames, &checkpointed)
+		result.OK = err == nil && busy == 0
+		result.Messages = []string{fmt.Sprintf("%d of %d frames checkpointed", checkpointed, logFrames)}
+		if busy != 0 {
+			result.Messages = append(result.Messages, "blocked by another connection")
+		}
+	case MaintenanceFixOrphans:
+		result.Orphans, err = db.FixOrphans()
+	default:
+		return nil, fmt.Errorf("unknown maintenance operation %q", op)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// checkIntegrity runs integrity_check or quick_check and returns its messages, "ok" when nothing is wrong
+func (db *DB) checkIntegrity(pragma string) ([]string, error) {
+	rows, err := db.Query("PRAGMA " + pragma)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []string
+	for rows.Next() {
+		var message string
+		if err := rows.Scan(&message); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+// GetTableStats returns the size of every table and index, largest first, with the row counts of tables
+func (db *DB) GetTableStats() ([]TableStats, error) {
+	db.WaitForReady()
+
+	rows, err := db.Query(`
+		SELECT s.name, m.type, m.tbl_name, SUM(s.pgsize)
+		FROM dbstat s
+		JOIN sqlite_schema m ON m.name = s.name
+		WHERE m.type IN ('table', 'index')
+		GROUP BY s.name`)
+	if err != nil {
+		return nil, fmt.Errorf("read dbstat: %w", err)
+	}
+
+	var stats []TableStats
+	for rows.Next() {
+		var s TableStats
+		if err := rows.Scan(&s.Name, &s.Type, &s.Table, &s.Size); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range stats {
+		if stats[i].Type != "table" {
+			continue
+		}
+		quoted := `"` + strings.ReplaceAll(stats[i].Name, `"`, `""`) + `"`
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + quoted).Scan(&stats[i].Rows); err != nil {
+			return nil, fmt.Errorf("count %s: %w", stats[i].Name, err)
+		}
+	}
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Size > stats[j].Size })
+	return stats, nil
+}
+
+// FindOrphans counts the rows whose parent was deleted
+func (db *DB) FindOrphans() (*OrphanCounts, error) {
+	db.WaitForReady()
+
+	counts := &OrphanCounts{}
+	for _, q := range []struct {
+		count *int64
+		query string
+	}{
+		{&counts.Articles, `SELECT COUNT(*) FROM articles WHERE ` + orphanArticlesCondition},
+		{&counts.Contents, `SELECT COUNT(*) FROM article_contents WHERE ` + orphanContentsCondition},
+		{&counts.ChatSessions, `SELECT COUNT(*) FROM chat_sessions WHERE ` + orphanSessionsCondition},
+		{&counts.ChatMessages, `SELECT COUNT(*) FROM chat_messages WHERE ` + orphanMessagesCondition},
+	} {
+		if err := db.QueryRow(q.query).Scan(q.count); err != nil {
+			return nil, fmt.Errorf("count orphans: %w", err)
+		}
+	}
+	return counts, nil
+}
+
+// FixOrphans deletes the rows whose parent was deleted and returns how many were deleted.
+// Orphan articles go first, so that their contents and chat sessions are removed too.
+func (db *DB) FixOrphans() (*OrphanCounts, error) {
+	db.WaitForReady()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	counts := &OrphanCounts{}
+	for _, q := range []struct {
+		count *int64
+		query string
+	}{
+		{&counts.Articles, `DELETE FROM articles WHERE ` + orphanArticlesCondition},
+		{&counts.Contents, `DELETE FROM article_contents WHERE ` + orphanContentsCondition},
+		{&counts.ChatMessages, `DELETE FROM chat_messages WHERE ` + orphanMessagesCondition},
+		{&counts.ChatSessions, `DELETE FROM chat_sessions WHERE ` + orphanSessionsCondition},
+	} {
+		res, err := tx.Exec(q.query)
+		if err != nil {
+			return nil, fmt.Errorf("delete orphans: %w", err)
+		}
+		*q.count, _ = res.RowsAffected()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// ScheduledMaintenanceOperations returns the operations of scheduled maintenance, in the order they run
+func (db *DB) ScheduledMaintenanceOperations() []string {
+	value, _ := db.GetSetting("db_maintenance_operations")
+	selected := map[string]bool{}
+	for _, op := range strings.Split(value, ",") {
+		selected[strings.TrimSpace(op)] = true
+	}
+
+	var ops []string
+	for _, op := range MaintenanceOperations {
+		if selected[op] {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// IsMaintenanceDue reports whether scheduled maintenance should run now
+func (db *DB) IsMaintenanceDue() bool {
+	hoursStr, _ := db.GetSetting("db_maintenance_interval_hours")
+	hours, err := strconv.Atoi(hoursStr)
+	if err != nil || hours <= 0 {
+		return false
+	}
+
+	lastStr, _ := db.GetSetting("last_db_maintenance_time")
+	last, err := time.Parse(time.RFC3339, lastStr)
+	if err != nil {
+		return true
+	}
+	return time.Since(last) >= time.Duration(hours)*time.Hour
+}
+
+// RunScheduledMaintenance runs the operations of scheduled maintenance, logging problems,
+// and records when it ran
+func (db *DB) RunScheduledMaintenance() []*MaintenanceResult {
+	var results []*MaintenanceResult
+	for _, op := range db.ScheduledMaintenanceOperations() {
+		result, err := db.RunMaintenance(op)
+		if err != nil {
+			log.Printf("[Maintenance] %v", err)
+			continue
+		}
+		if !result.OK {
+			log.Printf("[Maintenance] %s: %s", op, strings.Join(result.Messages, "; "))
+		}
+		results = append(results, result)
+	}
+
+	_ = db.SetSetting("last_db_maintenance_time", time.Now().Format(time.RFC3339))
+	return results
+}
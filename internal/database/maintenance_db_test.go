@@ -0,0 +1,102 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	dbpkg "MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func TestDatabaseMaintenance(t *testing.T) {
+	db := setupTestDB(t)
+
+	feedID, err := db.AddFeed(&models.Feed{Title: "blog", URL: "https://example.com/feed"})
+	if err != nil {
+		t.Fatalf("AddFeed: %v", err)
+	}
+	for _, title := range []string{"kept", "orphan"} {
+		article := &models.Article{FeedID: feedID, Title: title, URL: "https://example.com/" + title, PublishedAt: time.Now()}
+		if err := db.SaveArticle(article); err != nil {
+			t.Fatalf("SaveArticle: %v", err)
+		}
+	}
+	var kept, orphan int64
+	db.QueryRow(`SELECT id FROM articles WHERE title = 'kept'`).Scan(&kept)
+	db.QueryRow(`SELECT id FROM articles WHERE title = 'orphan'`).Scan(&orphan)
+
+	// Leave an article of a deleted feed, the content of a deleted article and a chat of a deleted article
+	db.Exec(`UPDATE articles SET feed_id = 999 WHERE id = ?`, orphan)
+	db.SetArticleContent(orphan, "<p>orphan</p>")
+	db.SetArticleContent(kept, "<p>kept</p>")
+	db.Exec(`INSERT INTO article_contents (article_id, content) VALUES (998, 'gone')`)
+	db.Exec(`INSERT INTO chat_sessions (id, article_id, title) VALUES (1, ?, 'kept'), (2, 998, 'gone')`, kept)
+	db.Exec(`INSERT INTO chat_messages (session_id, role, content) VALUES (1, 'user', 'hi'), (2, 'user', 'hi'), (3, 'user', 'hi')`)
+
+	orphans, err := db.FindOrphans()
+	if err != nil {
+		t.Fatalf("FindOrphans: %v", err)
+	}
+	if *orphans != (dbpkg.OrphanCounts{Articles: 1, Contents: 1, ChatSessions: 1, ChatMessages: 2}) {
+		t.Errorf("FindOrphans = %+v, want 1 article, 1 content, 1 chat session and 2 messages", orphans)
+	}
+
+	stats, err := db.GetTableStats()
+	if err != nil {
+		t.Fatalf("GetTableStats: %v", err)
+	}
+	tables := map[string]int64{}
+	for _, s := range stats {
+		if s.Size <= 0 {
+			t.Errorf("%s %s has size %d", s.Type, s.Name, s.Size)
+		}
+		if s.Type == "table" {
+			tables[s.Name] = s.Rows
+		}
+	}
+	if tables["articles"] != 2 || tables["feeds"] != 1 || tables["chat_messages"] != 3 {
+		t.Errorf("Row counts = %v", tables)
+	}
+
+	result, err := db.RunMaintenance("fix_orphans")
+	if err != nil {
+		t.Fatalf("fix_orphans: %v", err)
+	}
+	// The orphan article's content goes with it
+	if *result.Orphans != (dbpkg.OrphanCounts{Articles: 1, Contents: 2, ChatSessions: 1, ChatMessages: 2}) {
+		t.Errorf("fix_orphans removed %+v", result.Orphans)
+	}
+	if orphans, _ := db.FindOrphans(); *orphans != (dbpkg.OrphanCounts{}) {
+		t.Errorf("Orphans left after fixing: %+v", orphans)
+	}
+	if _, found, _ := db.GetArticleContent(kept); !found {
+		t.Error("fix_orphans removed a cached content in use")
+	}
+
+	for _, op := range []string{"integrity_check", "quick_check", "optimize", "analyze"} {
+		result, err := db.RunMaintenance(op)
+		if err != nil || !result.OK {
+			t.Errorf("%s = %+v, %v", op, result, err)
+		}
+	}
+	if _, err := db.RunMaintenance("vacuum_everything"); err == nil {
+		t.Error("Unknown operation did not fail")
+	}
+
+	// Scheduled maintenance runs when due, in a fixed order
+	if db.IsMaintenanceDue() {
+		t.Error("Maintenance due while disabled")
+	}
+	db.SetSetting("db_maintenance_interval_hours", "24")
+	db.SetSetting("db_maintenance_operations", "optimize, quick_check,bogus")
+	if !db.IsMaintenanceDue() {
+		t.Error("Maintenance not due although it never ran")
+	}
+	results := db.RunScheduledMaintenance()
+	if len(results) != 2 || results[0].Operation != "quick_check" || results[1].Operation != "optimize" {
+		t.Errorf("RunScheduledMaintenance = %+v", results)
+	}
+	if db.IsMaintenanceDue() {
+		t.Error("Maintenance still due after running")
+	}
+}
@@ -2,10 +2,46 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"strings"
 )
 
+// SchemaVersion is the schema version produced by the migrations in this file.
+// It is stored in PRAGMA user_version and must be bumped whenever a migration changes the schema
+// in a way older builds cannot read.
+//
+// Version 2 stores article_contents.content gzip-compressed behind a header byte.
+const SchemaVersion = 2
+
+// ReadSchemaVersion returns the schema version stored in a database.
+// Databases created before schema versioning report 0.
+func ReadSchemaVersion(db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// writeSchemaVersion records that all migrations up to SchemaVersion have been applied
+func writeSchemaVersion(db *sql.DB) error {
+	// PRAGMA statements cannot take bound parameters
+	if _, err := db.Exec(fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion)); err != nil {
+		return fmt.Errorf("write schema version: %w", err)
+	}
+	return nil
+}
+
+// ValidateSchemaVersion checks that a database with the given schema version can be opened by this build.
+// Older versions are accepted because the migrations upgrade them on the next Init.
+func ValidateSchemaVersion(version int) error {
+	if version > SchemaVersion {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, SchemaVersion)
+	}
+	return nil
+}
+
 // runMigrations applies database migrations for existing databases.
 // This ensures all columns and tables exist as the schema evolves.
 func runMigrations(db *sql.DB) error {
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"modernc.org/sqlite"
+	sqlitelib "modernc.org/sqlite/lib"
+)
+
+// ReadViewsVersion is the version of the read-only views for external tools, in v_version.
+// Columns of a view are only added within a version; renaming or removing one bumps it.
+const ReadViewsVersion = 1
+
+// readViews are the views external tools read instead of the tables, whose columns
+// change with the app. They leave out secrets and cached contents, which are stored compressed.
+var readViews = []struct {
+	name  string
+	query string
+}{
+	{"v_version", `SELECT ` + fmt.Sprint(ReadViewsVersion) + ` AS version`},
+	{"v_feeds", `
+		SELECT f.id, f.title, f.url, f.link, f.description, f.category, f.image_url,
+			   f.type, f.last_updated, f.last_error,
+			   (SELECT group_concat(t.name, ',') FROM feed_tags ft JOIN tags t ON t.id = ft.tag_id WHERE ft.feed_id = f.id) AS tags,
+			   (SELECT COUNT(*) FROM articles a WHERE a.feed_id = f.id) AS article_count,
+			   (SELECT COUNT(*) FROM articles a WHERE a.feed_id = f.id AND a.is_read = 0) AS unread_count
+		FROM feeds f`},
+	{"v_articles", `
+		SELECT a.id, a.feed_id, f.title AS feed_title, f.category AS feed_category,
+			   a.title, a.translated_title, a.url, a.author, a.summary,
+			   a.image_url, a.audio_url, a.video_url, a.published_at,
+			   a.is_read, a.is_favorite, a.is_read_later, a.is_hidden
+		FROM articles a
+		JOIN feeds f ON f.id = a.feed_id`},
+}
+
+// QueryResult is the result of a read-only query
+type QueryResult struct {
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	Truncated bool            `json:"truncated"` // More rows than the limit
+}
+
+// createReadViews creates the read-only views, replacing those of another version
+func (db *DB) createReadViews() error {
+	var version int
+	if err := db.QueryRow(`SELECT version FROM v_version`).Scan(&version); err == nil && version == ReadViewsVersion {
+		return nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, view := range readViews {
+		if _, err := tx.Exec(`DROP VIEW IF EXISTS ` + view.name); err != nil {
+			return fmt.Errorf("drop view %s: %w", view.name, err)
+		}
+		if _, err := tx.Exec(`CREATE VIEW ` + view.name + ` AS ` + view.query); err != nil {
+			return fmt.Errorf("create view %s: %w", view.name, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// QueryReadOnly runs an ad-hoc query of the read-only views and returns up to limit rows.
+// The query runs on an in-memory snapshot holding only the rows of the views it names,
+// with query_only on and attaching databases disabled, so the tables cannot be reached.
+// Text stored as blobs is returned as text.
+func (db *DB) QueryReadOnly(ctx context.Context, query string, limit int) (*QueryResult, error) {
+	db.WaitForReady()
+
+	file, err := db.filePath()
+	if err != nil {
+		return nil, err
+	}
+	if file == "" {
+		return nil, errors.New("read-only queries need a database file")
+	}
+
+	rdb, err := sql.Open("sqlite", "file::memory:")
+	if err != nil {
+		return nil, err
+	}
+	defer rdb.Close()
+	conn, err := rdb.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := snapshotReadViews(ctx, conn, file, query); err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := &QueryResult{Columns: columns, Rows: [][]interface{}{}}
+	for rows.Next() {
+		if len(result.Rows) == limit {
+			result.Truncated = true
+			break
+		}
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok && utf8.Valid(b) {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, rows.Err()
+}
+
+// snapshotReadViews copies the read-only views a query names from the database file
+// into tables of the same name on an in-memory connection. It then detaches the file
+// and disables attaching, which also stops VACUUM INTO, and turns on query_only.
+func snapshotReadViews(ctx context.Context, conn *sql.Conn, file, query string) error {
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS src`, "file:"+file+"?mode=ro"); err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	lower := strings.ToLower(query)
+	for _, view := range readViews {
+		// Views the query does not name would only be copied in vain
+		if !strings.Contains(lower, view.name) {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, `CREATE TABLE main.`+view.name+` AS SELECT * FROM src.`+view.name); err != nil {
+			return fmt.Errorf("copy %s: %w", view.name, err)
+		}
+	}
+	if _, err := conn.ExecContext(ctx, `DETACH DATABASE src`); err != nil {
+		return fmt.Errorf("close database: %w", err)
+	}
+	if _, err := sqlite.Limit(conn, sqlitelib.SQLITE_LIMIT_ATTACHED, 0); err != nil {
+		return fmt.Errorf("disable attaching: %w", err)
+	}
+	if _, err := conn.ExecContext(ctx, `PRAGMA query_only = ON`); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package database_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	dbpkg "MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func TestQueryReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	db, err := dbpkg.NewDB(filepath.Join(dir, "rss.db"))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	// Views are left alone when their version is current
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init() again error = %v", err)
+	}
+
+	feedID, err := db.AddFeed(&models.Feed{Title: "blog", URL: "https://example.com/feed", Category: "Tech"})
+	if err != nil {
+		t.Fatalf("AddFeed: %v", err)
+	}
+	for _, title := range []string{"first", "second", "third"} {
+		article := &models.Article{FeedID: feedID, Title: title, URL: "https://example.com/" + title, PublishedAt: time.Now()}
+		if err := db.SaveArticle(article); err != nil {
+			t.Fatalf("SaveArticle: %v", err)
+		}
+	}
+	ctx := context.Background()
+
+	result, err := db.QueryReadOnly(ctx, `SELECT title, feed_title, feed_category FROM v_articles ORDER BY title`, 2)
+	if err != nil {
+		t.Fatalf("QueryReadOnly: %v", err)
+	}
+	if len(result.Columns) != 3 || len(result.Rows) != 2 || !result.Truncated || result.Rows[0][0] != "first" || result.Rows[0][2] != "Tech" {
+		t.Errorf("QueryReadOnly = %+v, want 2 of 3 rows", result)
+	}
+	result, err = db.QueryReadOnly(ctx, `SELECT v.version, f.article_count, f.unread_count FROM v_version v, v_feeds f`, 10)
+	if err != nil || len(result.Rows) != 1 || result.Rows[0][0] != int64(dbpkg.ReadViewsVersion) || result.Rows[0][1] != int64(3) {
+		t.Errorf("QueryReadOnly of v_feeds = %+v, %v", result, err)
+	}
+
+	// Every column of every view can be read, also those named like tables
+	for _, view := range []string{"v_articles", "v_feeds", "v_version"} {
+		var want []string
+		rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, view)
+		if err != nil {
+			t.Fatalf("columns of %s: %v", view, err)
+		}
+		for rows.Next() {
+			var name string
+			rows.Scan(&name)
+			want = append(want, name)
+		}
+		rows.Close()
+
+		result, err := db.QueryReadOnly(ctx, `SELECT `+strings.Join(want, ", ")+` FROM `+view, 10)
+		if err != nil {
+			t.Errorf("QueryReadOnly of every column of %s: %v", view, err)
+			continue
+		}
+		if len(result.Columns) != len(want) || len(result.Rows) == 0 {
+			t.Errorf("QueryReadOnly of %s = %d columns and %d rows, want %d columns", view, len(result.Columns), len(result.Rows), len(want))
+		}
+	}
+	result, err = db.QueryReadOnly(ctx, `SELECT tags, 'feeds' AS settings FROM v_feeds`, 10)
+	if err != nil || len(result.Rows) != 1 || result.Rows[0][1] != "feeds" {
+		t.Errorf("QueryReadOnly of tags = %+v, %v", result, err)
+	}
+
+	// Only the views are there: tables, attaching the file and writing it elsewhere all fail
+	for _, query := range []string{
+		`SELECT * FROM settings`,
+		`SELECT * FROM v_feeds; SELECT value FROM settings`,
+		`SELECT * FROM main.articles`,
+		`DELETE FROM v_articles`,
+		`SELECT 1; CREATE TABLE notes (x)`,
+		`ATTACH DATABASE '` + filepath.Join(dir, "rss.db") + `' AS app; SELECT * FROM app.settings`,
+		`PRAGMA query_only = OFF; VACUUM INTO '` + filepath.Join(dir, "copy.db") + `'`,
+	} {
+		if _, err := db.QueryReadOnly(ctx, query, 10); err == nil {
+			t.Errorf("QueryReadOnly(%q) did not fail", query)
+		}
+	}
+	result, err = db.QueryReadOnly(ctx, `SELECT name FROM sqlite_master`, 10)
+	if err != nil || len(result.Rows) != 0 {
+		t.Errorf("QueryReadOnly of the schema = %+v, %v, want no tables", result, err)
+	}
+
+	var count int
+	db.QueryRow(`SELECT COUNT(*) FROM articles`).Scan(&count)
+	if count != 3 {
+		t.Errorf("Read-only queries changed the database: %d articles left", count)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "copy.db")); err == nil {
+		t.Error("VACUUM INTO wrote a copy of the database")
+	}
+}
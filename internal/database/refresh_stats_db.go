@@ -0,0 +1,94 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// FeedRefreshStats is what intelligent refresh learned about a feed from its past refreshes
+type FeedRefreshStats struct {
+	FeedID        int64
+	Fetches       int       // Successful refreshes observed
+	HitRate       float64   // Moving average of the share of refreshes that found new items
+	LastNewItems  int       // New items found by the last refresh
+	NewestItemAt  time.Time // Publication time of the newest item seen
+	LastFetchAt   time.Time
+	NextRefreshAt time.Time // Planned next refresh (zero = not planned yet)
+}
+
+const feedRefreshStatsColumns = `feed_id, fetches, hit_rate, last_new_items, newest_item_at, last_fetch_at, next_refresh_at`
+
+// scanFeedRefreshStats scans a row of feedRefreshStatsColumns
+func scanFeedRefreshStats(scan func(dest ...interface{}) error) (*FeedRefreshStats, error) {
+	var s FeedRefreshStats
+	var newestItemAt, lastFetchAt, nextRefreshAt int64
+	if err := scan(&s.FeedID, &s.Fetches, &s.HitRate, &s.LastNewItems, &newestItemAt, &lastFetchAt, &nextRefreshAt); err != nil {
+		return nil, err
+	}
+	s.NewestItemAt = unixTime(newestItemAt)
+	s.LastFetchAt = unixTime(lastFetchAt)
+	s.NextRefreshAt = unixTime(nextRefreshAt)
+	return &s, nil
+}
+
+// GetFeedRefreshStats returns the refresh statistics of a feed, or nil if it has none yet
+func (db *DB) GetFeedRefreshStats(feedID int64) (*FeedRefreshStats, error) {
+	db.WaitForReady()
+
+	row := db.QueryRow(`SELECT `+feedRefreshStatsColumns+` FROM feed_refresh_stats WHERE feed_id = ?`, feedID)
+	s, err := scanFeedRefreshStats(row.Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get feed refresh stats: %w", err)
+	}
+	return s, nil
+}
+
+// SaveFeedRefreshStats stores the refresh statistics of a feed
+func (db *DB) SaveFeedRefreshStats(s FeedRefreshStats) error {
+	db.WaitForReady()
+
+	_, err := db.Exec(`
+		INSERT INTO feed_refresh_stats (`+feedRefreshStatsColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(feed_id) DO UPDATE SET
+			fetches = excluded.fetches,
+			hit_rate = excluded.hit_rate,
+			last_new_items = excluded.last_new_items,
+			newest_item_at = excluded.newest_item_at,
+			last_fetch_at = excluded.last_fetch_at,
+			next_refresh_at = excluded.next_refresh_at
+	`, s.FeedID, s.Fetches, s.HitRate, s.LastNewItems, timeUnix(s.NewestItemAt), timeUnix(s.LastFetchAt), timeUnix(s.NextRefreshAt))
+	if err != nil {
+		return fmt.Errorf("save feed refresh stats: %w", err)
+	}
+	return nil
+}
+
+// GetArticlePublishTimes returns the publication times of a feed's newest articles, newest first
+func (db *DB) GetArticlePublishTimes(feedID int64, limit int) ([]time.Time, error) {
+	db.WaitForReady()
+
+	rows, err := db.Query(`SELECT published_at FROM articles
+		WHERE feed_id = ? AND published_at IS NOT NULL
+		ORDER BY published_at DESC LIMIT ?`, feedID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("get article publish times: %w", err)
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	for rows.Next() {
+		var publishedAt sql.NullTime
+		if err := rows.Scan(&publishedAt); err != nil {
+			return nil, fmt.Errorf("scan publish time: %w", err)
+		}
+		if publishedAt.Valid && !publishedAt.Time.IsZero() {
+			times = append(times, publishedAt.Time)
+		}
+	}
+	return times, rows.Err()
+}
@@ -0,0 +1,332 @@
+package database
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// RetentionPreview is what a retention policy would delete
+type RetentionPreview struct {
+	Policy   models.RetentionPolicy // ID 0 for the global default policy
+	Default  bool                   // The global default policy of feeds without one of their own
+	Feeds    int                    // Feeds the policy applies to
+	Articles int64                  // Articles it would delete
+	Contents int64                  // Cached article contents it would delete
+}
+
+const retentionPolicyColumns = `id, feed_id, tag_id, category, kind, value, content_days`
+
+// GetRetentionPolicies returns all retention policies
+func (db *DB) GetRetentionPolicies() ([]models.RetentionPolicy, error) {
+	db.WaitForReady()
+
+	rows, err := db.Query(`SELECT ` + retentionPolicyColumns + ` FROM retention_policies ORDER BY feed_id, tag_id, category`)
+	if err != nil {
+		return nil, fmt.Errorf("get retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := make([]models.RetentionPolicy, 0)
+	for rows.Next() {
+		var p models.RetentionPolicy
+		if err := rows.Scan(&p.ID, &p.FeedID, &p.TagID, &p.Category, &p.Kind, &p.Value, &p.ContentDays); err != nil {
+			return nil, fmt.Errorf("scan retention policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// SaveRetentionPolicy stores the retention policy of a feed, tag or category,
+// replacing the one it had, and returns its ID
+func (db *DB) SaveRetentionPolicy(p models.RetentionPolicy) (int64, error) {
+	db.WaitForReady()
+
+	_, err := db.Exec(`INSERT INTO retention_policies (feed_id, tag_id, category, kind, value, content_days)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(feed_id, tag_id, category) DO UPDATE SET
+			kind = excluded.kind,
+			value = excluded.value,
+			content_days = excluded.content_days`,
+		p.FeedID, p.TagID, p.Category, p.Kind, p.Value, p.ContentDays)
+	if err != nil {
+		return 0, fmt.Errorf("save retention policy: %w", err)
+	}
+
+	var id int64
+	err = db.QueryRow(`SELECT id FROM retention_policies WHERE feed_id = ? AND tag_id = ? AND category = ?`,
+		p.FeedID, p.TagID, p.Category).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("save retention policy: %w", err)
+	}
+	return id, nil
+}
+
+// DeleteRetentionPolicy removes a retention policy
+func (db *DB) DeleteRetentionPolicy(id int64) error {
+	db.WaitForReady()
+	_, err := db.Exec(`DELETE FROM retention_policies WHERE id = ?`, id)
+	return err
+}
+
+// defaultRetentionPolicy is the policy of feeds without one of their own: the
+// articles are kept max_article_age_days
+func (db *DB) defaultRetentionPolicy() models.RetentionPolicy {
+	maxAgeDays := 30
+	if value, err := db.GetSetting("max_article_age_days"); err == nil {
+		if days, err := strconv.Atoi(value); err == nil && days > 0 {
+			maxAgeDays = days
+		}
+	}
+	return models.RetentionPolicy{Kind: models.RetentionKeepDays, Value: maxAgeDays}
+}
+
+// retentionPlan maps each policy to the feeds it applies to. A feed's own policy
+// comes first, then the policy of its first tag that has one (in tag order), then
+// the one of its category or closest parent category. Without withDefault, feeds
+// without a policy are left out, else they follow the default policy.
+func (db *DB) retentionPlan(withDefault bool) ([]models.RetentionPolicy, map[int][]int64, error) {
+	policies, err := db.GetRetentionPolicies()
+	if err != nil {
+		return nil, nil, err
+	}
+	byFeed := make(map[int64]int)
+	byTag := make(map[int64]int)
+	byCategory := make(map[string]int)
+	for i, p := range policies {
+		switch {
+		case p.FeedID != 0:
+			byFeed[p.FeedID] = i
+		case p.TagID != 0:
+			byTag[p.TagID] = i
+		default:
+			byCategory[p.Category] = i
+		}
+	}
+	defaultIndex := -1
+	if withDefault {
+		policies = append(policies, db.defaultRetentionPolicy())
+		defaultIndex = len(policies) - 1
+	}
+
+	feedTags := make(map[int64][]int64)
+	rows, err := db.Query(`SELECT ft.feed_id, ft.tag_id FROM feed_tags ft
+		JOIN tags t ON t.id = ft.tag_id ORDER BY t.position, t.id`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get feed tags: %w", err)
+	}
+	for rows.Next() {
+		var feedID, tagID int64
+		if err := rows.Scan(&feedID, &tagID); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("scan feed tag: %w", err)
+		}
+		feedTags[feedID] = append(feedTags[feedID], tagID)
+	}
+	rows.Close()
+
+	rows, err = db.Query(`SELECT id, COALESCE(category, '') FROM feeds`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get feeds: %w", err)
+	}
+	defer rows.Close()
+
+	plan := make(map[int][]int64)
+	for rows.Next() {
+		var feedID int64
+		var category string
+		if err := rows.Scan(&feedID, &category); err != nil {
+			return nil, nil, fmt.Errorf("scan feed: %w", err)
+		}
+		if i := resolveRetention(feedID, category, feedTags[feedID], byFeed, byTag, byCategory, defaultIndex); i >= 0 {
+			plan[i] = append(plan[i], feedID)
+		}
+	}
+	return policies, plan, rows.Err()
+}
+
+// resolveRetention returns the index of the policy of a feed, -1 without one
+func resolveRetention(feedID int64, category string, tagIDs []int64, byFeed, byTag map[int64]int, byCategory map[string]int, defaultIndex int) int {
+	if i, ok := byFeed[feedID]; ok {
+		return i
+	}
+	for _, tagID := range tagIDs {
+		if i, ok := byTag[tagID]; ok {
+			return i
+		}
+	}
+	for category != "" {
+		if i, ok := byCategory[category]; ok {
+			return i
+		}
+		slash := strings.LastIndex(category, "/")
+		if slash < 0 {
+			break
+		}
+		category = category[:slash]
+	}
+	return defaultIndex
+}
+
+// retentionCondition returns the condition selecting the articles of a feed a
+// policy deletes, empty for policies that keep everything
+func retentionCondition(p models.RetentionPolicy, feedID int64, now time.Time) (string, []interface{}) {
+	const base = `feed_id = ? AND is_favorite = 0 AND is_read_later = 0`
+	cutoff := now.AddDate(0, 0, -p.Value)
+	switch p.Kind {
+	case models.RetentionKeepDays:
+		return base + ` AND published_at < ?`, []interface{}{feedID, cutoff}
+	case models.RetentionDeleteReadAfter:
+		return base + ` AND is_read = 1 AND published_at < ?`, []interface{}{feedID, cutoff}
+	case models.RetentionKeepLast:
+		return base + ` AND id NOT IN (SELECT id FROM articles WHERE feed_id = ?
+			ORDER BY published_at DESC, id DESC LIMIT ?)`, []interface{}{feedID, feedID, p.Value}
+	}
+	return "", nil
+}
+
+// contentRetentionCondition returns the condition selecting the cached contents
+// of a feed a policy deletes: the contents of the articles it deletes, and
+// contents older than its content age. It is empty when nothing is deleted.
+func contentRetentionCondition(p models.RetentionPolicy, feedID int64, articleCondition string, articleArgs []interface{}, defaultDays int) (string, []interface{}) {
+	conditions := make([]string, 0, 2)
+	args := make([]interface{}, 0, len(articleArgs)+2)
+	if articleCondition != "" {
+		conditions = append(conditions, `article_id IN (SELECT id FROM articles WHERE `+articleCondition+`)`)
+		args = append(args, articleArgs...)
+	}
+
+	days := p.ContentDays
+	if days == 0 {
+		days = defaultDays
+	}
+	if days > 0 {
+		conditions = append(conditions, `(article_id IN (SELECT id FROM articles WHERE feed_id = ?)
+			AND fetched_at < datetime('now', '-' || ? || ' days'))`)
+		args = append(args, feedID, days)
+	}
+	return strings.Join(conditions, ` OR `), args
+}
+
+// runRetention counts, or deletes when apply is set, what the retention
+// policies delete. Without withDefault only feeds with a policy are cleaned up.
+func (db *DB) runRetention(withDefault, apply bool) ([]RetentionPreview, error) {
+	db.WaitForReady()
+
+	policies, plan, err := db.retentionPlan(withDefault)
+	if err != nil {
+		return nil, err
+	}
+	// Cached contents follow max_article_age_days when their policy sets no age,
+	// as long as the default policy applies
+	defaultContentDays := 0
+	if withDefault {
+		defaultContentDays = db.defaultRetentionPolicy().Value
+	}
+
+	now := time.Now()
+	previews := make([]RetentionPreview, len(policies))
+	for i, p := range policies {
+		preview := RetentionPreview{Policy: p, Default: withDefault && i == len(policies)-1}
+		for _, feedID := range plan[i] {
+			preview.Feeds++
+			condition, args := retentionCondition(p, feedID, now)
+			articles, contents, err := db.retentionFeed(p, feedID, condition, args, defaultContentDays, apply)
+			if err != nil {
+				return nil, fmt.Errorf("apply retention policy to feed %d: %w", feedID, err)
+			}
+			preview.Articles += articles
+			preview.Contents += contents
+		}
+		previews[i] = preview
+	}
+	return previews, nil
+}
+
+// retentionFeed counts, or deletes when apply is set, the articles and cached
+// contents of a feed a policy deletes
+func (db *DB) retentionFeed(p models.RetentionPolicy, feedID int64, condition string, args []interface{}, defaultContentDays int, apply bool) (int64, int64, error) {
+	if !apply {
+		contentCondition, contentArgs := contentRetentionCondition(p, feedID, condition, args, defaultContentDays)
+		contents, err := db.retentionStep("article_contents", false, contentCondition, contentArgs)
+		if err != nil {
+			return 0, 0, err
+		}
+		articles, err := db.retentionStep("articles", false, condition, args)
+		return articles, contents, err
+	}
+
+	// Contents past their age go first, then the articles take their own
+	// contents along, to the archive when it is on
+	contentCondition, contentArgs := contentRetentionCondition(p, feedID, "", nil, defaultContentDays)
+	contents, err := db.retentionStep("article_contents", true, contentCondition, contentArgs)
+	if err != nil || condition == "" {
+		return 0, contents, err
+	}
+	articles, removedContents, err := db.removeArticles(condition, args...)
+	return articles, contents + removedContents, err
+}
+
+// retentionStep counts or deletes the rows of a table matching a condition
+func (db *DB) retentionStep(table string, apply bool, condition string, args []interface{}) (int64, error) {
+	if condition == "" {
+		return 0, nil
+	}
+	if apply {
+		result, err := db.Exec(`DELETE FROM `+table+` WHERE `+condition, args...)
+		if err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	}
+	var count int64
+	err := db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE `+condition, args...).Scan(&count)
+	return count, err
+}
+
+// PreviewRetention returns how many articles and cached contents each retention
+// policy would delete, and with withDefault the global default policy last
+func (db *DB) PreviewRetention(withDefault bool) ([]RetentionPreview, error) {
+	return db.runRetention(withDefault, false)
+}
+
+// ApplyRetention deletes the articles and cached contents the retention
+// policies, and with withDefault the global default policy, do not keep
+func (db *DB) ApplyRetention(withDefault bool) (articles, contents int64, err error) {
+	previews, err := db.runRetention(withDefault, true)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, p := range previews {
+		articles += p.Articles
+		contents += p.Contents
+	}
+	return articles, contents, nil
+}
+
+// retainedFeedsCondition returns a condition leaving out the articles of feeds
+// kept forever, for cleanups that free space, empty without such feeds.
+// Cleanups must not run when it fails, as they could delete those articles.
+func (db *DB) retainedFeedsCondition() (string, error) {
+	policies, plan, err := db.retentionPlan(false)
+	if err != nil {
+		return "", fmt.Errorf("load retention policies: %w", err)
+	}
+	ids := make([]string, 0)
+	for i, p := range policies {
+		if p.Kind != models.RetentionKeepForever {
+			continue
+		}
+		for _, feedID := range plan[i] {
+			ids = append(ids, strconv.FormatInt(feedID, 10))
+		}
+	}
+	if len(ids) == 0 {
+		return "", nil
+	}
+	return ` AND feed_id NOT IN (` + strings.Join(ids, ",") + `)`, nil
+}
@@ -0,0 +1,154 @@
+package database_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+func TestRetentionPolicies(t *testing.T) {
+	db := setupTestDB(t)
+	db.SetSetting("max_article_age_days", "3")
+
+	// Every feed gets five articles, 1h to 4 days and 1h old; the oldest is a favorite
+	now := time.Now()
+	addFeed := func(title, category string) int64 {
+		id, err := db.AddFeed(&models.Feed{Title: title, URL: "https://example.com/" + title, Category: category})
+		if err != nil {
+			t.Fatalf("AddFeed: %v", err)
+		}
+		for i := 0; i < 5; i++ {
+			article := &models.Article{
+				FeedID:                id,
+				Title:                 fmt.Sprintf("%s %d", title, i),
+				URL:                   fmt.Sprintf("https://example.com/%s/%d", title, i),
+				PublishedAt:           now.Add(-time.Duration(i*24+1) * time.Hour),
+				HasValidPublishedTime: true,
+				IsFavorite:            i == 4,
+			}
+			if err := db.SaveArticle(article); err != nil {
+				t.Fatalf("SaveArticle: %v", err)
+			}
+		}
+		return id
+	}
+	articleID := func(title string) int64 {
+		var id int64
+		if err := db.QueryRow(`SELECT id FROM articles WHERE title = ?`, title).Scan(&id); err != nil {
+			t.Fatalf("article %s: %v", title, err)
+		}
+		return id
+	}
+	count := func(feedID int64) int {
+		var n int
+		db.QueryRow(`SELECT COUNT(*) FROM articles WHERE feed_id = ?`, feedID).Scan(&n)
+		return n
+	}
+
+	news := addFeed("news", "News/World")
+	research := addFeed("research", "Research")
+	tagged := addFeed("tagged", "")
+	reads := addFeed("reads", "")
+	plain := addFeed("plain", "")
+
+	tagID, err := db.AddTag(&models.Tag{Name: "Latest"})
+	if err != nil {
+		t.Fatalf("AddTag: %v", err)
+	}
+	if err := db.SetFeedTags(tagged, []int64{tagID}); err != nil {
+		t.Fatalf("SetFeedTags: %v", err)
+	}
+	db.Exec(`UPDATE articles SET is_read = 1 WHERE feed_id IN (?, ?) AND title NOT LIKE '% 0'`, reads, research)
+	if err := db.SetArticleContent(articleID("tagged 1"), "<p>content</p>"); err != nil {
+		t.Fatalf("SetArticleContent: %v", err)
+	}
+	if err := db.SetArticleContent(articleID("research 4"), "<p>content</p>"); err != nil {
+		t.Fatalf("SetArticleContent: %v", err)
+	}
+
+	for _, p := range []models.RetentionPolicy{
+		{Category: "News", Kind: models.RetentionKeepDays, Value: 2},
+		{Category: "Research", Kind: models.RetentionKeepForever, ContentDays: -1},
+		{TagID: tagID, Kind: models.RetentionKeepLast, Value: 1},
+		{FeedID: reads, Kind: models.RetentionDeleteReadAfter, Value: 1},
+	} {
+		if _, err := db.SaveRetentionPolicy(p); err != nil {
+			t.Fatalf("SaveRetentionPolicy: %v", err)
+		}
+	}
+	// Saving again replaces the policy
+	if _, err := db.SaveRetentionPolicy(models.RetentionPolicy{Category: "News", Kind: models.RetentionKeepDays, Value: 2}); err != nil {
+		t.Fatalf("SaveRetentionPolicy: %v", err)
+	}
+	policies, _ := db.GetRetentionPolicies()
+	if len(policies) != 4 {
+		t.Fatalf("Got %d policies, want 4", len(policies))
+	}
+
+	previews, err := db.PreviewRetention(false)
+	if err != nil {
+		t.Fatalf("PreviewRetention: %v", err)
+	}
+	want := map[string][2]int64{"News": {2, 0}, "Research": {0, 0}, "tag": {3, 1}, "feed": {3, 0}}
+	for _, p := range previews {
+		key := p.Policy.Category
+		if p.Policy.TagID != 0 {
+			key = "tag"
+		} else if p.Policy.FeedID != 0 {
+			key = "feed"
+		}
+		if got := [2]int64{p.Articles, p.Contents}; p.Feeds != 1 || got != want[key] {
+			t.Errorf("Preview of %s: %d feeds, articles and contents %v, want 1 feed and %v", key, p.Feeds, got, want[key])
+		}
+	}
+	if len(previews) != 4 || count(news) != 5 {
+		t.Fatalf("Preview changed something or has %d entries", len(previews))
+	}
+
+	previews, _ = db.PreviewRetention(true)
+	if last := previews[len(previews)-1]; !last.Default || last.Feeds != 1 || last.Articles != 1 {
+		t.Errorf("Default policy preview = %+v, want 1 article of 1 feed", last)
+	}
+
+	articles, contents, err := db.ApplyRetention(true)
+	if err != nil {
+		t.Fatalf("ApplyRetention: %v", err)
+	}
+	if articles != 9 || contents != 1 {
+		t.Errorf("ApplyRetention deleted %d articles and %d contents, want 9 and 1", articles, contents)
+	}
+	for feedID, want := range map[int64]int{news: 3, research: 5, tagged: 2, reads: 2, plain: 4} {
+		if got := count(feedID); got != want {
+			t.Errorf("Feed %d has %d articles, want %d", feedID, got, want)
+		}
+	}
+
+	// Cleanups freeing space leave feeds kept forever alone
+	if _, err := db.CleanupOldReadArticles(0); err != nil {
+		t.Fatalf("CleanupOldReadArticles: %v", err)
+	}
+	if got := count(research); got != 5 {
+		t.Errorf("Research feed has %d articles after freeing space, want 5", got)
+	}
+
+	// Removing a tag removes its policy
+	if err := db.DeleteTag(tagID); err != nil {
+		t.Fatalf("DeleteTag: %v", err)
+	}
+	if policies, _ := db.GetRetentionPolicies(); len(policies) != 3 {
+		t.Errorf("Got %d policies after removing the tag, want 3", len(policies))
+	}
+
+	// Without the policies, cleanups freeing space do not run at all
+	if _, err := db.Exec(`ALTER TABLE retention_policies RENAME TO retention_policies_gone`); err != nil {
+		t.Fatalf("rename policies: %v", err)
+	}
+	if _, err := db.CleanupOldUnreadArticles(0); err == nil {
+		t.Error("CleanupOldUnreadArticles ran without the retention policies")
+	}
+	if got := count(research); got != 5 {
+		t.Errorf("Research feed has %d articles after a failed cleanup, want 5", got)
+	}
+}
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScheduleRule is the refresh schedule of a feed or, with FeedID 0, of a category
+type ScheduleRule struct {
+	FeedID   int64
+	Category string
+	Spec     string // Cron expressions and time windows, see package schedule
+}
+
+// GetScheduleRules returns the refresh schedules of all feeds and categories
+func (db *DB) GetScheduleRules() ([]ScheduleRule, error) {
+	db.WaitForReady()
+
+	rows, err := db.Query(`SELECT feed_id, category, spec FROM schedule_rules ORDER BY feed_id, category`)
+	if err != nil {
+		return nil, fmt.Errorf("get schedule rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]ScheduleRule, 0)
+	for rows.Next() {
+		var r ScheduleRule
+		if err := rows.Scan(&r.FeedID, &r.Category, &r.Spec); err != nil {
+			return nil, fmt.Errorf("scan schedule rule: %w", err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// SaveScheduleRule stores the refresh schedule of a feed or category, removing
+// it when the spec is empty. The planned refreshes of the affected feeds are
+// dropped so they are planned again with the new schedule.
+func (db *DB) SaveScheduleRule(r ScheduleRule) error {
+	db.WaitForReady()
+
+	if r.FeedID != 0 {
+		r.Category = ""
+	}
+	var err error
+	if strings.TrimSpace(r.Spec) == "" {
+		_, err = db.Exec(`DELETE FROM schedule_rules WHERE feed_id = ? AND category = ?`, r.FeedID, r.Category)
+	} else {
+		_, err = db.Exec(`INSERT INTO schedule_rules (feed_id, category, spec) VALUES (?, ?, ?)
+			ON CONFLICT(feed_id, category) DO UPDATE SET spec = excluded.spec`,
+			r.FeedID, r.Category, strings.TrimSpace(r.Spec))
+	}
+	if err != nil {
+		return fmt.Errorf("save schedule rule: %w", err)
+	}
+
+	if r.FeedID != 0 {
+		_, _ = db.Exec(`UPDATE feed_schedule SET next_due_at = 0 WHERE feed_id = ?`, r.FeedID)
+	} else {
+		_, _ = db.Exec(`UPDATE feed_schedule SET next_due_at = 0 WHERE feed_id IN
+			(SELECT id FROM feeds WHERE category = ? OR substr(category, 1, ?) = ?)`,
+			r.Category, len(r.Category)+1, r.Category+"/")
+	}
+	return nil
+}
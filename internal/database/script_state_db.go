@@ -0,0 +1,125 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ScriptState is what a feed script left for its next run
+type ScriptState struct {
+	ScriptPath string
+	LastRunAt  time.Time // End of the last successful run (zero = never)
+	Cursor     string    // Value the script stored for its next run
+}
+
+// GetScriptState returns the state of a script, or nil if it never ran
+func (db *DB) GetScriptState(scriptPath string) (*ScriptState, error) {
+	db.WaitForReady()
+
+	var state ScriptState
+	var lastRunAt int64
+	err := db.QueryRow(`
+		SELECT script_path, last_run_at, cursor FROM script_state WHERE script_path = ?
+	`, scriptPath).Scan(&state.ScriptPath, &lastRunAt, &state.Cursor)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get script state: %w", err)
+	}
+
+	if lastRunAt > 0 {
+		state.LastRunAt = time.Unix(lastRunAt, 0)
+	}
+	return &state, nil
+}
+
+// SaveScriptState stores the state of a script after a successful run
+func (db *DB) SaveScriptState(state ScriptState) error {
+	db.WaitForReady()
+
+	_, err := db.Exec(`
+		INSERT INTO script_state (script_path, last_run_at, cursor)
+		VALUES (?, ?, ?)
+		ON CONFLICT(script_path) DO UPDATE SET
+			last_run_at = excluded.last_run_at,
+			cursor = excluded.cursor
+	`, state.ScriptPath, state.LastRunAt.Unix(), state.Cursor)
+	if err != nil {
+		return fmt.Errorf("save script state: %w", err)
+	}
+	return nil
+}
+
+// Statuses of a script run
+const (
+	ScriptRunOK     = "ok"
+	ScriptRunFailed = "error"
+)
+
+// ScriptRun is the outcome of the latest run of a script
+type ScriptRun struct {
+	ScriptPath    string
+	LastAttemptAt time.Time     // Start of the latest run
+	LastRunAt     time.Time     // End of the last successful run (zero = never), read only
+	Status        string        // ScriptRunOK or ScriptRunFailed
+	Error         string        // Why the run failed
+	Duration      time.Duration // Run time
+	StderrTail    string        // End of what the script wrote to stderr
+}
+
+// SaveScriptRun records the outcome of a script's latest run, keeping its state
+func (db *DB) SaveScriptRun(run ScriptRun) error {
+	db.WaitForReady()
+
+	_, err := db.Exec(`
+		INSERT INTO script_state (script_path, last_attempt_at, last_status, last_error, last_duration_ms, stderr_tail)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(script_path) DO UPDATE SET
+			last_attempt_at = excluded.last_attempt_at,
+			last_status = excluded.last_status,
+			last_error = excluded.last_error,
+			last_duration_ms = excluded.last_duration_ms,
+			stderr_tail = excluded.stderr_tail
+	`, run.ScriptPath, run.LastAttemptAt.Unix(), run.Status, run.Error, run.Duration.Milliseconds(), run.StderrTail)
+	if err != nil {
+		return fmt.Errorf("save script run: %w", err)
+	}
+	return nil
+}
+
+// GetScriptRuns returns the latest run of every script that ran, keyed by script path
+func (db *DB) GetScriptRuns() (map[string]ScriptRun, error) {
+	db.WaitForReady()
+
+	rows, err := db.Query(`
+		SELECT script_path, last_attempt_at, last_run_at, last_status, last_error, last_duration_ms, stderr_tail
+		FROM script_state
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("get script runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make(map[string]ScriptRun)
+	for rows.Next() {
+		var run ScriptRun
+		var lastAttemptAt, lastRunAt, durationMS int64
+		if err := rows.Scan(&run.ScriptPath, &lastAttemptAt, &lastRunAt, &run.Status, &run.Error, &durationMS, &run.StderrTail); err != nil {
+			return nil, fmt.Errorf("get script runs: %w", err)
+		}
+		if lastAttemptAt > 0 {
+			run.LastAttemptAt = time.Unix(lastAttemptAt, 0)
+		}
+		if lastRunAt > 0 {
+			run.LastRunAt = time.Unix(lastRunAt, 0)
+		}
+		run.Duration = time.Duration(durationMS) * time.Millisecond
+		runs[run.ScriptPath] = run
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("get script runs: %w", err)
+	}
+	return runs, nil
+}
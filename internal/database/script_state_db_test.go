@@ -0,0 +1,74 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	dbpkg "MrRSS/internal/database"
+)
+
+func TestScriptState(t *testing.T) {
+	db := setupTestDB(t)
+
+	state, err := db.GetScriptState("feed.py")
+	if err != nil {
+		t.Fatalf("GetScriptState() error = %v", err)
+	}
+	if state != nil {
+		t.Fatalf("GetScriptState() = %+v, want nil before the first run", state)
+	}
+
+	lastRun := time.Unix(1700000000, 0)
+	for _, cursor := range []string{"page-2", "page-3"} {
+		if err := db.SaveScriptState(dbpkg.ScriptState{ScriptPath: "feed.py", LastRunAt: lastRun, Cursor: cursor}); err != nil {
+			t.Fatalf("SaveScriptState() error = %v", err)
+		}
+	}
+
+	state, err = db.GetScriptState("feed.py")
+	if err != nil {
+		t.Fatalf("GetScriptState() error = %v", err)
+	}
+	if state == nil || state.Cursor != "page-3" || !state.LastRunAt.Equal(lastRun) {
+		t.Errorf("GetScriptState() = %+v, want the last saved state", state)
+	}
+}
+
+func TestScriptRuns(t *testing.T) {
+	db := setupTestDB(t)
+
+	// A failed first run is recorded without a successful run time
+	if err := db.SaveScriptRun(dbpkg.ScriptRun{ScriptPath: "feed.py", LastAttemptAt: time.Unix(1700000000, 0), Status: dbpkg.ScriptRunFailed, Error: "exit status 1", StderrTail: "oops"}); err != nil {
+		t.Fatalf("SaveScriptRun() error = %v", err)
+	}
+	state, err := db.GetScriptState("feed.py")
+	if err != nil {
+		t.Fatalf("GetScriptState() error = %v", err)
+	}
+	if state == nil || !state.LastRunAt.IsZero() || state.Cursor != "" {
+		t.Errorf("GetScriptState() = %+v, want an empty state after a failed run", state)
+	}
+
+	// Saving the state keeps the run record, and the next run keeps the state
+	if err := db.SaveScriptState(dbpkg.ScriptState{ScriptPath: "feed.py", LastRunAt: time.Unix(1700000100, 0), Cursor: "c"}); err != nil {
+		t.Fatalf("SaveScriptState() error = %v", err)
+	}
+	if err := db.SaveScriptRun(dbpkg.ScriptRun{ScriptPath: "feed.py", LastAttemptAt: time.Unix(1700000200, 0), Status: dbpkg.ScriptRunOK, Duration: 250 * time.Millisecond}); err != nil {
+		t.Fatalf("SaveScriptRun() error = %v", err)
+	}
+
+	runs, err := db.GetScriptRuns()
+	if err != nil {
+		t.Fatalf("GetScriptRuns() error = %v", err)
+	}
+	run, ok := runs["feed.py"]
+	if !ok {
+		t.Fatalf("GetScriptRuns() = %v, want feed.py", runs)
+	}
+	if run.Status != dbpkg.ScriptRunOK || run.Error != "" || run.Duration != 250*time.Millisecond || run.LastRunAt.Unix() != 1700000100 {
+		t.Errorf("GetScriptRuns() run = %+v, want the latest run and the stored state's run time", run)
+	}
+	if state, _ := db.GetScriptState("feed.py"); state == nil || state.Cursor != "c" {
+		t.Errorf("GetScriptState() = %+v, want the cursor kept", state)
+	}
+}
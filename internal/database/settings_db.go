@@ -29,6 +29,7 @@ func (db *DB) SetSetting(key, value string) error {
 // and stored back to support migration from old versions.
 func (db *DB) GetEncryptedSetting(key string) (string, error) {
 	db.WaitForReady()
+	defer db.HoldSecrets()()
 
 	// Get the stored value
 	storedValue, err := db.GetSetting(key)
@@ -76,6 +77,7 @@ func (db *DB) GetEncryptedSetting(key string) (string, error) {
 // SetEncryptedSetting encrypts and stores a sensitive setting value.
 func (db *DB) SetEncryptedSetting(key, value string) error {
 	db.WaitForReady()
+	defer db.HoldSecrets()()
 
 	// Empty value - store as is
 	if value == "" {
@@ -113,3 +113,19 @@ func TestInitInsertsDefaults(t *testing.T) {
 		t.Fatalf("default setting %s = %q, want %q", key, got, want)
 	}
 }
+
+func TestSchemaVersion(t *testing.T) {
+	db := setupTestDB(t)
+
+	version, err := dbpkg.ReadSchemaVersion(db.DB)
+	if err != nil || version != dbpkg.SchemaVersion {
+		t.Fatalf("ReadSchemaVersion() = %d, %v; want %d", version, err, dbpkg.SchemaVersion)
+	}
+
+	if err := dbpkg.ValidateSchemaVersion(dbpkg.SchemaVersion); err != nil {
+		t.Fatalf("ValidateSchemaVersion(current) error = %v", err)
+	}
+	if err := dbpkg.ValidateSchemaVersion(dbpkg.SchemaVersion + 1); err == nil {
+		t.Fatal("ValidateSchemaVersion(newer) error = nil, want error")
+	}
+}
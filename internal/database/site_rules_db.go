@@ -0,0 +1,112 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// GetSiteRules returns all full-text extraction rules ordered by host
+func (db *DB) GetSiteRules() ([]models.SiteRule, error) {
+	db.WaitForReady()
+
+	rows, err := db.Query(`
+		SELECT id, host, content_selector, strip_selectors, next_page_selector, updated_at
+		FROM site_rules
+		ORDER BY host ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("get site rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.SiteRule{}
+	for rows.Next() {
+		var rule models.SiteRule
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&rule.ID, &rule.Host, &rule.ContentSelector, &rule.StripSelectors, &rule.NextPageSelector, &updatedAt); err != nil {
+			return nil, fmt.Errorf("get site rules: %w", err)
+		}
+		rule.UpdatedAt = updatedAt.Time
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// GetSiteRuleForHost returns the rule of the most specific site matching host
+// ("blog.example.com" falls back to "example.com"), or nil if there is none
+func (db *DB) GetSiteRuleForHost(host string) (*models.SiteRule, error) {
+	db.WaitForReady()
+
+	host = NormalizeSiteHost(host)
+	for candidate := host; candidate != ""; {
+		var rule models.SiteRule
+		var updatedAt sql.NullTime
+		err := db.QueryRow(`
+			SELECT id, host, content_selector, strip_selectors, next_page_selector, updated_at
+			FROM site_rules WHERE host = ?
+		`, candidate).Scan(&rule.ID, &rule.Host, &rule.ContentSelector, &rule.StripSelectors, &rule.NextPageSelector, &updatedAt)
+		if err == nil {
+			rule.UpdatedAt = updatedAt.Time
+			return &rule, nil
+		}
+		if err != sql.ErrNoRows {
+			return nil, fmt.Errorf("get site rule: %w", err)
+		}
+
+		// Stop before the top-level domain
+		_, parent, ok := strings.Cut(candidate, ".")
+		if !ok || !strings.Contains(parent, ".") {
+			break
+		}
+		candidate = parent
+	}
+	return nil, nil
+}
+
+// SaveSiteRule creates the rule of a site or replaces the existing one and returns its ID
+func (db *DB) SaveSiteRule(rule *models.SiteRule) (int64, error) {
+	db.WaitForReady()
+
+	rule.Host = NormalizeSiteHost(rule.Host)
+	var id int64
+	err := db.QueryRow(`
+		INSERT INTO site_rules (host, content_selector, strip_selectors, next_page_selector, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(host) DO UPDATE SET
+			content_selector = excluded.content_selector,
+			strip_selectors = excluded.strip_selectors,
+			next_page_selector = excluded.next_page_selector,
+			updated_at = excluded.updated_at
+		RETURNING id
+	`, rule.Host, rule.ContentSelector, rule.StripSelectors, rule.NextPageSelector, time.Now()).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("save site rule: %w", err)
+	}
+	rule.ID = id
+	return id, nil
+}
+
+// DeleteSiteRule removes a site rule
+func (db *DB) DeleteSiteRule(id int64) error {
+	db.WaitForReady()
+
+	if _, err := db.Exec(`DELETE FROM site_rules WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("delete site rule: %w", err)
+	}
+	return nil
+}
+
+// NormalizeSiteHost lowercases a host and drops its port and "www." prefix,
+// so rules match however the site is linked
+func NormalizeSiteHost(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, ok := strings.Cut(host, ":"); ok {
+		host = h
+	}
+	host = strings.TrimSuffix(host, ".")
+	return strings.TrimPrefix(host, "www.")
+}
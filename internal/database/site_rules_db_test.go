@@ -0,0 +1,62 @@
+package database_test
+
+import (
+	"testing"
+
+	"MrRSS/internal/models"
+)
+
+func TestSiteRules(t *testing.T) {
+	db := setupTestDB(t)
+
+	rule := &models.SiteRule{Host: "WWW.Example.com", ContentSelector: "article", StripSelectors: ".ad"}
+	id, err := db.SaveSiteRule(rule)
+	if err != nil {
+		t.Fatalf("SaveSiteRule() error = %v", err)
+	}
+	if rule.Host != "example.com" {
+		t.Errorf("Host = %q, want the normalized host", rule.Host)
+	}
+
+	// Saving the same site again replaces its rule
+	again := &models.SiteRule{Host: "example.com", ContentSelector: "div.body"}
+	if againID, err := db.SaveSiteRule(again); err != nil || againID != id {
+		t.Fatalf("SaveSiteRule() = %d, %v, want the existing ID %d", againID, err, id)
+	}
+	if _, err := db.SaveSiteRule(&models.SiteRule{Host: "news.example.com", ContentSelector: "main"}); err != nil {
+		t.Fatalf("SaveSiteRule() error = %v", err)
+	}
+
+	for host, want := range map[string]string{
+		"example.com":             "div.body",
+		"www.example.com":         "div.body",
+		"blog.example.com":        "div.body",
+		"a.news.example.com:8080": "main",
+		"example.org":             "",
+	} {
+		got, err := db.GetSiteRuleForHost(host)
+		if err != nil {
+			t.Fatalf("GetSiteRuleForHost(%q) error = %v", host, err)
+		}
+		if want == "" {
+			if got != nil {
+				t.Errorf("GetSiteRuleForHost(%q) = %+v, want nil", host, got)
+			}
+			continue
+		}
+		if got == nil || got.ContentSelector != want {
+			t.Errorf("GetSiteRuleForHost(%q) = %+v, want selector %q", host, got, want)
+		}
+	}
+
+	rules, err := db.GetSiteRules()
+	if err != nil || len(rules) != 2 || rules[0].Host != "example.com" || rules[0].UpdatedAt.IsZero() {
+		t.Fatalf("GetSiteRules() = %+v, %v", rules, err)
+	}
+	if err := db.DeleteSiteRule(id); err != nil {
+		t.Fatalf("DeleteSiteRule() error = %v", err)
+	}
+	if got, _ := db.GetSiteRuleForHost("example.com"); got != nil {
+		t.Errorf("GetSiteRuleForHost() = %+v after delete, want nil", got)
+	}
+}
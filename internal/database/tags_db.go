@@ -91,8 +91,11 @@ func (db *DB) DeleteTag(id int64) error {
 	db.WaitForReady()
 
 	query := `DELETE FROM tags WHERE id = ?`
-	_, err := db.Exec(query, id)
-	return err
+	if _, err := db.Exec(query, id); err != nil {
+		return err
+	}
+	_, _ = db.Exec(`DELETE FROM retention_policies WHERE tag_id = ?`, id)
+	return nil
 }
 
 // ReorderTag changes the position of a tag.
@@ -323,6 +326,7 @@ func (db *DB) GetFeedsByTag(tagID int64) ([]models.Feed, error) {
 		if lastError.Valid {
 			feed.LastError = lastError.String
 		}
+		feed.EmailPassword = decryptFeedSecret(feed.EmailPassword)
 
 		feeds = append(feeds, feed)
 	}
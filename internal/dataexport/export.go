@@ -0,0 +1,505 @@
+// Package dataexport exports and imports all user data in one versioned archive,
+// for moving between desktop and server instances.
+package dataexport
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"MrRSS/internal/crypto"
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils/fileutil"
+	"MrRSS/internal/version"
+)
+
+// FormatVersion is the version of the export document written by this build
+const FormatVersion = 1
+
+// Archive entry names
+const (
+	documentEntry  = "export.json"
+	customCSSEntry = "custom_article.css"
+)
+
+// customCSSFileName is the custom article CSS file in the data directory
+const customCSSFileName = "custom_article.css"
+
+// machineLocalSettings are not exported because they describe this machine or instance
+var machineLocalSettings = map[string]bool{
+	"window_x":                 true,
+	"window_y":                 true,
+	"window_width":             true,
+	"window_height":            true,
+	"window_maximized":         true,
+	"last_global_refresh":      true,
+	"last_network_test":        true,
+	"last_backup_time":         true,
+	"last_db_maintenance_time": true,
+	"freshrss_last_sync_time":  true,
+	"restore_required_secrets": true,
+	"network_speed":            true,
+	"network_bandwidth_mbps":   true,
+	"network_latency_ms":       true,
+	"backup_directory":         true,
+	"custom_css_file":          true,
+	"obsidian_vault_path":      true,
+}
+
+// Document is the content of an export archive
+type Document struct {
+	Format          int       `json:"format"`
+	AppVersion      string    `json:"app_version"`
+	ExportedAt      time.Time `json:"exported_at"`
+	IncludesSecrets bool      `json:"includes_secrets"`
+
+	Settings map[string]string `json:"settings"`
+	// SecretSettings lists settings that are encrypted when stored
+	SecretSettings []string `json:"secret_settings,omitempty"`
+
+	Tags []models.Tag `json:"tags"`
+	// Feeds carry their tags; category and position keep the sidebar order
+	Feeds        []models.Feed        `json:"feeds"`
+	SavedFilters []models.SavedFilter `json:"saved_filters"`
+	AIProfiles   []models.AIProfile   `json:"ai_profiles"`
+	Articles     []Article            `json:"articles"`
+	ChatSessions []ChatSession        `json:"chat_sessions"`
+	Statistics   []Statistic          `json:"statistics"`
+	// EmailAccounts holds the newsletter routing of mailbox feeds
+	EmailAccounts []EmailAccount `json:"email_accounts,omitempty"`
+
+	// CustomCSS is stored as a separate archive entry
+	CustomCSS string `json:"-"`
+}
+
+// Article is a kept article (favorite, read later or with a chat session) with its cached content
+type Article struct {
+	models.Article
+	FeedURL string `json:"feed_url"`
+	Content string `json:"content,omitempty"`
+}
+
+// EmailAccount is the newsletter routing of one mailbox feed, with feeds referenced by URL
+type EmailAccount struct {
+	AccountURL      string       `json:"account_url"`
+	AutoCreateFeeds bool         `json:"auto_create_feeds"`
+	ProcessedAction string       `json:"processed_action"`
+	ProcessedFolder string       `json:"processed_folder"`
+	FeedURLs        []string     `json:"feed_urls"` // Feeds that read this mailbox
+	Routes          []EmailRoute `json:"routes"`
+}
+
+// EmailRoute is a newsletter routing rule
+type EmailRoute struct {
+	FeedURL   string `json:"feed_url"`
+	MatchType string `json:"match_type"`
+	Pattern   string `json:"pattern"`
+	Position  int    `json:"position"`
+}
+
+// ChatSession is an AI chat session with its messages
+type ChatSession struct {
+	ArticleUniqueID string                 `json:"article_unique_id"`
+	Title           string                 `json:"title"`
+	CreatedAt       time.Time              `json:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+	Messages        []database.ChatMessage `json:"messages"`
+}
+
+// Statistic is a daily statistics counter
+type Statistic struct {
+	Date  string `json:"date"`
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+// ExportOptions controls what is exported
+type ExportOptions struct {
+	// IncludeSecrets exports API keys, passwords and encrypted settings in plain text
+	IncludeSecrets bool
+}
+
+// Build collects all exportable data from the database
+func Build(db *database.DB, opts ExportOptions) (*Document, error) {
+	doc := &Document{
+		Format:          FormatVersion,
+		AppVersion:      version.Version,
+		ExportedAt:      time.Now().UTC(),
+		IncludesSecrets: opts.IncludeSecrets,
+	}
+
+	steps := []struct {
+		name string
+		fn   func(*database.DB, *Document, ExportOptions) error
+	}{
+		{"settings", exportSettings},
+		{"feeds", exportFeeds},
+		{"email routes", exportEmailRoutes},
+		{"saved filters", exportSavedFilters},
+		{"AI profiles", exportAIProfiles},
+		{"articles", exportArticles},
+		{"chat sessions", exportChatSessions},
+		{"statistics", exportStatistics},
+	}
+	for _, step := range steps {
+		if err := step.fn(db, doc, opts); err != nil {
+			return nil, fmt.Errorf("export %s: %w", step.name, err)
+		}
+	}
+
+	dataDir, err := fileutil.GetDataDir()
+	if err != nil {
+		return nil, fmt.Errorf("get data dir: %w", err)
+	}
+	if css, err := os.ReadFile(filepath.Join(dataDir, customCSSFileName)); err == nil {
+		doc.CustomCSS = string(css)
+	}
+
+	return doc, nil
+}
+
+// Write writes an export document as a zip archive
+func Write(doc *Document, w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	entry, err := zw.Create(documentEntry)
+	if err != nil {
+		return fmt.Errorf("add %s: %w", documentEntry, err)
+	}
+	enc := json.NewEncoder(entry)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("write %s: %w", documentEntry, err)
+	}
+
+	if doc.CustomCSS != "" {
+		entry, err := zw.Create(customCSSEntry)
+		if err != nil {
+			return fmt.Errorf("add %s: %w", customCSSEntry, err)
+		}
+		if _, err := io.WriteString(entry, doc.CustomCSS); err != nil {
+			return fmt.Errorf("write %s: %w", customCSSEntry, err)
+		}
+	}
+
+	return zw.Close()
+}
+
+// Read reads an export archive written by Write
+func Read(r io.ReaderAt, size int64) (*Document, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+
+	var doc *Document
+	for _, f := range zr.File {
+		switch f.Name {
+		case documentEntry:
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("open %s: %w", documentEntry, err)
+			}
+			doc = &Document{}
+			err = json.NewDecoder(rc).Decode(doc)
+			rc.Close()
+			if err != nil {
+				return nil, fmt.Errorf("decode %s: %w", documentEntry, err)
+			}
+		}
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("not a MrRSS export: %s missing", documentEntry)
+	}
+	if doc.Format > FormatVersion {
+		return nil, fmt.Errorf("export format %d is newer than supported format %d", doc.Format, FormatVersion)
+	}
+
+	for _, f := range zr.File {
+		if f.Name != customCSSEntry {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", customCSSEntry, err)
+		}
+		css, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", customCSSEntry, err)
+		}
+		doc.CustomCSS = string(css)
+	}
+
+	return doc, nil
+}
+
+// FileName returns the file name of an export created at the given time
+func FileName(t time.Time) string {
+	return "mrrss-export-" + t.Format("20060102-150405") + ".zip"
+}
+
+// exportSettings exports settings except machine-local ones. Encrypted settings are only
+// exported when secrets are included.
+func exportSettings(db *database.DB, doc *Document, opts ExportOptions) error {
+	rows, err := db.Query("SELECT key, value FROM settings ORDER BY key")
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		stored[key] = value
+	}
+	rows.Close()
+
+	doc.Settings = make(map[string]string)
+	for key, value := range stored {
+		if machineLocalSettings[key] {
+			continue
+		}
+		if crypto.IsEncrypted(value) {
+			doc.SecretSettings = append(doc.SecretSettings, key)
+			if !opts.IncludeSecrets {
+				continue
+			}
+			if value, err = db.GetEncryptedSetting(key); err != nil {
+				return err
+			}
+		}
+		doc.Settings[key] = value
+	}
+	return nil
+}
+
+// exportFeeds exports local feeds with their tags. FreshRSS feeds come back with the next sync.
+func exportFeeds(db *database.DB, doc *Document, opts ExportOptions) error {
+	tags, err := db.GetTags()
+	if err != nil {
+		return err
+	}
+	doc.Tags = tags
+
+	feeds, err := db.GetFeeds()
+	if err != nil {
+		return err
+	}
+
+	var ids []int64
+	for _, feed := range feeds {
+		if !feed.IsFreshRSSSource {
+			ids = append(ids, feed.ID)
+		}
+	}
+	feedTags, err := db.GetTagsForFeeds(ids)
+	if err != nil {
+		return err
+	}
+
+	doc.Feeds = []models.Feed{}
+	for _, feed := range feeds {
+		if feed.IsFreshRSSSource {
+			continue
+		}
+		feed.Tags = feedTags[feed.ID]
+		feed.LastError = ""
+		feed.LatestArticleTime = nil
+		feed.ArticlesPerMonth = 0
+		feed.LastUpdateStatus = ""
+		if !opts.IncludeSecrets {
+			feed.EmailPassword = ""
+			feed.EmailOAuthClientSecret = ""
+			feed.EmailOAuthRefreshToken = ""
+			feed.JSONHeaders = ""
+		}
+		// IDs don't survive an import, the link to the mailbox is exported with the routes
+		feed.EmailAccountID = 0
+		doc.Feeds = append(doc.Feeds, feed)
+	}
+	return nil
+}
+
+// exportEmailRoutes exports the routing of newsletter mailboxes that route to other feeds
+func exportEmailRoutes(db *database.DB, doc *Document, opts ExportOptions) error {
+	feeds, err := db.GetFeeds()
+	if err != nil {
+		return err
+	}
+	urls := make(map[int64]string)
+	routedFeeds := make(map[int64][]string)
+	for _, feed := range feeds {
+		urls[feed.ID] = feed.URL
+		if feed.EmailAccountID != 0 {
+			routedFeeds[feed.EmailAccountID] = append(routedFeeds[feed.EmailAccountID], feed.URL)
+		}
+	}
+
+	for _, feed := range feeds {
+		if feed.Type != "email" || feed.EmailAccountID != 0 || feed.IsFreshRSSSource {
+			continue
+		}
+		routes, err := db.GetEmailRoutes(feed.ID)
+		if err != nil {
+			return err
+		}
+		routing, err := db.GetEmailRouting(feed.ID)
+		if err != nil {
+			return err
+		}
+		if len(routes) == 0 && len(routedFeeds[feed.ID]) == 0 && routing == (models.EmailRouting{AccountFeedID: feed.ID}) {
+			continue
+		}
+
+		account := EmailAccount{
+			AccountURL:      feed.URL,
+			AutoCreateFeeds: routing.AutoCreateFeeds,
+			ProcessedAction: routing.ProcessedAction,
+			ProcessedFolder: routing.ProcessedFolder,
+			FeedURLs:        routedFeeds[feed.ID],
+			Routes:          []EmailRoute{},
+		}
+		for _, route := range routes {
+			account.Routes = append(account.Routes, EmailRoute{
+				FeedURL:   urls[route.FeedID],
+				MatchType: route.MatchType,
+				Pattern:   route.Pattern,
+				Position:  route.Position,
+			})
+		}
+		doc.EmailAccounts = append(doc.EmailAccounts, account)
+	}
+	return nil
+}
+
+// exportSavedFilters exports saved filters in their sidebar order
+func exportSavedFilters(db *database.DB, doc *Document, opts ExportOptions) error {
+	filters, err := db.GetSavedFilters()
+	if err != nil {
+		return err
+	}
+	doc.SavedFilters = filters
+	if doc.SavedFilters == nil {
+		doc.SavedFilters = []models.SavedFilter{}
+	}
+	return nil
+}
+
+// exportAIProfiles exports AI profiles, with API keys only when secrets are included
+func exportAIProfiles(db *database.DB, doc *Document, opts ExportOptions) error {
+	var profiles []models.AIProfile
+	var err error
+	if opts.IncludeSecrets {
+		profiles, err = db.GetAllAIProfiles()
+	} else {
+		profiles, err = db.GetAllAIProfilesWithoutKeys()
+	}
+	if err != nil {
+		return err
+	}
+	doc.AIProfiles = profiles
+	if doc.AIProfiles == nil {
+		doc.AIProfiles = []models.AIProfile{}
+	}
+	return nil
+}
+
+// exportArticles exports favorites, read-later articles and articles with chat sessions,
+// including their cached content
+func exportArticles(db *database.DB, doc *Document, opts ExportOptions) error {
+	rows, err := db.Query(`
+		SELECT a.title, a.url, COALESCE(a.image_url, ''), COALESCE(a.audio_url, ''), COALESCE(a.video_url, ''),
+			a.published_at, a.is_read, a.is_favorite, a.is_hidden, a.is_read_later,
+			COALESCE(a.translated_title, ''), COALESCE(a.summary, ''), COALESCE(a.unique_id, ''), COALESCE(a.author, ''),
+			f.url, COALESCE(content_text(c.content), '')
+		FROM articles a
+		JOIN feeds f ON f.id = a.feed_id
+		LEFT JOIN article_contents c ON c.article_id = a.id
+		WHERE COALESCE(f.is_freshrss_source, 0) = 0
+			AND (a.is_favorite = 1 OR a.is_read_later = 1 OR a.id IN (SELECT article_id FROM chat_sessions))
+		ORDER BY a.published_at DESC`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	doc.Articles = []Article{}
+	for rows.Next() {
+		var a Article
+		var publishedAt sql.NullTime
+		if err := rows.Scan(&a.Title, &a.URL, &a.ImageURL, &a.AudioURL, &a.VideoURL,
+			&publishedAt, &a.IsRead, &a.IsFavorite, &a.IsHidden, &a.IsReadLater,
+			&a.TranslatedTitle, &a.Summary, &a.UniqueID, &a.Author,
+			&a.FeedURL, &a.Content); err != nil {
+			return err
+		}
+		a.PublishedAt = publishedAt.Time
+		doc.Articles = append(doc.Articles, a)
+	}
+	return rows.Err()
+}
+
+// exportChatSessions exports AI chat sessions with their messages
+func exportChatSessions(db *database.DB, doc *Document, opts ExportOptions) error {
+	rows, err := db.Query(`
+		SELECT s.id, a.unique_id, s.title, s.created_at, s.updated_at
+		FROM chat_sessions s
+		JOIN articles a ON a.id = s.article_id
+		JOIN feeds f ON f.id = a.feed_id
+		WHERE COALESCE(f.is_freshrss_source, 0) = 0
+		ORDER BY s.created_at`)
+	if err != nil {
+		return err
+	}
+
+	var ids []int64
+	doc.ChatSessions = []ChatSession{}
+	for rows.Next() {
+		var id int64
+		var s ChatSession
+		if err := rows.Scan(&id, &s.ArticleUniqueID, &s.Title, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+		doc.ChatSessions = append(doc.ChatSessions, s)
+	}
+	rows.Close()
+
+	for i, id := range ids {
+		messages, err := db.GetChatMessages(id)
+		if err != nil {
+			return err
+		}
+		doc.ChatSessions[i].Messages = messages
+	}
+	return nil
+}
+
+// exportStatistics exports all daily statistics counters
+func exportStatistics(db *database.DB, doc *Document, opts ExportOptions) error {
+	rows, err := db.Query("SELECT event_date, event_type, count FROM statistics ORDER BY event_date, event_type")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	doc.Statistics = []Statistic{}
+	for rows.Next() {
+		var s Statistic
+		if err := rows.Scan(&s.Date, &s.Type, &s.Count); err != nil {
+			return err
+		}
+		doc.Statistics = append(doc.Statistics, s)
+	}
+	return rows.Err()
+}
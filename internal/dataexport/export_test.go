@@ -0,0 +1,167 @@
+package dataexport
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func openTestDB(t *testing.T, name string) *database.DB {
+	t.Helper()
+	db, err := database.NewDB(filepath.Join(t.TempDir(), name))
+	if err != nil {
+		t.Fatalf("NewDB() error = %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	// Keep custom CSS lookups inside the test
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	src := openTestDB(t, "src.db")
+	tagID, err := src.AddTag(&models.Tag{Name: "Tech", Color: "#000000"})
+	if err != nil {
+		t.Fatalf("AddTag() error = %v", err)
+	}
+	feedID, err := src.AddFeed(&models.Feed{Title: "Blog", URL: "https://example.com/feed", Category: "News", Position: 2})
+	if err != nil {
+		t.Fatalf("AddFeed() error = %v", err)
+	}
+	if err := src.SetFeedTags(feedID, []int64{tagID}); err != nil {
+		t.Fatalf("SetFeedTags() error = %v", err)
+	}
+	if err := src.SaveArticle(&models.Article{FeedID: feedID, Title: "Kept", URL: "https://example.com/kept",
+		PublishedAt: time.Now(), HasValidPublishedTime: true, IsFavorite: true}); err != nil {
+		t.Fatalf("SaveArticle() error = %v", err)
+	}
+	var articleID int64
+	_ = src.QueryRow("SELECT id FROM articles WHERE url = ?", "https://example.com/kept").Scan(&articleID)
+	if err := src.SetArticleContent(articleID, "<p>full text</p>"); err != nil {
+		t.Fatalf("SetArticleContent() error = %v", err)
+	}
+	sessionID, _ := src.CreateChatSession(articleID, "Questions")
+	_, _ = src.CreateChatMessage(sessionID, "user", "What is this?", "")
+	_ = src.IncrementStat("article_read")
+	_ = src.SetSetting("theme", "dark")
+
+	doc, err := Build(src, ExportOptions{})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := Write(doc, &buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	doc, err = Read(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	dst := openTestDB(t, "dst.db")
+	ctx := context.Background()
+
+	report, err := Import(ctx, dst, doc, ImportOptions{Mode: ModeMerge, DryRun: true})
+	if err != nil {
+		t.Fatalf("Import(dry run) error = %v", err)
+	}
+	if report.Feeds.Added != 1 || report.Articles.Added != 1 || report.ChatSessions.Added != 1 || report.Tags.Added != 1 {
+		t.Fatalf("Import(dry run) report = %+v", report)
+	}
+	if feeds, _ := dst.GetFeeds(); len(feeds) != 0 {
+		t.Fatalf("dry run changed the database: %d feeds", len(feeds))
+	}
+
+	if _, err := Import(ctx, dst, doc, ImportOptions{Mode: ModeMerge}); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	feeds, _ := dst.GetFeeds()
+	if len(feeds) != 1 || feeds[0].Category != "News" || feeds[0].Position != 2 {
+		t.Fatalf("imported feeds = %+v", feeds)
+	}
+	tags, _ := dst.GetFeedTags(feeds[0].ID)
+	if len(tags) != 1 || tags[0].Name != "Tech" {
+		t.Fatalf("imported feed tags = %+v", tags)
+	}
+	var newArticleID int64
+	_ = dst.QueryRow("SELECT id FROM articles WHERE is_favorite = 1").Scan(&newArticleID)
+	if content, found, _ := dst.GetArticleContent(newArticleID); !found || content != "<p>full text</p>" {
+		t.Fatalf("imported article content = %q, %v", content, found)
+	}
+	if theme, _ := dst.GetSetting("theme"); theme != "dark" {
+		t.Fatalf("imported theme = %q, want dark", theme)
+	}
+
+	// Importing the same export again changes nothing
+	report, err = Import(ctx, dst, doc, ImportOptions{Mode: ModeMerge})
+	if err != nil {
+		t.Fatalf("second Import() error = %v", err)
+	}
+	if report.Articles.Added != 0 || report.ChatSessions.Added != 0 || report.Statistics.Added != 0 || report.Settings.Updated != 0 {
+		t.Fatalf("second Import() report = %+v, want no additions", report)
+	}
+}
+
+func TestExportImportEmailRoutes(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	src := openTestDB(t, "src.db")
+	accountID, err := src.AddFeed(&models.Feed{Title: "Inbox", URL: "email://me@example.com", Type: "email",
+		EmailAddress: "me@example.com", EmailIMAPServer: "imap.example.com", EmailUsername: "me", EmailPassword: "secret"})
+	if err != nil {
+		t.Fatalf("AddFeed() error = %v", err)
+	}
+	routedID, err := src.AddFeed(&models.Feed{Title: "Weekly", URL: "email://me@example.com/weekly.example.com", Type: "email"})
+	if err != nil {
+		t.Fatalf("AddFeed() error = %v", err)
+	}
+	_ = src.SetFeedEmailAccount(routedID, accountID)
+	if _, err := src.AddEmailRoute(&models.EmailRoute{AccountFeedID: accountID, FeedID: routedID, MatchType: "list_id", Pattern: "weekly.example.com"}); err != nil {
+		t.Fatalf("AddEmailRoute() error = %v", err)
+	}
+	_ = src.SaveEmailRouting(models.EmailRouting{AccountFeedID: accountID, ProcessedAction: "move", ProcessedFolder: "Read"})
+
+	doc, err := Build(src, ExportOptions{})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	dst := openTestDB(t, "dst.db")
+	// Feeds get different IDs in the new database
+	_, _ = dst.AddFeed(&models.Feed{Title: "Other", URL: "https://example.com/other"})
+
+	report, err := Import(context.Background(), dst, doc, ImportOptions{Mode: ModeMerge})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if report.EmailRoutes.Added != 1 {
+		t.Fatalf("Import() email routes report = %+v", report.EmailRoutes)
+	}
+
+	feeds, _ := dst.GetFeeds()
+	ids := make(map[string]models.Feed)
+	for _, feed := range feeds {
+		ids[feed.URL] = feed
+	}
+	account, routed := ids["email://me@example.com"], ids["email://me@example.com/weekly.example.com"]
+	if routed.EmailAccountID != account.ID || account.ID == 0 {
+		t.Fatalf("routed feed account = %d, want %d", routed.EmailAccountID, account.ID)
+	}
+	routes, _ := dst.GetEmailRoutes(account.ID)
+	if len(routes) != 1 || routes[0].FeedID != routed.ID || routes[0].Pattern != "weekly.example.com" {
+		t.Fatalf("imported routes = %+v", routes)
+	}
+	if routing, _ := dst.GetEmailRouting(account.ID); routing.ProcessedAction != "move" || routing.ProcessedFolder != "Read" {
+		t.Fatalf("imported routing = %+v", routing)
+	}
+}
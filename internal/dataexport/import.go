@@ -0,0 +1,698 @@
+package dataexport
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"MrRSS/internal/config"
+	"MrRSS/internal/crypto"
+	"MrRSS/internal/database"
+	"MrRSS/internal/utils/fileutil"
+)
+
+// Import modes
+const (
+	// ModeMerge adds new items and updates existing ones, keeping everything else
+	ModeMerge = "merge"
+	// ModeReplace deletes local feeds, their articles, tags, filters, AI profiles, chats and statistics first
+	ModeReplace = "replace"
+)
+
+// ImportOptions controls how an export is imported
+type ImportOptions struct {
+	Mode string
+	// DryRun reports what would change without changing anything
+	DryRun bool
+}
+
+// SectionReport counts the changes to one kind of data
+type SectionReport struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+	Deleted int `json:"deleted"`
+}
+
+// ImportReport describes the changes made (or, for a dry run, that would be made) by an import
+type ImportReport struct {
+	Mode             string        `json:"mode"`
+	DryRun           bool          `json:"dry_run"`
+	Settings         SectionReport `json:"settings"`
+	Tags             SectionReport `json:"tags"`
+	Feeds            SectionReport `json:"feeds"`
+	EmailRoutes      SectionReport `json:"email_routes"`
+	SavedFilters     SectionReport `json:"saved_filters"`
+	AIProfiles       SectionReport `json:"ai_profiles"`
+	Articles         SectionReport `json:"articles"`
+	ChatSessions     SectionReport `json:"chat_sessions"`
+	Statistics       SectionReport `json:"statistics"`
+	CustomCSSChanged bool          `json:"custom_css_changed"`
+	Warnings         []string      `json:"warnings"`
+}
+
+// importer holds the state of one import transaction
+type importer struct {
+	ctx    context.Context
+	tx     *sql.Tx
+	doc    *Document
+	report *ImportReport
+
+	tagIDs     map[string]int64
+	feedIDs    map[string]int64
+	profileIDs map[int64]int64
+	articleIDs map[string]int64
+}
+
+// Import applies an export document to the database.
+// Everything runs in one transaction; a dry run rolls it back after building the report.
+func Import(ctx context.Context, db *database.DB, doc *Document, opts ImportOptions) (*ImportReport, error) {
+	if opts.Mode == "" {
+		opts.Mode = ModeMerge
+	}
+	if opts.Mode != ModeMerge && opts.Mode != ModeReplace {
+		return nil, fmt.Errorf("unknown import mode: %s", opts.Mode)
+	}
+
+	db.WaitForReady()
+	// Secrets are encrypted throughout the import
+	defer db.HoldSecrets()()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	im := &importer{
+		ctx:        ctx,
+		tx:         tx,
+		doc:        doc,
+		report:     &ImportReport{Mode: opts.Mode, DryRun: opts.DryRun, Warnings: []string{}},
+		tagIDs:     make(map[string]int64),
+		feedIDs:    make(map[string]int64),
+		profileIDs: make(map[int64]int64),
+		articleIDs: make(map[string]int64),
+	}
+
+	if opts.Mode == ModeReplace {
+		if err := im.clear(); err != nil {
+			return nil, fmt.Errorf("clear existing data: %w", err)
+		}
+	}
+
+	steps := []struct {
+		name string
+		fn   func() error
+	}{
+		{"tags", im.importTags},
+		{"feeds", im.importFeeds},
+		{"email routes", im.importEmailRoutes},
+		{"saved filters", im.importSavedFilters},
+		{"AI profiles", im.importAIProfiles},
+		{"settings", im.importSettings},
+		{"articles", im.importArticles},
+		{"chat sessions", im.importChatSessions},
+		{"statistics", im.importStatistics},
+	}
+	for _, step := range steps {
+		if err := step.fn(); err != nil {
+			return nil, fmt.Errorf("import %s: %w", step.name, err)
+		}
+	}
+
+	dataDir, err := fileutil.GetDataDir()
+	if err != nil {
+		return nil, fmt.Errorf("get data dir: %w", err)
+	}
+	cssPath := filepath.Join(dataDir, customCSSFileName)
+	if doc.CustomCSS != "" {
+		current, _ := os.ReadFile(cssPath)
+		im.report.CustomCSSChanged = string(current) != doc.CustomCSS
+	}
+
+	if opts.DryRun {
+		return im.report, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit import: %w", err)
+	}
+
+	if im.report.CustomCSSChanged {
+		if err := os.WriteFile(cssPath, []byte(doc.CustomCSS), 0644); err != nil {
+			im.report.Warnings = append(im.report.Warnings, fmt.Sprintf("custom CSS not written: %v", err))
+		}
+	}
+
+	log.Printf("Imported export from %s (%s mode): %d feeds added, %d articles added",
+		doc.ExportedAt.Format("2006-01-02 15:04"), opts.Mode, im.report.Feeds.Added, im.report.Articles.Added)
+	return im.report, nil
+}
+
+// exec runs a statement and returns the number of affected rows
+func (im *importer) exec(query string, args ...interface{}) (int, error) {
+	result, err := im.tx.ExecContext(im.ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+// insert runs an INSERT and returns the new row ID
+func (im *importer) insert(query string, args ...interface{}) (int64, error) {
+	result, err := im.tx.ExecContext(im.ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// lookupID returns the ID found by a query, or 0 if there is none
+func (im *importer) lookupID(query string, args ...interface{}) (int64, error) {
+	var id int64
+	err := im.tx.QueryRowContext(im.ctx, query, args...).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
+
+// warn records a problem that did not stop the import
+func (im *importer) warn(format string, args ...interface{}) {
+	im.report.Warnings = append(im.report.Warnings, fmt.Sprintf(format, args...))
+}
+
+// clear deletes the data that replace mode overwrites. FreshRSS feeds and their articles are kept.
+func (im *importer) clear() error {
+	localArticles := "SELECT a.id FROM articles a JOIN feeds f ON f.id = a.feed_id WHERE COALESCE(f.is_freshrss_source, 0) = 0"
+
+	var err error
+	if _, err = im.exec("DELETE FROM chat_messages"); err != nil {
+		return err
+	}
+	if im.report.ChatSessions.Deleted, err = im.exec("DELETE FROM chat_sessions"); err != nil {
+		return err
+	}
+	if _, err = im.exec("DELETE FROM article_contents WHERE article_id IN (" + localArticles + ")"); err != nil {
+		return err
+	}
+	if im.report.Articles.Deleted, err = im.exec("DELETE FROM articles WHERE id IN (" + localArticles + ")"); err != nil {
+		return err
+	}
+	if _, err = im.exec("DELETE FROM feed_tags"); err != nil {
+		return err
+	}
+	if im.report.EmailRoutes.Deleted, err = im.exec("DELETE FROM email_routes"); err != nil {
+		return err
+	}
+	if _, err = im.exec("DELETE FROM email_routing"); err != nil {
+		return err
+	}
+	if im.report.Feeds.Deleted, err = im.exec("DELETE FROM feeds WHERE COALESCE(is_freshrss_source, 0) = 0"); err != nil {
+		return err
+	}
+	if im.report.Tags.Deleted, err = im.exec("DELETE FROM tags"); err != nil {
+		return err
+	}
+	if im.report.SavedFilters.Deleted, err = im.exec("DELETE FROM saved_filters"); err != nil {
+		return err
+	}
+	if im.report.AIProfiles.Deleted, err = im.exec("DELETE FROM ai_profiles"); err != nil {
+		return err
+	}
+	if im.report.Statistics.Deleted, err = im.exec("DELETE FROM statistics"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// importTags matches tags by name
+func (im *importer) importTags() error {
+	for _, tag := range im.doc.Tags {
+		var id int64
+		var color string
+		var position int
+		err := im.tx.QueryRowContext(im.ctx, "SELECT id, color, COALESCE(position, 0) FROM tags WHERE name = ?", tag.Name).
+			Scan(&id, &color, &position)
+		switch {
+		case err == sql.ErrNoRows:
+			if id, err = im.insert("INSERT INTO tags (name, color, position) VALUES (?, ?, ?)", tag.Name, tag.Color, tag.Position); err != nil {
+				return err
+			}
+			im.report.Tags.Added++
+		case err != nil:
+			return err
+		case color != tag.Color || position != tag.Position:
+			if _, err := im.exec("UPDATE tags SET color = ?, position = ? WHERE id = ?", tag.Color, tag.Position, id); err != nil {
+				return err
+			}
+			im.report.Tags.Updated++
+		default:
+			im.report.Tags.Skipped++
+		}
+		im.tagIDs[tag.Name] = id
+	}
+	return nil
+}
+
+// importFeeds matches local feeds by URL and restores their tags
+func (im *importer) importFeeds() error {
+	for _, feed := range im.doc.Feeds {
+		emailPassword, err := crypto.Encrypt(feed.EmailPassword)
+		if err != nil {
+			return fmt.Errorf("encrypt email password: %w", err)
+		}
+		oauthClientSecret, err := crypto.Encrypt(feed.EmailOAuthClientSecret)
+		if err != nil {
+			return fmt.Errorf("encrypt OAuth client secret: %w", err)
+		}
+		oauthRefreshToken, err := crypto.Encrypt(feed.EmailOAuthRefreshToken)
+		if err != nil {
+			return fmt.Errorf("encrypt OAuth refresh token: %w", err)
+		}
+		jsonHeaders, err := crypto.Encrypt(feed.JSONHeaders)
+		if err != nil {
+			return fmt.Errorf("encrypt request headers: %w", err)
+		}
+
+		id, err := im.lookupID("SELECT id FROM feeds WHERE url = ? AND COALESCE(is_freshrss_source, 0) = 0", feed.URL)
+		if err != nil {
+			return err
+		}
+
+		if id == 0 {
+			id, err = im.insert(`INSERT INTO feeds (
+				title, url, link, description, category, image_url, position,
+				script_path, hide_from_timeline, proxy_url, proxy_enabled, refresh_interval,
+				is_image_mode, type,
+				xpath_item, xpath_item_title, xpath_item_content, xpath_item_uri,
+				xpath_item_author, xpath_item_timestamp, xpath_item_time_format,
+				xpath_item_thumbnail, xpath_item_categories, xpath_item_uid,
+				xpath_next_page, xpath_max_pages, xpath_detail_content,
+				xpath_detail_author, xpath_detail_timestamp,
+				jsonpath_item, jsonpath_item_title, jsonpath_item_uri, jsonpath_item_content,
+				jsonpath_item_author, jsonpath_item_timestamp, jsonpath_item_time_format,
+				jsonpath_item_thumbnail, jsonpath_item_uid,
+				json_headers, json_page_param, json_cursor_path, json_max_pages,
+				full_text_on_ingest, article_view_mode, auto_expand_content,
+				email_address, email_imap_server, email_imap_port,
+				email_username, email_password, email_folder, email_last_uid,
+				email_security, email_auth_method, email_oauth_token_url,
+				email_oauth_client_id, email_oauth_client_secret, email_oauth_refresh_token,
+				email_inbound_token, is_freshrss_source, freshrss_stream_id
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 0, '')`,
+				feed.Title, feed.URL, feed.Link, feed.Description, feed.Category, feed.ImageURL, feed.Position,
+				feed.ScriptPath, feed.HideFromTimeline, feed.ProxyURL, feed.ProxyEnabled, feed.RefreshInterval,
+				feed.IsImageMode, feed.Type,
+				feed.XPathItem, feed.XPathItemTitle, feed.XPathItemContent, feed.XPathItemUri,
+				feed.XPathItemAuthor, feed.XPathItemTimestamp, feed.XPathItemTimeFormat,
+				feed.XPathItemThumbnail, feed.XPathItemCategories, feed.XPathItemUid,
+				feed.XPathNextPage, feed.XPathMaxPages, feed.XPathDetailContent,
+				feed.XPathDetailAuthor, feed.XPathDetailTimestamp,
+				feed.JSONPathItem, feed.JSONPathItemTitle, feed.JSONPathItemUri, feed.JSONPathItemContent,
+				feed.JSONPathItemAuthor, feed.JSONPathItemTimestamp, feed.JSONPathItemTimeFormat,
+				feed.JSONPathItemThumbnail, feed.JSONPathItemUid,
+				jsonHeaders, feed.JSONPageParam, feed.JSONCursorPath, feed.JSONMaxPages,
+				feed.FullTextOnIngest, feed.ArticleViewMode, feed.AutoExpandContent,
+				feed.EmailAddress, feed.EmailIMAPServer, feed.EmailIMAPPort,
+				feed.EmailUsername, emailPassword, feed.EmailFolder, feed.EmailLastUID,
+				feed.EmailSecurity, feed.EmailAuthMethod, feed.EmailOAuthTokenURL,
+				feed.EmailOAuthClientID, oauthClientSecret, oauthRefreshToken,
+				feed.EmailInboundToken)
+			if err != nil {
+				return err
+			}
+			im.report.Feeds.Added++
+		} else {
+			// An export without secrets keeps the stored password, OAuth secrets and request headers
+			query := `UPDATE feeds SET title = ?, link = ?, description = ?, category = ?, image_url = ?, position = ?,
+				script_path = ?, hide_from_timeline = ?, proxy_url = ?, proxy_enabled = ?, refresh_interval = ?,
+				is_image_mode = ?, type = ?,
+				xpath_item = ?, xpath_item_title = ?, xpath_item_content = ?, xpath_item_uri = ?,
+				xpath_item_author = ?, xpath_item_timestamp = ?, xpath_item_time_format = ?,
+				xpath_item_thumbnail = ?, xpath_item_categories = ?, xpath_item_uid = ?,
+				xpath_next_page = ?, xpath_max_pages = ?, xpath_detail_content = ?,
+				xpath_detail_author = ?, xpath_detail_timestamp = ?,
+				jsonpath_item = ?, jsonpath_item_title = ?, jsonpath_item_uri = ?, jsonpath_item_content = ?,
+				jsonpath_item_author = ?, jsonpath_item_timestamp = ?, jsonpath_item_time_format = ?,
+				jsonpath_item_thumbnail = ?, jsonpath_item_uid = ?,
+				json_page_param = ?, json_cursor_path = ?, json_max_pages = ?,
+				full_text_on_ingest = ?, article_view_mode = ?, auto_expand_content = ?,
+				email_address = ?, email_imap_server = ?, email_imap_port = ?, email_username = ?, email_folder = ?,
+				email_security = ?, email_auth_method = ?, email_oauth_token_url = ?, email_oauth_client_id = ?`
+			args := []interface{}{
+				feed.Title, feed.Link, feed.Description, feed.Category, feed.ImageURL, feed.Position,
+				feed.ScriptPath, feed.HideFromTimeline, feed.ProxyURL, feed.ProxyEnabled, feed.RefreshInterval,
+				feed.IsImageMode, feed.Type,
+				feed.XPathItem, feed.XPathItemTitle, feed.XPathItemContent, feed.XPathItemUri,
+				feed.XPathItemAuthor, feed.XPathItemTimestamp, feed.XPathItemTimeFormat,
+				feed.XPathItemThumbnail, feed.XPathItemCategories, feed.XPathItemUid,
+				feed.XPathNextPage, feed.XPathMaxPages, feed.XPathDetailContent,
+				feed.XPathDetailAuthor, feed.XPathDetailTimestamp,
+				feed.JSONPathItem, feed.JSONPathItemTitle, feed.JSONPathItemUri, feed.JSONPathItemContent,
+				feed.JSONPathItemAuthor, feed.JSONPathItemTimestamp, feed.JSONPathItemTimeFormat,
+				feed.JSONPathItemThumbnail, feed.JSONPathItemUid,
+				feed.JSONPageParam, feed.JSONCursorPath, feed.JSONMaxPages,
+				feed.FullTextOnIngest, feed.ArticleViewMode, feed.AutoExpandContent,
+				feed.EmailAddress, feed.EmailIMAPServer, feed.EmailIMAPPort, feed.EmailUsername, feed.EmailFolder,
+				feed.EmailSecurity, feed.EmailAuthMethod, feed.EmailOAuthTokenURL, feed.EmailOAuthClientID,
+			}
+			if emailPassword != "" {
+				query += ", email_password = ?"
+				args = append(args, emailPassword)
+			}
+			if oauthClientSecret != "" {
+				query += ", email_oauth_client_secret = ?"
+				args = append(args, oauthClientSecret)
+			}
+			if oauthRefreshToken != "" {
+				query += ", email_oauth_refresh_token = ?"
+				args = append(args, oauthRefreshToken)
+			}
+			if jsonHeaders != "" {
+				query += ", json_headers = ?"
+				args = append(args, jsonHeaders)
+			}
+			if feed.EmailInboundToken != "" {
+				query += ", email_inbound_token = ?"
+				args = append(args, feed.EmailInboundToken)
+			}
+			if _, err := im.exec(query+" WHERE id = ?", append(args, id)...); err != nil {
+				return err
+			}
+			im.report.Feeds.Updated++
+		}
+		im.feedIDs[feed.URL] = id
+
+		for _, tag := range feed.Tags {
+			tagID, ok := im.tagIDs[tag.Name]
+			if !ok {
+				im.warn("feed %s: unknown tag %s", feed.URL, tag.Name)
+				continue
+			}
+			if _, err := im.exec("INSERT OR IGNORE INTO feed_tags (feed_id, tag_id) VALUES (?, ?)", id, tagID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importEmailRoutes restores newsletter routing, matching routes by feed, match type and pattern
+func (im *importer) importEmailRoutes() error {
+	for _, account := range im.doc.EmailAccounts {
+		accountID, ok := im.feedIDs[account.AccountURL]
+		if !ok {
+			im.warn("email routes: unknown mailbox feed %s", account.AccountURL)
+			continue
+		}
+
+		if _, err := im.exec(`INSERT INTO email_routing (account_feed_id, auto_create_feeds, processed_action, processed_folder)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(account_feed_id) DO UPDATE SET
+				auto_create_feeds = excluded.auto_create_feeds,
+				processed_action = excluded.processed_action,
+				processed_folder = excluded.processed_folder`,
+			accountID, account.AutoCreateFeeds, account.ProcessedAction, account.ProcessedFolder); err != nil {
+			return err
+		}
+
+		for _, url := range account.FeedURLs {
+			feedID, ok := im.feedIDs[url]
+			if !ok {
+				im.warn("email routes: unknown routed feed %s", url)
+				continue
+			}
+			if _, err := im.exec("UPDATE feeds SET email_account_id = ? WHERE id = ?", accountID, feedID); err != nil {
+				return err
+			}
+		}
+
+		for _, route := range account.Routes {
+			feedID, ok := im.feedIDs[route.FeedURL]
+			if !ok {
+				im.warn("email routes: unknown routed feed %s", route.FeedURL)
+				continue
+			}
+			id, err := im.lookupID("SELECT id FROM email_routes WHERE account_feed_id = ? AND feed_id = ? AND match_type = ? AND pattern = ?",
+				accountID, feedID, route.MatchType, route.Pattern)
+			if err != nil {
+				return err
+			}
+			if id != 0 {
+				im.report.EmailRoutes.Skipped++
+				continue
+			}
+			if _, err := im.exec("INSERT INTO email_routes (account_feed_id, feed_id, match_type, pattern, position) VALUES (?, ?, ?, ?, ?)",
+				accountID, feedID, route.MatchType, route.Pattern, route.Position); err != nil {
+				return err
+			}
+			im.report.EmailRoutes.Added++
+		}
+	}
+	return nil
+}
+
+// importSavedFilters matches saved filters by name
+func (im *importer) importSavedFilters() error {
+	now := time.Now().Format(time.RFC3339)
+	for _, filter := range im.doc.SavedFilters {
+		var id int64
+		var conditions string
+		var position int
+		err := im.tx.QueryRowContext(im.ctx, "SELECT id, conditions, COALESCE(position, 0) FROM saved_filters WHERE name = ?", filter.Name).
+			Scan(&id, &conditions, &position)
+		switch {
+		case err == sql.ErrNoRows:
+			if _, err := im.exec("INSERT INTO saved_filters (name, conditions, position, created_at, updated_at) VALUES (?, ?, ?, ?, ?)",
+				filter.Name, filter.Conditions, filter.Position, filter.CreatedAt.Format(time.RFC3339), now); err != nil {
+				return err
+			}
+			im.report.SavedFilters.Added++
+		case err != nil:
+			return err
+		case conditions != filter.Conditions || position != filter.Position:
+			if _, err := im.exec("UPDATE saved_filters SET conditions = ?, position = ?, updated_at = ? WHERE id = ?",
+				filter.Conditions, filter.Position, now, id); err != nil {
+				return err
+			}
+			im.report.SavedFilters.Updated++
+		default:
+			im.report.SavedFilters.Skipped++
+		}
+	}
+	return nil
+}
+
+// importAIProfiles matches AI profiles by name and remembers their new IDs for the *_profile_id settings
+func (im *importer) importAIProfiles() error {
+	for _, profile := range im.doc.AIProfiles {
+		apiKey, err := crypto.Encrypt(profile.APIKey)
+		if err != nil {
+			return fmt.Errorf("encrypt API key: %w", err)
+		}
+
+		id, err := im.lookupID("SELECT id FROM ai_profiles WHERE name = ?", profile.Name)
+		if err != nil {
+			return err
+		}
+
+		if id == 0 {
+			if id, err = im.insert(`INSERT INTO ai_profiles (name, api_key, endpoint, model, custom_headers, is_default, created_at, updated_at)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				profile.Name, apiKey, profile.Endpoint, profile.Model, profile.CustomHeaders, profile.IsDefault,
+				profile.CreatedAt, time.Now()); err != nil {
+				return err
+			}
+			im.report.AIProfiles.Added++
+		} else {
+			query := "UPDATE ai_profiles SET endpoint = ?, model = ?, custom_headers = ?, updated_at = ?"
+			args := []interface{}{profile.Endpoint, profile.Model, profile.CustomHeaders, time.Now()}
+			if apiKey != "" {
+				query += ", api_key = ?"
+				args = append(args, apiKey)
+			}
+			if _, err := im.exec(query+" WHERE id = ?", append(args, id)...); err != nil {
+				return err
+			}
+			im.report.AIProfiles.Updated++
+		}
+		im.profileIDs[profile.ID] = id
+
+		if profile.IsDefault {
+			if _, err := im.exec("UPDATE ai_profiles SET is_default = (id = ?)", id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importSettings imports known, portable settings. Profile references are remapped to the imported profiles.
+func (im *importer) importSettings() error {
+	known := make(map[string]bool)
+	for _, key := range config.SettingsKeys() {
+		known[key] = true
+	}
+	secret := make(map[string]bool)
+	for _, key := range im.doc.SecretSettings {
+		secret[key] = true
+	}
+
+	for key, value := range im.doc.Settings {
+		if !known[key] || machineLocalSettings[key] {
+			im.report.Settings.Skipped++
+			continue
+		}
+
+		if strings.HasSuffix(key, "_profile_id") && value != "" {
+			if oldID, err := strconv.ParseInt(value, 10, 64); err == nil {
+				if newID, ok := im.profileIDs[oldID]; ok {
+					value = strconv.FormatInt(newID, 10)
+				}
+			}
+		}
+
+		var current string
+		err := im.tx.QueryRowContext(im.ctx, "SELECT value FROM settings WHERE key = ?", key).Scan(&current)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		if secret[key] {
+			if crypto.IsEncrypted(current) {
+				if decrypted, err := crypto.Decrypt(current); err == nil && decrypted == value {
+					im.report.Settings.Skipped++
+					continue
+				}
+			}
+			if value, err = crypto.Encrypt(value); err != nil {
+				return fmt.Errorf("encrypt setting %s: %w", key, err)
+			}
+		} else if current == value {
+			im.report.Settings.Skipped++
+			continue
+		}
+
+		if _, err := im.exec("INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", key, value); err != nil {
+			return err
+		}
+		im.report.Settings.Updated++
+	}
+	return nil
+}
+
+// importArticles matches kept articles by unique ID. Flags are merged so nothing stops being a favorite.
+func (im *importer) importArticles() error {
+	for _, article := range im.doc.Articles {
+		feedID, ok := im.feedIDs[article.FeedURL]
+		if !ok {
+			im.warn("article %s: feed %s is not in the export", article.URL, article.FeedURL)
+			im.report.Articles.Skipped++
+			continue
+		}
+
+		var id int64
+		var isFavorite, isReadLater, isRead bool
+		err := im.tx.QueryRowContext(im.ctx, "SELECT id, is_favorite, is_read_later, is_read FROM articles WHERE unique_id = ?", article.UniqueID).
+			Scan(&id, &isFavorite, &isReadLater, &isRead)
+		switch {
+		case err == sql.ErrNoRows:
+			if id, err = im.insert(`INSERT INTO articles (feed_id, title, url, image_url, audio_url, video_url, published_at,
+				translated_title, is_read, is_favorite, is_hidden, is_read_later, summary, unique_id, author)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				feedID, article.Title, article.URL, article.ImageURL, article.AudioURL, article.VideoURL, article.PublishedAt,
+				article.TranslatedTitle, article.IsRead, article.IsFavorite, article.IsHidden, article.IsReadLater,
+				article.Summary, article.UniqueID, article.Author); err != nil {
+				return err
+			}
+			im.report.Articles.Added++
+		case err != nil:
+			return err
+		case (article.IsFavorite && !isFavorite) || (article.IsReadLater && !isReadLater) || (article.IsRead && !isRead):
+			if _, err := im.exec("UPDATE articles SET is_favorite = ?, is_read_later = ?, is_read = ? WHERE id = ?",
+				isFavorite || article.IsFavorite, isReadLater || article.IsReadLater, isRead || article.IsRead, id); err != nil {
+				return err
+			}
+			im.report.Articles.Updated++
+		default:
+			im.report.Articles.Skipped++
+		}
+		im.articleIDs[article.UniqueID] = id
+
+		if article.Content != "" {
+			if _, err := im.exec("INSERT OR IGNORE INTO article_contents (article_id, content) VALUES (?, ?)", id, article.Content); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// importChatSessions matches chat sessions by article, title and creation time
+func (im *importer) importChatSessions() error {
+	for _, session := range im.doc.ChatSessions {
+		articleID, ok := im.articleIDs[session.ArticleUniqueID]
+		if !ok {
+			im.warn("chat session %q: article is not in the export", session.Title)
+			im.report.ChatSessions.Skipped++
+			continue
+		}
+
+		existing, err := im.lookupID("SELECT id FROM chat_sessions WHERE article_id = ? AND title = ? AND created_at = ?",
+			articleID, session.Title, session.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if existing != 0 {
+			im.report.ChatSessions.Skipped++
+			continue
+		}
+
+		sessionID, err := im.insert("INSERT INTO chat_sessions (article_id, title, created_at, updated_at) VALUES (?, ?, ?, ?)",
+			articleID, session.Title, session.CreatedAt, session.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		for _, message := range session.Messages {
+			if _, err := im.exec("INSERT INTO chat_messages (session_id, role, content, thinking, created_at) VALUES (?, ?, ?, ?, ?)",
+				sessionID, message.Role, message.Content, message.Thinking, message.CreatedAt); err != nil {
+				return err
+			}
+		}
+		im.report.ChatSessions.Added++
+	}
+	return nil
+}
+
+// importStatistics merges daily counters, keeping the larger count so repeated imports do not double them
+func (im *importer) importStatistics() error {
+	for _, stat := range im.doc.Statistics {
+		var count int
+		err := im.tx.QueryRowContext(im.ctx, "SELECT count FROM statistics WHERE event_date = ? AND event_type = ?", stat.Date, stat.Type).Scan(&count)
+		switch {
+		case err == sql.ErrNoRows:
+			if _, err := im.exec("INSERT INTO statistics (event_date, event_type, count) VALUES (?, ?, ?)", stat.Date, stat.Type, stat.Count); err != nil {
+				return err
+			}
+			im.report.Statistics.Added++
+		case err != nil:
+			return err
+		case stat.Count > count:
+			if _, err := im.exec("UPDATE statistics SET count = ? WHERE event_date = ? AND event_type = ?", stat.Count, stat.Date, stat.Type); err != nil {
+				return err
+			}
+			im.report.Statistics.Updated++
+		default:
+			im.report.Statistics.Skipped++
+		}
+	}
+	return nil
+}
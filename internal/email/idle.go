@@ -0,0 +1,297 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emersion/go-imap/client"
+
+	"MrRSS/internal/models"
+)
+
+const (
+	// idleRestart restarts IDLE before servers drop idle connections (RFC 2177 suggests 29 minutes)
+	idleRestart = 25 * time.Minute
+	// newMailDelay batches messages that arrive together into one fetch
+	newMailDelay = 2 * time.Second
+
+	minReconnectDelay = 5 * time.Second
+	maxReconnectDelay = 5 * time.Minute
+	// stableSession is how long a connection must last before the backoff resets
+	stableSession = time.Minute
+)
+
+var errIdleUnsupported = errors.New("server does not support IDLE")
+
+// IdleManager keeps one IMAP IDLE connection per mailbox folder and reports new mail.
+// Newsletter feeds that read the same folder of the same account share a connection.
+type IdleManager struct {
+	// OnNewMail is called with the feeds of a folder when new mail arrives or after reconnecting
+	OnNewMail func(feedIDs []int64)
+	// OnRefreshTokenRotated is called when the OAuth token endpoint issues a new refresh token
+	OnRefreshTokenRotated func(feedIDs []int64, refreshToken string)
+
+	mu       sync.Mutex
+	watchers map[string]*watcher
+}
+
+// watcher holds the IDLE connection of one folder
+type watcher struct {
+	manager *IdleManager
+	folder  string
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	mu          sync.Mutex
+	account     Account
+	fingerprint string
+	feedIDs     []int64
+}
+
+// NewIdleManager creates an IDLE manager without any connections.
+func NewIdleManager() *IdleManager {
+	return &IdleManager{watchers: make(map[string]*watcher)}
+}
+
+// Sync starts, updates and stops connections so they match the given newsletter feeds.
+func (m *IdleManager) Sync(ctx context.Context, feeds []models.Feed) {
+	type group struct {
+		account     Account
+		folder      string
+		fingerprint string
+		feedIDs     []int64
+	}
+	groups := make(map[string]*group)
+	for i := range feeds {
+		feed := &feeds[i]
+		account := AccountFromFeed(feed)
+		if account.Validate() != nil {
+			continue
+		}
+		folder := feed.EmailFolder
+		if folder == "" {
+			folder = "INBOX"
+		}
+		key := watcherKey(account, folder)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{account: account, folder: folder, fingerprint: accountFingerprint(account)}
+			groups[key] = g
+		}
+		g.feedIDs = append(g.feedIDs, feed.ID)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, w := range m.watchers {
+		g, ok := groups[key]
+		if ok && g.fingerprint == w.currentFingerprint() {
+			continue
+		}
+		// The folder is no longer used or its credentials changed
+		w.cancel()
+		delete(m.watchers, key)
+	}
+
+	for key, g := range groups {
+		sort.Slice(g.feedIDs, func(i, j int) bool { return g.feedIDs[i] < g.feedIDs[j] })
+		if w, ok := m.watchers[key]; ok {
+			w.update(g.account, g.feedIDs)
+			continue
+		}
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		w := &watcher{
+			manager:     m,
+			folder:      g.folder,
+			cancel:      cancel,
+			done:        make(chan struct{}),
+			account:     g.account,
+			fingerprint: g.fingerprint,
+			feedIDs:     g.feedIDs,
+		}
+		m.watchers[key] = w
+		go w.run(watchCtx)
+	}
+}
+
+// Stop closes all connections and waits for them to log out.
+func (m *IdleManager) Stop() {
+	m.mu.Lock()
+	watchers := m.watchers
+	m.watchers = make(map[string]*watcher)
+	m.mu.Unlock()
+
+	for _, w := range watchers {
+		w.cancel()
+	}
+	for _, w := range watchers {
+		<-w.done
+	}
+}
+
+// watcherKey identifies a folder of an account
+func watcherKey(a Account, folder string) string {
+	return strings.Join([]string{strings.ToLower(a.Server), fmt.Sprint(a.Port), a.Username, folder}, "\x00")
+}
+
+// accountFingerprint covers the settings that require a new connection when they change.
+// The refresh token is left out, it rotates and is picked up on the next reconnect.
+func accountFingerprint(a Account) string {
+	return strings.Join([]string{a.Security, a.AuthMethod, a.Password, a.OAuth.TokenURL, a.OAuth.ClientID, a.OAuth.ClientSecret}, "\x00")
+}
+
+func (w *watcher) currentFingerprint() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.fingerprint
+}
+
+// update replaces the feeds and the refresh token used on the next reconnect
+func (w *watcher) update(account Account, feedIDs []int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.account.OAuth.RefreshToken = account.OAuth.RefreshToken
+	w.feedIDs = feedIDs
+}
+
+func (w *watcher) snapshot() (Account, []int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.account, append([]int64(nil), w.feedIDs...)
+}
+
+// notify reports new mail for the folder's feeds
+func (w *watcher) notify() {
+	if w.manager.OnNewMail == nil {
+		return
+	}
+	_, feedIDs := w.snapshot()
+	w.manager.OnNewMail(feedIDs)
+}
+
+// run keeps the folder's IDLE connection open, reconnecting with exponential backoff
+func (w *watcher) run(ctx context.Context) {
+	defer close(w.done)
+
+	delay := minReconnectDelay
+	for {
+		started := time.Now()
+		err := w.session(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		account, _ := w.snapshot()
+		if errors.Is(err, errIdleUnsupported) {
+			// Scheduled refreshes keep polling this folder
+			log.Printf("IMAP IDLE: %s on %s, newsletters in %s are polled instead", err, account.Server, w.folder)
+			return
+		}
+
+		if time.Since(started) > stableSession {
+			delay = minReconnectDelay
+		}
+		log.Printf("IMAP IDLE: connection to %s (%s) lost: %v, reconnecting in %v", account.Server, w.folder, err, delay)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// session connects, selects the folder and idles until the connection fails or ctx ends
+func (w *watcher) session(ctx context.Context) error {
+	account, feedIDs := w.snapshot()
+	account.OnRefreshTokenRotated = func(refreshToken string) {
+		w.mu.Lock()
+		w.account.OAuth.RefreshToken = refreshToken
+		w.mu.Unlock()
+		if w.manager.OnRefreshTokenRotated != nil {
+			w.manager.OnRefreshTokenRotated(feedIDs, refreshToken)
+		}
+	}
+
+	c, err := Dial(ctx, account)
+	if err != nil {
+		return err
+	}
+
+	updates := make(chan client.Update, 16)
+	c.Updates = updates
+	defer func() {
+		// Keep draining so logging out never blocks on an unread update
+		go func() {
+			for {
+				select {
+				case <-updates:
+				case <-c.LoggedOut():
+					return
+				}
+			}
+		}()
+		c.Logout()
+	}()
+
+	if ok, err := c.Support("IDLE"); err != nil {
+		return err
+	} else if !ok {
+		return errIdleUnsupported
+	}
+
+	if _, err := c.Select(w.folder, true); err != nil {
+		return fmt.Errorf("failed to select mailbox %s: %w", w.folder, err)
+	}
+	log.Printf("IMAP IDLE: watching %s on %s for %d feed(s)", w.folder, account.Server, len(feedIDs))
+
+	// Pick up mail that arrived while disconnected
+	w.notify()
+
+	stop := make(chan struct{})
+	idleDone := make(chan error, 1)
+	go func() {
+		idleDone <- c.Idle(stop, &client.IdleOptions{LogoutTimeout: idleRestart})
+	}()
+
+	var pending <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			close(stop)
+			<-idleDone
+			return ctx.Err()
+
+		case err := <-idleDone:
+			if err == nil {
+				err = errors.New("IDLE ended")
+			}
+			return err
+
+		case update := <-updates:
+			// EXISTS and RECENT arrive as mailbox updates. The status they point to is
+			// shared with the client's reader, so rather than comparing message counts
+			// every update triggers a fetch, which only picks up UIDs above the last one.
+			if _, ok := update.(*client.MailboxUpdate); ok && pending == nil {
+				pending = time.After(newMailDelay)
+			}
+
+		case <-pending:
+			pending = nil
+			w.notify()
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package email
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// waitFor polls cond until it holds or the test times out
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestIdleManagerSharesConnectionAndReportsNewMail(t *testing.T) {
+	server := newFakeIMAPServer(t, "IMAP4rev1 IDLE")
+
+	var mu sync.Mutex
+	var calls [][]int64
+	manager := NewIdleManager()
+	manager.OnNewMail = func(feedIDs []int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, feedIDs)
+	}
+	callCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(calls)
+	}
+
+	feed := models.Feed{
+		Type:            "email",
+		EmailIMAPServer: "127.0.0.1",
+		EmailIMAPPort:   server.port(),
+		EmailSecurity:   SecurityPlaintext,
+		EmailUsername:   "user",
+		EmailPassword:   "secret",
+		EmailFolder:     "INBOX",
+	}
+	first, second := feed, feed
+	first.ID, second.ID = 1, 2
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	manager.Sync(ctx, []models.Feed{first, second})
+	defer manager.Stop()
+
+	// Connecting reports the folder once to catch up on missed mail
+	waitFor(t, "initial notification", func() bool { return callCount() == 1 })
+	waitFor(t, "IDLE command", func() bool { return server.sawCommand("IDLE") })
+
+	mu.Lock()
+	if got := calls[0]; len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("notified feeds = %v, want [1 2]", got)
+	}
+	mu.Unlock()
+	if n := server.connectionCount(); n != 1 {
+		t.Fatalf("connections = %d, want 1 shared connection", n)
+	}
+
+	server.push("* 4 EXISTS\r\n")
+	waitFor(t, "new mail notification", func() bool { return callCount() == 2 })
+
+	// Removing the feeds closes the connection
+	manager.Sync(ctx, nil)
+	waitFor(t, "logout", func() bool { return server.sawCommand("LOGOUT") })
+}
+
+func TestIdleManagerSkipsServersWithoutIdle(t *testing.T) {
+	server := newFakeIMAPServer(t, "IMAP4rev1")
+
+	manager := NewIdleManager()
+	notified := make(chan struct{}, 1)
+	manager.OnNewMail = func([]int64) { notified <- struct{}{} }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	manager.Sync(ctx, []models.Feed{{
+		ID:              1,
+		Type:            "email",
+		EmailIMAPServer: "127.0.0.1",
+		EmailIMAPPort:   server.port(),
+		EmailSecurity:   SecurityPlaintext,
+		EmailUsername:   "user",
+		EmailPassword:   "secret",
+	}})
+
+	waitFor(t, "logout", func() bool { return server.sawCommand("LOGOUT") })
+	manager.Stop()
+
+	select {
+	case <-notified:
+		t.Fatal("folder without IDLE support should be left to polling")
+	default:
+	}
+	if server.sawCommand("IDLE") {
+		t.Fatal("IDLE sent to a server that does not support it")
+	}
+	if n := server.connectionCount(); n != 1 {
+		t.Fatalf("connections = %d, want no reconnect", n)
+	}
+}
@@ -0,0 +1,325 @@
+// Package email connects to IMAP mailboxes that are subscribed to as newsletter feeds.
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-imap"
+	id "github.com/emersion/go-imap-id"
+	"github.com/emersion/go-imap/client"
+	"github.com/emersion/go-sasl"
+
+	"MrRSS/internal/models"
+	"MrRSS/internal/version"
+)
+
+// Connection security modes
+const (
+	SecurityTLS       = "tls"       // Implicit TLS, usually port 993
+	SecuritySTARTTLS  = "starttls"  // Plain connection upgraded with STARTTLS, usually port 143
+	SecurityPlaintext = "plaintext" // No encryption, only when explicitly chosen
+)
+
+// Authentication methods
+const (
+	AuthPassword    = "password"
+	AuthXOAUTH2     = "xoauth2"
+	AuthOAuthBearer = "oauthbearer"
+)
+
+// Connection stages reported in ConnError
+const (
+	StageConnect = "connect"
+	StageTLS     = "tls"
+	StageToken   = "token"
+	StageAuth    = "auth"
+)
+
+const dialTimeout = 30 * time.Second
+
+// ConnError describes which stage of connecting to the IMAP server failed.
+type ConnError struct {
+	Stage string
+	Err   error
+}
+
+func (e *ConnError) Error() string {
+	switch e.Stage {
+	case StageConnect:
+		return fmt.Sprintf("failed to connect to IMAP server: %v", e.Err)
+	case StageTLS:
+		return fmt.Sprintf("secure connection failed: %v", e.Err)
+	case StageToken:
+		return fmt.Sprintf("failed to get OAuth access token: %v", e.Err)
+	case StageAuth:
+		return fmt.Sprintf("IMAP authentication failed: %v", e.Err)
+	default:
+		return e.Err.Error()
+	}
+}
+
+func (e *ConnError) Unwrap() error {
+	return e.Err
+}
+
+// Account holds everything needed to log in to an IMAP mailbox.
+type Account struct {
+	Server     string
+	Port       int
+	Security   string // tls, starttls or plaintext; empty picks by port
+	AuthMethod string // password, xoauth2 or oauthbearer; empty means password
+	Username   string
+	Password   string // Password, or the access token for OAuth without a refresh token
+	OAuth      OAuthConfig
+
+	// OnRefreshTokenRotated is called when the token endpoint issues a new refresh token
+	OnRefreshTokenRotated func(refreshToken string)
+}
+
+// AccountFromFeed builds an account from an email feed's settings.
+func AccountFromFeed(feed *models.Feed) Account {
+	return Account{
+		Server:     feed.EmailIMAPServer,
+		Port:       feed.EmailIMAPPort,
+		Security:   feed.EmailSecurity,
+		AuthMethod: feed.EmailAuthMethod,
+		Username:   feed.EmailUsername,
+		Password:   feed.EmailPassword,
+		OAuth: OAuthConfig{
+			TokenURL:     feed.EmailOAuthTokenURL,
+			ClientID:     feed.EmailOAuthClientID,
+			ClientSecret: feed.EmailOAuthClientSecret,
+			RefreshToken: feed.EmailOAuthRefreshToken,
+		},
+	}
+}
+
+// ResolveSecurity returns the security mode to use.
+// Without an explicit mode port 143 uses STARTTLS and every other port implicit TLS;
+// plaintext is never chosen implicitly.
+func (a Account) ResolveSecurity() (string, error) {
+	switch strings.ToLower(a.Security) {
+	case "":
+		if a.Port == 143 {
+			return SecuritySTARTTLS, nil
+		}
+		return SecurityTLS, nil
+	case SecurityTLS:
+		return SecurityTLS, nil
+	case SecuritySTARTTLS:
+		return SecuritySTARTTLS, nil
+	case SecurityPlaintext:
+		return SecurityPlaintext, nil
+	default:
+		return "", fmt.Errorf("unknown IMAP security mode %q", a.Security)
+	}
+}
+
+// ResolveAuthMethod returns the authentication method to use.
+func (a Account) ResolveAuthMethod() (string, error) {
+	switch strings.ToLower(a.AuthMethod) {
+	case "", AuthPassword:
+		return AuthPassword, nil
+	case AuthXOAUTH2:
+		return AuthXOAUTH2, nil
+	case AuthOAuthBearer:
+		return AuthOAuthBearer, nil
+	default:
+		return "", fmt.Errorf("unknown IMAP authentication method %q", a.AuthMethod)
+	}
+}
+
+// Validate checks that the account has the fields its authentication method needs.
+func (a Account) Validate() error {
+	if a.Server == "" || a.Username == "" {
+		return errors.New("IMAP server and username are required")
+	}
+	if _, err := a.ResolveSecurity(); err != nil {
+		return err
+	}
+	method, err := a.ResolveAuthMethod()
+	if err != nil {
+		return err
+	}
+	if method == AuthPassword {
+		if a.Password == "" {
+			return errors.New("IMAP password is required")
+		}
+		return nil
+	}
+	if a.OAuth.RefreshToken == "" && a.Password == "" {
+		return errors.New("an OAuth refresh token or access token is required")
+	}
+	if a.OAuth.RefreshToken != "" && a.OAuth.TokenURL == "" {
+		return errors.New("an OAuth token URL is required to use a refresh token")
+	}
+	return nil
+}
+
+// Dial connects and logs in to the IMAP server.
+// There is no fallback between security modes: if the chosen mode fails the error is returned.
+func Dial(ctx context.Context, a Account) (*client.Client, error) {
+	if err := a.Validate(); err != nil {
+		return nil, err
+	}
+	if a.Port == 0 {
+		a.Port = 993
+	}
+	security, _ := a.ResolveSecurity()
+	method, _ := a.ResolveAuthMethod()
+
+	// Get the access token first so a bad refresh token doesn't cost a connection
+	token := a.Password
+	if method != AuthPassword && a.OAuth.RefreshToken != "" {
+		var err error
+		token, err = a.OAuth.accessToken(ctx, a.OnRefreshTokenRotated)
+		if err != nil {
+			return nil, &ConnError{Stage: StageToken, Err: err}
+		}
+	}
+
+	c, err := connect(a.Server, a.Port, security)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send ID command before login (RFC 2971)
+	// This is required by some email providers like NetEase (163, 126)
+	_ = sendID(c)
+
+	if err := authenticate(c, a, method, token); err != nil {
+		c.Logout()
+		return nil, &ConnError{Stage: StageAuth, Err: err}
+	}
+
+	return c, nil
+}
+
+// connect opens the connection using the given security mode
+func connect(server string, port int, security string) (*client.Client, error) {
+	addr := net.JoinHostPort(server, fmt.Sprintf("%d", port))
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	tlsConfig := &tls.Config{ServerName: server}
+
+	switch security {
+	case SecurityTLS:
+		c, err := client.DialWithDialerTLS(dialer, addr, tlsConfig)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) {
+				return nil, &ConnError{Stage: StageConnect, Err: err}
+			}
+			return nil, &ConnError{Stage: StageTLS, Err: fmt.Errorf("%w (if the server uses port 143, choose STARTTLS)", err)}
+		}
+		return c, nil
+
+	case SecuritySTARTTLS:
+		c, err := client.DialWithDialer(dialer, addr)
+		if err != nil {
+			return nil, &ConnError{Stage: StageConnect, Err: err}
+		}
+		supported, err := c.SupportStartTLS()
+		if err != nil {
+			c.Logout()
+			return nil, &ConnError{Stage: StageTLS, Err: err}
+		}
+		if !supported {
+			c.Logout()
+			return nil, &ConnError{Stage: StageTLS, Err: errors.New("server does not support STARTTLS")}
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			c.Logout()
+			return nil, &ConnError{Stage: StageTLS, Err: err}
+		}
+		return c, nil
+
+	default:
+		c, err := client.DialWithDialer(dialer, addr)
+		if err != nil {
+			return nil, &ConnError{Stage: StageConnect, Err: err}
+		}
+		return c, nil
+	}
+}
+
+// authenticate logs in with the password or an OAuth SASL mechanism
+func authenticate(c *client.Client, a Account, method, token string) error {
+	switch method {
+	case AuthXOAUTH2:
+		if ok, _ := c.SupportAuth(XOAuth2); !ok {
+			return errors.New("server does not support XOAUTH2")
+		}
+		return c.Authenticate(NewXOAuth2Client(a.Username, token))
+	case AuthOAuthBearer:
+		if ok, _ := c.SupportAuth(sasl.OAuthBearer); !ok {
+			return errors.New("server does not support OAUTHBEARER")
+		}
+		return c.Authenticate(sasl.NewOAuthBearerClient(&sasl.OAuthBearerOptions{
+			Username: a.Username,
+			Token:    token,
+			Host:     a.Server,
+			Port:     a.Port,
+		}))
+	default:
+		return c.Login(a.Username, a.Password)
+	}
+}
+
+// sendID sends the IMAP ID command to identify the client
+func sendID(c *client.Client) error {
+	idClient := id.NewClient(c)
+
+	// Check if server supports ID extension
+	supported, err := idClient.SupportID()
+	if err != nil || !supported {
+		return nil
+	}
+
+	clientID := id.ID{
+		id.FieldName:    "MrRSS",
+		id.FieldVersion: version.Version,
+		id.FieldVendor:  "MrRSS",
+		id.FieldOS:      runtime.GOOS,
+	}
+
+	_, err = idClient.ID(clientID)
+	return err
+}
+
+// initialLookback limits the first fetch of a folder to recent mail
+const initialLookback = 30 * 24 * time.Hour
+
+// SearchNewUIDs returns the UIDs above lastUID in the selected folder, in ascending order.
+// The first fetch (lastUID 0) only looks at the last month of mail.
+func SearchNewUIDs(c *client.Client, lastUID uint32) ([]uint32, error) {
+	criteria := imap.NewSearchCriteria()
+	uidRange := new(imap.SeqSet)
+	uidRange.AddRange(lastUID+1, 0) // 0 means "*", the highest UID in the folder
+	criteria.Uid = uidRange
+	if lastUID == 0 {
+		criteria.Since = time.Now().Add(-initialLookback)
+	}
+
+	uids, err := c.UidSearch(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	// "n:*" always matches the last message, even when its UID is below n
+	newUIDs := uids[:0]
+	for _, uid := range uids {
+		if uid > lastUID {
+			newUIDs = append(newUIDs, uid)
+		}
+	}
+	sort.Slice(newUIDs, func(i, j int) bool { return newUIDs[i] < newUIDs[j] })
+	return newUIDs, nil
+}
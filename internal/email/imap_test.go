@@ -0,0 +1,309 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestResolveSecurity(t *testing.T) {
+	tests := []struct {
+		security string
+		port     int
+		want     string
+		wantErr  bool
+	}{
+		{"", 993, SecurityTLS, false},
+		{"", 143, SecuritySTARTTLS, false},
+		{"", 0, SecurityTLS, false},
+		{"STARTTLS", 993, SecuritySTARTTLS, false},
+		{"plaintext", 143, SecurityPlaintext, false},
+		{"ssl", 993, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := Account{Security: tt.security, Port: tt.port}.ResolveSecurity()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ResolveSecurity(%q, %d) error = %v, wantErr %v", tt.security, tt.port, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ResolveSecurity(%q, %d) = %q, want %q", tt.security, tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestAccountValidate(t *testing.T) {
+	base := Account{Server: "imap.example.com", Username: "user@example.com"}
+
+	if err := base.Validate(); err == nil {
+		t.Error("password login without password should fail")
+	}
+
+	oauth := base
+	oauth.AuthMethod = AuthXOAUTH2
+	oauth.OAuth.RefreshToken = "refresh"
+	if err := oauth.Validate(); err == nil {
+		t.Error("refresh token without token URL should fail")
+	}
+
+	oauth.OAuth.TokenURL = "https://oauth.example.com/token"
+	if err := oauth.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestXOAuth2ClientStart(t *testing.T) {
+	mech, ir, err := NewXOAuth2Client("user@example.com", "token123").Start()
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if mech != "XOAUTH2" {
+		t.Errorf("mech = %q, want XOAUTH2", mech)
+	}
+	want := "user=user@example.com\x01auth=Bearer token123\x01\x01"
+	if string(ir) != want {
+		t.Errorf("initial response = %q, want %q", ir, want)
+	}
+}
+
+// fakeIMAPServer is a plaintext IMAP server that records the commands it receives
+type fakeIMAPServer struct {
+	listener     net.Listener
+	capabilities string
+
+	mu          sync.Mutex
+	received    []string
+	connections []net.Conn
+}
+
+func newFakeIMAPServer(t *testing.T, capabilities string) *fakeIMAPServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeIMAPServer{listener: l, capabilities: capabilities}
+	t.Cleanup(func() { l.Close() })
+	go s.serve()
+	return s
+}
+
+func (s *fakeIMAPServer) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeIMAPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeIMAPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	s.mu.Lock()
+	s.connections = append(s.connections, conn)
+	s.mu.Unlock()
+	fmt.Fprint(conn, "* OK fake server ready\r\n")
+
+	var idleTag string
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			s.mu.Lock()
+			s.received = append(s.received, line)
+			s.mu.Unlock()
+		}
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 1 && strings.EqualFold(fields[0], "DONE") {
+			fmt.Fprintf(conn, "%s OK idle done\r\n", idleTag)
+			continue
+		}
+		if len(fields) < 2 {
+			continue
+		}
+		tag, cmd := fields[0], strings.ToUpper(fields[1])
+		switch cmd {
+		case "SELECT", "EXAMINE":
+			fmt.Fprintf(conn, "* 3 EXISTS\r\n* OK [UIDVALIDITY 7] ok\r\n%s OK [READ-ONLY] done\r\n", tag)
+		case "IDLE":
+			idleTag = tag
+			fmt.Fprint(conn, "+ idling\r\n")
+		case "CAPABILITY":
+			fmt.Fprintf(conn, "* CAPABILITY %s\r\n%s OK done\r\n", s.capabilities, tag)
+		case "LOGOUT":
+			fmt.Fprintf(conn, "* BYE\r\n%s OK done\r\n", tag)
+			return
+		default:
+			fmt.Fprintf(conn, "%s OK done\r\n", tag)
+		}
+	}
+}
+
+// push sends an untagged response to every open connection
+func (s *fakeIMAPServer) push(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.connections {
+		fmt.Fprint(conn, line)
+	}
+}
+
+func (s *fakeIMAPServer) connectionCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.connections)
+}
+
+func (s *fakeIMAPServer) sawCommand(cmd string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, line := range s.received {
+		if strings.Contains(strings.ToUpper(line), cmd) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fakeIMAPServer) sawLogin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, line := range s.received {
+		if strings.Contains(strings.ToUpper(line), "LOGIN") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDialTLSDoesNotFallBackToPlaintext(t *testing.T) {
+	server := newFakeIMAPServer(t, "IMAP4rev1 AUTH=PLAIN")
+
+	_, err := Dial(context.Background(), Account{
+		Server:   "127.0.0.1",
+		Port:     server.port(),
+		Security: SecurityTLS,
+		Username: "user",
+		Password: "secret",
+	})
+
+	var connErr *ConnError
+	if !errors.As(err, &connErr) || connErr.Stage != StageTLS {
+		t.Fatalf("Dial() error = %v, want TLS stage error", err)
+	}
+	if server.sawLogin() {
+		t.Fatal("password was sent over a plaintext connection")
+	}
+}
+
+func TestDialSTARTTLSRequiresServerSupport(t *testing.T) {
+	server := newFakeIMAPServer(t, "IMAP4rev1 AUTH=PLAIN")
+
+	_, err := Dial(context.Background(), Account{
+		Server:   "127.0.0.1",
+		Port:     server.port(),
+		Security: SecuritySTARTTLS,
+		Username: "user",
+		Password: "secret",
+	})
+
+	var connErr *ConnError
+	if !errors.As(err, &connErr) || connErr.Stage != StageTLS {
+		t.Fatalf("Dial() error = %v, want TLS stage error", err)
+	}
+	if !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("error %q should mention STARTTLS", err)
+	}
+	if server.sawLogin() {
+		t.Fatal("password was sent over a plaintext connection")
+	}
+}
+
+func TestDialPlaintextWhenChosen(t *testing.T) {
+	server := newFakeIMAPServer(t, "IMAP4rev1 AUTH=PLAIN")
+
+	c, err := Dial(context.Background(), Account{
+		Server:   "127.0.0.1",
+		Port:     server.port(),
+		Security: SecurityPlaintext,
+		Username: "user",
+		Password: "secret",
+	})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	c.Logout()
+
+	if !server.sawLogin() {
+		t.Fatal("expected a LOGIN over the explicitly chosen plaintext connection")
+	}
+}
+
+func TestAccessTokenRefreshAndRotation(t *testing.T) {
+	requests := 0
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() error = %v", err)
+		}
+		if r.Form.Get("grant_type") != "refresh_token" || r.Form.Get("refresh_token") != "old-refresh" {
+			t.Errorf("unexpected token request: %v", r.Form)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"access-1","expires_in":3600,"refresh_token":"new-refresh"}`)
+	}))
+	defer tokenServer.Close()
+
+	config := OAuthConfig{TokenURL: tokenServer.URL, ClientID: "client", RefreshToken: "old-refresh"}
+	var rotated string
+
+	token, err := config.accessToken(context.Background(), func(refreshToken string) { rotated = refreshToken })
+	if err != nil {
+		t.Fatalf("accessToken() error = %v", err)
+	}
+	if token != "access-1" {
+		t.Errorf("access token = %q, want access-1", token)
+	}
+	if rotated != "new-refresh" {
+		t.Errorf("rotated refresh token = %q, want new-refresh", rotated)
+	}
+
+	// The stored refresh token is now the rotated one, its access token is cached
+	config.RefreshToken = rotated
+	if token, err := config.accessToken(context.Background(), nil); err != nil || token != "access-1" {
+		t.Fatalf("cached accessToken() = %q, %v", token, err)
+	}
+	if requests != 1 {
+		t.Errorf("token endpoint called %d times, want 1", requests)
+	}
+}
+
+func TestAccessTokenError(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"invalid_grant","error_description":"Token has been expired or revoked."}`)
+	}))
+	defer tokenServer.Close()
+
+	config := OAuthConfig{TokenURL: tokenServer.URL, ClientID: "client", RefreshToken: "revoked"}
+	_, err := config.accessToken(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "invalid_grant") {
+		t.Fatalf("accessToken() error = %v, want invalid_grant", err)
+	}
+}
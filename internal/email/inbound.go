@@ -0,0 +1,254 @@
+package email
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-smtp"
+)
+
+// Inbound protocols
+const (
+	ProtocolSMTP = "smtp"
+	ProtocolLMTP = "lmtp"
+)
+
+const (
+	// inboundPrefix starts the local part of every generated address, e.g. feed-abc123@example.com
+	inboundPrefix = "feed-"
+	// MaxInboundMessageBytes limits the size of a received newsletter
+	MaxInboundMessageBytes = 10 << 20
+	maxInboundRecipients   = 50
+	inboundTimeout         = 2 * time.Minute
+)
+
+var tokenEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewInboundToken returns a random token for a generated newsletter address.
+func NewInboundToken() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ToLower(tokenEncoding.EncodeToString(b)), nil
+}
+
+// InboundAddress returns the generated address of a token.
+func InboundAddress(token, domain string) string {
+	return inboundPrefix + token + "@" + domain
+}
+
+// InboundToken extracts the token from a recipient address.
+// When domain is set, addresses at other domains are rejected.
+func InboundToken(address, domain string) (string, bool) {
+	address = strings.ToLower(strings.Trim(strings.TrimSpace(address), "<>"))
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return "", false
+	}
+	local, host := address[:at], address[at+1:]
+	if domain != "" && host != strings.ToLower(domain) {
+		return "", false
+	}
+	if !strings.HasPrefix(local, inboundPrefix) {
+		return "", false
+	}
+	token := strings.TrimPrefix(local, inboundPrefix)
+	// Allow subaddressing such as feed-abc123+tag@example.com
+	if plus := strings.IndexByte(token, '+'); plus >= 0 {
+		token = token[:plus]
+	}
+	return token, token != ""
+}
+
+// Receiver accepts newsletters over SMTP or LMTP for generated addresses and hands
+// each message to the feed its address belongs to.
+type Receiver struct {
+	Protocol string // smtp or lmtp
+	// Addr is host:port, or unix:/path for a socket (usual for LMTP)
+	Addr   string
+	Domain string
+
+	// Lookup returns the feed of an address token
+	Lookup func(token string) (int64, bool)
+	// Deliver stores a raw message in a feed
+	Deliver func(feedID int64, raw []byte) error
+
+	server   *smtp.Server
+	listener net.Listener
+}
+
+// Start opens the listener and serves connections in the background.
+func (r *Receiver) Start() error {
+	if r.Lookup == nil || r.Deliver == nil {
+		return errors.New("receiver needs Lookup and Deliver")
+	}
+	if r.Protocol != ProtocolSMTP && r.Protocol != ProtocolLMTP {
+		return fmt.Errorf("unknown inbound protocol %q", r.Protocol)
+	}
+
+	network, addr := "tcp", r.Addr
+	if path, ok := strings.CutPrefix(r.Addr, "unix:"); ok {
+		network, addr = "unix", path
+		// A socket left over from an unclean shutdown would block the listener
+		_ = os.Remove(path)
+	}
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", r.Addr, err)
+	}
+
+	s := smtp.NewServer(&inboundBackend{receiver: r})
+	s.LMTP = r.Protocol == ProtocolLMTP
+	s.Domain = r.Domain
+	if s.Domain == "" {
+		s.Domain = "localhost"
+	}
+	s.MaxMessageBytes = MaxInboundMessageBytes
+	s.MaxRecipients = maxInboundRecipients
+	s.ReadTimeout = inboundTimeout
+	s.WriteTimeout = inboundTimeout
+	s.AuthDisabled = true
+
+	r.server = s
+	r.listener = l
+	go func() {
+		if err := s.Serve(l); err != nil && !errors.Is(err, net.ErrClosed) {
+			log.Printf("Inbound mail: %s server stopped: %v", r.Protocol, err)
+		}
+	}()
+	log.Printf("Inbound mail: accepting newsletters over %s on %s", strings.ToUpper(r.Protocol), l.Addr())
+	return nil
+}
+
+// ListenAddr returns the address the receiver is listening on.
+func (r *Receiver) ListenAddr() net.Addr {
+	if r.listener == nil {
+		return nil
+	}
+	return r.listener.Addr()
+}
+
+// Close stops accepting mail and closes open connections.
+func (r *Receiver) Close() error {
+	if r.server == nil {
+		return nil
+	}
+	return r.server.Close()
+}
+
+var errUnknownRecipient = &smtp.SMTPError{
+	Code:         550,
+	EnhancedCode: smtp.EnhancedCode{5, 1, 1},
+	Message:      "No newsletter feed for this address",
+}
+
+var errDeliveryFailed = &smtp.SMTPError{
+	Code:         451,
+	EnhancedCode: smtp.EnhancedCode{4, 3, 0},
+	Message:      "Could not store the message, try again later",
+}
+
+// inboundBackend creates sessions for anonymous senders; there are no user accounts
+type inboundBackend struct {
+	receiver *Receiver
+}
+
+func (b *inboundBackend) Login(state *smtp.ConnectionState, username, password string) (smtp.Session, error) {
+	return nil, smtp.ErrAuthUnsupported
+}
+
+func (b *inboundBackend) AnonymousLogin(state *smtp.ConnectionState) (smtp.Session, error) {
+	return &inboundSession{receiver: b.receiver}, nil
+}
+
+// inboundRecipient is an accepted RCPT TO address and its feed
+type inboundRecipient struct {
+	address string
+	feedID  int64
+}
+
+type inboundSession struct {
+	receiver   *Receiver
+	recipients []inboundRecipient
+}
+
+func (s *inboundSession) Reset() {
+	s.recipients = nil
+}
+
+func (s *inboundSession) Logout() error {
+	return nil
+}
+
+func (s *inboundSession) Mail(from string, opts smtp.MailOptions) error {
+	return nil
+}
+
+// Rcpt only accepts generated addresses of existing feeds, so the server is no open relay
+func (s *inboundSession) Rcpt(to string) error {
+	token, ok := InboundToken(to, s.receiver.Domain)
+	if !ok {
+		return errUnknownRecipient
+	}
+	feedID, ok := s.receiver.Lookup(token)
+	if !ok {
+		return errUnknownRecipient
+	}
+	s.recipients = append(s.recipients, inboundRecipient{address: to, feedID: feedID})
+	return nil
+}
+
+func (s *inboundSession) Data(r io.Reader) error {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	delivered := make(map[int64]error)
+	for _, rcpt := range s.recipients {
+		if _, done := delivered[rcpt.feedID]; done {
+			continue
+		}
+		delivered[rcpt.feedID] = s.deliver(rcpt.feedID, raw)
+	}
+	for _, err := range delivered {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LMTPData reports a status per recipient, so one failing feed doesn't make the MTA resend to all
+func (s *inboundSession) LMTPData(r io.Reader, status smtp.StatusCollector) error {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	delivered := make(map[int64]error)
+	for _, rcpt := range s.recipients {
+		err, done := delivered[rcpt.feedID]
+		if !done {
+			err = s.deliver(rcpt.feedID, raw)
+			delivered[rcpt.feedID] = err
+		}
+		status.SetStatus(rcpt.address, err)
+	}
+	return nil
+}
+
+func (s *inboundSession) deliver(feedID int64, raw []byte) error {
+	if err := s.receiver.Deliver(feedID, raw); err != nil {
+		log.Printf("Inbound mail: failed to store message for feed %d: %v", feedID, err)
+		return errDeliveryFailed
+	}
+	return nil
+}
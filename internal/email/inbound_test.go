@@ -0,0 +1,87 @@
+package email
+
+import (
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestInboundToken(t *testing.T) {
+	tests := []struct {
+		address, domain, token string
+		ok                     bool
+	}{
+		{"feed-abc123@news.example.com", "news.example.com", "abc123", true},
+		{"<Feed-ABC123@News.Example.com>", "news.example.com", "abc123", true},
+		{"feed-abc123+weekly@news.example.com", "news.example.com", "abc123", true},
+		{"feed-abc123@other.example.com", "news.example.com", "", false},
+		{"feed-abc123@anything.example", "", "abc123", true},
+		{"postmaster@news.example.com", "news.example.com", "", false},
+		{"feed-@news.example.com", "news.example.com", "", false},
+	}
+	for _, tt := range tests {
+		token, ok := InboundToken(tt.address, tt.domain)
+		if token != tt.token || ok != tt.ok {
+			t.Errorf("InboundToken(%q, %q) = %q, %v, want %q, %v", tt.address, tt.domain, token, ok, tt.token, tt.ok)
+		}
+	}
+
+	token, err := NewInboundToken()
+	if err != nil {
+		t.Fatalf("NewInboundToken() error = %v", err)
+	}
+	if got, ok := InboundToken(InboundAddress(token, "news.example.com"), "news.example.com"); !ok || got != token {
+		t.Errorf("round trip of %q = %q, %v", token, got, ok)
+	}
+}
+
+func TestReceiverDeliversToFeed(t *testing.T) {
+	var mu sync.Mutex
+	delivered := make(map[int64]string)
+
+	receiver := &Receiver{
+		Protocol: ProtocolSMTP,
+		Addr:     "127.0.0.1:0",
+		Domain:   "news.example.com",
+		Lookup: func(token string) (int64, bool) {
+			if token == "known" {
+				return 7, true
+			}
+			return 0, false
+		},
+		Deliver: func(feedID int64, raw []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			delivered[feedID] = string(raw)
+			return nil
+		},
+	}
+	if err := receiver.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer receiver.Close()
+	addr := receiver.ListenAddr().String()
+
+	msg := "From: sender@example.org\r\nSubject: Hello\r\n\r\nBody\r\n"
+	if err := smtp.SendMail(addr, nil, "sender@example.org", []string{"feed-known@news.example.com"}, []byte(msg)); err != nil {
+		t.Fatalf("SendMail() error = %v", err)
+	}
+
+	mu.Lock()
+	got := delivered[7]
+	mu.Unlock()
+	if !strings.Contains(got, "Subject: Hello") {
+		t.Fatalf("delivered message = %q", got)
+	}
+
+	// Unknown addresses are refused, the listener is no open relay
+	err := smtp.SendMail(addr, nil, "sender@example.org", []string{"feed-unknown@news.example.com"}, []byte(msg))
+	if err == nil || !strings.Contains(err.Error(), "550") {
+		t.Fatalf("SendMail(unknown) error = %v, want 550", err)
+	}
+	err = smtp.SendMail(addr, nil, "sender@example.org", []string{"someone@elsewhere.example"}, []byte(msg))
+	if err == nil || !strings.Contains(err.Error(), "550") {
+		t.Fatalf("SendMail(relay) error = %v, want 550", err)
+	}
+}
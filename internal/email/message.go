@@ -0,0 +1,157 @@
+package email
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+// ParsedMessage is the content of a raw RFC 5322 newsletter.
+type ParsedMessage struct {
+	MessageID   string
+	Subject     string
+	FromName    string
+	FromAddress string
+	Date        time.Time
+	ListID      string
+	ListName    string
+	// HTML is the HTML body, or the plain text body converted to HTML
+	HTML string
+}
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: charset.NewReaderLabel}
+
+// ParseMessage reads the headers and the best body of a raw message.
+// For multipart messages the first HTML part wins over plain text; attachments are ignored.
+func ParseMessage(r io.Reader) (*ParsedMessage, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+
+	parsed := &ParsedMessage{
+		MessageID: strings.Trim(strings.TrimSpace(msg.Header.Get("Message-Id")), "<>"),
+	}
+	if subject, err := wordDecoder.DecodeHeader(msg.Header.Get("Subject")); err == nil {
+		parsed.Subject = subject
+	} else {
+		parsed.Subject = msg.Header.Get("Subject")
+	}
+	parser := mail.AddressParser{WordDecoder: wordDecoder}
+	if from, err := parser.ParseList(msg.Header.Get("From")); err == nil && len(from) > 0 {
+		parsed.FromName = from[0].Name
+		parsed.FromAddress = from[0].Address
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		parsed.Date = date
+	}
+	parsed.ListID, parsed.ListName = ParseListID(msg.Header.Get("List-Id"))
+
+	htmlBody, textBody, err := readBody(textproto.MIMEHeader(msg.Header), msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	if htmlBody != "" {
+		parsed.HTML = htmlBody
+	} else if strings.TrimSpace(textBody) != "" {
+		parsed.HTML = textToHTML(textBody)
+	}
+	return parsed, nil
+}
+
+// readBody returns the first HTML and the first plain text body found in a message part
+func readBody(header textproto.MIMEHeader, body io.Reader) (htmlBody, textBody string, err error) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		// RFC 2045: without a valid Content-Type the body is US-ASCII plain text
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	if disposition, _, _ := mime.ParseMediaType(header.Get("Content-Disposition")); disposition == "attachment" {
+		return "", "", nil
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				return htmlBody, textBody, nil
+			}
+			if err != nil {
+				// Keep what was read before a malformed part
+				return htmlBody, textBody, nil
+			}
+			partHTML, partText, err := readBody(part.Header, part)
+			if err != nil {
+				return htmlBody, textBody, err
+			}
+			if htmlBody == "" {
+				htmlBody = partHTML
+			}
+			if textBody == "" {
+				textBody = partText
+			}
+			if htmlBody != "" {
+				return htmlBody, textBody, nil
+			}
+		}
+	}
+
+	if mediaType != "text/html" && mediaType != "text/plain" {
+		return "", "", nil
+	}
+
+	content, err := io.ReadAll(decodeBody(header, params, body))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read message body: %w", err)
+	}
+	if mediaType == "text/html" {
+		return string(content), "", nil
+	}
+	return "", string(content), nil
+}
+
+// decodeBody undoes the transfer encoding and converts the charset to UTF-8
+func decodeBody(header textproto.MIMEHeader, params map[string]string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	label := strings.ToLower(params["charset"])
+	if label == "" || label == "utf-8" || label == "us-ascii" {
+		return body
+	}
+	if decoded, err := charset.NewReaderLabel(label, body); err == nil {
+		return decoded
+	}
+	return body
+}
+
+// textToHTML escapes a plain text newsletter and keeps its line breaks
+func textToHTML(text string) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "\r\n", "\n")
+	paragraphs := strings.Split(text, "\n\n")
+	var b strings.Builder
+	for _, p := range paragraphs {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(p), "\n", "<br>\n"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,89 @@
+package email
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMessageMultipartPrefersHTML(t *testing.T) {
+	raw := "From: =?UTF-8?Q?Caf=C3=A9_Weekly?= <news@cafe.example>\r\n" +
+		"Subject: =?UTF-8?B?SXNzdWUgIzQy?=\r\n" +
+		"Date: Mon, 02 Jan 2006 15:04:05 +0000\r\n" +
+		"Message-Id: <abc@cafe.example>\r\n" +
+		"List-Id: Cafe Weekly <weekly.cafe.example>\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/alternative; boundary=b1\r\n" +
+		"\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"plain version\r\n" +
+		"--b1\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"PHA+SGVsbG8gPGI+d29ybGQ8L2I+PC9wPg==\r\n" +
+		"--b1--\r\n"
+
+	parsed, err := ParseMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v", err)
+	}
+	if parsed.Subject != "Issue #42" {
+		t.Errorf("Subject = %q", parsed.Subject)
+	}
+	if parsed.FromName != "Café Weekly" || parsed.FromAddress != "news@cafe.example" {
+		t.Errorf("From = %q <%s>", parsed.FromName, parsed.FromAddress)
+	}
+	if parsed.MessageID != "abc@cafe.example" || parsed.ListID != "weekly.cafe.example" {
+		t.Errorf("MessageID = %q, ListID = %q", parsed.MessageID, parsed.ListID)
+	}
+	if parsed.HTML != "<p>Hello <b>world</b></p>" {
+		t.Errorf("HTML = %q", parsed.HTML)
+	}
+	if parsed.Date.IsZero() {
+		t.Error("Date not parsed")
+	}
+}
+
+func TestParseMessagePlainText(t *testing.T) {
+	raw := "From: a@example.com\r\n" +
+		"Subject: Plain\r\n" +
+		"Content-Type: text/plain; charset=iso-8859-1\r\n" +
+		"Content-Transfer-Encoding: quoted-printable\r\n" +
+		"\r\n" +
+		"Gr=FC=DFe <friends>\r\nsecond line\r\n\r\nnext paragraph\r\n"
+
+	parsed, err := ParseMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v", err)
+	}
+	want := "<p>Grüße &lt;friends&gt;<br>\nsecond line</p>\n<p>next paragraph</p>\n"
+	if parsed.HTML != want {
+		t.Errorf("HTML = %q, want %q", parsed.HTML, want)
+	}
+}
+
+func TestParseMessageSkipsAttachments(t *testing.T) {
+	raw := "Subject: Report\r\n" +
+		"Content-Type: multipart/mixed; boundary=m\r\n" +
+		"\r\n" +
+		"--m\r\n" +
+		"Content-Type: text/html\r\n" +
+		"Content-Disposition: attachment; filename=old.html\r\n" +
+		"\r\n" +
+		"<p>attached</p>\r\n" +
+		"--m\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"inline body\r\n" +
+		"--m--\r\n"
+
+	parsed, err := ParseMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v", err)
+	}
+	if parsed.HTML != "<p>inline body</p>\n" {
+		t.Errorf("HTML = %q", parsed.HTML)
+	}
+}
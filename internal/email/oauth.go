@@ -0,0 +1,134 @@
+package email
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emersion/go-sasl"
+)
+
+// XOAuth2 is the XOAUTH2 SASL mechanism name used by Gmail and Outlook.
+const XOAuth2 = "XOAUTH2"
+
+// OAuthConfig holds the OAuth client and refresh token used to get IMAP access tokens.
+type OAuthConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	RefreshToken string
+}
+
+type xoauth2Client struct {
+	username string
+	token    string
+}
+
+// NewXOAuth2Client returns a SASL client for the XOAUTH2 mechanism.
+func NewXOAuth2Client(username, token string) sasl.Client {
+	return &xoauth2Client{username: username, token: token}
+}
+
+func (c *xoauth2Client) Start() (string, []byte, error) {
+	ir := "user=" + c.username + "\x01auth=Bearer " + c.token + "\x01\x01"
+	return XOAuth2, []byte(ir), nil
+}
+
+// Next answers the error challenge with an empty response so the server sends its final NO
+func (c *xoauth2Client) Next(challenge []byte) ([]byte, error) {
+	return []byte{}, nil
+}
+
+type cachedToken struct {
+	accessToken string
+	expiresAt   time.Time
+}
+
+var (
+	tokenCacheMu sync.Mutex
+	tokenCache   = make(map[string]cachedToken)
+)
+
+var tokenHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// accessToken returns a cached access token or exchanges the refresh token for a new one
+func (o OAuthConfig) accessToken(ctx context.Context, onRotated func(string)) (string, error) {
+	key := o.TokenURL + "\x00" + o.ClientID + "\x00" + o.RefreshToken
+
+	tokenCacheMu.Lock()
+	cached, ok := tokenCache[key]
+	tokenCacheMu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.accessToken, nil
+	}
+
+	form := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {o.RefreshToken},
+		"client_id":     {o.ClientID},
+	}
+	if o.ClientSecret != "" {
+		form.Set("client_secret", o.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := tokenHTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		RefreshToken     string `json:"refresh_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+	if body.Error != "" {
+		if body.ErrorDescription != "" {
+			return "", fmt.Errorf("%s: %s", body.Error, body.ErrorDescription)
+		}
+		return "", errors.New(body.Error)
+	}
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		return "", fmt.Errorf("token endpoint returned status %d without an access token", resp.StatusCode)
+	}
+
+	// Refresh a minute early so a token never expires mid-login
+	expiresIn := time.Duration(body.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = time.Hour
+	}
+	token := cachedToken{accessToken: body.AccessToken, expiresAt: time.Now().Add(expiresIn - time.Minute)}
+
+	tokenCacheMu.Lock()
+	delete(tokenCache, key)
+	if body.RefreshToken != "" && body.RefreshToken != o.RefreshToken {
+		key = o.TokenURL + "\x00" + o.ClientID + "\x00" + body.RefreshToken
+	}
+	tokenCache[key] = token
+	tokenCacheMu.Unlock()
+
+	// Some providers (e.g. Microsoft) rotate refresh tokens, the new one must be stored
+	if body.RefreshToken != "" && body.RefreshToken != o.RefreshToken && onRotated != nil {
+		onRotated(body.RefreshToken)
+	}
+
+	return body.AccessToken, nil
+}
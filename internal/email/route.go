@@ -0,0 +1,223 @@
+package email
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/textproto"
+	"regexp"
+	"strings"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+
+	"MrRSS/internal/models"
+)
+
+// Route match types
+const (
+	MatchFrom    = "from"    // Sender address
+	MatchDomain  = "domain"  // Sender domain, subdomains included
+	MatchListID  = "list_id" // List-Id header (RFC 2919)
+	MatchSubject = "subject" // Regular expression on the subject
+)
+
+// Actions applied to messages after they were fetched
+const (
+	ProcessedNone = ""     // Leave the message alone
+	ProcessedMove = "move" // Move it to the processed folder
+	ProcessedFlag = "flag" // Set the \Flagged flag
+)
+
+// Message holds the parts of a newsletter that routes match on.
+type Message struct {
+	From     string // Sender address
+	Subject  string
+	ListID   string // List-Id identifier, e.g. "weekly.example.com"
+	ListName string // List-Id description, e.g. "Example Weekly"
+}
+
+// ValidateRoute checks that a route's match type is known and its pattern is usable.
+func ValidateRoute(matchType, pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return errors.New("route pattern is required")
+	}
+	switch matchType {
+	case MatchFrom, MatchDomain, MatchListID:
+		return nil
+	case MatchSubject:
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid subject pattern: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown route match type %q", matchType)
+	}
+}
+
+// ValidateProcessedAction checks the action applied to processed messages.
+func ValidateProcessedAction(action, folder string) error {
+	switch action {
+	case ProcessedNone, ProcessedFlag:
+		return nil
+	case ProcessedMove:
+		if strings.TrimSpace(folder) == "" {
+			return errors.New("a folder is required to move processed messages")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown processed action %q", action)
+	}
+}
+
+// Router picks the feed a message belongs to.
+type Router struct {
+	routes []compiledRoute
+}
+
+type compiledRoute struct {
+	route   models.EmailRoute
+	pattern string
+	subject *regexp.Regexp
+}
+
+// NewRouter prepares routes for matching, in the order given. Invalid routes are skipped.
+func NewRouter(routes []models.EmailRoute) *Router {
+	r := &Router{}
+	for _, route := range routes {
+		if err := ValidateRoute(route.MatchType, route.Pattern); err != nil {
+			log.Printf("Skipping email route %d: %v", route.ID, err)
+			continue
+		}
+		c := compiledRoute{route: route, pattern: normalizePattern(route.MatchType, route.Pattern)}
+		if route.MatchType == MatchSubject {
+			c.subject = regexp.MustCompile(route.Pattern)
+		}
+		r.routes = append(r.routes, c)
+	}
+	return r
+}
+
+// Route returns the feed of the first route that matches the message.
+func (r *Router) Route(m Message) (int64, bool) {
+	from := strings.ToLower(strings.TrimSpace(m.From))
+	domain := ""
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = from[at+1:]
+	}
+	listID := strings.ToLower(m.ListID)
+
+	for _, c := range r.routes {
+		matched := false
+		switch c.route.MatchType {
+		case MatchFrom:
+			matched = from != "" && from == c.pattern
+		case MatchDomain:
+			matched = domain != "" && (domain == c.pattern || strings.HasSuffix(domain, "."+c.pattern))
+		case MatchListID:
+			matched = listID != "" && listID == c.pattern
+		case MatchSubject:
+			matched = c.subject.MatchString(m.Subject)
+		}
+		if matched {
+			return c.route.FeedID, true
+		}
+	}
+	return 0, false
+}
+
+// normalizePattern lowercases address patterns and strips the decoration users tend to paste
+func normalizePattern(matchType, pattern string) string {
+	pattern = strings.TrimSpace(pattern)
+	switch matchType {
+	case MatchFrom:
+		return strings.ToLower(strings.Trim(pattern, "<>"))
+	case MatchDomain:
+		return strings.ToLower(strings.TrimPrefix(pattern, "@"))
+	case MatchListID:
+		id, _ := ParseListID(pattern)
+		return id
+	}
+	return pattern
+}
+
+// ParseListID splits a List-Id header value such as "Example Weekly <weekly.example.com>"
+// into its lowercased identifier and description.
+func ParseListID(value string) (id, name string) {
+	value = strings.TrimSpace(value)
+	start := strings.LastIndex(value, "<")
+	end := strings.LastIndex(value, ">")
+	if start < 0 || end < start {
+		return strings.ToLower(value), ""
+	}
+	id = strings.ToLower(strings.TrimSpace(value[start+1 : end]))
+	name = strings.Trim(strings.TrimSpace(value[:start]), `"`)
+	return id, name
+}
+
+// ListIDSection is the header section to fetch so messages can be routed by List-Id.
+// It peeks, so fetching doesn't mark messages as seen.
+func ListIDSection() *imap.BodySectionName {
+	return &imap.BodySectionName{
+		BodyPartName: imap.BodyPartName{
+			Specifier: imap.HeaderSpecifier,
+			Fields:    []string{"List-Id"},
+		},
+		Peek: true,
+	}
+}
+
+// MessageFromIMAP collects the routing fields of a fetched message.
+func MessageFromIMAP(msg *imap.Message) Message {
+	var m Message
+	if msg.Envelope != nil {
+		m.Subject = msg.Envelope.Subject
+		if len(msg.Envelope.From) > 0 {
+			m.From = msg.Envelope.From[0].Address()
+		}
+	}
+	if header := msg.GetBody(ListIDSection()); header != nil {
+		m.ListID, m.ListName = listIDFromHeader(header)
+	}
+	return m
+}
+
+// listIDFromHeader reads the List-Id field from a raw header block
+func listIDFromHeader(r io.Reader) (id, name string) {
+	header, err := textproto.NewReader(bufio.NewReader(r)).ReadMIMEHeader()
+	if err != nil && len(header) == 0 {
+		return "", ""
+	}
+	value := header.Get("List-Id")
+	if value == "" {
+		return "", ""
+	}
+	return ParseListID(value)
+}
+
+// ApplyProcessedAction moves or flags messages in the selected folder after they were stored.
+// The folder must be selected read-write.
+func ApplyProcessedAction(c *client.Client, uids []uint32, action, folder string) error {
+	if len(uids) == 0 || action == ProcessedNone {
+		return nil
+	}
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(uids...)
+
+	switch action {
+	case ProcessedMove:
+		if err := c.UidMove(seqset, folder); err != nil {
+			return fmt.Errorf("failed to move messages to %s: %w", folder, err)
+		}
+	case ProcessedFlag:
+		item := imap.FormatFlagsOp(imap.AddFlags, true)
+		if err := c.UidStore(seqset, item, []interface{}{imap.FlaggedFlag}, nil); err != nil {
+			return fmt.Errorf("failed to flag messages: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown processed action %q", action)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package email
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"MrRSS/internal/models"
+)
+
+func TestParseListID(t *testing.T) {
+	tests := []struct {
+		value, id, name string
+	}{
+		{"Example Weekly <Weekly.Example.com>", "weekly.example.com", "Example Weekly"},
+		{`"Quoted Name" <news.example.org>`, "news.example.org", "Quoted Name"},
+		{"<bare.example.net>", "bare.example.net", ""},
+		{"no-brackets.example.com", "no-brackets.example.com", ""},
+	}
+	for _, tt := range tests {
+		id, name := ParseListID(tt.value)
+		if id != tt.id || name != tt.name {
+			t.Errorf("ParseListID(%q) = %q, %q, want %q, %q", tt.value, id, name, tt.id, tt.name)
+		}
+	}
+}
+
+func TestListIDFromHeader(t *testing.T) {
+	id, name := listIDFromHeader(strings.NewReader("List-Id: Example Weekly <weekly.example.com>\r\n\r\n"))
+	if id != "weekly.example.com" || name != "Example Weekly" {
+		t.Errorf("listIDFromHeader() = %q, %q", id, name)
+	}
+	if id, _ := listIDFromHeader(strings.NewReader("\r\n")); id != "" {
+		t.Errorf("listIDFromHeader(empty) = %q, want empty", id)
+	}
+}
+
+func TestRouterRoute(t *testing.T) {
+	router := NewRouter([]models.EmailRoute{
+		{ID: 1, FeedID: 10, MatchType: MatchListID, Pattern: "Weekly <weekly.example.com>"},
+		{ID: 2, FeedID: 20, MatchType: MatchFrom, Pattern: "Editor@Example.org"},
+		{ID: 3, FeedID: 30, MatchType: MatchDomain, Pattern: "@substack.com"},
+		{ID: 4, FeedID: 40, MatchType: MatchSubject, Pattern: `(?i)^\[digest\]`},
+		{ID: 5, FeedID: 50, MatchType: MatchSubject, Pattern: "("}, // invalid, skipped
+	})
+
+	tests := []struct {
+		name   string
+		msg    Message
+		feedID int64
+		ok     bool
+	}{
+		{"list id wins over sender", Message{From: "editor@example.org", ListID: "weekly.example.com"}, 10, true},
+		{"sender address", Message{From: "EDITOR@example.org"}, 20, true},
+		{"subdomain", Message{From: "writer@news.substack.com"}, 30, true},
+		{"domain suffix is not a subdomain", Message{From: "writer@notsubstack.com"}, 0, false},
+		{"subject", Message{From: "a@b.c", Subject: "[Digest] Monday"}, 40, true},
+		{"no match", Message{From: "someone@else.com", Subject: "Hello"}, 0, false},
+	}
+	for _, tt := range tests {
+		feedID, ok := router.Route(tt.msg)
+		if feedID != tt.feedID || ok != tt.ok {
+			t.Errorf("%s: Route() = %d, %v, want %d, %v", tt.name, feedID, ok, tt.feedID, tt.ok)
+		}
+	}
+}
+
+func TestValidateProcessedAction(t *testing.T) {
+	if err := ValidateProcessedAction(ProcessedMove, ""); err == nil {
+		t.Error("move without a folder should fail")
+	}
+	if err := ValidateProcessedAction("delete", ""); err == nil {
+		t.Error("unknown action should fail")
+	}
+	if err := ValidateProcessedAction(ProcessedFlag, ""); err != nil {
+		t.Errorf("ValidateProcessedAction(flag) error = %v", err)
+	}
+}
+
+func TestApplyProcessedAction(t *testing.T) {
+	server := newFakeIMAPServer(t, "IMAP4rev1 AUTH=PLAIN MOVE")
+
+	c, err := Dial(context.Background(), Account{
+		Server:   "127.0.0.1",
+		Port:     server.port(),
+		Security: SecurityPlaintext,
+		Username: "user",
+		Password: "secret",
+	})
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer c.Logout()
+
+	if _, err := c.Select("INBOX", false); err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if err := ApplyProcessedAction(c, []uint32{4, 5}, ProcessedFlag, ""); err != nil {
+		t.Fatalf("ApplyProcessedAction(flag) error = %v", err)
+	}
+	if err := ApplyProcessedAction(c, []uint32{4, 5}, ProcessedMove, "Newsletters"); err != nil {
+		t.Fatalf("ApplyProcessedAction(move) error = %v", err)
+	}
+
+	if !server.sawCommand(`UID STORE 4:5 +FLAGS.SILENT (\FLAGGED)`) {
+		t.Error("expected messages to be flagged")
+	}
+	if !server.sawCommand(`UID MOVE 4:5 "NEWSLETTERS"`) {
+		t.Error("expected messages to be moved")
+	}
+}
@@ -6,6 +6,14 @@ import (
 	"time"
 )
 
+// Cached article contents from before compression are compressed in the
+// background, a batch at a time, starting a while after startup
+const (
+	contentCompressionDelay = time.Minute
+	contentCompressionBatch = 100
+	contentCompressionPause = time.Second
+)
+
 // CleanupManager manages automatic cleanup with retry mechanism
 type CleanupManager struct {
 	fetcher *Fetcher
@@ -49,6 +57,9 @@ func (cm *CleanupManager) Start() {
 	cm.wg.Add(1)
 	go cm.retryLoop()
 
+	cm.wg.Add(1)
+	go cm.compressStoredContents()
+
 	log.Println("Cleanup manager started")
 }
 
@@ -134,14 +145,16 @@ func (cm *CleanupManager) canCleanup() bool {
 	return true
 }
 
-// executeCleanup executes the layered cleanup
+// executeCleanup applies the retention policies, then executes the layered cleanup
 func (cm *CleanupManager) executeCleanup() {
 	log.Println("Starting automatic cleanup...")
 
+	totalRemoved := cm.applyRetention()
+
 	maxSizeMB := cm.getTargetSize()
 
 	// Execute layered cleanup with 80% target
-	totalRemoved := cm.layeredCleanup(maxSizeMB * 0.8)
+	totalRemoved += cm.layeredCleanup(maxSizeMB * 0.8)
 
 	if totalRemoved > 0 {
 		log.Printf("Automatic cleanup completed: removed %d items", totalRemoved)
@@ -150,6 +163,21 @@ func (cm *CleanupManager) executeCleanup() {
 	}
 }
 
+// applyRetention deletes what the retention policies do not keep. Feeds without
+// a policy of their own keep articles max_article_age_days when auto cleanup is on.
+func (cm *CleanupManager) applyRetention() int64 {
+	autoCleanup, _ := cm.fetcher.db.GetSetting("auto_cleanup_enabled")
+	articles, contents, err := cm.fetcher.db.ApplyRetention(autoCleanup == "true")
+	if err != nil {
+		log.Printf("Retention cleanup error: %v", err)
+		return 0
+	}
+	if articles > 0 || contents > 0 {
+		log.Printf("Retention cleanup: removed %d articles and %d article contents", articles, contents)
+	}
+	return articles + contents
+}
+
 // getTargetSize returns the target database size in MB
 func (cm *CleanupManager) getTargetSize() float64 {
 	maxSizeMBStr, _ := cm.fetcher.db.GetSetting("max_cache_size_mb")
@@ -286,6 +314,36 @@ func (cm *CleanupManager) retryLoop() {
 	}
 }
 
+// compressStoredContents compresses the article contents cached before
+// compression, until all are done or the manager stops
+func (cm *CleanupManager) compressStoredContents() {
+	defer cm.wg.Done()
+
+	wait := contentCompressionDelay
+	total := int64(0)
+	for {
+		select {
+		case <-cm.stopChan:
+			return
+		case <-time.After(wait):
+		}
+		wait = contentCompressionPause
+
+		count, err := cm.fetcher.db.CompressArticleContents(contentCompressionBatch)
+		if err != nil {
+			log.Printf("Article content compression error: %v", err)
+			return
+		}
+		if count == 0 {
+			break
+		}
+		total += count
+	}
+	if total > 0 {
+		log.Printf("Compressed %d cached article contents", total)
+	}
+}
+
 // CheckSizeAndCleanup checks database size and triggers cleanup if needed
 func (cm *CleanupManager) CheckSizeAndCleanup() {
 	maxSizeMB := cm.getTargetSize()
@@ -1,28 +1,32 @@
 package feed
 
 import (
+	"bytes"
 	"context"
-	"crypto/tls"
+	"crypto/sha256"
 	"fmt"
 	"io"
-	"runtime"
+	"log"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/emersion/go-imap"
-	id "github.com/emersion/go-imap-id"
 	"github.com/emersion/go-imap/client"
 	"github.com/mmcdole/gofeed"
 
 	"MrRSS/internal/database"
+	"MrRSS/internal/email"
 	"MrRSS/internal/models"
-	"MrRSS/internal/version"
 )
 
 // EmailFetcher handles fetching and parsing newsletter emails
 type EmailFetcher struct {
 	db     *database.DB
 	parser *gofeed.Parser
+
+	// accountLocks serializes fetches of one mailbox, which its routed feeds share
+	accountLocks sync.Map
 }
 
 // NewEmailFetcher creates a new email fetcher
@@ -33,51 +37,84 @@ func NewEmailFetcher(db *database.DB) *EmailFetcher {
 	}
 }
 
-// FetchEmails fetches new emails from IMAP and converts them to feed items
-func (ef *EmailFetcher) FetchEmails(ctx context.Context, feed *models.Feed) ([]*gofeed.Item, error) {
-	if feed.EmailIMAPServer == "" || feed.EmailUsername == "" || feed.EmailPassword == "" {
-		return nil, fmt.Errorf("IMAP credentials not configured")
+// fetchedEmail is a parsed message together with its UID and routing fields
+type fetchedEmail struct {
+	uid     uint32
+	message email.Message
+	item    *gofeed.Item
+}
+
+// FetchEmails fetches new emails from the feed's mailbox and converts them to feed items.
+// Items are returned per feed ID: routing rules can send messages to other feeds,
+// everything no rule matches belongs to the mailbox feed itself.
+func (ef *EmailFetcher) FetchEmails(ctx context.Context, feed *models.Feed) (map[int64][]*gofeed.Item, error) {
+	if err := email.AccountFromFeed(feed).Validate(); err != nil {
+		return nil, fmt.Errorf("IMAP credentials not configured: %w", err)
+	}
+
+	lock, _ := ef.accountLocks.LoadOrStore(feed.ID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	// Another fetch may have advanced the UID while this one waited
+	if current, err := ef.db.GetFeedByID(feed.ID); err == nil {
+		feed.EmailLastUID = current.EmailLastUID
+		feed.EmailUIDValidity = current.EmailUIDValidity
 	}
 
+	routes, err := ef.db.GetEmailRoutes(feed.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load email routes: %w", err)
+	}
+	routing, err := ef.db.GetEmailRouting(feed.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load email routing options: %w", err)
+	}
+	router := email.NewRouter(routes)
+
 	// Connect to IMAP server
-	c, err := ef.connectToIMAP(feed)
+	c, err := ef.connectToIMAP(ctx, feed)
 	if err != nil {
 		return nil, fmt.Errorf("IMAP connection failed: %w", err)
 	}
 	defer c.Logout()
 
-	// Select mailbox
-	_, err = c.Select(feed.EmailFolder, false)
+	// Select mailbox read-only so fetching doesn't mark newsletters as seen,
+	// unless processed messages are moved or flagged
+	mbox, err := c.Select(feed.EmailFolder, routing.ProcessedAction == email.ProcessedNone)
 	if err != nil {
 		return nil, fmt.Errorf("failed to select mailbox %s: %w", feed.EmailFolder, err)
 	}
 
-	// Determine UID range for fetching
-	fromUID := uint32(1)
-	if feed.EmailLastUID > 0 {
-		fromUID = uint32(feed.EmailLastUID + 1)
+	// A changed UIDVALIDITY means the folder was rebuilt and old UIDs are meaningless.
+	// Start over; already stored articles are deduplicated by their unique ID.
+	lastUID := feed.EmailLastUID
+	if feed.EmailUIDValidity != 0 && feed.EmailUIDValidity != mbox.UidValidity {
+		log.Printf("UIDVALIDITY of %s changed for feed %d (%d -> %d), rescanning folder",
+			feed.EmailFolder, feed.ID, feed.EmailUIDValidity, mbox.UidValidity)
+		lastUID = 0
 	}
 
 	// Search for emails newer than last processed UID
-	criteria := imap.NewSearchCriteria()
-	seqset := new(imap.SeqSet)
-	seqset.AddRange(fromUID, ^uint32(0)) // Use ^uint32(0) as max UID
-	criteria.Uid = seqset
-	criteria.Since = time.Now().AddDate(0, -1, 0) // Last 1 month
-
-	uids, err := c.Search(criteria)
+	uids, err := email.SearchNewUIDs(c, uint32(lastUID))
 	if err != nil {
 		return nil, fmt.Errorf("IMAP search failed: %w", err)
 	}
 
 	if len(uids) == 0 {
+		if mbox.UidValidity != feed.EmailUIDValidity || lastUID != feed.EmailLastUID {
+			if err := ef.db.UpdateFeedEmailUIDState(feed.ID, mbox.UidValidity, lastUID); err != nil {
+				return nil, fmt.Errorf("failed to update last UID: %w", err)
+			}
+		}
 		return nil, nil
 	}
 
 	// Fetch emails in batches
 	batchSize := 50
-	items := make([]*gofeed.Item, 0, len(uids))
-	maxUID := feed.EmailLastUID
+	delivered := make(map[int64][]*gofeed.Item)
+	processed := make([]uint32, 0, len(uids))
+	maxUID := lastUID
 
 	for i := 0; i < len(uids); i += batchSize {
 		end := i + batchSize
@@ -86,107 +123,117 @@ func (ef *EmailFetcher) FetchEmails(ctx context.Context, feed *models.Feed) ([]*
 		}
 		batchUIDs := uids[i:end]
 
-		// Track max UID in this batch
-		if int(batchUIDs[len(batchUIDs)-1]) > maxUID {
-			maxUID = int(batchUIDs[len(batchUIDs)-1])
-		}
-
-		batchItems, err := ef.fetchEmailBatch(c, batchUIDs)
+		batch, err := ef.fetchEmailBatch(c, batchUIDs)
 		if err != nil {
 			return nil, err
 		}
-		items = append(items, batchItems...)
+		for _, fetched := range batch {
+			feedID, ok := router.Route(fetched.message)
+			if !ok && routing.AutoCreateFeeds && fetched.message.ListID != "" {
+				// First message of a new mailing list: give it a feed and a route
+				route, err := ef.createListFeed(feed, fetched.message)
+				if err != nil {
+					log.Printf("Failed to create feed for list %s: %v", fetched.message.ListID, err)
+				} else {
+					routes = append(routes, route)
+					router = email.NewRouter(routes)
+					feedID, ok = route.FeedID, true
+				}
+			}
+			if !ok {
+				feedID = feed.ID
+			}
+			delivered[feedID] = append(delivered[feedID], fetched.item)
+			processed = append(processed, fetched.uid)
+		}
+
+		// UIDs are sorted, the last one of the batch is the highest
+		maxUID = int(batchUIDs[len(batchUIDs)-1])
 	}
 
-	// Update last UID if we processed new emails
-	if maxUID > feed.EmailLastUID {
-		if err := ef.db.UpdateFeedEmailLastUID(feed.ID, maxUID); err != nil {
-			return items, fmt.Errorf("failed to update last UID: %w", err)
-		}
+	// Update last UID now that the new emails are processed
+	if err := ef.db.UpdateFeedEmailUIDState(feed.ID, mbox.UidValidity, maxUID); err != nil {
+		return delivered, fmt.Errorf("failed to update last UID: %w", err)
 	}
 
-	return items, nil
-}
+	// Moving or flagging is best effort, the messages are already delivered
+	if err := email.ApplyProcessedAction(c, processed, routing.ProcessedAction, routing.ProcessedFolder); err != nil {
+		log.Printf("Newsletter feed %d: %v", feed.ID, err)
+	}
 
-// connectToIMAP establishes a connection to the IMAP server
-func (ef *EmailFetcher) connectToIMAP(feed *models.Feed) (*client.Client, error) {
-	server := fmt.Sprintf("%s:%d", feed.EmailIMAPServer, feed.EmailIMAPPort)
+	return delivered, nil
+}
 
-	// Create TLS config
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: false,
-		ServerName:         feed.EmailIMAPServer,
+// createListFeed adds a routed feed and a List-Id route for a mailing list seen for the first time
+func (ef *EmailFetcher) createListFeed(account *models.Feed, msg email.Message) (models.EmailRoute, error) {
+	title := msg.ListName
+	if title == "" {
+		title = msg.ListID
 	}
 
-	// Connect with TLS
-	c, err := client.DialTLS(server, tlsConfig)
+	feedID, err := ef.db.AddFeed(&models.Feed{
+		Title:        title,
+		URL:          "email://" + account.EmailAddress + "/" + msg.ListID,
+		Description:  fmt.Sprintf("Newsletters from %s", msg.ListID),
+		Category:     account.Category,
+		Type:         "email",
+		EmailAddress: account.EmailAddress,
+		EmailFolder:  account.EmailFolder,
+	})
 	if err != nil {
-		// Fallback to non-TLS if TLS fails
-		c, err = client.Dial(server)
-		if err != nil {
-			return nil, fmt.Errorf("failed to connect to IMAP server: %w", err)
-		}
+		return models.EmailRoute{}, err
 	}
-
-	// Send ID command before login (RFC 2971)
-	// This is required by some email providers like NetEase (163, 126)
-	if err := ef.sendIMAPID(c); err != nil {
-		// Log the error but continue - ID command is optional for most servers
-		// Some servers may not support the ID extension
+	if err := ef.db.SetFeedEmailAccount(feedID, account.ID); err != nil {
+		return models.EmailRoute{}, err
 	}
 
-	// Login
-	if err := c.Login(feed.EmailUsername, feed.EmailPassword); err != nil {
-		c.Logout()
-		return nil, fmt.Errorf("IMAP authentication failed: %w", err)
+	route := models.EmailRoute{
+		AccountFeedID: account.ID,
+		FeedID:        feedID,
+		MatchType:     email.MatchListID,
+		Pattern:       msg.ListID,
 	}
-
-	return c, nil
-}
-
-// sendIMAPID sends the IMAP ID command to identify the client
-// This is required by some email providers (e.g., NetEase 163/126) as per RFC 2971
-func (ef *EmailFetcher) sendIMAPID(c *client.Client) error {
-	idClient := id.NewClient(c)
-
-	// Check if server supports ID extension
-	supported, err := idClient.SupportID()
-	if err != nil || !supported {
-		// Server doesn't support ID extension, skip
-		return nil
+	if _, err := ef.db.AddEmailRoute(&route); err != nil {
+		return models.EmailRoute{}, err
 	}
+	log.Printf("Created newsletter feed %q for list %s", title, msg.ListID)
+	return route, nil
+}
 
-	// Send client identification
-	clientID := id.ID{
-		id.FieldName:    "MrRSS",
-		id.FieldVersion: version.Version,
-		id.FieldVendor:  "MrRSS",
-		id.FieldOS:      runtime.GOOS,
+// connectToIMAP establishes a connection to the IMAP server using the feed's security mode and login method
+func (ef *EmailFetcher) connectToIMAP(ctx context.Context, feed *models.Feed) (*client.Client, error) {
+	account := email.AccountFromFeed(feed)
+	account.OnRefreshTokenRotated = func(refreshToken string) {
+		if err := ef.db.UpdateFeedWithOptions(feed.ID, database.FeedUpdateOptions{EmailOAuthRefreshToken: &refreshToken}); err != nil {
+			log.Printf("Failed to store rotated OAuth refresh token for feed %d: %v", feed.ID, err)
+		}
 	}
-
-	_, err = idClient.ID(clientID)
-	return err
+	return email.Dial(ctx, account)
 }
 
 // fetchEmailBatch fetches and parses a batch of emails
-func (ef *EmailFetcher) fetchEmailBatch(c *client.Client, uids []uint32) ([]*gofeed.Item, error) {
+func (ef *EmailFetcher) fetchEmailBatch(c *client.Client, uids []uint32) ([]fetchedEmail, error) {
 	seqset := new(imap.SeqSet)
 	seqset.AddNum(uids...)
 
-	// Fetch the envelope and body
+	// Fetch the envelope, full message and List-Id header by UID
 	messages := make(chan *imap.Message, len(uids))
-	err := c.Fetch(seqset, []imap.FetchItem{imap.FetchEnvelope, imap.FetchBody}, messages)
+	items := []imap.FetchItem{imap.FetchUid, imap.FetchEnvelope, messageSection.FetchItem(), email.ListIDSection().FetchItem()}
+	err := c.UidFetch(seqset, items, messages)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch messages: %w", err)
 	}
 
-	items := make([]*gofeed.Item, 0, len(uids))
+	fetched := make([]fetchedEmail, 0, len(uids))
 
 	for msg := range messages {
 		if msg == nil {
 			break
 		}
 
+		// Read the routing fields before the body, both are single-use readers
+		message := email.MessageFromIMAP(msg)
+
 		item, err := ef.parseEmailToItem(msg)
 		if err != nil {
 			// Skip invalid emails but continue processing others
@@ -194,11 +241,11 @@ func (ef *EmailFetcher) fetchEmailBatch(c *client.Client, uids []uint32) ([]*gof
 		}
 
 		if item != nil {
-			items = append(items, item)
+			fetched = append(fetched, fetchedEmail{uid: msg.Uid, message: message, item: item})
 		}
 	}
 
-	return items, nil
+	return fetched, nil
 }
 
 // parseEmailToItem converts an IMAP message to a gofeed Item
@@ -237,23 +284,69 @@ func (ef *EmailFetcher) parseEmailToItem(msg *imap.Message) (*gofeed.Item, error
 	return item, nil
 }
 
-// extractEmailBody extracts the text/HTML content from an email message
+// messageSection fetches the whole raw message without setting \Seen
+var messageSection = &imap.BodySectionName{Peek: true}
+
+// extractEmailBody extracts the HTML content from an email message
 func (ef *EmailFetcher) extractEmailBody(msg *imap.Message) (string, error) {
-	// Try to get the body section from the message
-	for _, r := range msg.Body {
-		// r is already an imap.Literal (io.Reader)
-		data, err := io.ReadAll(r)
-		if err != nil {
-			continue
-		}
+	r := msg.GetBody(messageSection)
+	if r == nil {
+		return "", fmt.Errorf("no body content found")
+	}
+	return extractRawEmailBody(r)
+}
+
+// extractRawEmailBody extracts the HTML content from a raw RFC 5322 message
+func extractRawEmailBody(r io.Reader) (string, error) {
+	parsed, err := email.ParseMessage(r)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(parsed.HTML) == "" {
+		return "", fmt.Errorf("no body content found")
+	}
+	return parsed.HTML, nil
+}
 
-		content := string(data)
-		if strings.TrimSpace(content) != "" {
-			return content, nil
+// parseRawEmailToItem converts a raw message received by the built-in SMTP listener to a gofeed Item
+func parseRawEmailToItem(raw []byte) (*gofeed.Item, error) {
+	parsed, err := email.ParseMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	date := parsed.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+	id := parsed.MessageID
+	if id == "" {
+		id = fmt.Sprintf("%x", sha256.Sum256(raw))
+	}
+
+	item := &gofeed.Item{
+		Title:     parsed.Subject,
+		Link:      "email://" + id,
+		GUID:      "email-" + id,
+		Published: date.Format(time.RFC1123),
+	}
+	if parsed.FromAddress != "" {
+		item.Author = &gofeed.Person{Name: parsed.FromName, Email: parsed.FromAddress}
+		// If no subject, use sender's name as title
+		if item.Title == "" && parsed.FromName != "" {
+			item.Title = fmt.Sprintf("Email from %s", parsed.FromName)
+		} else if item.Title == "" {
+			item.Title = fmt.Sprintf("Email from %s", parsed.FromAddress)
 		}
 	}
 
-	return "", fmt.Errorf("no body content found")
+	item.Description = parsed.HTML
+	if strings.TrimSpace(item.Description) == "" {
+		item.Description = "(No content available)"
+	}
+	item.Description = cleanEmailContent(item.Description)
+
+	return item, nil
 }
 
 // cleanEmailContent removes unnecessary elements from email HTML
@@ -0,0 +1,235 @@
+package feed
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils/httputil"
+
+	"github.com/mmcdole/gofeed"
+)
+
+const (
+	// defaultAutoPauseFailures is the default number of consecutive failures pausing a feed
+	defaultAutoPauseFailures = 10
+	// feedBackoffBase is the backoff after the second consecutive failure, doubled for each further one
+	feedBackoffBase = 15 * time.Minute
+	// feedBackoffMax caps the backoff of a failing feed
+	feedBackoffMax = 24 * time.Hour
+)
+
+// Classes of refresh failures
+const (
+	ErrorClassNotFound   = "not_found"   // 404 or 410
+	ErrorClassForbidden  = "forbidden"   // 401 or 403
+	ErrorClassThrottled  = "throttled"   // 429 or 503
+	ErrorClassHTTPClient = "http_client" // Other 4xx
+	ErrorClassHTTPServer = "http_server" // Other 5xx
+	ErrorClassTimeout    = "timeout"
+	ErrorClassDNS        = "dns"
+	ErrorClassTLS        = "tls"
+	ErrorClassNetwork    = "network"
+	ErrorClassParse      = "parse"
+	ErrorClassScript     = "script"
+	ErrorClassOther      = "other"
+)
+
+// classifyFetchError returns the HTTP status of a refresh failure (0 without
+// a response) and its class
+func classifyFetchError(err error) (int, string) {
+	status := 0
+	var statusErr *httputil.StatusError
+	var feedErr gofeed.HTTPError
+	if errors.As(err, &statusErr) {
+		status = statusErr.StatusCode
+	} else if errors.As(err, &feedErr) {
+		status = feedErr.StatusCode
+	}
+
+	switch {
+	case status == http.StatusNotFound || status == http.StatusGone:
+		return status, ErrorClassNotFound
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return status, ErrorClassForbidden
+	case httputil.IsThrottleStatus(status):
+		return status, ErrorClassThrottled
+	case status >= 400 && status < 500:
+		return status, ErrorClassHTTPClient
+	case status >= 500:
+		return status, ErrorClassHTTPServer
+	}
+
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr *x509.UnknownAuthorityError
+	var hostErr x509.HostnameError
+	var scriptErr *ScriptError
+	var xpathErr *XPathError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return 0, ErrorClassTimeout
+	case errors.As(err, &dnsErr):
+		return 0, ErrorClassDNS
+	case errors.As(err, &certErr), errors.As(err, &hostErr), strings.Contains(err.Error(), "tls:"):
+		return 0, ErrorClassTLS
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return 0, ErrorClassTimeout
+	case errors.As(err, &netErr):
+		return 0, ErrorClassNetwork
+	case errors.As(err, &scriptErr):
+		return 0, ErrorClassScript
+	case errors.As(err, &xpathErr) && xpathErr.Operation != "fetch",
+		errors.Is(err, gofeed.ErrFeedTypeNotDetected),
+		strings.Contains(err.Error(), "XML syntax error"):
+		return 0, ErrorClassParse
+	}
+	return 0, ErrorClassOther
+}
+
+// feedRetryBackoff returns how long a feed with the given number of consecutive
+// failures waits before its next scheduled refresh. A single failure waits for
+// the feed's normal interval.
+func feedRetryBackoff(failures int) time.Duration {
+	if failures < 2 {
+		return 0
+	}
+	backoff := feedBackoffBase
+	for i := 2; i < failures && backoff < feedBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > feedBackoffMax {
+		backoff = feedBackoffMax
+	}
+	return backoff
+}
+
+// autoPauseFailures returns the number of consecutive failures pausing a feed, 0 to never pause
+func (f *Fetcher) autoPauseFailures() int {
+	value, err := f.db.GetSetting("feed_auto_pause_failures")
+	if err != nil {
+		return defaultAutoPauseFailures
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return defaultAutoPauseFailures
+	}
+	return n
+}
+
+// recordFeedHealth updates the failure history of a feed after a refresh. A
+// failure extends the feed's backoff and pauses it once it failed too often in
+// a row, a success clears its history. Cancelled refreshes and throttled hosts,
+// which the task manager backs off from, do not count.
+func (f *Fetcher) recordFeedHealth(feed models.Feed, fetchErr error) {
+	if fetchErr == nil {
+		if err := f.db.ClearFeedHealth(feed.ID); err != nil {
+			log.Printf("Failed to clear health of feed %s: %v", feed.Title, err)
+		}
+		return
+	}
+	if errors.Is(fetchErr, context.Canceled) {
+		return
+	}
+	status, class := classifyFetchError(fetchErr)
+	if class == ErrorClassThrottled {
+		return
+	}
+
+	health, err := f.db.GetFeedHealth(feed.ID)
+	if err != nil {
+		log.Printf("Failed to get health of feed %s: %v", feed.Title, err)
+		return
+	}
+	now := time.Now()
+	if health == nil {
+		health = &database.FeedHealth{FeedID: feed.ID, FirstFailureAt: now}
+	}
+	health.ConsecutiveFailures++
+	health.LastFailureAt = now
+	health.StatusCode = status
+	health.ErrorClass = class
+	health.LastError = fetchErr.Error()
+	health.NextRetryAt = time.Time{}
+	if backoff := feedRetryBackoff(health.ConsecutiveFailures); backoff > 0 {
+		health.NextRetryAt = now.Add(backoff)
+	}
+
+	threshold := f.autoPauseFailures()
+	pausing := threshold > 0 && health.ConsecutiveFailures >= threshold && !health.Paused()
+	if pausing {
+		health.PausedAt = now
+	}
+	if err := f.db.SaveFeedHealth(*health); err != nil {
+		log.Printf("Failed to save health of feed %s: %v", feed.Title, err)
+		return
+	}
+
+	if pausing {
+		log.Printf("Pausing feed %s after %d consecutive failures: %v", feed.Title, health.ConsecutiveFailures, fetchErr)
+		message := "Paused after " + strconv.Itoa(health.ConsecutiveFailures) + " consecutive failures: " + fetchErr.Error()
+		f.db.UpdateFeedError(feed.ID, message)
+	}
+}
+
+// SkipUnhealthyFeeds returns the feeds due for a scheduled refresh, leaving out
+// paused feeds and failing feeds still in backoff
+func (f *Fetcher) SkipUnhealthyFeeds(feeds []models.Feed) []models.Feed {
+	healths, err := f.db.GetFeedHealthMap()
+	if err != nil {
+		log.Printf("Failed to get feed health, refreshing all feeds: %v", err)
+		return feeds
+	}
+	if len(healths) == 0 {
+		return feeds
+	}
+
+	now := time.Now()
+	due := make([]models.Feed, 0, len(feeds))
+	paused, waiting := 0, 0
+	for _, feed := range feeds {
+		health, failing := healths[feed.ID]
+		switch {
+		case failing && health.Paused():
+			paused++
+		case failing && now.Before(health.NextRetryAt):
+			waiting++
+		default:
+			due = append(due, feed)
+		}
+	}
+	if paused > 0 || waiting > 0 {
+		log.Printf("Skipping %d paused feeds and %d failing feeds in backoff", paused, waiting)
+	}
+	return due
+}
+
+// RetryFeed lifts the pause and backoff of a failing feed and refreshes it right away
+func (f *Fetcher) RetryFeed(ctx context.Context, feed models.Feed) error {
+	if _, err := f.db.ResumeFeed(feed.ID); err != nil {
+		return err
+	}
+	f.taskManager.AddToQueueHead(ctx, feed, TaskReasonManualRefresh)
+	return nil
+}
+
+// updateMovedFeedURL stores the new URL of a feed that permanently moved
+func (f *Fetcher) updateMovedFeedURL(feed *models.Feed, movedTo string) {
+	if feed.ID == 0 || movedTo == feed.URL {
+		return
+	}
+	if err := f.db.UpdateFeedURL(feed.ID, movedTo); err != nil {
+		log.Printf("Feed %s moved permanently to %s, keeping the old URL: %v", feed.Title, movedTo, err)
+		return
+	}
+	log.Printf("Feed %s moved permanently from %s to %s, URL updated", feed.Title, feed.URL, movedTo)
+	feed.URL = movedTo
+}
@@ -0,0 +1,166 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils/httputil"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func TestClassifyFetchError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		class  string
+	}{
+		{"gone", httputil.NewStatusError(&http.Response{StatusCode: 410, Status: "410 Gone"}), 410, ErrorClassNotFound},
+		{"forbidden", fmt.Errorf("fetch: %w", gofeed.HTTPError{StatusCode: 403, Status: "403 Forbidden"}), 403, ErrorClassForbidden},
+		{"throttled", gofeed.HTTPError{StatusCode: 429}, 429, ErrorClassThrottled},
+		{"client", gofeed.HTTPError{StatusCode: 400}, 400, ErrorClassHTTPClient},
+		{"server", gofeed.HTTPError{StatusCode: 502}, 502, ErrorClassHTTPServer},
+		{"deadline", fmt.Errorf("fetch: %w", context.DeadlineExceeded), 0, ErrorClassTimeout},
+		{"dns", &net.DNSError{Err: "no such host", Name: "nowhere.invalid"}, 0, ErrorClassDNS},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, 0, ErrorClassNetwork},
+		{"parse", gofeed.ErrFeedTypeNotDetected, 0, ErrorClassParse},
+		{"script", &ScriptError{Message: "exit status 1"}, 0, ErrorClassScript},
+		{"other", errors.New("something else"), 0, ErrorClassOther},
+	}
+	for _, tt := range tests {
+		status, class := classifyFetchError(tt.err)
+		if status != tt.status || class != tt.class {
+			t.Errorf("%s: classifyFetchError = (%d, %q), want (%d, %q)", tt.name, status, class, tt.status, tt.class)
+		}
+	}
+}
+
+func TestFeedRetryBackoff(t *testing.T) {
+	for failures, want := range map[int]time.Duration{
+		1:  0,
+		2:  feedBackoffBase,
+		3:  2 * feedBackoffBase,
+		5:  8 * feedBackoffBase,
+		20: feedBackoffMax,
+	} {
+		if got := feedRetryBackoff(failures); got != want {
+			t.Errorf("feedRetryBackoff(%d) = %v, want %v", failures, got, want)
+		}
+	}
+}
+
+func TestRecordFeedHealth_AutoPause(t *testing.T) {
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db.Init: %v", err)
+	}
+	db.SetSetting("feed_auto_pause_failures", "3")
+
+	var feeds []models.Feed
+	for _, url := range []string{"https://a.example/feed", "https://b.example/feed"} {
+		feed := models.Feed{Title: url, URL: url}
+		if feed.ID, err = db.AddFeed(&feed); err != nil {
+			t.Fatalf("AddFeed: %v", err)
+		}
+		feeds = append(feeds, feed)
+	}
+
+	f := NewFetcher(db)
+	notFound := httputil.NewStatusError(&http.Response{StatusCode: 404, Status: "404 Not Found"})
+
+	// A single failure keeps the feed on its normal schedule
+	f.recordFeedHealth(feeds[0], notFound)
+	if due := f.SkipUnhealthyFeeds(feeds); len(due) != 2 {
+		t.Errorf("Feed should be refreshed after its first failure, due = %d", len(due))
+	}
+
+	// Throttled hosts are backed off by the task manager and do not count
+	f.recordFeedHealth(feeds[0], gofeed.HTTPError{StatusCode: 429})
+	health, _ := db.GetFeedHealth(feeds[0].ID)
+	if health == nil || health.ConsecutiveFailures != 1 || health.StatusCode != 404 || health.ErrorClass != ErrorClassNotFound {
+		t.Fatalf("Unexpected health after one failure: %+v", health)
+	}
+
+	f.recordFeedHealth(feeds[0], notFound)
+	health, _ = db.GetFeedHealth(feeds[0].ID)
+	if health.Paused() || !health.NextRetryAt.After(time.Now().Add(feedBackoffBase-time.Minute)) {
+		t.Errorf("Second failure should back off without pausing: %+v", health)
+	}
+	if due := f.SkipUnhealthyFeeds(feeds); len(due) != 1 || due[0].ID != feeds[1].ID {
+		t.Errorf("Feed in backoff should be skipped, due = %+v", due)
+	}
+
+	f.recordFeedHealth(feeds[0], notFound)
+	health, _ = db.GetFeedHealth(feeds[0].ID)
+	if !health.Paused() || health.ConsecutiveFailures != 3 {
+		t.Fatalf("Third failure should pause the feed: %+v", health)
+	}
+	if feed, _ := db.GetFeedByID(feeds[0].ID); !strings.HasPrefix(feed.LastError, "Paused after 3 consecutive failures") {
+		t.Errorf("Paused feed error = %q", feed.LastError)
+	}
+
+	// Resuming lifts the pause, a success clears the history
+	if _, err := db.ResumeFeed(feeds[0].ID); err != nil {
+		t.Fatalf("ResumeFeed: %v", err)
+	}
+	if due := f.SkipUnhealthyFeeds(feeds); len(due) != 2 {
+		t.Errorf("Resumed feed should be refreshed, due = %d", len(due))
+	}
+	f.recordFeedHealth(feeds[0], nil)
+	if health, _ := db.GetFeedHealth(feeds[0].ID); health != nil {
+		t.Errorf("Success should clear the health, got %+v", health)
+	}
+}
+
+func TestFetchFeed_PermanentRedirect(t *testing.T) {
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db.Init: %v", err)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+		case "/temporary":
+			http.Redirect(w, r, "/new", http.StatusFound)
+		case "/new":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			fmt.Fprint(w, `<?xml version="1.0"?><rss><channel><title>t</title><item><title>a</title><link>http://x/a</link><guid>a</guid></item></channel></rss>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	f := NewFetcher(db)
+	for path, want := range map[string]string{"/old": "/new", "/temporary": "/temporary"} {
+		feed := models.Feed{Title: path, URL: srv.URL + path}
+		if feed.ID, err = db.AddFeed(&feed); err != nil {
+			t.Fatalf("AddFeed: %v", err)
+		}
+		if err := f.fetchFeedWithContext(context.Background(), feed); err != nil {
+			t.Fatalf("fetchFeedWithContext(%s): %v", path, err)
+		}
+		stored, _ := db.GetFeedByID(feed.ID)
+		if stored.URL != srv.URL+want {
+			t.Errorf("URL of %s after refresh = %s, want %s", path, stored.URL, srv.URL+want)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"MrRSS/internal/database"
+	"MrRSS/internal/fulltext"
 	"MrRSS/internal/models"
 	"MrRSS/internal/rsshub"
 	"MrRSS/internal/rules"
@@ -37,6 +38,9 @@ type Fetcher struct {
 	refreshCalculator *IntelligentRefreshCalculator
 	taskManager       *TaskManager
 	cleanupManager    *CleanupManager
+	fullTextOnce      sync.Once
+	fullText          *fulltext.Extractor // Full-text extraction during refresh, see fullTextExtractor
+	fullTextSem       chan struct{}       // Bounds the extractions of cacheFullTexts
 }
 
 func NewFetcher(db *database.DB) *Fetcher {
@@ -194,12 +198,30 @@ func (f *Fetcher) getHTTPClient(feed models.Feed) (*http.Client, error) {
 }
 
 func (f *Fetcher) FetchAll(ctx context.Context) {
+	f.fetchAll(ctx, false)
+}
+
+// FetchAllDue is FetchAll for the scheduled global refresh. It leaves out feeds
+// refreshed successfully since the last half global interval and feeds their
+// refresh rules do not allow refreshing now.
+func (f *Fetcher) FetchAllDue(ctx context.Context) {
+	f.fetchAll(ctx, true)
+}
+
+func (f *Fetcher) fetchAll(ctx context.Context, dueOnly bool) {
 	// Get all feeds
 	feeds, err := f.db.GetFeeds()
 	if err != nil {
 		log.Println("Error getting feeds:", err)
 		return
 	}
+	f.ApplyScriptSchedules(feeds)
+	feeds = f.SkipUnhealthyFeeds(feeds)
+	if dueOnly {
+		now := time.Now()
+		feeds = f.skipRecentlyRefreshed(feeds, now)
+		feeds = filterByRules(feeds, f.LoadRefreshRules(), now)
+	}
 
 	if len(feeds) == 0 {
 		log.Println("No feeds to refresh")
@@ -331,8 +353,12 @@ func (f *Fetcher) FetchFeed(ctx context.Context, feed models.Feed) {
 		if err := f.db.SaveArticles(ctx, articlesToSave); err != nil {
 			log.Printf("Error saving articles for feed %s: %v", feed.Title, err)
 		} else {
-			// Cache article content from RSS feed
-			f.cacheArticleContents(articlesWithContent)
+			// Cache the full text of new articles, or the article content from the RSS feed
+			if feed.FullTextOnIngest {
+				f.cacheFullTexts(ctx, feed, articlesWithContent)
+			} else {
+				f.cacheArticleContents(articlesWithContent)
+			}
 
 			// Apply rules to newly saved articles
 			// We fetch the recent articles for this feed since SaveArticles doesn't return IDs
@@ -398,6 +424,23 @@ func (f *Fetcher) fetchFeedWithContext(ctx context.Context, feed models.Feed) er
 	default:
 	}
 
+	if err := f.saveArticles(ctx, feed, articlesWithContent); err != nil {
+		return err
+	}
+
+	// Learn when the feed posts, to plan its next intelligent refresh
+	published := make([]time.Time, 0, len(articlesWithContent))
+	for _, awc := range articlesWithContent {
+		if awc.Article.HasValidPublishedTime {
+			published = append(published, awc.Article.PublishedAt)
+		}
+	}
+	f.refreshCalculator.RecordFetch(feed, published, time.Now())
+	return nil
+}
+
+// saveArticles stores processed articles, then caches their content and applies rules
+func (f *Fetcher) saveArticles(ctx context.Context, feed models.Feed, articlesWithContent []*ArticleWithContent) error {
 	if len(articlesWithContent) > 0 {
 		// Extract just the articles for saving
 		articlesToSave := make([]*models.Article, len(articlesWithContent))
@@ -412,9 +455,15 @@ func (f *Fetcher) fetchFeedWithContext(ctx context.Context, feed models.Feed) er
 		// Post-processing operations (content caching and rule application)
 		// These are non-critical and run asynchronously to avoid blocking the feed refresh
 		// Even if they fail or are slow, the feed has already been successfully saved
+		// The fetch attempt ends before this work does, it stops with the refresh instead
+		refreshCtx := refreshContext(ctx)
 		go func() {
-			// Cache article content from RSS feed
-			f.cacheArticleContents(articlesWithContent)
+			// Cache the full text of new articles, or the article content from the RSS feed
+			if feed.FullTextOnIngest {
+				f.cacheFullTexts(refreshCtx, feed, articlesWithContent)
+			} else {
+				f.cacheArticleContents(articlesWithContent)
+			}
 
 			// Apply rules to newly saved articles
 			savedArticles, err := f.db.GetArticles("", feed.ID, "", false, len(articlesToSave), 0)
@@ -438,6 +487,34 @@ func (f *Fetcher) fetchFeedWithContext(ctx context.Context, feed models.Feed) er
 	return nil
 }
 
+// saveRoutedEmails stores newsletters that a mailbox's routing rules sent to another feed
+func (f *Fetcher) saveRoutedEmails(ctx context.Context, feedID int64, items []*gofeed.Item) {
+	feed, err := f.db.GetFeedByID(feedID)
+	if err != nil {
+		log.Printf("Dropping %d routed newsletters, feed %d not found: %v", len(items), feedID, err)
+		return
+	}
+	if err := f.saveArticles(ctx, *feed, f.processArticles(*feed, items)); err != nil {
+		log.Printf("Error saving routed newsletters for feed %s: %v", feed.Title, err)
+	}
+}
+
+// DeliverEmail stores a newsletter received by the built-in SMTP/LMTP listener in its feed
+func (f *Fetcher) DeliverEmail(ctx context.Context, feedID int64, raw []byte) error {
+	feed, err := f.db.GetFeedByID(feedID)
+	if err != nil {
+		return err
+	}
+	item, err := parseRawEmailToItem(raw)
+	if err != nil {
+		return err
+	}
+	if err := f.saveArticles(ctx, *feed, f.processArticles(*feed, []*gofeed.Item{item})); err != nil {
+		return err
+	}
+	return f.db.UpdateFeedLastUpdated(feed.ID)
+}
+
 // FetchSingleFeed fetches a single feed with progress tracking.
 // This is used when adding a new feed, refreshing a single feed from the context menu,
 // or when the scheduler triggers individual feed refreshes.
@@ -453,6 +530,12 @@ func (f *Fetcher) FetchSingleFeed(ctx context.Context, feed models.Feed, isManua
 	}
 }
 
+// FetchNewMail queues a newsletter feed after IMAP IDLE reported new mail.
+// It goes to the queue head so new mail shows up within seconds.
+func (f *Fetcher) FetchNewMail(ctx context.Context, feed models.Feed) {
+	f.taskManager.AddToQueueHead(ctx, feed, TaskReasonNewMail)
+}
+
 // FetchFeedForArticle fetches a feed immediately when article content is missing.
 // This bypasses the queue and pool limits.
 func (f *Fetcher) FetchFeedForArticle(ctx context.Context, feed models.Feed) {
@@ -0,0 +1,103 @@
+package feed
+
+import (
+	"context"
+	"time"
+
+	"MrRSS/internal/fulltext"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+)
+
+const (
+	// fullTextHostInterval spaces the full-text requests a refresh sends to the same site
+	fullTextHostInterval = 2 * time.Second
+	// fullTextTimeout limits extracting one article, including its next pages
+	fullTextTimeout = 2 * time.Minute
+	// fullTextIngestConcurrency limits how many articles are extracted at once across all feeds
+	fullTextIngestConcurrency = 4
+)
+
+type refreshContextKey struct{}
+
+// withRefreshContext records the refresh a fetch attempt belongs to. Work that
+// outlives the attempt, like caching full texts, stops with the refresh instead.
+func withRefreshContext(attempt, refresh context.Context) context.Context {
+	return context.WithValue(attempt, refreshContextKey{}, refresh)
+}
+
+// refreshContext returns the refresh ctx belongs to, or ctx itself outside a fetch attempt
+func refreshContext(ctx context.Context) context.Context {
+	if refresh, ok := ctx.Value(refreshContextKey{}).(context.Context); ok {
+		return refresh
+	}
+	return ctx
+}
+
+// fullTextExtractor returns the extractor used during refresh, sending its
+// requests through the feed's client. All feeds share its per-host spacing.
+func (f *Fetcher) fullTextExtractor(feed models.Feed) *fulltext.Extractor {
+	f.fullTextOnce.Do(func() {
+		var rules fulltext.RuleSource
+		if f.db != nil {
+			rules = f.db
+		}
+		f.fullText = fulltext.NewExtractor(rules, nil, fullTextHostInterval)
+		f.fullTextSem = make(chan struct{}, fullTextIngestConcurrency)
+	})
+
+	client, err := f.getHTTPClient(feed)
+	if err != nil {
+		utils.DebugLog("fullTextExtractor: Using the default client for feed %s: %v", feed.Title, err)
+		return f.fullText
+	}
+	return f.fullText.WithClient(client)
+}
+
+// cacheFullTexts stores the full text of articles whose content isn't cached
+// yet, so articles are only extracted once. When extraction fails the feed's
+// own content is cached instead. It stops when ctx is cancelled.
+func (f *Fetcher) cacheFullTexts(ctx context.Context, feed models.Feed, articlesWithContent []*ArticleWithContent) {
+	extractor := f.fullTextExtractor(feed)
+	for _, awc := range articlesWithContent {
+		if ctx.Err() != nil {
+			return
+		}
+		if awc.Article.URL == "" {
+			continue
+		}
+		articleID, err := f.db.GetArticleIDByUniqueID(awc.Article.Title, awc.Article.FeedID, awc.Article.PublishedAt, awc.Article.HasValidPublishedTime)
+		if err != nil {
+			utils.DebugLog("Could not find article ID for %s: %v", awc.Article.Title, err)
+			continue
+		}
+		if _, cached, err := f.db.GetArticleContent(articleID); err != nil || cached {
+			continue
+		}
+
+		select {
+		case f.fullTextSem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		content := awc.Content
+		extractCtx, cancel := context.WithTimeout(ctx, fullTextTimeout)
+		result, err := extractor.Extract(extractCtx, awc.Article.URL)
+		cancel()
+		<-f.fullTextSem
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			utils.DebugLog("cacheFullTexts: Keeping feed content of %s: %v", awc.Article.URL, err)
+		} else if result.Content != "" {
+			content = result.Content
+		}
+		if content == "" {
+			continue
+		}
+		if err := f.db.SetArticleContent(articleID, content); err != nil {
+			utils.DebugLog("cacheFullTexts: Failed to cache content for article %d: %v", articleID, err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func TestCacheFullTexts(t *testing.T) {
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("Failed to init db: %v", err)
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `<html><body><div class="menu">Menu</div><div class="body">Full story</div></body></html>`)
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	if _, err := db.SaveSiteRule(&models.SiteRule{Host: host, ContentSelector: "div.body"}); err != nil {
+		t.Fatalf("SaveSiteRule failed: %v", err)
+	}
+	feed := models.Feed{Title: "Site", URL: server.URL + "/feed", FullTextOnIngest: true}
+	feedID, err := db.AddFeed(&feed)
+	if err != nil {
+		t.Fatalf("AddFeed failed: %v", err)
+	}
+	feed.ID = feedID
+
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	articles := []*ArticleWithContent{
+		{Article: &models.Article{FeedID: feedID, Title: "Story", URL: server.URL + "/story", PublishedAt: published, HasValidPublishedTime: true}, Content: "Summary"},
+		{Article: &models.Article{FeedID: feedID, Title: "Gone", URL: server.URL + "/missing", PublishedAt: published, HasValidPublishedTime: true}, Content: "Feed content"},
+	}
+	toSave := []*models.Article{articles[0].Article, articles[1].Article}
+	if err := db.SaveArticles(context.Background(), toSave); err != nil {
+		t.Fatalf("SaveArticles failed: %v", err)
+	}
+
+	fetcher := &Fetcher{db: db}
+	fetcher.cacheFullTexts(context.Background(), feed, articles)
+
+	for title, want := range map[string]string{"Story": `<div class="body">Full story</div>`, "Gone": "Feed content"} {
+		id, err := db.GetArticleIDByUniqueID(title, feedID, published, true)
+		if err != nil {
+			t.Fatalf("GetArticleIDByUniqueID(%q) failed: %v", title, err)
+		}
+		content, found, err := db.GetArticleContent(id)
+		if err != nil || !found || content != want {
+			t.Errorf("Cached content of %q = %q (found %v, err %v), want %q", title, content, found, err, want)
+		}
+	}
+
+	// Cached articles are not extracted again on the next refresh
+	requests = 0
+	fetcher.cacheFullTexts(context.Background(), feed, articles)
+	if requests != 0 {
+		t.Errorf("Second refresh sent %d requests, want none", requests)
+	}
+}
+
+func TestCacheFullTextsStopsWithRefresh(t *testing.T) {
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("Failed to create db: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("Failed to init db: %v", err)
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `<html><body><p>Full story</p></body></html>`)
+	}))
+	defer server.Close()
+
+	feed := models.Feed{Title: "Site", URL: server.URL + "/feed", FullTextOnIngest: true}
+	feedID, err := db.AddFeed(&feed)
+	if err != nil {
+		t.Fatalf("AddFeed failed: %v", err)
+	}
+	feed.ID = feedID
+
+	published := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	article := &models.Article{FeedID: feedID, Title: "Story", URL: server.URL + "/story", PublishedAt: published, HasValidPublishedTime: true}
+	if err := db.SaveArticles(context.Background(), []*models.Article{article}); err != nil {
+		t.Fatalf("SaveArticles failed: %v", err)
+	}
+
+	// The fetch attempt has already ended, only the refresh it belongs to counts
+	refresh, cancelRefresh := context.WithCancel(context.Background())
+	attempt, cancelAttempt := context.WithCancel(refresh)
+	cancelAttempt()
+	if got := refreshContext(withRefreshContext(attempt, refresh)); got.Err() != nil {
+		t.Fatalf("refreshContext returned a cancelled context: %v", got.Err())
+	}
+
+	cancelRefresh()
+	fetcher := &Fetcher{db: db}
+	fetcher.cacheFullTexts(refreshContext(withRefreshContext(attempt, refresh)), feed, []*ArticleWithContent{{Article: article, Content: "Summary"}})
+
+	if requests != 0 {
+		t.Errorf("Cancelled refresh sent %d requests, want none", requests)
+	}
+	id, err := db.GetArticleIDByUniqueID("Story", feedID, published, true)
+	if err != nil {
+		t.Fatalf("GetArticleIDByUniqueID failed: %v", err)
+	}
+	if _, found, err := db.GetArticleContent(id); err != nil || found {
+		t.Errorf("Cancelled refresh cached content (found %v, err %v)", found, err)
+	}
+}
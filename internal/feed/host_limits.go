@@ -0,0 +1,282 @@
+package feed
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"MrRSS/internal/models"
+	"MrRSS/internal/rsshub"
+	"MrRSS/internal/utils/httputil"
+
+	"github.com/mmcdole/gofeed"
+)
+
+const (
+	// defaultMaxPerHost is the default number of feeds of one host refreshed at once
+	defaultMaxPerHost = 2
+	// defaultHostInterval is the default minimum time between requests to one host
+	defaultHostInterval = time.Second
+	// hostBackoffBase is the backoff of a throttled host without a Retry-After header,
+	// doubled for every further throttled response
+	hostBackoffBase = time.Minute
+	// hostBackoffMax caps the doubled backoff
+	hostBackoffMax = 30 * time.Minute
+	// maxRetryAfter caps the backoff asked for by a Retry-After header
+	maxRetryAfter = 24 * time.Hour
+)
+
+// hostLimits enforces per-host concurrency and request spacing for feed
+// refreshes, and keeps throttled hosts in backoff
+type hostLimits struct {
+	mu         sync.Mutex
+	maxPerHost int
+	interval   time.Duration
+	hosts      map[string]*hostState
+}
+
+// hostState is the refresh state of one host
+type hostState struct {
+	active       int       // Refreshes in progress
+	lastStart    time.Time // Start of the last refresh
+	backoffUntil time.Time // No refreshes before this time
+	throttles    int       // Throttled responses since the last success
+	lastStatus   int       // Status code of the last throttled response
+}
+
+// HostInfo describes the refresh state of a host
+type HostInfo struct {
+	Host         string    `json:"host"`
+	Active       int       `json:"active"`
+	Queued       int       `json:"queued"`
+	BackoffUntil time.Time `json:"backoff_until"` // Zero when not backing off
+	Throttles    int       `json:"throttles"`
+	LastStatus   int       `json:"last_status"`
+}
+
+func newHostLimits() *hostLimits {
+	return &hostLimits{
+		maxPerHost: defaultMaxPerHost,
+		interval:   defaultHostInterval,
+		hosts:      make(map[string]*hostState),
+	}
+}
+
+// configure sets the per-host concurrency cap and request spacing
+func (l *hostLimits) configure(maxPerHost int, interval time.Duration) {
+	if maxPerHost < 1 {
+		maxPerHost = defaultMaxPerHost
+	}
+	if interval < 0 {
+		interval = 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxPerHost = maxPerHost
+	l.interval = interval
+}
+
+// tryAcquire starts a refresh on host when its limits allow it. Otherwise it
+// returns how long until spacing allows one, 0 when the host is at its cap.
+// Feeds without a host are never limited.
+func (l *hostLimits) tryAcquire(host string, now time.Time) (bool, time.Duration) {
+	if host == "" {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := l.hosts[host]
+	if state == nil {
+		state = &hostState{}
+		l.hosts[host] = state
+	}
+	if now.Before(state.backoffUntil) {
+		return false, state.backoffUntil.Sub(now)
+	}
+	if state.active >= l.maxPerHost {
+		return false, 0
+	}
+	if next := state.lastStart.Add(l.interval); now.Before(next) {
+		return false, next.Sub(now)
+	}
+
+	state.active++
+	state.lastStart = now
+	return true, 0
+}
+
+// release ends a refresh started by tryAcquire
+func (l *hostLimits) release(host string) {
+	if host == "" {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if state := l.hosts[host]; state != nil && state.active > 0 {
+		state.active--
+	}
+	l.prune(time.Now())
+}
+
+// backoffUntil returns the end of a host's backoff, zero when it is not backing off
+func (l *hostLimits) backoffUntil(host string, now time.Time) time.Time {
+	if host == "" {
+		return time.Time{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if state := l.hosts[host]; state != nil && now.Before(state.backoffUntil) {
+		return state.backoffUntil
+	}
+	return time.Time{}
+}
+
+// throttled puts a host into backoff after a 429 or 503 response, for the
+// Retry-After delay when the server sent one and exponentially longer otherwise.
+// It returns the end of the backoff.
+func (l *hostLimits) throttled(host string, status int, retryAfter time.Duration, now time.Time) time.Time {
+	if host == "" {
+		return time.Time{}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := l.hosts[host]
+	if state == nil {
+		state = &hostState{}
+		l.hosts[host] = state
+	}
+	state.throttles++
+	state.lastStatus = status
+
+	delay := retryAfter
+	if delay <= 0 {
+		delay = hostBackoffBase
+		for i := 1; i < state.throttles && delay < hostBackoffMax; i++ {
+			delay *= 2
+		}
+		if delay > hostBackoffMax {
+			delay = hostBackoffMax
+		}
+	}
+	if delay > maxRetryAfter {
+		delay = maxRetryAfter
+	}
+	if until := now.Add(delay); until.After(state.backoffUntil) {
+		state.backoffUntil = until
+	}
+	return state.backoffUntil
+}
+
+// succeeded resets a host's backoff after a successful refresh
+func (l *hostLimits) succeeded(host string) {
+	if host == "" {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if state := l.hosts[host]; state != nil {
+		state.throttles = 0
+		state.lastStatus = 0
+	}
+}
+
+// snapshot returns the state of the hosts with refreshes in progress or in backoff
+func (l *hostLimits) snapshot(now time.Time) []HostInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.prune(now)
+	hosts := make([]HostInfo, 0, len(l.hosts))
+	for host, state := range l.hosts {
+		info := HostInfo{
+			Host:       host,
+			Active:     state.active,
+			Throttles:  state.throttles,
+			LastStatus: state.lastStatus,
+		}
+		if now.Before(state.backoffUntil) {
+			info.BackoffUntil = state.backoffUntil
+		}
+		hosts = append(hosts, info)
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Host < hosts[j].Host })
+	return hosts
+}
+
+// prune forgets idle hosts that no longer limit anything. Must be called with l.mu held.
+func (l *hostLimits) prune(now time.Time) {
+	for host, state := range l.hosts {
+		if state.active == 0 && state.throttles == 0 && !now.Before(state.backoffUntil) &&
+			!now.Before(state.lastStart.Add(l.interval)) {
+			delete(l.hosts, host)
+		}
+	}
+}
+
+// throttleOf reports whether a fetch error is a 429 or 503 response, with its
+// status code and the delay asked for by its Retry-After header
+func throttleOf(err error) (int, time.Duration, bool) {
+	var statusErr *httputil.StatusError
+	if errors.As(err, &statusErr) && statusErr.IsThrottled() {
+		return statusErr.StatusCode, statusErr.RetryAfter, true
+	}
+	// gofeed drops the response headers, so there is no Retry-After to honour
+	var feedErr gofeed.HTTPError
+	if errors.As(err, &feedErr) && httputil.IsThrottleStatus(feedErr.StatusCode) {
+		return feedErr.StatusCode, 0, true
+	}
+	return 0, 0, false
+}
+
+// feedHost returns the host (with its port, if any) a feed refresh sends its
+// requests to, empty for feeds that are not fetched over HTTP (scripts, newsletters)
+func (f *Fetcher) feedHost(feed models.Feed) string {
+	if feed.ScriptPath != "" || feed.Type == "email" {
+		return ""
+	}
+	feedURL := feed.URL
+	if rsshub.IsRSSHubURL(feedURL) {
+		transformed, err := f.transformRSSHubURL(feedURL)
+		if err != nil {
+			return ""
+		}
+		feedURL = transformed
+	}
+	u, err := url.Parse(feedURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
+// loadHostLimits reads the per-host limits from settings
+func (f *Fetcher) loadHostLimits(limits *hostLimits) {
+	maxPerHost := defaultMaxPerHost
+	if value, err := f.db.GetSetting("max_refreshes_per_host"); err == nil {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			maxPerHost = n
+		}
+	}
+	interval := defaultHostInterval
+	if value, err := f.db.GetSetting("host_request_interval_ms"); err == nil {
+		if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+			interval = time.Duration(ms) * time.Millisecond
+		}
+	}
+	limits.configure(maxPerHost, interval)
+}
@@ -0,0 +1,218 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils/httputil"
+
+	"github.com/mmcdole/gofeed"
+)
+
+func TestHostLimits_ConcurrencyAndSpacing(t *testing.T) {
+	limits := newHostLimits()
+	limits.configure(2, time.Second)
+	now := time.Now()
+
+	if ok, _ := limits.tryAcquire("a.example", now); !ok {
+		t.Fatal("First request to a host should start")
+	}
+	ok, wait := limits.tryAcquire("a.example", now.Add(100*time.Millisecond))
+	if ok || wait != 900*time.Millisecond {
+		t.Errorf("Request within the interval = (%v, %v), want (false, 900ms)", ok, wait)
+	}
+	if ok, _ := limits.tryAcquire("b.example", now); !ok {
+		t.Error("Other hosts should not be limited")
+	}
+	if ok, _ := limits.tryAcquire("a.example", now.Add(time.Second)); !ok {
+		t.Fatal("Request after the interval should start")
+	}
+	ok, wait = limits.tryAcquire("a.example", now.Add(5*time.Second))
+	if ok || wait != 0 {
+		t.Errorf("Request over the cap = (%v, %v), want (false, 0)", ok, wait)
+	}
+
+	limits.release("a.example")
+	if ok, _ := limits.tryAcquire("a.example", now.Add(5*time.Second)); !ok {
+		t.Error("Request after a release should start")
+	}
+	if ok, _ := limits.tryAcquire("", now); !ok {
+		t.Error("Feeds without a host should never be limited")
+	}
+}
+
+func TestHostLimits_Backoff(t *testing.T) {
+	limits := newHostLimits()
+	limits.configure(2, 0)
+	now := time.Now()
+
+	until := limits.throttled("a.example", http.StatusTooManyRequests, 90*time.Second, now)
+	if !until.Equal(now.Add(90 * time.Second)) {
+		t.Errorf("Backoff with Retry-After ends at %v, want %v", until, now.Add(90*time.Second))
+	}
+	if ok, wait := limits.tryAcquire("a.example", now.Add(time.Second)); ok || wait != 89*time.Second {
+		t.Errorf("Request during backoff = (%v, %v), want (false, 89s)", ok, wait)
+	}
+	if got := limits.backoffUntil("a.example", now); !got.Equal(until) {
+		t.Errorf("backoffUntil = %v, want %v", got, until)
+	}
+
+	// Without Retry-After the backoff doubles with each throttled response
+	later := now.Add(time.Hour)
+	if got := limits.throttled("a.example", http.StatusServiceUnavailable, 0, later); !got.Equal(later.Add(2 * hostBackoffBase)) {
+		t.Errorf("Second backoff ends at %v, want %v", got, later.Add(2*hostBackoffBase))
+	}
+	for i := 0; i < 10; i++ {
+		limits.throttled("a.example", http.StatusServiceUnavailable, 0, later)
+	}
+	if got := limits.backoffUntil("a.example", later); !got.Equal(later.Add(hostBackoffMax)) {
+		t.Errorf("Backoff is capped at %v, got %v", later.Add(hostBackoffMax), got)
+	}
+	if got := limits.throttled("b.example", http.StatusTooManyRequests, 48*time.Hour, now); !got.Equal(now.Add(maxRetryAfter)) {
+		t.Errorf("Retry-After is capped at %v, got %v", now.Add(maxRetryAfter), got)
+	}
+
+	hosts := limits.snapshot(later)
+	if len(hosts) != 2 || hosts[0].Host != "a.example" || hosts[0].LastStatus != http.StatusServiceUnavailable || hosts[0].Throttles != 12 {
+		t.Fatalf("Unexpected snapshot: %+v", hosts)
+	}
+
+	limits.succeeded("a.example")
+	hosts = limits.snapshot(later.Add(hostBackoffMax))
+	if len(hosts) != 1 || hosts[0].Host != "b.example" {
+		t.Errorf("Recovered hosts should be forgotten, got %+v", hosts)
+	}
+}
+
+func TestThrottleOf(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "120")
+	statusErr := httputil.NewStatusError(&http.Response{StatusCode: 429, Status: "429 Too Many Requests", Header: header})
+
+	at := time.Now().Add(time.Hour).UTC()
+	header = http.Header{}
+	header.Set("Retry-After", at.Format(http.TimeFormat))
+	dateErr := httputil.NewStatusError(&http.Response{StatusCode: 503, Status: "503 Service Unavailable", Header: header})
+
+	tests := []struct {
+		name       string
+		err        error
+		throttled  bool
+		status     int
+		retryAfter time.Duration
+	}{
+		{"seconds", fmt.Errorf("fetch: %w", statusErr), true, 429, 2 * time.Minute},
+		{"gofeed", gofeed.HTTPError{StatusCode: 503, Status: "503 Service Unavailable"}, true, 503, 0},
+		{"not found", httputil.NewStatusError(&http.Response{StatusCode: 404, Status: "404 Not Found"}), false, 0, 0},
+		{"other", fmt.Errorf("connection refused"), false, 0, 0},
+	}
+	for _, tt := range tests {
+		status, retryAfter, throttled := throttleOf(tt.err)
+		if throttled != tt.throttled || status != tt.status || retryAfter != tt.retryAfter {
+			t.Errorf("%s: throttleOf = (%d, %v, %v), want (%d, %v, %v)", tt.name, status, retryAfter, throttled, tt.status, tt.retryAfter, tt.throttled)
+		}
+	}
+
+	// HTTP dates are converted to the remaining delay
+	if _, retryAfter, _ := throttleOf(dateErr); retryAfter < 59*time.Minute || retryAfter > time.Hour {
+		t.Errorf("Retry-After date gave %v, want about an hour", retryAfter)
+	}
+}
+
+func TestTaskManager_PerHostLimits(t *testing.T) {
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db.Init: %v", err)
+	}
+	db.SetSetting("max_refreshes_per_host", "1")
+	db.SetSetting("host_request_interval_ms", "50")
+
+	var mu sync.Mutex
+	active, peak := 0, 0
+	requests := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		if r.URL.Path == "/limited" {
+			mu.Unlock()
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		active++
+		if active > peak {
+			peak = active
+		}
+		mu.Unlock()
+
+		time.Sleep(100 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprintf(w, `<?xml version="1.0"?><rss><channel><title>t</title><item><title>%s</title><link>http://x%s</link><guid>%s</guid></item></channel></rss>`, r.URL.Path, r.URL.Path, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	var feeds []models.Feed
+	for _, path := range []string{"/a", "/b", "/c"} {
+		feed := models.Feed{Title: path, URL: srv.URL + path}
+		id, err := db.AddFeed(&feed)
+		if err != nil {
+			t.Fatalf("AddFeed: %v", err)
+		}
+		feed.ID = id
+		feeds = append(feeds, feed)
+	}
+
+	f := NewFetcher(db)
+	tm := f.GetTaskManager()
+	tm.AddGlobalRefresh(context.Background(), feeds)
+	if !tm.Wait(10 * time.Second) {
+		t.Fatal("Refresh did not finish in time")
+	}
+	mu.Lock()
+	if peak != 1 {
+		t.Errorf("Peak concurrent requests to one host = %d, want 1", peak)
+	}
+	mu.Unlock()
+
+	// A 429 puts the host into backoff, and queued feeds of the host are skipped
+	limited := models.Feed{Title: "limited", URL: srv.URL + "/limited"}
+	if limited.ID, err = db.AddFeed(&limited); err != nil {
+		t.Fatalf("AddFeed: %v", err)
+	}
+	tm.AddToQueueHead(context.Background(), limited, TaskReasonManualRefresh)
+	if !tm.Wait(10 * time.Second) {
+		t.Fatal("Refresh of the limited feed did not finish in time")
+	}
+	host := strings.TrimPrefix(srv.URL, "http://")
+	hosts := tm.GetHostStates()
+	if len(hosts) != 1 || hosts[0].Host != host || hosts[0].LastStatus != http.StatusTooManyRequests || hosts[0].BackoffUntil.Before(time.Now().Add(59*time.Minute)) {
+		t.Fatalf("Unexpected host states: %+v", hosts)
+	}
+
+	tm.AddToQueueTail(context.Background(), feeds[0], TaskReasonScheduledCustom)
+	if !tm.Wait(10 * time.Second) {
+		t.Fatal("Skipping the feed did not finish in time")
+	}
+	mu.Lock()
+	if requests["/limited"] != 1 || requests["/a"] != 1 {
+		t.Errorf("Requests during backoff: %v, want one each to /limited and /a", requests)
+	}
+	mu.Unlock()
+	if msg := tm.GetProgress().Errors[feeds[0].ID]; !strings.Contains(msg, "asked to slow down") {
+		t.Errorf("Skipped feed error = %q", msg)
+	}
+}
@@ -3,7 +3,10 @@ package feed
 import (
 	"MrRSS/internal/database"
 	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+	"log"
 	"math"
+	"sort"
 	"time"
 )
 
@@ -16,7 +19,149 @@ const (
 	DefaultRefreshInterval = 30 * time.Minute
 )
 
-// IntelligentRefreshCalculator calculates optimal refresh intervals based on feed activity
+const (
+	// hoursPerWeek is the number of hour-of-week buckets of a posting model
+	hoursPerWeek = 7 * 24
+	// postingHistory is how far back publication times feed the posting model
+	postingHistory = 8 * 7 * 24 * time.Hour
+	// postingHistoryLimit caps the number of articles read for the posting model
+	postingHistoryLimit = 500
+	// minObservedSpan is the shortest history a posting rate is computed over,
+	// so a single burst of posts does not look like a feed posting every minute
+	minObservedSpan = 24 * time.Hour
+	// priorWeight is how many observed weeks of an hour of the week weigh as much
+	// as the feed's overall posting rate
+	priorWeight = 2.0
+	// hitRateAlpha is the weight of the latest refresh in the moving hit rate
+	hitRateAlpha = 0.2
+	// targetHitRate is the share of refreshes that should find new items
+	targetHitRate = 0.5
+	// minFetchesForHitRate is the number of observed refreshes before the hit rate adjusts scheduling
+	minFetchesForHitRate = 5
+)
+
+// PostingModel estimates how often a feed posts in each hour of the week
+type PostingModel struct {
+	// Rates holds the expected posting hours per hour, indexed by weekday*24+hour (Sunday first)
+	Rates [hoursPerWeek]float64
+	// Events is the number of posting events the model was built from, posts within an hour counting once
+	Events int
+
+	loc *time.Location
+}
+
+// hourOfWeek returns the hour-of-week bucket of t in its location
+func hourOfWeek(t time.Time) int {
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// BuildPostingModel builds a posting model from publication times, as of now and
+// in now's time zone. Posts within an hour of each other count once, so a burst
+// weighs like a single post. Hours of the week seen only a few times lean toward
+// the feed's overall posting rate.
+func BuildPostingModel(published []time.Time, now time.Time) PostingModel {
+	model := PostingModel{loc: now.Location()}
+	start := now.Add(-postingHistory)
+
+	times := make([]time.Time, 0, len(published))
+	for _, t := range published {
+		if !t.Before(start) && !t.After(now) {
+			times = append(times, t)
+		}
+	}
+	if len(times) == 0 {
+		return model
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	var counts [hoursPerWeek]float64
+	var lastEvent time.Time
+	for _, t := range times {
+		if model.Events > 0 && t.Sub(lastEvent) < time.Hour {
+			continue
+		}
+		lastEvent = t
+		model.Events++
+		counts[hourOfWeek(t.In(model.loc))]++
+	}
+	oldest := times[0]
+
+	if now.Sub(oldest) < minObservedSpan {
+		oldest = now.Add(-minObservedSpan)
+	}
+	overall := float64(model.Events) / now.Sub(oldest).Hours()
+
+	// How often each hour of the week was observed
+	var exposure [hoursPerWeek]float64
+	for t := oldest; t.Before(now); t = t.Add(time.Hour) {
+		exposure[hourOfWeek(t.In(model.loc))]++
+	}
+	for i := range model.Rates {
+		model.Rates[i] = (counts[i] + priorWeight*overall) / (exposure[i] + priorWeight)
+	}
+	return model
+}
+
+// reach returns when the expected number of posts since from reaches target,
+// zero if that is more than limit away
+func (m PostingModel) reach(from time.Time, target float64, limit time.Duration) time.Time {
+	if m.Events == 0 {
+		return time.Time{}
+	}
+
+	t := from.In(m.loc)
+	end := from.Add(limit)
+	expected := 0.0
+	for t.Before(end) {
+		next := time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, m.loc)
+		if !next.After(t) {
+			next = t.Add(time.Hour)
+		}
+		if next.After(end) {
+			next = end
+		}
+		rate := m.Rates[hourOfWeek(t)]
+		gain := rate * next.Sub(t).Hours()
+		if rate > 0 && expected+gain >= target {
+			return t.Add(time.Duration((target - expected) / rate * float64(time.Hour)))
+		}
+		expected += gain
+		t = next
+	}
+	return time.Time{}
+}
+
+// NextPost predicts when the feed most likely posts next after the given time,
+// the median time of its next post. It returns zero when that is more than
+// MaxRefreshInterval away.
+func (m PostingModel) NextPost(after time.Time) time.Time {
+	return m.reach(after, math.Ln2, MaxRefreshInterval)
+}
+
+// PlanRefresh returns when to refresh a feed next, as of now. The refresh is
+// planned for the feed's next likely post, sooner when past refreshes found new
+// items more often than targetHitRate and later when they mostly found nothing.
+func PlanRefresh(model PostingModel, stats *database.FeedRefreshStats, now time.Time) time.Time {
+	if model.Events < 2 {
+		return now.Add(DefaultRefreshInterval)
+	}
+
+	target := math.Ln2
+	if stats != nil && stats.Fetches >= minFetchesForHitRate {
+		target *= math.Pow(2, 2*(targetHitRate-stats.HitRate))
+	}
+
+	next := model.reach(now, target, MaxRefreshInterval)
+	switch {
+	case next.IsZero():
+		return now.Add(MaxRefreshInterval)
+	case next.Before(now.Add(MinRefreshInterval)):
+		return now.Add(MinRefreshInterval)
+	}
+	return next
+}
+
+// IntelligentRefreshCalculator plans feed refreshes around when feeds usually post
 type IntelligentRefreshCalculator struct {
 	db *database.DB
 }
@@ -26,67 +171,100 @@ func NewIntelligentRefreshCalculator(db *database.DB) *IntelligentRefreshCalcula
 	return &IntelligentRefreshCalculator{db: db}
 }
 
-// CalculateInterval calculates the optimal refresh interval for a feed
-// based on its recent article publication frequency
+// model builds the posting model of a feed from its stored articles
+func (irc *IntelligentRefreshCalculator) model(feedID int64, now time.Time) PostingModel {
+	published, err := irc.db.GetArticlePublishTimes(feedID, postingHistoryLimit)
+	if err != nil {
+		log.Printf("Failed to get publish times of feed %d: %v", feedID, err)
+	}
+	return BuildPostingModel(published, now)
+}
+
+// CalculateInterval calculates how long from now until the next refresh of a feed
 // Interval range: 5 minutes to 24 hours
 func (irc *IntelligentRefreshCalculator) CalculateInterval(feed models.Feed) time.Duration {
-	// Get recent articles (last 30 days) to analyze frequency
-	articles, err := irc.db.GetArticles("", feed.ID, "", false, 100, 0)
-	if err != nil || len(articles) == 0 {
-		return DefaultRefreshInterval
+	now := time.Now()
+	stats, err := irc.db.GetFeedRefreshStats(feed.ID)
+	if err != nil {
+		log.Printf("Failed to get refresh stats of feed %s: %v", feed.Title, err)
 	}
+	return PlanRefresh(irc.model(feed.ID, now), stats, now).Sub(now)
+}
 
-	// Calculate average time between articles
-	avgInterval := irc.calculateAverageInterval(articles)
-
-	// Apply smart scaling: refresh more frequently than publication rate
-	// but with reasonable bounds
-	optimalInterval := avgInterval / 2
-
-	// Clamp to min/max bounds (5 minutes to 24 hours)
-	if optimalInterval < MinRefreshInterval {
-		return MinRefreshInterval
+// NextRefreshAt returns when a feed is due for its next intelligent refresh. The
+// time planned after its last refresh is kept across restarts.
+func (irc *IntelligentRefreshCalculator) NextRefreshAt(feed models.Feed) time.Time {
+	var next time.Time
+	stats, err := irc.db.GetFeedRefreshStats(feed.ID)
+	if err != nil {
+		log.Printf("Failed to get refresh stats of feed %s: %v", feed.Title, err)
 	}
-	if optimalInterval > MaxRefreshInterval {
-		return MaxRefreshInterval
+	if stats != nil && !stats.NextRefreshAt.IsZero() {
+		next = stats.NextRefreshAt
+	} else {
+		next = feed.LastUpdated.Add(irc.CalculateInterval(feed))
 	}
 
-	return optimalInterval
+	// Failed refreshes do not plan a new one, wait at least the minimum interval
+	if earliest := feed.LastUpdated.Add(MinRefreshInterval); next.Before(earliest) {
+		next = earliest
+	}
+	return next
 }
 
-// calculateAverageInterval computes the average time between article publications
-func (irc *IntelligentRefreshCalculator) calculateAverageInterval(articles []models.Article) time.Duration {
-	if len(articles) < 2 {
-		return DefaultRefreshInterval
+// RecordFetch updates a feed's hit rate after a successful refresh that saw items
+// published at the given times, and plans its next refresh
+func (irc *IntelligentRefreshCalculator) RecordFetch(feed models.Feed, published []time.Time, now time.Time) {
+	if feed.ID == 0 {
+		return
+	}
+	stats, err := irc.db.GetFeedRefreshStats(feed.ID)
+	if err != nil {
+		log.Printf("Failed to get refresh stats of feed %s: %v", feed.Title, err)
+		return
+	}
+	if stats == nil {
+		stats = &database.FeedRefreshStats{FeedID: feed.ID}
 	}
 
-	// Note: Articles should be ordered by published_at DESC from the database query.
-	// If ordering is not guaranteed, explicit sorting should be added here.
-	// Calculate intervals between consecutive articles
-	var totalInterval time.Duration
-	validIntervals := 0
-
-	for i := 0; i < len(articles)-1; i++ {
-		// Articles already have time.Time for PublishedAt
-		published1 := articles[i].PublishedAt
-		published2 := articles[i+1].PublishedAt
-
-		interval := published1.Sub(published2)
-		// Only count positive intervals (skip negative or zero)
-		if interval > 0 {
-			totalInterval += interval
-			validIntervals++
+	newItems := 0
+	newest := stats.NewestItemAt
+	for _, t := range published {
+		// Stored times have second precision
+		t = t.Truncate(time.Second)
+		if t.After(stats.NewestItemAt) {
+			newItems++
+		}
+		if t.After(newest) {
+			newest = t
 		}
 	}
 
-	if validIntervals == 0 {
-		return DefaultRefreshInterval
+	// The first refresh finds everything new, and items without dates tell nothing
+	if !stats.LastFetchAt.IsZero() && len(published) > 0 {
+		hit := 0.0
+		if newItems > 0 {
+			hit = 1
+		}
+		if stats.Fetches == 0 {
+			stats.HitRate = hit
+		} else {
+			stats.HitRate = hitRateAlpha*hit + (1-hitRateAlpha)*stats.HitRate
+		}
+		stats.Fetches++
 	}
+	stats.LastNewItems = newItems
+	stats.NewestItemAt = newest
+	stats.LastFetchAt = now
 
-	avgInterval := totalInterval / time.Duration(validIntervals)
-
-	// Round to nearest second for cleaner intervals
-	return time.Duration(math.Round(avgInterval.Seconds())) * time.Second
+	model := irc.model(feed.ID, now)
+	stats.NextRefreshAt = PlanRefresh(model, stats, now)
+	if err := irc.db.SaveFeedRefreshStats(*stats); err != nil {
+		log.Printf("Failed to save refresh stats of feed %s: %v", feed.Title, err)
+		return
+	}
+	utils.DebugLog("Feed %s: %d new items, hit rate %.2f, next post expected %v, next refresh at %v",
+		feed.Title, newItems, stats.HitRate, model.NextPost(now), stats.NextRefreshAt)
 }
 
 // GetStaggeredDelay calculates a staggered delay for a feed
@@ -186,3 +186,118 @@ func TestIntelligentRefreshCalculator_WithArticles(t *testing.T) {
 		t.Errorf("Interval %v is too high (above 2 hours)", interval)
 	}
 }
+
+// weekdayPosts returns publication times at 09:10 on each weekday of the four weeks before now
+func weekdayPosts(now time.Time) []time.Time {
+	var published []time.Time
+	for day := 1; day <= 28; day++ {
+		t := time.Date(now.Year(), now.Month(), now.Day()-day, 9, 10, 0, 0, now.Location())
+		if t.Weekday() != time.Saturday && t.Weekday() != time.Sunday {
+			published = append(published, t)
+		}
+	}
+	return published
+}
+
+func TestPostingModel_WeeklyPattern(t *testing.T) {
+	monday := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	model := BuildPostingModel(weekdayPosts(monday), monday)
+	if model.Events != 20 {
+		t.Fatalf("Events = %d, want 20", model.Events)
+	}
+
+	// The next post is expected during Tuesday's posting hour
+	next := model.NextPost(monday)
+	if next.Before(time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)) || next.After(time.Date(2024, 3, 5, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("NextPost = %v, want Tuesday between 09:00 and 10:00", next)
+	}
+	if planned := PlanRefresh(model, nil, monday); !planned.Equal(next) {
+		t.Errorf("PlanRefresh = %v, want the next likely post %v", planned, next)
+	}
+
+	// Nothing is expected over the weekend
+	friday := time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)
+	model = BuildPostingModel(weekdayPosts(friday), friday)
+	if planned := PlanRefresh(model, nil, friday); !planned.Equal(friday.Add(MaxRefreshInterval)) {
+		t.Errorf("PlanRefresh on Friday afternoon = %v, want %v", planned, friday.Add(MaxRefreshInterval))
+	}
+}
+
+func TestPostingModel_Bursts(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+
+	// Twenty posts within a minute are a single posting event
+	var burst []time.Time
+	for i := 0; i < 20; i++ {
+		burst = append(burst, now.Add(-time.Hour-time.Duration(i)*time.Second))
+	}
+	model := BuildPostingModel(burst, now)
+	if model.Events != 1 {
+		t.Errorf("Events = %d, want 1", model.Events)
+	}
+	if planned := PlanRefresh(model, nil, now); !planned.Equal(now.Add(DefaultRefreshInterval)) {
+		t.Errorf("PlanRefresh with too little history = %v, want the default interval", planned)
+	}
+
+	// Posts in the future or beyond the history are ignored
+	model = BuildPostingModel([]time.Time{now.Add(time.Hour), now.Add(-postingHistory - time.Hour)}, now)
+	if model.Events != 0 {
+		t.Errorf("Events = %d, want 0", model.Events)
+	}
+}
+
+func TestPlanRefresh_HitRate(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	var published []time.Time
+	for i := 1; i <= 48; i++ {
+		published = append(published, now.Add(-time.Duration(i)*3*time.Hour))
+	}
+	model := BuildPostingModel(published, now)
+
+	base := PlanRefresh(model, nil, now)
+	hits := PlanRefresh(model, &database.FeedRefreshStats{Fetches: 10, HitRate: 1}, now)
+	misses := PlanRefresh(model, &database.FeedRefreshStats{Fetches: 10, HitRate: 0}, now)
+	if !hits.Before(base) || !misses.After(base) {
+		t.Errorf("PlanRefresh = %v without stats, %v always finding items, %v never finding items", base, hits, misses)
+	}
+	if early := PlanRefresh(model, &database.FeedRefreshStats{Fetches: 2, HitRate: 1}, now); !early.Equal(base) {
+		t.Errorf("A few refreshes should not adjust the plan, got %v, want %v", early, base)
+	}
+}
+
+func TestIntelligentRefreshCalculator_RecordFetch(t *testing.T) {
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB error: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db Init error: %v", err)
+	}
+	calculator := NewIntelligentRefreshCalculator(db)
+	feed := models.Feed{ID: 1, Title: "Test Feed"}
+
+	now := time.Now()
+	published := []time.Time{now.Add(-2 * time.Hour), now.Add(-time.Hour)}
+	calculator.RecordFetch(feed, published, now)
+	stats, err := db.GetFeedRefreshStats(feed.ID)
+	if err != nil || stats == nil {
+		t.Fatalf("GetFeedRefreshStats() = %v, %v", stats, err)
+	}
+	if stats.Fetches != 0 || stats.LastNewItems != 2 || stats.NextRefreshAt.Unix() != now.Add(DefaultRefreshInterval).Unix() {
+		t.Errorf("First refresh should not count toward the hit rate: %+v", stats)
+	}
+
+	// A refresh finding nothing new, then one finding a new item
+	calculator.RecordFetch(feed, published, now.Add(time.Hour))
+	calculator.RecordFetch(feed, append(published, now.Add(90*time.Minute)), now.Add(2*time.Hour))
+	stats, _ = db.GetFeedRefreshStats(feed.ID)
+	if stats.Fetches != 2 || stats.LastNewItems != 1 || stats.HitRate != hitRateAlpha {
+		t.Errorf("Unexpected stats after two refreshes: %+v", stats)
+	}
+
+	// The planned refresh is kept across restarts
+	feed.LastUpdated = now
+	if next := calculator.NextRefreshAt(feed); next.Unix() != stats.NextRefreshAt.Unix() {
+		t.Errorf("NextRefreshAt = %v, want the stored %v", next, stats.NextRefreshAt)
+	}
+}
@@ -0,0 +1,135 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/feed/source"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils/httputil"
+
+	"github.com/mmcdole/gofeed"
+)
+
+// JSONPathFeedType is the feed type of JSON API feeds
+const JSONPathFeedType = "JSON+JSONPath"
+
+// ParseJSONHeaders parses request headers given as one "Name: value" per line
+func ParseJSONHeaders(headers string) (map[string]string, error) {
+	parsed := make(map[string]string)
+	for _, line := range strings.Split(headers, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid header line %q, expected \"Name: value\"", line)
+		}
+		parsed[name] = strings.TrimSpace(value)
+	}
+	return parsed, nil
+}
+
+// jsonPathConfig builds the source configuration of a JSON API feed
+func jsonPathConfig(feed *models.Feed) (*source.Config, error) {
+	headers, err := ParseJSONHeaders(feed.JSONHeaders)
+	if err != nil {
+		return nil, err
+	}
+	return &source.Config{
+		URL:                feed.URL,
+		SourceType:         source.TypeJSONPath,
+		JSONPathItem:       feed.JSONPathItem,
+		JSONPathTitle:      feed.JSONPathItemTitle,
+		JSONPathLink:       feed.JSONPathItemUri,
+		JSONPathContent:    feed.JSONPathItemContent,
+		JSONPathDate:       feed.JSONPathItemTimestamp,
+		JSONPathDateFormat: feed.JSONPathItemTimeFormat,
+		JSONPathAuthor:     feed.JSONPathItemAuthor,
+		JSONPathThumbnail:  feed.JSONPathItemThumbnail,
+		JSONPathID:         feed.JSONPathItemUid,
+		JSONPageParam:      feed.JSONPageParam,
+		JSONCursorPath:     feed.JSONCursorPath,
+		JSONMaxPages:       feed.JSONMaxPages,
+		Headers:            headers,
+	}, nil
+}
+
+// parseFeedWithJSONPath fetches a JSON API and extracts its items with JSONPath
+func (f *Fetcher) parseFeedWithJSONPath(ctx context.Context, feed *models.Feed) (*gofeed.Feed, error) {
+	config, err := jsonPathConfig(feed)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient, err := httputil.CreateHTTPClient("", 30*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	src := source.NewJSONPathSource()
+	src.SetHTTPClient(httpClient)
+
+	parsedFeed, err := src.Fetch(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	parsedFeed.Title = feed.Title
+	parsedFeed.Description = feed.Description
+	return parsedFeed, nil
+}
+
+// AddJSONPathSubscription adds a JSON API feed described by feed's URL and
+// JSONPath fields and returns the feed ID. The API is requested once first, so
+// a wrong URL, header or item path is reported instead of stored.
+func (f *Fetcher) AddJSONPathSubscription(feed *models.Feed) (int64, error) {
+	feed.Type = JSONPathFeedType
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	parsedFeed, err := f.parseFeedWithJSONPath(ctx, feed)
+	if err != nil {
+		return 0, err
+	}
+	if len(parsedFeed.Items) == 0 {
+		return 0, fmt.Errorf("no items found, the item path %q doesn't match any entries with a title or link", feed.JSONPathItem)
+	}
+
+	if feed.Title == "" {
+		feed.Title = "JSON Feed"
+	}
+
+	feedID, err := f.db.AddFeed(feed)
+	if err != nil {
+		return 0, err
+	}
+
+	// AddFeed only stores the common columns
+	if err := f.db.UpdateFeedWithOptions(feedID, JSONPathUpdateOptions(feed)); err != nil {
+		return 0, err
+	}
+	return feedID, nil
+}
+
+// JSONPathUpdateOptions returns the update that stores the JSONPath fields of feed
+func JSONPathUpdateOptions(feed *models.Feed) database.FeedUpdateOptions {
+	return database.FeedUpdateOptions{
+		JSONPathItem:           &feed.JSONPathItem,
+		JSONPathItemTitle:      &feed.JSONPathItemTitle,
+		JSONPathItemUri:        &feed.JSONPathItemUri,
+		JSONPathItemContent:    &feed.JSONPathItemContent,
+		JSONPathItemAuthor:     &feed.JSONPathItemAuthor,
+		JSONPathItemTimestamp:  &feed.JSONPathItemTimestamp,
+		JSONPathItemTimeFormat: &feed.JSONPathItemTimeFormat,
+		JSONPathItemThumbnail:  &feed.JSONPathItemThumbnail,
+		JSONPathItemUid:        &feed.JSONPathItemUid,
+		JSONHeaders:            &feed.JSONHeaders,
+		JSONPageParam:          &feed.JSONPageParam,
+		JSONCursorPath:         &feed.JSONCursorPath,
+		JSONMaxPages:           &feed.JSONMaxPages,
+	}
+}
@@ -0,0 +1,134 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+func TestParseFeedWithJSONPath_PagesAndHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Query().Get("page") {
+		case "1":
+			fmt.Fprint(w, `{"data": {"posts": [
+				{"id": 1, "title": "First", "url": "/posts/1", "body": "<p>one</p>", "author": {"name": "Ann"}, "published": 1700000000},
+				{"id": 2, "title": "Second", "url": "/posts/2", "published": "2023-11-15T10:00:00Z"}
+			]}}`)
+		case "2":
+			fmt.Fprint(w, `{"data": {"posts": [{"id": 3, "title": "Third", "url": "/posts/3"}]}}`)
+		default:
+			fmt.Fprint(w, `{"data": {"posts": []}}`)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := &Fetcher{}
+	feed := &models.Feed{
+		Title:                 "API",
+		URL:                   server.URL + "/api?page=1",
+		Type:                  JSONPathFeedType,
+		JSONPathItem:          "$.data.posts[*]",
+		JSONPathItemTitle:     "title",
+		JSONPathItemUri:       "url",
+		JSONPathItemContent:   "body",
+		JSONPathItemAuthor:    "@.author.name",
+		JSONPathItemTimestamp: "published",
+		JSONPathItemUid:       "id",
+		JSONHeaders:           "Authorization: Bearer secret\n",
+		JSONPageParam:         "page",
+	}
+
+	parsed, err := fetcher.parseFeedWithJSONPath(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("parseFeedWithJSONPath failed: %v", err)
+	}
+	if len(parsed.Items) != 3 {
+		t.Fatalf("Expected 3 items from 2 pages, got %d", len(parsed.Items))
+	}
+
+	first := parsed.Items[0]
+	if first.Title != "First" || first.Link != server.URL+"/posts/1" || first.GUID != "1" {
+		t.Errorf("Unexpected first item: title=%q link=%q guid=%q", first.Title, first.Link, first.GUID)
+	}
+	if first.Content != "<p>one</p>" {
+		t.Errorf("Expected content, got %q", first.Content)
+	}
+	if first.Author == nil || first.Author.Name != "Ann" {
+		t.Errorf("Expected author Ann, got %+v", first.Author)
+	}
+	if first.PublishedParsed == nil || !first.PublishedParsed.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("Expected Unix timestamp to be parsed, got %v", first.PublishedParsed)
+	}
+	if second := parsed.Items[1]; second.PublishedParsed == nil || second.PublishedParsed.Format(time.RFC3339) != "2023-11-15T10:00:00Z" {
+		t.Errorf("Expected RFC 3339 date to be parsed, got %v", second.PublishedParsed)
+	}
+
+	// Without the header the API refuses the request
+	feed.JSONHeaders = ""
+	if _, err := fetcher.parseFeedWithJSONPath(context.Background(), feed); err == nil {
+		t.Error("Expected an error without the Authorization header")
+	}
+}
+
+func TestParseFeedWithJSONPath_Cursor(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Query().Get("after") {
+		case "":
+			fmt.Fprint(w, `{"items": [{"name": "a", "link": "https://example.com/a"}], "next": "c1"}`)
+		case "c1":
+			fmt.Fprint(w, `{"items": [{"name": "b", "link": "https://example.com/b"}], "next": "c2"}`)
+		default:
+			fmt.Fprint(w, `{"items": [{"name": "c", "link": "https://example.com/c"}], "next": "c2"}`)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := &Fetcher{}
+	feed := &models.Feed{
+		URL:               server.URL,
+		Type:              JSONPathFeedType,
+		JSONPathItem:      "$.items",
+		JSONPathItemTitle: "name",
+		JSONPathItemUri:   "link",
+		JSONPageParam:     "after",
+		JSONCursorPath:    "$.next",
+		JSONMaxPages:      10,
+	}
+
+	parsed, err := fetcher.parseFeedWithJSONPath(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("parseFeedWithJSONPath failed: %v", err)
+	}
+	// The repeated cursor stops the loop after the third page
+	if len(parsed.Items) != 3 || requests != 3 {
+		t.Fatalf("Expected 3 items from 3 requests, got %d items from %d requests", len(parsed.Items), requests)
+	}
+	if parsed.Items[2].GUID != "https://example.com/c" {
+		t.Errorf("Expected the link as GUID without an id path, got %q", parsed.Items[2].GUID)
+	}
+}
+
+func TestParseJSONHeaders(t *testing.T) {
+	headers, err := ParseJSONHeaders("X-Api-Key: abc\n\nAccept: application/json ")
+	if err != nil {
+		t.Fatalf("ParseJSONHeaders failed: %v", err)
+	}
+	if headers["X-Api-Key"] != "abc" || headers["Accept"] != "application/json" {
+		t.Errorf("Unexpected headers: %v", headers)
+	}
+	if _, err := ParseJSONHeaders("not a header"); err == nil {
+		t.Error("Expected an error for a line without a colon")
+	}
+}
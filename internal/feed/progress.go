@@ -4,6 +4,14 @@ package feed
 type Progress struct {
 	IsRunning bool             `json:"is_running"`
 	Errors    map[int64]string `json:"errors,omitempty"` // Map of feed ID to error message
+	Sync      *SyncProgress    `json:"sync,omitempty"`   // FreshRSS sync progress, nil when no sync is running
+}
+
+// SyncProgress tracks a running FreshRSS sync
+type SyncProgress struct {
+	Stage   string `json:"stage"`   // e.g. "subscriptions", "articles", "starred", "read", "push"
+	Current int    `json:"current"` // Current item index within the stage
+	Total   int    `json:"total"`   // Total items of the stage
 }
 
 // ProgressWithStats extends Progress with runtime statistics
@@ -20,6 +28,16 @@ func (f *Fetcher) GetProgress() Progress {
 	return f.taskManager.GetProgress()
 }
 
+// ReportSyncProgress records the progress of a running FreshRSS sync
+func (f *Fetcher) ReportSyncProgress(stage string, current, total int) {
+	f.taskManager.SetSyncProgress(SyncProgress{Stage: stage, Current: current, Total: total})
+}
+
+// FinishSyncProgress clears the FreshRSS sync progress once the sync ends
+func (f *Fetcher) FinishSyncProgress() {
+	f.taskManager.ClearSyncProgress()
+}
+
 // GetProgressWithStats returns the current progress with statistics
 func (f *Fetcher) GetProgressWithStats() ProgressWithStats {
 	progress := f.GetProgress()
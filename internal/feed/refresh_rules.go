@@ -0,0 +1,161 @@
+package feed
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"MrRSS/internal/models"
+	"MrRSS/internal/schedule"
+)
+
+// defaultMeteredMinFavorites is how many favorites a feed needs to be refreshed
+// in metered-connection mode when none is set
+const defaultMeteredMinFavorites = 3
+
+// RefreshRules limit when feeds are refreshed on a schedule: the refresh
+// schedules of feeds and categories, the global quiet hours and the
+// metered-connection mode
+type RefreshRules struct {
+	feeds        map[int64]*schedule.Spec
+	categories   map[string]*schedule.Spec
+	quiet        *schedule.Spec // Nil without quiet hours
+	metered      bool
+	minFavorites int
+	favorites    map[int64]int // Favorite articles by feed, only loaded in metered mode
+}
+
+// LoadRefreshRules reads the refresh rules from the settings and the database.
+// Rules that cannot be read are left out, so refreshes go on without them.
+func (f *Fetcher) LoadRefreshRules() *RefreshRules {
+	r := &RefreshRules{
+		feeds:        make(map[int64]*schedule.Spec),
+		categories:   make(map[string]*schedule.Spec),
+		minFavorites: defaultMeteredMinFavorites,
+	}
+
+	rules, err := f.db.GetScheduleRules()
+	if err != nil {
+		log.Printf("Failed to get refresh schedules: %v", err)
+	}
+	for _, rule := range rules {
+		spec, err := schedule.Parse(rule.Spec)
+		if err != nil {
+			log.Printf("Ignoring invalid refresh schedule %q: %v", rule.Spec, err)
+			continue
+		}
+		if rule.FeedID != 0 {
+			r.feeds[rule.FeedID] = spec
+		} else {
+			r.categories[rule.Category] = spec
+		}
+	}
+
+	if value, _ := f.db.GetSetting("quiet_hours"); strings.TrimSpace(value) != "" {
+		if spec, err := schedule.Parse(value); err != nil {
+			log.Printf("Ignoring invalid quiet hours %q: %v", value, err)
+		} else {
+			r.quiet = spec
+		}
+	}
+
+	if value, _ := f.db.GetSetting("metered_mode"); value == "true" {
+		r.metered = true
+		if value, _ := f.db.GetSetting("metered_min_favorites"); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+				r.minFavorites = n
+			}
+		}
+		if r.favorites, err = f.db.GetFavoriteCountsForAllFeeds(); err != nil {
+			log.Printf("Failed to get favorite counts for metered mode: %v", err)
+		}
+	}
+	return r
+}
+
+// Spec returns the refresh schedule of a feed: its own, else the one of its
+// category or the closest parent category, nil if it has none
+func (r *RefreshRules) Spec(feed models.Feed) *schedule.Spec {
+	if spec, ok := r.feeds[feed.ID]; ok {
+		return spec
+	}
+	for category := feed.Category; category != ""; {
+		if spec, ok := r.categories[category]; ok {
+			return spec
+		}
+		i := strings.LastIndex(category, "/")
+		if i < 0 {
+			break
+		}
+		category = category[:i]
+	}
+	return nil
+}
+
+// UsesCron reports whether a feed is refreshed at the times of a cron expression
+func (r *RefreshRules) UsesCron(feed models.Feed) bool {
+	spec := r.Spec(feed)
+	return spec != nil && spec.HasCron()
+}
+
+// Rule returns the refresh schedule of a feed as written, empty without one
+func (r *RefreshRules) Rule(feed models.Feed) string {
+	if spec := r.Spec(feed); spec != nil {
+		return spec.String()
+	}
+	return ""
+}
+
+// Quiet reports whether t falls in the global quiet hours, when nothing is refreshed
+func (r *RefreshRules) Quiet(t time.Time) bool {
+	return r.quiet != nil && r.quiet.InWindow(t)
+}
+
+// Metered reports whether metered-connection mode leaves a feed out, refreshing
+// only feeds with at least the set number of favorites
+func (r *RefreshRules) Metered(feed models.Feed) bool {
+	return r.metered && r.favorites[feed.ID] < r.minFavorites
+}
+
+// Allows reports whether a feed may be refreshed at t
+func (r *RefreshRules) Allows(feed models.Feed, t time.Time) bool {
+	if r.Quiet(t) || r.Metered(feed) {
+		return false
+	}
+	spec := r.Spec(feed)
+	return spec == nil || spec.Allowed(t)
+}
+
+// NextAllowed returns the first time from t on a feed may be refreshed, leaving
+// out metered-connection mode; zero if there is none within a year
+func (r *RefreshRules) NextAllowed(feed models.Feed, t time.Time) time.Time {
+	spec := r.Spec(feed)
+	// Moving out of the quiet hours may move into a paused window and back
+	for i := 0; i < 8 && !t.IsZero(); i++ {
+		if spec != nil {
+			t = spec.NextAllowed(t)
+		}
+		if !r.Quiet(t) {
+			return t
+		}
+		t = r.quiet.NextOutside(t)
+	}
+	return t
+}
+
+// filterByRules leaves out the feeds that may not be refreshed at t and those
+// refreshed at the times of a cron expression, which are scheduled one by one
+func filterByRules(feeds []models.Feed, rules *RefreshRules, t time.Time) []models.Feed {
+	allowed := make([]models.Feed, 0, len(feeds))
+	for _, feed := range feeds {
+		if rules.UsesCron(feed) || !rules.Allows(feed, t) {
+			continue
+		}
+		allowed = append(allowed, feed)
+	}
+	if skipped := len(feeds) - len(allowed); skipped > 0 {
+		log.Printf("Skipping %d feeds outside their refresh schedule", skipped)
+	}
+	return allowed
+}
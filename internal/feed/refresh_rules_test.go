@@ -0,0 +1,111 @@
+package feed
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func TestRefreshRules_Specs(t *testing.T) {
+	f, db := newScheduleTestFetcher(t)
+	for _, rule := range []database.ScheduleRule{
+		{Category: "Tech", Spec: "mon-fri 09:00-18:00"},
+		{FeedID: 2, Spec: "0 7 * * *"},
+	} {
+		if err := db.SaveScheduleRule(rule); err != nil {
+			t.Fatalf("SaveScheduleRule: %v", err)
+		}
+	}
+	rules := f.LoadRefreshRules()
+
+	nested := models.Feed{ID: 1, Category: "Tech/AI"}
+	own := models.Feed{ID: 2, Category: "Tech"}
+	other := models.Feed{ID: 3, Category: "News"}
+
+	if got := rules.Rule(nested); got != "mon-fri 09:00-18:00" {
+		t.Errorf("Subcategory feed rule = %q, want the parent category's", got)
+	}
+	if got := rules.Rule(own); got != "0 7 * * *" {
+		t.Errorf("Feed rule = %q, want its own over its category's", got)
+	}
+	if rules.Spec(other) != nil || !rules.UsesCron(own) || rules.UsesCron(nested) {
+		t.Error("Unexpected rules for the feeds")
+	}
+
+	// Monday 2026-03-02 noon UTC and the Saturday after
+	monday := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	saturday := monday.AddDate(0, 0, 5)
+	if !rules.Allows(nested, monday) || rules.Allows(nested, saturday) || !rules.Allows(other, saturday) {
+		t.Error("Work hours window not applied")
+	}
+	if got := rules.NextAllowed(nested, saturday); !got.Equal(time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("NextAllowed = %v, want Monday 09:00", got)
+	}
+
+	// The global refresh leaves out feeds outside their window and cron-scheduled feeds
+	if due := filterByRules([]models.Feed{nested, own, other}, rules, saturday); len(due) != 1 || due[0].ID != other.ID {
+		t.Errorf("filterByRules = %+v, want only the unrestricted feed", due)
+	}
+
+	// Cron-scheduled feeds are due at their next cron time
+	db.SetSetting("refresh_mode", "fixed")
+	if got := f.NextDueAt(own, monday, rules); !got.Equal(time.Date(2026, 3, 3, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("NextDueAt = %v, want 07:00 the next day", got)
+	}
+	if mode := scheduleMode(models.Feed{ID: 2, RefreshInterval: -2}, "fixed", rules); mode != "" {
+		t.Errorf("Never-refresh feed with a cron schedule has mode %q", mode)
+	}
+
+	// Removing a rule drops it
+	if err := db.SaveScheduleRule(database.ScheduleRule{FeedID: 2}); err != nil {
+		t.Fatalf("SaveScheduleRule: %v", err)
+	}
+	if got := f.LoadRefreshRules().Rule(own); got != "mon-fri 09:00-18:00" {
+		t.Errorf("Rule after removing the feed's own = %q, want the category's", got)
+	}
+}
+
+func TestRefreshRules_QuietHoursAndMetered(t *testing.T) {
+	f, db := newScheduleTestFetcher(t)
+	db.SetSetting("quiet_hours", "23:00-07:00")
+
+	feeds := make([]models.Feed, 2)
+	for i := range feeds {
+		feeds[i] = models.Feed{Title: fmt.Sprintf("feed %d", i), URL: fmt.Sprintf("https://example.com/%d", i)}
+		id, err := db.AddFeed(&feeds[i])
+		if err != nil {
+			t.Fatalf("AddFeed: %v", err)
+		}
+		feeds[i].ID = id
+	}
+	for i := 0; i < 3; i++ {
+		article := &models.Article{FeedID: feeds[0].ID, Title: fmt.Sprintf("a%d", i), URL: fmt.Sprintf("https://example.com/a%d", i), IsFavorite: true}
+		if err := db.SaveArticle(article); err != nil {
+			t.Fatalf("SaveArticle: %v", err)
+		}
+	}
+
+	night := time.Date(2026, 3, 2, 23, 30, 0, 0, time.Local)
+	day := time.Date(2026, 3, 2, 12, 0, 0, 0, time.Local)
+	rules := f.LoadRefreshRules()
+	if !rules.Quiet(night) || rules.Quiet(day) || rules.Allows(feeds[1], night) {
+		t.Error("Quiet hours not applied")
+	}
+	if got := rules.NextAllowed(feeds[1], night); !got.Equal(time.Date(2026, 3, 3, 7, 0, 0, 0, time.Local)) {
+		t.Errorf("NextAllowed in quiet hours = %v, want 07:00", got)
+	}
+
+	// Metered-connection mode refreshes only feeds with enough favorites
+	db.SetSetting("metered_mode", "true")
+	db.SetSetting("metered_min_favorites", "3")
+	rules = f.LoadRefreshRules()
+	if !rules.Allows(feeds[0], day) || rules.Allows(feeds[1], day) {
+		t.Error("Metered-connection mode should only allow the feed with 3 favorites")
+	}
+	if due := filterByRules(feeds, rules, day); len(due) != 1 || due[0].ID != feeds[0].ID {
+		t.Errorf("filterByRules in metered mode = %+v", due)
+	}
+}
@@ -0,0 +1,275 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+const (
+	// defaultGlobalRefreshInterval is the refresh interval of feeds using the global setting when none is set
+	defaultGlobalRefreshInterval = 30 * time.Minute
+	// startupSpreadWindow is how long the refreshes that came due while the app was
+	// not running are spread over after a start
+	startupSpreadWindow = 10 * time.Minute
+)
+
+// Refresh schedule modes of a feed
+const (
+	ScheduleModeGlobal      = "global"      // Refreshed with all feeds at the global interval
+	ScheduleModeCustom      = "custom"      // Refreshed at its own fixed interval
+	ScheduleModeIntelligent = "intelligent" // Refreshed around when it usually posts
+	ScheduleModeCron        = "cron"        // Refreshed at the times of a cron expression
+)
+
+// ScheduledRefresh is an upcoming scheduled refresh of a feed
+type ScheduledRefresh struct {
+	FeedID        int64
+	Title         string
+	Category      string
+	Mode          string
+	Rule          string // Refresh schedule of the feed or its category, empty without one
+	NextDueAt     time.Time
+	LastAttemptAt time.Time // Zero before the first refresh
+	LastSuccessAt time.Time // Zero before the first successful refresh
+	Queued        bool      // Waiting in the refresh queue
+}
+
+// GlobalRefreshInterval returns the refresh interval of feeds using the global setting
+func (f *Fetcher) GlobalRefreshInterval() time.Duration {
+	value, _ := f.db.GetSetting("update_interval")
+	if minutes, err := strconv.Atoi(value); err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultGlobalRefreshInterval
+}
+
+// scheduleMode returns how a feed is refreshed in the given refresh mode, empty
+// for feeds that are not refreshed on a schedule
+func scheduleMode(feed models.Feed, refreshMode string, rules *RefreshRules) string {
+	switch {
+	case refreshMode == "never", feed.IsFreshRSSSource, feed.RefreshInterval == -2:
+		return ""
+	case rules.UsesCron(feed):
+		return ScheduleModeCron
+	case feed.RefreshInterval > 0:
+		return ScheduleModeCustom
+	case feed.RefreshInterval == -1, refreshMode == "intelligent":
+		return ScheduleModeIntelligent
+	}
+	return ScheduleModeGlobal
+}
+
+// NextDueAt returns when a feed refreshed at the given time is due again, zero
+// for feeds that are not refreshed on a schedule
+func (f *Fetcher) NextDueAt(feed models.Feed, refreshedAt time.Time, rules *RefreshRules) time.Time {
+	refreshMode, _ := f.db.GetSetting("refresh_mode")
+	switch scheduleMode(feed, refreshMode, rules) {
+	case ScheduleModeCron:
+		return rules.Spec(feed).Next(refreshedAt)
+	case ScheduleModeCustom:
+		return refreshedAt.Add(time.Duration(feed.RefreshInterval) * time.Minute)
+	case ScheduleModeIntelligent:
+		feed.LastUpdated = refreshedAt
+		return f.refreshCalculator.NextRefreshAt(feed)
+	case ScheduleModeGlobal:
+		return refreshedAt.Add(f.GlobalRefreshInterval())
+	}
+	return time.Time{}
+}
+
+// RefreshDueAt returns when a feed is due for its next scheduled refresh, from
+// its stored schedule when it has one
+func (f *Fetcher) RefreshDueAt(feed models.Feed, schedule database.FeedSchedule, rules *RefreshRules) time.Time {
+	if !schedule.NextDueAt.IsZero() {
+		return schedule.NextDueAt
+	}
+	return f.NextDueAt(feed, feed.LastUpdated, rules)
+}
+
+// recordRefreshAttempt stores the outcome of a refresh in the feed's schedule and plans its next one.
+// Refreshes cancelled by a shutdown stay queued and are resumed after the restart.
+func (f *Fetcher) recordRefreshAttempt(feed models.Feed, fetchErr error) {
+	if errors.Is(fetchErr, context.Canceled) {
+		return
+	}
+	now := time.Now()
+	if err := f.db.RecordFeedAttempt(feed.ID, now, fetchErr == nil, f.NextDueAt(feed, now, f.LoadRefreshRules())); err != nil {
+		log.Printf("Failed to record refresh of feed %s: %v", feed.Title, err)
+	}
+}
+
+// ResumeQueuedRefreshes queues the refreshes again that were still queued when the app stopped
+func (f *Fetcher) ResumeQueuedRefreshes(ctx context.Context) {
+	schedules, err := f.db.GetFeedSchedules()
+	if err != nil {
+		log.Printf("Failed to get queued refreshes: %v", err)
+		return
+	}
+	feeds, err := f.db.GetFeeds()
+	if err != nil {
+		log.Printf("Failed to get feeds for queued refreshes: %v", err)
+		return
+	}
+
+	queued := make([]models.Feed, 0)
+	for _, feed := range feeds {
+		if !schedules[feed.ID].QueuedAt.IsZero() {
+			queued = append(queued, feed)
+		}
+	}
+	queued = f.SkipUnhealthyFeeds(queued)
+	if len(queued) == 0 {
+		return
+	}
+	sort.SliceStable(queued, func(i, j int) bool {
+		return schedules[queued[i].ID].QueuedAt.Before(schedules[queued[j].ID].QueuedAt)
+	})
+
+	log.Printf("Resuming %d refreshes queued before the restart", len(queued))
+	for _, feed := range queued {
+		f.taskManager.AddToQueueTail(ctx, feed, TaskReasonScheduledCustom)
+	}
+}
+
+// SpreadOverdueRefreshes spreads the refreshes of the given feeds that came due
+// while the app was not running over the next few minutes, oldest first, so they
+// do not all start at once
+func (f *Fetcher) SpreadOverdueRefreshes(feeds []models.Feed, rules *RefreshRules, now time.Time) {
+	schedules, err := f.db.GetFeedSchedules()
+	if err != nil {
+		log.Printf("Failed to get feed schedules: %v", err)
+		return
+	}
+
+	type overdueFeed struct {
+		id  int64
+		due time.Time
+	}
+	overdue := make([]overdueFeed, 0)
+	for _, feed := range feeds {
+		schedule := schedules[feed.ID]
+		if !schedule.QueuedAt.IsZero() {
+			continue
+		}
+		if due := f.RefreshDueAt(feed, schedule, rules); !due.IsZero() && !due.After(now) {
+			overdue = append(overdue, overdueFeed{id: feed.ID, due: due})
+		}
+	}
+	if len(overdue) < 2 {
+		return
+	}
+	sort.SliceStable(overdue, func(i, j int) bool { return overdue[i].due.Before(overdue[j].due) })
+
+	spacing := startupSpreadWindow / time.Duration(len(overdue))
+	for i, o := range overdue {
+		if err := f.db.RescheduleFeed(o.id, now.Add(time.Duration(i)*spacing)); err != nil {
+			log.Printf("Failed to reschedule feed %d: %v", o.id, err)
+		}
+	}
+	log.Printf("Spreading %d overdue refreshes over %v", len(overdue), startupSpreadWindow)
+}
+
+// skipRecentlyRefreshed leaves out the feeds using the global setting that were
+// refreshed successfully within the last half global interval, e.g. by hand
+func (f *Fetcher) skipRecentlyRefreshed(feeds []models.Feed, now time.Time) []models.Feed {
+	schedules, err := f.db.GetFeedSchedules()
+	if err != nil {
+		log.Printf("Failed to get feed schedules, refreshing all feeds: %v", err)
+		return feeds
+	}
+
+	recent := now.Add(-f.GlobalRefreshInterval() / 2)
+	due := make([]models.Feed, 0, len(feeds))
+	for _, feed := range feeds {
+		if feed.RefreshInterval == 0 && schedules[feed.ID].LastSuccessAt.After(recent) {
+			continue
+		}
+		due = append(due, feed)
+	}
+	if skipped := len(feeds) - len(due); skipped > 0 {
+		log.Printf("Skipping %d feeds refreshed since %s", skipped, recent.Format(time.RFC3339))
+	}
+	return due
+}
+
+// UpcomingRefreshes returns the scheduled refreshes of all feeds, soonest first
+func (f *Fetcher) UpcomingRefreshes(now time.Time) ([]ScheduledRefresh, error) {
+	refreshMode, _ := f.db.GetSetting("refresh_mode")
+	feeds, err := f.db.GetFeeds()
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := f.db.GetFeedSchedules()
+	if err != nil {
+		return nil, err
+	}
+	healths, err := f.db.GetFeedHealthMap()
+	if err != nil {
+		return nil, err
+	}
+	rules := f.LoadRefreshRules()
+
+	// Feeds using the global setting are refreshed together by the next global refresh
+	interval := f.GlobalRefreshInterval()
+	nextGlobal := now
+	if value, err := f.db.GetSetting("last_global_refresh"); err == nil {
+		if last, err := time.Parse(time.RFC3339, value); err == nil && last.Add(interval).After(now) {
+			nextGlobal = last.Add(interval)
+		}
+	}
+
+	refreshes := make([]ScheduledRefresh, 0, len(feeds))
+	for _, feed := range feeds {
+		mode := scheduleMode(feed, refreshMode, rules)
+		if mode == "" || rules.Metered(feed) {
+			continue
+		}
+		health, failing := healths[feed.ID]
+		if failing && health.Paused() {
+			continue
+		}
+
+		schedule := schedules[feed.ID]
+		refresh := ScheduledRefresh{
+			FeedID:        feed.ID,
+			Title:         feed.Title,
+			Category:      feed.Category,
+			Mode:          mode,
+			Rule:          rules.Rule(feed),
+			LastAttemptAt: schedule.LastAttemptAt,
+			LastSuccessAt: schedule.LastSuccessAt,
+			Queued:        !schedule.QueuedAt.IsZero(),
+		}
+		if mode == ScheduleModeGlobal {
+			refresh.NextDueAt = nextGlobal
+			if schedule.LastSuccessAt.After(nextGlobal.Add(-interval / 2)) {
+				refresh.NextDueAt = nextGlobal.Add(interval)
+			}
+		} else {
+			refresh.NextDueAt = f.RefreshDueAt(feed, schedule, rules)
+		}
+		if failing && health.NextRetryAt.After(refresh.NextDueAt) {
+			refresh.NextDueAt = health.NextRetryAt
+		}
+		if refresh.NextDueAt.Before(now) {
+			refresh.NextDueAt = now
+		}
+		// Refreshes due outside the feed's schedule or in the quiet hours wait until they are allowed
+		if refresh.NextDueAt = rules.NextAllowed(feed, refresh.NextDueAt); refresh.NextDueAt.IsZero() {
+			continue
+		}
+		refreshes = append(refreshes, refresh)
+	}
+
+	sort.SliceStable(refreshes, func(i, j int) bool {
+		return refreshes[i].NextDueAt.Before(refreshes[j].NextDueAt)
+	})
+	return refreshes, nil
+}
@@ -0,0 +1,152 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+)
+
+func newScheduleTestFetcher(t *testing.T) (*Fetcher, *database.DB) {
+	t.Helper()
+	db, err := database.NewDB(":memory:")
+	if err != nil {
+		t.Fatalf("NewDB: %v", err)
+	}
+	if err := db.Init(); err != nil {
+		t.Fatalf("db.Init: %v", err)
+	}
+	return NewFetcher(db), db
+}
+
+func TestSchedule_RecordsAttemptsAndResumesQueue(t *testing.T) {
+	f, db := newScheduleTestFetcher(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprintf(w, `<?xml version="1.0"?><rss><channel><title>t</title><item><title>a</title><link>http://x%s</link><guid>%s</guid></item></channel></rss>`, r.URL.Path, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	feed := models.Feed{Title: "custom", URL: srv.URL + "/custom", RefreshInterval: 60}
+	var err error
+	if feed.ID, err = db.AddFeed(&feed); err != nil {
+		t.Fatalf("AddFeed: %v", err)
+	}
+
+	// A refresh still queued when the app stopped runs again after the restart
+	if err := db.MarkFeedsQueued([]int64{feed.ID}, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("MarkFeedsQueued: %v", err)
+	}
+	before := time.Now().Add(-time.Second)
+	f.ResumeQueuedRefreshes(context.Background())
+	if !f.GetTaskManager().Wait(10 * time.Second) {
+		t.Fatal("Resumed refresh did not finish in time")
+	}
+
+	schedules, err := db.GetFeedSchedules()
+	if err != nil {
+		t.Fatalf("GetFeedSchedules: %v", err)
+	}
+	schedule := schedules[feed.ID]
+	if !schedule.QueuedAt.IsZero() || schedule.LastSuccessAt.Before(before) || !schedule.LastAttemptAt.Equal(schedule.LastSuccessAt) {
+		t.Fatalf("Unexpected schedule after the refresh: %+v", schedule)
+	}
+	if want := schedule.LastAttemptAt.Add(time.Hour); !schedule.NextDueAt.Equal(want) {
+		t.Errorf("NextDueAt = %v, want %v", schedule.NextDueAt, want)
+	}
+	if due := f.RefreshDueAt(feed, schedule, f.LoadRefreshRules()); !due.Equal(schedule.NextDueAt) {
+		t.Errorf("RefreshDueAt = %v, want the stored %v", due, schedule.NextDueAt)
+	}
+
+	// Refreshed feeds using the global setting are not refreshed again by the next global refresh
+	global := models.Feed{ID: 99, Title: "global"}
+	if due := f.skipRecentlyRefreshed([]models.Feed{feed, global}, time.Now()); len(due) != 2 {
+		t.Errorf("Custom-interval and never refreshed feeds should stay, got %d", len(due))
+	}
+	global.ID = feed.ID
+	if due := f.skipRecentlyRefreshed([]models.Feed{global}, time.Now()); len(due) != 0 {
+		t.Errorf("Recently refreshed global feed should be skipped, got %+v", due)
+	}
+}
+
+func TestSchedule_SpreadOverdueRefreshes(t *testing.T) {
+	f, db := newScheduleTestFetcher(t)
+	now := time.Unix(time.Now().Unix(), 0)
+
+	var feeds []models.Feed
+	for i := 0; i < 4; i++ {
+		feed := models.Feed{Title: fmt.Sprintf("feed %d", i), URL: fmt.Sprintf("https://example.com/%d", i), RefreshInterval: 30}
+		var err error
+		if feed.ID, err = db.AddFeed(&feed); err != nil {
+			t.Fatalf("AddFeed: %v", err)
+		}
+		// Came due while the app was not running, the oldest first
+		if err := db.RecordFeedAttempt(feed.ID, now.Add(-3*time.Hour), true, now.Add(-time.Duration(4-i)*time.Hour)); err != nil {
+			t.Fatalf("RecordFeedAttempt: %v", err)
+		}
+		feeds = append(feeds, feed)
+	}
+
+	f.SpreadOverdueRefreshes(feeds, f.LoadRefreshRules(), now)
+	schedules, err := db.GetFeedSchedules()
+	if err != nil {
+		t.Fatalf("GetFeedSchedules: %v", err)
+	}
+	spacing := startupSpreadWindow / 4
+	for i, feed := range feeds {
+		if want := now.Add(time.Duration(i) * spacing); !schedules[feed.ID].NextDueAt.Equal(want) {
+			t.Errorf("Feed %d due at %v, want %v", i, schedules[feed.ID].NextDueAt, want)
+		}
+	}
+}
+
+func TestSchedule_UpcomingRefreshes(t *testing.T) {
+	f, db := newScheduleTestFetcher(t)
+	now := time.Unix(time.Now().Unix(), 0)
+	db.SetSetting("update_interval", "60")
+	db.SetSetting("last_global_refresh", now.Add(-20*time.Minute).Format(time.RFC3339))
+
+	add := func(title string, interval int) int64 {
+		feed := models.Feed{Title: title, URL: "https://example.com/" + title, RefreshInterval: interval}
+		id, err := db.AddFeed(&feed)
+		if err != nil {
+			t.Fatalf("AddFeed: %v", err)
+		}
+		return id
+	}
+	globalID := add("global", 0)
+	customID := add("custom", 15)
+	add("never", -2)
+	if err := db.RescheduleFeed(customID, now.Add(10*time.Minute)); err != nil {
+		t.Fatalf("RescheduleFeed: %v", err)
+	}
+
+	refreshes, err := f.UpcomingRefreshes(now)
+	if err != nil {
+		t.Fatalf("UpcomingRefreshes: %v", err)
+	}
+	if len(refreshes) != 2 {
+		t.Fatalf("Got %d refreshes, want 2 without the never-refresh feed: %+v", len(refreshes), refreshes)
+	}
+	if r := refreshes[0]; r.FeedID != customID || r.Mode != ScheduleModeCustom || !r.NextDueAt.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("First refresh = %+v, want the custom feed in 10 minutes", r)
+	}
+	if r := refreshes[1]; r.FeedID != globalID || r.Mode != ScheduleModeGlobal || !r.NextDueAt.Equal(now.Add(40*time.Minute)) {
+		t.Errorf("Second refresh = %+v, want the global feed with the next global refresh", r)
+	}
+
+	// In intelligent mode feeds using the global setting are planned one by one
+	db.SetSetting("refresh_mode", "intelligent")
+	refreshes, _ = f.UpcomingRefreshes(now)
+	for _, r := range refreshes {
+		if r.FeedID == globalID && r.Mode != ScheduleModeIntelligent {
+			t.Errorf("Global feed mode in intelligent mode = %q", r.Mode)
+		}
+	}
+}
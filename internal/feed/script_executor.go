@@ -4,18 +4,42 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
 	"strings"
+	"sync"
 	"time"
 
+	"MrRSS/internal/database"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+
 	"github.com/mmcdole/gofeed"
 )
 
 // ScriptExecutor handles executing custom scripts for feed fetching
 type ScriptExecutor struct {
 	scriptsDir string
+	setupMu    sync.Mutex // Serializes virtualenv setups
+}
+
+// stderrTailSize is how much of the end of a script's stderr is kept
+const stderrTailSize = 2048
+
+// ScriptRun describes a finished run of a script
+type ScriptRun struct {
+	State    ScriptState   // State for the next run, unchanged when the run failed
+	Duration time.Duration // Run time, including the setup of its virtualenv
+	Stderr   string        // End of what the script wrote to stderr
+}
+
+// outputTail returns the end of a command's output as valid UTF-8
+func outputTail(output []byte) string {
+	if len(output) > stderrTailSize {
+		output = output[len(output)-stderrTailSize:]
+	}
+	return strings.TrimSpace(strings.ToValidUTF8(string(output), ""))
 }
 
 // NewScriptExecutor creates a new ScriptExecutor
@@ -23,24 +47,30 @@ func NewScriptExecutor(scriptsDir string) *ScriptExecutor {
 	return &ScriptExecutor{scriptsDir: scriptsDir}
 }
 
-// findPythonExecutable tries to find a working Python executable
-func findPythonExecutable(ctx context.Context) (string, error) {
-	// Try different Python executables in order of preference
-	candidates := []string{"python", "python3", "py"}
-
-	for _, candidate := range candidates {
-		cmd := exec.CommandContext(ctx, candidate, "--version")
-		if err := cmd.Run(); err == nil {
-			return candidate, nil
-		}
-	}
+// ExecuteScript runs the given script and parses its output as a feed
+// The script should output RSS/Atom XML, a JSON Feed or JSON lines items to stdout
+func (e *ScriptExecutor) ExecuteScript(ctx context.Context, scriptPath string) (*gofeed.Feed, error) {
+	feed, _, err := e.RunScript(ctx, scriptPath, ScriptState{})
+	return feed, err
+}
 
-	return "", fmt.Errorf("no Python executable found")
+// RunScript runs the given script in its sandbox with the state of its last run
+// and returns the parsed feed and the state to keep for the next run.
+// The state is readable as JSON on stdin and through the MRRSS_LAST_RUN and
+// MRRSS_CURSOR variables; the script stores a new cursor by writing it to the
+// file named by MRRSS_CURSOR_FILE. The run's duration and stderr are reported
+// whether or not it succeeded.
+func (e *ScriptExecutor) RunScript(ctx context.Context, scriptPath string, state ScriptState) (*gofeed.Feed, ScriptRun, error) {
+	start := time.Now()
+	run := ScriptRun{State: state}
+	feed, err := e.runScript(ctx, scriptPath, &run)
+	run.Duration = time.Since(start)
+	return feed, run, err
 }
 
-// ExecuteScript runs the given script and parses the output as an RSS feed
-// The script should output valid RSS/Atom XML to stdout
-func (e *ScriptExecutor) ExecuteScript(ctx context.Context, scriptPath string) (*gofeed.Feed, error) {
+// runScript does the work of RunScript, filling in run's state and stderr
+func (e *ScriptExecutor) runScript(ctx context.Context, scriptPath string, run *ScriptRun) (*gofeed.Feed, error) {
+	state := run.State
 	// Construct full path
 	fullPath := filepath.Join(e.scriptsDir, scriptPath)
 	fullPath = filepath.Clean(fullPath)
@@ -55,48 +85,44 @@ func (e *ScriptExecutor) ExecuteScript(ctx context.Context, scriptPath string) (
 		return nil, fmt.Errorf("invalid script path: script must be within scripts directory")
 	}
 
-	// Create a context with timeout (30 seconds for script execution)
-	execCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
+	config, err := LoadScriptConfig(fullPath)
+	if err != nil {
+		return nil, err
+	}
 
-	// Prepare command based on OS and file extension
-	var cmd *exec.Cmd
-	ext := strings.ToLower(filepath.Ext(fullPath))
+	argv, runtimeEnv, err := e.scriptCommand(ctx, fullPath, relPath, config)
+	if err != nil {
+		return nil, err
+	}
 
-	switch ext {
-	case ".py":
-		// Python script - try to find a working Python executable
-		pythonCmd, err := findPythonExecutable(execCtx)
-		if err != nil {
-			return nil, fmt.Errorf("python script execution failed: %w", err)
-		}
-		cmd = exec.CommandContext(execCtx, pythonCmd, fullPath)
-	case ".sh":
-		// Shell script (Unix-like systems)
-		if runtime.GOOS == "windows" {
-			return nil, fmt.Errorf("shell scripts are not supported on Windows")
-		}
-		cmd = exec.CommandContext(execCtx, "bash", fullPath)
-	case ".ps1":
-		// PowerShell script (Windows)
-		if runtime.GOOS != "windows" {
-			cmd = exec.CommandContext(execCtx, "pwsh", "-File", fullPath)
-		} else {
-			cmd = exec.CommandContext(execCtx, "powershell.exe", "-ExecutionPolicy", "Bypass", "-File", fullPath)
-		}
-	case ".js":
-		// Node.js script
-		cmd = exec.CommandContext(execCtx, "node", fullPath)
-	case ".rb":
-		// Ruby script
-		cmd = exec.CommandContext(execCtx, "ruby", fullPath)
-	default:
-		// Try to execute directly (for compiled binaries)
-		cmd = exec.CommandContext(execCtx, fullPath)
+	// Create a context with the script's timeout
+	execCtx, cancel := context.WithTimeout(ctx, config.timeout())
+	defer cancel()
+	cmd := exec.CommandContext(execCtx, argv[0], argv[1:]...)
+
+	// The script stores its next cursor in this file, which starts with the current one
+	cursorFile, err := os.CreateTemp("", "mrrss-cursor-*")
+	if err != nil {
+		return nil, fmt.Errorf("create cursor file: %w", err)
+	}
+	defer os.Remove(cursorFile.Name())
+	_, err = cursorFile.WriteString(state.Cursor)
+	if closeErr := cursorFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("write cursor file: %w", err)
 	}
 
 	// Set working directory to the scripts directory
 	cmd.Dir = e.scriptsDir
+	cmd.Env = scriptEnv(config, state, cursorFile.Name(), runtimeEnv)
+	cmd.Stdin = bytes.NewReader(state.stdin())
+	// Don't wait forever for processes the script left holding its output
+	cmd.WaitDelay = 2 * time.Second
+	if err := applyScriptSandbox(cmd, config); err != nil {
+		return nil, err
+	}
 
 	// Capture stdout and stderr
 	var stdout, stderr bytes.Buffer
@@ -104,29 +130,94 @@ func (e *ScriptExecutor) ExecuteScript(ctx context.Context, scriptPath string) (
 	cmd.Stderr = &stderr
 
 	// Execute the script
-	if err := cmd.Run(); err != nil {
-		stderrStr := stderr.String()
-		if stderrStr != "" {
-			return nil, fmt.Errorf("script execution failed: %v, stderr: %s", err, stderrStr)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("script execution failed: %v", err)
+	}
+	err = cmd.Wait()
+	run.Stderr = outputTail(stderr.Bytes())
+	if err != nil {
+		if run.Stderr != "" {
+			return nil, fmt.Errorf("script execution failed: %v, stderr: %s", err, run.Stderr)
 		}
 		return nil, fmt.Errorf("script execution failed: %v", err)
 	}
 
-	// Get the script output
-	output := stdout.String()
+	// Parse the script output as a feed
+	feed, err := parseScriptOutput(stdout.String())
+	if err != nil {
+		return nil, err
+	}
 
-	// Sanitize the XML to remove problematic links (like file:// URLs)
-	cleanedOutput := sanitizeFeedXML(output)
+	run.State = ScriptState{LastRunAt: time.Now(), Cursor: state.Cursor}
+	if cursor, err := os.ReadFile(cursorFile.Name()); err == nil {
+		run.State.Cursor = strings.TrimSpace(string(cursor))
+	}
+	return feed, nil
+}
 
-	// Parse the sanitized output as RSS/Atom feed
-	fp := gofeed.NewParser()
-	feed, err := fp.ParseString(cleanedOutput)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse script output as feed: %v", err)
+// runFeedScript runs a feed's script with the state stored after its last
+// successful run, stores the state it leaves for the next one and records the
+// outcome of the run for the scripts list
+func (f *Fetcher) runFeedScript(ctx context.Context, scriptPath string) (*gofeed.Feed, error) {
+	var state ScriptState
+	if f.db != nil {
+		stored, err := f.db.GetScriptState(scriptPath)
+		if err != nil {
+			return nil, err
+		}
+		if stored != nil {
+			state = ScriptState{LastRunAt: stored.LastRunAt, Cursor: stored.Cursor}
+		}
 	}
 
-	// Fix Atom authors for feeds that use simple text format
-	fixFeedAuthors(feed, cleanedOutput)
+	feed, run, runErr := f.scriptExecutor.RunScript(ctx, scriptPath, state)
+
+	if f.db != nil {
+		record := database.ScriptRun{
+			ScriptPath:    scriptPath,
+			LastAttemptAt: time.Now(),
+			Status:        database.ScriptRunOK,
+			Duration:      run.Duration,
+			StderrTail:    run.Stderr,
+		}
+		if runErr != nil {
+			record.Status = database.ScriptRunFailed
+			record.Error = runErr.Error()
+		}
+		if err := f.db.SaveScriptRun(record); err != nil {
+			utils.DebugLog("runFeedScript: Failed to record the run of %s: %v", scriptPath, err)
+		}
+	}
+	if runErr != nil {
+		return nil, runErr
+	}
 
+	if f.db != nil {
+		if err := f.db.SaveScriptState(database.ScriptState{ScriptPath: scriptPath, LastRunAt: run.State.LastRunAt, Cursor: run.State.Cursor}); err != nil {
+			utils.DebugLog("runFeedScript: Failed to store the state of %s: %v", scriptPath, err)
+		}
+	}
 	return feed, nil
 }
+
+// ApplyScriptSchedules gives script feeds following the global refresh interval
+// the schedule_minutes of their script's manifest, so the scheduler refreshes
+// them on their own interval instead
+func (f *Fetcher) ApplyScriptSchedules(feeds []models.Feed) {
+	if f.scriptExecutor == nil {
+		return
+	}
+	for i := range feeds {
+		feed := &feeds[i]
+		if feed.ScriptPath == "" || feed.RefreshInterval != 0 {
+			continue
+		}
+		config, err := LoadScriptConfig(filepath.Join(f.scriptExecutor.scriptsDir, feed.ScriptPath))
+		if err != nil {
+			continue
+		}
+		if config.ScheduleMinutes > 0 {
+			feed.RefreshInterval = config.ScheduleMinutes
+		}
+	}
+}
@@ -5,9 +5,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
+
+	"MrRSS/internal/models"
 )
 
 func TestScriptExecutor_ExecuteScript_InvalidPath(t *testing.T) {
@@ -135,3 +138,284 @@ func TestFindPythonExecutable(t *testing.T) {
 		t.Errorf("Found Python executable '%s' failed to run: %v", pythonCmd, err)
 	}
 }
+
+// writeTestScript writes a shell script and its optional sidecar config
+func writeTestScript(t *testing.T, dir, name, content, config string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("Shell scripts are not supported on Windows")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash not available")
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0755); err != nil {
+		t.Fatalf("Failed to create test script: %v", err)
+	}
+	if config != "" {
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(config), 0644); err != nil {
+			t.Fatalf("Failed to create script config: %v", err)
+		}
+	}
+}
+
+func TestScriptExecutor_RunScript_JSONLinesAndState(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTestScript(t, tempDir, "items.sh", `#!/bin/bash
+read -r state
+printf '%s' "next-$MRRSS_CURSOR" > "$MRRSS_CURSOR_FILE"
+echo "{\"id\": \"1\", \"title\": \"From $MRRSS_CURSOR\", \"url\": \"https://example.com/1\", \"published\": 1700000000}"
+echo "{\"title\": \"State\", \"link\": \"https://example.com/2\", \"summary\": $(printf '%s' "$state" | sed 's/"/\\"/g; s/^/"/; s/$/"/')}"
+`, "")
+
+	executor := NewScriptExecutor(tempDir)
+	lastRun := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	feed, run, err := executor.RunScript(context.Background(), "items.sh", ScriptState{LastRunAt: lastRun, Cursor: "c1"})
+	if err != nil {
+		t.Fatalf("RunScript() error = %v", err)
+	}
+
+	if len(feed.Items) != 2 {
+		t.Fatalf("Items count = %d, want 2", len(feed.Items))
+	}
+	first := feed.Items[0]
+	if first.Title != "From c1" || first.GUID != "1" || first.PublishedParsed == nil || first.PublishedParsed.Unix() != 1700000000 {
+		t.Errorf("Unexpected first item: %+v", first)
+	}
+	if feed.Items[1].Link != "https://example.com/2" || feed.Items[1].GUID != "https://example.com/2" {
+		t.Errorf("Expected link alias to set link and GUID, got %+v", feed.Items[1])
+	}
+	if want := `{"cursor":"c1","last_run":"2024-05-01T12:00:00Z"}`; feed.Items[1].Description != want {
+		t.Errorf("stdin state = %q, want %q", feed.Items[1].Description, want)
+	}
+	if run.State.Cursor != "next-c1" {
+		t.Errorf("Next cursor = %q, want next-c1", run.State.Cursor)
+	}
+	if !run.State.LastRunAt.After(lastRun) {
+		t.Errorf("Next last run = %v, want a time after the previous run", run.State.LastRunAt)
+	}
+}
+
+func TestScriptExecutor_RunScript_JSONFeed(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTestScript(t, tempDir, "jsonfeed.sh", `#!/bin/bash
+cat <<'JSON'
+{"version": "https://jsonfeed.org/version/1.1", "title": "JSON Feed", "items": [{"id": "a", "url": "https://example.com/a", "title": "A"}]}
+JSON
+`, "")
+
+	feed, err := NewScriptExecutor(tempDir).ExecuteScript(context.Background(), "jsonfeed.sh")
+	if err != nil {
+		t.Fatalf("ExecuteScript() error = %v", err)
+	}
+	if feed.Title != "JSON Feed" || len(feed.Items) != 1 || feed.Items[0].Title != "A" {
+		t.Errorf("Unexpected feed: title=%q items=%d", feed.Title, len(feed.Items))
+	}
+}
+
+func TestScriptExecutor_RunScript_CleanEnvironment(t *testing.T) {
+	t.Setenv("MRRSS_TEST_SECRET", "hidden")
+	t.Setenv("MRRSS_TEST_ALLOWED", "visible")
+	tempDir := t.TempDir()
+	writeTestScript(t, tempDir, "env.sh", `#!/bin/bash
+echo "{\"title\": \"secret=${MRRSS_TEST_SECRET} allowed=${MRRSS_TEST_ALLOWED}\", \"url\": \"https://example.com\"}"
+`, `{"env_allowlist": ["MRRSS_TEST_ALLOWED"]}`)
+
+	feed, err := NewScriptExecutor(tempDir).ExecuteScript(context.Background(), "env.sh")
+	if err != nil {
+		t.Fatalf("ExecuteScript() error = %v", err)
+	}
+	if got := feed.Items[0].Title; got != "secret= allowed=visible" {
+		t.Errorf("Script environment = %q, want only the allowlisted variable", got)
+	}
+}
+
+func TestScriptExecutor_RunScript_ConfigTimeout(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTestScript(t, tempDir, "slow.sh", "#!/bin/bash\nsleep 30\n", `{"timeout_seconds": 1}`)
+
+	start := time.Now()
+	_, err := NewScriptExecutor(tempDir).ExecuteScript(context.Background(), "slow.sh")
+	if err == nil {
+		t.Fatal("ExecuteScript() should fail when the script exceeds its timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Script ran for %v, want it stopped after about 1 second", elapsed)
+	}
+}
+
+func TestScriptExecutor_RunScript_InvalidConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTestScript(t, tempDir, "bad.sh", "#!/bin/bash\n", `{"memory_limit_mb": -1}`)
+
+	if _, err := NewScriptExecutor(tempDir).ExecuteScript(context.Background(), "bad.sh"); err == nil || !strings.Contains(err.Error(), "memory_limit_mb") {
+		t.Errorf("ExecuteScript() error = %v, want a memory_limit_mb validation error", err)
+	}
+}
+
+func TestScriptExecutor_RunScript_NetworkDisabled(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Network-off mode is only supported on Linux")
+	}
+	tempDir := t.TempDir()
+	writeTestScript(t, tempDir, "net.sh", `#!/bin/bash
+ifaces=$(tail -n +3 /proc/net/dev | cut -d: -f1 | tr -d ' ' | tr '\n' ' ')
+echo "{\"title\": \"$ifaces\", \"url\": \"https://example.com\"}"
+`, `{"network_disabled": true}`)
+
+	feed, err := NewScriptExecutor(tempDir).ExecuteScript(context.Background(), "net.sh")
+	if err != nil {
+		t.Skipf("User namespaces unavailable: %v", err)
+	}
+	if got := strings.TrimSpace(feed.Items[0].Title); got != "lo" {
+		t.Errorf("Network interfaces = %q, want only lo", got)
+	}
+}
+
+func TestScriptExecutor_RunScript_Limits(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Memory and CPU limits are only applied on Linux")
+	}
+	tempDir := t.TempDir()
+	// The limits are in place before the script's first line runs
+	writeTestScript(t, tempDir, "limits.sh", `#!/bin/sh
+echo "{\"title\": \"memory=$(ulimit -v) cpu=$(ulimit -t) args=$*\", \"url\": \"https://example.com\"}"
+`, `{"memory_limit_mb": 512, "cpu_limit_seconds": 7, "args": ["a b", "c"]}`)
+
+	feed, err := NewScriptExecutor(tempDir).ExecuteScript(context.Background(), "limits.sh")
+	if err != nil {
+		t.Fatalf("ExecuteScript() error = %v", err)
+	}
+	if got := feed.Items[0].Title; got != "memory=524288 cpu=7 args=a b c" {
+		t.Errorf("Script limits = %q, want 512 MiB of address space, 7 CPU seconds and the arguments", got)
+	}
+}
+
+func TestScriptExecutor_RunScript_Manifest(t *testing.T) {
+	tempDir := t.TempDir()
+	// Without the manifest's interpreter the .txt script would be executed directly and fail
+	writeTestScript(t, tempDir, "manifest.txt", `echo "{\"title\": \"$1 $GREETING $MRRSS_CURSOR\", \"url\": \"https://example.com\"}"
+`, `{"interpreter": "bash", "interpreter_args": ["--norc"], "args": ["hello"], "env": {"GREETING": "world"}}`)
+	if err := os.Chmod(filepath.Join(tempDir, "manifest.txt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	feed, _, err := NewScriptExecutor(tempDir).RunScript(context.Background(), "manifest.txt", ScriptState{Cursor: "c"})
+	if err != nil {
+		t.Fatalf("RunScript() error = %v", err)
+	}
+	if got := feed.Items[0].Title; got != "hello world c" {
+		t.Errorf("Title = %q, want the manifest's args and env", got)
+	}
+}
+
+func TestScriptExecutor_RunScript_FailureReportsStderr(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTestScript(t, tempDir, "fail.sh", "#!/bin/bash\necho 'first line' >&2\necho 'boom' >&2\nexit 3\n", "")
+
+	state := ScriptState{Cursor: "keep"}
+	_, run, err := NewScriptExecutor(tempDir).RunScript(context.Background(), "fail.sh", state)
+	if err == nil {
+		t.Fatal("RunScript() should fail when the script exits with an error")
+	}
+	if run.Stderr != "first line\nboom" {
+		t.Errorf("Stderr = %q, want the script's stderr", run.Stderr)
+	}
+	if run.State != state {
+		t.Errorf("State = %+v, want the previous state after a failed run", run.State)
+	}
+	if run.Duration <= 0 {
+		t.Errorf("Duration = %v, want the run time", run.Duration)
+	}
+}
+
+func TestOutputTail(t *testing.T) {
+	long := strings.Repeat("a", stderrTailSize) + "end"
+	if got := outputTail([]byte(long)); len(got) != stderrTailSize || !strings.HasSuffix(got, "end") {
+		t.Errorf("outputTail() kept %d bytes, want the last %d", len(got), stderrTailSize)
+	}
+	// Cutting in the middle of a multi-byte character must not leave invalid UTF-8
+	cut := "é" + strings.Repeat("b", stderrTailSize-1)
+	if got := outputTail([]byte(cut)); got != strings.Repeat("b", stderrTailSize-1) {
+		t.Errorf("outputTail() = %q..., want the partial character dropped", got[:10])
+	}
+}
+
+func TestLoadScriptConfig_DependenciesNeedVenv(t *testing.T) {
+	tempDir := t.TempDir()
+	scriptFile := filepath.Join(tempDir, "feed.py")
+	if err := os.WriteFile(scriptFile+".json", []byte(`{"dependencies": ["requests"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadScriptConfig(scriptFile); err == nil || !strings.Contains(err.Error(), "venv") {
+		t.Errorf("LoadScriptConfig() error = %v, want dependencies to require a virtualenv", err)
+	}
+}
+
+func TestScriptExecutor_RunScript_Venv(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Creating a virtualenv is slow")
+	}
+	pythonCmd, err := findPythonExecutable(context.Background())
+	if err != nil {
+		t.Skipf("No Python executable found: %v", err)
+	}
+	if err := exec.Command(pythonCmd, "-m", "venv", "--help").Run(); err != nil {
+		t.Skip("The venv module is not available")
+	}
+
+	tempDir := t.TempDir()
+	script := `import os, sys, json
+print(json.dumps({"title": str(sys.prefix == os.environ.get("VIRTUAL_ENV")), "url": "https://example.com"}))
+`
+	if err := os.MkdirAll(filepath.Join(tempDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "sub", "venv.py"), []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "sub", "venv.py.json"), []byte(`{"venv": true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	executor := NewScriptExecutor(tempDir)
+	feed, _, err := executor.RunScript(context.Background(), filepath.Join("sub", "venv.py"), ScriptState{})
+	if err != nil {
+		t.Fatalf("RunScript() error = %v", err)
+	}
+	if got := feed.Items[0].Title; got != "True" {
+		t.Errorf("Script ran in a virtualenv = %q, want True", got)
+	}
+	if _, err := os.Stat(venvPython(executor.venvDir(filepath.Join("sub", "venv.py")))); err != nil {
+		t.Errorf("Expected the virtualenv to be created: %v", err)
+	}
+}
+
+func TestScriptExecutor_VenvDirUnique(t *testing.T) {
+	executor := NewScriptExecutor(t.TempDir())
+	// These flattened to the same name when separators were replaced by underscores
+	if a, b := executor.venvDir(filepath.Join("a", "b.py")), executor.venvDir("a_b.py"); a == b {
+		t.Errorf("venvDir() = %q for both a/b.py and a_b.py", a)
+	}
+}
+
+func TestApplyScriptSchedules(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "hourly.py.json"), []byte(`{"schedule_minutes": 60}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fetcher := &Fetcher{scriptExecutor: NewScriptExecutor(tempDir)}
+	feeds := []models.Feed{
+		{ScriptPath: "hourly.py"},
+		{ScriptPath: "hourly.py", RefreshInterval: 15},
+		{ScriptPath: "plain.py"},
+		{URL: "https://example.com/feed.xml"},
+	}
+	fetcher.ApplyScriptSchedules(feeds)
+
+	for i, want := range []int{60, 15, 0, 0} {
+		if feeds[i].RefreshInterval != want {
+			t.Errorf("feeds[%d].RefreshInterval = %d, want %d", i, feeds[i].RefreshInterval, want)
+		}
+	}
+}
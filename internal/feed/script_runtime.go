@@ -0,0 +1,199 @@
+package feed
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// venvsDirName is the directory in the scripts directory holding the scripts' virtualenvs
+	venvsDirName = ".venvs"
+	// venvStampName is the file in a virtualenv listing the dependencies installed into it
+	venvStampName = "mrrss-dependencies.txt"
+	// venvSetupTimeout limits creating a virtualenv and installing its dependencies
+	venvSetupTimeout = 10 * time.Minute
+)
+
+var (
+	pythonMu         sync.Mutex
+	pythonExecutable string
+)
+
+// findPythonExecutable tries to find a working Python executable.
+// The first one found is remembered.
+func findPythonExecutable(ctx context.Context) (string, error) {
+	pythonMu.Lock()
+	defer pythonMu.Unlock()
+	if pythonExecutable != "" {
+		return pythonExecutable, nil
+	}
+
+	// Try different Python executables in order of preference. "python" is
+	// still Python 2 on some systems, so "python3" comes first.
+	candidates := []string{"python3", "python", "py"}
+
+	for _, candidate := range candidates {
+		cmd := exec.CommandContext(ctx, candidate, "--version")
+		if err := cmd.Run(); err == nil {
+			pythonExecutable = candidate
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("no Python executable found")
+}
+
+// scriptCommand returns the command line running a script and the variables
+// its runtime needs. The manifest's interpreter or virtualenv wins over the
+// interpreter picked by file extension.
+func (e *ScriptExecutor) scriptCommand(ctx context.Context, fullPath, relPath string, config ScriptConfig) ([]string, []string, error) {
+	ext := strings.ToLower(filepath.Ext(fullPath))
+	withArgs := func(interpreter ...string) []string {
+		argv := append(interpreter, config.InterpreterArgs...)
+		argv = append(argv, fullPath)
+		return append(argv, config.Args...)
+	}
+
+	if config.Venv {
+		if ext != ".py" {
+			return nil, nil, fmt.Errorf("venv is only supported for Python scripts")
+		}
+		setupCtx, cancel := context.WithTimeout(ctx, venvSetupTimeout)
+		defer cancel()
+		venvDir, err := e.ensureVenv(setupCtx, relPath, config)
+		if err != nil {
+			return nil, nil, err
+		}
+		binDir := filepath.Dir(venvPython(venvDir))
+		env := []string{
+			"VIRTUAL_ENV=" + venvDir,
+			"PATH=" + binDir + string(os.PathListSeparator) + os.Getenv("PATH"),
+		}
+		return withArgs(venvPython(venvDir)), env, nil
+	}
+
+	if config.Interpreter != "" {
+		return withArgs(config.Interpreter), nil, nil
+	}
+
+	switch ext {
+	case ".py":
+		// Python script - try to find a working Python executable
+		pythonCmd, err := findPythonExecutable(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("python script execution failed: %w", err)
+		}
+		return withArgs(pythonCmd), nil, nil
+	case ".sh":
+		// Shell script (Unix-like systems)
+		if runtime.GOOS == "windows" {
+			return nil, nil, fmt.Errorf("shell scripts are not supported on Windows")
+		}
+		return withArgs("bash"), nil, nil
+	case ".ps1":
+		// PowerShell script (Windows)
+		if runtime.GOOS != "windows" {
+			return withArgs("pwsh", "-File"), nil, nil
+		}
+		return withArgs("powershell.exe", "-ExecutionPolicy", "Bypass", "-File"), nil, nil
+	case ".js":
+		// Node.js script
+		return withArgs("node"), nil, nil
+	case ".ts":
+		// Deno script, which only asks for the permissions feed scripts commonly need
+		return withArgs("deno", "run", "--allow-net", "--allow-env", "--allow-read", "--allow-write"), nil, nil
+	case ".rb":
+		// Ruby script
+		return withArgs("ruby"), nil, nil
+	default:
+		// Try to execute directly (for compiled binaries)
+		return append([]string{fullPath}, config.Args...), nil, nil
+	}
+}
+
+// venvDir returns the virtualenv directory of a script, named after the script
+// file and a hash of its path, so scripts of the same name in different folders
+// get their own
+func (e *ScriptExecutor) venvDir(relPath string) string {
+	sum := sha256.Sum256([]byte(filepath.ToSlash(relPath)))
+	name := filepath.Base(relPath) + "-" + hex.EncodeToString(sum[:8])
+	return filepath.Join(e.scriptsDir, venvsDirName, name)
+}
+
+// venvPython returns the Python executable of a virtualenv
+func venvPython(venvDir string) string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(venvDir, "Scripts", "python.exe")
+	}
+	return filepath.Join(venvDir, "bin", "python")
+}
+
+// ensureVenv creates the virtualenv of a script if needed and installs its
+// dependencies when they changed since the last installation
+func (e *ScriptExecutor) ensureVenv(ctx context.Context, relPath string, config ScriptConfig) (string, error) {
+	e.setupMu.Lock()
+	defer e.setupMu.Unlock()
+
+	venvDir := e.venvDir(relPath)
+	python := venvPython(venvDir)
+	if _, err := os.Stat(python); err != nil {
+		base := config.Interpreter
+		if base == "" {
+			found, err := findPythonExecutable(ctx)
+			if err != nil {
+				return "", fmt.Errorf("create virtualenv: %w", err)
+			}
+			base = found
+		}
+		if err := os.MkdirAll(filepath.Dir(venvDir), 0755); err != nil {
+			return "", fmt.Errorf("create virtualenv: %w", err)
+		}
+		if err := runSetupCommand(ctx, base, "-m", "venv", venvDir); err != nil {
+			return "", fmt.Errorf("create virtualenv: %w", err)
+		}
+	}
+
+	stamp := filepath.Join(venvDir, venvStampName)
+	wanted := strings.Join(config.Dependencies, "\n")
+	installed, err := os.ReadFile(stamp)
+	if err == nil && string(installed) == wanted {
+		return venvDir, nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("read installed dependencies: %w", err)
+	}
+
+	if len(config.Dependencies) > 0 {
+		args := append([]string{"-m", "pip", "install", "--disable-pip-version-check", "--quiet"}, config.Dependencies...)
+		if err := runSetupCommand(ctx, python, args...); err != nil {
+			return "", fmt.Errorf("install dependencies: %w", err)
+		}
+	}
+	if err := os.WriteFile(stamp, []byte(wanted), 0644); err != nil {
+		return "", fmt.Errorf("record installed dependencies: %w", err)
+	}
+	return venvDir, nil
+}
+
+// runSetupCommand runs a command preparing a script's runtime and reports the
+// end of its output when it fails
+func runSetupCommand(ctx context.Context, name string, args ...string) error {
+	output, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		if tail := outputTail(output); tail != "" {
+			return fmt.Errorf("%v: %s", err, tail)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultScriptTimeout is how long a script may run when its config sets no timeout
+	DefaultScriptTimeout = 30 * time.Second
+	// MaxScriptTimeout caps the timeout a script config can ask for
+	MaxScriptTimeout = 10 * time.Minute
+)
+
+// scriptBaseEnv lists the variables scripts always inherit, so interpreters,
+// locales, temp directories and proxies keep working in the clean environment
+var scriptBaseEnv = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "LANG", "LC_ALL", "LC_CTYPE", "TZ",
+	"TMPDIR", "TMP", "TEMP",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy",
+	// Windows needs these to start most programs
+	"SYSTEMROOT", "SYSTEMDRIVE", "WINDIR", "COMSPEC", "PATHEXT", "USERPROFILE", "APPDATA", "LOCALAPPDATA", "PROGRAMDATA",
+}
+
+// ScriptConfig is a script's manifest, read from a sidecar file next to the
+// script named after it with ".json" appended (feed.py -> feed.py.json).
+// It sets how the script runs and the limits of its sandbox.
+type ScriptConfig struct {
+	Interpreter     string            `json:"interpreter,omitempty"`       // Command running the script (default: by file extension)
+	InterpreterArgs []string          `json:"interpreter_args,omitempty"`  // Arguments before the script path
+	Args            []string          `json:"args,omitempty"`              // Arguments after the script path
+	Env             map[string]string `json:"env,omitempty"`               // Variables set for the script
+	ScheduleMinutes int               `json:"schedule_minutes,omitempty"`  // Refresh interval of feeds using the script that follow the global interval (0 = global)
+	Venv            bool              `json:"venv,omitempty"`              // Run a Python script in its own virtualenv
+	Dependencies    []string          `json:"dependencies,omitempty"`      // pip requirements installed into the virtualenv
+	TimeoutSeconds  int               `json:"timeout_seconds,omitempty"`   // Run time limit (0 = 30 seconds)
+	MemoryLimitMB   int               `json:"memory_limit_mb,omitempty"`   // Address space limit, Linux only (0 = none)
+	CPULimitSeconds int               `json:"cpu_limit_seconds,omitempty"` // CPU time limit, Linux only (0 = none)
+	EnvAllowlist    []string          `json:"env_allowlist,omitempty"`     // Variables passed through besides the base set
+	NetworkDisabled bool              `json:"network_disabled,omitempty"`  // Run without network access, Linux only
+}
+
+// ScriptState is handed to a script so it can continue where its last run stopped
+type ScriptState struct {
+	LastRunAt time.Time // End of the last successful run (zero = never)
+	Cursor    string    // Value the script stored on its last run
+}
+
+// lastRun formats the last run time for the script, empty when it never ran
+func (s ScriptState) lastRun() string {
+	if s.LastRunAt.IsZero() {
+		return ""
+	}
+	return s.LastRunAt.UTC().Format(time.RFC3339)
+}
+
+// stdin returns the state as the JSON object written to the script's stdin
+func (s ScriptState) stdin() []byte {
+	data, _ := json.Marshal(map[string]string{
+		"last_run": s.lastRun(),
+		"cursor":   s.Cursor,
+	})
+	return append(data, '\n')
+}
+
+// scriptConfigPath returns the sidecar config path of a script
+func scriptConfigPath(scriptFile string) string {
+	return scriptFile + ".json"
+}
+
+// LoadScriptConfig reads the sidecar config of a script. A missing file gives the defaults.
+func LoadScriptConfig(scriptFile string) (ScriptConfig, error) {
+	var config ScriptConfig
+	data, err := os.ReadFile(scriptConfigPath(scriptFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, fmt.Errorf("read script config: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("parse script config %s: %w", filepath.Base(scriptConfigPath(scriptFile)), err)
+	}
+	if err := config.validate(); err != nil {
+		return config, fmt.Errorf("script config %s: %w", filepath.Base(scriptConfigPath(scriptFile)), err)
+	}
+	return config, nil
+}
+
+// validate checks the limits are in range
+func (c ScriptConfig) validate() error {
+	if c.TimeoutSeconds < 0 || time.Duration(c.TimeoutSeconds)*time.Second > MaxScriptTimeout {
+		return fmt.Errorf("timeout_seconds must be between 0 and %d", int(MaxScriptTimeout.Seconds()))
+	}
+	if c.MemoryLimitMB < 0 {
+		return errors.New("memory_limit_mb cannot be negative")
+	}
+	if c.CPULimitSeconds < 0 {
+		return errors.New("cpu_limit_seconds cannot be negative")
+	}
+	for _, name := range c.EnvAllowlist {
+		if !validEnvName(name) {
+			return fmt.Errorf("invalid variable name %q in env_allowlist", name)
+		}
+	}
+	for name := range c.Env {
+		if !validEnvName(name) || strings.HasPrefix(name, "MRRSS_") {
+			return fmt.Errorf("invalid variable name %q in env", name)
+		}
+	}
+	if c.ScheduleMinutes < 0 {
+		return errors.New("schedule_minutes cannot be negative")
+	}
+	if len(c.Dependencies) > 0 && !c.Venv {
+		return errors.New("dependencies are installed into the script's virtualenv and need \"venv\": true")
+	}
+	return nil
+}
+
+// validEnvName reports whether name can be used as an environment variable
+func validEnvName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "=\x00")
+}
+
+// timeout returns the run time limit
+func (c ScriptConfig) timeout() time.Duration {
+	if c.TimeoutSeconds <= 0 {
+		return DefaultScriptTimeout
+	}
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// scriptEnv builds the clean environment of a script: the allowed variables of
+// MrRSS's own environment, the manifest's variables, the runtime's variables
+// (such as the virtualenv's PATH) and the MRRSS_* variables describing its state.
+// Later entries override earlier ones.
+func scriptEnv(config ScriptConfig, state ScriptState, cursorFile string, runtimeEnv []string) []string {
+	env := []string{}
+	seen := make(map[string]bool)
+	for _, name := range append(append([]string{}, scriptBaseEnv...), config.EnvAllowlist...) {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+
+	names := make([]string, 0, len(config.Env))
+	for name := range config.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, name+"="+config.Env[name])
+	}
+	env = append(env, runtimeEnv...)
+
+	return append(env,
+		"MRRSS_LAST_RUN="+state.lastRun(),
+		"MRRSS_CURSOR="+state.Cursor,
+		"MRRSS_CURSOR_FILE="+cursorFile,
+	)
+}
@@ -0,0 +1,55 @@
+//go:build linux
+
+package feed
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// applyScriptSandbox runs the script in its own process group, so a timeout
+// also kills what it started, in an empty network namespace when the network
+// is disabled, and with the memory and CPU limits. The user namespace lets
+// this work without privileges.
+func applyScriptSandbox(cmd *exec.Cmd, config ScriptConfig) error {
+	attr := &syscall.SysProcAttr{Setpgid: true}
+	if config.NetworkDisabled {
+		attr.Cloneflags = syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}}
+	}
+	cmd.SysProcAttr = attr
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return limitScript(cmd, config)
+}
+
+// limitScript starts the script through sh, which sets the memory and CPU
+// rlimits before it execs the script, so no code of the script runs without
+// them. Processes it starts inherit them.
+func limitScript(cmd *exec.Cmd, config ScriptConfig) error {
+	var limits []string
+	if config.MemoryLimitMB > 0 {
+		// ulimit -v counts KiB
+		limits = append(limits, fmt.Sprintf("ulimit -v %d", config.MemoryLimitMB<<10))
+	}
+	if config.CPULimitSeconds > 0 {
+		limits = append(limits, fmt.Sprintf("ulimit -t %d", config.CPULimitSeconds))
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		return fmt.Errorf("set script limits: %w", err)
+	}
+	script := strings.Join(limits, " && ") + ` && exec "$0" "$@"`
+	cmd.Args = append([]string{"sh", "-c", script, cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = sh
+	return nil
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package feed
+
+import (
+	"errors"
+	"os/exec"
+
+	"MrRSS/internal/utils"
+)
+
+// applyScriptSandbox refuses network-off mode, which needs Linux namespaces.
+// Memory and CPU limits are only applied on Linux.
+func applyScriptSandbox(cmd *exec.Cmd, config ScriptConfig) error {
+	if config.NetworkDisabled {
+		return errors.New("network_disabled is only supported on Linux")
+	}
+	if config.MemoryLimitMB > 0 || config.CPULimitSeconds > 0 {
+		utils.DebugLog("applyScriptSandbox: Memory and CPU limits are only applied on Linux")
+	}
+	return nil
+}
@@ -2,20 +2,17 @@ package source
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
 	"io"
-	"runtime"
 	"strings"
 	"time"
 
 	"github.com/emersion/go-imap"
-	id "github.com/emersion/go-imap-id"
 	"github.com/emersion/go-imap/client"
 	"github.com/mmcdole/gofeed"
 
-	"MrRSS/internal/version"
+	"MrRSS/internal/email"
 )
 
 // EmailSource fetches newsletter emails via IMAP.
@@ -42,8 +39,8 @@ func (e *EmailSource) Validate(config *Config) error {
 	if config.EmailUsername == "" {
 		return errors.New("email username is required for email source")
 	}
-	if config.EmailPassword == "" {
-		return errors.New("email password is required for email source")
+	if err := account(config).Validate(); err != nil {
+		return err
 	}
 	if config.EmailIMAPPort == 0 {
 		config.EmailIMAPPort = 993 // Default IMAP SSL port
@@ -61,32 +58,20 @@ func (e *EmailSource) Fetch(ctx context.Context, config *Config) (*gofeed.Feed,
 	}
 
 	// Connect to IMAP server
-	c, err := e.connectToIMAP(config)
+	c, err := email.Dial(ctx, account(config))
 	if err != nil {
 		return nil, fmt.Errorf("IMAP connection failed: %w", err)
 	}
 	defer c.Logout()
 
 	// Select mailbox
-	_, err = c.Select(config.EmailFolder, false)
+	_, err = c.Select(config.EmailFolder, true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to select mailbox %s: %w", config.EmailFolder, err)
 	}
 
-	// Determine UID range for fetching
-	fromUID := uint32(1)
-	if config.EmailLastUID > 0 {
-		fromUID = uint32(config.EmailLastUID + 1)
-	}
-
-	// Search for emails
-	criteria := imap.NewSearchCriteria()
-	seqset := new(imap.SeqSet)
-	seqset.AddRange(fromUID, ^uint32(0))
-	criteria.Uid = seqset
-	criteria.Since = time.Now().AddDate(0, -1, 0) // Last month
-
-	uids, err := c.Search(criteria)
+	// Search for emails after the last processed UID
+	uids, err := email.SearchNewUIDs(c, uint32(config.EmailLastUID))
 	if err != nil {
 		return nil, fmt.Errorf("IMAP search failed: %w", err)
 	}
@@ -120,55 +105,17 @@ func (e *EmailSource) Fetch(ctx context.Context, config *Config) (*gofeed.Feed,
 	return feed, nil
 }
 
-// connectToIMAP establishes a connection to the IMAP server.
-func (e *EmailSource) connectToIMAP(config *Config) (*client.Client, error) {
-	server := fmt.Sprintf("%s:%d", config.EmailIMAPServer, config.EmailIMAPPort)
-
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: false,
-		ServerName:         config.EmailIMAPServer,
+// account builds the IMAP account for a source config.
+func account(config *Config) email.Account {
+	return email.Account{
+		Server:     config.EmailIMAPServer,
+		Port:       config.EmailIMAPPort,
+		Security:   config.EmailSecurity,
+		AuthMethod: config.EmailAuthMethod,
+		Username:   config.EmailUsername,
+		Password:   config.EmailPassword,
+		OAuth:      config.EmailOAuth,
 	}
-
-	// Connect with TLS
-	c, err := client.DialTLS(server, tlsConfig)
-	if err != nil {
-		// Fallback to non-TLS
-		c, err = client.Dial(server)
-		if err != nil {
-			return nil, fmt.Errorf("failed to connect: %w", err)
-		}
-	}
-
-	// Send ID command (RFC 2971) - required by some providers
-	_ = e.sendIMAPID(c)
-
-	// Login
-	if err := c.Login(config.EmailUsername, config.EmailPassword); err != nil {
-		c.Logout()
-		return nil, fmt.Errorf("authentication failed: %w", err)
-	}
-
-	return c, nil
-}
-
-// sendIMAPID sends the IMAP ID command.
-func (e *EmailSource) sendIMAPID(c *client.Client) error {
-	idClient := id.NewClient(c)
-
-	supported, err := idClient.SupportID()
-	if err != nil || !supported {
-		return nil
-	}
-
-	clientID := id.ID{
-		id.FieldName:    "MrRSS",
-		id.FieldVersion: version.Version,
-		id.FieldVendor:  "MrRSS",
-		id.FieldOS:      runtime.GOOS,
-	}
-
-	_, err = idClient.ID(clientID)
-	return err
 }
 
 // fetchEmailBatch fetches and parses a batch of emails.
@@ -177,7 +124,7 @@ func (e *EmailSource) fetchEmailBatch(c *client.Client, uids []uint32) ([]*gofee
 	seqset.AddNum(uids...)
 
 	messages := make(chan *imap.Message, len(uids))
-	err := c.Fetch(seqset, []imap.FetchItem{imap.FetchEnvelope, imap.FetchBody}, messages)
+	err := c.UidFetch(seqset, []imap.FetchItem{imap.FetchUid, imap.FetchEnvelope, imap.FetchBody}, messages)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch messages: %w", err)
 	}
@@ -6,16 +6,19 @@ import (
 	"time"
 
 	"github.com/mmcdole/gofeed"
+
+	"MrRSS/internal/email"
 )
 
 // Type represents the type of feed source.
 type Type string
 
 const (
-	TypeRSS    Type = "rss"    // Standard RSS/Atom feed via HTTP
-	TypeScript Type = "script" // Custom script that outputs RSS
-	TypeXPath  Type = "xpath"  // HTML scraping with XPath selectors
-	TypeEmail  Type = "email"  // Email/IMAP as feed source
+	TypeRSS      Type = "rss"      // Standard RSS/Atom feed via HTTP
+	TypeScript   Type = "script"   // Custom script that outputs RSS
+	TypeXPath    Type = "xpath"    // HTML scraping with XPath selectors
+	TypeEmail    Type = "email"    // Email/IMAP as feed source
+	TypeJSONPath Type = "jsonpath" // JSON API with JSONPath expressions
 )
 
 // Source is the interface that all feed sources must implement.
@@ -50,6 +53,28 @@ type Config struct {
 	XPathItemLinkSelector    string // CSS selector for item link
 	XPathItemContentSelector string // CSS selector for item content
 	XPathItemDateSelector    string // CSS selector for item date
+	XPathNextPageSelector    string // CSS selector for the link to the next listing page
+	XPathMaxPages            int    // Listing pages to follow (0 = default when a next page selector is set)
+
+	// XPath detail page fields, applied to each item's own page
+	XPathDetailContentSelector string // CSS selector for the full content
+	XPathDetailAuthorSelector  string // CSS selector for the author
+	XPathDetailDateSelector    string // CSS selector for the date
+
+	// JSONPath source fields. Item field paths are relative to each item
+	// ("title", "@.author.name" or "$.title").
+	JSONPathItem       string // JSONPath to the items array
+	JSONPathTitle      string // Path to the item title
+	JSONPathLink       string // Path to the item link
+	JSONPathContent    string // Path to the item content
+	JSONPathDate       string // Path to the item date
+	JSONPathDateFormat string // Go time layout for the date (empty = common formats, numbers are Unix times)
+	JSONPathAuthor     string // Path to the item author
+	JSONPathThumbnail  string // Path to the item thumbnail URL
+	JSONPathID         string // Path to the item unique ID
+	JSONPageParam      string // Query parameter carrying the page number or cursor (empty = single page)
+	JSONCursorPath     string // JSONPath to the next cursor in the response (empty = page numbers)
+	JSONMaxPages       int    // Pages to request (0 = default when a page parameter is set)
 
 	// Email source fields
 	EmailIMAPServer string // IMAP server address
@@ -58,6 +83,9 @@ type Config struct {
 	EmailPassword   string // IMAP password
 	EmailFolder     string // IMAP folder to fetch from (default: INBOX)
 	EmailLastUID    int    // Last processed email UID
+	EmailSecurity   string // Connection security: tls, starttls or plaintext (default: by port)
+	EmailAuthMethod string // Login method: password, xoauth2 or oauthbearer (default: password)
+	EmailOAuth      email.OAuthConfig
 
 	// Network configuration
 	ProxyURL  string            // HTTP proxy URL
@@ -0,0 +1,386 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"MrRSS/internal/utils/httputil"
+
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/mmcdole/gofeed"
+)
+
+const (
+	// DefaultJSONMaxPages is the number of pages requested when a page parameter
+	// is set without a page limit
+	DefaultJSONMaxPages = 5
+	// MaxJSONPages caps the page limit
+	MaxJSONPages = 50
+)
+
+// JSONPathSource fetches items from JSON APIs using JSONPath expressions.
+type JSONPathSource struct {
+	client *http.Client
+}
+
+// NewJSONPathSource creates a new JSONPath source.
+func NewJSONPathSource() *JSONPathSource {
+	return &JSONPathSource{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Type returns the source type identifier.
+func (j *JSONPathSource) Type() Type {
+	return TypeJSONPath
+}
+
+// Validate checks if the configuration is valid for JSONPath source.
+func (j *JSONPathSource) Validate(config *Config) error {
+	if config == nil {
+		return errors.New("config is nil")
+	}
+	if config.URL == "" {
+		return errors.New("URL is required for JSONPath source")
+	}
+	if config.JSONPathItem == "" {
+		return errors.New("item path is required for JSONPath source")
+	}
+	if _, err := jsonpath.New(config.JSONPathItem); err != nil {
+		return fmt.Errorf("invalid item path %q: %w", config.JSONPathItem, err)
+	}
+	for _, path := range []string{
+		config.JSONPathTitle, config.JSONPathLink, config.JSONPathContent, config.JSONPathDate,
+		config.JSONPathAuthor, config.JSONPathThumbnail, config.JSONPathID, config.JSONCursorPath,
+	} {
+		if path == "" {
+			continue
+		}
+		if _, err := jsonpath.New(itemPath(path)); err != nil {
+			return fmt.Errorf("invalid path %q: %w", path, err)
+		}
+	}
+	if config.JSONCursorPath != "" && config.JSONPageParam == "" {
+		return errors.New("page parameter is required for cursor pagination")
+	}
+	return nil
+}
+
+// SetHTTPClient allows setting a custom HTTP client.
+func (j *JSONPathSource) SetHTTPClient(client *http.Client) {
+	if client != nil {
+		j.client = client
+	}
+}
+
+// Fetch requests the URL and extracts items with the configured paths.
+// With a page parameter it requests further pages, either by page number or
+// by the cursor found at the cursor path, up to the page limit.
+func (j *JSONPathSource) Fetch(ctx context.Context, config *Config) (*gofeed.Feed, error) {
+	if err := j.Validate(config); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	feed := &gofeed.Feed{
+		Link:  config.URL,
+		Items: []*gofeed.Item{},
+	}
+
+	pageURL := config.URL
+	pageNumber := startPage(config)
+	seen := make(map[string]bool)
+	cursors := make(map[string]bool)
+	for page := 1; ; page++ {
+		doc, err := j.fetchDocument(ctx, config, pageURL)
+		if err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			// Keep the items of the pages read so far
+			break
+		}
+
+		items, err := extractJSONItems(doc, config, pageURL)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if !seen[item.GUID] {
+				seen[item.GUID] = true
+				feed.Items = append(feed.Items, item)
+			}
+		}
+
+		if page >= jsonPageLimit(config) || len(items) == 0 {
+			break
+		}
+		var next string
+		if config.JSONCursorPath != "" {
+			cursor := jsonValueString(lookup(doc, config.JSONCursorPath))
+			if cursor == "" || cursors[cursor] {
+				break
+			}
+			cursors[cursor] = true
+			next = setQueryParam(pageURL, config.JSONPageParam, cursor)
+		} else {
+			pageNumber++
+			next = setQueryParam(pageURL, config.JSONPageParam, strconv.Itoa(pageNumber))
+		}
+		if next == "" {
+			break
+		}
+		pageURL = next
+	}
+
+	return feed, nil
+}
+
+// jsonPageLimit returns how many pages to request
+func jsonPageLimit(config *Config) int {
+	if config.JSONPageParam == "" {
+		return 1
+	}
+	if config.JSONMaxPages <= 0 {
+		return DefaultJSONMaxPages
+	}
+	return min(config.JSONMaxPages, MaxJSONPages)
+}
+
+// startPage returns the page number already in the URL, or 1
+func startPage(config *Config) int {
+	if config.JSONPageParam == "" {
+		return 1
+	}
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return 1
+	}
+	if n, err := strconv.Atoi(u.Query().Get(config.JSONPageParam)); err == nil {
+		return n
+	}
+	return 1
+}
+
+// setQueryParam returns pageURL with the query parameter set to value
+func setQueryParam(pageURL, param, value string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	query := u.Query()
+	query.Set(param, value)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// fetchDocument requests a page and decodes its JSON body.
+func (j *JSONPathSource) fetchDocument(ctx context.Context, config *Config, pageURL string) (interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if config.UserAgent != "" {
+		req.Header.Set("User-Agent", config.UserAgent)
+	} else {
+		req.Header.Set("User-Agent", "MrRSS/1.0")
+	}
+	for name, value := range config.Headers {
+		req.Header.Set(name, value)
+	}
+	if config.BasicAuthUser != "" {
+		req.SetBasicAuth(config.BasicAuthUser, config.BasicAuthPassword)
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", pageURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %w", httputil.NewStatusError(resp))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	return doc, nil
+}
+
+// extractJSONItems extracts feed items from a decoded response.
+func extractJSONItems(doc interface{}, config *Config, pageURL string) ([]*gofeed.Item, error) {
+	value, err := jsonpath.Get(config.JSONPathItem, doc)
+	if err != nil {
+		return nil, fmt.Errorf("item path %q: %w", config.JSONPathItem, err)
+	}
+
+	// "$.data" and "$.data[*]" both select the elements of the array
+	var nodes []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		nodes = v
+	case map[string]interface{}:
+		nodes = []interface{}{v}
+	}
+
+	items := []*gofeed.Item{}
+	for _, node := range nodes {
+		item := &gofeed.Item{
+			Title:   jsonValueString(lookup(node, config.JSONPathTitle)),
+			Link:    resolveJSONLink(pageURL, jsonValueString(lookup(node, config.JSONPathLink))),
+			Content: jsonValueString(lookup(node, config.JSONPathContent)),
+		}
+		if author := jsonValueString(lookup(node, config.JSONPathAuthor)); author != "" {
+			item.Author = &gofeed.Person{Name: author}
+		}
+		if thumbnail := jsonValueString(lookup(node, config.JSONPathThumbnail)); thumbnail != "" {
+			item.Image = &gofeed.Image{URL: resolveJSONLink(pageURL, thumbnail)}
+		}
+		if t, ok := parseJSONDate(lookup(node, config.JSONPathDate), config.JSONPathDateFormat); ok {
+			item.PublishedParsed = &t
+		}
+
+		item.GUID = jsonValueString(lookup(node, config.JSONPathID))
+		if item.GUID == "" {
+			item.GUID = item.Link
+		}
+		if item.GUID == "" {
+			item.GUID = item.Title
+		}
+
+		// Only add items with title or link
+		if item.Title != "" || item.Link != "" {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// itemPath turns a path relative to an item ("title", "@.title") into a JSONPath
+func itemPath(path string) string {
+	switch {
+	case strings.HasPrefix(path, "$"):
+		return path
+	case strings.HasPrefix(path, "@"):
+		return "$" + path[1:]
+	case strings.HasPrefix(path, "["):
+		return "$" + path
+	default:
+		return "$." + path
+	}
+}
+
+// lookup evaluates a path against a node. Missing keys give nil.
+func lookup(node interface{}, path string) interface{} {
+	if path == "" {
+		return nil
+	}
+	value, err := jsonpath.Get(itemPath(path), node)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+// jsonValueString converts a selected value to text. Wildcard paths give their first match.
+func jsonValueString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		for _, elem := range v {
+			if s := jsonValueString(elem); s != "" {
+				return s
+			}
+		}
+		return ""
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return string(data)
+	}
+}
+
+// resolveJSONLink resolves a relative link against the API URL.
+func resolveJSONLink(base, href string) string {
+	if href == "" {
+		return ""
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return href
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	return baseURL.ResolveReference(ref).String()
+}
+
+// parseJSONDate parses a selected date. Numbers are Unix timestamps in seconds,
+// or milliseconds when too large for seconds. Strings are parsed with format,
+// or with common formats when none is set.
+func parseJSONDate(value interface{}, format string) (time.Time, bool) {
+	if list, ok := value.([]interface{}); ok {
+		if len(list) == 0 {
+			return time.Time{}, false
+		}
+		value = list[0]
+	}
+
+	if n, ok := value.(float64); ok {
+		if n > 1e12 {
+			return time.UnixMilli(int64(n)).UTC(), true
+		}
+		return time.Unix(int64(n), 0).UTC(), true
+	}
+
+	s, ok := value.(string)
+	if !ok || strings.TrimSpace(s) == "" {
+		return time.Time{}, false
+	}
+	s = strings.TrimSpace(s)
+
+	formats := []string{format}
+	if format == "" {
+		formats = []string{
+			time.RFC3339Nano,
+			time.RFC1123,
+			time.RFC1123Z,
+			"2006-01-02T15:04:05",
+			"2006-01-02 15:04:05",
+			"2006-01-02",
+		}
+	}
+	for _, layout := range formats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -12,10 +12,11 @@ import (
 
 // Manager manages different feed sources and provides a unified interface.
 type Manager struct {
-	rss    *RSSSource
-	script *ScriptSource
-	xpath  *XPathSource
-	email  *EmailSource
+	rss      *RSSSource
+	script   *ScriptSource
+	xpath    *XPathSource
+	jsonpath *JSONPathSource
+	email    *EmailSource
 
 	mu sync.RWMutex
 }
@@ -23,10 +24,11 @@ type Manager struct {
 // NewManager creates a new source manager.
 func NewManager(scriptsDir string) *Manager {
 	return &Manager{
-		rss:    NewRSSSource(),
-		script: NewScriptSource(scriptsDir),
-		xpath:  NewXPathSource(),
-		email:  NewEmailSource(),
+		rss:      NewRSSSource(),
+		script:   NewScriptSource(scriptsDir),
+		xpath:    NewXPathSource(),
+		jsonpath: NewJSONPathSource(),
+		email:    NewEmailSource(),
 	}
 }
 
@@ -42,6 +44,8 @@ func (m *Manager) GetSource(sourceType Type) (Source, error) {
 		return m.script, nil
 	case TypeXPath:
 		return m.xpath, nil
+	case TypeJSONPath:
+		return m.jsonpath, nil
 	case TypeEmail:
 		return m.email, nil
 	default:
@@ -81,18 +85,22 @@ func (m *Manager) detectSourceType(config *Config) Type {
 	if config.XPathItemSelector != "" {
 		return TypeXPath
 	}
+	if config.JSONPathItem != "" {
+		return TypeJSONPath
+	}
 
 	// Default to RSS
 	return TypeRSS
 }
 
-// SetHTTPClient sets the HTTP client for RSS and XPath sources.
+// SetHTTPClient sets the HTTP client for RSS, XPath and JSONPath sources.
 func (m *Manager) SetHTTPClient(client *http.Client) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	m.rss.SetHTTPClient(client)
 	m.xpath.SetHTTPClient(client)
+	m.jsonpath.SetHTTPClient(client)
 }
 
 // Validate validates the configuration for the appropriate source.
@@ -135,6 +143,15 @@ func ConfigFromXPath(url, itemSelector string) *Config {
 	}
 }
 
+// ConfigFromJSONPath creates a JSONPath config.
+func ConfigFromJSONPath(url, itemPath string) *Config {
+	return &Config{
+		URL:          url,
+		JSONPathItem: itemPath,
+		SourceType:   TypeJSONPath,
+	}
+}
+
 // ConfigFromEmail creates an email config.
 func ConfigFromEmail(server string, port int, username, password, folder string) *Config {
 	return &Config{
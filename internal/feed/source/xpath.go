@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
@@ -53,14 +55,88 @@ func (x *XPathSource) SetHTTPClient(client *http.Client) {
 	}
 }
 
+const (
+	// defaultXPathMaxPages is the number of listing pages followed when a next page
+	// selector is set without a page limit
+	defaultXPathMaxPages = 5
+	// maxXPathPages caps the page limit
+	maxXPathPages = 50
+	// detailConcurrency limits how many item pages are fetched at once
+	detailConcurrency = 4
+)
+
 // Fetch retrieves content from the URL and extracts items using selectors.
+// It follows the next page link up to the page limit and completes items from
+// their own pages when detail selectors are set.
 func (x *XPathSource) Fetch(ctx context.Context, config *Config) (*gofeed.Feed, error) {
 	if err := x.Validate(config); err != nil {
 		return nil, fmt.Errorf("validation failed: %w", err)
 	}
 
+	doc, err := x.fetchDocument(ctx, config, config.URL)
+	if err != nil {
+		return nil, err
+	}
+	feed := &gofeed.Feed{
+		Title:       x.extractText(doc, config.XPathTitleSelector),
+		Description: x.extractText(doc, config.XPathDescSelector),
+		Link:        config.URL,
+		Items:       []*gofeed.Item{},
+	}
+
+	seen := make(map[string]bool)
+	visited := map[string]bool{config.URL: true}
+	pageURL := config.URL
+	for page := 1; ; page++ {
+		for _, item := range x.extractItems(doc, config, pageURL) {
+			key := item.Link
+			if key == "" {
+				key = item.Title
+			}
+			if !seen[key] {
+				seen[key] = true
+				feed.Items = append(feed.Items, item)
+			}
+		}
+
+		if page >= pageLimit(config) {
+			break
+		}
+		next, _ := doc.Find(config.XPathNextPageSelector).First().Attr("href")
+		next = x.resolveURL(pageURL, next)
+		if next == "" || visited[next] {
+			break
+		}
+		visited[next] = true
+
+		// Keep the items of the pages read so far
+		if doc, err = x.fetchDocument(ctx, config, next); err != nil {
+			break
+		}
+		pageURL = next
+	}
+
+	if config.XPathDetailContentSelector != "" || config.XPathDetailAuthorSelector != "" || config.XPathDetailDateSelector != "" {
+		x.fetchDetails(ctx, config, feed.Items)
+	}
+	return feed, nil
+}
+
+// pageLimit returns how many listing pages to read
+func pageLimit(config *Config) int {
+	if config.XPathNextPageSelector == "" {
+		return 1
+	}
+	if config.XPathMaxPages <= 0 {
+		return defaultXPathMaxPages
+	}
+	return min(config.XPathMaxPages, maxXPathPages)
+}
+
+// fetchDocument fetches and parses an HTML page.
+func (x *XPathSource) fetchDocument(ctx context.Context, config *Config, pageURL string) (*goquery.Document, error) {
 	// Build request
-	req, err := http.NewRequestWithContext(ctx, "GET", config.URL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -88,22 +164,12 @@ func (x *XPathSource) Fetch(ctx context.Context, config *Config) (*gofeed.Feed,
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
-
-	// Extract items
-	feed := x.extractFeed(doc, config)
-	return feed, nil
+	return doc, nil
 }
 
-// extractFeed extracts feed items from the HTML document.
-func (x *XPathSource) extractFeed(doc *goquery.Document, config *Config) *gofeed.Feed {
-	feed := &gofeed.Feed{
-		Title:       x.extractText(doc, config.XPathTitleSelector),
-		Description: x.extractText(doc, config.XPathDescSelector),
-		Link:        config.URL,
-		Items:       []*gofeed.Item{},
-	}
-
-	// Extract items
+// extractItems extracts feed items from a listing page.
+func (x *XPathSource) extractItems(doc *goquery.Document, config *Config, pageURL string) []*gofeed.Item {
+	items := []*gofeed.Item{}
 	doc.Find(config.XPathItemSelector).Each(func(i int, s *goquery.Selection) {
 		item := &gofeed.Item{}
 
@@ -117,10 +183,10 @@ func (x *XPathSource) extractFeed(doc *goquery.Document, config *Config) *gofeed
 		// Extract link
 		if config.XPathItemLinkSelector != "" {
 			link, _ := s.Find(config.XPathItemLinkSelector).Attr("href")
-			item.Link = x.resolveURL(config.URL, link)
+			item.Link = x.resolveURL(pageURL, link)
 		} else {
 			link, _ := s.Find("a").First().Attr("href")
-			item.Link = x.resolveURL(config.URL, link)
+			item.Link = x.resolveURL(pageURL, link)
 		}
 
 		// Extract content
@@ -140,11 +206,50 @@ func (x *XPathSource) extractFeed(doc *goquery.Document, config *Config) *gofeed
 
 		// Only add items with title or link
 		if item.Title != "" || item.Link != "" {
-			feed.Items = append(feed.Items, item)
+			items = append(items, item)
 		}
 	})
 
-	return feed
+	return items
+}
+
+// fetchDetails completes items from their own pages, a few pages at a time.
+// Items whose page fails keep what the listing had.
+func (x *XPathSource) fetchDetails(ctx context.Context, config *Config, items []*gofeed.Item) {
+	sem := make(chan struct{}, detailConcurrency)
+	var wg sync.WaitGroup
+	for _, item := range items {
+		if item.Link == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(item *gofeed.Item) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			doc, err := x.fetchDocument(ctx, config, item.Link)
+			if err != nil {
+				return
+			}
+			if config.XPathDetailContentSelector != "" {
+				if content := doc.Find(config.XPathDetailContentSelector).First(); content.Length() > 0 {
+					item.Content, _ = content.Html()
+				}
+			}
+			if name := x.extractText(doc, config.XPathDetailAuthorSelector); name != "" {
+				item.Author = &gofeed.Person{Name: name}
+			}
+			if t := x.parseDate(x.extractText(doc, config.XPathDetailDateSelector)); t != nil {
+				item.PublishedParsed = t
+			}
+		}(item)
+	}
+	wg.Wait()
 }
 
 // extractText extracts text content using a selector.
@@ -160,20 +265,15 @@ func (x *XPathSource) resolveURL(base, href string) string {
 	if href == "" {
 		return ""
 	}
-	if strings.HasPrefix(href, "http://") || strings.HasPrefix(href, "https://") {
+	baseURL, err := url.Parse(base)
+	if err != nil {
 		return href
 	}
-	if strings.HasPrefix(href, "//") {
-		return "https:" + href
-	}
-	if strings.HasPrefix(href, "/") {
-		// Extract base domain
-		parts := strings.SplitN(base, "/", 4)
-		if len(parts) >= 3 {
-			return parts[0] + "//" + parts[2] + href
-		}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return href
 	}
-	return base + "/" + href
+	return baseURL.ResolveReference(ref).String()
 }
 
 // parseDate attempts to parse a date string.
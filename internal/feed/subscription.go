@@ -1,7 +1,9 @@
 package feed
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -111,8 +113,10 @@ func sanitizeFeedXML(xmlContent string) string {
 	return cleaned
 }
 
-// fetchAndSanitizeFeed fetches feed content and sanitizes it before parsing
-func (f *Fetcher) fetchAndSanitizeFeed(ctx context.Context, feedURL string) (string, error) {
+// fetchAndSanitizeFeed fetches feed content and sanitizes it before parsing.
+// When the feed was reached only through permanent redirects (301/308), it
+// also returns the URL the feed moved to.
+func (f *Fetcher) fetchAndSanitizeFeed(ctx context.Context, feedURL string) (string, string, error) {
 	debugTimer := NewDebugTimer(fmt.Sprintf("FetchSanitize-%s", feedURL), shouldEnableDebugLogging(feedURL))
 	defer debugTimer.End()
 
@@ -123,7 +127,20 @@ func (f *Fetcher) fetchAndSanitizeFeed(ctx context.Context, feedURL string) (str
 	httpClient, err := f.getHTTPClient(models.Feed{URL: feedURL})
 	if err != nil {
 		debugTimer.LogWithTime("Failed to create HTTP client: %v", err)
-		return "", fmt.Errorf("failed to create HTTP client: %w", err)
+		return "", "", fmt.Errorf("failed to create HTTP client: %w", err)
+	}
+	// Track redirects, a feed reached only through permanent ones has moved
+	var movedTo string
+	permanent := true
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		if code := req.Response.StatusCode; code != http.StatusMovedPermanently && code != http.StatusPermanentRedirect {
+			permanent = false
+		}
+		movedTo = req.URL.String()
+		return nil
 	}
 	debugTimer.Stage("HTTP client created")
 
@@ -131,7 +148,7 @@ func (f *Fetcher) fetchAndSanitizeFeed(ctx context.Context, feedURL string) (str
 	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
 	if err != nil {
 		debugTimer.LogWithTime("Failed to create request: %v", err)
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return "", "", fmt.Errorf("failed to create request: %w", err)
 	}
 	debugTimer.Stage("Request created")
 
@@ -149,14 +166,18 @@ func (f *Fetcher) fetchAndSanitizeFeed(ctx context.Context, feedURL string) (str
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		debugTimer.LogWithTime("HTTP request failed: %v", err)
-		return "", fmt.Errorf("failed to fetch feed: %w", err)
+		return "", "", fmt.Errorf("failed to fetch feed: %w", err)
 	}
 	defer resp.Body.Close()
 	debugTimer.Stage("HTTP request completed")
 
+	if !permanent {
+		movedTo = ""
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		debugTimer.LogWithTime("HTTP status not OK: %d", resp.StatusCode)
-		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return "", "", httputil.NewStatusError(resp)
 	}
 
 	debugTimer.LogWithTime("Reading response body")
@@ -165,7 +186,7 @@ func (f *Fetcher) fetchAndSanitizeFeed(ctx context.Context, feedURL string) (str
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		debugTimer.LogWithTime("Failed to read body: %v", err)
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return "", "", fmt.Errorf("failed to read response body: %w", err)
 	}
 	debugTimer.LogWithTime("Read %d bytes from response", len(body))
 	debugTimer.Stage("Body read complete")
@@ -178,7 +199,7 @@ func (f *Fetcher) fetchAndSanitizeFeed(ctx context.Context, feedURL string) (str
 	debugTimer.LogWithTime("Sanitization complete, length=%d", len(cleanedXML))
 	debugTimer.Stage("Sanitization complete")
 
-	return cleanedXML, nil
+	return cleanedXML, movedTo, nil
 }
 
 // AddSubscription adds a new feed subscription and returns the feed ID.
@@ -187,7 +208,7 @@ func (f *Fetcher) AddSubscription(url string, category string, customTitle strin
 
 	// Try fetching and sanitizing the feed first
 	ctx := context.Background()
-	cleanedXML, err := f.fetchAndSanitizeFeed(ctx, url)
+	cleanedXML, _, err := f.fetchAndSanitizeFeed(ctx, url)
 	if err != nil {
 		utils.DebugLog("AddSubscription: Failed to fetch feed for %s: %v", url, err)
 		// Fall through to standard parsing which might handle it differently
@@ -485,7 +506,7 @@ func (f *Fetcher) ParseFeed(ctx context.Context, url string) (*gofeed.Feed, erro
 
 // ParseFeedWithScript parses an RSS feed, using a custom script or XPath if specified.
 // If scriptPath is non-empty, it executes the script.
-// If feed.Type is "HTML+XPath" or "XML+XPath", it uses XPath parsing.
+// If feed.Type is "HTML+XPath" or "XML+XPath", it uses XPath parsing, and "JSON+JSONPath" uses JSONPath.
 // Otherwise, it fetches from the URL as normal.
 // priority: true for high-priority requests (like article content fetching), false for normal requests (like feed refresh)
 func (f *Fetcher) ParseFeedWithScript(ctx context.Context, url string, scriptPath string, priority bool) (*gofeed.Feed, error) {
@@ -514,12 +535,35 @@ func (f *Fetcher) parseFeedWithFeedInternal(ctx context.Context, feed *models.Fe
 			return nil, fmt.Errorf("email fetcher not initialized")
 		}
 
+		// Newsletters sent to a generated address are pushed by the SMTP listener, there is nothing to poll
+		if feed.EmailInboundToken != "" && feed.EmailIMAPServer == "" {
+			return &gofeed.Feed{Title: feed.Title, Link: feed.URL, Description: feed.Description}, nil
+		}
+
+		// Routed feeds read their newsletters from another feed's mailbox
+		account := feed
+		if feed.EmailAccountID != 0 {
+			var err error
+			account, err = f.db.GetFeedByID(feed.EmailAccountID)
+			if err != nil {
+				return nil, fmt.Errorf("mailbox account %d not found: %w", feed.EmailAccountID, err)
+			}
+		}
+
 		// Fetch emails from IMAP
-		items, err := f.emailFetcher.FetchEmails(ctx, feed)
+		delivered, err := f.emailFetcher.FetchEmails(ctx, account)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch emails: %w", err)
 		}
 
+		// Messages routed to other feeds of the mailbox are stored right away
+		for feedID, routedItems := range delivered {
+			if feedID != feed.ID {
+				f.saveRoutedEmails(ctx, feedID, routedItems)
+			}
+		}
+		items := delivered[feed.ID]
+
 		// Create gofeed.Feed from email items
 		parsedFeed := &gofeed.Feed{
 			Title:       feed.Title,
@@ -546,7 +590,7 @@ func (f *Fetcher) parseFeedWithFeedInternal(ctx context.Context, feed *models.Fe
 			defer cancel()
 		}
 
-		return f.scriptExecutor.ExecuteScript(scriptCtx, feed.ScriptPath)
+		return f.runFeedScript(scriptCtx, feed.ScriptPath)
 	}
 
 	// Check if this is an XPath-based feed
@@ -564,6 +608,19 @@ func (f *Fetcher) parseFeedWithFeedInternal(ctx context.Context, feed *models.Fe
 		return f.parseFeedWithXPath(xpathCtx, feed)
 	}
 
+	// Check if this is a JSON API feed
+	if feed.Type == JSONPathFeedType {
+		utils.DebugLog("parseFeedWithFeedInternal: Using JSONPath parsing for %s", feed.URL)
+		jsonCtx := ctx
+		if priority {
+			var cancel context.CancelFunc
+			jsonCtx, cancel = context.WithTimeout(ctx, 15*time.Second) // Shorter timeout for content fetching
+			defer cancel()
+		}
+
+		return f.parseFeedWithJSONPath(jsonCtx, feed)
+	}
+
 	debugTimer.Stage("Traditional URL fetching")
 	utils.DebugLog("parseFeedWithFeedInternal: Using traditional URL-based fetching for %s", feed.URL)
 	// Use traditional URL-based fetching
@@ -590,7 +647,7 @@ func (f *Fetcher) parseFeedWithFeedInternal(ctx context.Context, feed *models.Fe
 	// Try fetching and sanitizing the feed first to handle file:// URLs in atom:link
 	debugTimer.LogWithTime("About to call fetchAndSanitizeFeed")
 	utils.DebugLog("parseFeedWithFeedInternal: Attempting to fetch and sanitize feed for %s", actualURL)
-	cleanedXML, sanitizeErr := f.fetchAndSanitizeFeed(fetchCtx, actualURL)
+	cleanedXML, movedTo, sanitizeErr := f.fetchAndSanitizeFeed(fetchCtx, actualURL)
 	debugTimer.LogWithTime("fetchAndSanitizeFeed completed, err=%v", sanitizeErr)
 
 	if sanitizeErr == nil {
@@ -610,6 +667,10 @@ func (f *Fetcher) parseFeedWithFeedInternal(ctx context.Context, feed *models.Fe
 			utils.DebugLog("parseFeedWithFeedInternal: Successfully parsed sanitized feed for %s", actualURL)
 			// Fix Atom authors for feeds that use simple text format
 			fixFeedAuthors(parsedFeed, cleanedXML)
+			// Follow permanent moves of the feed itself, RSSHub routes keep their rsshub:// URL
+			if movedTo != "" && actualURL == feed.URL {
+				f.updateMovedFeedURL(feed, movedTo)
+			}
 			return parsedFeed, nil
 		}
 		utils.DebugLog("parseFeedWithFeedInternal: Parsing sanitized feed failed: %v", err)
@@ -617,6 +678,11 @@ func (f *Fetcher) parseFeedWithFeedInternal(ctx context.Context, feed *models.Fe
 	} else {
 		debugTimer.LogWithTime("Sanitization failed, will try standard parsing")
 		utils.DebugLog("parseFeedWithFeedInternal: Sanitization failed: %v", sanitizeErr)
+		// The fallback would only hit a server asking us to slow down again
+		var statusErr *httputil.StatusError
+		if errors.As(sanitizeErr, &statusErr) && statusErr.IsThrottled() {
+			return nil, sanitizeErr
+		}
 	}
 
 	// Fallback: Try standard parsing first
@@ -673,50 +739,30 @@ func (f *Fetcher) parseFeedWithFeedInternal(ctx context.Context, feed *models.Fe
 	return parsedFeed, nil
 }
 
-// parseFeedWithXPath parses a feed using XPath expressions
-func (f *Fetcher) parseFeedWithXPath(_ context.Context, feed *models.Feed) (*gofeed.Feed, error) {
+// parseFeedWithXPath parses a feed using XPath expressions.
+// It follows the next page link up to the page limit, then fetches item pages
+// for the detail expressions when any are set.
+func (f *Fetcher) parseFeedWithXPath(ctx context.Context, feed *models.Feed) (*gofeed.Feed, error) {
 	if feed.XPathItem == "" {
 		return nil, &XPathError{
 			Operation: "validate",
 			Details:   "XPath item expression is required for XPath-based feeds",
 		}
 	}
-
-	// Fetch the content
-	httpClient, err := httputil.CreateHTTPClient("", 30*time.Second)
-	if err != nil {
+	if feed.Type != "HTML+XPath" && feed.Type != "XML+XPath" {
 		return nil, &XPathError{
-			Operation: "fetch",
-			URL:       feed.URL,
-			Details:   "Failed to create HTTP client",
-			Err:       err,
-		}
-	}
-	resp, err := httpClient.Get(feed.URL)
-	if err != nil {
-		return nil, &XPathError{
-			Operation: "fetch",
-			URL:       feed.URL,
-			Details:   "Failed to fetch content. Please check the URL and your network connection",
-			Err:       err,
-		}
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return nil, &XPathError{
-			Operation: "fetch",
-			URL:       feed.URL,
-			Details:   fmt.Sprintf("HTTP %d: %s. The server may be unreachable or the page may have moved", resp.StatusCode, resp.Status),
+			Operation: "validate",
+			Details:   fmt.Sprintf("Unsupported feed type '%s'. Must be 'HTML+XPath' or 'XML+XPath'", feed.Type),
 		}
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	// Fetch the content
+	httpClient, err := httputil.CreateHTTPClient("", 30*time.Second)
 	if err != nil {
 		return nil, &XPathError{
 			Operation: "fetch",
 			URL:       feed.URL,
-			Details:   "Failed to read response body",
+			Details:   "Failed to create HTTP client",
 			Err:       err,
 		}
 	}
@@ -729,66 +775,133 @@ func (f *Fetcher) parseFeedWithXPath(_ context.Context, feed *models.Feed) (*gof
 		Items:       make([]*gofeed.Item, 0),
 	}
 
-	// Parse based on type
+	seenItems := make(map[string]bool)
+	visitedPages := make(map[string]bool)
+	pageURL := feed.URL
+	for page := 1; page <= xpathPageLimit(feed) && pageURL != "" && !visitedPages[pageURL]; page++ {
+		visitedPages[pageURL] = true
+
+		body, err := fetchXPathPage(ctx, httpClient, pageURL)
+		if err == nil {
+			var items []*gofeed.Item
+			items, pageURL, err = f.extractXPathPage(feed, pageURL, body)
+			for _, item := range items {
+				// Listings shift while we page through them, keep the first copy
+				if !seenItems[item.GUID] {
+					seenItems[item.GUID] = true
+					parsedFeed.Items = append(parsedFeed.Items, item)
+				}
+			}
+		}
+		if err != nil {
+			if page == 1 {
+				return nil, err
+			}
+			// Keep the items of the pages read so far
+			utils.DebugLog("parseFeedWithXPath: Stopping at page %d of %s: %v", page, feed.URL, err)
+			break
+		}
+	}
+
+	if hasXPathDetail(feed) {
+		f.fetchXPathDetails(ctx, httpClient, feed, parsedFeed.Items)
+	}
+
+	return parsedFeed, nil
+}
+
+// extractXPathPage extracts the items of one listing page and the link to the next page
+func (f *Fetcher) extractXPathPage(feed *models.Feed, pageURL string, body []byte) ([]*gofeed.Item, string, error) {
+	// Relative links on later pages resolve against that page
+	pageFeed := *feed
+	pageFeed.URL = pageURL
+
+	var items []*gofeed.Item
+	var nextPage string
 	switch feed.Type {
 	case "HTML+XPath":
-		doc, err := htmlquery.Parse(strings.NewReader(string(body)))
+		doc, err := htmlquery.Parse(bytes.NewReader(body))
 		if err != nil {
-			return nil, &XPathError{
+			return nil, "", &XPathError{
 				Operation: "parse",
-				URL:       feed.URL,
+				URL:       pageURL,
 				Details:   "Failed to parse HTML. The page structure may have changed or the content may not be valid HTML",
 				Err:       err,
 			}
 		}
-		items := htmlquery.Find(doc, feed.XPathItem)
-		if len(items) == 0 {
-			return nil, &XPathError{
+		nodes := htmlquery.Find(doc, feed.XPathItem)
+		if len(nodes) == 0 {
+			return nil, "", &XPathError{
 				Operation: "extract",
-				URL:       feed.URL,
+				URL:       pageURL,
 				XPathExpr: feed.XPathItem,
 				Details:   "No items found. The Item XPath expression doesn't match any elements on the page. The page structure may have changed",
 			}
 		}
 
 		// Process HTML items
-		for _, item := range items {
-			gofeedItem := f.extractItemFromHTMLNode(item, feed)
-			parsedFeed.Items = append(parsedFeed.Items, gofeedItem)
+		for _, node := range nodes {
+			items = append(items, f.extractItemFromHTMLNode(node, &pageFeed))
+		}
+		if feed.XPathNextPage != "" {
+			node, err := htmlquery.Query(doc, feed.XPathNextPage)
+			if err != nil {
+				return nil, "", invalidNextPageXPath(pageURL, feed.XPathNextPage, err)
+			}
+			if node != nil {
+				nextPage = htmlLinkValue(node)
+			}
 		}
 	case "XML+XPath":
-		doc, err := xmlquery.Parse(strings.NewReader(string(body)))
+		doc, err := xmlquery.Parse(bytes.NewReader(body))
 		if err != nil {
-			return nil, &XPathError{
+			return nil, "", &XPathError{
 				Operation: "parse",
-				URL:       feed.URL,
+				URL:       pageURL,
 				Details:   "Failed to parse XML. The content may not be valid XML",
 				Err:       err,
 			}
 		}
-		items := xmlquery.Find(doc, feed.XPathItem)
-		if len(items) == 0 {
-			return nil, &XPathError{
+		nodes := xmlquery.Find(doc, feed.XPathItem)
+		if len(nodes) == 0 {
+			return nil, "", &XPathError{
 				Operation: "extract",
-				URL:       feed.URL,
+				URL:       pageURL,
 				XPathExpr: feed.XPathItem,
 				Details:   "No items found. The Item XPath expression doesn't match any elements in the XML. The structure may have changed",
 			}
 		}
 
 		// Process XML items
-		for _, item := range items {
-			gofeedItem := f.extractItemFromXMLNode(item, feed)
-			parsedFeed.Items = append(parsedFeed.Items, gofeedItem)
+		for _, node := range nodes {
+			items = append(items, f.extractItemFromXMLNode(node, &pageFeed))
 		}
-	default:
-		return nil, &XPathError{
-			Operation: "validate",
-			Details:   fmt.Sprintf("Unsupported feed type '%s'. Must be 'HTML+XPath' or 'XML+XPath'", feed.Type),
+		if feed.XPathNextPage != "" {
+			node, err := xmlquery.Query(doc, feed.XPathNextPage)
+			if err != nil {
+				return nil, "", invalidNextPageXPath(pageURL, feed.XPathNextPage, err)
+			}
+			if node != nil {
+				nextPage = strings.TrimSpace(node.InnerText())
+				if href := node.SelectAttr("href"); href != "" {
+					nextPage = href
+				}
+			}
 		}
 	}
 
-	return parsedFeed, nil
+	return items, resolveXPathURL(pageURL, nextPage), nil
+}
+
+// invalidNextPageXPath reports a next page expression that does not compile
+func invalidNextPageXPath(pageURL, expr string, err error) error {
+	return &XPathError{
+		Operation: "extract",
+		URL:       pageURL,
+		XPathExpr: expr,
+		Details:   "Invalid next page XPath expression",
+		Err:       err,
+	}
 }
 
 // extractItemFromHTMLNode extracts a gofeed.Item from an HTML node
@@ -886,46 +999,8 @@ func (f *Fetcher) extractItemFromHTMLNode(item *html.Node, feed *models.Feed) *g
 	// Extract timestamp
 	if feed.XPathItemTimestamp != "" {
 		if timeNode := htmlquery.FindOne(item, feed.XPathItemTimestamp); timeNode != nil {
-			timeStr := strings.TrimSpace(htmlquery.InnerText(timeNode))
-			// Remove icon text if present (e.g., "calendar_month 2025-12" -> "2025-12")
-			if strings.Contains(timeStr, " ") {
-				parts := strings.Split(timeStr, " ")
-				// Find the date part (usually the last part that looks like a date)
-				for i := len(parts) - 1; i >= 0; i-- {
-					part := strings.TrimSpace(parts[i])
-					if part != "" && (strings.Contains(part, "-") || strings.Contains(part, "/") || len(part) >= 4) {
-						timeStr = part
-						break
-					}
-				}
-			}
-			if timeStr != "" {
-				var parsedTime time.Time
-				var err error
-				if feed.XPathItemTimeFormat != "" {
-					parsedTime, err = time.Parse(feed.XPathItemTimeFormat, timeStr)
-				} else {
-					// Try common formats
-					formats := []string{
-						time.RFC3339,
-						time.RFC1123,
-						"2006-01-02T15:04:05Z07:00",
-						"2006-01-02 15:04:05",
-						"2006-01-02",
-						"2006/01/02",
-						"01/02/2006",
-						"2006-01",
-					}
-					for _, format := range formats {
-						parsedTime, err = time.Parse(format, timeStr)
-						if err == nil {
-							break
-						}
-					}
-				}
-				if err == nil {
-					gofeedItem.PublishedParsed = &parsedTime
-				}
+			if parsedTime, ok := parseXPathTimestamp(htmlquery.InnerText(timeNode), feed.XPathItemTimeFormat); ok {
+				gofeedItem.PublishedParsed = &parsedTime
 			}
 		}
 	}
@@ -1063,31 +1138,8 @@ func (f *Fetcher) extractItemFromXMLNode(item *xmlquery.Node, feed *models.Feed)
 	// Extract timestamp
 	if feed.XPathItemTimestamp != "" {
 		if timeNode := xmlquery.FindOne(item, feed.XPathItemTimestamp); timeNode != nil {
-			timeStr := strings.TrimSpace(timeNode.InnerText())
-			if timeStr != "" {
-				var parsedTime time.Time
-				var err error
-				if feed.XPathItemTimeFormat != "" {
-					parsedTime, err = time.Parse(feed.XPathItemTimeFormat, timeStr)
-				} else {
-					// Try common formats
-					formats := []string{
-						time.RFC3339,
-						time.RFC1123,
-						"2006-01-02T15:04:05Z07:00",
-						"2006-01-02 15:04:05",
-						"2006-01-02",
-					}
-					for _, format := range formats {
-						parsedTime, err = time.Parse(format, timeStr)
-						if err == nil {
-							break
-						}
-					}
-				}
-				if err == nil {
-					gofeedItem.PublishedParsed = &parsedTime
-				}
+			if parsedTime, ok := parseXPathTimestamp(timeNode.InnerText(), feed.XPathItemTimeFormat); ok {
+				gofeedItem.PublishedParsed = &parsedTime
 			}
 		}
 	}
@@ -8,6 +8,7 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
@@ -21,6 +22,7 @@ const (
 	TaskReasonScheduledCustom                   // Scheduled refresh with custom interval
 	TaskReasonScheduledGlobal                   // Global refresh
 	TaskReasonArticleClick                      // Article content missing
+	TaskReasonNewMail                           // IMAP IDLE reported new newsletter mail
 )
 
 // RefreshTask represents a single feed refresh task
@@ -28,6 +30,7 @@ type RefreshTask struct {
 	Feed      models.Feed
 	Reason    TaskReason
 	CreatedAt time.Time
+	Host      string // Host the refresh holds a slot of, empty when not limited
 }
 
 // TaskManager manages the task queue and pool for feed refreshing
@@ -35,7 +38,8 @@ type TaskManager struct {
 	fetcher *Fetcher
 
 	// Double-ended queue for pending tasks
-	queue      []int64 // Feed IDs only for efficient storage
+	queue      []int64          // Feed IDs only for efficient storage
+	queueHosts map[int64]string // Host of each queued feed
 	queueMutex sync.RWMutex
 
 	// Task pool for active tasks (limited capacity)
@@ -46,6 +50,12 @@ type TaskManager struct {
 	poolCapacity int
 	poolSem      chan struct{} // Semaphore for pool capacity
 
+	// Per-host concurrency, spacing and backoff
+	hosts     *hostLimits
+	wakeTimer *time.Timer // Resumes processing once a waiting host allows a request
+	wakeAt    time.Time
+	wakeMutex sync.Mutex
+
 	// State tracking
 	isRunning  bool
 	isStopped  bool
@@ -81,9 +91,11 @@ func NewTaskManager(fetcher *Fetcher, poolCapacity int) *TaskManager {
 	tm := &TaskManager{
 		fetcher:      fetcher,
 		queue:        make([]int64, 0),
+		queueHosts:   make(map[int64]string),
 		pool:         make(map[int64]*RefreshTask),
 		poolCapacity: poolCapacity,
 		poolSem:      make(chan struct{}, poolCapacity),
+		hosts:        newHostLimits(),
 		stopChan:     make(chan struct{}),
 	}
 
@@ -141,12 +153,20 @@ func (tm *TaskManager) Stop() {
 	// Signal stop
 	close(tm.stopChan)
 
+	tm.wakeMutex.Lock()
+	if tm.wakeTimer != nil {
+		tm.wakeTimer.Stop()
+		tm.wakeTimer = nil
+	}
+	tm.wakeMutex.Unlock()
+
 	// Wait for all workers to complete
 	tm.wg.Wait()
 
 	// Clear state
 	tm.queueMutex.Lock()
 	tm.queue = make([]int64, 0)
+	tm.queueHosts = make(map[int64]string)
 	tm.queueMutex.Unlock()
 
 	log.Println("Task manager stopped")
@@ -173,7 +193,7 @@ func (tm *TaskManager) MarkCompleted() {
 }
 
 // AddToQueueHead adds a task to the queue head (highest priority)
-// Used for: manual add, manual refresh
+// Used for: manual add, manual refresh, new newsletter mail
 func (tm *TaskManager) AddToQueueHead(ctx context.Context, feed models.Feed, reason TaskReason) {
 	// Skip FreshRSS feeds - they are refreshed via sync, not standard refresh
 	if feed.IsFreshRSSSource {
@@ -198,6 +218,8 @@ func (tm *TaskManager) AddToQueueHead(ctx context.Context, feed models.Feed, rea
 	}
 	tm.progressMutex.Unlock()
 
+	host := tm.fetcher.feedHost(feed)
+
 	// Check if already in pool first (before acquiring queue lock)
 	tm.poolMutex.RLock()
 	inPool := tm.pool[feed.ID] != nil
@@ -207,12 +229,16 @@ func (tm *TaskManager) AddToQueueHead(ctx context.Context, feed models.Feed, rea
 	// Lock order: always pool check first, then queue operations
 	tm.queueMutex.Lock()
 	removed := removeFromQueue(&tm.queue, feed.ID)
+	if removed {
+		delete(tm.queueHosts, feed.ID)
+	}
 
 	// Only add if not in pool
 	var added bool
 	if !inPool {
 		// Add to queue head
 		tm.queue = append([]int64{feed.ID}, tm.queue...)
+		tm.queueHosts[feed.ID] = host
 		added = true
 	}
 
@@ -226,6 +252,7 @@ func (tm *TaskManager) AddToQueueHead(ctx context.Context, feed models.Feed, rea
 			log.Printf("Added feed %s to queue head (reason: %d)", feed.Title, reason)
 		}
 		tm.logOperation("AF", feed.Title)
+		tm.markQueued(feed.ID)
 	} else {
 		log.Printf("Feed %s already in pool, ignoring (reason: %d)", feed.Title, reason)
 		return
@@ -264,6 +291,8 @@ func (tm *TaskManager) AddToQueueTail(ctx context.Context, feed models.Feed, rea
 	}
 	tm.progressMutex.Unlock()
 
+	host := tm.fetcher.feedHost(feed)
+
 	// Check if already in pool first (before acquiring queue lock)
 	// Lock order: always check pool first, then queue
 	tm.poolMutex.RLock()
@@ -278,6 +307,7 @@ func (tm *TaskManager) AddToQueueTail(ctx context.Context, feed models.Feed, rea
 	var added bool
 	if !inQueue && !inPool {
 		tm.queue = append(tm.queue, feed.ID)
+		tm.queueHosts[feed.ID] = host
 		added = true
 	}
 
@@ -287,6 +317,7 @@ func (tm *TaskManager) AddToQueueTail(ctx context.Context, feed models.Feed, rea
 	if added {
 		log.Printf("Added feed %s to queue tail (reason: %d)", feed.Title, reason)
 		tm.logOperation("AR", feed.Title)
+		tm.markQueued(feed.ID)
 	} else {
 		if inQueue {
 			log.Printf("Feed %s already in queue, ignoring (reason: %d)", feed.Title, reason)
@@ -368,6 +399,12 @@ func (tm *TaskManager) AddGlobalRefresh(ctx context.Context, feeds []models.Feed
 		log.Printf("Failed to clear all feed errors: %v", err)
 	}
 
+	// Resolve hosts before taking the queue lock, RSSHub routes read settings
+	hosts := make(map[int64]string, len(feeds))
+	for _, feed := range feeds {
+		hosts[feed.ID] = tm.fetcher.feedHost(feed)
+	}
+
 	// Add feeds to queue tail with deduplication
 	// Lock order: always check pool first, then queue
 	existingFeedIDs := make(map[int64]bool)
@@ -389,6 +426,7 @@ func (tm *TaskManager) AddGlobalRefresh(ctx context.Context, feeds []models.Feed
 	for _, feed := range feeds {
 		if !existingFeedIDs[feed.ID] {
 			tm.queue = append(tm.queue, feed.ID)
+			tm.queueHosts[feed.ID] = hosts[feed.ID]
 			existingFeedIDs[feed.ID] = true
 			addedCount++
 			addedFeeds = append(addedFeeds, feed)
@@ -398,10 +436,13 @@ func (tm *TaskManager) AddGlobalRefresh(ctx context.Context, feeds []models.Feed
 	tm.queueMutex.Unlock()
 
 	// Log operations after releasing locks to avoid deadlock
+	addedIDs := make([]int64, 0, len(addedFeeds))
 	for _, feed := range addedFeeds {
 		log.Printf("Added feed %s to queue tail (global refresh)", feed.Title)
 		tm.logOperation("AR", feed.Title)
+		addedIDs = append(addedIDs, feed.ID)
 	}
+	tm.markQueued(addedIDs...)
 
 	log.Printf("Added %d feeds to queue tail for global refresh", addedCount)
 
@@ -428,6 +469,7 @@ func (tm *TaskManager) ExecuteImmediately(ctx context.Context, feed models.Feed)
 	// Remove from queue if present
 	tm.queueMutex.Lock()
 	removedFromQueue := removeFromQueue(&tm.queue, feed.ID)
+	delete(tm.queueHosts, feed.ID)
 	tm.queueMutex.Unlock()
 
 	// Remove from pool if present
@@ -486,20 +528,21 @@ func (tm *TaskManager) ExecuteImmediately(ctx context.Context, feed models.Feed)
 		ctx1, cancel1 := context.WithTimeout(ctx, 10*time.Second)
 		defer cancel1()
 
-		err = tm.fetcher.fetchFeedWithContext(ctx1, task.Feed)
+		err = tm.fetcher.fetchFeedWithContext(withRefreshContext(ctx1, ctx), task.Feed)
 		if err == nil {
 			success = true
 			log.Printf("Successfully fetched feed: %s (immediate, first attempt)", task.Feed.Title)
 		}
 
-		// Second attempt: use configured retry timeout if first attempt failed
-		if !success && err != nil {
+		// Second attempt: use configured retry timeout if first attempt failed,
+		// unless the server asked us to slow down
+		if _, _, throttled := throttleOf(err); !success && err != nil && !throttled {
 			log.Printf("First attempt failed for %s: %v, retrying with %v timeout", task.Feed.Title, err, retryTimeout)
 
 			ctx2, cancel2 := context.WithTimeout(ctx, retryTimeout)
 			defer cancel2()
 
-			err = tm.fetcher.fetchFeedWithContext(ctx2, task.Feed)
+			err = tm.fetcher.fetchFeedWithContext(withRefreshContext(ctx2, ctx), task.Feed)
 			if err == nil {
 				success = true
 				log.Printf("Successfully fetched feed: %s (immediate, second attempt)", task.Feed.Title)
@@ -509,8 +552,11 @@ func (tm *TaskManager) ExecuteImmediately(ctx context.Context, feed models.Feed)
 		// Handle result
 		if err != nil {
 			log.Printf("Failed to fetch feed %s (immediate): %v", task.Feed.Title, err)
+			tm.recordThrottle(tm.fetcher.feedHost(task.Feed), err)
 			tm.fetcher.db.UpdateFeedError(task.Feed.ID, err.Error())
 			tm.fetcher.db.UpdateFeedLastUpdated(task.Feed.ID)
+			tm.fetcher.recordFeedHealth(task.Feed, err)
+			tm.fetcher.recordRefreshAttempt(task.Feed, err)
 
 			tm.progressMutex.Lock()
 			if tm.progress.Errors == nil {
@@ -521,6 +567,8 @@ func (tm *TaskManager) ExecuteImmediately(ctx context.Context, feed models.Feed)
 		} else {
 			tm.fetcher.db.UpdateFeedError(task.Feed.ID, "")
 			tm.fetcher.db.UpdateFeedLastUpdated(task.Feed.ID)
+			tm.fetcher.recordFeedHealth(task.Feed, nil)
+			tm.fetcher.recordRefreshAttempt(task.Feed, nil)
 		}
 	}()
 
@@ -532,6 +580,8 @@ func (tm *TaskManager) ExecuteImmediately(ctx context.Context, feed models.Feed)
 
 // processQueue processes tasks from the queue
 func (tm *TaskManager) processQueue(ctx context.Context) {
+	tm.fetcher.loadHostLimits(tm.hosts)
+
 	for {
 		// Check if stopped
 		select {
@@ -548,16 +598,46 @@ func (tm *TaskManager) processQueue(ctx context.Context) {
 		poolAtCapacity := len(tm.pool) >= tm.poolCapacity
 		tm.poolMutex.Unlock()
 
+		// Take the first queued feed whose host allows a request now. Feeds of
+		// hosts in backoff are dropped from the queue rather than held until it ends.
 		tm.queueMutex.Lock()
 		var feedID int64
-		if !poolAtCapacity && len(tm.queue) > 0 {
-			feedID = tm.queue[0]
-			tm.queue = tm.queue[1:]
+		var host string
+		var wake time.Duration
+		var throttled []throttledTask
+		if !poolAtCapacity {
+			now := time.Now()
+			for i := 0; i < len(tm.queue); {
+				id := tm.queue[i]
+				h := tm.queueHosts[id]
+				if until := tm.hosts.backoffUntil(h, now); !until.IsZero() {
+					tm.queue = append(tm.queue[:i], tm.queue[i+1:]...)
+					delete(tm.queueHosts, id)
+					throttled = append(throttled, throttledTask{feedID: id, host: h, until: until})
+					continue
+				}
+				if ok, wait := tm.hosts.tryAcquire(h, now); ok {
+					feedID, host = id, h
+					tm.queue = append(tm.queue[:i], tm.queue[i+1:]...)
+					delete(tm.queueHosts, id)
+					break
+				} else if wait > 0 && (wake == 0 || wait < wake) {
+					wake = wait
+				}
+				i++
+			}
 		}
 		tm.queueMutex.Unlock()
 
+		for _, skipped := range throttled {
+			tm.skipThrottled(skipped)
+		}
+
 		if feedID == 0 {
-			// No task available or pool is full
+			// No task available, pool is full or every queued host must wait
+			if wake > 0 {
+				tm.scheduleWake(ctx, wake)
+			}
 			tm.checkCompletion()
 			return
 		}
@@ -566,6 +646,7 @@ func (tm *TaskManager) processQueue(ctx context.Context) {
 		feed, err := tm.fetcher.db.GetFeedByID(feedID)
 		if err != nil {
 			log.Printf("Error getting feed %d: %v", feedID, err)
+			tm.hosts.release(host)
 			continue
 		}
 
@@ -574,6 +655,7 @@ func (tm *TaskManager) processQueue(ctx context.Context) {
 			Feed:      *feed,
 			Reason:    TaskReasonScheduledGlobal, // Default reason
 			CreatedAt: time.Now(),
+			Host:      host,
 		}
 
 		// Acquire semaphore FIRST (this will block if pool is at capacity)
@@ -597,11 +679,79 @@ func (tm *TaskManager) processQueue(ctx context.Context) {
 	}
 }
 
+// throttledTask is a queued feed dropped because its host is in backoff
+type throttledTask struct {
+	feedID int64
+	host   string
+	until  time.Time
+}
+
+// skipThrottled records a feed dropped from the queue because its host is in backoff
+func (tm *TaskManager) skipThrottled(skipped throttledTask) {
+	message := fmt.Sprintf("skipped: %s asked to slow down, next refresh after %s", skipped.host, skipped.until.Format(time.RFC3339))
+	title := fmt.Sprintf("%d", skipped.feedID)
+	if feed, err := tm.fetcher.db.GetFeedByID(skipped.feedID); err == nil {
+		title = feed.Title
+	}
+	log.Printf("Skipping feed %s: %s", title, message)
+	tm.logOperation("SK", title)
+
+	tm.fetcher.db.UpdateFeedError(skipped.feedID, message)
+	tm.fetcher.db.UpdateFeedLastUpdated(skipped.feedID)
+	if err := tm.fetcher.db.RescheduleFeed(skipped.feedID, skipped.until); err != nil {
+		log.Printf("Failed to reschedule feed %s: %v", title, err)
+	}
+
+	tm.progressMutex.Lock()
+	if tm.progress.Errors == nil {
+		tm.progress.Errors = make(map[int64]string)
+	}
+	tm.progress.Errors[skipped.feedID] = message
+	tm.progressMutex.Unlock()
+
+	tm.updateStats()
+}
+
+// scheduleWake resumes queue processing after delay, unless an earlier wake is pending
+func (tm *TaskManager) scheduleWake(ctx context.Context, delay time.Duration) {
+	tm.wakeMutex.Lock()
+	defer tm.wakeMutex.Unlock()
+
+	at := time.Now().Add(delay)
+	if tm.wakeTimer != nil {
+		if !tm.wakeAt.After(at) {
+			return
+		}
+		tm.wakeTimer.Stop()
+	}
+	tm.wakeAt = at
+	tm.wakeTimer = time.AfterFunc(delay, func() {
+		tm.wakeMutex.Lock()
+		tm.wakeTimer = nil
+		tm.wakeMutex.Unlock()
+		tm.processQueue(ctx)
+	})
+}
+
+// recordThrottle puts a host into backoff when a fetch error is a 429 or 503 response
+func (tm *TaskManager) recordThrottle(host string, err error) bool {
+	status, retryAfter, ok := throttleOf(err)
+	if !ok {
+		return false
+	}
+	until := tm.hosts.throttled(host, status, retryAfter, time.Now())
+	if host != "" {
+		log.Printf("Host %s answered HTTP %d, backing off until %s", host, status, until.Format(time.RFC3339))
+	}
+	return true
+}
+
 // processTask processes a single task with timeout and retry logic
 func (tm *TaskManager) processTask(ctx context.Context, task *RefreshTask) {
 	defer func() {
-		// Release semaphore
+		// Release semaphore and host slot
 		<-tm.poolSem
+		tm.hosts.release(task.Host)
 		tm.wg.Done()
 
 		// Remove from pool
@@ -634,24 +784,29 @@ func (tm *TaskManager) processTask(ctx context.Context, task *RefreshTask) {
 	defer cancel1()
 
 	log.Printf("Starting first attempt to fetch feed: %s (timeout: 60s)", task.Feed.Title)
-	err = tm.fetcher.fetchFeedWithContext(ctx1, task.Feed)
+	err = tm.fetcher.fetchFeedWithContext(withRefreshContext(ctx1, ctx), task.Feed)
 	if err == nil {
 		success = true
 		log.Printf("Successfully fetched feed: %s (first attempt)", task.Feed.Title)
 	}
 
+	// A server asking us to slow down is not retried, its host goes into backoff
+	throttled := !success && tm.recordThrottle(task.Host, err)
+
 	// Second attempt: use configured retry timeout if first attempt failed
-	if !success && err != nil {
+	if !success && err != nil && !throttled {
 		log.Printf("First attempt failed for %s: %v, retrying with %v timeout", task.Feed.Title, err, retryTimeout)
 		tm.logOperation("RT", task.Feed.Title)
 
 		ctx2, cancel2 := context.WithTimeout(ctx, retryTimeout)
 		defer cancel2()
 
-		err = tm.fetcher.fetchFeedWithContext(ctx2, task.Feed)
+		err = tm.fetcher.fetchFeedWithContext(withRefreshContext(ctx2, ctx), task.Feed)
 		if err == nil {
 			success = true
 			log.Printf("Successfully fetched feed: %s (second attempt)", task.Feed.Title)
+		} else {
+			tm.recordThrottle(task.Host, err)
 		}
 	}
 
@@ -663,6 +818,8 @@ func (tm *TaskManager) processTask(ctx context.Context, task *RefreshTask) {
 		// Update feed error and last_updated in database
 		tm.fetcher.db.UpdateFeedError(task.Feed.ID, err.Error())
 		tm.fetcher.db.UpdateFeedLastUpdated(task.Feed.ID)
+		tm.fetcher.recordFeedHealth(task.Feed, err)
+		tm.fetcher.recordRefreshAttempt(task.Feed, err)
 
 		// Add to progress errors
 		tm.progressMutex.Lock()
@@ -673,9 +830,12 @@ func (tm *TaskManager) processTask(ctx context.Context, task *RefreshTask) {
 		tm.progressMutex.Unlock()
 	} else {
 		tm.logOperation("SC", task.Feed.Title)
+		tm.hosts.succeeded(task.Host)
 		// Clear error on success and update last_updated
 		tm.fetcher.db.UpdateFeedError(task.Feed.ID, "")
 		tm.fetcher.db.UpdateFeedLastUpdated(task.Feed.ID)
+		tm.fetcher.recordFeedHealth(task.Feed, nil)
+		tm.fetcher.recordRefreshAttempt(task.Feed, nil)
 	}
 }
 
@@ -712,10 +872,31 @@ func (tm *TaskManager) GetProgress() Progress {
 	tm.progressMutex.Lock()
 	defer tm.progressMutex.Unlock()
 
-	return Progress{
+	progress := Progress{
 		IsRunning: tm.progress.IsRunning,
 		Errors:    tm.progress.Errors,
 	}
+	if tm.progress.Sync != nil {
+		syncProgress := *tm.progress.Sync
+		progress.Sync = &syncProgress
+	}
+	return progress
+}
+
+// SetSyncProgress updates the progress of a running FreshRSS sync
+func (tm *TaskManager) SetSyncProgress(syncProgress SyncProgress) {
+	tm.progressMutex.Lock()
+	defer tm.progressMutex.Unlock()
+
+	tm.progress.Sync = &syncProgress
+}
+
+// ClearSyncProgress removes the FreshRSS sync progress
+func (tm *TaskManager) ClearSyncProgress() {
+	tm.progressMutex.Lock()
+	defer tm.progressMutex.Unlock()
+
+	tm.progress.Sync = nil
 }
 
 // GetStats returns the current statistics
@@ -835,6 +1016,31 @@ func (tm *TaskManager) GetQueueTasks(limit int) []QueueTaskInfo {
 	return tasks
 }
 
+// GetHostStates returns the refresh state of the hosts with refreshes in
+// progress, queued or in backoff, sorted by host
+func (tm *TaskManager) GetHostStates() []HostInfo {
+	hosts := tm.hosts.snapshot(time.Now())
+
+	tm.queueMutex.RLock()
+	queued := make(map[string]int)
+	for _, id := range tm.queue {
+		if host := tm.queueHosts[id]; host != "" {
+			queued[host]++
+		}
+	}
+	tm.queueMutex.RUnlock()
+
+	for i := range hosts {
+		hosts[i].Queued = queued[hosts[i].Host]
+		delete(queued, hosts[i].Host)
+	}
+	for host, count := range queued {
+		hosts = append(hosts, HostInfo{Host: host, Queued: count})
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Host < hosts[j].Host })
+	return hosts
+}
+
 // PoolTaskInfo contains information about a task in the pool
 type PoolTaskInfo struct {
 	FeedID    int64      `json:"feed_id"`
@@ -883,10 +1089,24 @@ func (tm *TaskManager) ClearQueue() {
 	defer tm.queueMutex.Unlock()
 
 	tm.queue = make([]int64, 0)
+	tm.queueHosts = make(map[int64]string)
+	if err := tm.fetcher.db.ClearQueuedFeeds(); err != nil {
+		log.Printf("Failed to clear queued refreshes: %v", err)
+	}
 
 	log.Println("Queue cleared")
 }
 
+// markQueued persists that feeds were queued, so the queue survives a restart
+func (tm *TaskManager) markQueued(feedIDs ...int64) {
+	if len(feedIDs) == 0 {
+		return
+	}
+	if err := tm.fetcher.db.MarkFeedsQueued(feedIDs, time.Now()); err != nil {
+		log.Printf("Failed to persist queued refreshes: %v", err)
+	}
+}
+
 // updateStats updates the statistics
 func (tm *TaskManager) updateStats() {
 	tm.poolMutex.RLock()
@@ -991,9 +1211,10 @@ func (tm *TaskManager) initTaskLog() {
 }
 
 // logOperation logs a task operation with the specified format
-// Format: AF/AR/MV/RT/SC/FL n/m name
+// Format: AF/AR/MV/RT/SC/FL/SK n/m name
 // AF = Add to Front (queue head), AR = Add to Rear (queue tail)
 // MV = Move to Pool, RT = Retry, SC = Success, FL = Failure
+// SK = Skipped (host in backoff)
 // n = pool task count, m = queue task count
 func (tm *TaskManager) logOperation(operation string, feedName string) {
 	if !tm.logEnabled || tm.logFile == nil {
@@ -0,0 +1,295 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils"
+	"MrRSS/internal/utils/httputil"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/mmcdole/gofeed"
+	"golang.org/x/net/html"
+)
+
+const (
+	// DefaultXPathMaxPages is the number of listing pages followed when a feed
+	// has a next page expression but no page limit
+	DefaultXPathMaxPages = 5
+	// MaxXPathPages caps the page limit of a feed
+	MaxXPathPages = 50
+	// xpathDetailConcurrency limits how many item pages are fetched at once
+	xpathDetailConcurrency = 4
+)
+
+// xpathPageLimit returns how many listing pages to read for a feed
+func xpathPageLimit(feed *models.Feed) int {
+	if feed.XPathNextPage == "" {
+		return 1
+	}
+	if feed.XPathMaxPages <= 0 {
+		return DefaultXPathMaxPages
+	}
+	return min(feed.XPathMaxPages, MaxXPathPages)
+}
+
+// hasXPathDetail reports whether items need their own page fetched
+func hasXPathDetail(feed *models.Feed) bool {
+	return feed.XPathDetailContent != "" || feed.XPathDetailAuthor != "" || feed.XPathDetailTimestamp != ""
+}
+
+// fetchXPathPage downloads a listing or item page
+func fetchXPathPage(ctx context.Context, client *http.Client, pageURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, &XPathError{
+			Operation: "fetch",
+			URL:       pageURL,
+			Details:   "Invalid page URL",
+			Err:       err,
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &XPathError{
+			Operation: "fetch",
+			URL:       pageURL,
+			Details:   "Failed to fetch content. Please check the URL and your network connection",
+			Err:       err,
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &XPathError{
+			Operation: "fetch",
+			URL:       pageURL,
+			Details:   "The server may be unreachable or the page may have moved",
+			Err:       httputil.NewStatusError(resp),
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &XPathError{
+			Operation: "fetch",
+			URL:       pageURL,
+			Details:   "Failed to read response body",
+			Err:       err,
+		}
+	}
+	return body, nil
+}
+
+// htmlLinkValue returns the href of an element, or the text of an attribute or text node
+func htmlLinkValue(node *html.Node) string {
+	if href := htmlquery.SelectAttr(node, "href"); href != "" {
+		return href
+	}
+	return strings.TrimSpace(htmlquery.InnerText(node))
+}
+
+// resolveXPathURL resolves a link found on a page against the page URL
+func resolveXPathURL(pageURL, link string) string {
+	if link == "" {
+		return ""
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return link
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// parseXPathTimestamp parses an extracted timestamp with the feed's time format,
+// or with common formats when none is set
+func parseXPathTimestamp(value, format string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, false
+	}
+	candidates := []string{value}
+	// Also try without icon text (e.g., "calendar_month 2025-12" -> "2025-12")
+	if strings.Contains(value, " ") {
+		parts := strings.Split(value, " ")
+		// Find the date part (usually the last part that looks like a date)
+		for i := len(parts) - 1; i >= 0; i-- {
+			part := strings.TrimSpace(parts[i])
+			if part != "" && (strings.Contains(part, "-") || strings.Contains(part, "/") || len(part) >= 4) {
+				candidates = append(candidates, part)
+				break
+			}
+		}
+	}
+
+	formats := []string{format}
+	if format == "" {
+		// Try common formats
+		formats = []string{
+			time.RFC3339,
+			time.RFC1123,
+			"2006-01-02T15:04:05Z07:00",
+			"2006-01-02 15:04:05",
+			"2006-01-02",
+			"2006/01/02",
+			"01/02/2006",
+			"2006-01",
+		}
+	}
+	for _, candidate := range candidates {
+		for _, layout := range formats {
+			if t, err := time.Parse(layout, candidate); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// fetchXPathDetails completes items from their own pages. Items whose content is
+// already cached in article_contents are restored from the database instead.
+func (f *Fetcher) fetchXPathDetails(ctx context.Context, client *http.Client, feed *models.Feed, items []*gofeed.Item) {
+	sem := make(chan struct{}, xpathDetailConcurrency)
+	var wg sync.WaitGroup
+	for _, item := range items {
+		// Generated links point back to the listing, there is no item page
+		if item.Link == "" || strings.Contains(item.Link, "#xpath-") {
+			continue
+		}
+		if f.restoreCachedXPathDetail(feed, item) {
+			continue
+		}
+
+		wg.Add(1)
+		go func(item *gofeed.Item) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			if err := fetchXPathDetail(ctx, client, feed, item); err != nil {
+				utils.DebugLog("fetchXPathDetails: Keeping listing data for %s: %v", item.Link, err)
+			}
+		}(item)
+	}
+	wg.Wait()
+}
+
+// restoreCachedXPathDetail fills an item from its stored article, so its page isn't fetched
+// again and the article keeps the author and date taken from that page
+func (f *Fetcher) restoreCachedXPathDetail(feed *models.Feed, item *gofeed.Item) bool {
+	if f.db == nil || feed.ID == 0 {
+		return false
+	}
+	article, content, found, err := f.db.GetCachedArticleByURL(feed.ID, item.Link)
+	if err != nil || !found {
+		return false
+	}
+
+	if feed.XPathDetailContent != "" {
+		item.Content = content
+	}
+	if feed.XPathDetailAuthor != "" && article.Author != "" {
+		item.Author = &gofeed.Person{Name: article.Author}
+	}
+	if feed.XPathDetailTimestamp != "" && item.PublishedParsed == nil && article.HasValidPublishedTime {
+		publishedAt := article.PublishedAt
+		item.PublishedParsed = &publishedAt
+	}
+	return true
+}
+
+// fetchXPathDetail fetches an item's page and applies the detail expressions to it
+func fetchXPathDetail(ctx context.Context, client *http.Client, feed *models.Feed, item *gofeed.Item) error {
+	body, err := fetchXPathPage(ctx, client, item.Link)
+	if err != nil {
+		return err
+	}
+	doc, err := htmlquery.Parse(bytes.NewReader(body))
+	if err != nil {
+		return &XPathError{
+			Operation: "parse",
+			URL:       item.Link,
+			Details:   "Failed to parse the item page as HTML",
+			Err:       err,
+		}
+	}
+
+	content, err := queryXPathDetail(doc, feed.XPathDetailContent, item.Link)
+	if err != nil {
+		return err
+	}
+	author, err := queryXPathDetail(doc, feed.XPathDetailAuthor, item.Link)
+	if err != nil {
+		return err
+	}
+	timestamp, err := queryXPathDetail(doc, feed.XPathDetailTimestamp, item.Link)
+	if err != nil {
+		return err
+	}
+
+	if content != nil {
+		item.Content = htmlquery.OutputHTML(content, true)
+	}
+	if author != nil {
+		if name := strings.TrimSpace(htmlquery.InnerText(author)); name != "" {
+			item.Author = &gofeed.Person{Name: name}
+		}
+	}
+	if timestamp != nil {
+		if t, ok := parseXPathTimestamp(htmlquery.InnerText(timestamp), feed.XPathItemTimeFormat); ok {
+			item.PublishedParsed = &t
+		}
+	}
+	return nil
+}
+
+// queryXPathDetail returns the first node of an item page matching a detail expression,
+// nil if the expression is empty or matches nothing
+func queryXPathDetail(doc *html.Node, expr, pageURL string) (*html.Node, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	node, err := htmlquery.Query(doc, expr)
+	if err != nil {
+		return nil, &XPathError{
+			Operation: "extract",
+			URL:       pageURL,
+			XPathExpr: expr,
+			Details:   "Invalid detail XPath expression",
+			Err:       err,
+		}
+	}
+	return node, nil
+}
+
+// ValidateXPath checks that an XPath expression compiles; empty expressions are valid
+func ValidateXPath(expr string) error {
+	if expr == "" {
+		return nil
+	}
+	if _, err := xpath.Compile(expr); err != nil {
+		return &XPathError{
+			Operation: "validate",
+			XPathExpr: expr,
+			Details:   "Invalid XPath expression",
+			Err:       err,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,407 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils/httputil"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
+	"github.com/mmcdole/gofeed"
+	"golang.org/x/net/html"
+)
+
+const (
+	// previewMaxItems limits the items returned by a preview
+	previewMaxItems = 50
+	// previewMaxSamples limits the timestamp values reported as unparsable
+	previewMaxSamples = 5
+	// minSuggestRepeats is how often a structure must repeat to be suggested as items
+	minSuggestRepeats = 3
+	// maxSuggestions limits the suggested item expressions
+	maxSuggestions = 5
+)
+
+// XPathPreview is the result of applying a feed's XPath expressions to one page.
+type XPathPreview struct {
+	ItemCount       int                `json:"item_count"`
+	Items           []*gofeed.Item     `json:"items"`
+	Fields          []XPathFieldReport `json:"fields"`
+	Warnings        []string           `json:"warnings"`
+	TimestampErrors []string           `json:"timestamp_errors"` // Extracted values the time format could not parse
+	NextPage        string             `json:"next_page,omitempty"`
+	Suggestions     []XPathSuggestion  `json:"suggestions,omitempty"`
+}
+
+// XPathFieldReport tells how one expression matched.
+type XPathFieldReport struct {
+	Field      string `json:"field"`
+	Expression string `json:"expression"`
+	Matches    int    `json:"matches"`         // Items the expression matched in (page matches for item and next page)
+	Error      string `json:"error,omitempty"` // Set when the expression is invalid
+}
+
+// XPathSuggestion is an item expression found from repeated page structure.
+type XPathSuggestion struct {
+	Expression string `json:"expression"`
+	Matches    int    `json:"matches"`
+	Sample     string `json:"sample"` // Text of the first match's link
+}
+
+// xpathItemFields lists the per-item expressions of a feed in form order
+func xpathItemFields(feed *models.Feed) []XPathFieldReport {
+	return []XPathFieldReport{
+		{Field: "xpath_item_title", Expression: feed.XPathItemTitle},
+		{Field: "xpath_item_content", Expression: feed.XPathItemContent},
+		{Field: "xpath_item_uri", Expression: feed.XPathItemUri},
+		{Field: "xpath_item_author", Expression: feed.XPathItemAuthor},
+		{Field: "xpath_item_timestamp", Expression: feed.XPathItemTimestamp},
+		{Field: "xpath_item_thumbnail", Expression: feed.XPathItemThumbnail},
+		{Field: "xpath_item_categories", Expression: feed.XPathItemCategories},
+		{Field: "xpath_item_uid", Expression: feed.XPathItemUid},
+	}
+}
+
+// clearXPathField drops an invalid expression, extraction panics on those
+func clearXPathField(feed *models.Feed, field string) {
+	switch field {
+	case "xpath_item_title":
+		feed.XPathItemTitle = ""
+	case "xpath_item_content":
+		feed.XPathItemContent = ""
+	case "xpath_item_uri":
+		feed.XPathItemUri = ""
+	case "xpath_item_author":
+		feed.XPathItemAuthor = ""
+	case "xpath_item_timestamp":
+		feed.XPathItemTimestamp = ""
+	case "xpath_item_thumbnail":
+		feed.XPathItemThumbnail = ""
+	case "xpath_item_categories":
+		feed.XPathItemCategories = ""
+	case "xpath_item_uid":
+		feed.XPathItemUid = ""
+	}
+}
+
+// xpathPreviewDoc runs expressions on a parsed HTML or XML page
+type xpathPreviewDoc struct {
+	root any
+	// queryAll returns the matches of expr below node
+	queryAll func(node any, expr string) ([]any, error)
+	// text returns the text of a node
+	text func(node any) string
+	// href returns the href attribute of a node, if any
+	href func(node any) string
+	// extract builds the feed item of a node
+	extract func(node any, feed *models.Feed) *gofeed.Item
+}
+
+func (f *Fetcher) newXPathPreviewDoc(feed *models.Feed, body []byte) (*xpathPreviewDoc, error) {
+	switch feed.Type {
+	case "HTML+XPath":
+		doc, err := htmlquery.Parse(bytes.NewReader(body))
+		if err != nil {
+			return nil, &XPathError{Operation: "parse", URL: feed.URL, Details: "Failed to parse HTML", Err: err}
+		}
+		return &xpathPreviewDoc{
+			root: doc,
+			queryAll: func(node any, expr string) ([]any, error) {
+				nodes, err := htmlquery.QueryAll(node.(*html.Node), expr)
+				return toAny(nodes), err
+			},
+			text: func(node any) string { return htmlquery.InnerText(node.(*html.Node)) },
+			href: func(node any) string { return htmlquery.SelectAttr(node.(*html.Node), "href") },
+			extract: func(node any, feed *models.Feed) *gofeed.Item {
+				return f.extractItemFromHTMLNode(node.(*html.Node), feed)
+			},
+		}, nil
+	case "XML+XPath":
+		doc, err := xmlquery.Parse(bytes.NewReader(body))
+		if err != nil {
+			return nil, &XPathError{Operation: "parse", URL: feed.URL, Details: "Failed to parse XML", Err: err}
+		}
+		return &xpathPreviewDoc{
+			root: doc,
+			queryAll: func(node any, expr string) ([]any, error) {
+				nodes, err := xmlquery.QueryAll(node.(*xmlquery.Node), expr)
+				return toAny(nodes), err
+			},
+			text: func(node any) string { return node.(*xmlquery.Node).InnerText() },
+			href: func(node any) string { return node.(*xmlquery.Node).SelectAttr("href") },
+			extract: func(node any, feed *models.Feed) *gofeed.Item {
+				return f.extractItemFromXMLNode(node.(*xmlquery.Node), feed)
+			},
+		}, nil
+	default:
+		return nil, &XPathError{
+			Operation: "validate",
+			Details:   fmt.Sprintf("Unsupported feed type '%s'. Must be 'HTML+XPath' or 'XML+XPath'", feed.Type),
+		}
+	}
+}
+
+func toAny[T any](nodes []T) []any {
+	result := make([]any, len(nodes))
+	for i, n := range nodes {
+		result[i] = n
+	}
+	return result
+}
+
+// PreviewXPath applies a feed's XPath expressions to a page and reports how each one matched.
+// The page at feed.URL is fetched when body is nil.
+func (f *Fetcher) PreviewXPath(ctx context.Context, feed *models.Feed, body []byte) (*XPathPreview, error) {
+	if body == nil {
+		httpClient, err := httputil.CreateHTTPClient("", 30*time.Second)
+		if err != nil {
+			return nil, &XPathError{Operation: "fetch", URL: feed.URL, Details: "Failed to create HTTP client", Err: err}
+		}
+		if body, err = fetchXPathPage(ctx, httpClient, feed.URL); err != nil {
+			return nil, err
+		}
+	}
+
+	doc, err := f.newXPathPreviewDoc(feed, body)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &XPathPreview{
+		Items:           []*gofeed.Item{},
+		Warnings:        []string{},
+		TimestampErrors: []string{},
+	}
+	if feed.Type == "HTML+XPath" {
+		preview.Suggestions = suggestItemXPaths(doc.root.(*html.Node))
+	}
+
+	itemReport := XPathFieldReport{Field: "xpath_item", Expression: feed.XPathItem}
+	var itemNodes []any
+	if feed.XPathItem == "" {
+		itemReport.Error = "Item XPath expression is required"
+	} else if itemNodes, err = doc.queryAll(doc.root, feed.XPathItem); err != nil {
+		itemReport.Error = err.Error()
+	} else if itemReport.Matches = len(itemNodes); len(itemNodes) == 0 {
+		preview.Warnings = append(preview.Warnings, "The item expression matches nothing on the page")
+	}
+	preview.Fields = append(preview.Fields, itemReport)
+	preview.ItemCount = len(itemNodes)
+
+	// Extraction uses a copy without the invalid expressions
+	extractFeed := *feed
+	for _, report := range xpathItemFields(feed) {
+		if report.Expression != "" {
+			f.matchXPathField(doc, &report, itemNodes, feed.XPathItemTimeFormat, preview)
+		}
+		if report.Error != "" {
+			clearXPathField(&extractFeed, report.Field)
+		} else if report.Expression != "" && len(itemNodes) > 0 {
+			switch {
+			case report.Matches == 0:
+				preview.Warnings = append(preview.Warnings, fmt.Sprintf("%s matches none of the %d items", report.Field, len(itemNodes)))
+			case report.Matches < len(itemNodes):
+				preview.Warnings = append(preview.Warnings, fmt.Sprintf("%s matches only %d of the %d items", report.Field, report.Matches, len(itemNodes)))
+			}
+		}
+		preview.Fields = append(preview.Fields, report)
+	}
+
+	if feed.XPathItemTitle == "" {
+		preview.Warnings = append(preview.Warnings, "No title expression, titles are generated from the content")
+	}
+	if feed.XPathItemUri == "" {
+		preview.Warnings = append(preview.Warnings, "No link expression, items get generated links that open the listing page")
+	}
+	if feed.XPathItemTimeFormat != "" && feed.XPathItemTimestamp == "" {
+		preview.Warnings = append(preview.Warnings, "The time format is set but there is no timestamp expression")
+	}
+
+	if feed.XPathNextPage != "" {
+		report := XPathFieldReport{Field: "xpath_next_page", Expression: feed.XPathNextPage}
+		if nodes, err := doc.queryAll(doc.root, feed.XPathNextPage); err != nil {
+			report.Error = err.Error()
+		} else if report.Matches = len(nodes); len(nodes) == 0 {
+			preview.Warnings = append(preview.Warnings, "The next page expression matches nothing, only this page will be read")
+		} else {
+			link := doc.href(nodes[0])
+			if link == "" {
+				link = strings.TrimSpace(doc.text(nodes[0]))
+			}
+			preview.NextPage = resolveXPathURL(feed.URL, link)
+		}
+		preview.Fields = append(preview.Fields, report)
+	}
+
+	for i, node := range itemNodes {
+		if i == previewMaxItems {
+			break
+		}
+		preview.Items = append(preview.Items, doc.extract(node, &extractFeed))
+	}
+	return preview, nil
+}
+
+// matchXPathField counts the items an expression matches in and collects timestamps that don't parse
+func (f *Fetcher) matchXPathField(doc *xpathPreviewDoc, report *XPathFieldReport, itemNodes []any, timeFormat string, preview *XPathPreview) {
+	// Extraction reads the href of the item itself for these
+	ownHref := report.Field == "xpath_item_uri" &&
+		(report.Expression == "./@href" || report.Expression == "@href" || report.Expression == "href")
+
+	// Check the expression even when there are no items to run it on
+	if _, err := doc.queryAll(doc.root, report.Expression); err != nil {
+		report.Error = err.Error()
+		return
+	}
+
+	for _, node := range itemNodes {
+		if ownHref {
+			if doc.href(node) != "" {
+				report.Matches++
+			}
+			continue
+		}
+		matches, err := doc.queryAll(node, report.Expression)
+		if err != nil {
+			report.Error = err.Error()
+			return
+		}
+		if len(matches) == 0 {
+			continue
+		}
+		report.Matches++
+
+		if report.Field == "xpath_item_timestamp" && len(preview.TimestampErrors) < previewMaxSamples {
+			value := strings.TrimSpace(doc.text(matches[0]))
+			if _, ok := parseXPathTimestamp(value, timeFormat); value != "" && !ok {
+				preview.TimestampErrors = append(preview.TimestampErrors, value)
+			}
+		}
+	}
+}
+
+// suggestSkipTags are never proposed as feed items
+var suggestSkipTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true, "head": true,
+	"meta": true, "link": true, "br": true, "hr": true, "option": true,
+	"svg": true, "path": true, "input": true, "td": true, "th": true,
+}
+
+// suggestItemXPaths proposes item expressions for elements that repeat under one parent
+// and contain a link. Candidates with more text come first, so article lists rank above menus.
+func suggestItemXPaths(doc *html.Node) []XPathSuggestion {
+	candidates := make(map[string]bool)
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		groups := make(map[string][]*html.Node)
+		var order []string
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || suggestSkipTags[c.Data] {
+				continue
+			}
+			key := c.Data + "." + firstClass(c)
+			if groups[key] == nil {
+				order = append(order, key)
+			}
+			groups[key] = append(groups[key], c)
+			walk(c)
+		}
+		for _, key := range order {
+			if nodes := groups[key]; len(nodes) >= minSuggestRepeats {
+				if expr := suggestExpression(n, nodes[0]); expr != "" {
+					candidates[expr] = true
+				}
+			}
+		}
+	}
+	walk(doc)
+
+	type scored struct {
+		XPathSuggestion
+		score int
+	}
+	var results []scored
+	for expr := range candidates {
+		nodes, err := htmlquery.QueryAll(doc, expr)
+		if err != nil {
+			continue
+		}
+		var linked, textLen int
+		var sample string
+		for _, node := range nodes {
+			link := node
+			if node.Data != "a" {
+				link = htmlquery.FindOne(node, ".//a[@href]")
+			}
+			if link == nil || htmlquery.SelectAttr(link, "href") == "" {
+				continue
+			}
+			linked++
+			textLen += min(len(strings.TrimSpace(htmlquery.InnerText(node))), 500)
+			if sample == "" {
+				sample = truncateSample(strings.Join(strings.Fields(htmlquery.InnerText(link)), " "))
+			}
+		}
+		if linked < minSuggestRepeats {
+			continue
+		}
+		results = append(results, scored{
+			XPathSuggestion: XPathSuggestion{Expression: expr, Matches: len(nodes), Sample: sample},
+			score:           textLen,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].Expression < results[j].Expression
+	})
+	suggestions := []XPathSuggestion{}
+	for i := 0; i < len(results) && i < maxSuggestions; i++ {
+		suggestions = append(suggestions, results[i].XPathSuggestion)
+	}
+	return suggestions
+}
+
+// suggestExpression addresses an element by its class, or through its parent's id or class
+func suggestExpression(parent, child *html.Node) string {
+	if class := firstClass(child); class != "" {
+		return fmt.Sprintf("//%s[%s]", child.Data, classPredicate(class))
+	}
+	if id := htmlquery.SelectAttr(parent, "id"); id != "" && !strings.Contains(id, "'") {
+		return fmt.Sprintf("//*[@id='%s']/%s", id, child.Data)
+	}
+	if class := firstClass(parent); class != "" && parent.Type == html.ElementNode {
+		return fmt.Sprintf("//%s[%s]/%s", parent.Data, classPredicate(class), child.Data)
+	}
+	return ""
+}
+
+// firstClass returns the first class name of an element
+func firstClass(n *html.Node) string {
+	classes := strings.Fields(htmlquery.SelectAttr(n, "class"))
+	if len(classes) == 0 || strings.Contains(classes[0], "'") {
+		return ""
+	}
+	return classes[0]
+}
+
+// classPredicate matches elements that have a class among others
+func classPredicate(class string) string {
+	return fmt.Sprintf("contains(concat(' ', normalize-space(@class), ' '), ' %s ')", class)
+}
+
+func truncateSample(s string) string {
+	const maxRunes = 80
+	if runes := []rune(s); len(runes) > maxRunes {
+		return string(runes[:maxRunes]) + "…"
+	}
+	return s
+}
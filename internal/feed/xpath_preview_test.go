@@ -0,0 +1,97 @@
+package feed
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"MrRSS/internal/models"
+)
+
+const previewPage = `<html><body>
+	<nav><ul class="menu">
+		<li><a href="/">Home</a></li><li><a href="/about">About</a></li><li><a href="/tags">Tags</a></li>
+	</ul></nav>
+	<main>
+		<article class="post card"><h2><a href="/p/1">First post title</a></h2><p>A long teaser text for the first post</p><time>2024-01-05</time></article>
+		<article class="post card"><h2><a href="/p/2">Second post title</a></h2><p>A long teaser text for the second post</p><time>05.01.2024</time></article>
+		<article class="post card"><h2><a href="/p/3">Third post title</a></h2><p>A long teaser text for the third post</p></article>
+	</main>
+	<a class="next" href="/page/2">Older</a>
+</body></html>`
+
+func TestPreviewXPath(t *testing.T) {
+	feed := &models.Feed{
+		URL:                "https://example.com/blog",
+		Type:               "HTML+XPath",
+		XPathItem:          "//article",
+		XPathItemTitle:     ".//h2",
+		XPathItemUri:       ".//h2/a/@href",
+		XPathItemTimestamp: ".//time",
+		XPathItemAuthor:    ".//span[@class=",
+		XPathNextPage:      "//a[@class='next']",
+	}
+
+	preview, err := (&Fetcher{}).PreviewXPath(context.Background(), feed, []byte(previewPage))
+	if err != nil {
+		t.Fatalf("PreviewXPath() error = %v", err)
+	}
+
+	if preview.ItemCount != 3 || len(preview.Items) != 3 {
+		t.Fatalf("got %d items (%d returned), want 3", preview.ItemCount, len(preview.Items))
+	}
+	if preview.Items[0].Link != "https://example.com/p/1" || preview.Items[0].Title != "First post title" {
+		t.Errorf("first item = %q %q", preview.Items[0].Title, preview.Items[0].Link)
+	}
+
+	fields := make(map[string]XPathFieldReport)
+	for _, f := range preview.Fields {
+		fields[f.Field] = f
+	}
+	if fields["xpath_item"].Matches != 3 || fields["xpath_item_title"].Matches != 3 {
+		t.Errorf("item/title matches = %d/%d", fields["xpath_item"].Matches, fields["xpath_item_title"].Matches)
+	}
+	if fields["xpath_item_timestamp"].Matches != 2 {
+		t.Errorf("timestamp matches = %d, want 2", fields["xpath_item_timestamp"].Matches)
+	}
+	if fields["xpath_item_author"].Error == "" {
+		t.Error("the invalid author expression should report an error")
+	}
+	if _, ok := fields["xpath_item_content"]; !ok {
+		t.Error("empty fields should still be listed")
+	}
+
+	if len(preview.TimestampErrors) != 1 || preview.TimestampErrors[0] != "05.01.2024" {
+		t.Errorf("timestamp errors = %v", preview.TimestampErrors)
+	}
+	if !slices.Contains(preview.Warnings, "xpath_item_timestamp matches only 2 of the 3 items") {
+		t.Errorf("warnings = %v", preview.Warnings)
+	}
+	if preview.NextPage != "https://example.com/page/2" {
+		t.Errorf("next page = %q", preview.NextPage)
+	}
+
+	if len(preview.Suggestions) == 0 {
+		t.Fatal("expected item suggestions")
+	}
+	best := preview.Suggestions[0]
+	if !strings.Contains(best.Expression, "article") || best.Matches != 3 || best.Sample != "First post title" {
+		t.Errorf("best suggestion = %+v, want the article list", best)
+	}
+}
+
+func TestPreviewXPathWithoutItems(t *testing.T) {
+	feed := &models.Feed{Type: "HTML+XPath", XPathItem: "//div[@class='missing']", XPathItemTitle: ".//h2"}
+
+	preview, err := (&Fetcher{}).PreviewXPath(context.Background(), feed, []byte(previewPage))
+	if err != nil {
+		t.Fatalf("PreviewXPath() error = %v", err)
+	}
+	if preview.ItemCount != 0 || !slices.Contains(preview.Warnings, "The item expression matches nothing on the page") {
+		t.Errorf("item count = %d, warnings = %v", preview.ItemCount, preview.Warnings)
+	}
+	if !slices.Contains(preview.Warnings, "No link expression, items get generated links that open the listing page") {
+		t.Errorf("warnings = %v", preview.Warnings)
+	}
+}
@@ -2,7 +2,13 @@ package feed
 
 import (
 	"MrRSS/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -190,3 +196,143 @@ func TestParseFeedWithXPath_XML(t *testing.T) {
 		t.Errorf("Expected GUID '12345', got '%s'", item.GUID)
 	}
 }
+
+func TestParseFeedWithXPath_PaginationAndDetail(t *testing.T) {
+	var detailRequests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/list", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "":
+			fmt.Fprint(w, `<html><body>
+				<div class="post"><a href="/post/1">One</a><p>teaser 1</p></div>
+				<div class="post"><a href="/post/2">Two</a><p>teaser 2</p></div>
+				<a class="next" href="?page=2">Older</a>
+			</body></html>`)
+		case "2":
+			// Post 2 shifted onto this page and the next link loops back
+			fmt.Fprint(w, `<html><body>
+				<div class="post"><a href="/post/2">Two</a><p>teaser 2</p></div>
+				<div class="post"><a href="/post/3">Three</a><p>teaser 3</p></div>
+				<a class="next" href="/list">Newer</a>
+			</body></html>`)
+		default:
+			t.Errorf("unexpected page %q", r.URL.RawQuery)
+		}
+	})
+	mux.HandleFunc("/post/", func(w http.ResponseWriter, r *http.Request) {
+		detailRequests.Add(1)
+		id := strings.TrimPrefix(r.URL.Path, "/post/")
+		fmt.Fprintf(w, `<html><body><article><p>Full text %s</p></article>
+			<span class="by">Author %s</span><time>2024-03-0%s</time></body></html>`, id, id, id)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	feed := &models.Feed{
+		URL:                  server.URL + "/list",
+		Type:                 "HTML+XPath",
+		XPathItem:            "//div[@class='post']",
+		XPathItemTitle:       ".//a",
+		XPathItemContent:     ".//p",
+		XPathItemUri:         ".//a",
+		XPathNextPage:        "//a[@class='next']/@href",
+		XPathDetailContent:   "//article",
+		XPathDetailAuthor:    "//span[@class='by']",
+		XPathDetailTimestamp: "//time",
+	}
+
+	parsed, err := (&Fetcher{}).parseFeedWithXPath(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("parseFeedWithXPath() error = %v", err)
+	}
+	if len(parsed.Items) != 3 {
+		t.Fatalf("got %d items, want 3 without duplicates", len(parsed.Items))
+	}
+	if got := detailRequests.Load(); got != 3 {
+		t.Errorf("fetched %d detail pages, want 3", got)
+	}
+
+	for i, item := range parsed.Items {
+		n := i + 1
+		if item.Link != fmt.Sprintf("%s/post/%d", server.URL, n) {
+			t.Errorf("item %d link = %q", n, item.Link)
+		}
+		if !strings.Contains(item.Content, fmt.Sprintf("Full text %d", n)) {
+			t.Errorf("item %d content = %q, want the detail page content", n, item.Content)
+		}
+		if item.Author == nil || item.Author.Name != fmt.Sprintf("Author %d", n) {
+			t.Errorf("item %d author = %v", n, item.Author)
+		}
+		if item.PublishedParsed == nil || item.PublishedParsed.Day() != n {
+			t.Errorf("item %d date = %v", n, item.PublishedParsed)
+		}
+	}
+}
+
+func TestParseFeedWithXPath_PageLimit(t *testing.T) {
+	var pages atomic.Int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := pages.Add(1)
+		fmt.Fprintf(w, `<html><body><div class="post"><a href="/post/%d">Post %d</a></div>
+			<a class="next" href="%s/page/%d">Next</a></body></html>`, n, n, server.URL, n+1)
+	}))
+	defer server.Close()
+
+	feed := &models.Feed{
+		URL:           server.URL + "/page/1",
+		Type:          "HTML+XPath",
+		XPathItem:     "//div[@class='post']",
+		XPathItemUri:  ".//a",
+		XPathNextPage: "//a[@class='next']",
+		XPathMaxPages: 3,
+	}
+
+	parsed, err := (&Fetcher{}).parseFeedWithXPath(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("parseFeedWithXPath() error = %v", err)
+	}
+	if len(parsed.Items) != 3 || pages.Load() != 3 {
+		t.Errorf("got %d items from %d pages, want 3 from 3", len(parsed.Items), pages.Load())
+	}
+}
+
+func TestParseFeedWithXPath_InvalidPageExpressions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><div class="post"><a href="/post/1">One</a></div>
+			<a class="next" href="/page/2">Next</a></body></html>`)
+	}))
+	defer server.Close()
+
+	// Invalid detail expressions leave the items as they are
+	feed := &models.Feed{
+		URL:                server.URL,
+		Type:               "HTML+XPath",
+		XPathItem:          "//div[@class='post']",
+		XPathItemUri:       ".//a",
+		XPathDetailContent: "//article[",
+	}
+	parsed, err := (&Fetcher{}).parseFeedWithXPath(context.Background(), feed)
+	if err != nil {
+		t.Fatalf("parseFeedWithXPath() error = %v", err)
+	}
+	if len(parsed.Items) != 1 || parsed.Items[0].Content != "" {
+		t.Errorf("got %d items, want 1 without detail content", len(parsed.Items))
+	}
+
+	feed.XPathDetailContent = ""
+	feed.XPathNextPage = "//a[@class='next'"
+	_, err = (&Fetcher{}).parseFeedWithXPath(context.Background(), feed)
+	var xpathErr *XPathError
+	if !errors.As(err, &xpathErr) || xpathErr.XPathExpr != feed.XPathNextPage {
+		t.Errorf("parseFeedWithXPath() error = %v, want an XPathError for the next page expression", err)
+	}
+}
+
+func TestValidateXPath(t *testing.T) {
+	for expr, valid := range map[string]bool{"": true, "//a/@href": true, "//a[": false, "///": false} {
+		if err := ValidateXPath(expr); (err == nil) != valid {
+			t.Errorf("ValidateXPath(%q) = %v, want valid %v", expr, err, valid)
+		}
+	}
+}
@@ -27,6 +27,13 @@ type SyncResult struct {
 type BidirectionalSyncService struct {
 	client *Client
 	db     *database.DB
+
+	// lastSyncTime is the previous successful sync, used as the lower bound of remote change times
+	lastSyncTime time.Time
+	// pendingByArticle indexes unsynced status changes during a sync (see loadPendingChanges)
+	pendingByArticle map[int64][]database.SyncQueueItem
+	// progress receives sync progress updates, may be nil
+	progress ProgressReporter
 }
 
 // NewBidirectionalSyncService creates a new bidirectional sync service
@@ -46,12 +53,25 @@ func (s *BidirectionalSyncService) Sync(ctx context.Context) (*SyncResult, error
 	}
 	startTime := time.Now()
 	defer func() { result.Duration = time.Since(startTime) }()
+	defer s.finishProgress()
 
 	// Stage 1: Login to FreshRSS
 	if err := s.client.Login(ctx); err != nil {
 		return result, fmt.Errorf("login failed: %w", err)
 	}
 
+	s.lastSyncTime = s.getLastSyncTime()
+	s.pendingByArticle = nil
+
+	// Local subscription edits are pushed before pulling so the pull does not revert them
+	subChanges, err := s.pushSubscriptionChanges(ctx)
+	if err != nil {
+		log.Printf("Warning: Failed to push subscription changes: %v", err)
+		result.Errors = append(result.Errors, fmt.Sprintf("push subscriptions failed: %v", err))
+	} else {
+		result.PushChangesCount += subChanges
+	}
+
 	// Stage 2: Pull from server (feeds, articles, starred status, read status)
 	log.Printf("Stage 1: Pull from server")
 	pullChanges, err := s.pullFromServer(ctx)
@@ -68,6 +88,7 @@ func (s *BidirectionalSyncService) Sync(ctx context.Context) (*SyncResult, error
 
 	// Stage 3: Push local changes to server
 	log.Printf("Stage 2: Push to server")
+	s.reportProgress("push", 0, 0)
 	pushChanges, err := s.pushToServer(ctx)
 	if err != nil {
 		log.Printf("Stage 2 ERROR: push failed: %v", err)
@@ -76,16 +97,35 @@ func (s *BidirectionalSyncService) Sync(ctx context.Context) (*SyncResult, error
 	} else {
 		log.Printf("Stage 2 SUCCESS: %d changes pushed", pushChanges)
 		result.PushSuccess = true
-		result.PushChangesCount = pushChanges
+		result.PushChangesCount += pushChanges
 	}
 
+	// Keep the conflict log bounded
+	_ = s.db.DeleteOldSyncConflicts(30 * 24 * time.Hour)
+
 	return result, nil
 }
 
+// getLastSyncTime returns the time of the previous sync, or the zero time if there was none
+func (s *BidirectionalSyncService) getLastSyncTime() time.Time {
+	lastSyncStr, _ := s.db.GetSetting("freshrss_last_sync_time")
+	if lastSyncStr == "" {
+		return time.Time{}
+	}
+	lastSync, err := time.Parse(time.RFC3339, lastSyncStr)
+	if err != nil {
+		return time.Time{}
+	}
+	return lastSync
+}
+
 // SyncFeed syncs articles for a single FreshRSS feed/stream
 // This is called when user right-clicks a FreshRSS feed and selects "Sync Feed"
-// Now fetches ALL articles using pagination, not just a limited number
+// The stream is always checked, but only items crawled since its last sync are listed
+// and only unknown items are downloaded
 func (s *BidirectionalSyncService) SyncFeed(ctx context.Context, streamID string) (int, error) {
+	defer s.finishProgress()
+
 	// Login to FreshRSS
 	if err := s.client.Login(ctx); err != nil {
 		return 0, fmt.Errorf("login failed: %w", err)
@@ -93,23 +133,23 @@ func (s *BidirectionalSyncService) SyncFeed(ctx context.Context, streamID string
 
 	log.Printf("[SyncFeed] Syncing stream: %s", streamID)
 
-	// Fetch all articles from this stream using pagination
-	allArticles, err := s.fetchAllArticles(ctx, streamID, 10000)
-	if err != nil {
-		return 0, fmt.Errorf("fetch articles from stream: %w", err)
-	}
+	s.lastSyncTime = s.getLastSyncTime()
+	s.pendingByArticle = nil
+	s.reportProgress("articles", 0, 1)
 
-	if len(allArticles) == 0 {
-		log.Printf("[SyncFeed] No articles in stream: %s", streamID)
-		return 0, nil
+	var unread *UnreadCount
+	if unreadCounts, err := s.client.GetUnreadCounts(ctx); err == nil {
+		if uc, ok := unreadCounts[streamID]; ok {
+			unread = &uc
+		}
 	}
 
-	// Save articles to database
-	count, err := s.saveArticlesFromServer(ctx, allArticles)
+	count, _, err := s.syncStream(ctx, streamID, unread, true)
 	if err != nil {
-		return 0, fmt.Errorf("save articles: %w", err)
+		return count, fmt.Errorf("sync stream: %w", err)
 	}
 
+	s.reportProgress("articles", 1, 1)
 	log.Printf("[SyncFeed] Synced %d articles for stream: %s", count, streamID)
 	return count, nil
 }
@@ -171,11 +211,14 @@ func (s *BidirectionalSyncService) SyncArticleStatus(ctx context.Context, articl
 }
 
 // pullFromServer pulls changes from FreshRSS server
+// Articles are pulled incrementally per stream (see syncStream); read and starred
+// state is applied from the server's ID lists without downloading contents
 func (s *BidirectionalSyncService) pullFromServer(ctx context.Context) (int, error) {
 	totalChanges := 0
 	log.Printf("pullFromServer: Starting pull from server")
 
 	// Step 1: Get subscriptions and create feeds
+	s.reportProgress("subscriptions", 0, 0)
 	subscriptions, err := s.client.GetSubscriptions(ctx)
 	if err != nil {
 		log.Printf("Warning: Failed to get subscriptions: %v", err)
@@ -194,95 +237,55 @@ func (s *BidirectionalSyncService) pullFromServer(ctx context.Context) (int, err
 			log.Printf("Created/updated %d feeds from FreshRSS", feedsCreated)
 		}
 
-		// Step 2: Get articles from each subscription (no limit - fetch all articles)
-		articlesPerFeed := 10000 // Increased from 100 to fetch more articles per feed
-		totalArticles := 0
-
-		for _, sub := range subscriptions {
-			feedURL := strings.TrimPrefix(sub.ID, "feed/")
-
-			// Fetch all articles using pagination
-			continuation := ""
-			feedArticleCount := 0
-			for {
-				result, err := s.client.GetStreamContents(ctx, sub.ID, nil, articlesPerFeed, continuation)
-				if err != nil {
-					log.Printf("Warning: Failed to get articles for feed %s: %v", feedURL, err)
-					break
-				}
+		// Step 2: Pull new articles, skipping streams whose unread state did not change
+		unreadCounts, err := s.client.GetUnreadCounts(ctx)
+		if err != nil {
+			log.Printf("Warning: Failed to get unread counts, checking every stream: %v", err)
+		}
 
-				if len(result.Items) > 0 {
-					saved, err := s.saveArticlesFromServer(ctx, result.Items)
-					if err != nil {
-						log.Printf("Warning: Failed to save articles for feed %s: %v", feedURL, err)
-					} else {
-						totalArticles += saved
-						feedArticleCount += saved
-					}
-				}
+		totalArticles := 0
+		skippedStreams := 0
+		for i, sub := range subscriptions {
+			s.reportProgress("articles", i+1, len(subscriptions))
 
-				// Check if there are more articles to fetch
-				if result.Continuation == "" {
-					break
-				}
-				continuation = result.Continuation
-				log.Printf("Fetched %d articles for feed %s, continuing with pagination...", feedArticleCount, feedURL)
-				time.Sleep(50 * time.Millisecond)
+			var unread *UnreadCount
+			if uc, ok := unreadCounts[sub.ID]; ok {
+				unread = &uc
 			}
 
-			log.Printf("Total articles fetched for feed %s: %d", feedURL, feedArticleCount)
-			time.Sleep(50 * time.Millisecond)
+			saved, skipped, err := s.syncStream(ctx, sub.ID, unread, false)
+			if err != nil {
+				log.Printf("Warning: Failed to sync articles for stream %s: %v", sub.ID, err)
+			}
+			if skipped {
+				skippedStreams++
+			}
+			totalArticles += saved
 		}
 
 		totalChanges += totalArticles
-		log.Printf("Saved %d total articles from %d feeds", totalArticles, len(subscriptions))
+		log.Printf("Saved %d new articles from %d feeds (%d unchanged feeds skipped)",
+			totalArticles, len(subscriptions), skippedStreams)
 	} else {
 		log.Printf("No subscriptions to sync from FreshRSS")
 	}
 
-	// Step 3: Apply starred status from server (fetch all with pagination)
+	// Step 3: Apply starred status from server
 	log.Printf("pullFromServer: Step 3 - Applying starred status")
-	starredArticles, err := s.fetchAllArticles(ctx, "user/-/state/com.google/starred", 10000)
-	if err != nil {
-		log.Printf("Warning: Failed to get starred articles: %v", err)
-	} else {
-		for _, article := range starredArticles {
-			_, err := s.applyServerStatus(article.URL, true, "is_favorite")
-			if err != nil {
-				log.Printf("Warning: Failed to apply starred status for %s: %v", article.URL, err)
-			}
-		}
-		log.Printf("Applied starred status to %d articles from server", len(starredArticles))
-	}
-
-	// Step 4: Apply read status from server using unread count API (fetch all with pagination)
-	log.Printf("pullFromServer: Step 4 - Applying read status using unread count API")
-
-	// First, get unread counts from server to verify sync
-	unreadCounts, err := s.client.GetUnreadCount(ctx)
+	starredChanges, err := s.applyStarredState(ctx)
 	if err != nil {
-		log.Printf("Warning: Failed to get unread counts: %v", err)
+		log.Printf("Warning: Failed to apply starred status: %v", err)
 	} else {
-		// Log unread counts for debugging
-		for streamID, count := range unreadCounts {
-			if count > 0 {
-				log.Printf("Server unread count: %s = %d", streamID, count)
-			}
-		}
+		log.Printf("Applied starred status from server: %d changes", starredChanges)
 	}
 
-	// Fetch all read articles using pagination
-	readArticles, err := s.fetchAllArticles(ctx, "user/-/state/com.google/read", 10000)
+	// Step 4: Apply read status from server
+	log.Printf("pullFromServer: Step 4 - Applying read status")
+	readChanges, err := s.applyReadState(ctx)
 	if err != nil {
-		log.Printf("Warning: Failed to get read articles: %v", err)
+		log.Printf("Warning: Failed to apply read status: %v", err)
 	} else {
-		for _, article := range readArticles {
-			_, err := s.applyServerStatus(article.URL, true, "is_read")
-			if err != nil {
-				log.Printf("Warning: Failed to apply read status for %s: %v", article.URL, err)
-			}
-		}
-		log.Printf("Applied read status to %d articles from server", len(readArticles))
+		log.Printf("Applied read status from server: %d changes", readChanges)
 	}
 
 	log.Printf("Pull from server: %d total changes applied", totalChanges)
@@ -291,98 +294,6 @@ func (s *BidirectionalSyncService) pullFromServer(ctx context.Context) (int, err
 	return totalChanges, nil
 }
 
-// fetchAllArticles fetches all articles from a stream using pagination
-// This helper function automatically handles pagination to fetch all articles
-func (s *BidirectionalSyncService) fetchAllArticles(ctx context.Context, streamID string, itemsPerPage int) ([]Article, error) {
-	allArticles := make([]Article, 0)
-	continuation := ""
-	pageCount := 0
-
-	for {
-		result, err := s.client.GetStreamContents(ctx, streamID, nil, itemsPerPage, continuation)
-		if err != nil {
-			return allArticles, fmt.Errorf("fetch page %d: %w", pageCount, err)
-		}
-
-		if len(result.Items) == 0 {
-			break
-		}
-
-		allArticles = append(allArticles, result.Items...)
-		pageCount++
-
-		log.Printf("Fetched page %d (%d articles) from stream %s (total: %d)",
-			pageCount, len(result.Items), streamID, len(allArticles))
-
-		// Check if there are more articles to fetch
-		if result.Continuation == "" {
-			break
-		}
-		continuation = result.Continuation
-
-		// Small delay to avoid overwhelming the server
-		time.Sleep(50 * time.Millisecond)
-	}
-
-	log.Printf("Finished fetching %d total articles from stream %s (%d pages)",
-		len(allArticles), streamID, pageCount)
-	return allArticles, nil
-}
-
-// applyServerStatus applies server status to local article
-// If local has a pending sync for the same action type, clear it to avoid conflicts
-func (s *BidirectionalSyncService) applyServerStatus(articleURL string, status bool, column string) (int, error) {
-	// Check if article exists locally
-	localArticle, err := s.db.GetArticleByURL(articleURL)
-	if err != nil {
-		// Article doesn't exist locally, skip
-		return 0, nil
-	}
-
-	// Check if there's a pending sync change for the SAME action type
-	// Only clear conflicting pending syncs
-	pendingItems, err := s.db.GetPendingSyncChanges(1000)
-	hasConflictingSync := false
-	for _, item := range pendingItems {
-		if item.ArticleURL == articleURL {
-			isConflictingAction := false
-			switch column {
-			case "is_favorite":
-				// Star/unstar actions conflict with favorite status changes
-				isConflictingAction = (item.Action == database.SyncActionStar || item.Action == database.SyncActionUnstar)
-			case "is_read":
-				// Mark read/unread actions conflict with read status changes
-				isConflictingAction = (item.Action == database.SyncActionMarkRead || item.Action == database.SyncActionMarkUnread)
-			}
-
-			if isConflictingAction {
-				hasConflictingSync = true
-				log.Printf("[Conflict detected] Article %s has pending %s, server says %s=%v - clearing pending sync",
-					articleURL, item.Action, column, status)
-				break
-			}
-		}
-	}
-
-	// Apply server status
-	switch column {
-	case "is_favorite":
-		err = s.db.SetArticleFavorite(localArticle.ID, status)
-	case "is_read":
-		err = s.db.MarkArticleRead(localArticle.ID, status)
-	}
-	if err != nil {
-		return 0, err
-	}
-
-	// Clear conflicting pending sync if any
-	if hasConflictingSync {
-		_ = s.db.ClearPendingSyncForArticle(localArticle.ID)
-	}
-
-	return 1, nil
-}
-
 // createFeedsFromSubscriptions creates local feeds from FreshRSS subscriptions
 func (s *BidirectionalSyncService) createFeedsFromSubscriptions(ctx context.Context, subscriptions []Subscription) (int, error) {
 	feedsCreated := 0
@@ -449,9 +360,17 @@ func (s *BidirectionalSyncService) createFeedsFromSubscriptions(ctx context.Cont
 		return newCategory
 	}
 
+	// Feeds with local edits that have not reached the server yet keep their local state
+	pendingChanges := s.pendingSubscriptionURLs()
+
 	for _, sub := range subscriptions {
 		feedURL := sub.URL
 
+		if action, pending := pendingChanges[feedURL]; pending {
+			log.Printf("Skipping server state for feed '%s': local %s is pending", sub.Title, action)
+			continue
+		}
+
 		// Extract category from subscription categories
 		category := ""
 		if len(sub.Categories) > 0 {
@@ -591,6 +510,9 @@ func (s *BidirectionalSyncService) createFeedsFromSubscriptions(ctx context.Cont
 
 	for _, feed := range existingFeeds {
 		if feed.IsFreshRSSSource {
+			if _, pending := pendingChanges[feed.URL]; pending {
+				continue
+			}
 			if !remoteFeedURLs[feed.URL] {
 				log.Printf("Deleting local FreshRSS feed '%s' (removed from server)", feed.Title)
 				err := s.db.DeleteFeed(feed.ID)
@@ -703,9 +625,9 @@ func (s *BidirectionalSyncService) saveArticlesFromServer(ctx context.Context, a
 				}
 			}
 
-			// Update read status from FreshRSS (server is authoritative)
+			// Update read status from FreshRSS unless a newer local change is pending
 			// Only update if status differs to avoid unnecessary writes
-			if isRead != existingArticle.IsRead {
+			if s.resolveStatus(existingArticle.ID, article.URL, "is_read", existingArticle.IsRead, isRead, article.Updated) {
 				err := s.db.MarkArticleRead(existingArticle.ID, isRead)
 				if err != nil {
 					log.Printf("Warning: Failed to update read status for article %s: %v", article.URL, err)
@@ -715,8 +637,8 @@ func (s *BidirectionalSyncService) saveArticlesFromServer(ctx context.Context, a
 				}
 			}
 
-			// Update favorite status from FreshRSS (server is authoritative)
-			if isStarred != existingArticle.IsFavorite {
+			// Update favorite status from FreshRSS unless a newer local change is pending
+			if s.resolveStatus(existingArticle.ID, article.URL, "is_favorite", existingArticle.IsFavorite, isStarred, article.Updated) {
 				err := s.db.SetArticleFavorite(existingArticle.ID, isStarred)
 				if err != nil {
 					log.Printf("Warning: Failed to update favorite status for article %s: %v", article.URL, err)
@@ -827,8 +749,8 @@ func (s *BidirectionalSyncService) saveArticlesFromServer(ctx context.Context, a
 func (s *BidirectionalSyncService) pushToServer(ctx context.Context) (int, error) {
 	totalChanges := 0
 
-	// First, process any failed items from the queue (retry mechanism)
-	pendingChanges, err := s.db.GetPendingSyncChanges(500)
+	// First, process queued items whose retry backoff has expired
+	pendingChanges, err := s.db.GetRetryableSyncChanges(500)
 	if err != nil {
 		log.Printf("Warning: Failed to get pending changes: %v", err)
 	} else if len(pendingChanges) > 0 {
@@ -866,31 +788,18 @@ func (s *BidirectionalSyncService) pushToServer(ctx context.Context) (int, error
 
 	log.Printf("[Push] Checking %d FreshRSS feeds for local changes to push", len(freshRSSFeeds))
 
-	// Get remote read and starred articles
-	remoteReadArticles := make(map[string]bool)    // URL -> is read
-	remoteStarredArticles := make(map[string]bool) // URL -> is starred
-
-	// Fetch remote read status (fetch all with pagination)
-	readArticles, err := s.fetchAllArticles(ctx, "user/-/state/com.google/read", 10000)
+	// Get remote unread and starred item IDs; ID lists are far cheaper than stream contents
+	remoteUnread, err := s.fetchItemIDSet(ctx, StreamQuery{StreamID: TagReadingList, ExcludeTags: []string{TagRead}})
 	if err != nil {
-		log.Printf("[Push] Warning: Failed to get remote read articles: %v", err)
-	} else {
-		for _, article := range readArticles {
-			remoteReadArticles[article.URL] = true
-		}
-		log.Printf("[Push] Fetched %d read articles from remote", len(remoteReadArticles))
+		return totalChanges, fmt.Errorf("get remote unread items: %w", err)
 	}
+	log.Printf("[Push] Fetched %d unread item IDs from remote", len(remoteUnread))
 
-	// Fetch remote starred status (fetch all with pagination)
-	starredArticles, err := s.fetchAllArticles(ctx, "user/-/state/com.google/starred", 10000)
+	remoteStarred, err := s.fetchItemIDSet(ctx, StreamQuery{StreamID: TagStarred})
 	if err != nil {
-		log.Printf("[Push] Warning: Failed to get remote starred articles: %v", err)
-	} else {
-		for _, article := range starredArticles {
-			remoteStarredArticles[article.URL] = true
-		}
-		log.Printf("[Push] Fetched %d starred articles from remote", len(remoteStarredArticles))
+		return totalChanges, fmt.Errorf("get remote starred items: %w", err)
 	}
+	log.Printf("[Push] Fetched %d starred item IDs from remote", len(remoteStarred))
 
 	// For each FreshRSS feed, check articles and sync differences
 	readIDs := make([]string, 0)
@@ -931,14 +840,14 @@ func (s *BidirectionalSyncService) pushToServer(ctx context.Context) (int, error
 		log.Printf("[Push] Total %d articles to check for feed %s", len(allArticles), feed.Title)
 
 		for _, article := range allArticles {
-			// Use FreshRSS Item ID if available, otherwise fall back to URL
-			identifier := article.URL
-			if article.FreshRSSItemID != "" {
-				identifier = article.FreshRSSItemID
+			// Remote state is known by item ID only
+			if article.FreshRSSItemID == "" {
+				continue
 			}
+			identifier := article.FreshRSSItemID
 
 			// Check read status differences
-			remoteIsRead := remoteReadArticles[article.URL]
+			remoteIsRead := !remoteUnread[identifier]
 			if article.IsRead && !remoteIsRead {
 				// Local is read, remote is not - push read status
 				readIDs = append(readIDs, identifier)
@@ -948,7 +857,7 @@ func (s *BidirectionalSyncService) pushToServer(ctx context.Context) (int, error
 			}
 
 			// Check starred status differences
-			remoteIsStarred := remoteStarredArticles[article.URL]
+			remoteIsStarred := remoteStarred[identifier]
 			if article.IsFavorite && !remoteIsStarred {
 				// Local is starred, remote is not - push star status
 				starIDs = append(starIDs, identifier)
@@ -1005,16 +914,11 @@ func (s *BidirectionalSyncService) pushToServer(ctx context.Context) (int, error
 }
 
 // pushPendingItems pushes items that failed previously (from the queue)
+// Each action is pushed as one batch; a failed batch is rescheduled with exponential backoff
+// while the other batches proceed.
 func (s *BidirectionalSyncService) pushPendingItems(ctx context.Context, pendingChanges []database.SyncQueueItem) (int, error) {
 	totalChanges := 0
 
-	// Group changes by action type
-	readIDs := make([]string, 0)
-	unreadIDs := make([]string, 0)
-	starIDs := make([]string, 0)
-	unstarIDs := make([]string, 0)
-	itemIDs := make([]int64, 0)
-
 	// Get article IDs to fetch FreshRSS item IDs
 	articleIDs := make([]int64, len(pendingChanges))
 	for i, item := range pendingChanges {
@@ -1034,10 +938,15 @@ func (s *BidirectionalSyncService) pushPendingItems(ctx context.Context, pending
 		articleByID[article.ID] = article
 	}
 
+	// Group changes by action type, keeping queue IDs alongside the identifiers
+	type actionBatch struct {
+		identifiers []string
+		queueIDs    []int64
+	}
+	batches := make(map[database.SyncAction]*actionBatch)
+
 	// Use FreshRSS item ID if available, otherwise fall back to URL
 	for _, item := range pendingChanges {
-		itemIDs = append(itemIDs, item.ID)
-
 		article, exists := articleByID[item.ArticleID]
 		identifier := item.ArticleURL // Default fallback
 
@@ -1048,53 +957,48 @@ func (s *BidirectionalSyncService) pushPendingItems(ctx context.Context, pending
 			log.Printf("  Warning: No FreshRSS Item ID for article %d, using URL: %s", item.ArticleID, item.ArticleURL)
 		}
 
-		switch item.Action {
-		case database.SyncActionMarkRead:
-			readIDs = append(readIDs, identifier)
-		case database.SyncActionMarkUnread:
-			unreadIDs = append(unreadIDs, identifier)
-		case database.SyncActionStar:
-			starIDs = append(starIDs, identifier)
-		case database.SyncActionUnstar:
-			unstarIDs = append(unstarIDs, identifier)
+		batch, ok := batches[item.Action]
+		if !ok {
+			batch = &actionBatch{}
+			batches[item.Action] = batch
 		}
+		batch.identifiers = append(batch.identifiers, identifier)
+		batch.queueIDs = append(batch.queueIDs, item.ID)
 	}
 
-	// Execute batch operations
-	if len(readIDs) > 0 {
-		log.Printf("[PushPending] Marking %d articles as read", len(readIDs))
-		if err := s.client.MarkAsReadBatch(ctx, readIDs); err != nil {
-			log.Printf("[PushPending] ERROR marking as read: %v", err)
-			return totalChanges, fmt.Errorf("mark read batch: %w", err)
-		}
-		log.Printf("[PushPending] Successfully marked %d articles as read", len(readIDs))
-		totalChanges += len(readIDs)
+	pushers := []struct {
+		action database.SyncAction
+		push   func(context.Context, []string) error
+	}{
+		{database.SyncActionMarkRead, s.client.MarkAsReadBatch},
+		{database.SyncActionMarkUnread, s.client.MarkAsUnreadBatch},
+		{database.SyncActionStar, s.client.StarBatch},
+		{database.SyncActionUnstar, s.client.UnstarBatch},
 	}
 
-	if len(unreadIDs) > 0 {
-		if err := s.client.MarkAsUnreadBatch(ctx, unreadIDs); err != nil {
-			return totalChanges, fmt.Errorf("mark unread batch: %w", err)
+	var firstErr error
+	for _, pusher := range pushers {
+		batch, ok := batches[pusher.action]
+		if !ok {
+			continue
 		}
-		totalChanges += len(unreadIDs)
-	}
 
-	if len(starIDs) > 0 {
-		if err := s.client.StarBatch(ctx, starIDs); err != nil {
-			return totalChanges, fmt.Errorf("star batch: %w", err)
+		log.Printf("[PushPending] Pushing %d %s changes", len(batch.identifiers), pusher.action)
+		if err := pusher.push(ctx, batch.identifiers); err != nil {
+			log.Printf("[PushPending] ERROR pushing %s: %v", pusher.action, err)
+			for _, id := range batch.queueIDs {
+				_ = s.db.MarkSyncFailed(id, err.Error())
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s batch: %w", pusher.action, err)
+			}
+			continue
 		}
-		totalChanges += len(starIDs)
-	}
 
-	if len(unstarIDs) > 0 {
-		if err := s.client.UnstarBatch(ctx, unstarIDs); err != nil {
-			return totalChanges, fmt.Errorf("unstar batch: %w", err)
+		if err := s.db.MarkSynced(batch.queueIDs); err != nil {
+			log.Printf("Warning: Failed to mark items as synced: %v", err)
 		}
-		totalChanges += len(unstarIDs)
-	}
-
-	// Mark all as synced
-	if err := s.db.MarkSynced(itemIDs); err != nil {
-		log.Printf("Warning: Failed to mark items as synced: %v", err)
+		totalChanges += len(batch.identifiers)
 	}
 
 	log.Printf("[PushPending] Successfully synced %d items from queue", totalChanges)
@@ -1102,7 +1006,7 @@ func (s *BidirectionalSyncService) pushPendingItems(ctx context.Context, pending
 	// Clean up old synced items
 	_ = s.db.DeleteOldSyncedItems(7 * 24 * time.Hour)
 
-	return totalChanges, nil
+	return totalChanges, firstErr
 }
 
 // GetPendingCount returns the number of pending sync changes
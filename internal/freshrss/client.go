@@ -9,6 +9,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -231,8 +232,31 @@ type Article struct {
 	OriginStreamID string    `json:"origin_stream_id,omitempty"` // Stream ID of the feed
 }
 
+// UnreadCount is the unread state of a stream reported by the server
+type UnreadCount struct {
+	StreamID       string
+	Count          int
+	NewestItemUsec int64 // Timestamp of the newest item in microseconds
+}
+
 // GetUnreadCount retrieves unread counts for all feeds
 func (c *Client) GetUnreadCount(ctx context.Context) (map[string]int, error) {
+	unreads, err := c.GetUnreadCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert to map for easier lookup
+	counts := make(map[string]int, len(unreads))
+	for streamID, unread := range unreads {
+		counts[streamID] = unread.Count
+	}
+
+	return counts, nil
+}
+
+// GetUnreadCounts retrieves unread counts and newest item timestamps keyed by stream ID
+func (c *Client) GetUnreadCounts(ctx context.Context) (map[string]UnreadCount, error) {
 	if c.authToken == "" {
 		return nil, fmt.Errorf("not authenticated")
 	}
@@ -257,11 +281,11 @@ func (c *Client) GetUnreadCount(ctx context.Context) (map[string]int, error) {
 	}
 
 	var result struct {
-		Max     int64 `json:"max"`
+		Max     json.RawMessage `json:"max"`
 		Unreads []struct {
-			ID              string `json:"id"`
-			Count           int    `json:"count"`
-			LatestTimestamp int64  `json:"newestItemTimestampUsec"`
+			ID              string        `json:"id"`
+			Count           int           `json:"count"`
+			LatestTimestamp flexibleInt64 `json:"newestItemTimestampUsec"`
 		} `json:"unreadcounts"`
 	}
 
@@ -269,15 +293,36 @@ func (c *Client) GetUnreadCount(ctx context.Context) (map[string]int, error) {
 		return nil, fmt.Errorf("decode unread-count response: %w", err)
 	}
 
-	// Convert to map for easier lookup
-	counts := make(map[string]int)
+	counts := make(map[string]UnreadCount, len(result.Unreads))
 	for _, unread := range result.Unreads {
-		counts[unread.ID] = unread.Count
+		counts[unread.ID] = UnreadCount{
+			StreamID:       unread.ID,
+			Count:          unread.Count,
+			NewestItemUsec: int64(unread.LatestTimestamp),
+		}
 	}
 
 	return counts, nil
 }
 
+// flexibleInt64 decodes integers that servers send either as JSON numbers or as strings
+type flexibleInt64 int64
+
+// UnmarshalJSON implements json.Unmarshaler
+func (f *flexibleInt64) UnmarshalJSON(data []byte) error {
+	str := strings.Trim(string(data), `"`)
+	if str == "" || str == "null" {
+		*f = 0
+		return nil
+	}
+	n, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s: %w", string(data), err)
+	}
+	*f = flexibleInt64(n)
+	return nil
+}
+
 // StreamContentsResult represents the result of stream contents API
 type StreamContentsResult struct {
 	Items        []Article
@@ -309,27 +354,22 @@ func (c *Client) GetReadArticles(ctx context.Context, maxItems int) ([]Article,
 // maxItems: maximum number of items to retrieve
 // continuationToken: token for pagination (empty for first request)
 func (c *Client) GetStreamContents(ctx context.Context, streamID string, excludeTypes []string, maxItems int, continuationToken string) (*StreamContentsResult, error) {
+	return c.QueryStreamContents(ctx, StreamQuery{
+		StreamID:     streamID,
+		ExcludeTags:  excludeTypes,
+		Count:        maxItems,
+		Continuation: continuationToken,
+	})
+}
+
+// QueryStreamContents retrieves articles from a stream, optionally limited to a crawl time window
+func (c *Client) QueryStreamContents(ctx context.Context, query StreamQuery) (*StreamContentsResult, error) {
 	if c.authToken == "" {
 		return nil, fmt.Errorf("not authenticated")
 	}
 
-	// Build URL with parameters
-	params := url.Values{}
-	params.Set("output", "json")
-	params.Set("n", fmt.Sprintf("%d", maxItems))
-
-	if continuationToken != "" {
-		params.Set("c", continuationToken)
-	}
-
-	// Add exclude types (xt parameter)
-	// Google Reader API allows filtering out specific states
-	for _, exclude := range excludeTypes {
-		params.Add("xt", exclude)
-	}
-
 	streamURL := fmt.Sprintf("%s/reader/api/0/stream/contents/%s?%s",
-		c.baseURL, streamID, params.Encode())
+		c.baseURL, query.StreamID, query.values().Encode())
 
 	req, err := http.NewRequestWithContext(ctx, "GET", streamURL, nil)
 	if err != nil {
@@ -348,33 +388,41 @@ func (c *Client) GetStreamContents(ctx context.Context, streamID string, exclude
 		return nil, fmt.Errorf("stream contents request failed with status %d", resp.StatusCode)
 	}
 
-	var result struct {
-		ID           string `json:"id"`
-		Updated      int64  `json:"updated"`
-		Continuation string `json:"continuation,omitempty"`
-		Items        []struct {
-			ID        string `json:"id"`
-			Title     string `json:"title"`
-			Canonical []struct {
-				Href string `json:"href"`
-			} `json:"canonical"`
-			Summary struct {
-				Content   string `json:"content"`
-				Direction string `json:"direction,omitempty"`
-			} `json:"summary"`
-			Published  int64    `json:"published"`
-			Updated    int64    `json:"updated"` // crawlTimeMsec
-			Author     string   `json:"author,omitempty"`
-			Categories []string `json:"categories"`
-			Origin     struct {
-				StreamID string `json:"streamId"`
-				Title    string `json:"title"`
-				HtmlURL  string `json:"htmlUrl,omitempty"`
-			} `json:"origin,omitempty"`
-		} `json:"items"`
-	}
+	return decodeStreamContents(resp.Body)
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+// streamContentsResponse is the wire format of stream/contents and stream/items/contents
+type streamContentsResponse struct {
+	ID           string `json:"id"`
+	Updated      int64  `json:"updated"`
+	Continuation string `json:"continuation,omitempty"`
+	Items        []struct {
+		ID        string `json:"id"`
+		Title     string `json:"title"`
+		Canonical []struct {
+			Href string `json:"href"`
+		} `json:"canonical"`
+		Summary struct {
+			Content   string `json:"content"`
+			Direction string `json:"direction,omitempty"`
+		} `json:"summary"`
+		Published     int64    `json:"published"`
+		Updated       int64    `json:"updated"` // crawlTimeMsec
+		CrawlTimeMsec string   `json:"crawlTimeMsec,omitempty"`
+		Author        string   `json:"author,omitempty"`
+		Categories    []string `json:"categories"`
+		Origin        struct {
+			StreamID string `json:"streamId"`
+			Title    string `json:"title"`
+			HtmlURL  string `json:"htmlUrl,omitempty"`
+		} `json:"origin,omitempty"`
+	} `json:"items"`
+}
+
+// decodeStreamContents converts a stream contents response into articles
+func decodeStreamContents(body io.Reader) (*StreamContentsResult, error) {
+	var result streamContentsResponse
+	if err := json.NewDecoder(body).Decode(&result); err != nil {
 		return nil, fmt.Errorf("decode stream contents response: %w", err)
 	}
 
@@ -385,13 +433,19 @@ func (c *Client) GetStreamContents(ctx context.Context, streamID string, exclude
 			articleURL = item.Canonical[0].Href
 		}
 
+		// FreshRSS reports crawlTimeMsec as a string instead of "updated"
+		updated := time.Unix(item.Updated/1000, 0) // Convert milliseconds to seconds
+		if crawlMsec, err := strconv.ParseInt(item.CrawlTimeMsec, 10, 64); err == nil && crawlMsec > 0 {
+			updated = time.UnixMilli(crawlMsec)
+		}
+
 		articles = append(articles, Article{
 			ID:             item.ID,
 			Title:          item.Title,
 			URL:            articleURL,
 			Content:        item.Summary.Content,
 			Published:      time.Unix(item.Published, 0),
-			Updated:        time.Unix(item.Updated/1000, 0), // Convert milliseconds to seconds
+			Updated:        updated,
 			Author:         item.Author,
 			Categories:     item.Categories,
 			OriginStreamID: item.Origin.StreamID,
@@ -411,6 +465,9 @@ const (
 	TagStarred = "user/-/state/com.google/starred"
 )
 
+// labelPrefix is the stream ID prefix of user categories (folders)
+const labelPrefix = "user/-/label/"
+
 // editTag is a helper function to add or remove tags from items
 func (c *Client) editTag(ctx context.Context, itemIDs []string, addTag string, removeTag string) error {
 	if c.authToken == "" {
@@ -507,6 +564,80 @@ func (c *Client) UnstarBatch(ctx context.Context, itemIDs []string) error {
 
 // SubscribeToFeed subscribes to a new feed
 func (c *Client) SubscribeToFeed(ctx context.Context, feedURL, title string) error {
+	return c.EditSubscription(ctx, SubscriptionEdit{
+		Action:   "subscribe",
+		StreamID: "feed/" + feedURL,
+		Title:    title,
+	})
+}
+
+// QuickAdd subscribes to a feed URL and returns the stream ID assigned by the server
+func (c *Client) QuickAdd(ctx context.Context, feedURL string) (string, error) {
+	if c.authToken == "" {
+		return "", fmt.Errorf("not authenticated")
+	}
+
+	token, err := c.GetToken(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get token: %w", err)
+	}
+
+	data := url.Values{}
+	data.Set("T", token)
+	data.Set("quickadd", feedURL)
+
+	req, err := http.NewRequestWithContext(ctx, "POST",
+		c.baseURL+"/reader/api/0/subscription/quickadd",
+		strings.NewReader(data.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("create quickadd request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "GoogleLogin auth="+c.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("quickadd request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("quickadd failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		NumResults int    `json:"numResults"`
+		StreamID   string `json:"streamId"`
+		Error      string `json:"error,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("decode quickadd response: %w", err)
+	}
+
+	if result.StreamID == "" {
+		if result.Error != "" {
+			return "", fmt.Errorf("quickadd failed: %s", result.Error)
+		}
+		return "", fmt.Errorf("quickadd returned no stream ID for %s", feedURL)
+	}
+
+	return result.StreamID, nil
+}
+
+// SubscriptionEdit describes a subscription/edit call.
+// Action is "subscribe", "unsubscribe" or "edit"; empty optional fields are omitted.
+type SubscriptionEdit struct {
+	Action      string
+	StreamID    string
+	Title       string
+	AddLabel    string // Category name to add
+	RemoveLabel string // Category name to remove
+}
+
+// EditSubscription subscribes, unsubscribes, renames or relabels a feed on the server
+func (c *Client) EditSubscription(ctx context.Context, edit SubscriptionEdit) error {
 	if c.authToken == "" {
 		return fmt.Errorf("not authenticated")
 	}
@@ -518,34 +649,37 @@ func (c *Client) SubscribeToFeed(ctx context.Context, feedURL, title string) err
 
 	data := url.Values{}
 	data.Set("T", token)
-	data.Set("s", "feed/"+feedURL)
-	if title != "" {
-		data.Set("t", title)
+	data.Set("ac", edit.Action)
+	data.Set("s", edit.StreamID)
+	if edit.Title != "" {
+		data.Set("t", edit.Title)
+	}
+	if edit.AddLabel != "" {
+		data.Set("a", labelPrefix+edit.AddLabel)
+	}
+	if edit.RemoveLabel != "" {
+		data.Set("r", labelPrefix+edit.RemoveLabel)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST",
 		c.baseURL+"/reader/api/0/subscription/edit",
 		strings.NewReader(data.Encode()))
 	if err != nil {
-		return fmt.Errorf("create subscribe request: %w", err)
+		return fmt.Errorf("create subscription edit request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "GoogleLogin auth="+c.authToken)
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
-	// Add subscription
-	data.Set("ac", "subscribe")
-	req.Body = io.NopCloser(strings.NewReader(data.Encode()))
-
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("subscribe request: %w", err)
+		return fmt.Errorf("subscription edit request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("subscribe failed with status %d: %s", resp.StatusCode, string(body))
+		return fmt.Errorf("subscription %s failed with status %d: %s", edit.Action, resp.StatusCode, string(body))
 	}
 
 	return nil
@@ -0,0 +1,124 @@
+package freshrss
+
+import (
+	"log"
+	"time"
+
+	"MrRSS/internal/database"
+)
+
+// fieldActions maps an article status column to the queue actions that change it
+var fieldActions = map[string][2]database.SyncAction{
+	"is_read":     {database.SyncActionMarkRead, database.SyncActionMarkUnread},
+	"is_favorite": {database.SyncActionStar, database.SyncActionUnstar},
+}
+
+// loadPendingChanges indexes unsynced queue items by article ID.
+// The index is built once per sync and consulted for every pulled article.
+func (s *BidirectionalSyncService) loadPendingChanges() map[int64][]database.SyncQueueItem {
+	if s.pendingByArticle != nil {
+		return s.pendingByArticle
+	}
+
+	s.pendingByArticle = make(map[int64][]database.SyncQueueItem)
+	items, err := s.db.GetPendingSyncChanges(10000)
+	if err != nil {
+		log.Printf("Warning: Failed to load pending sync changes: %v", err)
+		return s.pendingByArticle
+	}
+	for _, item := range items {
+		s.pendingByArticle[item.ArticleID] = append(s.pendingByArticle[item.ArticleID], item)
+	}
+	return s.pendingByArticle
+}
+
+// latestPendingChange returns the most recent unsynced change of a status column for an article
+func (s *BidirectionalSyncService) latestPendingChange(articleID int64, column string) *database.SyncQueueItem {
+	actions, ok := fieldActions[column]
+	if !ok {
+		return nil
+	}
+
+	var latest *database.SyncQueueItem
+	items := s.loadPendingChanges()[articleID]
+	for i := range items {
+		if items[i].Action != actions[0] && items[i].Action != actions[1] {
+			continue
+		}
+		if latest == nil || !items[i].CreatedAt.Before(latest.CreatedAt) {
+			latest = &items[i]
+		}
+	}
+	return latest
+}
+
+// resolveStatus decides whether the server value of a status column should overwrite the local one.
+//
+// Without a pending local change the server is authoritative. When both sides changed, the
+// later writer wins. The Google Reader API does not expose when an item's state changed, so
+// the remote timestamp is the latest point we know the server state could have been set:
+// the later of the item's crawl time and the previous successful sync. A local change made
+// after that point wins and stays queued for the push stage; an older one is discarded.
+// Every conflict is recorded in the conflict log.
+func (s *BidirectionalSyncService) resolveStatus(articleID int64, articleURL, column string, localValue, serverValue bool, remoteSeen time.Time) bool {
+	pending := s.latestPendingChange(articleID, column)
+	if pending == nil {
+		return localValue != serverValue
+	}
+
+	actions := fieldActions[column]
+	pendingValue := pending.Action == actions[0]
+	if pendingValue == serverValue {
+		// Server already reflects the local change, nothing left to push
+		_ = s.db.ClearPendingSyncActions(articleID, actions[0], actions[1])
+		s.forgetPending(articleID, actions)
+		return localValue != serverValue
+	}
+
+	remoteChangedAt := remoteSeen
+	if s.lastSyncTime.After(remoteChangedAt) {
+		remoteChangedAt = s.lastSyncTime
+	}
+
+	resolution := "remote"
+	if !pending.CreatedAt.Before(remoteChangedAt) {
+		resolution = "local"
+	}
+
+	log.Printf("[Conflict] Article %d %s: local=%v (at %s) remote=%v (at %s) -> %s wins",
+		articleID, column, pendingValue, pending.CreatedAt.Format(time.RFC3339),
+		serverValue, remoteChangedAt.Format(time.RFC3339), resolution)
+
+	if err := s.db.LogSyncConflict(database.SyncConflict{
+		ArticleID:       articleID,
+		ArticleURL:      articleURL,
+		Field:           column,
+		LocalValue:      pendingValue,
+		RemoteValue:     serverValue,
+		LocalChangedAt:  pending.CreatedAt,
+		RemoteChangedAt: remoteChangedAt,
+		Resolution:      resolution,
+	}); err != nil {
+		log.Printf("Warning: Failed to log sync conflict: %v", err)
+	}
+
+	if resolution == "local" {
+		return false
+	}
+
+	_ = s.db.ClearPendingSyncActions(articleID, actions[0], actions[1])
+	s.forgetPending(articleID, actions)
+	return localValue != serverValue
+}
+
+// forgetPending drops cleared actions from the in-memory pending index
+func (s *BidirectionalSyncService) forgetPending(articleID int64, actions [2]database.SyncAction) {
+	items := s.pendingByArticle[articleID]
+	kept := items[:0]
+	for _, item := range items {
+		if item.Action != actions[0] && item.Action != actions[1] {
+			kept = append(kept, item)
+		}
+	}
+	s.pendingByArticle[articleID] = kept
+}
@@ -0,0 +1,209 @@
+package freshrss
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"MrRSS/internal/database"
+)
+
+const (
+	// incrementalSyncOverlap widens the ot window so items crawled during the previous sync are not missed.
+	// Already known items are filtered out by ID, so the overlap costs no content downloads.
+	incrementalSyncOverlap = 10 * time.Minute
+
+	// itemContentsBatchSize is the number of items requested per stream/items/contents call
+	itemContentsBatchSize = 250
+)
+
+// ProgressReporter receives progress updates while a sync runs.
+// feed.Fetcher implements it so syncs are reported through /api/progress.
+type ProgressReporter interface {
+	ReportSyncProgress(stage string, current, total int)
+	FinishSyncProgress()
+}
+
+// SetProgressReporter sets where sync progress is reported
+func (s *BidirectionalSyncService) SetProgressReporter(reporter ProgressReporter) {
+	s.progress = reporter
+}
+
+// reportProgress forwards sync progress to the reporter, if any
+func (s *BidirectionalSyncService) reportProgress(stage string, current, total int) {
+	if s.progress != nil {
+		s.progress.ReportSyncProgress(stage, current, total)
+	}
+}
+
+// finishProgress tells the reporter that the sync ended
+func (s *BidirectionalSyncService) finishProgress() {
+	if s.progress != nil {
+		s.progress.FinishSyncProgress()
+	}
+}
+
+// syncStream pulls new items of a stream incrementally.
+//
+// The stream is skipped when its unread count and newest item timestamp match the previous sync
+// (unless force is set). Otherwise only item IDs crawled since the previous sync are listed and
+// contents are downloaded for IDs that are not stored locally yet.
+// It returns the number of saved articles and whether the stream was skipped.
+func (s *BidirectionalSyncService) syncStream(ctx context.Context, streamID string, unread *UnreadCount, force bool) (int, bool, error) {
+	state, err := s.db.GetFreshRSSStreamState(streamID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	if !force && state != nil && unread != nil &&
+		state.UnreadCount == unread.Count && state.NewestItemUsec == unread.NewestItemUsec {
+		return 0, true, nil
+	}
+
+	syncStartedAt := time.Now()
+	query := StreamQuery{StreamID: streamID}
+	if state != nil && state.LastSyncAt.Unix() > 0 {
+		query.Since = state.LastSyncAt.Add(-incrementalSyncOverlap)
+	}
+
+	itemIDs, err := s.client.GetAllStreamItemIDs(ctx, query)
+	if err != nil {
+		return 0, false, fmt.Errorf("get item ids: %w", err)
+	}
+
+	known, err := s.db.GetKnownFreshRSSItemIDs(itemIDs)
+	if err != nil {
+		return 0, false, err
+	}
+
+	unknown := make([]string, 0, len(itemIDs))
+	for _, id := range itemIDs {
+		if !known[id] {
+			unknown = append(unknown, id)
+		}
+	}
+
+	saved := 0
+	for start := 0; start < len(unknown); start += itemContentsBatchSize {
+		end := start + itemContentsBatchSize
+		if end > len(unknown) {
+			end = len(unknown)
+		}
+
+		articles, err := s.client.GetItemContents(ctx, unknown[start:end])
+		if err != nil {
+			// Keep the previous state so the next sync retries the same window
+			return saved, false, fmt.Errorf("get item contents: %w", err)
+		}
+
+		count, err := s.saveArticlesFromServer(ctx, articles)
+		if err != nil {
+			return saved, false, fmt.Errorf("save articles: %w", err)
+		}
+		saved += count
+	}
+
+	newState := database.FreshRSSStreamState{
+		StreamID:   streamID,
+		LastSyncAt: syncStartedAt,
+	}
+	if unread != nil {
+		newState.UnreadCount = unread.Count
+		newState.NewestItemUsec = unread.NewestItemUsec
+	}
+	if err := s.db.SaveFreshRSSStreamState(newState); err != nil {
+		log.Printf("Warning: Failed to save sync state of %s: %v", streamID, err)
+	}
+
+	if len(itemIDs) > 0 {
+		log.Printf("Stream %s: %d item IDs since last sync, %d new, %d saved", streamID, len(itemIDs), len(unknown), saved)
+	}
+	return saved, false, nil
+}
+
+// fetchItemIDSet lists every item ID of a stream as a set
+func (s *BidirectionalSyncService) fetchItemIDSet(ctx context.Context, query StreamQuery) (map[string]bool, error) {
+	ids, err := s.client.GetAllStreamItemIDs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set, nil
+}
+
+// applyStarredState applies the server's starred set to local FreshRSS articles
+func (s *BidirectionalSyncService) applyStarredState(ctx context.Context) (int, error) {
+	starred, err := s.fetchItemIDSet(ctx, StreamQuery{StreamID: TagStarred})
+	if err != nil {
+		return 0, fmt.Errorf("get starred item ids: %w", err)
+	}
+
+	return s.applyItemState("is_favorite", func(itemID string) bool { return starred[itemID] })
+}
+
+// applyReadState applies the server's read state to local FreshRSS articles.
+// Only unread IDs are listed, as the unread set is much smaller than the read one.
+func (s *BidirectionalSyncService) applyReadState(ctx context.Context) (int, error) {
+	unread, err := s.fetchItemIDSet(ctx, StreamQuery{
+		StreamID:    TagReadingList,
+		ExcludeTags: []string{TagRead},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("get unread item ids: %w", err)
+	}
+
+	return s.applyItemState("is_read", func(itemID string) bool { return !unread[itemID] })
+}
+
+// applyItemState updates a status column of every local FreshRSS article from the server state
+func (s *BidirectionalSyncService) applyItemState(column string, serverValue func(itemID string) bool) (int, error) {
+	articles, err := s.db.GetFreshRSSArticles()
+	if err != nil {
+		return 0, err
+	}
+
+	changes := 0
+	for i, article := range articles {
+		if i%500 == 0 {
+			s.reportProgress(stageForColumn(column), i, len(articles))
+		}
+
+		value := serverValue(article.FreshRSSItemID)
+		localValue := article.IsRead
+		if column == "is_favorite" {
+			localValue = article.IsFavorite
+		}
+
+		// The ID APIs carry no timestamps, so the previous sync bounds the remote change time
+		if !s.resolveStatus(article.ID, article.URL, column, localValue, value, time.Time{}) {
+			continue
+		}
+
+		switch column {
+		case "is_favorite":
+			err = s.db.SetArticleFavorite(article.ID, value)
+		case "is_read":
+			err = s.db.MarkArticleRead(article.ID, value)
+		}
+		if err != nil {
+			log.Printf("Warning: Failed to apply %s for %s: %v", column, article.URL, err)
+			continue
+		}
+		changes++
+	}
+
+	return changes, nil
+}
+
+// stageForColumn names the progress stage of a status column
+func stageForColumn(column string) string {
+	if column == "is_favorite" {
+		return "starred"
+	}
+	return "read"
+}
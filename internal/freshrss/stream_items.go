@@ -0,0 +1,191 @@
+package freshrss
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TagReadingList is the stream of all items of all subscriptions
+const TagReadingList = "user/-/state/com.google/reading-list"
+
+// itemIDPrefix is the prefix of long-form Google Reader item IDs
+const itemIDPrefix = "tag:google.com,2005:reader/item/"
+
+// StreamQuery describes a stream request
+type StreamQuery struct {
+	StreamID     string
+	ExcludeTags  []string  // xt: states to exclude, e.g. TagRead
+	Count        int       // n: maximum number of items per page
+	Continuation string    // c: token of the next page
+	Since        time.Time // ot: only items crawled after this time
+	Until        time.Time // nt: only items crawled before this time
+}
+
+// values encodes the query parameters shared by stream endpoints
+func (q StreamQuery) values() url.Values {
+	params := url.Values{}
+	params.Set("output", "json")
+	if q.Count > 0 {
+		params.Set("n", strconv.Itoa(q.Count))
+	}
+	if q.Continuation != "" {
+		params.Set("c", q.Continuation)
+	}
+	if !q.Since.IsZero() {
+		params.Set("ot", strconv.FormatInt(q.Since.Unix(), 10))
+	}
+	if !q.Until.IsZero() {
+		params.Set("nt", strconv.FormatInt(q.Until.Unix(), 10))
+	}
+	// Google Reader API allows filtering out specific states
+	for _, exclude := range q.ExcludeTags {
+		params.Add("xt", exclude)
+	}
+	return params
+}
+
+// StreamItemIDsResult represents one page of the stream/items/ids API
+type StreamItemIDsResult struct {
+	ItemIDs      []string // Long-form item IDs, comparable with Article.ID
+	Continuation string
+}
+
+// GetStreamItemIDs retrieves one page of item IDs of a stream without their contents
+func (c *Client) GetStreamItemIDs(ctx context.Context, query StreamQuery) (*StreamItemIDsResult, error) {
+	if c.authToken == "" {
+		return nil, fmt.Errorf("not authenticated")
+	}
+
+	params := query.values()
+	params.Set("s", query.StreamID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET",
+		c.baseURL+"/reader/api/0/stream/items/ids?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create item ids request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "GoogleLogin auth="+c.authToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("item ids request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("item ids request failed with status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		ItemRefs []struct {
+			ID string `json:"id"`
+		} `json:"itemRefs"`
+		Continuation string `json:"continuation,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode item ids response: %w", err)
+	}
+
+	ids := make([]string, 0, len(result.ItemRefs))
+	for _, ref := range result.ItemRefs {
+		if id := longItemID(ref.ID); id != "" {
+			ids = append(ids, id)
+		}
+	}
+
+	return &StreamItemIDsResult{
+		ItemIDs:      ids,
+		Continuation: result.Continuation,
+	}, nil
+}
+
+// GetAllStreamItemIDs follows continuation tokens and returns every item ID of a stream
+func (c *Client) GetAllStreamItemIDs(ctx context.Context, query StreamQuery) ([]string, error) {
+	if query.Count == 0 {
+		query.Count = 10000
+	}
+
+	var ids []string
+	for {
+		page, err := c.GetStreamItemIDs(ctx, query)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, page.ItemIDs...)
+
+		if page.Continuation == "" || len(page.ItemIDs) == 0 {
+			return ids, nil
+		}
+		query.Continuation = page.Continuation
+	}
+}
+
+// GetItemContents retrieves the contents of specific items
+func (c *Client) GetItemContents(ctx context.Context, itemIDs []string) ([]Article, error) {
+	if c.authToken == "" {
+		return nil, fmt.Errorf("not authenticated")
+	}
+	if len(itemIDs) == 0 {
+		return nil, nil
+	}
+
+	token, err := c.GetToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get token: %w", err)
+	}
+
+	data := url.Values{}
+	data.Set("T", token)
+	for _, id := range itemIDs {
+		data.Add("i", id)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST",
+		c.baseURL+"/reader/api/0/stream/items/contents?output=json",
+		strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("create item contents request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "GoogleLogin auth="+c.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("item contents request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("item contents request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	result, err := decodeStreamContents(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// longItemID converts an item ID to the long form used by stream contents.
+// stream/items/ids returns decimal IDs while stream/contents returns
+// "tag:google.com,2005:reader/item/" followed by 16 hex digits.
+func longItemID(id string) string {
+	if id == "" || strings.HasPrefix(id, itemIDPrefix) {
+		return id
+	}
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s%016x", itemIDPrefix, n)
+}
@@ -0,0 +1,140 @@
+package freshrss
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"sync"
+
+	"MrRSS/internal/database"
+)
+
+// freshRSSSuffixPattern matches the suffix added to FreshRSS titles and categories
+// that collide with local ones (see createFeedsFromSubscriptions)
+var freshRSSSuffixPattern = regexp.MustCompile(` \(FreshRSS( \d+)?\)$`)
+
+// stripFreshRSSSuffix returns the server-side name of a locally disambiguated title or category
+func stripFreshRSSSuffix(name string) string {
+	return freshRSSSuffixPattern.ReplaceAllString(name, "")
+}
+
+// PushSubscriptionChanges logs in and pushes queued subscription changes to the server.
+// This is called right after a local subscribe, rename, move or unsubscribe.
+func (s *BidirectionalSyncService) PushSubscriptionChanges(ctx context.Context) (int, error) {
+	if err := s.client.Login(ctx); err != nil {
+		return 0, fmt.Errorf("login failed: %w", err)
+	}
+	return s.pushSubscriptionChanges(ctx)
+}
+
+// subscriptionPushMu keeps pushes after local changes and global syncs from
+// sending the same queued change twice
+var subscriptionPushMu sync.Mutex
+
+// pushSubscriptionChanges pushes local subscribe, rename, move and unsubscribe changes.
+// Failed changes are retried on later syncs with exponential backoff.
+func (s *BidirectionalSyncService) pushSubscriptionChanges(ctx context.Context) (int, error) {
+	subscriptionPushMu.Lock()
+	defer subscriptionPushMu.Unlock()
+
+	changes, err := s.db.GetRetryableSubscriptionChanges(200)
+	if err != nil {
+		return 0, fmt.Errorf("get subscription changes: %w", err)
+	}
+
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	log.Printf("[Push Subscriptions] Pushing %d subscription changes", len(changes))
+
+	pushed := 0
+	for _, change := range changes {
+		if err := s.pushSubscriptionChange(ctx, change); err != nil {
+			log.Printf("[Push Subscriptions] ERROR %s %s: %v", change.Action, change.FeedURL, err)
+			if markErr := s.db.MarkSubscriptionChangeFailed(change.ID, err.Error()); markErr != nil {
+				log.Printf("[Push Subscriptions] Failed to record failure: %v", markErr)
+			}
+			continue
+		}
+
+		if err := s.db.MarkSubscriptionChangeSynced(change.ID); err != nil {
+			log.Printf("[Push Subscriptions] Failed to mark change %d as synced: %v", change.ID, err)
+		}
+		pushed++
+	}
+
+	log.Printf("[Push Subscriptions] %d of %d changes pushed", pushed, len(changes))
+	return pushed, nil
+}
+
+// pushSubscriptionChange applies a single queued change on the server
+func (s *BidirectionalSyncService) pushSubscriptionChange(ctx context.Context, change database.SubscriptionChange) error {
+	streamID := change.StreamID
+	if streamID == "" {
+		streamID = "feed/" + change.FeedURL
+	}
+
+	switch change.Action {
+	case database.SubscriptionActionSubscribe:
+		newStreamID, err := s.client.QuickAdd(ctx, change.FeedURL)
+		if err != nil {
+			return err
+		}
+
+		title := stripFreshRSSSuffix(change.Title)
+		category := stripFreshRSSSuffix(change.Category)
+		if title != "" || category != "" {
+			if err := s.client.EditSubscription(ctx, SubscriptionEdit{
+				Action:   "edit",
+				StreamID: newStreamID,
+				Title:    title,
+				AddLabel: category,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// The feed now lives on the server, so later pulls must update it rather than duplicate it
+		return s.db.LinkFeedToFreshRSS(change.FeedID, newStreamID)
+
+	case database.SubscriptionActionUnsubscribe:
+		return s.client.EditSubscription(ctx, SubscriptionEdit{
+			Action:   "unsubscribe",
+			StreamID: streamID,
+		})
+
+	case database.SubscriptionActionRename:
+		return s.client.EditSubscription(ctx, SubscriptionEdit{
+			Action:   "edit",
+			StreamID: streamID,
+			Title:    stripFreshRSSSuffix(change.Title),
+		})
+
+	case database.SubscriptionActionMove:
+		newLabel := stripFreshRSSSuffix(change.Category)
+		oldLabel := stripFreshRSSSuffix(change.OldCategory)
+		if newLabel == oldLabel {
+			return nil
+		}
+		return s.client.EditSubscription(ctx, SubscriptionEdit{
+			Action:      "edit",
+			StreamID:    streamID,
+			AddLabel:    newLabel,
+			RemoveLabel: oldLabel,
+		})
+	}
+
+	return fmt.Errorf("unknown subscription action %q", change.Action)
+}
+
+// pendingSubscriptionURLs returns feed URLs whose local subscription edits have not reached the server
+func (s *BidirectionalSyncService) pendingSubscriptionURLs() map[string]database.SubscriptionAction {
+	pending, err := s.db.GetPendingSubscriptionStreams()
+	if err != nil {
+		log.Printf("Warning: Failed to get pending subscription changes: %v", err)
+		return map[string]database.SubscriptionAction{}
+	}
+	return pending
+}
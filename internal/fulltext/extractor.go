@@ -0,0 +1,307 @@
+// Package fulltext extracts the full text of articles from their web pages,
+// using per-site rules when one matches and readability otherwise.
+package fulltext
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"MrRSS/internal/models"
+
+	"codeberg.org/readeck/go-readability/v2"
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+const (
+	// MaxPages caps how many pages of a multi-page article are joined
+	MaxPages = 10
+	// maxPageSize limits the size of a downloaded page
+	maxPageSize = 10 << 20
+	// userAgent is sent with page requests, as many sites refuse unknown clients
+	userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+)
+
+// Extraction methods reported in a Result
+const (
+	MethodRule        = "rule"
+	MethodReadability = "readability"
+)
+
+// RuleSource looks up the site rule for a host
+type RuleSource interface {
+	GetSiteRuleForHost(host string) (*models.SiteRule, error)
+}
+
+// Result is the extracted article
+type Result struct {
+	Content  string `json:"content"`
+	Title    string `json:"title"`
+	Method   string `json:"method"`    // MethodRule or MethodReadability
+	RuleHost string `json:"rule_host"` // Host of the rule used, empty without one
+	Pages    int    `json:"pages"`     // Pages read
+}
+
+// Extractor fetches pages and extracts their article content
+type Extractor struct {
+	rules   RuleSource
+	client  *http.Client
+	limiter *hostLimiter
+}
+
+// NewExtractor creates an extractor using rules from rules (may be nil) and
+// client for requests (nil = a client with a 30 second timeout). With a
+// positive minInterval, requests to the same host are spaced by at least that long.
+func NewExtractor(rules RuleSource, client *http.Client, minInterval time.Duration) *Extractor {
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Extractor{
+		rules:   rules,
+		client:  client,
+		limiter: newHostLimiter(minInterval),
+	}
+}
+
+// WithClient returns an extractor sending its requests through client,
+// sharing this one's rules and per-host spacing
+func (e *Extractor) WithClient(client *http.Client) *Extractor {
+	return &Extractor{rules: e.rules, client: client, limiter: e.limiter}
+}
+
+// Extract extracts the article at pageURL with the rule of its site
+func (e *Extractor) Extract(ctx context.Context, pageURL string) (*Result, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid article URL %q", pageURL)
+	}
+	var rule *models.SiteRule
+	if e.rules != nil {
+		rule, err = e.rules.GetSiteRuleForHost(u.Hostname())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return e.ExtractWithRule(ctx, pageURL, rule)
+}
+
+// ExtractWithRule extracts the article at pageURL with the given rule (nil = readability only)
+func (e *Extractor) ExtractWithRule(ctx context.Context, pageURL string, rule *models.SiteRule) (*Result, error) {
+	if rule != nil {
+		if err := ValidateRule(rule); err != nil {
+			return nil, err
+		}
+	}
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid article URL %q", pageURL)
+	}
+
+	doc, err := e.fetch(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Pages: 1}
+	if rule != nil {
+		result.RuleHost = rule.Host
+	}
+
+	if rule != nil && rule.ContentSelector != "" {
+		result.Title = pageTitle(doc)
+		content := ruleContent(doc, rule)
+		// Follow the article's next pages, stopping at loops
+		seen := map[string]bool{u.String(): true}
+		pageDoc, pageBase := doc, u
+		for result.Pages < MaxPages && rule.NextPageSelector != "" && content != "" {
+			next := nextPageURL(pageDoc, pageBase, rule.NextPageSelector)
+			if next == nil || seen[next.String()] {
+				break
+			}
+			seen[next.String()] = true
+			nextDoc, err := e.fetch(ctx, next)
+			if err != nil {
+				break
+			}
+			content += ruleContent(nextDoc, rule)
+			result.Pages++
+			pageDoc, pageBase = nextDoc, next
+		}
+		if content != "" {
+			result.Content = content
+			result.Method = MethodRule
+			return result, nil
+		}
+		// The selector matched nothing, let readability try the page
+	}
+
+	if rule != nil {
+		strip(doc, rule)
+	}
+	article, err := readability.FromDocument(doc, u)
+	if err != nil {
+		return nil, fmt.Errorf("readability parse: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := article.RenderHTML(&buf); err != nil {
+		return nil, fmt.Errorf("render HTML: %w", err)
+	}
+	result.Content = buf.String()
+	result.Title = article.Title()
+	result.Method = MethodReadability
+	return result, nil
+}
+
+// fetch downloads and parses a page, waiting for its host's turn
+func (e *Extractor) fetch(ctx context.Context, u *url.URL) (*html.Node, error) {
+	if err := e.limiter.wait(ctx, u.Hostname()); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: HTTP %d", u, resp.StatusCode)
+	}
+
+	doc, err := html.Parse(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", u, err)
+	}
+	return doc, nil
+}
+
+// ValidateRule checks a rule has a host and valid selectors
+func ValidateRule(rule *models.SiteRule) error {
+	if strings.TrimSpace(rule.Host) == "" {
+		return errors.New("host is required")
+	}
+	for _, selector := range append([]string{rule.ContentSelector, rule.NextPageSelector}, stripSelectors(rule)...) {
+		if selector == "" {
+			continue
+		}
+		if _, err := compile(selector); err != nil {
+			return fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+	}
+	return nil
+}
+
+// stripSelectors splits the rule's strip list
+func stripSelectors(rule *models.SiteRule) []string {
+	var selectors []string
+	for _, line := range strings.Split(rule.StripSelectors, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			selectors = append(selectors, line)
+		}
+	}
+	return selectors
+}
+
+// isXPath reports whether a selector is an XPath expression rather than CSS
+func isXPath(selector string) bool {
+	return strings.HasPrefix(selector, "/") || strings.HasPrefix(selector, "(") || strings.HasPrefix(selector, "./")
+}
+
+// selector finds the nodes matching a CSS selector or XPath expression
+type selector func(doc *html.Node) []*html.Node
+
+// compile parses a CSS selector or XPath expression
+func compile(expr string) (selector, error) {
+	if isXPath(expr) {
+		if _, err := htmlquery.QueryAll(&html.Node{Type: html.DocumentNode}, expr); err != nil {
+			return nil, err
+		}
+		return func(doc *html.Node) []*html.Node {
+			nodes, _ := htmlquery.QueryAll(doc, expr)
+			return nodes
+		}, nil
+	}
+	sel, err := cascadia.ParseGroup(expr)
+	if err != nil {
+		return nil, err
+	}
+	return func(doc *html.Node) []*html.Node {
+		return cascadia.QueryAll(doc, sel)
+	}, nil
+}
+
+// find returns the nodes matching an expression validated by ValidateRule
+func find(doc *html.Node, expr string) []*html.Node {
+	sel, err := compile(expr)
+	if err != nil {
+		return nil
+	}
+	return sel(doc)
+}
+
+// strip removes the rule's strip list from the page
+func strip(doc *html.Node, rule *models.SiteRule) {
+	for _, expr := range stripSelectors(rule) {
+		for _, node := range find(doc, expr) {
+			if node.Parent != nil {
+				node.Parent.RemoveChild(node)
+			}
+		}
+	}
+}
+
+// ruleContent strips the page and returns the HTML of the content elements
+func ruleContent(doc *html.Node, rule *models.SiteRule) string {
+	strip(doc, rule)
+	var buf bytes.Buffer
+	for _, node := range find(doc, rule.ContentSelector) {
+		if node.Type == html.TextNode {
+			buf.WriteString(html.EscapeString(node.Data))
+			continue
+		}
+		_ = html.Render(&buf, node)
+	}
+	return buf.String()
+}
+
+// nextPageURL returns the next page link found with the rule's selector
+func nextPageURL(doc *html.Node, base *url.URL, expr string) *url.URL {
+	for _, node := range find(doc, expr) {
+		href := ""
+		if node.Type == html.ElementNode {
+			href = htmlquery.SelectAttr(node, "href")
+		} else {
+			href = strings.TrimSpace(htmlquery.InnerText(node))
+		}
+		if href == "" {
+			continue
+		}
+		next, err := base.Parse(href)
+		if err != nil || (next.Scheme != "http" && next.Scheme != "https") {
+			continue
+		}
+		next.Fragment = ""
+		return next
+	}
+	return nil
+}
+
+// pageTitle returns the text of the page's title element
+func pageTitle(doc *html.Node) string {
+	if node := htmlquery.FindOne(doc, "//title"); node != nil {
+		return strings.TrimSpace(htmlquery.InnerText(node))
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package fulltext
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"MrRSS/internal/models"
+)
+
+// staticRules returns the same rule for every host
+type staticRules struct{ rule *models.SiteRule }
+
+func (s staticRules) GetSiteRuleForHost(host string) (*models.SiteRule, error) {
+	return s.rule, nil
+}
+
+const articlePage = `<html><head><title>Story</title></head><body>
+<nav>Menu</nav>
+<div class="story"><p>Page %d text.</p><div class="ad">Buy now</div></div>
+%s
+</body></html>`
+
+func newArticleServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/story" && r.URL.Query().Get("page") == "2":
+			fmt.Fprintf(w, articlePage, 2, `<a class="next" href="/story-3">Next</a>`)
+		case r.URL.Path == "/story":
+			fmt.Fprintf(w, articlePage, 1, `<a class="next" href="/story?page=2">Next</a>`)
+		case r.URL.Path == "/story-3":
+			fmt.Fprintf(w, articlePage, 3, `<a class="next" href="/story">Back to page 1</a>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestExtractWithRule_SelectorsAndNextPages(t *testing.T) {
+	server := newArticleServer(t)
+	defer server.Close()
+
+	for _, tc := range []struct {
+		name string
+		rule *models.SiteRule
+	}{
+		{"css", &models.SiteRule{Host: "example.com", ContentSelector: "div.story", StripSelectors: ".ad\nnav", NextPageSelector: "a.next"}},
+		{"xpath", &models.SiteRule{Host: "example.com", ContentSelector: "//div[@class='story']", StripSelectors: "//div[@class='ad']", NextPageSelector: "//a[@class='next']/@href"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			extractor := NewExtractor(staticRules{tc.rule}, nil, 0)
+			result, err := extractor.Extract(context.Background(), server.URL+"/story")
+			if err != nil {
+				t.Fatalf("Extract() error = %v", err)
+			}
+			if result.Method != MethodRule || result.RuleHost != "example.com" || result.Title != "Story" {
+				t.Errorf("Unexpected result: method=%q host=%q title=%q", result.Method, result.RuleHost, result.Title)
+			}
+			// Pages 1 to 3, the link of page 3 back to page 1 ends the loop
+			if result.Pages != 3 {
+				t.Errorf("Pages = %d, want 3", result.Pages)
+			}
+			for _, want := range []string{"Page 1 text.", "Page 2 text.", "Page 3 text."} {
+				if !strings.Contains(result.Content, want) {
+					t.Errorf("Content %q lacks %q", result.Content, want)
+				}
+			}
+			if strings.Contains(result.Content, "Buy now") {
+				t.Errorf("Content %q should not contain stripped elements", result.Content)
+			}
+		})
+	}
+}
+
+func TestExtractWithRule_FallsBackToReadability(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><head><title>Long read</title></head><body><article>`+
+			strings.Repeat(`<p>This paragraph is long enough for readability to consider it the article body, with commas, and more words.</p>`, 10)+
+			`</article></body></html>`)
+	}))
+	defer server.Close()
+
+	rule := &models.SiteRule{Host: "example.com", ContentSelector: "div.missing"}
+	result, err := NewExtractor(nil, nil, 0).ExtractWithRule(context.Background(), server.URL, rule)
+	if err != nil {
+		t.Fatalf("ExtractWithRule() error = %v", err)
+	}
+	if result.Method != MethodReadability || !strings.Contains(result.Content, "long enough for readability") {
+		t.Errorf("Expected readability output, got method=%q content=%q", result.Method, result.Content)
+	}
+}
+
+func TestValidateRule(t *testing.T) {
+	valid := &models.SiteRule{Host: "example.com", ContentSelector: "article .body", StripSelectors: "//aside\n\n.share", NextPageSelector: "a[rel=next]"}
+	if err := ValidateRule(valid); err != nil {
+		t.Errorf("ValidateRule() error = %v", err)
+	}
+	for _, rule := range []*models.SiteRule{
+		{ContentSelector: "article"},
+		{Host: "example.com", ContentSelector: "div[["},
+		{Host: "example.com", StripSelectors: "//div[@class="},
+	} {
+		if err := ValidateRule(rule); err == nil {
+			t.Errorf("ValidateRule(%+v) should fail", rule)
+		}
+	}
+}
+
+func TestHostLimiter(t *testing.T) {
+	limiter := newHostLimiter(50 * time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := limiter.wait(ctx, "a.example"); err != nil {
+			t.Fatalf("wait() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("Three requests to one host took %v, want at least 100ms", elapsed)
+	}
+
+	// Other hosts don't wait for it
+	start = time.Now()
+	if err := limiter.wait(ctx, "b.example"); err != nil {
+		t.Fatalf("wait() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("Request to another host waited %v", elapsed)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_ = limiter.wait(ctx, "a.example")
+	if err := limiter.wait(cancelled, "a.example"); err == nil {
+		t.Error("wait() should fail once the context is cancelled")
+	}
+}
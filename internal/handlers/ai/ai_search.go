@@ -13,11 +13,13 @@ import (
 	"MrRSS/internal/config"
 	"MrRSS/internal/handlers/core"
 	"MrRSS/internal/handlers/response"
+	"MrRSS/internal/models"
 )
 
 // AISearchRequest represents the request for AI-powered search
 type AISearchRequest struct {
-	Query string `json:"query"`
+	Query          string `json:"query"`
+	IncludeArchive bool   `json:"include_archive"` // Also search the article archive
 }
 
 // AISearchResponse represents the response from AI search
@@ -175,7 +177,8 @@ func buildSearchSQL(terms *SearchTerms, limit int) string {
 		relevanceScore = strings.Join(scoreTerms, " + ")
 	}
 
-	// Build full query with LEFT JOIN to article_contents for content search
+	// Build full query with LEFT JOIN to article_contents for content search,
+	// decompressing the contents
 	whereClause := strings.Join(requiredConditions, " OR ")
 	query := fmt.Sprintf(`
 		SELECT a.id, a.feed_id, a.title, a.url, a.image_url, a.audio_url, a.video_url,
@@ -184,7 +187,7 @@ func buildSearchSQL(terms *SearchTerms, limit int) string {
 			   (%s) AS relevance_score
 		FROM articles a
 		JOIN feeds f ON a.feed_id = f.id
-		LEFT JOIN article_contents c ON a.id = c.article_id
+		LEFT JOIN (SELECT article_id, content_text(content) AS content FROM article_contents) c ON a.id = c.article_id
 		WHERE a.is_hidden = 0 AND (%s)
 		ORDER BY relevance_score DESC, a.published_at DESC
 		LIMIT %d
@@ -195,7 +198,7 @@ func buildSearchSQL(terms *SearchTerms, limit int) string {
 
 // HandleAISearch handles POST /api/ai/search for AI-powered article search
 // @Summary      AI-powered article search
-// @Description  Use AI to expand keywords and search articles with relevance ranking
+// @Description  Use AI to expand keywords and search articles with relevance ranking. With include_archive, archived articles follow, marked archived.
 // @Tags         ai
 // @Accept       json
 // @Produce      json
@@ -335,10 +338,21 @@ func HandleAISearch(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("[AI Search] Found %d articles", len(articles))
 
+	// Archived articles come after the ones in the database
+	var archived []models.Article
+	if req.IncludeArchive {
+		archived, err = h.DB.SearchArchiveWithSQL(searchSQL)
+		if err != nil {
+			log.Printf("[AI Search] Archive query error: %v", err)
+		} else {
+			log.Printf("[AI Search] Found %d archived articles", len(archived))
+		}
+	}
+
 	// Convert articles to response format
-	articleMaps := make([]map[string]any, len(articles))
-	for i, article := range articles {
-		articleMaps[i] = map[string]any{
+	articleMaps := make([]map[string]any, 0, len(articles)+len(archived))
+	for i, article := range append(articles, archived...) {
+		articleMaps = append(articleMaps, map[string]any{
 			"id":               article.ID,
 			"feed_id":          article.FeedID,
 			"title":            article.Title,
@@ -355,13 +369,14 @@ func HandleAISearch(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 			"author":           article.Author,
 			"translated_title": article.TranslatedTitle,
 			"summary":          article.Summary,
-		}
+			"archived":         i >= len(articles),
+		})
 	}
 
 	response.JSON(w, AISearchResponse{
 		Success:     true,
 		Articles:    articleMaps,
 		SearchTerms: strings.Join(allTerms, ", "),
-		TotalCount:  len(articles),
+		TotalCount:  len(articleMaps),
 	})
 }
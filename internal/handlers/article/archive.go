@@ -0,0 +1,126 @@
+package article
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/handlers/response"
+	"MrRSS/internal/models"
+)
+
+// ArchiveRestoreRequest selects the archived articles to restore
+type ArchiveRestoreRequest struct {
+	Year int     `json:"year"` // 0 for all years
+	IDs  []int64 `json:"ids"`  // Omitted for all articles of the year
+}
+
+// ArchiveRestoreResponse tells how many archived articles were restored
+type ArchiveRestoreResponse struct {
+	Restored int64 `json:"restored"`
+	Skipped  int64 `json:"skipped"` // Their feed was deleted, or they are in the database again
+}
+
+// HandleArchives lists the article archives.
+// @Summary      List article archives
+// @Description  List the archive of every year, newest first, with its number of articles and file size. With archive_enabled, cleanups move articles to the archive of the year they were published in instead of deleting them.
+// @Tags         articles
+// @Produce      json
+// @Success      200  {array}   database.ArchiveInfo  "Archives"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /archive [get]
+func HandleArchives(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	archives, err := h.DB.ListArchives()
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	response.JSON(w, archives)
+}
+
+// HandleArchiveSearch searches archived articles.
+// @Summary      Search archived articles
+// @Description  Find archived articles whose title, summary or cached content contains a keyword, newest first
+// @Tags         articles
+// @Produce      json
+// @Param        q      query     string  true   "Keyword"
+// @Param        limit  query     int     false  "Maximum number of articles (default 50, at most 500)"
+// @Success      200  {array}   models.Article  "Archived articles"
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /archive/search [get]
+func HandleArchiveSearch(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	keyword := strings.TrimSpace(r.URL.Query().Get("q"))
+	if keyword == "" {
+		response.Error(w, errors.New("q is required"), http.StatusBadRequest)
+		return
+	}
+	limit := 50
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			response.Error(w, errors.New("limit must be a positive number"), http.StatusBadRequest)
+			return
+		}
+		limit = min(n, 500)
+	}
+
+	articles, err := h.DB.SearchArchive(keyword, limit)
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	if articles == nil {
+		articles = []models.Article{}
+	}
+	response.JSON(w, articles)
+}
+
+// HandleArchiveRestore moves archived articles back to the database.
+// @Summary      Restore archived articles
+// @Description  Move archived articles back to the database with their cached contents: the given IDs, or all articles, of a year or of all years. Articles whose feed was deleted, or that were fetched again since, stay archived.
+// @Tags         articles
+// @Accept       json
+// @Produce      json
+// @Param        request  body      ArchiveRestoreRequest  true  "Articles to restore"
+// @Success      200  {object}  ArchiveRestoreResponse  "Restored articles"
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /archive/restore [post]
+func HandleArchiveRestore(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req ArchiveRestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	restored, skipped, err := h.DB.RestoreArchivedArticles(req.Year, req.IDs)
+	if errors.Is(err, database.ErrArchiveUnavailable) {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	response.JSON(w, ArchiveRestoreResponse{Restored: restored, Skipped: skipped})
+}
@@ -297,7 +297,7 @@ func HandleCleanupArticleContent(h *core.Handler, w http.ResponseWriter, r *http
 // @Tags         articles
 // @Accept       json
 // @Produce      json
-// @Success      200  {object}  map[string]interface{}  "Cache info (cached_articles count)"
+// @Success      200  {object}  database.ArticleContentStats  "Cache info (cached_articles, compressed_articles, raw_size and stored_size in bytes)"
 // @Failure      500  {object}  map[string]string  "Internal server error"
 // @Router       /articles/content-cache-info [get]
 func HandleGetArticleContentCacheInfo(h *core.Handler, w http.ResponseWriter, r *http.Request) {
@@ -306,16 +306,14 @@ func HandleGetArticleContentCacheInfo(h *core.Handler, w http.ResponseWriter, r
 		return
 	}
 
-	count, err := h.DB.GetArticleContentCount()
+	stats, err := h.DB.GetArticleContentStats()
 	if err != nil {
 		log.Printf("Error getting article content cache info: %v", err)
 		response.Error(w, err, http.StatusInternalServerError)
 		return
 	}
 
-	response.JSON(w, map[string]interface{}{
-		"cached_articles": count,
-	})
+	response.JSON(w, stats)
 }
 
 // HandleMarkRelativeToArticle marks articles as read relative to a reference article's published time.
@@ -67,9 +67,9 @@ func HandleGetArticleContent(h *core.Handler, w http.ResponseWriter, r *http.Req
 	})
 }
 
-// HandleFetchFullArticle fetches the full article content from the original URL using readability.
+// HandleFetchFullArticle fetches the full article content from the original URL using site rules or readability.
 // @Summary      Fetch full article content
-// @Description  Fetch the full article content from the original URL using readability extraction (requires full_text_fetch_enabled setting)
+// @Description  Fetch the full article content from the original URL using the site's extraction rule or readability (requires full_text_fetch_enabled setting or the feed's full_text_on_ingest)
 // @Tags         articles
 // @Accept       json
 // @Produce      json
@@ -105,10 +105,17 @@ func HandleFetchFullArticle(h *core.Handler, w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Check if full-text fetching is enabled (global setting only)
+	// Get feed URL to use as referer for image proxying
+	feed, err := h.DB.GetFeedByID(article.FeedID)
+	var feedURL string
+	if err == nil && feed != nil {
+		feedURL = feed.URL
+	}
+
+	// Check if full-text fetching is enabled, globally or for the article's feed
 	// auto_expand_content only affects auto-expansion behavior, not manual button clicks
 	fullTextEnabledStr, _ := h.DB.GetSetting("full_text_fetch_enabled")
-	if fullTextEnabledStr != "true" {
+	if fullTextEnabledStr != "true" && (feed == nil || !feed.FullTextOnIngest) {
 		response.Error(w, nil, http.StatusForbidden)
 		return
 	}
@@ -121,13 +128,6 @@ func HandleFetchFullArticle(h *core.Handler, w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	// Get feed URL to use as referer for image proxying
-	feed, err := h.DB.GetFeedByID(article.FeedID)
-	var feedURL string
-	if err == nil && feed != nil {
-		feedURL = feed.URL
-	}
-
 	response.JSON(w, map[string]string{
 		"content":  fullContent,
 		"feed_url": feedURL,
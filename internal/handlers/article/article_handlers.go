@@ -5,7 +5,6 @@ import (
 	"log"
 	"net/http"
 	"sort"
-	"strings"
 	"time"
 
 	"MrRSS/internal/handlers/core"
@@ -68,6 +67,7 @@ func HandleProgress(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 type TaskDetailsResponse struct {
 	PoolTasks  []PoolTaskInfo  `json:"pool_tasks"`
 	QueueTasks []QueueTaskInfo `json:"queue_tasks"`
+	Hosts      []HostInfo      `json:"hosts"`
 }
 
 // PoolTaskInfo contains information about a task in the pool
@@ -85,9 +85,19 @@ type QueueTaskInfo struct {
 	Position  int    `json:"position"`
 }
 
+// HostInfo contains the refresh state of a host
+type HostInfo struct {
+	Host         string `json:"host"`
+	Active       int    `json:"active"`
+	Queued       int    `json:"queued"`
+	BackoffUntil string `json:"backoff_until,omitempty"` // Set while the host is in backoff
+	Throttles    int    `json:"throttles"`               // 429/503 responses since the last success
+	LastStatus   int    `json:"last_status,omitempty"`
+}
+
 // HandleTaskDetails returns detailed information about tasks in pool and queue
 // @Summary      Get task details
-// @Description  Get detailed information about tasks in pool and queue
+// @Description  Get detailed information about tasks in pool and queue, and the per-host refresh limits state
 // @Tags         articles
 // @Accept       json
 // @Produce      json
@@ -124,9 +134,26 @@ func HandleTaskDetails(h *core.Handler, w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	// Get per-host state (concurrency, queued feeds and backoff)
+	hostsRaw := tm.GetHostStates()
+	hosts := make([]HostInfo, len(hostsRaw))
+	for i, host := range hostsRaw {
+		hosts[i] = HostInfo{
+			Host:       host.Host,
+			Active:     host.Active,
+			Queued:     host.Queued,
+			Throttles:  host.Throttles,
+			LastStatus: host.LastStatus,
+		}
+		if !host.BackoffUntil.IsZero() {
+			hosts[i].BackoffUntil = host.BackoffUntil.Format(time.RFC3339)
+		}
+	}
+
 	resp := TaskDetailsResponse{
 		PoolTasks:  poolTasks,
 		QueueTasks: queueTasks,
+		Hosts:      hosts,
 	}
 
 	response.JSON(w, resp)
@@ -240,26 +267,9 @@ func HandleFilteredArticles(h *core.Handler, w http.ResponseWriter, r *http.Requ
 			articleIDs[i] = article.ID
 		}
 
-		// Build placeholders for SQL query
-		placeholders := make([]string, len(articleIDs))
-		args := make([]interface{}, len(articleIDs))
-		for i, id := range articleIDs {
-			placeholders[i] = "?"
-			args[i] = id
-		}
-
 		// Query all article contents at once
-		query := `SELECT article_id, content FROM article_contents WHERE article_id IN (` + strings.Join(placeholders, ",") + `)`
-		rows, err := h.DB.Query(query, args...)
-		if err == nil {
-			defer rows.Close()
-			for rows.Next() {
-				var articleID int64
-				var content string
-				if err := rows.Scan(&articleID, &content); err == nil {
-					articleContents[articleID] = content
-				}
-			}
+		if contents, err := h.DB.GetArticleContentsBatch(articleIDs); err == nil {
+			articleContents = contents
 		}
 	}
 
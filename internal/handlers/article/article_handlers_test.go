@@ -198,3 +198,115 @@ func TestHandleExportToObsidian(t *testing.T) {
 		t.Fatalf("Export not successful: %v", response)
 	}
 }
+
+func TestHandleScheduleRules(t *testing.T) {
+	h := setupHandler(t)
+	feedID, err := h.DB.AddFeed(&models.Feed{Title: "F", URL: "http://x", Category: "Tech"})
+	if err != nil {
+		t.Fatalf("AddFeed: %v", err)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/schedule/rules", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		article.HandleScheduleRules(h, w, req)
+		return w
+	}
+
+	for body, want := range map[string]int{
+		`{"category": "Tech", "schedule": "mon-fri 25:00-26:00"}`:       http.StatusBadRequest,
+		`{"schedule": "0 7 * * *"}`:                                     http.StatusBadRequest,
+		`{"feed_id": 999, "schedule": "0 7 * * *"}`:                     http.StatusNotFound,
+		`{"category": "Tech", "schedule": "weekends off"}`:              http.StatusOK,
+		fmt.Sprintf(`{"feed_id": %d, "schedule": "0 7 * * *"}`, feedID): http.StatusOK,
+	} {
+		if w := post(body); w.Code != want {
+			t.Errorf("POST %s: status %d, want %d", body, w.Code, want)
+		}
+	}
+
+	w := post(`{"category": "Tech", "schedule": ""}`)
+	var rules []article.ScheduleRuleEntry
+	if err := json.NewDecoder(w.Body).Decode(&rules); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(rules) != 1 || rules[0].FeedID != feedID || rules[0].FeedTitle != "F" || rules[0].Schedule != "0 7 * * *" {
+		t.Errorf("Rules after removing the category's = %+v, want only the feed's", rules)
+	}
+
+	// The feed is now refreshed at its cron times
+	req := httptest.NewRequest(http.MethodGet, "/api/schedule", nil)
+	w = httptest.NewRecorder()
+	article.HandleSchedule(h, w, req)
+	var entries []article.ScheduleEntry
+	if err := json.NewDecoder(w.Body).Decode(&entries); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Mode != "cron" || entries[0].Rule != "0 7 * * *" {
+		t.Errorf("Schedule = %+v, want the feed in cron mode", entries)
+	}
+}
+
+func TestHandleRetentionPolicies(t *testing.T) {
+	h := setupHandler(t)
+	feedID, err := h.DB.AddFeed(&models.Feed{Title: "News", URL: "http://news", Category: "News"})
+	if err != nil {
+		t.Fatalf("AddFeed: %v", err)
+	}
+	old := &models.Article{FeedID: feedID, Title: "old", URL: "http://news/old", PublishedAt: time.Now().AddDate(0, 0, -3)}
+	if err := h.DB.SaveArticle(old); err != nil {
+		t.Fatalf("SaveArticle: %v", err)
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/retention/policies", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		article.HandleRetentionPolicies(h, w, req)
+		return w
+	}
+	for body, want := range map[string]int{
+		`{"kind": "keep_days", "value": 2}`:                          http.StatusBadRequest,
+		`{"category": "News", "tag_id": 1, "kind": "keep_forever"}`:  http.StatusBadRequest,
+		`{"category": "News", "kind": "keep_days"}`:                  http.StatusBadRequest,
+		`{"category": "News", "kind": "keep_some"}`:                  http.StatusBadRequest,
+		`{"tag_id": 42, "kind": "keep_forever"}`:                     http.StatusNotFound,
+		`{"category": "News", "kind": "keep_days", "value": 2}`:      http.StatusOK,
+		`{"category": "Blogs", "kind": "keep_forever", "value": 10}`: http.StatusOK,
+	} {
+		if w := post(body); w.Code != want {
+			t.Errorf("POST %s: status %d, want %d", body, w.Code, want)
+		}
+	}
+
+	h.DB.SetSetting("auto_cleanup_enabled", "false")
+	req := httptest.NewRequest(http.MethodGet, "/api/retention/preview", nil)
+	w := httptest.NewRecorder()
+	article.HandleRetentionPreview(h, w, req)
+	var previews []article.RetentionPreviewEntry
+	if err := json.NewDecoder(w.Body).Decode(&previews); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(previews) != 2 {
+		t.Fatalf("Got %d previews, want 2 without the default policy", len(previews))
+	}
+	for _, p := range previews {
+		want := int64(0)
+		if p.Policy.Category == "News" {
+			want = 1
+		}
+		if p.Articles != want || p.Policy.Value != 0 && p.Policy.Kind == "keep_forever" {
+			t.Errorf("Preview %+v, want %d articles", p, want)
+		}
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/retention/policies?id=%d", previews[0].Policy.ID), nil)
+	w = httptest.NewRecorder()
+	article.HandleRetentionPolicies(h, w, req)
+	var policies []models.RetentionPolicy
+	if err := json.NewDecoder(w.Body).Decode(&policies); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(policies) != 1 {
+		t.Errorf("Got %d policies after deleting one, want 1", len(policies))
+	}
+}
@@ -0,0 +1,163 @@
+package article
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/handlers/response"
+	"MrRSS/internal/schedule"
+)
+
+// ScheduleEntry is an upcoming scheduled refresh of a feed
+type ScheduleEntry struct {
+	FeedID        int64  `json:"feed_id"`
+	FeedTitle     string `json:"feed_title"`
+	Category      string `json:"category"`
+	Mode          string `json:"mode"`           // "global", "custom", "intelligent" or "cron"
+	Rule          string `json:"rule,omitempty"` // Refresh schedule of the feed or its category
+	NextDueAt     string `json:"next_due_at"`
+	LastAttemptAt string `json:"last_attempt_at,omitempty"`
+	LastSuccessAt string `json:"last_success_at,omitempty"`
+	Queued        bool   `json:"queued"` // Waiting in the refresh queue
+}
+
+// HandleSchedule lists the upcoming scheduled feed refreshes, soonest first.
+// @Summary      List upcoming refreshes
+// @Description  List the next scheduled refresh of every feed refreshed automatically, soonest first. Refreshes wait for the feed's refresh schedule and the quiet hours to allow them. Paused and never-refresh feeds, and feeds left out by metered-connection mode, are left out.
+// @Tags         articles
+// @Produce      json
+// @Param        limit  query     int  false  "Maximum number of refreshes (default: all)"  minimum(1)
+// @Success      200  {array}   ScheduleEntry  "Upcoming refreshes"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /schedule [get]
+func HandleSchedule(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	refreshes, err := h.Fetcher.UpcomingRefreshes(time.Now())
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 && limit < len(refreshes) {
+		refreshes = refreshes[:limit]
+	}
+
+	entries := make([]ScheduleEntry, len(refreshes))
+	for i, refresh := range refreshes {
+		entries[i] = ScheduleEntry{
+			FeedID:    refresh.FeedID,
+			FeedTitle: refresh.Title,
+			Category:  refresh.Category,
+			Mode:      refresh.Mode,
+			Rule:      refresh.Rule,
+			NextDueAt: refresh.NextDueAt.Format(time.RFC3339),
+			Queued:    refresh.Queued,
+		}
+		if !refresh.LastAttemptAt.IsZero() {
+			entries[i].LastAttemptAt = refresh.LastAttemptAt.Format(time.RFC3339)
+		}
+		if !refresh.LastSuccessAt.IsZero() {
+			entries[i].LastSuccessAt = refresh.LastSuccessAt.Format(time.RFC3339)
+		}
+	}
+
+	response.JSON(w, entries)
+}
+
+// ScheduleRuleEntry is the refresh schedule of a feed or a category
+type ScheduleRuleEntry struct {
+	FeedID    int64  `json:"feed_id,omitempty"`
+	FeedTitle string `json:"feed_title,omitempty"`
+	Category  string `json:"category,omitempty"`
+	Schedule  string `json:"schedule"` // Cron expressions and time windows separated by ";", empty to remove
+}
+
+// HandleScheduleRules lists or sets the refresh schedules of feeds and categories.
+// @Summary      Feed and category refresh schedules
+// @Description  GET lists the refresh schedules of feeds and categories. POST sets the schedule of a feed (feed_id) or a category (category), or removes it with an empty schedule. A schedule is made of clauses separated by ";": cron expressions giving the times to refresh at ("0 7 * * *"), time windows refreshes are limited to ("mon-fri 09:00-18:00"), and windows followed by "off" during which nothing is refreshed ("weekends off"). A feed's own schedule overrides the one of its category, which applies to its subcategories too.
+// @Tags         articles
+// @Accept       json
+// @Produce      json
+// @Param        request  body      ScheduleRuleEntry  false  "Schedule to set (POST)"
+// @Success      200  {array}   ScheduleRuleEntry  "Refresh schedules"
+// @Failure      400  {object}  map[string]string  "Bad request (invalid schedule, or neither or both of feed_id and category)"
+// @Failure      404  {object}  map[string]string  "Feed not found"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /schedule/rules [get]
+// @Router       /schedule/rules [post]
+func HandleScheduleRules(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req ScheduleRuleEntry
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.Error(w, err, http.StatusBadRequest)
+			return
+		}
+		req.Category = strings.Trim(strings.TrimSpace(req.Category), "/")
+		if (req.FeedID == 0) == (req.Category == "") {
+			response.Error(w, errors.New("either feed_id or category is required"), http.StatusBadRequest)
+			return
+		}
+		if strings.TrimSpace(req.Schedule) != "" {
+			if _, err := schedule.Parse(req.Schedule); err != nil {
+				response.Error(w, err, http.StatusBadRequest)
+				return
+			}
+		}
+		if req.FeedID != 0 {
+			if _, err := h.DB.GetFeedByID(req.FeedID); errors.Is(err, sql.ErrNoRows) {
+				response.Error(w, fmt.Errorf("feed %d not found", req.FeedID), http.StatusNotFound)
+				return
+			} else if err != nil {
+				response.Error(w, err, http.StatusInternalServerError)
+				return
+			}
+		}
+		rule := database.ScheduleRule{FeedID: req.FeedID, Category: req.Category, Spec: req.Schedule}
+		if err := h.DB.SaveScheduleRule(rule); err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+	default:
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	rules, err := h.DB.GetScheduleRules()
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	feeds, err := h.DB.GetFeeds()
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	titles := make(map[int64]string, len(feeds))
+	for _, feed := range feeds {
+		titles[feed.ID] = feed.Title
+	}
+
+	entries := make([]ScheduleRuleEntry, len(rules))
+	for i, rule := range rules {
+		entries[i] = ScheduleRuleEntry{
+			FeedID:    rule.FeedID,
+			FeedTitle: titles[rule.FeedID],
+			Category:  rule.Category,
+			Schedule:  rule.Spec,
+		}
+	}
+	response.JSON(w, entries)
+}
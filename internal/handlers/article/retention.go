@@ -0,0 +1,157 @@
+package article
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/handlers/response"
+	"MrRSS/internal/models"
+)
+
+// RetentionPreviewEntry is what a retention policy would delete on the next cleanup
+type RetentionPreviewEntry struct {
+	Policy   models.RetentionPolicy `json:"policy"`
+	Default  bool                   `json:"default"` // The global default policy (max_article_age_days) of feeds without one
+	Feeds    int                    `json:"feeds"`
+	Articles int64                  `json:"articles"`
+	Contents int64                  `json:"contents"`
+}
+
+// validateRetentionPolicy checks a retention policy sent by the client
+func validateRetentionPolicy(h *core.Handler, p *models.RetentionPolicy) (int, error) {
+	p.Category = strings.Trim(strings.TrimSpace(p.Category), "/")
+	targets := 0
+	for _, set := range []bool{p.FeedID != 0, p.TagID != 0, p.Category != ""} {
+		if set {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return http.StatusBadRequest, errors.New("exactly one of feed_id, tag_id and category is required")
+	}
+
+	switch p.Kind {
+	case models.RetentionKeepForever:
+		p.Value = 0
+	case models.RetentionKeepLast, models.RetentionKeepDays, models.RetentionDeleteReadAfter:
+		if p.Value <= 0 {
+			return http.StatusBadRequest, fmt.Errorf("%s needs a positive value", p.Kind)
+		}
+	default:
+		return http.StatusBadRequest, fmt.Errorf("unknown retention kind %q", p.Kind)
+	}
+	if p.ContentDays < -1 {
+		return http.StatusBadRequest, errors.New("content_days must be -1 (forever), 0 (global default) or a number of days")
+	}
+
+	if p.FeedID != 0 {
+		if _, err := h.DB.GetFeedByID(p.FeedID); errors.Is(err, sql.ErrNoRows) {
+			return http.StatusNotFound, fmt.Errorf("feed %d not found", p.FeedID)
+		} else if err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+	if p.TagID != 0 {
+		if tag, err := h.DB.GetTagByID(p.TagID); err != nil {
+			return http.StatusInternalServerError, err
+		} else if tag == nil {
+			return http.StatusNotFound, fmt.Errorf("tag %d not found", p.TagID)
+		}
+	}
+	return 0, nil
+}
+
+// HandleRetentionPolicies lists, sets or removes retention policies.
+// @Summary      Retention policies
+// @Description  GET lists the retention policies of feeds, tags and categories. POST sets the policy of a feed (feed_id), tag (tag_id) or category (category), replacing the one it had. DELETE removes a policy. Kinds are keep_forever, keep_last (value = articles), keep_days (value = days) and delete_read_after (value = days, unread articles are kept). content_days sets how long cached article contents are kept: -1 forever, 0 like feeds without a policy. A feed's own policy comes first, then the one of its first tag with a policy, then the one of its category or closest parent category. Favorite and read later articles are always kept.
+// @Tags         articles
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.RetentionPolicy  false  "Policy to set (POST)"
+// @Param        id       query     int  false  "Policy ID (DELETE)"
+// @Success      200  {array}   models.RetentionPolicy  "Retention policies"
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      404  {object}  map[string]string  "Feed or tag not found"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /retention/policies [get]
+// @Router       /retention/policies [post]
+// @Router       /retention/policies [delete]
+func HandleRetentionPolicies(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var policy models.RetentionPolicy
+		if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+			response.Error(w, err, http.StatusBadRequest)
+			return
+		}
+		if code, err := validateRetentionPolicy(h, &policy); err != nil {
+			response.Error(w, err, code)
+			return
+		}
+		if _, err := h.DB.SaveRetentionPolicy(policy); err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+	case http.MethodDelete:
+		id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		if err != nil {
+			response.Error(w, err, http.StatusBadRequest)
+			return
+		}
+		if err := h.DB.DeleteRetentionPolicy(id); err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+	default:
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	policies, err := h.DB.GetRetentionPolicies()
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	response.JSON(w, policies)
+}
+
+// HandleRetentionPreview shows how many articles each retention policy would delete.
+// @Summary      Preview retention policies
+// @Description  Count the articles and cached article contents each retention policy would delete on the next cleanup. With auto cleanup on, the global default policy of feeds without one (max_article_age_days) comes last.
+// @Tags         articles
+// @Produce      json
+// @Success      200  {array}   RetentionPreviewEntry  "What each policy would delete"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /retention/preview [get]
+func HandleRetentionPreview(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	autoCleanup, _ := h.DB.GetSetting("auto_cleanup_enabled")
+	previews, err := h.DB.PreviewRetention(autoCleanup == "true")
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	entries := make([]RetentionPreviewEntry, len(previews))
+	for i, p := range previews {
+		entries[i] = RetentionPreviewEntry{
+			Policy:   p.Policy,
+			Default:  p.Default,
+			Feeds:    p.Feeds,
+			Articles: p.Articles,
+			Contents: p.Contents,
+		}
+	}
+	response.JSON(w, entries)
+}
@@ -0,0 +1,144 @@
+package article
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"MrRSS/internal/fulltext"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/handlers/response"
+	"MrRSS/internal/models"
+)
+
+// HandleSiteRules lists the full-text extraction rules, or creates or replaces the rule of a site.
+// @Summary      List or save site rules
+// @Description  GET lists all full-text extraction rules. POST saves the rule of a site, replacing its existing rule. Selectors are CSS, or XPath when they start with "/" or "(".
+// @Tags         articles
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.SiteRule  false  "Rule to save (POST)"
+// @Success      200  {object}  []models.SiteRule  "Site rules (GET) or the saved rule (POST)"
+// @Failure      400  {object}  map[string]string  "Invalid host or selector"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /site-rules [get]
+// @Router       /site-rules [post]
+func HandleSiteRules(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := h.DB.GetSiteRules()
+		if err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+		response.JSON(w, rules)
+	case http.MethodPost:
+		var rule models.SiteRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			response.Error(w, err, http.StatusBadRequest)
+			return
+		}
+		if err := fulltext.ValidateRule(&rule); err != nil {
+			response.Error(w, err, http.StatusBadRequest)
+			return
+		}
+		if _, err := h.DB.SaveSiteRule(&rule); err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+		response.JSON(w, rule)
+	default:
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleDeleteSiteRule deletes a full-text extraction rule.
+// @Summary      Delete a site rule
+// @Description  Delete a full-text extraction rule by ID
+// @Tags         articles
+// @Accept       json
+// @Produce      json
+// @Param        id   query     int64   true  "Rule ID"
+// @Success      200  {string}  string  "Rule deleted"
+// @Failure      400  {object}  map[string]string  "Bad request (invalid ID)"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /site-rules/delete [post]
+func HandleDeleteSiteRule(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := h.DB.DeleteSiteRule(id); err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// HandleTestSiteRule extracts an article and returns the output, to try a rule before saving it.
+// @Summary      Test full-text extraction
+// @Description  Extract the article at url with the given rule, or with the saved rule of its site when no rule is given, and return the extracted content
+// @Tags         articles
+// @Accept       json
+// @Produce      json
+// @Param        request  body      object  true  "Article URL and optional rule (url, rule)"
+// @Success      200  {object}  fulltext.Result  "Extracted content, title, method, rule host and pages read"
+// @Failure      400  {object}  map[string]string  "Invalid URL or rule"
+// @Failure      502  {object}  map[string]string  "Extraction failed"
+// @Router       /site-rules/test [post]
+func HandleTestSiteRule(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+	var req struct {
+		URL  string           `json:"url"`
+		Rule *models.SiteRule `json:"rule"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		response.Error(w, errors.New("url must be an http or https URL"), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Minute)
+	defer cancel()
+	extractor := fulltext.NewExtractor(h.DB, nil, 0)
+
+	var result *fulltext.Result
+	if req.Rule != nil {
+		// An unsaved rule applies to the tested page
+		if req.Rule.Host == "" {
+			req.Rule.Host = u.Hostname()
+		}
+		if err := fulltext.ValidateRule(req.Rule); err != nil {
+			response.Error(w, err, http.StatusBadRequest)
+			return
+		}
+		result, err = extractor.ExtractWithRule(ctx, req.URL, req.Rule)
+	} else {
+		result, err = extractor.Extract(ctx, req.URL)
+	}
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			response.Error(w, err, http.StatusGatewayTimeout)
+			return
+		}
+		response.Error(w, err, http.StatusBadGateway)
+		return
+	}
+	response.JSON(w, result)
+}
@@ -0,0 +1,232 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"MrRSS/internal/backup"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/handlers/response"
+	"MrRSS/internal/utils/fileutil"
+)
+
+// HandleBackup streams a backup archive of the database, settings, custom CSS and scripts.
+// @Summary      Download backup
+// @Description  Download a zip archive with a consistent database snapshot, settings (secrets blanked), custom CSS and scripts
+// @Tags         backup
+// @Produce      application/zip
+// @Success      200  {file}    file  "Backup archive"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /backup [get]
+func HandleBackup(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Build the archive in a temp file so a failure can still be reported as an error response
+	tmp, err := os.CreateTemp("", "mrrss-backup-*.zip")
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := backup.Write(r.Context(), h.DB, tmp); err != nil {
+		log.Printf("Error creating backup: %v", err)
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", backup.FileName(time.Now())))
+	if _, err := io.Copy(w, tmp); err != nil {
+		log.Printf("Error sending backup: %v", err)
+	}
+}
+
+// HandleRestore validates an uploaded backup and stages it for the next start.
+// @Summary      Restore backup
+// @Description  Validate a backup archive and stage its database; the restore is applied on the next start. Custom scripts of the backup are skipped unless restore_scripts is true, which is admin-only: in server mode it needs the MRRSS_ADMIN_TOKEN bearer token.
+// @Tags         backup
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file             formData  file  true   "Backup archive"
+// @Param        restore_scripts  formData  bool  false  "Also restore custom scripts"
+// @Success      200  {object}  backup.RestoreResult  "Staged restore"
+// @Failure      400  {object}  map[string]string  "Invalid or incompatible backup"
+// @Failure      403  {object}  map[string]string  "Restoring scripts needs an admin"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /backup/restore [post]
+func HandleRestore(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Scripts run on this machine, so restoring them is an explicit admin decision
+	opts := backup.RestoreOptions{Scripts: r.FormValue("restore_scripts") == "true"}
+	if opts.Scripts && !core.IsAdmin(r) {
+		response.Error(w, errors.New("restoring scripts needs the admin token"), http.StatusForbidden)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	log.Printf("Received backup: %s, size: %d", header.Filename, header.Size)
+
+	// zip needs random access, so spool the upload to disk
+	tmp, err := os.CreateTemp("", "mrrss-restore-*.zip")
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, file)
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	dbPath, err := fileutil.GetDBPath()
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	result, err := backup.Restore(tmp, size, dbPath, opts)
+	if err != nil {
+		log.Printf("Error restoring backup: %v", err)
+		if result == nil {
+			response.Error(w, err, http.StatusBadRequest)
+		} else {
+			response.Error(w, err, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response.JSON(w, result)
+}
+
+// HandleListBackups lists the backups in the backup directory.
+// @Summary      List backups
+// @Description  List scheduled and manual backups in the backup directory, newest first
+// @Tags         backup
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "Backup directory and backups"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /backup/list [get]
+func HandleListBackups(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	dir, err := backup.Dir(h.DB)
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	backups, err := backup.List(dir)
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	lastBackup, _ := h.DB.GetSetting("last_backup_time")
+	response.JSON(w, map[string]interface{}{
+		"directory":   dir,
+		"backups":     backups,
+		"last_backup": lastBackup,
+	})
+}
+
+// HandleRunBackup writes a backup into the backup directory now.
+// @Summary      Run backup
+// @Description  Write a backup into the backup directory and rotate old backups
+// @Tags         backup
+// @Produce      json
+// @Success      200  {object}  backup.Info  "Created backup"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /backup/run [post]
+func HandleRunBackup(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	info, err := backup.WriteFile(r.Context(), h.DB)
+	if err != nil {
+		log.Printf("Error writing backup: %v", err)
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.JSON(w, info)
+}
+
+// HandleRequiredSecrets lists secrets from a restored backup that still need to be entered.
+// @Summary      Secrets to re-enter after restore
+// @Description  List settings, AI profile keys, feed passwords and OAuth secrets that were bound to another machine and are still empty
+// @Tags         backup
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "Required secrets"
+// @Router       /backup/secrets [get]
+func HandleRequiredSecrets(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	recorded, _ := h.DB.GetSetting("restore_required_secrets")
+
+	required := []string{}
+	for _, name := range strings.Split(recorded, ",") {
+		if name == "" {
+			continue
+		}
+		if secretEntered(h, name) {
+			continue
+		}
+		required = append(required, name)
+	}
+
+	// Stop prompting once everything has been entered again
+	if len(required) == 0 && recorded != "" {
+		_ = h.DB.SetSetting("restore_required_secrets", "")
+	}
+
+	response.JSON(w, map[string]interface{}{
+		"required_secrets": required,
+	})
+}
+
+// secretEntered reports whether a secret listed after a restore has a value again
+func secretEntered(h *core.Handler, name string) bool {
+	var value string
+	var err error
+	switch {
+	case strings.HasPrefix(name, "ai_profile:"):
+		err = h.DB.QueryRow("SELECT api_key FROM ai_profiles WHERE id = ?", strings.TrimPrefix(name, "ai_profile:")).Scan(&value)
+	case strings.HasPrefix(name, "feed_email_oauth_client_secret:"):
+		err = h.DB.QueryRow("SELECT COALESCE(email_oauth_client_secret, '') FROM feeds WHERE id = ?", strings.TrimPrefix(name, "feed_email_oauth_client_secret:")).Scan(&value)
+	case strings.HasPrefix(name, "feed_email_oauth_refresh_token:"):
+		err = h.DB.QueryRow("SELECT COALESCE(email_oauth_refresh_token, '') FROM feeds WHERE id = ?", strings.TrimPrefix(name, "feed_email_oauth_refresh_token:")).Scan(&value)
+	case strings.HasPrefix(name, "feed_json_headers:"):
+		err = h.DB.QueryRow("SELECT COALESCE(json_headers, '') FROM feeds WHERE id = ?", strings.TrimPrefix(name, "feed_json_headers:")).Scan(&value)
+	case strings.HasPrefix(name, "feed:"):
+		err = h.DB.QueryRow("SELECT COALESCE(email_password, '') FROM feeds WHERE id = ?", strings.TrimPrefix(name, "feed:")).Scan(&value)
+	default:
+		value, err = h.DB.GetSetting(name)
+	}
+	return err != nil || value != ""
+}
@@ -0,0 +1,112 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"MrRSS/internal/dataexport"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/handlers/response"
+)
+
+// HandleFullExport exports all user data as a versioned archive.
+// @Summary      Export all data
+// @Description  Download feeds with tags, saved filters, rules, settings, AI profiles, custom CSS, kept articles with content, chat sessions and statistics
+// @Tags         backup
+// @Produce      application/zip
+// @Param        include_secrets  query     bool  false  "Include API keys and passwords in plain text"
+// @Success      200  {file}    file  "Export archive"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /export/full [get]
+func HandleFullExport(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	doc, err := dataexport.Build(h.DB, dataexport.ExportOptions{
+		IncludeSecrets: r.URL.Query().Get("include_secrets") == "true",
+	})
+	if err != nil {
+		log.Printf("Error building export: %v", err)
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", dataexport.FileName(time.Now())))
+	if err := dataexport.Write(doc, w); err != nil {
+		log.Printf("Error writing export: %v", err)
+	}
+}
+
+// HandleFullImport imports an archive written by HandleFullExport.
+// @Summary      Import all data
+// @Description  Merge an export into the database or replace local data with it; dry_run reports the changes without applying them
+// @Tags         backup
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file     formData  file    true   "Export archive"
+// @Param        mode     query     string  false  "merge (default) or replace"
+// @Param        dry_run  query     bool    false  "Only report what would change"
+// @Success      200  {object}  dataexport.ImportReport  "Import report"
+// @Failure      400  {object}  map[string]string  "Invalid archive or mode"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /import/full [post]
+func HandleFullImport(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	// zip needs random access, so spool the upload to disk
+	tmp, err := os.CreateTemp("", "mrrss-import-*.zip")
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, file)
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	doc, err := dataexport.Read(tmp, size)
+	if err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	if mode != "" && mode != dataexport.ModeMerge && mode != dataexport.ModeReplace {
+		response.Error(w, errors.New("mode must be merge or replace"), http.StatusBadRequest)
+		return
+	}
+
+	report, err := dataexport.Import(r.Context(), h.DB, doc, dataexport.ImportOptions{
+		Mode:   mode,
+		DryRun: r.URL.Query().Get("dry_run") == "true",
+	})
+	if err != nil {
+		log.Printf("Error importing export: %v", err)
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.JSON(w, report)
+}
@@ -0,0 +1,90 @@
+package backup
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/handlers/response"
+)
+
+// MaintenanceStatus describes the database tables, indexes and orphaned rows
+type MaintenanceStatus struct {
+	Tables          []database.TableStats  `json:"tables"`
+	Orphans         *database.OrphanCounts `json:"orphans"`
+	Operations      []string               `json:"operations"`
+	LastMaintenance string                 `json:"last_maintenance"` // RFC3339, empty if scheduled maintenance never ran
+}
+
+// MaintenanceRequest selects the maintenance operations to run
+type MaintenanceRequest struct {
+	Operations []string `json:"operations"`
+}
+
+// HandleMaintenance reports database statistics or runs maintenance operations.
+// @Summary      Database maintenance
+// @Description  GET returns the size of every table and index (with the row counts of tables) and the number of orphaned rows: articles of deleted feeds, cached contents of deleted articles and chat sessions of deleted articles. POST runs maintenance operations in the order given: integrity_check, quick_check, optimize, wal_checkpoint (TRUNCATE), analyze and fix_orphans, which deletes the orphaned rows. Scheduled maintenance runs the operations of db_maintenance_operations every db_maintenance_interval_hours.
+// @Tags         backup
+// @Accept       json
+// @Produce      json
+// @Param        request  body      MaintenanceRequest  false  "Operations to run (POST)"
+// @Success      200  {object}  MaintenanceStatus  "Database statistics (GET)"
+// @Success      200  {array}   database.MaintenanceResult  "Operation results (POST)"
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /db/maintenance [get]
+// @Router       /db/maintenance [post]
+func HandleMaintenance(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		tables, err := h.DB.GetTableStats()
+		if err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+		orphans, err := h.DB.FindOrphans()
+		if err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+		last, _ := h.DB.GetSetting("last_db_maintenance_time")
+		response.JSON(w, MaintenanceStatus{
+			Tables:          tables,
+			Orphans:         orphans,
+			Operations:      database.MaintenanceOperations,
+			LastMaintenance: last,
+		})
+	case http.MethodPost:
+		var req MaintenanceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.Error(w, err, http.StatusBadRequest)
+			return
+		}
+		if len(req.Operations) == 0 {
+			response.Error(w, errors.New("operations is required"), http.StatusBadRequest)
+			return
+		}
+		for _, op := range req.Operations {
+			if !database.IsMaintenanceOperation(op) {
+				response.Error(w, fmt.Errorf("unknown maintenance operation %q", op), http.StatusBadRequest)
+				return
+			}
+		}
+
+		results := make([]*database.MaintenanceResult, 0, len(req.Operations))
+		for _, op := range req.Operations {
+			result, err := h.DB.RunMaintenance(op)
+			if err != nil {
+				response.Error(w, err, http.StatusInternalServerError)
+				return
+			}
+			results = append(results, result)
+		}
+		response.JSON(w, results)
+	default:
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+	}
+}
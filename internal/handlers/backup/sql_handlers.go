@@ -0,0 +1,71 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/handlers/response"
+)
+
+// SQLRequest is an ad-hoc read-only query
+type SQLRequest struct {
+	Query string `json:"query"`
+	Limit int    `json:"limit"` // Maximum number of rows, default 1000, at most 10000
+}
+
+// HandleSQL runs an ad-hoc read-only query.
+// @Summary      Read-only SQL query
+// @Description  Run a query of the views v_articles, v_feeds and v_version on an in-memory snapshot holding only the views' rows, so it can neither change the database nor read the tables, which hold secrets. The views have stable columns within the version in v_version. Admin-only: in server mode, send the MRRSS_ADMIN_TOKEN environment variable as a bearer token; without it set, the endpoint is off. Queries time out after 30 seconds.
+// @Tags         backup
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      SQLRequest  true  "Query"
+// @Success      200  {object}  database.QueryResult  "Columns and rows"
+// @Failure      400  {object}  map[string]string  "Invalid query, or one writing or reading tables"
+// @Failure      403  {object}  map[string]string  "Not an admin"
+// @Router       /sql [post]
+func HandleSQL(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+	if !core.IsAdmin(r) {
+		response.Error(w, errors.New("admin token required"), http.StatusForbidden)
+		return
+	}
+
+	var req SQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		response.Error(w, errors.New("query is required"), http.StatusBadRequest)
+		return
+	}
+	if req.Limit < 0 {
+		response.Error(w, errors.New("limit must not be negative"), http.StatusBadRequest)
+		return
+	}
+	limit := 1000
+	if req.Limit > 0 {
+		limit = min(req.Limit, 10000)
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	// Failing queries are the client's: bad SQL, writes, or the time limit
+	result, err := h.DB.QueryReadOnly(ctx, req.Query, limit)
+	if err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	response.JSON(w, result)
+}
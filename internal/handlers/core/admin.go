@@ -0,0 +1,28 @@
+package core
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+
+	"MrRSS/internal/utils/fileutil"
+)
+
+// AdminTokenEnv holds the bearer token of admin-only endpoints in server mode
+const AdminTokenEnv = "MRRSS_ADMIN_TOKEN"
+
+// IsAdmin reports whether a request may use admin-only endpoints. The desktop app only
+// serves its local user; in server mode the request needs the MRRSS_ADMIN_TOKEN bearer
+// token, and admin-only endpoints are off when it is not set.
+func IsAdmin(r *http.Request) bool {
+	if !fileutil.IsServerMode() {
+		return true
+	}
+	token := os.Getenv(AdminTokenEnv)
+	if token == "" {
+		return false
+	}
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
@@ -1,10 +1,13 @@
 package core
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"MrRSS/internal/database"
 	"MrRSS/internal/feed"
+	"MrRSS/internal/utils/fileutil"
 )
 
 func TestNewHandler_ConstructsHandler(t *testing.T) {
@@ -29,3 +32,26 @@ func TestNewHandler_ConstructsHandler(t *testing.T) {
 		t.Fatal("DiscoveryService should be initialized")
 	}
 }
+
+func TestIsAdmin(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/api/sql", nil)
+	if !IsAdmin(r) {
+		t.Error("Desktop requests should be admin")
+	}
+
+	fileutil.SetServerMode(true)
+	t.Cleanup(func() { fileutil.SetServerMode(false) })
+	t.Setenv(AdminTokenEnv, "")
+	r.Header.Set("Authorization", "Bearer ")
+	if IsAdmin(r) {
+		t.Error("Admin-only endpoints should be off without an admin token")
+	}
+
+	t.Setenv(AdminTokenEnv, "s3cret")
+	for header, want := range map[string]bool{"": false, "Bearer wrong": false, "s3cret": false, "Bearer s3cret": true} {
+		r.Header.Set("Authorization", header)
+		if got := IsAdmin(r); got != want {
+			t.Errorf("IsAdmin with Authorization %q = %v, want %v", header, got, want)
+		}
+	}
+}
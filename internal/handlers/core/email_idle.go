@@ -0,0 +1,69 @@
+package core
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"MrRSS/internal/database"
+	"MrRSS/internal/email"
+	"MrRSS/internal/models"
+)
+
+// emailIdleSyncInterval is how often IDLE connections are matched against the newsletter feeds
+const emailIdleSyncInterval = 2 * time.Minute
+
+// StartEmailIdle keeps IMAP IDLE connections open for newsletter feeds so new mail
+// is fetched within seconds instead of on the next scheduled refresh.
+// It blocks until ctx is cancelled.
+func (h *Handler) StartEmailIdle(ctx context.Context) {
+	manager := email.NewIdleManager()
+	manager.OnNewMail = func(feedIDs []int64) {
+		for _, id := range feedIDs {
+			feed, err := h.DB.GetFeedByID(id)
+			if err != nil {
+				continue
+			}
+			h.Fetcher.FetchNewMail(ctx, *feed)
+		}
+	}
+	manager.OnRefreshTokenRotated = func(feedIDs []int64, refreshToken string) {
+		for _, id := range feedIDs {
+			if err := h.DB.UpdateFeedWithOptions(id, database.FeedUpdateOptions{EmailOAuthRefreshToken: &refreshToken}); err != nil {
+				log.Printf("Failed to store rotated OAuth refresh token for feed %d: %v", id, err)
+			}
+		}
+	}
+	defer manager.Stop()
+
+	sync := func() {
+		var newsletters []models.Feed
+		if enabled, _ := h.DB.GetSetting("email_idle_enabled"); enabled == "true" {
+			feeds, err := h.DB.GetFeeds()
+			if err != nil {
+				log.Printf("IMAP IDLE: failed to load feeds: %v", err)
+				return
+			}
+			for _, feed := range feeds {
+				if feed.Type == "email" && !feed.IsFreshRSSSource {
+					newsletters = append(newsletters, feed)
+				}
+			}
+		}
+		manager.Sync(ctx, newsletters)
+	}
+
+	sync()
+
+	ticker := time.NewTicker(emailIdleSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sync()
+		}
+	}
+}
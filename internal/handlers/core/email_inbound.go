@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"MrRSS/internal/email"
+)
+
+// inboundConfig is the part of the settings the SMTP/LMTP listener is started with
+type inboundConfig struct {
+	enabled  bool
+	protocol string
+	listen   string
+	domain   string
+}
+
+func (h *Handler) loadInboundConfig() inboundConfig {
+	enabled, _ := h.DB.GetSetting("email_inbound_enabled")
+	protocol, _ := h.DB.GetSetting("email_inbound_protocol")
+	listen, _ := h.DB.GetSetting("email_inbound_listen")
+	domain, _ := h.DB.GetSetting("email_inbound_domain")
+	if protocol == "" {
+		protocol = email.ProtocolSMTP
+	}
+	return inboundConfig{enabled: enabled == "true", protocol: protocol, listen: listen, domain: domain}
+}
+
+// StartEmailInbound runs the built-in SMTP or LMTP listener that receives newsletters sent
+// to generated feed addresses, restarting it when its settings change.
+// It blocks until ctx is cancelled.
+func (h *Handler) StartEmailInbound(ctx context.Context) {
+	var receiver *email.Receiver
+	var current inboundConfig
+	defer func() {
+		if receiver != nil {
+			receiver.Close()
+		}
+	}()
+
+	sync := func() {
+		config := h.loadInboundConfig()
+		if config == current {
+			return
+		}
+		if receiver != nil {
+			receiver.Close()
+			receiver = nil
+		}
+		current = config
+		if !config.enabled {
+			return
+		}
+
+		receiver = &email.Receiver{
+			Protocol: config.protocol,
+			Addr:     config.listen,
+			Domain:   config.domain,
+			Lookup: func(token string) (int64, bool) {
+				id, err := h.DB.GetFeedIDByInboundToken(token)
+				if err != nil && !errors.Is(err, sql.ErrNoRows) {
+					log.Printf("Inbound mail: failed to look up address: %v", err)
+				}
+				return id, err == nil
+			},
+			Deliver: func(feedID int64, raw []byte) error {
+				return h.Fetcher.DeliverEmail(ctx, feedID, raw)
+			},
+		}
+		if err := receiver.Start(); err != nil {
+			log.Printf("Inbound mail: %v", err)
+			receiver = nil
+			// Try again on the next tick, e.g. once the port is free
+			current = inboundConfig{}
+		}
+	}
+
+	sync()
+
+	ticker := time.NewTicker(emailIdleSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sync()
+		}
+	}
+}
@@ -3,7 +3,6 @@
 package core
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"log"
@@ -16,6 +15,7 @@ import (
 	"MrRSS/internal/database"
 	"MrRSS/internal/discovery"
 	"MrRSS/internal/feed"
+	"MrRSS/internal/fulltext"
 	"MrRSS/internal/models"
 	svc "MrRSS/internal/service"
 	"MrRSS/internal/statistics"
@@ -23,8 +23,6 @@ import (
 	"MrRSS/internal/utils/textutil"
 	"MrRSS/internal/utils/urlutil"
 
-	"codeberg.org/readeck/go-readability/v2"
-
 	"github.com/mmcdole/gofeed"
 )
 
@@ -179,22 +177,21 @@ func (h *Handler) GetArticleContent(articleID int64) (string, bool, error) {
 	return "", false, nil
 }
 
-// FetchFullArticleContent fetches the full article content from the original URL using readability.
+// FetchFullArticleContent fetches the full article content from the original URL,
+// using the site rule of its host when there is one and readability otherwise.
 func (h *Handler) FetchFullArticleContent(url string) (string, error) {
-	// Use FromURL which handles the HTTP request internally
-	article, err := readability.FromURL(url, 30*time.Second)
-	if err != nil {
-		return "", fmt.Errorf("readability parse: %w", err)
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
 
-	// Render the article content as HTML
-	var buf bytes.Buffer
-	err = article.RenderHTML(&buf)
+	var rules fulltext.RuleSource
+	if h.DB != nil {
+		rules = h.DB
+	}
+	result, err := fulltext.NewExtractor(rules, nil, 0).Extract(ctx, url)
 	if err != nil {
-		return "", fmt.Errorf("render HTML: %w", err)
+		return "", err
 	}
-
-	return buf.String(), nil
+	return result.Content, nil
 }
 
 // findMatchingFeedItem finds the best matching feed item for an article using multiple criteria
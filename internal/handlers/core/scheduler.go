@@ -6,7 +6,9 @@ import (
 	"strconv"
 	"time"
 
+	"MrRSS/internal/backup"
 	"MrRSS/internal/cache"
+	"MrRSS/internal/feed"
 	"MrRSS/internal/models"
 	"MrRSS/internal/utils/fileutil"
 )
@@ -35,6 +37,13 @@ func (h *Handler) StartBackgroundScheduler(ctx context.Context) {
 		}
 	}()
 
+	// Scheduled backups run independently of the refresh mode
+	go h.startBackupScheduler(ctx)
+	go h.startMaintenanceScheduler(ctx)
+
+	// Refreshes still queued when the app stopped run first
+	h.Fetcher.ResumeQueuedRefreshes(ctx)
+
 	// Start the scheduler based on refresh mode
 	refreshMode, _ := h.DB.GetSetting("refresh_mode")
 
@@ -52,6 +61,53 @@ func (h *Handler) StartBackgroundScheduler(ctx context.Context) {
 	}
 }
 
+// startBackupScheduler writes scheduled backups when backups are enabled and due
+func (h *Handler) startBackupScheduler(ctx context.Context) {
+	runIfDue := func() {
+		if !backup.IsDue(h.DB) {
+			return
+		}
+		info, err := backup.WriteFile(ctx, h.DB)
+		if err != nil {
+			log.Printf("Scheduled backup failed: %v", err)
+			return
+		}
+		log.Printf("Scheduled backup written to %s", info.Path)
+	}
+
+	runIfDue()
+
+	ticker := time.NewTicker(15 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runIfDue()
+		}
+	}
+}
+
+// startMaintenanceScheduler runs the scheduled database maintenance operations when due
+func (h *Handler) startMaintenanceScheduler(ctx context.Context) {
+	ticker := time.NewTicker(15 * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if h.DB.IsMaintenanceDue() {
+				results := h.DB.RunScheduledMaintenance()
+				log.Printf("Scheduled database maintenance ran %d operations", len(results))
+			}
+		}
+	}
+}
+
 // startScheduler is the unified scheduler that handles both fixed and intelligent modes
 // Logic:
 // 1. Individual feeds with custom intervals (RefreshInterval != 0) are scheduled individually
@@ -86,29 +142,40 @@ func (h *Handler) startScheduler(ctx context.Context, intelligentMode bool) {
 		}
 	}
 
-	// Get global interval
-	getGlobalInterval := func() time.Duration {
-		intervalStr, _ := h.DB.GetSetting("update_interval")
-		interval := 30
-		if i, err := strconv.Atoi(intervalStr); err == nil && i > 0 {
-			interval = i
-		}
-		return time.Duration(interval) * time.Minute
-	}
-
-	globalInterval := getGlobalInterval()
+	globalInterval := h.Fetcher.GlobalRefreshInterval()
 	log.Printf("Global refresh interval: %v", globalInterval)
 
+	// Refreshes that came due while the app was not running start over the next minutes, not all at once
+	if feeds, err := h.DB.GetFeeds(); err == nil {
+		rules := h.Fetcher.LoadRefreshRules()
+		h.Fetcher.SpreadOverdueRefreshes(individualFeeds(feeds, intelligentMode, rules), rules, time.Now())
+	}
+
 	// Use a ticker to check every minute
 	ticker := time.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
 
+	quiet := false
 	for {
 		select {
 		case <-ctx.Done():
 			log.Println("Stopping scheduler")
 			return
 		case <-ticker.C:
+			// Nothing is refreshed during the quiet hours, refreshes that come due wait until they end
+			rules := h.Fetcher.LoadRefreshRules()
+			if rules.Quiet(time.Now()) {
+				if !quiet {
+					log.Println("Quiet hours started, pausing scheduled refreshes")
+					quiet = true
+				}
+				continue
+			}
+			if quiet {
+				log.Println("Quiet hours ended, resuming scheduled refreshes")
+				quiet = false
+			}
+
 			// Check if we need to trigger global refresh
 			timeSinceLastGlobal := time.Since(lastGlobalRefresh)
 			if timeSinceLastGlobal >= globalInterval {
@@ -120,7 +187,7 @@ func (h *Handler) startScheduler(ctx context.Context, intelligentMode bool) {
 			}
 
 			// Schedule individual feeds with custom intervals
-			go h.scheduleIndividualFeeds(ctx, intelligentMode)
+			go h.scheduleIndividualFeeds(ctx, intelligentMode, rules)
 		}
 	}
 }
@@ -134,6 +201,8 @@ func (h *Handler) triggerGlobalRefresh(ctx context.Context, intelligentMode bool
 		log.Printf("Error getting feeds for global refresh: %v", err)
 		return
 	}
+	h.Fetcher.ApplyScriptSchedules(feeds)
+	feeds = h.Fetcher.SkipUnhealthyFeeds(feeds)
 
 	// Filter feeds that use global setting (RefreshInterval == 0)
 	// Skip feeds with RefreshInterval == -2 (never refresh)
@@ -172,27 +241,10 @@ func (h *Handler) triggerGlobalRefresh(ctx context.Context, intelligentMode bool
 	log.Printf("Triggering global refresh for %d refreshable feeds (skipped %d FreshRSS feeds, intelligent mode: %v)",
 		len(refreshableFeeds), len(globalFeeds)-len(refreshableFeeds), intelligentMode)
 
-	if intelligentMode {
-		// In intelligent mode, schedule each feed individually with calculated intervals
-		calculator := h.Fetcher.GetIntelligentRefreshCalculator()
-		for _, feed := range refreshableFeeds {
-			interval := calculator.CalculateInterval(feed)
-			staggerDelay := h.Fetcher.GetStaggeredDelay(feed.ID, len(refreshableFeeds))
-
-			go func(f models.Feed, delay time.Duration, calculatedInterval time.Duration) {
-				time.Sleep(delay)
-				select {
-				case <-ctx.Done():
-					return
-				default:
-					log.Printf("Auto-refreshing feed %s (intelligent mode, interval: %v)", f.Title, calculatedInterval)
-					h.Fetcher.FetchSingleFeed(ctx, f, false)
-				}
-			}(feed, staggerDelay, interval)
-		}
-	} else {
-		// In fixed mode, refresh all feeds together
-		h.Fetcher.FetchAll(ctx)
+	// In intelligent mode, scheduleIndividualFeeds refreshes each feed when its planned refresh is due
+	if !intelligentMode {
+		// In fixed mode, refresh all feeds together, except those refreshed a moment ago
+		h.Fetcher.FetchAllDue(ctx)
 	}
 
 	// Run media cache cleanup if enabled
@@ -202,20 +254,14 @@ func (h *Handler) triggerGlobalRefresh(ctx context.Context, intelligentMode bool
 	}
 }
 
-// scheduleIndividualFeeds schedules feeds with custom intervals (RefreshInterval != 0)
-// These feeds are refreshed independently of the global refresh cycle
-func (h *Handler) scheduleIndividualFeeds(ctx context.Context, intelligentMode bool) {
-	feeds, err := h.DB.GetFeeds()
-	if err != nil {
-		log.Printf("Error getting feeds for individual scheduling: %v", err)
-		return
-	}
-
-	calculator := h.Fetcher.GetIntelligentRefreshCalculator()
-
+// individualFeeds returns the feeds scheduled one by one rather than with the global refresh:
+// feeds with custom intervals (RefreshInterval != 0) or cron schedules, and in intelligent mode
+// feeds using the global setting too
+func individualFeeds(feeds []models.Feed, intelligentMode bool, rules *feed.RefreshRules) []models.Feed {
+	individual := make([]models.Feed, 0, len(feeds))
 	for _, feed := range feeds {
-		// Skip feeds using global setting (RefreshInterval == 0)
-		if feed.RefreshInterval == 0 {
+		// Skip feeds using global setting (RefreshInterval == 0), unless the global setting is intelligent
+		if feed.RefreshInterval == 0 && !intelligentMode && !rules.UsesCron(feed) {
 			continue
 		}
 
@@ -229,6 +275,32 @@ func (h *Handler) scheduleIndividualFeeds(ctx context.Context, intelligentMode b
 			continue
 		}
 
+		individual = append(individual, feed)
+	}
+	return individual
+}
+
+// scheduleIndividualFeeds schedules feeds with custom intervals (RefreshInterval != 0) or cron schedules
+// These feeds are refreshed independently of the global refresh cycle, when their stored schedule says they are due
+// and their refresh rules allow it
+// In intelligent mode, feeds using the global setting are scheduled here too
+func (h *Handler) scheduleIndividualFeeds(ctx context.Context, intelligentMode bool, rules *feed.RefreshRules) {
+	feeds, err := h.DB.GetFeeds()
+	if err != nil {
+		log.Printf("Error getting feeds for individual scheduling: %v", err)
+		return
+	}
+	h.Fetcher.ApplyScriptSchedules(feeds)
+	feeds = h.Fetcher.SkipUnhealthyFeeds(feeds)
+
+	schedules, err := h.DB.GetFeedSchedules()
+	if err != nil {
+		log.Printf("Error getting feed schedules: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, feed := range individualFeeds(feeds, intelligentMode, rules) {
 		// Check if context is cancelled
 		select {
 		case <-ctx.Done():
@@ -236,35 +308,26 @@ func (h *Handler) scheduleIndividualFeeds(ctx context.Context, intelligentMode b
 		default:
 		}
 
-		// Determine refresh interval
-		var refreshInterval time.Duration
-		if feed.RefreshInterval > 0 {
-			// Use custom fixed interval
-			refreshInterval = time.Duration(feed.RefreshInterval) * time.Minute
-		} else if feed.RefreshInterval == -1 {
-			// Use intelligent interval
-			refreshInterval = calculator.CalculateInterval(feed)
+		nextRefresh := h.Fetcher.RefreshDueAt(feed, schedules[feed.ID], rules)
+		if nextRefresh.IsZero() || now.Before(nextRefresh) || !rules.Allows(feed, now) {
+			continue
 		}
 
-		// Check if feed needs refresh based on last_updated time
-		timeSinceUpdate := time.Since(feed.LastUpdated)
-		if timeSinceUpdate >= refreshInterval {
-			// Apply staggered delay
-			staggerDelay := h.Fetcher.GetStaggeredDelay(feed.ID, len(feeds))
-
-			// Schedule feed refresh
-			feedCopy := feed
-			go func(f models.Feed, delay time.Duration, interval time.Duration) {
-				time.Sleep(delay)
-				select {
-				case <-ctx.Done():
-					return
-				default:
-					log.Printf("Auto-refreshing feed %s (custom interval: %v)", f.Title, interval)
-					h.Fetcher.FetchSingleFeed(ctx, f, false)
-				}
-			}(feedCopy, staggerDelay, refreshInterval)
-		}
+		// Apply staggered delay
+		staggerDelay := h.Fetcher.GetStaggeredDelay(feed.ID, len(feeds))
+
+		// Schedule feed refresh
+		feedCopy := feed
+		go func(f models.Feed, delay time.Duration, due time.Time) {
+			time.Sleep(delay)
+			select {
+			case <-ctx.Done():
+				return
+			default:
+				log.Printf("Auto-refreshing feed %s (due at %s)", f.Title, due.Format(time.RFC3339))
+				h.Fetcher.FetchSingleFeed(ctx, f, false)
+			}
+		}(feedCopy, staggerDelay, nextRefresh)
 	}
 }
 
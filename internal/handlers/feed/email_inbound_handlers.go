@@ -0,0 +1,80 @@
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"MrRSS/internal/email"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/handlers/response"
+	"MrRSS/internal/models"
+)
+
+// HandleCreateInboundFeed creates a newsletter feed with a generated address for the built-in SMTP/LMTP listener.
+// @Summary      Create newsletter address
+// @Description  Create a feed that receives newsletters sent to a generated address like feed-<token>@email_inbound_domain
+// @Tags         email
+// @Accept       json
+// @Produce      json
+// @Param        request  body      object  true  "title and optional category"
+// @Success      200  {object}  map[string]interface{}  "feed_id and address"
+// @Failure      400  {object}  map[string]string  "Missing title or inbound domain"
+// @Router       /email/inbound/create [post]
+func HandleCreateInboundFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Title    string `json:"title"`
+		Category string `json:"category"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		response.Error(w, errors.New("title is required"), http.StatusBadRequest)
+		return
+	}
+
+	domain, _ := h.DB.GetSetting("email_inbound_domain")
+	if domain == "" {
+		response.Error(w, errors.New("set email_inbound_domain before creating newsletter addresses"), http.StatusBadRequest)
+		return
+	}
+
+	token, err := email.NewInboundToken()
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	address := email.InboundAddress(token, domain)
+
+	feedID, err := h.DB.AddFeed(&models.Feed{
+		Title:        title,
+		URL:          "email://" + address,
+		Description:  fmt.Sprintf("Newsletters sent to %s", address),
+		Category:     req.Category,
+		Type:         "email",
+		EmailAddress: address,
+	})
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	if err := h.DB.SetFeedInboundToken(feedID, token); err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.JSON(w, map[string]interface{}{
+		"feed_id": feedID,
+		"address": address,
+	})
+}
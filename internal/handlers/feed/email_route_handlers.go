@@ -0,0 +1,229 @@
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"MrRSS/internal/email"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/handlers/response"
+	"MrRSS/internal/models"
+)
+
+// emailAccountFeed loads a newsletter feed that owns its mailbox, so routes can be attached to it
+func emailAccountFeed(h *core.Handler, id int64) (*models.Feed, error) {
+	feed, err := h.DB.GetFeedByID(id)
+	if err != nil {
+		return nil, fmt.Errorf("feed %d not found", id)
+	}
+	if feed.Type != "email" || feed.EmailAccountID != 0 || feed.EmailIMAPServer == "" {
+		return nil, errors.New("routes can only be added to a newsletter feed with its own mailbox")
+	}
+	return feed, nil
+}
+
+// HandleEmailRoutes lists the routing rules and options of a mailbox account.
+// @Summary      List newsletter routes
+// @Description  Get the rules that split a newsletter feed's mailbox into per-sender feeds, with its auto-create and processed-message options
+// @Tags         email
+// @Produce      json
+// @Param        account_id  query     int  true  "Newsletter feed that owns the mailbox"
+// @Success      200  {object}  map[string]interface{}  "routing and routes"
+// @Failure      400  {object}  map[string]string  "Invalid account"
+// @Router       /email/routes [get]
+func HandleEmailRoutes(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	accountID, err := strconv.ParseInt(r.URL.Query().Get("account_id"), 10, 64)
+	if err != nil {
+		response.Error(w, errors.New("account_id is required"), http.StatusBadRequest)
+		return
+	}
+	if _, err := emailAccountFeed(h, accountID); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	routes, err := h.DB.GetEmailRoutes(accountID)
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	routing, err := h.DB.GetEmailRouting(accountID)
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.JSON(w, map[string]interface{}{
+		"routing": routing,
+		"routes":  routes,
+	})
+}
+
+// HandleAddEmailRoute adds a routing rule to a mailbox account.
+// @Summary      Add newsletter route
+// @Description  Route messages matching a sender address, sender domain, List-Id or subject regular expression to a feed. Without feed_id a new feed named title is created.
+// @Tags         email
+// @Accept       json
+// @Produce      json
+// @Param        request  body      object  true  "account_id, feed_id or title, match_type (from, domain, list_id, subject), pattern"
+// @Success      200  {object}  models.EmailRoute  "Created route"
+// @Failure      400  {object}  map[string]string  "Invalid route"
+// @Router       /email/routes/add [post]
+func HandleAddEmailRoute(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		AccountID int64  `json:"account_id"`
+		FeedID    int64  `json:"feed_id"`
+		Title     string `json:"title"`
+		MatchType string `json:"match_type"`
+		Pattern   string `json:"pattern"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	account, err := emailAccountFeed(h, req.AccountID)
+	if err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	if err := email.ValidateRoute(req.MatchType, req.Pattern); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	feedID := req.FeedID
+	if feedID == 0 {
+		title := strings.TrimSpace(req.Title)
+		if title == "" {
+			response.Error(w, errors.New("title is required to create a feed for the route"), http.StatusBadRequest)
+			return
+		}
+		feedID, err = h.DB.AddFeed(&models.Feed{
+			Title:        title,
+			URL:          fmt.Sprintf("email://%s/%s:%s", account.EmailAddress, req.MatchType, strings.ToLower(strings.TrimSpace(req.Pattern))),
+			Description:  fmt.Sprintf("Newsletters routed from %s", account.EmailAddress),
+			Category:     account.Category,
+			Type:         "email",
+			EmailAddress: account.EmailAddress,
+			EmailFolder:  account.EmailFolder,
+		})
+		if err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+		if err := h.DB.SetFeedEmailAccount(feedID, account.ID); err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+	} else if feedID != account.ID {
+		// Several routes may share a feed, but it must belong to this mailbox
+		target, err := h.DB.GetFeedByID(feedID)
+		if err != nil || target.EmailAccountID != account.ID {
+			response.Error(w, errors.New("feed_id must be the account itself or one of its routed feeds"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	route := models.EmailRoute{
+		AccountFeedID: account.ID,
+		FeedID:        feedID,
+		MatchType:     req.MatchType,
+		Pattern:       strings.TrimSpace(req.Pattern),
+	}
+	if _, err := h.DB.AddEmailRoute(&route); err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.JSON(w, route)
+}
+
+// HandleDeleteEmailRoute removes a routing rule. Its feed and articles are kept.
+// @Summary      Delete newsletter route
+// @Description  Remove a routing rule; matching messages go to the mailbox feed again
+// @Tags         email
+// @Accept       json
+// @Produce      json
+// @Param        request  body      object  true  "id"
+// @Success      200  {object}  map[string]string  "success"
+// @Router       /email/routes/delete [post]
+func HandleDeleteEmailRoute(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.DB.DeleteEmailRoute(req.ID); err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	response.JSON(w, map[string]string{"status": "success"})
+}
+
+// HandleEmailRouting updates the routing options of a mailbox account.
+// @Summary      Update newsletter routing options
+// @Description  Turn on feed creation for new List-Ids and choose what happens to fetched messages: nothing, move to processed_folder, or flag
+// @Tags         email
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.EmailRouting  true  "Routing options"
+// @Success      200  {object}  models.EmailRouting  "Saved options"
+// @Failure      400  {object}  map[string]string  "Invalid options"
+// @Router       /email/routing [post]
+func HandleEmailRouting(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var routing models.EmailRouting
+	if err := json.NewDecoder(r.Body).Decode(&routing); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	account, err := emailAccountFeed(h, routing.AccountFeedID)
+	if err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	routing.ProcessedFolder = strings.TrimSpace(routing.ProcessedFolder)
+	if err := email.ValidateProcessedAction(routing.ProcessedAction, routing.ProcessedFolder); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	// Moved messages get new UIDs, moving them into the watched folder would fetch them again
+	if routing.ProcessedAction == email.ProcessedMove && strings.EqualFold(routing.ProcessedFolder, account.EmailFolder) {
+		response.Error(w, errors.New("processed messages must be moved to a different folder"), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.DB.SaveEmailRouting(routing); err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	response.JSON(w, routing)
+}
@@ -7,6 +7,8 @@ import (
 	"strconv"
 	"time"
 
+	"MrRSS/internal/database"
+	ff "MrRSS/internal/feed"
 	"MrRSS/internal/handlers/core"
 	"MrRSS/internal/handlers/response"
 	"MrRSS/internal/rsshub"
@@ -35,6 +37,8 @@ func HandleFeeds(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		feeds[i].Tags = tags
 		// Clear sensitive password fields before sending to frontend
 		feeds[i].EmailPassword = ""
+		feeds[i].EmailOAuthClientSecret = ""
+		feeds[i].EmailOAuthRefreshToken = ""
 	}
 
 	response.JSON(w, feeds)
@@ -42,7 +46,7 @@ func HandleFeeds(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 
 // HandleAddFeed adds a new feed subscription and immediately fetches its articles.
 // @Summary      Add a new feed
-// @Description  Add a new RSS/Atom/Email/Script/XPath feed subscription
+// @Description  Add a new RSS/Atom/Email/Script/XPath/JSONPath feed subscription
 // @Tags         feeds
 // @Accept       json
 // @Produce      json
@@ -75,8 +79,11 @@ func HandleAddFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		XPathItemThumbnail  string `json:"xpath_item_thumbnail"`
 		XPathItemCategories string `json:"xpath_item_categories"`
 		XPathItemUid        string `json:"xpath_item_uid"`
-		ArticleViewMode     string `json:"article_view_mode"`
-		AutoExpandContent   string `json:"auto_expand_content"`
+		xpathPageFields
+		jsonPathFields
+		ArticleViewMode   string `json:"article_view_mode"`
+		AutoExpandContent string `json:"auto_expand_content"`
+		FullTextOnIngest  bool   `json:"full_text_on_ingest"`
 		// Email/Newsletter fields
 		EmailAddress    string `json:"email_address"`
 		EmailIMAPServer string `json:"email_imap_server"`
@@ -84,6 +91,7 @@ func HandleAddFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		EmailUsername   string `json:"email_username"`
 		EmailPassword   string `json:"email_password"`
 		EmailFolder     string `json:"email_folder"`
+		emailAuthFields
 		// Tags
 		Tags []int64 `json:"tags"`
 	}
@@ -92,6 +100,23 @@ func HandleAddFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Type == "email" {
+		if err := req.emailAuthFields.validate(); err != nil {
+			response.Error(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := req.xpathPageFields.validate(); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	if req.Type == ff.JSONPathFeedType {
+		if err := req.jsonPathFields.validate(); err != nil {
+			response.Error(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Normalize the URL to ensure it has a protocol
 	req.URL = urlutil.NormalizeFeedURL(req.URL)
 
@@ -120,6 +145,9 @@ func HandleAddFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	} else if req.XPathItem != "" {
 		// Add feed using XPath
 		feedID, err = h.Fetcher.AddXPathSubscription(req.URL, req.Category, req.Title, req.Type, req.XPathItem, req.XPathItemTitle, req.XPathItemContent, req.XPathItemUri, req.XPathItemAuthor, req.XPathItemTimestamp, req.XPathItemTimeFormat, req.XPathItemThumbnail, req.XPathItemCategories, req.XPathItemUid)
+	} else if req.Type == ff.JSONPathFeedType {
+		// Add feed using JSONPath
+		feedID, err = h.Fetcher.AddJSONPathSubscription(req.jsonPathFields.feed(req.URL, req.Category, req.Title))
 	} else if req.Type == "email" {
 		// Add feed as email newsletter subscription
 		feedID, err = h.Fetcher.AddEmailSubscription(req.EmailAddress, req.EmailIMAPServer, req.EmailUsername, req.EmailPassword, req.Category, req.Title, req.EmailFolder, req.EmailIMAPPort)
@@ -149,6 +177,22 @@ func HandleAddFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		response.Error(w, err, http.StatusInternalServerError)
 		return
 	}
+	if feed.Type == "email" {
+		if err := h.DB.UpdateFeedWithOptions(feed.ID, req.emailAuthFields.updateOptions()); err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	if feed.XPathItem != "" {
+		if err := h.DB.UpdateFeedWithOptions(feed.ID, req.xpathPageFields.updateOptions()); err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	if err := h.DB.UpdateFeedWithOptions(feed.ID, database.FeedUpdateOptions{FullTextOnIngest: &req.FullTextOnIngest}); err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
 
 	// Set tags for the feed
 	if len(req.Tags) > 0 {
@@ -158,6 +202,9 @@ func HandleAddFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Mirror the new subscription on FreshRSS when enabled
+	queueFreshRSSSubscribe(h, feed)
+
 	// Immediately fetch articles for the newly added feed in background
 	go func() {
 		feed, err := h.DB.GetFeedByID(feedID)
@@ -184,10 +231,12 @@ func HandleAddFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 func HandleDeleteFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Query().Get("id")
 	id, _ := strconv.ParseInt(idStr, 10, 64)
+	feed, _ := h.DB.GetFeedByID(id)
 	if err := h.DB.DeleteFeed(id); err != nil {
 		response.Error(w, err, http.StatusInternalServerError)
 		return
 	}
+	queueFreshRSSUnsubscribe(h, feed)
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -227,8 +276,11 @@ func HandleUpdateFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		XPathItemThumbnail  string `json:"xpath_item_thumbnail"`
 		XPathItemCategories string `json:"xpath_item_categories"`
 		XPathItemUid        string `json:"xpath_item_uid"`
-		ArticleViewMode     string `json:"article_view_mode"`
-		AutoExpandContent   string `json:"auto_expand_content"`
+		xpathPageFields
+		jsonPathFields
+		ArticleViewMode   string `json:"article_view_mode"`
+		AutoExpandContent string `json:"auto_expand_content"`
+		FullTextOnIngest  *bool  `json:"full_text_on_ingest"` // Unchanged when omitted
 		// Email/Newsletter fields
 		EmailAddress    string `json:"email_address"`
 		EmailIMAPServer string `json:"email_imap_server"`
@@ -236,6 +288,7 @@ func HandleUpdateFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		EmailUsername   string `json:"email_username"`
 		EmailPassword   string `json:"email_password"`
 		EmailFolder     string `json:"email_folder"`
+		emailAuthFields
 		// Tags
 		Tags []int64 `json:"tags"`
 	}
@@ -244,6 +297,23 @@ func HandleUpdateFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Type == "email" {
+		if err := req.emailAuthFields.validate(); err != nil {
+			response.Error(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := req.xpathPageFields.validate(); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+	if req.Type == ff.JSONPathFeedType {
+		if err := req.jsonPathFields.validate(); err != nil {
+			response.Error(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Normalize the URL to ensure it has a protocol
 	req.URL = urlutil.NormalizeFeedURL(req.URL)
 
@@ -291,6 +361,9 @@ func HandleUpdateFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Keep the previous state to detect renames and moves of FreshRSS feeds
+	previousFeed, _ := h.DB.GetFeedByID(req.ID)
+
 	// If title is empty, fetch the default title from the feed
 	finalTitle := req.Title
 	if finalTitle == "" {
@@ -319,6 +392,9 @@ func HandleUpdateFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		} else if req.XPathItem != "" || (currentFeed != nil && currentFeed.XPathItem != "") {
 			// XPath-based feed: use default title
 			finalTitle = "XPath Feed"
+		} else if req.Type == ff.JSONPathFeedType {
+			// JSON API feed: use default title
+			finalTitle = "JSON Feed"
 		} else if req.Type == "email" || (currentFeed != nil && currentFeed.Type == "email") {
 			// Email-based feed: use email address as title
 			emailAddr := req.EmailAddress
@@ -350,6 +426,30 @@ func HandleUpdateFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		response.Error(w, err, http.StatusInternalServerError)
 		return
 	}
+	if req.Type == "email" {
+		if err := h.DB.UpdateFeedWithOptions(req.ID, req.emailAuthFields.updateOptions()); err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	if req.XPathItem != "" {
+		if err := h.DB.UpdateFeedWithOptions(req.ID, req.xpathPageFields.updateOptions()); err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	if req.Type == ff.JSONPathFeedType {
+		if err := h.DB.UpdateFeedWithOptions(req.ID, req.jsonPathFields.updateOptions()); err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+	if req.FullTextOnIngest != nil {
+		if err := h.DB.UpdateFeedWithOptions(req.ID, database.FeedUpdateOptions{FullTextOnIngest: req.FullTextOnIngest}); err != nil {
+			response.Error(w, err, http.StatusInternalServerError)
+			return
+		}
+	}
 
 	// Update tags for the feed
 	if req.Tags != nil {
@@ -359,6 +459,8 @@ func HandleUpdateFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	queueFreshRSSFeedEdits(h, previousFeed, finalTitle, req.Category)
+
 	// Immediately fetch articles for the updated feed in background
 	go func() {
 		feed, err := h.DB.GetFeedByID(req.ID)
@@ -436,10 +538,12 @@ func HandleReorderFeed(h *core.Handler, w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	previousFeed, _ := h.DB.GetFeedByID(req.FeedID)
 	if err := h.DB.ReorderFeed(req.FeedID, req.Category, req.Position); err != nil {
 		response.Error(w, err, http.StatusInternalServerError)
 		return
 	}
+	queueFreshRSSFeedEdits(h, previousFeed, "", req.Category)
 
 	response.JSON(w, map[string]string{"status": "ok"})
 }
@@ -0,0 +1,142 @@
+package feed
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"MrRSS/internal/database"
+	ff "MrRSS/internal/feed"
+	"MrRSS/internal/freshrss"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/models"
+)
+
+// isPushableFeed reports whether a local feed can be subscribed to on a FreshRSS server.
+// Only plain HTTP(S) feeds qualify; script, XPath, JSONPath, email and RSSHub feeds only exist locally.
+func isPushableFeed(feed *models.Feed) bool {
+	if feed.IsFreshRSSSource || feed.ScriptPath != "" || feed.XPathItem != "" || feed.Type == ff.JSONPathFeedType || feed.Type == "email" {
+		return false
+	}
+	return strings.HasPrefix(feed.URL, "http://") || strings.HasPrefix(feed.URL, "https://")
+}
+
+// queueFreshRSSSubscriptionChange records a local subscription change and pushes it
+// to FreshRSS in the background. Failed pushes stay queued and are retried by the global sync.
+func queueFreshRSSSubscriptionChange(h *core.Handler, change database.SubscriptionChange) {
+	if !h.DB.ShouldSyncWithFreshRSS() {
+		return
+	}
+
+	if err := h.DB.EnqueueSubscriptionChange(change); err != nil {
+		log.Printf("[FreshRSS] Failed to queue %s for feed %d: %v", change.Action, change.FeedID, err)
+		return
+	}
+
+	subscriptionPush.mu.Lock()
+	defer subscriptionPush.mu.Unlock()
+	if subscriptionPush.running {
+		subscriptionPush.pending = true
+		return
+	}
+	subscriptionPush.running = true
+	go runFreshRSSSubscriptionPush(h)
+}
+
+// subscriptionPush tracks the background push, so only one runs at a time
+var subscriptionPush struct {
+	mu      sync.Mutex
+	running bool
+	pending bool // Changes were queued during the running push
+}
+
+// runFreshRSSSubscriptionPush pushes queued changes until none were queued during the last push
+func runFreshRSSSubscriptionPush(h *core.Handler) {
+	for {
+		pushFreshRSSSubscriptionChanges(h)
+
+		subscriptionPush.mu.Lock()
+		if !subscriptionPush.pending {
+			subscriptionPush.running = false
+			subscriptionPush.mu.Unlock()
+			return
+		}
+		subscriptionPush.pending = false
+		subscriptionPush.mu.Unlock()
+	}
+}
+
+// pushFreshRSSSubscriptionChanges pushes queued subscription changes to FreshRSS
+func pushFreshRSSSubscriptionChanges(h *core.Handler) {
+	serverURL, username, password, err := h.DB.GetFreshRSSConfig()
+	if err != nil || serverURL == "" || username == "" || password == "" {
+		log.Printf("[FreshRSS] Not configured, subscription changes stay queued")
+		return
+	}
+
+	syncService := freshrss.NewBidirectionalSyncService(serverURL, username, password, h.DB)
+	if _, err := syncService.PushSubscriptionChanges(context.Background()); err != nil {
+		log.Printf("[FreshRSS] Subscription push failed, will retry on next sync: %v", err)
+	}
+}
+
+// queueFreshRSSSubscribe pushes a newly added local feed to FreshRSS when enabled in settings
+func queueFreshRSSSubscribe(h *core.Handler, feed *models.Feed) {
+	if !isPushableFeed(feed) {
+		return
+	}
+	if pushNew, _ := h.DB.GetSetting("freshrss_push_new_feeds"); pushNew != "true" {
+		return
+	}
+
+	queueFreshRSSSubscriptionChange(h, database.SubscriptionChange{
+		FeedID:   feed.ID,
+		FeedURL:  feed.URL,
+		Action:   database.SubscriptionActionSubscribe,
+		Title:    feed.Title,
+		Category: feed.Category,
+	})
+}
+
+// queueFreshRSSFeedEdits pushes title and category edits of a FreshRSS feed to the server
+func queueFreshRSSFeedEdits(h *core.Handler, before *models.Feed, title, category string) {
+	if before == nil || !before.IsFreshRSSSource {
+		return
+	}
+
+	if title != "" && title != before.Title {
+		queueFreshRSSSubscriptionChange(h, database.SubscriptionChange{
+			FeedID:   before.ID,
+			FeedURL:  before.URL,
+			StreamID: before.FreshRSSStreamID,
+			Action:   database.SubscriptionActionRename,
+			Title:    title,
+		})
+	}
+
+	if category != before.Category {
+		queueFreshRSSSubscriptionChange(h, database.SubscriptionChange{
+			FeedID:      before.ID,
+			FeedURL:     before.URL,
+			StreamID:    before.FreshRSSStreamID,
+			Action:      database.SubscriptionActionMove,
+			Category:    category,
+			OldCategory: before.Category,
+		})
+	}
+}
+
+// queueFreshRSSUnsubscribe removes a deleted FreshRSS feed from the server
+func queueFreshRSSUnsubscribe(h *core.Handler, feed *models.Feed) {
+	if feed == nil || !feed.IsFreshRSSSource {
+		return
+	}
+
+	queueFreshRSSSubscriptionChange(h, database.SubscriptionChange{
+		FeedID:   feed.ID,
+		FeedURL:  feed.URL,
+		StreamID: feed.FreshRSSStreamID,
+		Action:   database.SubscriptionActionUnsubscribe,
+	})
+}
@@ -0,0 +1,123 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/handlers/response"
+)
+
+// brokenFeed is a failing feed with its failure history
+type brokenFeed struct {
+	FeedID              int64  `json:"feed_id"`
+	Title               string `json:"title"`
+	URL                 string `json:"url"`
+	Category            string `json:"category"`
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	FirstFailureAt      string `json:"first_failure_at"`
+	LastFailureAt       string `json:"last_failure_at"`
+	StatusCode          int    `json:"status_code,omitempty"`
+	ErrorClass          string `json:"error_class"`
+	LastError           string `json:"last_error"`
+	NextRetryAt         string `json:"next_retry_at,omitempty"` // Set while the feed is in backoff
+	Paused              bool   `json:"paused"`
+	PausedAt            string `json:"paused_at,omitempty"`
+}
+
+// formatTime formats a time for the API, empty for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// HandleBrokenFeeds lists the feeds whose last refreshes failed, paused feeds first.
+// @Summary      List broken feeds
+// @Description  List failing feeds with their consecutive failures, last HTTP status, error class, backoff and pause state
+// @Tags         feeds
+// @Produce      json
+// @Success      200  {array}   brokenFeed  "Failing feeds"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /feeds/broken [get]
+func HandleBrokenFeeds(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	healths, err := h.DB.GetFailingFeeds()
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	feeds := make([]brokenFeed, 0, len(healths))
+	for _, health := range healths {
+		feed, err := h.DB.GetFeedByID(health.FeedID)
+		if err != nil {
+			continue
+		}
+		item := brokenFeed{
+			FeedID:              feed.ID,
+			Title:               feed.Title,
+			URL:                 feed.URL,
+			Category:            feed.Category,
+			ConsecutiveFailures: health.ConsecutiveFailures,
+			FirstFailureAt:      formatTime(health.FirstFailureAt),
+			LastFailureAt:       formatTime(health.LastFailureAt),
+			StatusCode:          health.StatusCode,
+			ErrorClass:          health.ErrorClass,
+			LastError:           health.LastError,
+			Paused:              health.Paused(),
+			PausedAt:            formatTime(health.PausedAt),
+		}
+		if now.Before(health.NextRetryAt) {
+			item.NextRetryAt = formatTime(health.NextRetryAt)
+		}
+		feeds = append(feeds, item)
+	}
+
+	response.JSON(w, feeds)
+}
+
+// HandleRetryFeed lifts the pause and backoff of a failing feed and refreshes it right away.
+// @Summary      Retry a broken feed
+// @Description  Resume a paused or backing-off feed and queue an immediate refresh. Its failure history is kept until a refresh succeeds.
+// @Tags         feeds
+// @Produce      json
+// @Param        id   query     int64   true  "Feed ID"
+// @Success      200  {object}  map[string]string  "Retry status"
+// @Failure      400  {object}  map[string]string  "Bad request (invalid feed ID)"
+// @Failure      404  {object}  map[string]string  "Feed not found"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /feeds/broken/retry [post]
+func HandleRetryFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	feed, err := h.DB.GetFeedByID(id)
+	if err != nil {
+		response.Error(w, err, http.StatusNotFound)
+		return
+	}
+
+	if err := h.Fetcher.RetryFeed(context.Background(), *feed); err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.JSON(w, map[string]string{"status": "refreshing"})
+}
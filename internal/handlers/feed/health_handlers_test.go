@@ -0,0 +1,69 @@
+package feed_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"MrRSS/internal/database"
+	fh "MrRSS/internal/handlers/feed"
+	"MrRSS/internal/models"
+)
+
+func TestHandleBrokenFeeds_ListsAndRetries(t *testing.T) {
+	h := setupHandler(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Write([]byte(`<?xml version="1.0"?><rss><channel><title>t</title></channel></rss>`))
+	}))
+	defer srv.Close()
+
+	id, err := h.DB.AddFeed(&models.Feed{Title: "broken", URL: srv.URL + "/feed"})
+	if err != nil {
+		t.Fatalf("add feed: %v", err)
+	}
+	now := time.Now()
+	if err := h.DB.SaveFeedHealth(database.FeedHealth{
+		FeedID: id, ConsecutiveFailures: 10, FirstFailureAt: now.Add(-time.Hour), LastFailureAt: now,
+		StatusCode: 404, ErrorClass: "not_found", LastError: "HTTP 404: 404 Not Found", PausedAt: now,
+	}); err != nil {
+		t.Fatalf("save health: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	fh.HandleBrokenFeeds(h, w, httptest.NewRequest("GET", "/api/feeds/broken", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", w.Code)
+	}
+	var feeds []map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&feeds); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(feeds) != 1 || feeds[0]["title"] != "broken" || feeds[0]["paused"] != true || feeds[0]["status_code"] != float64(404) {
+		t.Fatalf("unexpected broken feeds: %v", feeds)
+	}
+
+	for query, want := range map[string]int{"id=abc": http.StatusBadRequest, "id=9999": http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		fh.HandleRetryFeed(h, w, httptest.NewRequest("POST", "/api/feeds/broken/retry?"+query, nil))
+		if w.Code != want {
+			t.Errorf("retry with %s: expected %d, got %d", query, want, w.Code)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	fh.HandleRetryFeed(h, w, httptest.NewRequest("POST", "/api/feeds/broken/retry?id="+strconv.FormatInt(id, 10), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("retry: expected 200 OK, got %d", w.Code)
+	}
+	if !h.Fetcher.GetTaskManager().Wait(10 * time.Second) {
+		t.Fatal("retry refresh did not finish in time")
+	}
+	if health, _ := h.DB.GetFeedHealth(id); health != nil {
+		t.Errorf("successful retry should clear the failure history, got %+v", health)
+	}
+}
@@ -1,32 +1,68 @@
 package feed
 
 import (
-	"crypto/tls"
+	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
-	"runtime"
+	"time"
 
+	"MrRSS/internal/database"
+	"MrRSS/internal/email"
 	"MrRSS/internal/handlers/core"
 	"MrRSS/internal/handlers/response"
-	"MrRSS/internal/version"
-
-	id "github.com/emersion/go-imap-id"
-	"github.com/emersion/go-imap/client"
 )
 
+// emailAuthFields are the IMAP security and OAuth fields shared by the feed add, update and test requests
+type emailAuthFields struct {
+	EmailSecurity          string `json:"email_security"`
+	EmailAuthMethod        string `json:"email_auth_method"`
+	EmailOAuthTokenURL     string `json:"email_oauth_token_url"`
+	EmailOAuthClientID     string `json:"email_oauth_client_id"`
+	EmailOAuthClientSecret string `json:"email_oauth_client_secret"`
+	EmailOAuthRefreshToken string `json:"email_oauth_refresh_token"`
+}
+
+// updateOptions returns the feed update for these fields.
+// Secrets are only replaced when a new value is sent, the feed list never returns them.
+func (f emailAuthFields) updateOptions() database.FeedUpdateOptions {
+	opts := database.FeedUpdateOptions{
+		EmailSecurity:      &f.EmailSecurity,
+		EmailAuthMethod:    &f.EmailAuthMethod,
+		EmailOAuthTokenURL: &f.EmailOAuthTokenURL,
+		EmailOAuthClientID: &f.EmailOAuthClientID,
+	}
+	if f.EmailOAuthClientSecret != "" {
+		opts.EmailOAuthClientSecret = &f.EmailOAuthClientSecret
+	}
+	if f.EmailOAuthRefreshToken != "" {
+		opts.EmailOAuthRefreshToken = &f.EmailOAuthRefreshToken
+	}
+	return opts
+}
+
+// validate checks the security mode and login method names
+func (f emailAuthFields) validate() error {
+	account := email.Account{Security: f.EmailSecurity, AuthMethod: f.EmailAuthMethod}
+	if _, err := account.ResolveSecurity(); err != nil {
+		return err
+	}
+	_, err := account.ResolveAuthMethod()
+	return err
+}
+
 // HandleTestIMAPConnection tests IMAP connection settings
 // @Summary      Test IMAP connection
-// @Description  Test IMAP server connection with provided credentials
+// @Description  Test IMAP server connection with provided credentials, security mode (tls, starttls, plaintext) and login method (password, xoauth2, oauthbearer)
 // @Tags         email
 // @Accept       json
 // @Produce      json
-// @Param        request  body      object  true  "IMAP connection test (email_imap_server, email_imap_port, email_username, email_password, email_folder)"
+// @Param        request  body      object  true  "IMAP connection test (email_imap_server, email_imap_port, email_username, email_password, email_folder, email_security, email_auth_method, email_oauth_token_url, email_oauth_client_id, email_oauth_client_secret, email_oauth_refresh_token, feed_id)"
 // @Success      200  {object}  map[string]string  "Connection successful (message)"
 // @Failure      400  {object}  map[string]string  "Bad request (missing required fields)"
 // @Failure      401  {object}  map[string]string  "Authentication failed"
-// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Failure      502  {object}  map[string]string  "Connection or TLS negotiation failed"
 // @Router       /email/imap/test [post]
 func HandleTestIMAPConnection(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	log.Printf("[IMAP Test] Handler called, method: %s", r.Method)
@@ -43,6 +79,9 @@ func HandleTestIMAPConnection(h *core.Handler, w http.ResponseWriter, r *http.Re
 		Username   string `json:"email_username"`
 		Password   string `json:"email_password"`
 		Folder     string `json:"email_folder"`
+		// Stored secrets of this feed are used when the form leaves them blank
+		FeedID int64 `json:"feed_id"`
+		emailAuthFields
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -51,15 +90,8 @@ func HandleTestIMAPConnection(h *core.Handler, w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	log.Printf("[IMAP Test] Request received: server=%s, port=%d, username=%s, folder=%s",
-		req.IMAPServer, req.IMAPPort, req.Username, req.Folder)
-
-	// Validate required fields
-	if req.IMAPServer == "" || req.Username == "" || req.Password == "" {
-		response.JSON(w, map[string]string{"error": "IMAP server, username, and password are required"})
-		w.WriteHeader(http.StatusBadRequest)
-		return
-	}
+	log.Printf("[IMAP Test] Request received: server=%s, port=%d, username=%s, folder=%s, security=%s, auth=%s",
+		req.IMAPServer, req.IMAPPort, req.Username, req.Folder, req.EmailSecurity, req.EmailAuthMethod)
 
 	// Set default port if not specified
 	if req.IMAPPort == 0 {
@@ -71,56 +103,63 @@ func HandleTestIMAPConnection(h *core.Handler, w http.ResponseWriter, r *http.Re
 		req.Folder = "INBOX"
 	}
 
-	// Try to connect to IMAP server
-	server := req.IMAPServer
-	if req.IMAPPort != 0 {
-		server = fmt.Sprintf("%s:%d", req.IMAPServer, req.IMAPPort)
+	account := email.Account{
+		Server:     req.IMAPServer,
+		Port:       req.IMAPPort,
+		Security:   req.EmailSecurity,
+		AuthMethod: req.EmailAuthMethod,
+		Username:   req.Username,
+		Password:   req.Password,
+		OAuth: email.OAuthConfig{
+			TokenURL:     req.EmailOAuthTokenURL,
+			ClientID:     req.EmailOAuthClientID,
+			ClientSecret: req.EmailOAuthClientSecret,
+			RefreshToken: req.EmailOAuthRefreshToken,
+		},
+	}
+	if req.FeedID != 0 {
+		if feed, err := h.DB.GetFeedByID(req.FeedID); err == nil {
+			if account.Password == "" {
+				account.Password = feed.EmailPassword
+			}
+			if account.OAuth.ClientSecret == "" {
+				account.OAuth.ClientSecret = feed.EmailOAuthClientSecret
+			}
+			if account.OAuth.RefreshToken == "" {
+				account.OAuth.RefreshToken = feed.EmailOAuthRefreshToken
+			}
+		}
 	}
 
-	// Create TLS config
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: false,
-		ServerName:         req.IMAPServer,
+	// Validate required fields
+	if err := account.Validate(); err != nil {
+		writeIMAPError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	// Try TLS first
-	log.Printf("[IMAP Test] Attempting TLS connection to %s", server)
-	c, err := client.DialTLS(server, tlsConfig)
+	ctx, cancel := context.WithTimeout(r.Context(), time.Minute)
+	defer cancel()
+
+	log.Printf("[IMAP Test] Connecting to %s:%d", req.IMAPServer, req.IMAPPort)
+	c, err := email.Dial(ctx, account)
 	if err != nil {
-		log.Printf("[IMAP Test] TLS failed, trying non-TLS: %v", err)
-		// Fallback to non-TLS
-		c, err = client.Dial(server)
-		if err != nil {
-			log.Printf("[IMAP Test] Connection failed: %v", err)
-			response.JSON(w, map[string]string{"error": "Failed to connect to IMAP server: " + err.Error()})
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+		log.Printf("[IMAP Test] Connection failed: %v", err)
+		status := http.StatusBadGateway
+		var connErr *email.ConnError
+		if errors.As(err, &connErr) && (connErr.Stage == email.StageAuth || connErr.Stage == email.StageToken) {
+			status = http.StatusUnauthorized
 		}
-	}
-	defer c.Logout()
-	log.Printf("[IMAP Test] Connected successfully")
-
-	// Send ID command before login (RFC 2971)
-	// This is required by some email providers like NetEase (163, 126)
-	sendIMAPIDCommand(c)
-
-	// Login
-	log.Printf("[IMAP Test] Attempting login for user: %s", req.Username)
-	if err := c.Login(req.Username, req.Password); err != nil {
-		log.Printf("[IMAP Test] Login failed: %v", err)
-		response.JSON(w, map[string]string{"error": "Authentication failed: " + err.Error()})
-		w.WriteHeader(http.StatusUnauthorized)
+		writeIMAPError(w, status, err.Error())
 		return
 	}
-	log.Printf("[IMAP Test] Login successful")
+	defer c.Logout()
+	log.Printf("[IMAP Test] Connected and logged in (TLS: %v)", c.IsTLS())
 
 	// Try to select the folder
 	log.Printf("[IMAP Test] Selecting folder: %s", req.Folder)
-	_, err = c.Select(req.Folder, false)
-	if err != nil {
+	if _, err := c.Select(req.Folder, false); err != nil {
 		log.Printf("[IMAP Test] Folder selection failed: %v", err)
-		response.JSON(w, map[string]string{"error": "Failed to select folder '" + req.Folder + "': " + err.Error()})
-		w.WriteHeader(http.StatusBadRequest)
+		writeIMAPError(w, http.StatusBadRequest, "Failed to select folder '"+req.Folder+"': "+err.Error())
 		return
 	}
 	log.Printf("[IMAP Test] Folder selected successfully")
@@ -130,31 +169,9 @@ func HandleTestIMAPConnection(h *core.Handler, w http.ResponseWriter, r *http.Re
 	response.JSON(w, map[string]string{"message": "Connection successful!"})
 }
 
-// sendIMAPIDCommand sends the IMAP ID command to identify the client
-// This is required by some email providers (e.g., NetEase 163/126) as per RFC 2971
-func sendIMAPIDCommand(c *client.Client) {
-	idClient := id.NewClient(c)
-
-	// Check if server supports ID extension
-	supported, err := idClient.SupportID()
-	if err != nil || !supported {
-		// Server doesn't support ID extension, skip
-		log.Printf("[IMAP ID] Server does not support ID extension")
-		return
-	}
-
-	// Send client identification
-	clientID := id.ID{
-		id.FieldName:    "MrRSS",
-		id.FieldVersion: version.Version,
-		id.FieldVendor:  "MrRSS",
-		id.FieldOS:      runtime.GOOS,
-	}
-
-	serverID, err := idClient.ID(clientID)
-	if err != nil {
-		log.Printf("[IMAP ID] Failed to send ID command: %v", err)
-		return
-	}
-	log.Printf("[IMAP ID] Server ID: %v", serverID)
+// writeIMAPError writes a connection test failure with the reason shown to the user
+func writeIMAPError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
 }
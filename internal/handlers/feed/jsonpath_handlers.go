@@ -0,0 +1,73 @@
+package feed
+
+import (
+	"errors"
+	"fmt"
+
+	"MrRSS/internal/database"
+	ff "MrRSS/internal/feed"
+	"MrRSS/internal/feed/source"
+	"MrRSS/internal/models"
+)
+
+// jsonPathFields are the JSON API fields shared by the feed add and update requests
+type jsonPathFields struct {
+	JSONPathItem           string `json:"jsonpath_item"`
+	JSONPathItemTitle      string `json:"jsonpath_item_title"`
+	JSONPathItemUri        string `json:"jsonpath_item_uri"`
+	JSONPathItemContent    string `json:"jsonpath_item_content"`
+	JSONPathItemAuthor     string `json:"jsonpath_item_author"`
+	JSONPathItemTimestamp  string `json:"jsonpath_item_timestamp"`
+	JSONPathItemTimeFormat string `json:"jsonpath_item_time_format"`
+	JSONPathItemThumbnail  string `json:"jsonpath_item_thumbnail"`
+	JSONPathItemUid        string `json:"jsonpath_item_uid"`
+	JSONHeaders            string `json:"json_headers"`
+	JSONPageParam          string `json:"json_page_param"`
+	JSONCursorPath         string `json:"json_cursor_path"`
+	JSONMaxPages           int    `json:"json_max_pages"`
+}
+
+// feed returns a JSON API feed with these fields
+func (f jsonPathFields) feed(url, category, title string) *models.Feed {
+	return &models.Feed{
+		Title:                  title,
+		URL:                    url,
+		Category:               category,
+		Type:                   ff.JSONPathFeedType,
+		JSONPathItem:           f.JSONPathItem,
+		JSONPathItemTitle:      f.JSONPathItemTitle,
+		JSONPathItemUri:        f.JSONPathItemUri,
+		JSONPathItemContent:    f.JSONPathItemContent,
+		JSONPathItemAuthor:     f.JSONPathItemAuthor,
+		JSONPathItemTimestamp:  f.JSONPathItemTimestamp,
+		JSONPathItemTimeFormat: f.JSONPathItemTimeFormat,
+		JSONPathItemThumbnail:  f.JSONPathItemThumbnail,
+		JSONPathItemUid:        f.JSONPathItemUid,
+		JSONHeaders:            f.JSONHeaders,
+		JSONPageParam:          f.JSONPageParam,
+		JSONCursorPath:         f.JSONCursorPath,
+		JSONMaxPages:           f.JSONMaxPages,
+	}
+}
+
+// updateOptions returns the feed update for these fields
+func (f jsonPathFields) updateOptions() database.FeedUpdateOptions {
+	return ff.JSONPathUpdateOptions(f.feed("", "", ""))
+}
+
+// validate checks the item path, headers and pagination settings
+func (f jsonPathFields) validate() error {
+	if f.JSONPathItem == "" {
+		return errors.New("jsonpath_item is required")
+	}
+	if _, err := ff.ParseJSONHeaders(f.JSONHeaders); err != nil {
+		return err
+	}
+	if f.JSONCursorPath != "" && f.JSONPageParam == "" {
+		return errors.New("json_page_param is required when json_cursor_path is set")
+	}
+	if f.JSONMaxPages < 0 || f.JSONMaxPages > source.MaxJSONPages {
+		return fmt.Errorf("json_max_pages must be between 0 and %d", source.MaxJSONPages)
+	}
+	return nil
+}
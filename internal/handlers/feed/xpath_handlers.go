@@ -0,0 +1,137 @@
+package feed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"MrRSS/internal/database"
+	ff "MrRSS/internal/feed"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/handlers/response"
+	"MrRSS/internal/models"
+	"MrRSS/internal/utils/urlutil"
+)
+
+// xpathPageFields are the pagination and detail page fields shared by the XPath feed add and update requests
+type xpathPageFields struct {
+	XPathNextPage        string `json:"xpath_next_page"`
+	XPathMaxPages        int    `json:"xpath_max_pages"`
+	XPathDetailContent   string `json:"xpath_detail_content"`
+	XPathDetailAuthor    string `json:"xpath_detail_author"`
+	XPathDetailTimestamp string `json:"xpath_detail_timestamp"`
+}
+
+// updateOptions returns the feed update for these fields
+func (f xpathPageFields) updateOptions() database.FeedUpdateOptions {
+	return database.FeedUpdateOptions{
+		XPathNextPage:        &f.XPathNextPage,
+		XPathMaxPages:        &f.XPathMaxPages,
+		XPathDetailContent:   &f.XPathDetailContent,
+		XPathDetailAuthor:    &f.XPathDetailAuthor,
+		XPathDetailTimestamp: &f.XPathDetailTimestamp,
+	}
+}
+
+// validate checks the page limit and that the expressions compile
+func (f xpathPageFields) validate() error {
+	if f.XPathMaxPages < 0 || f.XPathMaxPages > ff.MaxXPathPages {
+		return fmt.Errorf("xpath_max_pages must be between 0 and %d", ff.MaxXPathPages)
+	}
+	for _, expr := range []string{f.XPathNextPage, f.XPathDetailContent, f.XPathDetailAuthor, f.XPathDetailTimestamp} {
+		if err := ff.ValidateXPath(expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maxXPathPreviewBytes limits the page content pasted into a preview request
+const maxXPathPreviewBytes = 10 << 20
+
+// HandleXPathPreview applies XPath expressions to a page without adding a feed.
+// @Summary      Preview XPath feed
+// @Description  Fetch url, or use the pasted content, and apply the xpath_item* expressions. Returns the extracted items, per-field match counts, warnings for empty or invalid expressions, timestamps the time format could not parse, the next page link and suggested item expressions.
+// @Tags         feeds
+// @Accept       json
+// @Produce      json
+// @Param        request  body      object  true  "url or content, type (HTML+XPath or XML+XPath) and the XPath fields of a feed"
+// @Success      200  {object}  ff.XPathPreview  "Preview"
+// @Failure      400  {object}  map[string]string  "Page could not be fetched or parsed"
+// @Router       /xpath/preview [post]
+func HandleXPathPreview(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		URL     string `json:"url"`
+		Content string `json:"content"` // Pasted HTML or XML, used instead of fetching url
+		Type    string `json:"type"`
+		// XPath fields
+		XPathItem           string `json:"xpath_item"`
+		XPathItemTitle      string `json:"xpath_item_title"`
+		XPathItemContent    string `json:"xpath_item_content"`
+		XPathItemUri        string `json:"xpath_item_uri"`
+		XPathItemAuthor     string `json:"xpath_item_author"`
+		XPathItemTimestamp  string `json:"xpath_item_timestamp"`
+		XPathItemTimeFormat string `json:"xpath_item_time_format"`
+		XPathItemThumbnail  string `json:"xpath_item_thumbnail"`
+		XPathItemCategories string `json:"xpath_item_categories"`
+		XPathItemUid        string `json:"xpath_item_uid"`
+		xpathPageFields
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxXPathPreviewBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if req.Type == "" {
+		req.Type = "HTML+XPath"
+	}
+	var body []byte
+	if req.Content != "" {
+		body = []byte(req.Content)
+	} else if req.URL == "" {
+		response.Error(w, errors.New("url or content is required"), http.StatusBadRequest)
+		return
+	} else {
+		req.URL = urlutil.NormalizeFeedURL(req.URL)
+	}
+
+	feed := &models.Feed{
+		URL:                 req.URL,
+		Type:                req.Type,
+		XPathItem:           req.XPathItem,
+		XPathItemTitle:      req.XPathItemTitle,
+		XPathItemContent:    req.XPathItemContent,
+		XPathItemUri:        req.XPathItemUri,
+		XPathItemAuthor:     req.XPathItemAuthor,
+		XPathItemTimestamp:  req.XPathItemTimestamp,
+		XPathItemTimeFormat: req.XPathItemTimeFormat,
+		XPathItemThumbnail:  req.XPathItemThumbnail,
+		XPathItemCategories: req.XPathItemCategories,
+		XPathItemUid:        req.XPathItemUid,
+		XPathNextPage:       req.XPathNextPage,
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+	preview, err := h.Fetcher.PreviewXPath(ctx, feed, body)
+	if err != nil {
+		var xpathErr *ff.XPathError
+		if errors.As(err, &xpathErr) {
+			response.Error(w, err, http.StatusBadRequest)
+			return
+		}
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	response.JSON(w, preview)
+}
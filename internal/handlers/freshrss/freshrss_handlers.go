@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
+	"MrRSS/internal/database"
 	"MrRSS/internal/freshrss"
 	"MrRSS/internal/handlers/core"
 	"MrRSS/internal/handlers/response"
@@ -60,6 +62,7 @@ func HandleSyncFeed(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 
 	// Create bidirectional sync service
 	syncService := freshrss.NewBidirectionalSyncService(serverURL, username, password, h.DB)
+	syncService.SetProgressReporter(h.Fetcher)
 	log.Printf("[HandleSyncFeed] Syncing stream: %s", streamID)
 
 	// Perform sync in background
@@ -123,6 +126,7 @@ func HandleSync(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 
 	// Create bidirectional sync service
 	syncService := freshrss.NewBidirectionalSyncService(serverURL, username, password, h.DB)
+	syncService.SetProgressReporter(h.Fetcher)
 	log.Printf("[HandleSync] Sync service created, starting sync")
 
 	// Perform sync in background
@@ -155,7 +159,7 @@ func HandleSync(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 // @Tags         freshrss
 // @Accept       json
 // @Produce      json
-// @Success      200  {object}  map[string]interface{}  "Sync status (pending_changes, failed_items, last_sync_time)"
+// @Success      200  {object}  map[string]interface{}  "Sync status (pending_changes, failed_items, failed_subscription_changes, last_sync_time)"
 // @Router       /freshrss/status [get]
 func HandleSyncStatus(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -177,6 +181,13 @@ func HandleSyncStatus(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 		failedItems = nil
 	}
 
+	// Get failed subscription changes
+	failedSubscriptions, err := h.DB.GetFailedSubscriptionChangeCount()
+	if err != nil {
+		log.Printf("Error getting failed subscription changes: %v", err)
+		failedSubscriptions = 0
+	}
+
 	// Get last sync time from settings
 	lastSyncStr, _ := h.DB.GetSetting("freshrss_last_sync_time")
 	var lastSyncTime *time.Time
@@ -187,8 +198,44 @@ func HandleSyncStatus(h *core.Handler, w http.ResponseWriter, r *http.Request) {
 	}
 
 	response.JSON(w, map[string]interface{}{
-		"pending_changes": pendingCount,
-		"failed_items":    len(failedItems),
-		"last_sync_time":  lastSyncTime,
+		"pending_changes":             pendingCount,
+		"failed_items":                len(failedItems),
+		"failed_subscription_changes": failedSubscriptions,
+		"last_sync_time":              lastSyncTime,
 	})
 }
+
+// HandleSyncConflicts returns the conflict log of read/star changes made on both sides
+// @Summary      Get FreshRSS sync conflicts
+// @Description  List recent read/star conflicts between local and server state and how they were resolved (last writer wins)
+// @Tags         freshrss
+// @Accept       json
+// @Produce      json
+// @Param        limit  query     int  false  "Maximum number of entries (default 100)"
+// @Success      200  {array}   database.SyncConflict  "Conflict log entries, newest first"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /freshrss/conflicts [get]
+func HandleSyncConflicts(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := 100
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 1000 {
+			limit = l
+		}
+	}
+
+	conflicts, err := h.DB.GetSyncConflicts(limit)
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	if conflicts == nil {
+		conflicts = []database.SyncConflict{}
+	}
+
+	response.JSON(w, conflicts)
+}
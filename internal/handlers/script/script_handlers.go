@@ -7,7 +7,9 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
+	ff "MrRSS/internal/feed"
 	"MrRSS/internal/handlers/core"
 	"MrRSS/internal/handlers/response"
 	"MrRSS/internal/utils/fileutil"
@@ -86,13 +88,31 @@ func HandleOpenScriptsDir(h *core.Handler, w http.ResponseWriter, r *http.Reques
 	})
 }
 
+// scriptInfo describes a script in the scripts list
+type scriptInfo struct {
+	Name            string     `json:"name"`
+	Path            string     `json:"path"`
+	Type            string     `json:"type"`
+	Interpreter     string     `json:"interpreter,omitempty"`      // From the manifest
+	Venv            bool       `json:"venv,omitempty"`             // From the manifest
+	ScheduleMinutes int        `json:"schedule_minutes,omitempty"` // From the manifest
+	ManifestError   string     `json:"manifest_error,omitempty"`   // Why the manifest can't be used
+	LastStatus      string     `json:"last_status,omitempty"`      // "ok" or "error", empty before the first run
+	LastAttemptAt   *time.Time `json:"last_attempt_at,omitempty"`
+	LastRunAt       *time.Time `json:"last_run_at,omitempty"` // Last successful run
+	LastDurationMS  int64      `json:"last_duration_ms,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+	StderrTail      string     `json:"stderr_tail,omitempty"`
+}
+
 // HandleListScripts returns a list of available scripts in the scripts directory
+// with their manifest settings and the outcome of their latest run
 // @Summary      List available scripts
-// @Description  Get a list of all available scripts in the scripts directory (Python, Shell, PowerShell, Node.js, Ruby)
+// @Description  Get a list of all available scripts in the scripts directory (Python, Shell, PowerShell, Node.js, Deno, Ruby) with their last run status, duration and stderr tail
 // @Tags         scripts
 // @Accept       json
 // @Produce      json
-// @Success      200  {object}  map[string]interface{}  "List of scripts (scripts array with name, path, type)"
+// @Success      200  {object}  map[string]interface{}  "List of scripts (scripts array with name, path, type, manifest settings and last run)"
 // @Failure      500  {object}  map[string]string  "Internal server error"
 // @Router       /scripts/list [get]
 func HandleListScripts(h *core.Handler, w http.ResponseWriter, r *http.Request) {
@@ -107,24 +127,39 @@ func HandleListScripts(h *core.Handler, w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Valid script extensions
-	validExtensions := map[string]bool{
-		".py":  true,
-		".sh":  true,
-		".ps1": true,
-		".js":  true,
-		".rb":  true,
+	runs, err := h.DB.GetScriptRuns()
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
 	}
 
-	var scripts []map[string]string
+	// Script types by extension
+	scriptTypes := map[string]string{
+		".py":  "Python",
+		".sh":  "Shell",
+		".ps1": "PowerShell",
+		".js":  "Node.js",
+		".ts":  "Deno",
+		".rb":  "Ruby",
+	}
+
+	scripts := []scriptInfo{}
 
 	err = filepath.Walk(scriptsDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// Skip directories
+		// Skip directories, and hidden ones such as the virtualenvs entirely
 		if info.IsDir() {
+			if path != scriptsDir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		scriptType, ok := scriptTypes[strings.ToLower(filepath.Ext(path))]
+		if !ok {
 			return nil
 		}
 
@@ -134,29 +169,31 @@ func HandleListScripts(h *core.Handler, w http.ResponseWriter, r *http.Request)
 			return err
 		}
 
-		ext := strings.ToLower(filepath.Ext(path))
-		scriptType := ""
-
-		if validExtensions[ext] {
-			switch ext {
-			case ".py":
-				scriptType = "Python"
-			case ".sh":
-				scriptType = "Shell"
-			case ".ps1":
-				scriptType = "PowerShell"
-			case ".js":
-				scriptType = "Node.js"
-			case ".rb":
-				scriptType = "Ruby"
+		script := scriptInfo{
+			Name: info.Name(),
+			Path: relPath,
+			Type: scriptType,
+		}
+		if config, err := ff.LoadScriptConfig(path); err != nil {
+			script.ManifestError = err.Error()
+		} else {
+			script.Interpreter = config.Interpreter
+			script.Venv = config.Venv
+			script.ScheduleMinutes = config.ScheduleMinutes
+		}
+		if run, ok := runs[relPath]; ok {
+			script.LastStatus = run.Status
+			if !run.LastAttemptAt.IsZero() {
+				script.LastAttemptAt = &run.LastAttemptAt
 			}
-
-			scripts = append(scripts, map[string]string{
-				"name": info.Name(),
-				"path": relPath,
-				"type": scriptType,
-			})
+			if !run.LastRunAt.IsZero() {
+				script.LastRunAt = &run.LastRunAt
+			}
+			script.LastDurationMS = run.Duration.Milliseconds()
+			script.LastError = run.Error
+			script.StderrTail = run.StderrTail
 		}
+		scripts = append(scripts, script)
 
 		return nil
 	})
@@ -166,10 +203,6 @@ func HandleListScripts(h *core.Handler, w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if scripts == nil {
-		scripts = []map[string]string{}
-	}
-
 	response.JSON(w, map[string]interface{}{
 		"scripts":     scripts,
 		"scripts_dir": scriptsDir,
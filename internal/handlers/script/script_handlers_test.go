@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"MrRSS/internal/database"
 	corepkg "MrRSS/internal/handlers/core"
@@ -97,3 +98,70 @@ func TestHandleListScripts_IncludesCreatedScript(t *testing.T) {
 		t.Fatalf("test_script.py not listed in scripts")
 	}
 }
+
+func TestHandleListScripts_ReportsLastRun(t *testing.T) {
+	h := setupHandler(t)
+
+	scriptsDir, err := fileutil.GetScriptsDir()
+	if err != nil {
+		t.Fatalf("GetScriptsDir failed: %v", err)
+	}
+	testScript := filepath.Join(scriptsDir, "run_info_script.sh")
+	if err := os.WriteFile(testScript, []byte("echo"), fs.FileMode(0644)); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	defer os.Remove(testScript)
+	// Scripts inside virtualenvs are not feed scripts
+	venvScript := filepath.Join(scriptsDir, ".venvs", "x", "bin", "activate.py")
+	if err := os.MkdirAll(filepath.Dir(venvScript), 0755); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	if err := os.WriteFile(venvScript, []byte(""), fs.FileMode(0644)); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	defer os.RemoveAll(filepath.Join(scriptsDir, ".venvs", "x"))
+
+	if err := h.DB.SaveScriptRun(database.ScriptRun{
+		ScriptPath:    "run_info_script.sh",
+		LastAttemptAt: time.Now(),
+		Status:        database.ScriptRunFailed,
+		Error:         "exit status 1",
+		Duration:      1500 * time.Millisecond,
+		StderrTail:    "Traceback",
+	}); err != nil {
+		t.Fatalf("SaveScriptRun failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/scripts/list", nil)
+	rr := httptest.NewRecorder()
+	HandleListScripts(h, rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rr.Code)
+	}
+
+	var resp struct {
+		Scripts []scriptInfo `json:"scripts"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	var script *scriptInfo
+	for i := range resp.Scripts {
+		switch resp.Scripts[i].Name {
+		case "run_info_script.sh":
+			script = &resp.Scripts[i]
+		case "activate.py":
+			t.Errorf("script inside .venvs listed: %+v", resp.Scripts[i])
+		}
+	}
+	if script == nil {
+		t.Fatalf("run_info_script.sh not listed in scripts")
+	}
+	if script.LastStatus != "error" || script.LastDurationMS != 1500 || script.StderrTail != "Traceback" || script.LastAttemptAt == nil {
+		t.Errorf("unexpected last run: %+v", script)
+	}
+	if script.LastRunAt != nil {
+		t.Errorf("expected no successful run, got %v", script.LastRunAt)
+	}
+}
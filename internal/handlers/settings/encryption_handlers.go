@@ -0,0 +1,113 @@
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"MrRSS/internal/crypto"
+	"MrRSS/internal/database"
+	"MrRSS/internal/handlers/core"
+	"MrRSS/internal/handlers/response"
+)
+
+// HandleEncryptionStatus returns how stored secrets are encrypted.
+// @Summary      Get encryption status
+// @Description  Get the encryption mode (machine, passphrase or keyfile) and whether its key is unlocked
+// @Tags         settings
+// @Produce      json
+// @Success      200  {object}  database.EncryptionStatus  "Encryption status"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /encryption/status [get]
+func HandleEncryptionStatus(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	status, err := h.DB.GetEncryptionStatus()
+	if err != nil {
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+	response.JSON(w, status)
+}
+
+// HandleEncryptionUnlock unlocks the master key with a passphrase.
+// @Summary      Unlock encryption key
+// @Description  Unlock the passphrase-protected master key so encrypted settings can be read
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Param        request  body      object  true  "Passphrase (passphrase)"
+// @Success      200  {object}  database.EncryptionStatus  "Encryption status"
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      401  {object}  map[string]string  "Wrong passphrase"
+// @Router       /encryption/unlock [post]
+func HandleEncryptionUnlock(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Passphrase string `json:"passphrase"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.DB.UnlockEncryption(req.Passphrase); err != nil {
+		if errors.Is(err, crypto.ErrWrongSecret) {
+			response.Error(w, err, http.StatusUnauthorized)
+			return
+		}
+		response.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	HandleEncryptionStatus(h, w, r)
+}
+
+// HandleEncryptionRekey switches the encryption mode and re-encrypts all stored secrets.
+// @Summary      Change encryption key
+// @Description  Switch between machine, passphrase and key file encryption and migrate encrypted settings, AI profile keys and feed passwords
+// @Tags         settings
+// @Accept       json
+// @Produce      json
+// @Param        request  body      object  true  "New mode (mode, passphrase, key_file, remember_on_machine)"
+// @Success      200  {object}  database.RekeyResult  "Migration result"
+// @Failure      400  {object}  map[string]string  "Bad request"
+// @Failure      423  {object}  map[string]string  "Current key is locked"
+// @Failure      500  {object}  map[string]string  "Internal server error"
+// @Router       /encryption/rekey [post]
+func HandleEncryptionRekey(h *core.Handler, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		response.Error(w, nil, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Mode              string `json:"mode"`
+		Passphrase        string `json:"passphrase"`
+		KeyFile           string `json:"key_file"`
+		RememberOnMachine bool   `json:"remember_on_machine"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.DB.RekeyEncryption(database.RekeyOptions{
+		Mode:              req.Mode,
+		Passphrase:        req.Passphrase,
+		KeyFile:           req.KeyFile,
+		RememberOnMachine: req.RememberOnMachine,
+	})
+	if err != nil {
+		if errors.Is(err, crypto.ErrLocked) {
+			response.Error(w, err, http.StatusLocked)
+			return
+		}
+		response.Error(w, err, http.StatusBadRequest)
+		return
+	}
+
+	response.JSON(w, result)
+}
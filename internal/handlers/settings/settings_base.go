@@ -31,8 +31,13 @@ var AllSettings = []SettingDef{
 	{Key: "ai_translation_prompt", Encrypted: false},
 	{Key: "ai_usage_limit", Encrypted: false},
 	{Key: "ai_usage_tokens", Encrypted: false},
+	{Key: "archive_enabled", Encrypted: false},
 	{Key: "auto_cleanup_enabled", Encrypted: false},
 	{Key: "auto_show_all_content", Encrypted: false},
+	{Key: "backup_directory", Encrypted: false},
+	{Key: "backup_enabled", Encrypted: false},
+	{Key: "backup_interval_hours", Encrypted: false},
+	{Key: "backup_keep_count", Encrypted: false},
 	{Key: "baidu_app_id", Encrypted: false},
 	{Key: "baidu_secret_key", Encrypted: true},
 	{Key: "close_to_tray", Encrypted: false},
@@ -49,33 +54,48 @@ var AllSettings = []SettingDef{
 	{Key: "custom_translation_name", Encrypted: false},
 	{Key: "custom_translation_response_path", Encrypted: false},
 	{Key: "custom_translation_timeout", Encrypted: false},
+	{Key: "db_maintenance_interval_hours", Encrypted: false},
+	{Key: "db_maintenance_operations", Encrypted: false},
 	{Key: "deepl_api_key", Encrypted: true},
 	{Key: "deepl_endpoint", Encrypted: false},
 	{Key: "default_view_mode", Encrypted: false},
+	{Key: "email_idle_enabled", Encrypted: false},
+	{Key: "email_inbound_domain", Encrypted: false},
+	{Key: "email_inbound_enabled", Encrypted: false},
+	{Key: "email_inbound_listen", Encrypted: false},
+	{Key: "email_inbound_protocol", Encrypted: false},
+	{Key: "feed_auto_pause_failures", Encrypted: false},
 	{Key: "feed_drawer_expanded", Encrypted: false},
 	{Key: "feed_drawer_pinned", Encrypted: false},
 	{Key: "freshrss_api_password", Encrypted: true},
 	{Key: "freshrss_auto_sync_interval", Encrypted: false},
 	{Key: "freshrss_enabled", Encrypted: false},
 	{Key: "freshrss_last_sync_time", Encrypted: false},
+	{Key: "freshrss_push_new_feeds", Encrypted: false},
 	{Key: "freshrss_server_url", Encrypted: false},
 	{Key: "freshrss_sync_on_startup", Encrypted: false},
 	{Key: "freshrss_username", Encrypted: false},
 	{Key: "full_text_fetch_enabled", Encrypted: false},
 	{Key: "google_translate_endpoint", Encrypted: false},
+	{Key: "host_request_interval_ms", Encrypted: false},
 	{Key: "hover_mark_as_read", Encrypted: false},
 	{Key: "image_gallery_enabled", Encrypted: false},
 	{Key: "language", Encrypted: false},
+	{Key: "last_backup_time", Encrypted: false},
+	{Key: "last_db_maintenance_time", Encrypted: false},
 	{Key: "last_global_refresh", Encrypted: false},
 	{Key: "last_network_test", Encrypted: false},
 	{Key: "layout_mode", Encrypted: false},
 	{Key: "max_article_age_days", Encrypted: false},
 	{Key: "max_cache_size_mb", Encrypted: false},
 	{Key: "max_concurrent_refreshes", Encrypted: false},
+	{Key: "max_refreshes_per_host", Encrypted: false},
 	{Key: "media_cache_enabled", Encrypted: false},
 	{Key: "media_cache_max_age_days", Encrypted: false},
 	{Key: "media_cache_max_size_mb", Encrypted: false},
 	{Key: "media_proxy_fallback", Encrypted: false},
+	{Key: "metered_min_favorites", Encrypted: false},
+	{Key: "metered_mode", Encrypted: false},
 	{Key: "microsoft_api_key", Encrypted: true},
 	{Key: "microsoft_endpoint", Encrypted: false},
 	{Key: "microsoft_region", Encrypted: false},
@@ -94,7 +114,9 @@ var AllSettings = []SettingDef{
 	{Key: "proxy_port", Encrypted: false},
 	{Key: "proxy_type", Encrypted: false},
 	{Key: "proxy_username", Encrypted: true},
+	{Key: "quiet_hours", Encrypted: false},
 	{Key: "refresh_mode", Encrypted: false},
+	{Key: "restore_required_secrets", Encrypted: false},
 	{Key: "retry_timeout_seconds", Encrypted: false},
 	{Key: "rsshub_api_key", Encrypted: true},
 	{Key: "rsshub_enabled", Encrypted: false},
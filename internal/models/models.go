@@ -21,7 +21,7 @@ type Feed struct {
 	RefreshInterval    int       `json:"refresh_interval"`      // Custom refresh interval in minutes (0 = use global, -1 = intelligent, -2 = never, >0 = custom minutes)
 	IsImageMode        bool      `json:"is_image_mode"`         // Whether this feed is for image gallery mode
 	// XPath support for HTML/XML scraping
-	Type                string `json:"type"`                   // "HTML+XPath" or "XML+XPath"
+	Type                string `json:"type"`                   // "HTML+XPath", "XML+XPath", "JSON+JSONPath" or "email"
 	XPathItem           string `json:"xpath_item"`             // XPath to extract feed items
 	XPathItemTitle      string `json:"xpath_item_title"`       // XPath to extract item title
 	XPathItemContent    string `json:"xpath_item_content"`     // XPath to extract item content
@@ -32,16 +32,47 @@ type Feed struct {
 	XPathItemThumbnail  string `json:"xpath_item_thumbnail"`   // XPath to extract item thumbnail
 	XPathItemCategories string `json:"xpath_item_categories"`  // XPath to extract item categories
 	XPathItemUid        string `json:"xpath_item_uid"`         // XPath to extract item unique ID
-	ArticleViewMode     string `json:"article_view_mode"`      // Article view mode override ('global', 'webpage', 'rendered')
-	AutoExpandContent   string `json:"auto_expand_content"`    // Auto expand content mode ('global', 'enabled', 'disabled')
+	// XPath pagination and detail page extraction
+	XPathNextPage        string `json:"xpath_next_page,omitempty"`        // XPath to the link of the next listing page
+	XPathMaxPages        int    `json:"xpath_max_pages,omitempty"`        // Listing pages to follow (0 = default when a next page XPath is set)
+	XPathDetailContent   string `json:"xpath_detail_content,omitempty"`   // XPath to extract full content from the item's own page
+	XPathDetailAuthor    string `json:"xpath_detail_author,omitempty"`    // XPath to extract the author from the item's own page
+	XPathDetailTimestamp string `json:"xpath_detail_timestamp,omitempty"` // XPath to extract the timestamp from the item's own page
+	ArticleViewMode      string `json:"article_view_mode"`                // Article view mode override ('global', 'webpage', 'rendered')
+	AutoExpandContent    string `json:"auto_expand_content"`              // Auto expand content mode ('global', 'enabled', 'disabled')
+	// JSON API support (Type "JSON+JSONPath"), item paths are relative to each item
+	JSONPathItem           string `json:"jsonpath_item,omitempty"`             // JSONPath to the items array
+	JSONPathItemTitle      string `json:"jsonpath_item_title,omitempty"`       // Path to the item title
+	JSONPathItemUri        string `json:"jsonpath_item_uri,omitempty"`         // Path to the item link
+	JSONPathItemContent    string `json:"jsonpath_item_content,omitempty"`     // Path to the item content
+	JSONPathItemAuthor     string `json:"jsonpath_item_author,omitempty"`      // Path to the item author
+	JSONPathItemTimestamp  string `json:"jsonpath_item_timestamp,omitempty"`   // Path to the item date
+	JSONPathItemTimeFormat string `json:"jsonpath_item_time_format,omitempty"` // Time format for parsing the date (numbers are Unix times)
+	JSONPathItemThumbnail  string `json:"jsonpath_item_thumbnail,omitempty"`   // Path to the item thumbnail URL
+	JSONPathItemUid        string `json:"jsonpath_item_uid,omitempty"`         // Path to the item unique ID
+	JSONHeaders            string `json:"json_headers,omitempty"`              // Request headers, one "Name: value" per line
+	JSONPageParam          string `json:"json_page_param,omitempty"`           // Query parameter carrying the page number or cursor
+	JSONCursorPath         string `json:"json_cursor_path,omitempty"`          // JSONPath to the next cursor in the response (empty = page numbers)
+	JSONMaxPages           int    `json:"json_max_pages,omitempty"`            // Pages to request (0 = default when a page parameter is set)
+	FullTextOnIngest       bool   `json:"full_text_on_ingest"`                 // Fetch the full text of new articles during refresh
 	// Email/Newsletter support
-	EmailAddress    string `json:"email_address,omitempty"`     // Email address for newsletter subscriptions
-	EmailIMAPServer string `json:"email_imap_server,omitempty"` // IMAP server address
-	EmailIMAPPort   int    `json:"email_imap_port"`             // IMAP server port (default 993)
-	EmailUsername   string `json:"email_username,omitempty"`    // IMAP username
-	EmailPassword   string `json:"email_password,omitempty"`    // IMAP password (encrypted)
-	EmailFolder     string `json:"email_folder"`                // IMAP folder to monitor (default INBOX)
-	EmailLastUID    int    `json:"email_last_uid"`              // Last processed email UID for incremental updates
+	EmailAddress     string `json:"email_address,omitempty"`     // Email address for newsletter subscriptions
+	EmailIMAPServer  string `json:"email_imap_server,omitempty"` // IMAP server address
+	EmailIMAPPort    int    `json:"email_imap_port"`             // IMAP server port (default 993)
+	EmailUsername    string `json:"email_username,omitempty"`    // IMAP username
+	EmailPassword    string `json:"email_password,omitempty"`    // IMAP password (encrypted)
+	EmailFolder      string `json:"email_folder"`                // IMAP folder to monitor (default INBOX)
+	EmailLastUID     int    `json:"email_last_uid"`              // Last processed email UID for incremental updates
+	EmailUIDValidity uint32 `json:"email_uid_validity"`          // UIDVALIDITY of the folder when EmailLastUID was recorded
+	// IMAP connection security and OAuth login
+	EmailSecurity          string `json:"email_security,omitempty"`            // Connection security ('tls', 'starttls', 'plaintext'; empty picks by port)
+	EmailAuthMethod        string `json:"email_auth_method,omitempty"`         // Login method ('password', 'xoauth2', 'oauthbearer')
+	EmailOAuthTokenURL     string `json:"email_oauth_token_url,omitempty"`     // OAuth token endpoint used to refresh access tokens
+	EmailOAuthClientID     string `json:"email_oauth_client_id,omitempty"`     // OAuth client ID
+	EmailOAuthClientSecret string `json:"email_oauth_client_secret,omitempty"` // OAuth client secret (encrypted)
+	EmailOAuthRefreshToken string `json:"email_oauth_refresh_token,omitempty"` // OAuth refresh token (encrypted)
+	EmailAccountID         int64  `json:"email_account_id,omitempty"`          // Newsletter feed whose mailbox routes messages to this feed (0 = own mailbox)
+	EmailInboundToken      string `json:"email_inbound_token,omitempty"`       // Token of the generated address the built-in SMTP receiver accepts for this feed
 	// FreshRSS integration
 	IsFreshRSSSource bool   `json:"is_freshrss_source"` // Whether this feed is from FreshRSS sync
 	FreshRSSStreamID string `json:"freshrss_stream_id"` // FreshRSS stream ID (e.g., "feed/http://...")
@@ -85,6 +116,17 @@ type SavedFilter struct {
 	UpdatedAt  time.Time `json:"updated_at"`
 }
 
+// SiteRule holds the full-text extraction settings of a site. Selectors are
+// CSS selectors, or XPath expressions when they start with "/" or "(".
+type SiteRule struct {
+	ID               int64     `json:"id"`
+	Host             string    `json:"host"`               // Site host, also matching its subdomains
+	ContentSelector  string    `json:"content_selector"`   // Elements holding the article body (empty = readability)
+	StripSelectors   string    `json:"strip_selectors"`    // Elements removed from the page, one selector per line
+	NextPageSelector string    `json:"next_page_selector"` // Link to the next page of a multi-page article
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
 // Tag represents a user-defined tag for organizing feeds
 type Tag struct {
 	ID       int64  `json:"id"`
@@ -93,6 +135,45 @@ type Tag struct {
 	Position int    `json:"position"`
 }
 
+// EmailRoute sends newsletters from a mailbox account to one of its routed feeds.
+type EmailRoute struct {
+	ID            int64  `json:"id"`
+	AccountFeedID int64  `json:"account_feed_id"` // Newsletter feed that owns the mailbox
+	FeedID        int64  `json:"feed_id"`         // Feed that receives the matching messages
+	MatchType     string `json:"match_type"`      // 'from', 'domain', 'list_id' or 'subject'
+	Pattern       string `json:"pattern"`         // Address, domain, List-Id or subject regular expression
+	Position      int    `json:"position"`        // Routes are tried in ascending position
+}
+
+// EmailRouting holds the per-account routing options of a mailbox.
+type EmailRouting struct {
+	AccountFeedID   int64  `json:"account_feed_id"`
+	AutoCreateFeeds bool   `json:"auto_create_feeds"` // Create a feed for every new List-Id that no route matches
+	ProcessedAction string `json:"processed_action"`  // '' (leave), 'move' or 'flag'
+	ProcessedFolder string `json:"processed_folder"`  // Target folder for the 'move' action
+}
+
+// Kinds of retention policies
+const (
+	RetentionKeepForever     = "keep_forever"      // Never delete articles
+	RetentionKeepLast        = "keep_last"         // Keep the Value newest articles
+	RetentionKeepDays        = "keep_days"         // Delete articles older than Value days
+	RetentionDeleteReadAfter = "delete_read_after" // Delete read articles older than Value days
+)
+
+// RetentionPolicy says how long the articles of a feed, a tag or a category are
+// kept. Exactly one of FeedID, TagID and Category is set. Favorite and read
+// later articles are never deleted.
+type RetentionPolicy struct {
+	ID          int64  `json:"id"`
+	FeedID      int64  `json:"feed_id,omitempty"`
+	TagID       int64  `json:"tag_id,omitempty"`
+	Category    string `json:"category,omitempty"` // Applies to its subcategories too
+	Kind        string `json:"kind"`               // One of the Retention* kinds
+	Value       int    `json:"value"`              // Article count or days, by kind
+	ContentDays int    `json:"content_days"`       // Days cached article contents are kept (0 = global default, -1 = forever)
+}
+
 // AIProfile represents an AI configuration profile
 type AIProfile struct {
 	ID            int64     `json:"id"`
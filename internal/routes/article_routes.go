@@ -27,6 +27,9 @@ func registerArticleRoutes(mux *http.ServeMux, h *core.Handler) {
 	mux.HandleFunc("/api/articles/content", func(w http.ResponseWriter, r *http.Request) { article.HandleGetArticleContent(h, w, r) })
 	mux.HandleFunc("/api/articles/fetch-full", func(w http.ResponseWriter, r *http.Request) { article.HandleFetchFullArticle(h, w, r) })
 	mux.HandleFunc("/api/articles/extract-images", func(w http.ResponseWriter, r *http.Request) { article.HandleExtractAllImages(h, w, r) })
+	mux.HandleFunc("/api/site-rules", func(w http.ResponseWriter, r *http.Request) { article.HandleSiteRules(h, w, r) })
+	mux.HandleFunc("/api/site-rules/delete", func(w http.ResponseWriter, r *http.Request) { article.HandleDeleteSiteRule(h, w, r) })
+	mux.HandleFunc("/api/site-rules/test", func(w http.ResponseWriter, r *http.Request) { article.HandleTestSiteRule(h, w, r) })
 
 	// Article statistics
 	mux.HandleFunc("/api/articles/unread-counts", func(w http.ResponseWriter, r *http.Request) { article.HandleGetUnreadCounts(h, w, r) })
@@ -35,6 +38,11 @@ func registerArticleRoutes(mux *http.ServeMux, h *core.Handler) {
 	// Article cleanup
 	mux.HandleFunc("/api/articles/cleanup", func(w http.ResponseWriter, r *http.Request) { article.HandleCleanupArticles(h, w, r) })
 	mux.HandleFunc("/api/articles/cleanup-content", func(w http.ResponseWriter, r *http.Request) { article.HandleCleanupArticleContent(h, w, r) })
+	mux.HandleFunc("/api/retention/policies", func(w http.ResponseWriter, r *http.Request) { article.HandleRetentionPolicies(h, w, r) })
+	mux.HandleFunc("/api/retention/preview", func(w http.ResponseWriter, r *http.Request) { article.HandleRetentionPreview(h, w, r) })
+	mux.HandleFunc("/api/archive", func(w http.ResponseWriter, r *http.Request) { article.HandleArchives(h, w, r) })
+	mux.HandleFunc("/api/archive/search", func(w http.ResponseWriter, r *http.Request) { article.HandleArchiveSearch(h, w, r) })
+	mux.HandleFunc("/api/archive/restore", func(w http.ResponseWriter, r *http.Request) { article.HandleArchiveRestore(h, w, r) })
 	mux.HandleFunc("/api/articles/content-cache-info", func(w http.ResponseWriter, r *http.Request) { article.HandleGetArticleContentCacheInfo(h, w, r) })
 
 	// Translation
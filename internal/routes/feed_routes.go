@@ -20,7 +20,17 @@ func registerFeedRoutes(mux *http.ServeMux, h *core.Handler) {
 	mux.HandleFunc("/api/feeds/update", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleUpdateFeed(h, w, r) })
 	mux.HandleFunc("/api/feeds/refresh", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleRefreshFeed(h, w, r) })
 	mux.HandleFunc("/api/feeds/reorder", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleReorderFeed(h, w, r) })
+	mux.HandleFunc("/api/feeds/broken", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleBrokenFeeds(h, w, r) })
+	mux.HandleFunc("/api/feeds/broken/retry", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleRetryFeed(h, w, r) })
 	mux.HandleFunc("/api/feeds/test-imap", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleTestIMAPConnection(h, w, r) })
+	mux.HandleFunc("/api/xpath/preview", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleXPathPreview(h, w, r) })
+
+	// Newsletter routing routes
+	mux.HandleFunc("/api/email/routes", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleEmailRoutes(h, w, r) })
+	mux.HandleFunc("/api/email/routes/add", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleAddEmailRoute(h, w, r) })
+	mux.HandleFunc("/api/email/routes/delete", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleDeleteEmailRoute(h, w, r) })
+	mux.HandleFunc("/api/email/routing", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleEmailRouting(h, w, r) })
+	mux.HandleFunc("/api/email/inbound/create", func(w http.ResponseWriter, r *http.Request) { feedhandlers.HandleCreateInboundFeed(h, w, r) })
 
 	// Discovery routes
 	mux.HandleFunc("/api/feeds/discover", func(w http.ResponseWriter, r *http.Request) { discovery.HandleDiscoverBlogs(h, w, r) })
@@ -22,6 +22,8 @@ func registerOtherRoutes(mux *http.ServeMux, h *core.Handler) {
 	mux.HandleFunc("/api/refresh", func(w http.ResponseWriter, r *http.Request) { article.HandleRefresh(h, w, r) })
 	mux.HandleFunc("/api/progress", func(w http.ResponseWriter, r *http.Request) { article.HandleProgress(h, w, r) })
 	mux.HandleFunc("/api/progress/task-details", func(w http.ResponseWriter, r *http.Request) { article.HandleTaskDetails(h, w, r) })
+	mux.HandleFunc("/api/schedule", func(w http.ResponseWriter, r *http.Request) { article.HandleSchedule(h, w, r) })
+	mux.HandleFunc("/api/schedule/rules", func(w http.ResponseWriter, r *http.Request) { article.HandleScheduleRules(h, w, r) })
 
 	// OPML
 	mux.HandleFunc("/api/opml/import", func(w http.ResponseWriter, r *http.Request) { opml.HandleOPMLImport(h, w, r) })
@@ -71,4 +73,5 @@ func registerOtherRoutes(mux *http.ServeMux, h *core.Handler) {
 	mux.HandleFunc("/api/freshrss/sync", func(w http.ResponseWriter, r *http.Request) { freshrssHandler.HandleSync(h, w, r) })
 	mux.HandleFunc("/api/freshrss/sync-feed", func(w http.ResponseWriter, r *http.Request) { freshrssHandler.HandleSyncFeed(h, w, r) })
 	mux.HandleFunc("/api/freshrss/status", func(w http.ResponseWriter, r *http.Request) { freshrssHandler.HandleSyncStatus(h, w, r) })
+	mux.HandleFunc("/api/freshrss/conflicts", func(w http.ResponseWriter, r *http.Request) { freshrssHandler.HandleSyncConflicts(h, w, r) })
 }
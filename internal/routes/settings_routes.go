@@ -3,6 +3,7 @@ package routes
 import (
 	"net/http"
 
+	backuphandlers "MrRSS/internal/handlers/backup"
 	"MrRSS/internal/handlers/core"
 	settings "MrRSS/internal/handlers/settings"
 	stathandlers "MrRSS/internal/handlers/statistics"
@@ -13,6 +14,11 @@ func registerSettingsRoutes(mux *http.ServeMux, h *core.Handler) {
 	// Settings
 	mux.HandleFunc("/api/settings", func(w http.ResponseWriter, r *http.Request) { settings.HandleSettings(h, w, r) })
 
+	// Encryption key
+	mux.HandleFunc("/api/encryption/status", func(w http.ResponseWriter, r *http.Request) { settings.HandleEncryptionStatus(h, w, r) })
+	mux.HandleFunc("/api/encryption/unlock", func(w http.ResponseWriter, r *http.Request) { settings.HandleEncryptionUnlock(h, w, r) })
+	mux.HandleFunc("/api/encryption/rekey", func(w http.ResponseWriter, r *http.Request) { settings.HandleEncryptionRekey(h, w, r) })
+
 	// Statistics
 	mux.HandleFunc("/api/statistics", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodDelete {
@@ -23,4 +29,19 @@ func registerSettingsRoutes(mux *http.ServeMux, h *core.Handler) {
 	})
 	mux.HandleFunc("/api/statistics/all-time", func(w http.ResponseWriter, r *http.Request) { stathandlers.HandleGetAllTimeStatistics(h, w, r) })
 	mux.HandleFunc("/api/statistics/available-months", func(w http.ResponseWriter, r *http.Request) { stathandlers.HandleGetAvailableMonths(h, w, r) })
+
+	// Backup and restore
+	mux.HandleFunc("/api/backup", func(w http.ResponseWriter, r *http.Request) { backuphandlers.HandleBackup(h, w, r) })
+	mux.HandleFunc("/api/backup/restore", func(w http.ResponseWriter, r *http.Request) { backuphandlers.HandleRestore(h, w, r) })
+	mux.HandleFunc("/api/backup/list", func(w http.ResponseWriter, r *http.Request) { backuphandlers.HandleListBackups(h, w, r) })
+	mux.HandleFunc("/api/backup/run", func(w http.ResponseWriter, r *http.Request) { backuphandlers.HandleRunBackup(h, w, r) })
+	mux.HandleFunc("/api/backup/secrets", func(w http.ResponseWriter, r *http.Request) { backuphandlers.HandleRequiredSecrets(h, w, r) })
+
+	// Database maintenance and read-only queries
+	mux.HandleFunc("/api/db/maintenance", func(w http.ResponseWriter, r *http.Request) { backuphandlers.HandleMaintenance(h, w, r) })
+	mux.HandleFunc("/api/sql", func(w http.ResponseWriter, r *http.Request) { backuphandlers.HandleSQL(h, w, r) })
+
+	// Full export and import
+	mux.HandleFunc("/api/export/full", func(w http.ResponseWriter, r *http.Request) { backuphandlers.HandleFullExport(h, w, r) })
+	mux.HandleFunc("/api/import/full", func(w http.ResponseWriter, r *http.Request) { backuphandlers.HandleFullImport(h, w, r) })
 }
@@ -0,0 +1,440 @@
+// Package schedule parses refresh schedules: cron expressions and time windows
+// that say when feeds may be refreshed.
+//
+// A schedule is made of clauses separated by semicolons. Each clause is one of
+//
+//   - a 5-field cron expression ("minute hour day-of-month month day-of-week"),
+//     or one of @hourly, @daily and @weekly, giving the times to refresh at,
+//     e.g. "0 7 * * *" for once daily at 07:00
+//   - a time window "[days] [HH:MM-HH:MM]" the refreshes are limited to,
+//     e.g. "mon-fri 09:00-18:00" for work hours only
+//   - a window followed by "off", during which nothing is refreshed,
+//     e.g. "weekends off" or "22:00-06:00 off"
+//
+// Days are day names or ranges of them ("mon", "mon-fri", "sat,sun"), or one of
+// "daily", "weekdays" and "weekends". Windows may wrap around midnight.
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchLimit is how far ahead the next matching time is looked for
+const searchLimit = 366 * 24 * time.Hour
+
+// Spec is a parsed refresh schedule
+type Spec struct {
+	crons   []cron
+	windows []window
+	off     []window
+	raw     string
+}
+
+// Parse parses a schedule
+func Parse(s string) (*Spec, error) {
+	spec := &Spec{raw: strings.TrimSpace(s)}
+	for _, clause := range strings.Split(s, ";") {
+		clause = strings.ToLower(strings.TrimSpace(clause))
+		if clause == "" {
+			continue
+		}
+		if isCron(clause) {
+			c, err := parseCron(clause)
+			if err != nil {
+				return nil, fmt.Errorf("cron expression %q: %w", clause, err)
+			}
+			spec.crons = append(spec.crons, c)
+			continue
+		}
+
+		fields := strings.Fields(clause)
+		off := fields[len(fields)-1] == "off"
+		if off {
+			fields = fields[:len(fields)-1]
+		}
+		w, err := parseWindow(fields)
+		if err != nil {
+			return nil, fmt.Errorf("time window %q: %w", clause, err)
+		}
+		if off {
+			spec.off = append(spec.off, w)
+		} else {
+			spec.windows = append(spec.windows, w)
+		}
+	}
+	if len(spec.crons) == 0 && len(spec.windows) == 0 && len(spec.off) == 0 {
+		return nil, fmt.Errorf("empty schedule")
+	}
+	return spec, nil
+}
+
+// String returns the schedule as it was written
+func (s *Spec) String() string {
+	return s.raw
+}
+
+// HasCron reports whether the schedule gives the times to refresh at
+func (s *Spec) HasCron() bool {
+	return len(s.crons) > 0
+}
+
+// HasWindows reports whether the schedule limits refreshes to time windows
+func (s *Spec) HasWindows() bool {
+	return len(s.windows) > 0
+}
+
+// InWindow reports whether t falls in one of the schedule's (not "off") windows
+func (s *Spec) InWindow(t time.Time) bool {
+	for _, w := range s.windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed reports whether refreshing at t is allowed: t falls in one of the
+// schedule's windows, if it has any, and in none of its "off" windows
+func (s *Spec) Allowed(t time.Time) bool {
+	if len(s.windows) > 0 && !s.InWindow(t) {
+		return false
+	}
+	for _, w := range s.off {
+		if w.contains(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// NextAllowed returns the first minute from t on at which refreshing is
+// allowed, zero if there is none within a year
+func (s *Spec) NextAllowed(t time.Time) time.Time {
+	if s.Allowed(t) {
+		return t
+	}
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for end := t.Add(searchLimit); t.Before(end); t = t.Add(time.Minute) {
+		if s.Allowed(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// NextOutside returns the first minute from t on that falls in none of the
+// schedule's (not "off") windows, zero if there is none within a year
+func (s *Spec) NextOutside(t time.Time) time.Time {
+	if !s.InWindow(t) {
+		return t
+	}
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for end := t.Add(searchLimit); t.Before(end); t = t.Add(time.Minute) {
+		if !s.InWindow(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Next returns the first time after the given one that one of the schedule's
+// cron expressions matches and refreshing is allowed, zero if the schedule has
+// no cron expression or nothing matches within a year
+func (s *Spec) Next(after time.Time) time.Time {
+	if len(s.crons) == 0 {
+		return time.Time{}
+	}
+	t := after
+	end := after.Add(searchLimit)
+	for {
+		var next time.Time
+		for _, c := range s.crons {
+			if n := c.next(t, end); !n.IsZero() && (next.IsZero() || n.Before(next)) {
+				next = n
+			}
+		}
+		if next.IsZero() || s.Allowed(next) {
+			return next
+		}
+		t = next
+	}
+}
+
+// cron is a parsed cron expression, each field a bit set of matching values
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+var cronMacros = map[string]string{
+	"@hourly": "0 * * * *",
+	"@daily":  "0 0 * * *",
+	"@weekly": "0 0 * * 0",
+}
+
+// isCron tells cron expressions from time windows
+func isCron(clause string) bool {
+	if strings.HasPrefix(clause, "@") {
+		return true
+	}
+	fields := strings.Fields(clause)
+	return len(fields) == 5 && !strings.Contains(clause, ":")
+}
+
+func parseCron(expr string) (cron, error) {
+	if strings.HasPrefix(expr, "@") {
+		macro, ok := cronMacros[expr]
+		if !ok {
+			return cron{}, fmt.Errorf("unknown macro")
+		}
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return cron{}, fmt.Errorf("want 5 fields, got %d", len(fields))
+	}
+	var c cron
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return cron{}, fmt.Errorf("minute: %w", err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return cron{}, fmt.Errorf("hour: %w", err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return cron{}, fmt.Errorf("day of month: %w", err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12, monthNames); err != nil {
+		return cron{}, fmt.Errorf("month: %w", err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7, dayNames); err != nil {
+		return cron{}, fmt.Errorf("day of week: %w", err)
+	}
+	// 7 is Sunday too
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = fields[2] == "*"
+	c.dowAny = fields[4] == "*"
+	return c, nil
+}
+
+// parseCronField parses a comma-separated list of values, ranges and steps
+// ("*", "5", "1-5", "*/15", "9-17/2") into a bit set
+func parseCronField(field string, min, max int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if base, s, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", s)
+			}
+			part, step = base, n
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			from, to, isRange := strings.Cut(part, "-")
+			var err error
+			if lo, err = cronValue(from, min, max, names); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = cronValue(to, min, max, names); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				hi = max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func cronValue(s string, min, max int, names map[string]int) (int, error) {
+	if v, ok := names[s]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+// matchesDay reports whether the expression matches t's day. As in cron, a day
+// matches either day field when both are restricted.
+func (c cron) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// next returns the first minute after the given time the expression matches,
+// zero if there is none before end
+func (c cron) next(after, end time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	for t.Before(end) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			// Truncate works in UTC, which is off by the minutes of half-hour zones
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// window is a daily time window on some days of the week
+type window struct {
+	days       uint8 // Bit set of weekdays, Sunday first
+	start, end int   // Minutes since midnight, start == end for the whole day
+}
+
+var dayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+const allDays = 0x7f
+
+func parseWindow(fields []string) (window, error) {
+	w := window{days: allDays}
+	switch len(fields) {
+	case 1:
+		if strings.Contains(fields[0], ":") {
+			return w, w.parseTimes(fields[0])
+		}
+		return w, w.parseDays(fields[0])
+	case 2:
+		if err := w.parseDays(fields[0]); err != nil {
+			return w, err
+		}
+		return w, w.parseTimes(fields[1])
+	}
+	return w, fmt.Errorf("want [days] [HH:MM-HH:MM] [off]")
+}
+
+func (w *window) parseDays(s string) error {
+	switch s {
+	case "daily", "*":
+		w.days = allDays
+		return nil
+	case "weekdays":
+		w.days = 0x3e
+		return nil
+	case "weekends":
+		w.days = 0x41
+		return nil
+	}
+
+	w.days = 0
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		lo, ok := dayNames[dayPrefix(from)]
+		if !ok {
+			return fmt.Errorf("invalid day %q", from)
+		}
+		hi := lo
+		if isRange {
+			if hi, ok = dayNames[dayPrefix(to)]; !ok {
+				return fmt.Errorf("invalid day %q", to)
+			}
+		}
+		// Ranges may wrap around the end of the week, e.g. "fri-mon"
+		for d := lo; ; d = (d + 1) % 7 {
+			w.days |= 1 << uint(d)
+			if d == hi {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// dayPrefix shortens a day name to its first three letters
+func dayPrefix(s string) string {
+	if len(s) > 3 {
+		return s[:3]
+	}
+	return s
+}
+
+func (w *window) parseTimes(s string) error {
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return fmt.Errorf("invalid time range %q", s)
+	}
+	var err error
+	if w.start, err = parseClock(from); err != nil {
+		return err
+	}
+	if w.end, err = parseClock(to); err != nil {
+		return err
+	}
+	return nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight; "24:00" is midnight
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		if s == "24:00" {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// contains reports whether t falls in the window. A window wrapping around
+// midnight belongs to the day it starts on.
+func (w window) contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+	day := int(t.Weekday())
+	onDay := func(d int) bool { return w.days&(1<<uint(d)) != 0 }
+
+	switch {
+	case w.start == w.end:
+		return onDay(day)
+	case w.start < w.end:
+		return onDay(day) && minute >= w.start && minute < w.end
+	case minute >= w.start:
+		return onDay(day)
+	case minute < w.end:
+		return onDay((day + 6) % 7)
+	}
+	return false
+}
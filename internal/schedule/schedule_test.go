@@ -0,0 +1,125 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+// 2026-03-02 is a Monday
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"",
+		" ; ",
+		"60 * * * *",
+		"* * * *  * *",
+		"@yearly",
+		"mon-fri 9-17",
+		"someday 09:00-10:00",
+		"off",
+		"*/0 * * * *",
+	} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestSpec_Windows(t *testing.T) {
+	tests := []struct {
+		spec string
+		t    time.Time
+		want bool
+	}{
+		{"mon-fri 09:00-18:00", at(2, 9, 0), true},
+		{"mon-fri 09:00-18:00", at(2, 18, 0), false},
+		{"mon-fri 09:00-18:00", at(7, 12, 0), false}, // Saturday
+		{"weekends off", at(7, 12, 0), false},
+		{"weekends off", at(6, 23, 59), true}, // Friday
+		{"22:00-06:00 off", at(3, 23, 0), false},
+		{"22:00-06:00 off", at(3, 5, 59), false},
+		{"22:00-06:00 off", at(3, 6, 0), true},
+		{"fri 22:00-02:00", at(7, 1, 0), true}, // Early Saturday belongs to Friday's window
+		{"fri 22:00-02:00", at(8, 1, 0), false},
+		{"weekdays; wed off", at(4, 12, 0), false},
+		{"Monday,Tuesday", at(3, 12, 0), true},
+	}
+	for _, tt := range tests {
+		spec, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.spec, err)
+		}
+		if got := spec.Allowed(tt.t); got != tt.want {
+			t.Errorf("%q allowed at %v = %v, want %v", tt.spec, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestSpec_NextAllowed(t *testing.T) {
+	spec, err := Parse("mon-fri 09:00-18:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := spec.NextAllowed(at(6, 18, 30)); !got.Equal(at(9, 9, 0)) {
+		t.Errorf("Next allowed after Friday evening = %v, want Monday 09:00", got)
+	}
+	if got := spec.NextAllowed(at(2, 10, 15)); !got.Equal(at(2, 10, 15)) {
+		t.Errorf("Next allowed inside the window = %v, want the same time", got)
+	}
+
+	quiet, err := Parse("23:00-07:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := quiet.NextOutside(at(2, 23, 30)); !got.Equal(at(3, 7, 0)) {
+		t.Errorf("Next outside quiet hours = %v, want 07:00", got)
+	}
+}
+
+func TestSpec_Next(t *testing.T) {
+	tests := []struct {
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{"0 7 * * *", at(2, 7, 0), at(3, 7, 0)},
+		{"0 7 * * *", at(2, 6, 59), at(2, 7, 0)},
+		{"@hourly", at(2, 7, 30), at(2, 8, 0)},
+		{"*/15 9-17 * * mon-fri", at(6, 17, 50), at(9, 9, 0)},
+		{"30 8 1 * *", at(2, 0, 0), time.Date(2026, 4, 1, 8, 30, 0, 0, time.UTC)},
+		{"0 12 * * 7", at(2, 0, 0), at(8, 12, 0)}, // 7 is Sunday
+		{"0 * * * *; 00:00-08:00 off", at(2, 23, 30), at(3, 8, 0)},
+		{"0 9 * * *; weekends off", at(6, 9, 0), at(9, 9, 0)},
+		// Both day fields restricted: either matches
+		{"0 0 15 * mon", at(3, 0, 0), at(9, 0, 0)},
+	}
+	for _, tt := range tests {
+		spec, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.spec, err)
+		}
+		if got := spec.Next(tt.after); !got.Equal(tt.want) {
+			t.Errorf("%q next after %v = %v, want %v", tt.spec, tt.after, got, tt.want)
+		}
+	}
+
+	// Zones off UTC by a fraction of an hour step by their own hours
+	for _, loc := range []*time.Location{
+		time.FixedZone("Asia/Kolkata", 5*3600+30*60),
+		time.FixedZone("Asia/Kathmandu", 5*3600+45*60),
+	} {
+		spec, _ := Parse("0 7 * * *")
+		after := time.Date(2026, 3, 2, 6, 10, 0, 0, loc)
+		if got, want := spec.Next(after), time.Date(2026, 3, 2, 7, 0, 0, 0, loc); !got.Equal(want) {
+			t.Errorf("next in %s after %v = %v, want %v", loc, after, got, want)
+		}
+	}
+
+	spec, _ := Parse("mon-fri 09:00-18:00")
+	if got := spec.Next(at(2, 0, 0)); !got.IsZero() {
+		t.Errorf("Next of a schedule without cron = %v, want zero", got)
+	}
+}
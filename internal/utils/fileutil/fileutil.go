@@ -163,6 +163,20 @@ func GetScriptsDir() (string, error) {
 	return scriptsDir, nil
 }
 
+// GetBackupsDir returns the path to the default directory of scheduled backups.
+func GetBackupsDir() (string, error) {
+	dataDir, err := GetDataDir()
+	if err != nil {
+		return "", err
+	}
+	backupsDir := filepath.Join(dataDir, "backups")
+	err = os.MkdirAll(backupsDir, 0755)
+	if err != nil {
+		return "", err
+	}
+	return backupsDir, nil
+}
+
 // ValidateScriptPath validates that a script path is within the scripts directory.
 func ValidateScriptPath(scriptPath string) (string, error) {
 	scriptsDir, err := GetScriptsDir()
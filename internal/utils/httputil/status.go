@@ -0,0 +1,59 @@
+package httputil
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusError reports an HTTP response with an unexpected status.
+type StatusError struct {
+	StatusCode int
+	Status     string
+	RetryAfter time.Duration // Delay asked for by the Retry-After header, 0 without one
+}
+
+// NewStatusError creates a StatusError from a response, reading its Retry-After header.
+func NewStatusError(resp *http.Response) *StatusError {
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Status)
+}
+
+// IsThrottled reports whether the server asked the client to slow down.
+func (e *StatusError) IsThrottled() bool {
+	return IsThrottleStatus(e.StatusCode)
+}
+
+// IsThrottleStatus reports whether a status code asks the client to slow down
+// (429 Too Many Requests or 503 Service Unavailable).
+func IsThrottleStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable
+}
+
+// ParseRetryAfter parses a Retry-After header, given either in seconds or as
+// an HTTP date. It returns 0 for a missing, invalid or past value.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
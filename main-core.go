@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"MrRSS/internal/ai"
+	"MrRSS/internal/backup"
 	"MrRSS/internal/database"
 	"MrRSS/internal/feed"
 	handlers "MrRSS/internal/handlers/core"
@@ -126,6 +127,13 @@ func main() {
 	}
 	debugLog("Database path: %s", dbPath)
 
+	// Swap in a database staged by a restore before it is opened
+	if restored, err := backup.ApplyPendingRestore(dbPath); err != nil {
+		log.Printf("Error applying restored database: %v", err)
+	} else if restored {
+		log.Println("Applied restored database from backup")
+	}
+
 	// Initialize database
 	log.Println("Initializing Database...")
 	db, err := database.NewDB(dbPath)
@@ -187,6 +195,12 @@ func main() {
 	log.Println("Starting background scheduler...")
 	go h.StartBackgroundScheduler(bgCtx)
 
+	// Keep IMAP IDLE connections open so newsletters arrive within seconds
+	go h.StartEmailIdle(bgCtx)
+
+	// Receive newsletters sent to generated feed addresses, if enabled
+	go h.StartEmailInbound(bgCtx)
+
 	// Start Network Speed Detection (optional but good to have)
 	go func() {
 		log.Println("Detecting network speed...")
@@ -21,6 +21,7 @@ import (
 	"github.com/wailsapp/wails/v3/pkg/events"
 
 	"MrRSS/internal/ai"
+	"MrRSS/internal/backup"
 	"MrRSS/internal/database"
 	"MrRSS/internal/feed"
 	handlers "MrRSS/internal/handlers/core"
@@ -136,6 +137,13 @@ func main() {
 	}
 	debugLog("Database path: %s", dbPath)
 
+	// Swap in a database staged by a restore before it is opened
+	if restored, err := backup.ApplyPendingRestore(dbPath); err != nil {
+		log.Printf("Error applying restored database: %v", err)
+	} else if restored {
+		log.Println("Applied restored database from backup")
+	}
+
 	// Initialize database
 	log.Println("Initializing Database...")
 	db, err := database.NewDB(dbPath)